@@ -0,0 +1,300 @@
+// Package main provides a load test harness for the webpage analyzer API.
+//
+// It starts a built-in mock target server that serves synthetic pages, drives
+// the analyze endpoint with a configurable concurrency and URL mix, and reports
+// throughput and latency percentiles. By default the analyzer itself is started
+// in-process; pass -api to point the harness at an already running instance.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"webpage-analyzer/internal/analyzer"
+	httphandler "webpage-analyzer/internal/http"
+)
+
+// defaultMix is the page mix used when -mix is not given.
+const defaultMix = "small=5,large=2,login=2,notfound=1"
+
+// mixEntry is one weighted page kind in the URL mix.
+type mixEntry struct {
+	page   string
+	weight int
+}
+
+// result is the outcome of a single analyze request.
+type result struct {
+	latency time.Duration
+	status  int
+	err     error
+}
+
+// report summarizes a load test run.
+type report struct {
+	Requests   int
+	Errors     int
+	StatusCode map[int]int
+	Elapsed    time.Duration
+	Throughput float64
+	P50        time.Duration
+	P90        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+}
+
+func main() {
+	apiURL := flag.String("api", "", "Base URL of a running analyzer API (default: start one in-process)")
+	concurrency := flag.Int("concurrency", 10, "Number of concurrent clients")
+	requests := flag.Int("requests", 500, "Total number of analyze requests to send")
+	mix := flag.String("mix", defaultMix, "Weighted page mix, e.g. small=5,large=2,login=2,notfound=1")
+	flag.Parse()
+
+	// Keep the analyzer's logging (including expected 404s from the mix) out of the report.
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	entries, err := parseMix(*mix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -mix: %v\n", err)
+		os.Exit(2)
+	}
+	if err := validateLoad(*concurrency, *requests); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+
+	target := httptest.NewServer(newTargetHandler())
+	defer target.Close()
+
+	if *apiURL == "" {
+		api := httptest.NewServer(newAPIHandler())
+		defer api.Close()
+		*apiURL = api.URL
+	}
+
+	fmt.Printf("Load testing %s with %d requests at concurrency %d (mix: %s)\n", *apiURL, *requests, *concurrency, *mix)
+
+	r := run(*apiURL, target.URL, entries, *concurrency, *requests)
+	printReport(os.Stdout, r)
+}
+
+// newAPIHandler builds an in-process analyzer API.
+func newAPIHandler() http.Handler {
+	handler := httphandler.NewHandler(analyzer.NewService())
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/analyze", handler.AnalyzeWebpage)
+	return mux
+}
+
+// newTargetHandler builds the mock target site that the analyzer fetches.
+func newTargetHandler() http.Handler {
+	small := `<!DOCTYPE html><html><head><title>Small</title></head><body>
+<h1>Small page</h1><a href="/a">a</a><a href="https://example.org">ext</a></body></html>`
+
+	var large strings.Builder
+	large.WriteString(`<!DOCTYPE html><html><head><title>Large</title></head><body>`)
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&large, `<h2>Section %d</h2><p>Lorem ipsum dolor sit amet.</p><a href="/p/%d">internal</a><a href="https://ext%d.example.org/">external</a>`, i, i, i%20)
+	}
+	large.WriteString(`</body></html>`)
+
+	login := `<!DOCTYPE html><html><head><title>Login</title></head><body>
+<form action="/login"><input type="text" name="username"><input type="password" name="password">
+<button type="submit">Sign in</button></form></body></html>`
+
+	pages := map[string]string{
+		"/small": small,
+		"/large": large.String(),
+		"/login": login,
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(w, body)
+	})
+}
+
+// parseMix parses a "page=weight,..." specification.
+func parseMix(spec string) ([]mixEntry, error) {
+	var entries []mixEntry
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		page, weightStr, found := strings.Cut(part, "=")
+		weight := 1
+		if found {
+			w, err := strconv.Atoi(weightStr)
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("bad weight %q for page %q", weightStr, page)
+			}
+			weight = w
+		}
+		if weight > 0 {
+			entries = append(entries, mixEntry{page: page, weight: weight})
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("mix %q selects no pages", spec)
+	}
+	return entries, nil
+}
+
+// validateLoad checks that the run has at least one client and one request;
+// without a client, sending the first request would block forever.
+func validateLoad(concurrency, requests int) error {
+	if concurrency < 1 {
+		return fmt.Errorf("invalid -concurrency %d: must be at least 1", concurrency)
+	}
+	if requests < 1 {
+		return fmt.Errorf("invalid -requests %d: must be at least 1", requests)
+	}
+	return nil
+}
+
+// pick selects a page from the mix proportionally to its weight.
+func pick(entries []mixEntry, rng *rand.Rand) string {
+	total := 0
+	for _, e := range entries {
+		total += e.weight
+	}
+	n := rng.Intn(total)
+	for _, e := range entries {
+		if n < e.weight {
+			return e.page
+		}
+		n -= e.weight
+	}
+	return entries[len(entries)-1].page
+}
+
+// run drives the analyze endpoint and collects a report.
+func run(apiURL, targetURL string, entries []mixEntry, concurrency, requests int) report {
+	client := &http.Client{Timeout: 60 * time.Second}
+	jobs := make(chan string)
+	results := make(chan result, requests)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for page := range jobs {
+				results <- analyzeOnce(client, apiURL, targetURL+"/"+page)
+			}
+		}()
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	start := time.Now()
+	for i := 0; i < requests; i++ {
+		jobs <- pick(entries, rng)
+	}
+	close(jobs)
+	wg.Wait()
+	elapsed := time.Since(start)
+	close(results)
+
+	collected := make([]result, 0, requests)
+	for r := range results {
+		collected = append(collected, r)
+	}
+	return summarize(collected, elapsed)
+}
+
+// analyzeOnce sends a single analyze request and times it.
+func analyzeOnce(client *http.Client, apiURL, pageURL string) result {
+	body, _ := json.Marshal(analyzer.AnalysisRequest{URL: pageURL})
+
+	start := time.Now()
+	resp, err := client.Post(apiURL+"/api/analyze", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return result{latency: time.Since(start), err: err}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return result{latency: time.Since(start), status: resp.StatusCode}
+}
+
+// summarize aggregates individual results into a report.
+func summarize(results []result, elapsed time.Duration) report {
+	r := report{
+		Requests:   len(results),
+		StatusCode: make(map[int]int),
+		Elapsed:    elapsed,
+	}
+
+	latencies := make([]time.Duration, 0, len(results))
+	for _, res := range results {
+		if res.err != nil {
+			r.Errors++
+			continue
+		}
+		r.StatusCode[res.status]++
+		latencies = append(latencies, res.latency)
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	r.P50 = percentile(latencies, 50)
+	r.P90 = percentile(latencies, 90)
+	r.P99 = percentile(latencies, 99)
+	if len(latencies) > 0 {
+		r.Max = latencies[len(latencies)-1]
+	}
+	if elapsed > 0 {
+		r.Throughput = float64(len(results)) / elapsed.Seconds()
+	}
+	return r
+}
+
+// percentile returns the p-th percentile of sorted latencies (nearest-rank).
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// printReport writes a human-readable report.
+func printReport(w io.Writer, r report) {
+	fmt.Fprintf(w, "\nRequests:    %d (%d transport errors)\n", r.Requests, r.Errors)
+	fmt.Fprintf(w, "Elapsed:     %s\n", r.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "Throughput:  %.1f req/s\n", r.Throughput)
+	fmt.Fprintf(w, "Latency p50: %s\n", r.P50.Round(time.Microsecond))
+	fmt.Fprintf(w, "Latency p90: %s\n", r.P90.Round(time.Microsecond))
+	fmt.Fprintf(w, "Latency p99: %s\n", r.P99.Round(time.Microsecond))
+	fmt.Fprintf(w, "Latency max: %s\n", r.Max.Round(time.Microsecond))
+
+	codes := make([]int, 0, len(r.StatusCode))
+	for code := range r.StatusCode {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		fmt.Fprintf(w, "HTTP %d:    %d\n", code, r.StatusCode[code])
+	}
+}
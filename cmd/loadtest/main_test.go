@@ -0,0 +1,65 @@
+package main
+
+import (
+	"math/rand"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMix(t *testing.T) {
+	entries, err := parseMix("small=3, large=1,login")
+	require.NoError(t, err)
+	assert.Equal(t, []mixEntry{{"small", 3}, {"large", 1}, {"login", 1}}, entries)
+
+	_, err = parseMix("small=x")
+	assert.Error(t, err, "Non-numeric weights should be rejected")
+
+	_, err = parseMix("small=0")
+	assert.Error(t, err, "A mix with no positive weights should be rejected")
+}
+
+func TestValidateLoad(t *testing.T) {
+	assert.NoError(t, validateLoad(1, 1))
+	assert.Error(t, validateLoad(0, 10), "No clients would deadlock the run")
+	assert.Error(t, validateLoad(10, 0))
+	assert.Error(t, validateLoad(-1, 10))
+}
+
+func TestPickHonoursWeights(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	entries := []mixEntry{{"only", 1}, {"never", 0}}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "only", pick(entries, rng))
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	assert.Equal(t, 50*time.Millisecond, percentile(sorted, 50))
+	assert.Equal(t, 99*time.Millisecond, percentile(sorted, 99))
+	assert.Equal(t, time.Duration(0), percentile(nil, 50))
+}
+
+func TestRunAgainstInProcessAPI(t *testing.T) {
+	target := httptest.NewServer(newTargetHandler())
+	defer target.Close()
+	api := httptest.NewServer(newAPIHandler())
+	defer api.Close()
+
+	entries, err := parseMix("small=1,notfound=1")
+	require.NoError(t, err)
+
+	r := run(api.URL, target.URL, entries, 2, 10)
+
+	assert.Equal(t, 10, r.Requests)
+	assert.Equal(t, 0, r.Errors, "No transport errors expected")
+	assert.Equal(t, 10, r.StatusCode[200]+r.StatusCode[422], "Every request should be answered")
+}
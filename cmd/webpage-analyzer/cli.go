@@ -0,0 +1,338 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+// Output formats supported by the CLI subcommands.
+const (
+	outputJSON  = "json"
+	outputTable = "table"
+)
+
+// newRootCommand builds the CLI. Without a subcommand it runs the HTTP server.
+func newRootCommand() *cobra.Command {
+	cfg := defaultServerConfig()
+
+	root := &cobra.Command{
+		Use:          "webpage-analyzer",
+		Short:        "Analyze webpages over HTTP or from the command line",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			setupLogger()
+			if cfg.adminToken == "" {
+				cfg.adminToken = os.Getenv("WEBPAGE_ANALYZER_ADMIN_TOKEN")
+			}
+
+			svcs, err := setupServices(cfg)
+			if err != nil {
+				slog.Error("Failed to set up services", "error", err)
+				return err
+			}
+			defer svcs.Close()
+
+			if cfg.grpcPort != "" {
+				lis, err := net.Listen("tcp", ":"+cfg.grpcPort)
+				if err != nil {
+					slog.Error("Failed to listen for gRPC", "port", cfg.grpcPort, "error", err)
+					return err
+				}
+				grpcServer := setupGRPCServer(svcs)
+				defer grpcServer.GracefulStop()
+				go func() {
+					slog.Info("Starting gRPC server", "port", cfg.grpcPort)
+					if err := grpcServer.Serve(lis); err != nil {
+						slog.Error("gRPC server stopped", "error", err)
+					}
+				}()
+			}
+
+			server := setupServer(cfg, svcs)
+			if err := server.ListenAndServe(); err != nil {
+				slog.Error("Server failed to start", "error", err)
+				return err
+			}
+			return nil
+		},
+	}
+
+	flags := root.Flags()
+	flags.StringVar(&cfg.port, "port", cfg.port, "Port to run the server on")
+	flags.Float64Var(&cfg.logSampleRate, "log-sample-rate", cfg.logSampleRate, "Fraction of successful requests to access-log (errors are always logged)")
+	flags.StringSliceVar(&cfg.proxies, "trusted-proxies", cfg.proxies, "IP addresses or CIDR networks of reverse proxies whose X-Forwarded-For header gives the client IP in access logs, comma-separated (default: none, the peer address is logged)")
+	flags.StringVar(&cfg.storeDriver, "store", cfg.storeDriver, "Analysis history store: sqlite, postgres, or none")
+	flags.StringVar(&cfg.storeDSN, "store-dsn", cfg.storeDSN, "SQLite file path or Postgres connection string")
+	flags.StringVar(&cfg.jobsDSN, "jobs-dsn", cfg.jobsDSN, "SQLite file path of the background job queue (with --store=postgres jobs are kept in the store database)")
+	flags.BoolVar(&cfg.apiKeys, "api-keys", cfg.apiKeys, "Require an API key, with its daily quota and concurrency limit, on the endpoints that analyze pages (needs --admin-token to manage keys)")
+	flags.StringVar(&cfg.apiKeysDSN, "api-keys-dsn", cfg.apiKeysDSN, "SQLite file path of the API keys and their usage (with --store=postgres they are kept in the store database)")
+	flags.StringVar(&cfg.grpcPort, "grpc-port", cfg.grpcPort, "Port to run the gRPC server on (empty to disable)")
+	flags.IntVar(&cfg.minWorkers, "min-workers", cfg.minWorkers, "Minimum number of analysis workers kept alive")
+	flags.IntVar(&cfg.maxWorkers, "max-workers", cfg.maxWorkers, "Maximum number of analysis workers under load")
+	flags.StringSliceVar(&cfg.disabledMods, "disable-modules", cfg.disabledMods, "Analysis modules to switch off, e.g. contacts for privacy-sensitive deployments")
+	flags.StringVar(&cfg.reputation, "reputation", cfg.reputation, "Threat feed for the opt-in reputation module: safebrowsing, urlhaus, blocklist or dnsbl (empty disables it)")
+	flags.StringVar(&cfg.reputationKey, "reputation-key", cfg.reputationKey, "API key for the safebrowsing and urlhaus feeds")
+	flags.StringVar(&cfg.reputationSrc, "reputation-source", cfg.reputationSrc, "Domain list file for the blocklist feed, or DNS zone for the dnsbl feed, e.g. dbl.spamhaus.org")
+	flags.StringVar(&cfg.geoIPDB, "geoip-db", cfg.geoIPDB, "CSV database (network,asn,as_org,country) used to locate server IPs")
+	flags.StringSliceVar(&cfg.protected, "protected-domains", cfg.protected, "Brand domains whose lookalikes and typosquats are flagged in page links, comma-separated (default: well-known brands)")
+	flags.DurationVar(&cfg.cacheTTL, "cache-ttl", cfg.cacheTTL, "How long analysis results are cached (0 disables the cache)")
+	flags.StringVar(&cfg.cachePath, "cache-path", cfg.cachePath, "File to keep the result cache in, so it survives restarts (default: in memory)")
+	flags.BoolVar(&cfg.stripTracking, "cache-strip-tracking", cfg.stripTracking, "Serve URLs that differ only in tracking parameters such as utm_source from one cache entry")
+	flags.StringVar(&cfg.trailingSlash, "cache-trailing-slash", cfg.trailingSlash, "Whether URLs that differ only in a trailing slash share a cache entry: keep (they do not) or strip (they do)")
+	flags.StringSliceVar(&cfg.warmURLs, "warm-urls", cfg.warmURLs, "Pages to analyze into the result cache at startup, comma-separated (at most 100)")
+	flags.IntVar(&cfg.maxAnalyses, "max-concurrent-analyses", cfg.maxAnalyses, "Analysis requests served at once; further requests queue, then get a 503 (0 disables the limit)")
+	flags.IntVar(&cfg.analysisQueue, "analysis-queue", cfg.analysisQueue, "Analysis requests that may wait for a free slot")
+	flags.DurationVar(&cfg.queueTimeout, "analysis-queue-timeout", cfg.queueTimeout, "Longest an analysis request waits for a free slot before a 503")
+	flags.DurationVar(&cfg.idempotency, "idempotency-window", cfg.idempotency, "How long responses to requests with an Idempotency-Key are replayed to retries (0 disables it)")
+	flags.Float64Var(&cfg.hostRate, "host-rate-limit", cfg.hostRate, "Most requests per second sent to any one target host (0 means no limit)")
+	flags.BoolVar(&cfg.crawlDelay, "respect-crawl-delay", cfg.crawlDelay, "Space requests to each host by the Crawl-delay in its robots.txt (capped at 10s)")
+	flags.IntVar(&cfg.breakerLimit, "breaker-threshold", cfg.breakerLimit, "Consecutive failures after which a target host is temporarily skipped (0 disables the circuit breaker)")
+	flags.DurationVar(&cfg.breakerPause, "breaker-cooldown", cfg.breakerPause, "How long a failing target host is skipped before it is tried again")
+	flags.IntVar(&cfg.dnsCacheSize, "dns-cache-size", cfg.dnsCacheSize, "Host names whose DNS answers are cached between requests (0 disables the DNS cache)")
+	flags.IntVar(&cfg.maxRedirects, "max-redirects", cfg.maxRedirects, "Redirects followed when fetching a page (0 means 10, -1 follows none)")
+	flags.BoolVar(&cfg.noDowngrade, "forbid-downgrade-redirects", cfg.noDowngrade, "Refuse redirects from https to http")
+	flags.StringVar(&cfg.ipFamily, "ip-family", cfg.ipFamily, "IP versions used to fetch pages: prefer-ipv4, prefer-ipv6, ipv4 or ipv6 (default: as resolved)")
+	flags.StringVar(&cfg.localAddr, "local-addr", cfg.localAddr, "Local IP address to fetch pages from, on hosts with several interfaces")
+	flags.BoolVar(&cfg.http3, "http3", cfg.http3, "Experimental: fetch pages over HTTP/3 from hosts that advertise it")
+	flags.DurationVar(&cfg.retryWait, "retry-after-max-wait", cfg.retryWait, "Longest Retry-After from a 429 or 503 page that is waited out before fetching it again (0 never waits)")
+	flags.IntVar(&cfg.transport.MaxIdleConns, "max-idle-conns", cfg.transport.MaxIdleConns, "Idle outbound connections kept for reuse across all hosts (0 means no limit)")
+	flags.IntVar(&cfg.transport.MaxIdleConnsPerHost, "max-idle-conns-per-host", cfg.transport.MaxIdleConnsPerHost, "Idle outbound connections kept for reuse per host (0 means 2)")
+	flags.IntVar(&cfg.transport.MaxConnsPerHost, "max-conns-per-host", cfg.transport.MaxConnsPerHost, "Outbound connections open to any one host, busy or idle (0 means no limit)")
+	flags.DurationVar(&cfg.transport.IdleConnTimeout, "idle-conn-timeout", cfg.transport.IdleConnTimeout, "How long an unused outbound connection is kept open (0 keeps it until the host closes it)")
+	flags.DurationVar(&cfg.transport.TLSHandshakeTimeout, "tls-handshake-timeout", cfg.transport.TLSHandshakeTimeout, "Limit on the TLS handshake of an outbound connection (0 means no limit)")
+	flags.DurationVar(&cfg.transport.DialTimeout, "dial-timeout", cfg.transport.DialTimeout, "Limit on opening an outbound TCP connection (0 leaves it to the OS)")
+	flags.StringVar(&cfg.adminToken, "admin-token", cfg.adminToken, "Bearer token for the admin endpoints, such as /api/admin/loglevel (default: $WEBPAGE_ANALYZER_ADMIN_TOKEN; empty disables them)")
+	flags.StringVar(&cfg.assetsDir, "assets-dir", cfg.assetsDir, "For development: serve the frontend from the source tree at this path, e.g. \".\", instead of the copy embedded in the binary")
+	flags.StringVar(&cfg.canaryURL, "health-canary-url", cfg.canaryURL, "URL the deep health check fetches to test outbound DNS and HTTP (empty skips those checks)")
+
+	root.AddCommand(newAnalyzeCommand(), newCrawlCommand(), newAnalyzeFilesCommand())
+	return root
+}
+
+// newAnalyzeCommand builds the "analyze <url>" subcommand.
+func newAnalyzeCommand() *cobra.Command {
+	var (
+		output     string
+		modules    []string
+		probeLinks bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "analyze <url>",
+		Short: "Analyze a single webpage and print the result",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOutput(output); err != nil {
+				return err
+			}
+			setupCLILogger()
+
+			req := analyzer.AnalysisRequest{URL: args[0], Modules: modules}
+			if probeLinks {
+				req.Options = map[string]analyzer.ModuleOptions{analyzer.ModuleLinks: {"probe": true}}
+			}
+
+			analysis, err := analyzer.NewService().AnalyzeWebpage(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+
+			if output == outputJSON {
+				return writeJSON(cmd.OutOrStdout(), analysis)
+			}
+			return writeAnalysisTable(cmd.OutOrStdout(), analysis)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", outputTable, "Output format: table or json")
+	cmd.Flags().StringSliceVar(&modules, "modules", nil, "Analysis modules to run, comma-separated (default: all)")
+	cmd.Flags().BoolVar(&probeLinks, "probe-links", false, "Request each link and report broken ones")
+	return cmd
+}
+
+// newCrawlCommand builds the "crawl <url>" subcommand.
+func newCrawlCommand() *cobra.Command {
+	var (
+		output string
+		req    analyzer.CrawlRequest
+	)
+
+	cmd := &cobra.Command{
+		Use:   "crawl <url>",
+		Short: "Analyze a page and the same-host pages it links to",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOutput(output); err != nil {
+				return err
+			}
+			setupCLILogger()
+
+			req.URL = args[0]
+			result, err := analyzer.NewService().CrawlSite(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+
+			if output == outputJSON {
+				return writeJSON(cmd.OutOrStdout(), result)
+			}
+			return writeCrawlTable(cmd.OutOrStdout(), result)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", outputTable, "Output format: table or json")
+	cmd.Flags().IntVar(&req.MaxDepth, "depth", analyzer.DefaultCrawlMaxDepth, "Maximum link depth to follow from the starting page")
+	cmd.Flags().IntVar(&req.MaxPages, "max-pages", analyzer.DefaultCrawlMaxPages, "Maximum number of pages to fetch")
+	return cmd
+}
+
+// newAnalyzeFilesCommand builds the "analyze-files <path>..." subcommand.
+func newAnalyzeFilesCommand() *cobra.Command {
+	var (
+		output string
+		req    analyzer.FilesRequest
+	)
+
+	cmd := &cobra.Command{
+		Use:   "analyze-files <path>...",
+		Short: "Analyze HTML files, directories of them or glob patterns without fetching anything",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOutput(output); err != nil {
+				return err
+			}
+			setupCLILogger()
+
+			req.Paths = args
+			result, err := analyzer.AnalyzeFiles(cmd.Context(), analyzer.NewService(), req)
+			if err != nil {
+				return err
+			}
+
+			if output == outputJSON {
+				err = writeJSON(cmd.OutOrStdout(), result)
+			} else {
+				err = writeFilesTable(cmd.OutOrStdout(), result)
+			}
+			if err != nil {
+				return err
+			}
+			if len(result.Errors) > 0 {
+				return fmt.Errorf("%d of %d files failed", len(result.Errors), len(result.Pages)+len(result.Errors))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", outputTable, "Output format: table or json")
+	cmd.Flags().StringVar(&req.BaseURL, "base-url", "", "URL the files are served from, e.g. https://example.com/ for a site's export directory")
+	cmd.Flags().StringSliceVar(&req.Modules, "modules", nil, "Analysis modules to run, comma-separated (default: all that work offline)")
+	return cmd
+}
+
+// setupCLILogger keeps analysis logging on stderr and limited to warnings so stdout stays scriptable.
+func setupCLILogger() {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelWarn,
+	})))
+}
+
+// validateOutput checks the --output flag value.
+func validateOutput(output string) error {
+	if output != outputJSON && output != outputTable {
+		return fmt.Errorf("unsupported output format %q (use %q or %q)", output, outputTable, outputJSON)
+	}
+	return nil
+}
+
+// writeJSON prints v as indented JSON.
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeAnalysisTable prints a single analysis as a two-column table.
+func writeAnalysisTable(w io.Writer, a *analyzer.WebpageAnalysis) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "URL\t%s\n", a.URL)
+	fmt.Fprintf(tw, "HTML version\t%s\n", a.HTMLVersion)
+	fmt.Fprintf(tw, "Page title\t%s\n", a.PageTitle)
+	fmt.Fprintf(tw, "Headings\t%s\n", formatHeadings(a.Headings))
+	fmt.Fprintf(tw, "Internal links\t%d\n", a.InternalLinks)
+	fmt.Fprintf(tw, "External links\t%d\n", a.ExternalLinks)
+	fmt.Fprintf(tw, "Inaccessible links\t%d\n", a.InaccessibleLinks)
+	fmt.Fprintf(tw, "Login form\t%t\n", a.HasLoginForm)
+	if a.LinkProbe != nil {
+		fmt.Fprintf(tw, "Broken links\t%d of %d checked\n", a.LinkProbe.Broken, a.LinkProbe.Checked)
+	}
+	fmt.Fprintf(tw, "Processing time\t%s\n", a.ProcessingTime)
+	return tw.Flush()
+}
+
+// writeCrawlTable prints one row per crawled page followed by any errors.
+func writeCrawlTable(w io.Writer, r *analyzer.CrawlResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	writePageRows(tw, r.Pages)
+	for _, e := range r.Errors {
+		fmt.Fprintf(tw, "%s\tERROR %d: %s\t\t\t\t\t\n", e.URL, e.StatusCode, e.ErrorMessage)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d pages analyzed, %d failed in %s\n", len(r.Pages), len(r.Errors), r.ProcessingTime)
+	return err
+}
+
+// writeFilesTable prints one row per analyzed file followed by any errors.
+func writeFilesTable(w io.Writer, r *analyzer.FilesResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	writePageRows(tw, r.Pages)
+	for _, e := range r.Errors {
+		fmt.Fprintf(tw, "%s\tERROR: %s\t\t\t\t\t\n", e.URL, e.ErrorMessage)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d files analyzed, %d failed in %s\n", len(r.Pages), len(r.Errors), r.ProcessingTime)
+	return err
+}
+
+// writePageRows prints a header and one row per analyzed page.
+func writePageRows(w io.Writer, pages []*analyzer.WebpageAnalysis) {
+	fmt.Fprintln(w, "URL\tTITLE\tHEADINGS\tINTERNAL\tEXTERNAL\tINACCESSIBLE\tLOGIN")
+	for _, a := range pages {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%t\n",
+			a.URL, a.PageTitle, formatHeadings(a.Headings),
+			a.InternalLinks, a.ExternalLinks, a.InaccessibleLinks, a.HasLoginForm)
+	}
+}
+
+// formatHeadings renders heading counts as "h1:1 h2:3".
+func formatHeadings(headings map[string]int) string {
+	levels := make([]string, 0, len(headings))
+	for level := range headings {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+
+	parts := make([]string, 0, len(levels))
+	for _, level := range levels {
+		parts = append(parts, fmt.Sprintf("%s:%d", level, headings[level]))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+// newTestSite serves a tiny two-page site.
+func newTestSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			_, _ = io.WriteString(w, `<html><head><title>Home</title></head><body><h1>Hi</h1><a href="/about">About</a></body></html>`)
+		case "/about":
+			_, _ = io.WriteString(w, `<html><head><title>About</title></head><body></body></html>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(site.Close)
+	return site
+}
+
+// runCLI executes the root command with args and returns its stdout.
+func runCLI(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	cmd := newRootCommand()
+	cmd.SetOut(&out)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestAnalyzeCommand_JSON(t *testing.T) {
+	site := newTestSite(t)
+
+	out, err := runCLI(t, "analyze", site.URL+"/", "--output", "json")
+	require.NoError(t, err)
+
+	var analysis analyzer.WebpageAnalysis
+	require.NoError(t, json.Unmarshal([]byte(out), &analysis), "JSON output should decode")
+	assert.Equal(t, "Home", analysis.PageTitle)
+	assert.Equal(t, 1, analysis.InternalLinks)
+}
+
+func TestAnalyzeCommand_Table(t *testing.T) {
+	site := newTestSite(t)
+
+	out, err := runCLI(t, "analyze", site.URL+"/")
+	require.NoError(t, err)
+
+	assert.Contains(t, out, "Page title")
+	assert.Contains(t, out, "Home")
+	assert.Contains(t, out, "h1:1")
+}
+
+func TestAnalyzeCommand_Errors(t *testing.T) {
+	_, err := runCLI(t, "analyze")
+	assert.Error(t, err, "A URL argument is required")
+
+	_, err = runCLI(t, "analyze", "https://example.com", "--output", "xml")
+	assert.Error(t, err, "Unknown output formats should be rejected")
+}
+
+func TestAnalyzeCommand_ModulesAndProbe(t *testing.T) {
+	site := newTestSite(t)
+
+	out, err := runCLI(t, "analyze", site.URL+"/", "--modules", "links", "--probe-links", "--output", "json")
+	require.NoError(t, err)
+
+	var analysis analyzer.WebpageAnalysis
+	require.NoError(t, json.Unmarshal([]byte(out), &analysis))
+	assert.Equal(t, []string{analyzer.ModuleLinks}, analysis.Modules, "--modules should limit the modules that run")
+	assert.Empty(t, analysis.PageTitle)
+	require.NotNil(t, analysis.LinkProbe, "--probe-links should enable the link probe")
+	assert.Equal(t, 1, analysis.LinkProbe.Checked)
+	assert.Zero(t, analysis.LinkProbe.Broken)
+}
+
+func TestCrawlCommand(t *testing.T) {
+	site := newTestSite(t)
+
+	out, err := runCLI(t, "crawl", site.URL+"/", "--depth", "1")
+	require.NoError(t, err)
+
+	assert.Contains(t, out, "Home")
+	assert.Contains(t, out, "About")
+	assert.Contains(t, out, "2 pages analyzed, 0 failed")
+}
+
+func TestAnalyzeFilesCommand(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte(`<title>Home</title><h1>Hi</h1>`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "about.html"), []byte(`<title>About</title>`), 0o644))
+
+	out, err := runCLI(t, "analyze-files", dir, "--base-url", "https://example.com/")
+	require.NoError(t, err)
+	assert.Contains(t, out, "https://example.com/index.html")
+	assert.Contains(t, out, "About")
+	assert.Contains(t, out, "2 files analyzed, 0 failed")
+
+	out, err = runCLI(t, "analyze-files", filepath.Join(dir, "index.*"), "-o", "json")
+	require.NoError(t, err)
+	var result analyzer.FilesResult
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	require.Len(t, result.Pages, 1)
+	assert.Equal(t, "Home", result.Pages[0].PageTitle)
+}
+
+func TestAnalyzeFilesCommand_FailedFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "empty.html"), nil, 0o644))
+
+	out, err := runCLI(t, "analyze-files", dir)
+	assert.EqualError(t, err, "1 of 1 files failed", "Failed files should make the command fail, e.g. in CI")
+	assert.Contains(t, out, "html is required")
+
+	_, err = runCLI(t, "analyze-files", filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestFormatHeadings(t *testing.T) {
+	assert.Equal(t, "h1:1 h2:3", formatHeadings(map[string]int{"h2": 3, "h1": 1}))
+	assert.Equal(t, "-", formatHeadings(nil))
+}
@@ -1,73 +1,657 @@
 // Package main provides the main entry point for the webpage analyzer service.
-//
-// @title Webpage Analyzer API
-// @version 1.0.0
-// @description API for analyzing webpages and extracting comprehensive metadata including HTML version,
-// page title, headings structure, link analysis, and login form detection. The service uses parallel
-// processing with a worker pool for efficient analysis and provides detailed error handling for various
-// HTTP status codes and network issues.
-//
-// @license.name MIT
-// @license.url https://opensource.org/licenses/MIT
-//
-// @host localhost:8990
-// @BasePath /
-//
-// @schemes http https
 package main
 
 import (
-	"flag"
+	"context"
+	"fmt"
+	"io/fs"
 	"log/slog"
 	"net/http"
+	"net/netip"
 	"os"
+	"path/filepath"
+	"slices"
 	"time"
 
+	gogrpc "google.golang.org/grpc"
+
+	"webpage-analyzer/frontend"
 	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/apikeys"
+	"webpage-analyzer/internal/cache"
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/geoip"
+	"webpage-analyzer/internal/graphql"
+	grpchandler "webpage-analyzer/internal/grpc"
 	httphandler "webpage-analyzer/internal/http"
+	"webpage-analyzer/internal/jobs"
+	"webpage-analyzer/internal/openapi"
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/reputation"
+	"webpage-analyzer/internal/store"
+	"webpage-analyzer/internal/urlnorm"
+	"webpage-analyzer/internal/version"
+	"webpage-analyzer/internal/worker"
 )
 
-const (
-	staticDir = "frontend/public"
-)
+// serverConfig holds the command-line configurable server settings.
+type serverConfig struct {
+	port          string
+	logSampleRate float64
+	proxies       []string // Reverse proxies whose X-Forwarded-For is believed.
+	storeDriver   string   // "sqlite", "postgres", or "none" to disable persistence.
+	storeDSN      string
+	jobsDSN       string // SQLite file of the job queue; with Postgres the queue shares storeDSN.
+	apiKeys       bool   // Require tenants' API keys on the endpoints that analyze pages.
+	apiKeysDSN    string // SQLite file of the API keys; with Postgres they share storeDSN.
+	grpcPort      string // Empty disables the gRPC server.
+	minWorkers    int
+	maxWorkers    int
+	cacheTTL      time.Duration // Zero disables the result cache.
+	stripTracking bool          // Ignore tracking parameters in cache keys.
+	trailingSlash string        // Trailing slash policy for cache keys: keep or strip.
+	warmURLs      []string      // Pages analyzed into the cache at startup.
+	cachePath     string        // File the result cache is kept in; empty keeps it in memory.
+	disabledMods  []string      // Analysis modules removed from the registry, e.g. "contacts".
+	reputation    string        // Threat feed for the reputation module; empty disables it.
+	reputationKey string
+	reputationSrc string        // Domain list file of the blocklist feed, or zone of the dnsbl feed.
+	geoIPDB       string        // CSV network database for the infrastructure module; empty reports IPs only.
+	protected     []string      // Domains the suspicious_links module checks links against; empty uses its defaults.
+	maxAnalyses   int           // Analyses served at once by the REST API; zero disables the limit.
+	analysisQueue int           // Analysis requests that may wait for a slot.
+	queueTimeout  time.Duration // Longest a request waits for a slot before a 503.
+	idempotency   time.Duration // How long Idempotency-Key responses are replayed; zero disables it.
+	hostRate      float64       // Outbound requests per second to one host; zero means no limit.
+	crawlDelay    bool          // Honour robots.txt Crawl-delay for outbound requests.
+	breakerLimit  int           // Consecutive failures before a host is skipped; zero disables the breaker.
+	breakerPause  time.Duration // How long a failing host is skipped.
+	dnsCacheSize  int           // Host names whose DNS answers are cached; zero disables the cache.
+	maxRedirects  int           // Redirects followed per fetch; negative follows none.
+	noDowngrade   bool          // Refuse redirects from https to http.
+	ipFamily      string        // IP versions used for outbound connections, e.g. prefer-ipv4.
+	localAddr     string        // Local IP address outbound connections are made from.
+	http3         bool          // Fetch over HTTP/3 from hosts that advertise it.
+	retryWait     time.Duration // Longest Retry-After waited out; zero never waits.
+	canaryURL     string        // Fetched by the deep health check; empty skips the outbound checks.
+	adminToken    string        // Bearer token of the admin endpoints; empty disables them.
+	assetsDir     string        // Source tree to serve the frontend from; empty serves the embedded copy.
 
-func registerRoutes(handler *httphandler.Handler) {
-	// Serve static files from frontend/public.
-	fs := http.FileServer(http.Dir(staticDir))
-	http.Handle("/", fs)
+	// transport tunes the outbound connection pool and connection timeouts.
+	transport client.TransportConfig
+}
 
-	// API routes.
-	http.HandleFunc("/api/health", handler.HealthCheck)
-	http.HandleFunc("/api/analyze", handler.AnalyzeWebpage)
-	http.HandleFunc("/api/status", handler.GetAnalysisStatus)
-
-	// API Documentation routes.
-	http.HandleFunc("/api/openapi", handler.ServeOpenAPI)
-	http.HandleFunc("/docs", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, staticDir+"/docs.html")
-	})
+// defaultServerConfig returns the server configuration used when no flags are given.
+func defaultServerConfig() serverConfig {
+	return serverConfig{
+		port:          "8080",
+		logSampleRate: 1.0,
+		storeDriver:   store.DriverSQLite,
+		storeDSN:      "webpage-analyzer.db",
+		jobsDSN:       "webpage-analyzer-jobs.db",
+		apiKeysDSN:    "webpage-analyzer-keys.db",
+		grpcPort:      "9090",
+		minWorkers:    worker.DefaultPoolConfig().MinWorkers,
+		maxWorkers:    worker.DefaultPoolConfig().MaxWorkers,
+		cacheTTL:      cache.DefaultConfig().TTL,
+		trailingSlash: "keep",
+		maxAnalyses:   httphandler.DefaultConcurrencyLimiterConfig().MaxConcurrent,
+		analysisQueue: httphandler.DefaultConcurrencyLimiterConfig().MaxQueue,
+		queueTimeout:  httphandler.DefaultConcurrencyLimiterConfig().QueueTimeout,
+		idempotency:   httphandler.DefaultIdempotencyConfig().Window,
+		breakerLimit:  client.DefaultConfig().Breaker.Threshold,
+		breakerPause:  client.DefaultConfig().Breaker.Cooldown,
+		dnsCacheSize:  client.DefaultConfig().DNSCache.MaxEntries,
+		maxRedirects:  client.DefaultConfig().Redirects.MaxHops,
+		retryWait:     client.DefaultConfig().RetryAfter.MaxWait,
+		canaryURL:     "https://example.com/",
+		transport:     client.DefaultConfig().Transport,
+	}
 }
 
-// setupServer initializes and returns a configured HTTP server
-func setupServer(port string) *http.Server {
-	// Initialize structured logger
+// features reports which optional features the configuration enables, for
+// the version endpoint.
+func (cfg serverConfig) features() map[string]bool {
+	return map[string]bool{
+		"history_store":     cfg.storeDriver != "none",
+		"result_cache":      cfg.cacheTTL > 0,
+		"disk_cache":        cfg.cacheTTL > 0 && cfg.cachePath != "",
+		"grpc":              cfg.grpcPort != "",
+		"reputation":        cfg.reputation != "",
+		"geoip":             cfg.geoIPDB != "",
+		"concurrency_limit": cfg.maxAnalyses > 0,
+		"idempotency":       cfg.idempotency > 0,
+		"host_rate_limit":   cfg.hostRate > 0,
+		"crawl_delay":       cfg.crawlDelay,
+		"circuit_breaker":   cfg.breakerLimit > 0,
+		"dns_cache":         cfg.dnsCacheSize > 0,
+		"http3":             cfg.http3,
+		"api_keys":          cfg.apiKeys,
+	}
+}
+
+// services holds the service layer shared by the REST and gRPC APIs.
+type services struct {
+	analyzerService analyzer.Service
+	historyStore    store.Store // nil when persistence is disabled.
+	workerPool      *worker.WorkerPool
+	httpClient      client.HTTPClient
+	jobQueue        jobs.Queue
+	jobRunner       *jobs.Runner
+	apiKeys         apikeys.Store         // nil unless API keys are required.
+	meter           *apikeys.Meter        // nil unless API keys are required; shared by every API and the jobs.
+	diskCache       cache.PersistentCache // nil unless the result cache is kept on disk.
+	readiness       *httphandler.Readiness
+	limiter         *httphandler.ConcurrencyLimiter // nil unless analyses are limited; shared by every API.
+	trustedProxies  []netip.Prefix
+}
+
+// logLevel is the level of the server's logger, changed at runtime through
+// the admin log level endpoint.
+var logLevel = new(slog.LevelVar)
+
+// setupLogger installs the structured JSON logger used by the server.
+func setupLogger() {
+	logLevel.Set(slog.LevelInfo)
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+		Level: logLevel,
 	}))
 	slog.SetDefault(logger)
+}
+
+// setupServices initializes the analyzer service and, if enabled, the history store.
+func setupServices(cfg serverConfig) (*services, error) {
+	proxies, err := httphandler.ParseTrustedProxies(cfg.proxies)
+	if err != nil {
+		return nil, err
+	}
+
+	poolConfig := worker.DefaultPoolConfig()
+	poolConfig.MinWorkers = cfg.minWorkers
+	poolConfig.MaxWorkers = cfg.maxWorkers
+	pool := worker.NewDynamicWorkerPool(poolConfig)
+
+	clientConfig := client.DefaultConfig()
+	clientConfig.HostLimit.RequestsPerSecond = cfg.hostRate
+	clientConfig.HostLimit.RespectCrawlDelay = cfg.crawlDelay
+	clientConfig.Breaker = client.BreakerConfig{Threshold: cfg.breakerLimit, Cooldown: cfg.breakerPause}
+	clientConfig.DNSCache.MaxEntries = cfg.dnsCacheSize
+	clientConfig.Redirects = client.RedirectPolicy{MaxHops: cfg.maxRedirects, ForbidDowngrade: cfg.noDowngrade}
+	clientConfig.Dial = client.DialConfig{Family: client.IPFamily(cfg.ipFamily), LocalAddr: cfg.localAddr}
+	clientConfig.HTTP3 = cfg.http3
+	clientConfig.Transport = cfg.transport
+	clientConfig.RetryAfter.MaxWait = cfg.retryWait
+	if cfg.retryWait <= 0 {
+		clientConfig.RetryAfter.MaxRetries = 0
+	}
+	if err := clientConfig.Dial.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid outbound network settings: %v", err)
+	}
+	if err := clientConfig.Transport.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid outbound connection settings: %v", err)
+	}
+	slash, err := urlnorm.ParseTrailingSlash(cfg.trailingSlash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache key settings: %v", err)
+	}
+	httpClient := client.NewHTTPClientWithConfig(clientConfig)
+	if cfg.hostRate > 0 || cfg.crawlDelay {
+		slog.Info("Outbound per-host rate limit enabled", "requests_per_second", cfg.hostRate, "respect_crawl_delay", cfg.crawlDelay)
+	}
+	htmlParser := parser.NewHTMLParser()
+	registry := analyzer.NewDefaultRegistry(htmlParser, httpClient)
+	if cfg.reputation != "" {
+		checker, err := reputation.New(reputation.Config{Provider: cfg.reputation, APIKey: cfg.reputationKey, Path: cfg.reputationSrc, Zone: cfg.reputationSrc})
+		if err != nil {
+			pool.Shutdown()
+			return nil, fmt.Errorf("failed to configure reputation checks: %v", err)
+		}
+		if err := registry.Register(analyzer.NewReputationModule(htmlParser, checker)); err != nil {
+			pool.Shutdown()
+			return nil, err
+		}
+		slog.Info("Reputation module enabled", "provider", cfg.reputation)
+	}
+	if cfg.geoIPDB != "" {
+		db, err := geoip.Open(cfg.geoIPDB)
+		if err != nil {
+			pool.Shutdown()
+			return nil, err
+		}
+		registry.Remove(analyzer.ModuleInfrastructure)
+		if err := registry.Register(analyzer.NewInfrastructureModule(db)); err != nil {
+			pool.Shutdown()
+			return nil, err
+		}
+		slog.Info("GeoIP database loaded", "path", cfg.geoIPDB, "networks", db.Len())
+	}
+	if len(cfg.protected) > 0 {
+		registry.Remove(analyzer.ModuleSuspiciousLinks)
+		if err := registry.Register(analyzer.NewSuspiciousLinksModule(htmlParser, cfg.protected)); err != nil {
+			pool.Shutdown()
+			return nil, err
+		}
+		slog.Info("Protected domains configured", "domains", len(cfg.protected))
+	}
+	for _, name := range cfg.disabledMods {
+		if !registry.Remove(name) {
+			pool.Shutdown()
+			return nil, fmt.Errorf("cannot disable unknown analysis module %q", name)
+		}
+		slog.Info("Analysis module disabled", "module", name)
+	}
+
+	analyzerService := analyzer.NewService(
+		analyzer.WithHTTPClient(httpClient),
+		analyzer.WithHTMLParser(htmlParser),
+		analyzer.WithWorkerPool(pool),
+		analyzer.WithRegistry(registry),
+	)
+	svcs := &services{
+		analyzerService: analyzerService,
+		workerPool:      pool,
+		httpClient:      httpClient,
+		trustedProxies:  proxies,
+	}
+	if cfg.maxAnalyses > 0 {
+		limiterConfig := httphandler.DefaultConcurrencyLimiterConfig()
+		limiterConfig.MaxConcurrent = cfg.maxAnalyses
+		limiterConfig.MaxQueue = cfg.analysisQueue
+		limiterConfig.QueueTimeout = cfg.queueTimeout
+		svcs.limiter = httphandler.NewConcurrencyLimiter(limiterConfig)
+		slog.Info("Analysis concurrency limit enabled",
+			"max_concurrent", cfg.maxAnalyses,
+			"max_queue", cfg.analysisQueue,
+			"queue_timeout", cfg.queueTimeout,
+		)
+	}
+
+	if cfg.storeDriver != "none" {
+		st, err := store.Open(context.Background(), store.Config{Driver: cfg.storeDriver, DSN: cfg.storeDSN})
+		if err != nil {
+			pool.Shutdown()
+			return nil, fmt.Errorf("failed to open analysis store: %v", err)
+		}
+		svcs.historyStore = st
+		svcs.analyzerService = store.NewRecordingService(svcs.analyzerService, st)
+		slog.Info("Analysis history store enabled", "driver", cfg.storeDriver)
+	}
 
-	// Initialize services.
-	analyzerService := analyzer.NewService()
+	if cfg.cacheTTL > 0 {
+		// Cache outside the recording service so that cache hits are not stored again.
+		cacheConfig := cache.DefaultConfig()
+		cacheConfig.TTL = cfg.cacheTTL
+		resultCache := cache.NewMemoryCache(cacheConfig)
+		if cfg.cachePath != "" {
+			disk, err := cache.OpenDiskCache(cfg.cachePath, cacheConfig)
+			if err != nil {
+				svcs.Close()
+				return nil, err
+			}
+			svcs.diskCache, resultCache = disk, disk
+		}
+		keys := urlnorm.Options{StripTracking: cfg.stripTracking, TrailingSlash: slash}
+		svcs.analyzerService = cache.NewCachingServiceWithKeys(svcs.analyzerService, resultCache, keys)
+		slog.Info("Analysis result cache enabled", "ttl", cfg.cacheTTL, "path", cfg.cachePath)
+	}
+	if svcs.historyStore != nil {
+		// Record outcomes outside the cache so that cache hits are counted.
+		svcs.analyzerService = store.NewStatsService(svcs.analyzerService, svcs.historyStore)
+	}
 
+	if cfg.apiKeys {
+		if cfg.adminToken == "" {
+			svcs.Close()
+			return nil, fmt.Errorf("--api-keys needs --admin-token, which keys are managed with")
+		}
+		keys, err := apikeys.Open(context.Background(), apiKeysConfig(cfg))
+		if err != nil {
+			svcs.Close()
+			return nil, fmt.Errorf("failed to open API key store: %v", err)
+		}
+		svcs.apiKeys = keys
+		svcs.meter = apikeys.NewMeter(keys, nil)
+		slog.Info("API keys required", "driver", apiKeysConfig(cfg).Driver)
+	}
+
+	queue, err := jobs.Open(context.Background(), jobsConfig(cfg))
+	if err != nil {
+		svcs.Close()
+		return nil, fmt.Errorf("failed to open job queue: %v", err)
+	}
+	svcs.jobQueue = queue
+	svcs.jobRunner = jobs.NewRunner(queue, jobs.DefaultRunnerConfig())
+	if svcs.meter != nil {
+		// Each page of a job counts against the quota of the key that queued it.
+		svcs.jobRunner.Use(jobs.Metered(svcs.apiKeys, svcs.meter))
+	}
+	jobs.RegisterAnalyzerHandlers(svcs.jobRunner, svcs.analyzerService)
+	if err := svcs.jobRunner.Start(context.Background()); err != nil {
+		svcs.Close()
+		return nil, fmt.Errorf("failed to start job runner: %v", err)
+	}
+	if err := warmCache(cfg, queue); err != nil {
+		svcs.Close()
+		return nil, err
+	}
+
+	// The configuration is valid and every service has started.
+	svcs.readiness = httphandler.NewReadiness(svcs.readinessChecks()...)
+	svcs.readiness.SetReady(true)
+	return svcs, nil
+}
+
+// warmCache queues a job that analyzes the startup warm list into the cache.
+func warmCache(cfg serverConfig, queue jobs.Queue) error {
+	if len(cfg.warmURLs) == 0 {
+		return nil
+	}
+	if cfg.cacheTTL <= 0 {
+		slog.Warn("Ignoring the cache warm list because the result cache is disabled")
+		return nil
+	}
+	req := jobs.WarmRequest{URLs: cfg.warmURLs}
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("invalid cache warm list: %v", err)
+	}
+	job, err := queue.Enqueue(context.Background(), jobs.KindWarm, req)
+	if err != nil {
+		return fmt.Errorf("failed to queue cache warming: %v", err)
+	}
+	slog.Info("Warming analysis result cache", "pages", len(req.URLs), "job_id", job.ID)
+	return nil
+}
+
+// jobsConfig chooses where background jobs are kept: beside the history
+// store when there is one, otherwise in memory.
+func jobsConfig(cfg serverConfig) jobs.Config {
+	switch cfg.storeDriver {
+	case store.DriverPostgres:
+		return jobs.Config{Driver: jobs.DriverPostgres, DSN: cfg.storeDSN}
+	case "none":
+		return jobs.Config{Driver: jobs.DriverMemory}
+	default:
+		return jobs.Config{Driver: jobs.DriverSQLite, DSN: cfg.jobsDSN}
+	}
+}
+
+// apiKeysConfig keeps the API keys next to the analysis history: in its
+// Postgres database, in a SQLite file of their own, or in memory without one.
+func apiKeysConfig(cfg serverConfig) apikeys.Config {
+	switch cfg.storeDriver {
+	case store.DriverPostgres:
+		return apikeys.Config{Driver: apikeys.DriverPostgres, DSN: cfg.storeDSN}
+	case "none":
+		return apikeys.Config{Driver: apikeys.DriverMemory}
+	default:
+		return apikeys.Config{Driver: apikeys.DriverSQLite, DSN: cfg.apiKeysDSN}
+	}
+}
+
+// Close releases resources held by the services.
+func (s *services) Close() {
+	if s.readiness != nil {
+		s.readiness.SetReady(false)
+	}
+	if s.jobRunner != nil {
+		s.jobRunner.Stop()
+	}
+	if s.jobQueue != nil {
+		if err := s.jobQueue.Close(); err != nil {
+			slog.Error("Failed to close job queue", "error", err)
+		}
+	}
+	s.workerPool.Shutdown()
+	if s.apiKeys != nil {
+		if err := s.apiKeys.Close(); err != nil {
+			slog.Error("Failed to close API key store", "error", err)
+		}
+	}
+	if s.diskCache != nil {
+		if err := s.diskCache.Close(); err != nil {
+			slog.Error("Failed to close result cache", "error", err)
+		}
+	}
+	if s.historyStore != nil {
+		if err := s.historyStore.Close(); err != nil {
+			slog.Error("Failed to close analysis store", "error", err)
+		}
+	}
+}
+
+// healthChecks returns the dependency checks of the deep health check: the
+// outbound network when canaryURL is set, then the services that are enabled.
+func (s *services) healthChecks(canaryURL string) []httphandler.DependencyCheck {
+	var checks []httphandler.DependencyCheck
+	if canaryURL != "" {
+		checks = append(checks, httphandler.OutboundChecks(s.httpClient, canaryURL)...)
+	}
+	if s.historyStore != nil {
+		checks = append(checks, httphandler.StoreCheck(s.historyStore))
+	}
+	if s.diskCache != nil {
+		checks = append(checks, httphandler.CacheCheck(s.diskCache))
+	}
+	if s.jobQueue != nil {
+		checks = append(checks, httphandler.JobQueueCheck(s.jobQueue))
+	}
+	return append(checks, httphandler.WorkerPoolCheck(s.workerPool))
+}
+
+// readinessChecks returns the checks of the readiness probe: the history
+// store, if enabled, answers and the worker pool runs.
+func (s *services) readinessChecks() []httphandler.DependencyCheck {
+	var checks []httphandler.DependencyCheck
+	if s.historyStore != nil {
+		checks = append(checks, httphandler.StoreCheck(s.historyStore))
+	}
+	return append(checks, httphandler.WorkerPoolRunningCheck(s.workerPool))
+}
+
+// setupGRPCServer returns a gRPC server exposing the shared analyzer service.
+// Its calls need the same API keys, count against the same quotas and take
+// the same limiter slots as the REST API's.
+func setupGRPCServer(svcs *services) *gogrpc.Server {
+	var (
+		unary  []gogrpc.UnaryServerInterceptor
+		stream []gogrpc.StreamServerInterceptor
+	)
+	if svcs.apiKeys != nil {
+		// As on the REST API, keys are checked and counted before calls
+		// wait for a slot of the limiter.
+		unary = append(unary, grpchandler.AuthenticateUnary(svcs.apiKeys), grpchandler.MeterUnary(svcs.meter))
+		stream = append(stream, grpchandler.AuthenticateStream(svcs.apiKeys), grpchandler.MeterStream(svcs.meter))
+	}
+	if svcs.limiter != nil {
+		unary = append(unary, grpchandler.LimitUnary(svcs.limiter))
+		stream = append(stream, grpchandler.LimitStream(svcs.limiter))
+	}
+	return grpchandler.Register(svcs.analyzerService, gogrpc.ChainUnaryInterceptor(unary...), gogrpc.ChainStreamInterceptor(stream...))
+}
+
+// apiInfo describes the API in its OpenAPI spec.
+var apiInfo = openapi.Info{
+	Title: "Webpage Analyzer API",
+	Description: "API for analyzing webpages and extracting comprehensive metadata including HTML version, " +
+		"page title, headings structure, link analysis, and login form detection. The service uses parallel " +
+		"processing with a worker pool for efficient analysis and provides detailed error handling for various " +
+		"HTTP status codes and network issues.",
+	Version: "1.0.0",
+	License: &openapi.License{Name: "MIT", URL: "https://opensource.org/licenses/MIT"},
+}
+
+// loadAssets returns the web frontend embedded in the binary or, when dir is
+// set, that of the source tree at dir, so that edits show without a rebuild.
+func loadAssets(dir string) fs.FS {
+	if dir == "" {
+		return frontend.Public()
+	}
+	return os.DirFS(filepath.Join(dir, "frontend", "public"))
+}
+
+// assetsSource describes where loadAssets(dir) reads from, for the logs.
+func assetsSource(dir string) string {
+	if dir == "" {
+		return "embedded"
+	}
+	return dir
+}
+
+// registerRoutes registers every route. limiter, if not nil, bounds the
+// endpoints that analyze pages synchronously, GraphQL included; idempotency, if not nil, lets
+// clients retry the POST endpoints with an Idempotency-Key; dns, if not nil,
+// adds DNS cache metrics; health are the checks of the deep health check and
+// readiness answers readiness probes; build is served by the version endpoint;
+// keys, if not nil, requires API keys on the endpoints that analyze pages;
+// static is the web frontend. Every route is documented in mux's OpenAPI
+// spec, which is served last so that it holds them all.
+func registerRoutes(mux *openapi.Mux, handler *httphandler.Handler, graphqlHandler *graphql.Handler, pool *worker.WorkerPool, limiter *httphandler.ConcurrencyLimiter, idempotency *httphandler.Idempotency, dns client.DNSStatsReporter, health []httphandler.DependencyCheck, readiness *httphandler.Readiness, build version.Info, keys *httphandler.APIKeys, static fs.FS) {
+	idempotent := func(h http.Handler) http.Handler {
+		if idempotency == nil {
+			return h
+		}
+		return idempotency.Wrap(h)
+	}
+	// Keys are checked before replays, which stay within a tenant, and
+	// metered after them, so that replays do not count against the quota.
+	metered := func(h http.Handler) http.Handler {
+		if keys == nil {
+			return idempotent(h)
+		}
+		return keys.Authenticate(idempotent(keys.Meter(h)))
+	}
+	// Routes that read what a tenant analyzed or queued need its key too,
+	// and only see its own analyses and jobs.
+	authenticated := func(h http.Handler) http.Handler {
+		if keys == nil {
+			return h
+		}
+		return keys.Authenticate(h)
+	}
+	// Jobs are not metered when queued: each of their pages is charged to
+	// the key that queued them as it is analyzed.
+	queued := func(h http.HandlerFunc) http.Handler {
+		return authenticated(idempotent(h))
+	}
+	// Replays are answered before the limiter so they never wait for a slot.
+	limited := func(h http.HandlerFunc) http.Handler {
+		if limiter == nil {
+			return metered(h)
+		}
+		return metered(limiter.Limit(h))
+	}
+	// The operations of routes that take a key require it when keys are on.
+	keyed := func(op openapi.Operation) openapi.Operation {
+		if keys != nil {
+			op.Security = append(op.Security, httphandler.APIKeyScheme)
+			op.Responses = append(slices.Clip(op.Responses), httphandler.AuthenticateFailures...)
+		}
+		return op
+	}
+	// The operations of limited routes also answer past the key's limits
+	// and while the limiter is full.
+	limitedOp := func(op openapi.Operation) openapi.Operation {
+		op = keyed(op)
+		if keys != nil {
+			op.Responses = append(op.Responses, httphandler.MeterFailures...)
+		}
+		if limiter != nil {
+			op.Responses = append(slices.Clip(op.Responses), httphandler.LimitFailures...)
+		}
+		return op
+	}
+	var graphqlRoute http.Handler = graphqlHandler
+	graphqlOp := keyed(graphql.Doc)
+	if limiter != nil {
+		graphqlRoute = limiter.Limit(graphqlRoute)
+		graphqlOp.Responses = append(slices.Clip(graphqlOp.Responses), httphandler.LimitFailures...)
+	}
+	if keys != nil {
+		// The resolver meters each analysis of a query, however many it asks for.
+		graphqlRoute = keys.Authenticate(graphqlRoute)
+	}
+
+	// Serve the web frontend.
+	mux.Handle("/", http.FileServer(http.FS(static)))
+
+	// API routes.
+	mux.HandleFunc("/api/health", handler.HealthCheck, httphandler.HealthCheckDoc)
+	mux.HandleFunc("/api/health/deep", httphandler.DeepHealthHandler(health), httphandler.DeepHealthDoc)
+	mux.HandleFunc("/livez", httphandler.Livez, httphandler.LivezDoc)
+	mux.HandleFunc("/readyz", readiness.Handler(), httphandler.ReadyzDoc)
+	mux.HandleFunc("/api/version", httphandler.VersionHandler(build), httphandler.VersionDoc)
+	mux.Handle("/api/analyze", limited(handler.AnalyzeWebpage), limitedOp(httphandler.AnalyzeWebpageDoc))
+	mux.Handle("/api/analyze/html", limited(handler.AnalyzeHTML), limitedOp(httphandler.AnalyzeHTMLDoc))
+	mux.Handle("/api/analyze/from-sitemap", queued(handler.AnalyzeSitemap), keyed(httphandler.AnalyzeSitemapDoc))
+	mux.Handle("/api/analyze/from-sitemap/{id}", authenticated(http.HandlerFunc(handler.GetSitemapJob)), keyed(httphandler.GetSitemapJobDoc))
+	mux.Handle("/api/crawl", queued(handler.CrawlSite), keyed(httphandler.CrawlSiteDoc))
+	mux.Handle("/api/cache/warm", queued(handler.WarmCache), keyed(httphandler.WarmCacheDoc))
+	mux.Handle("/api/jobs", authenticated(http.HandlerFunc(handler.ListJobs)), keyed(httphandler.ListJobsDoc))
+	mux.Handle("/api/jobs/{id}", authenticated(http.HandlerFunc(handler.GetJob)), keyed(httphandler.GetJobDoc))
+	mux.Handle("/api/jobs/{id}/retry", authenticated(http.HandlerFunc(handler.RetryJob)), keyed(httphandler.RetryJobDoc))
+	mux.Handle("/api/compare", limited(handler.CompareWebpages), limitedOp(httphandler.CompareWebpagesDoc))
+	mux.Handle("/api/compare/languages", limited(handler.CompareLanguages), limitedOp(httphandler.CompareLanguagesDoc))
+	mux.Handle("/api/compare/devices", limited(handler.CompareDevices), limitedOp(httphandler.CompareDevicesDoc))
+	mux.Handle("/api/extract/text", limited(handler.ExtractText), limitedOp(httphandler.ExtractTextDoc))
+	mux.Handle("/api/status", authenticated(http.HandlerFunc(handler.GetAnalysisStatus)), keyed(httphandler.GetAnalysisStatusDoc))
+	mux.Handle("/api/analyses", authenticated(http.HandlerFunc(handler.ListAnalyses)), keyed(httphandler.ListAnalysesDoc))
+	mux.Handle("/api/analyses/{id}", authenticated(http.HandlerFunc(handler.GetAnalysis)), keyed(httphandler.GetAnalysisDoc))
+	mux.Handle("/api/analyses/{id}/diff/{otherId}", authenticated(http.HandlerFunc(handler.DiffAnalyses)), keyed(httphandler.DiffAnalysesDoc))
+	mux.Handle("/api/analyses/{id}/similar", authenticated(http.HandlerFunc(handler.SimilarAnalyses)), keyed(httphandler.SimilarAnalysesDoc))
+	mux.Handle("/api/stats", authenticated(http.HandlerFunc(handler.Stats)), keyed(httphandler.StatsDoc))
+	mux.Handle("/api/graphql", graphqlRoute, graphqlOp)
+	mux.HandleFunc("/metrics", httphandler.MetricsHandler(pool, limiter, dns))
+
+	// API Documentation routes. The spec is rendered when its handler is
+	// created, so it documents itself first.
+	mux.Document().Add("/api/openapi", httphandler.OpenAPIDoc)
+	mux.ServeMux.HandleFunc("/api/openapi", httphandler.OpenAPIHandler(mux.Document()))
+	mux.HandleFunc("/docs", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFileFS(w, r, static, "docs.html")
+	})
+}
+
+// setupMux returns the mux of every route, documented in its OpenAPI spec.
+func setupMux(cfg serverConfig, svcs *services) *openapi.Mux {
 	// Initialize handlers.
-	handler := httphandler.NewHandler(analyzerService)
+	handler := httphandler.NewHandlerWithJobs(svcs.analyzerService, svcs.historyStore, svcs.jobQueue)
+
+	var idempotency *httphandler.Idempotency
+	if cfg.idempotency > 0 {
+		idempotencyConfig := httphandler.DefaultIdempotencyConfig()
+		idempotencyConfig.Window = cfg.idempotency
+		idempotency = httphandler.NewIdempotency(idempotencyConfig)
+	}
+
+	dns, _ := svcs.httpClient.(client.DNSStatsReporter)
 
 	// Register all routes.
-	registerRoutes(handler)
+	mux := openapi.NewMux(apiInfo)
+	mux.Document().AddSecurityScheme(httphandler.AdminTokenScheme, httphandler.AdminTokenSecurity)
+	if cfg.adminToken != "" {
+		mux.Handle("/api/admin/loglevel", httphandler.RequireAdminToken(cfg.adminToken, httphandler.NewLogLevel(logLevel).Handler()), httphandler.LogLevelDocs...)
+	}
+	var keys *httphandler.APIKeys
+	if svcs.apiKeys != nil {
+		keys = httphandler.NewAPIKeys(svcs.apiKeys, svcs.meter, cfg.adminToken)
+		mux.Document().AddSecurityScheme(httphandler.APIKeyScheme, httphandler.APIKeySecurity)
+		mux.Handle("/api/admin/keys", httphandler.RequireAdminToken(cfg.adminToken, keys.KeysHandler()), httphandler.APIKeysDocs...)
+		mux.Handle("/api/admin/keys/{id}", httphandler.RequireAdminToken(cfg.adminToken, keys.KeyHandler()), httphandler.APIKeyDocs...)
+		mux.Handle("/api/usage", keys.UsageHandler(), httphandler.UsageDoc)
+	}
+	registerRoutes(mux, handler, graphql.NewHandler(svcs.analyzerService, svcs.historyStore, svcs.meter), svcs.workerPool, svcs.limiter, idempotency, dns, svcs.healthChecks(cfg.canaryURL), svcs.readiness, version.Get(cfg.features()), keys, loadAssets(cfg.assetsDir))
+	return mux
+}
+
+// setupServer initializes and returns a configured HTTP server
+func setupServer(cfg serverConfig, svcs *services) *http.Server {
+	port := cfg.port
+	mux := setupMux(cfg, svcs)
 
 	slog.Info("Starting webpage analyzer server",
 		"port", port,
-		"static_dir", staticDir,
+		"assets", assetsSource(cfg.assetsDir),
+		"version", version.Version,
 	)
 
 	// Log available endpoints
@@ -78,9 +662,34 @@ func setupServer(port string) *http.Server {
 		{"Frontend", "/"},
 		{"API Documentation", "/docs"},
 		{"Health check", "/api/health"},
+		{"Deep health check", "/api/health/deep"},
+		{"Liveness probe", "/livez"},
+		{"Readiness probe", "/readyz"},
+		{"Version", "/api/version"},
 		{"Analysis endpoint", "/api/analyze"},
+		{"Sitemap analysis endpoint", "/api/analyze/from-sitemap"},
+		{"Crawl endpoint", "/api/crawl"},
+		{"Jobs endpoint", "/api/jobs"},
+		{"Comparison endpoint", "/api/compare"},
+		{"Text extraction endpoint", "/api/extract/text"},
 		{"Status endpoint", "/api/status"},
+		{"Analysis history", "/api/analyses"},
+		{"Statistics", "/api/stats"},
+		{"GraphQL endpoint", "/api/graphql"},
 		{"OpenAPI spec", "/api/openapi"},
+		{"Metrics", "/metrics"},
+	}
+	if cfg.adminToken != "" {
+		endpoints = append(endpoints, struct {
+			name string
+			path string
+		}{"Log level (admin)", "/api/admin/loglevel"})
+	}
+	if svcs.apiKeys != nil {
+		endpoints = append(endpoints, []struct {
+			name string
+			path string
+		}{{"API keys (admin)", "/api/admin/keys"}, {"Usage", "/api/usage"}}...)
 	}
 
 	for _, endpoint := range endpoints {
@@ -93,8 +702,9 @@ func setupServer(port string) *http.Server {
 	// Create server with timeout configuration.
 	server := &http.Server{
 		Addr:         ":" + port,
+		Handler:      httphandler.AccessLog(mux, httphandler.AccessLogConfig{SampleRate: cfg.logSampleRate, TrustedProxies: svcs.trustedProxies}),
 		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		WriteTimeout: httphandler.WriteTimeout,
 		IdleTimeout:  60 * time.Second,
 	}
 
@@ -102,19 +712,14 @@ func setupServer(port string) *http.Server {
 		"read_timeout", server.ReadTimeout,
 		"write_timeout", server.WriteTimeout,
 		"idle_timeout", server.IdleTimeout,
+		"access_log_sample_rate", cfg.logSampleRate,
 	)
 
 	return server
 }
 
 func main() {
-	port := flag.String("port", "8080", "Port to run the server on")
-	flag.Parse()
-
-	server := setupServer(*port)
-
-	if err := server.ListenAndServe(); err != nil {
-		slog.Error("Server failed to start", "error", err)
+	if err := newRootCommand().Execute(); err != nil {
 		os.Exit(1)
 	}
 }
@@ -2,17 +2,39 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"webpage-analyzer/internal/grpc/analyzerpb"
+	httphandler "webpage-analyzer/internal/http"
+	"webpage-analyzer/internal/jobs"
+	"webpage-analyzer/internal/version"
 )
 
 func TestServerStartupAndEndpoints(t *testing.T) {
 	// Use the same setup logic as main()
-	server := setupServer("9876")
+	cfg := defaultServerConfig()
+	cfg.port = "9876"
+	cfg.storeDSN = ":memory:"
+	cfg.jobsDSN = ":memory:"
+	svcs, err := setupServices(cfg)
+	require.NoError(t, err)
+	defer svcs.Close()
+	server := setupServer(cfg, svcs)
 
 	// Start server in background
 	go func() {
@@ -33,6 +55,29 @@ func TestServerStartupAndEndpoints(t *testing.T) {
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 	})
 
+	t.Run("Probes", func(t *testing.T) {
+		for _, path := range []string{"/livez", "/readyz"} {
+			resp, err := http.Get("http://localhost:9876" + path)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		}
+	})
+
+	t.Run("VersionEndpoint", func(t *testing.T) {
+		resp, err := http.Get("http://localhost:9876/api/version")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var info version.Info
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+		assert.Equal(t, "dev", info.Version)
+		assert.NotEmpty(t, info.GoVersion)
+		assert.True(t, info.Features["history_store"])
+		assert.False(t, info.Features["http3"])
+	})
+
 	t.Run("StatusEndpoint", func(t *testing.T) {
 		resp, err := http.Get("http://localhost:9876/api/status")
 		require.NoError(t, err)
@@ -41,6 +86,14 @@ func TestServerStartupAndEndpoints(t *testing.T) {
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 	})
 
+	t.Run("MetricsEndpoint", func(t *testing.T) {
+		resp, err := http.Get("http://localhost:9876/metrics")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
 	t.Run("AnalyzeEndpointMethodNotAllowed", func(t *testing.T) {
 		resp, err := http.Get("http://localhost:9876/api/analyze")
 		require.NoError(t, err)
@@ -62,10 +115,347 @@ func TestServerStartupAndEndpoints(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	err := server.Shutdown(ctx)
+	err = server.Shutdown(ctx)
 	assert.NoError(t, err)
 }
 
-func TestStaticDirConstant(t *testing.T) {
-	assert.Equal(t, "frontend/public", staticDir)
+func TestGRPCServerStartup(t *testing.T) {
+	cfg := defaultServerConfig()
+	cfg.storeDriver = "none"
+	svcs, err := setupServices(cfg)
+	require.NoError(t, err)
+	defer svcs.Close()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	grpcServer := setupGRPCServer(svcs)
+	go func() { _ = grpcServer.Serve(lis) }()
+	defer grpcServer.Stop()
+
+	conn, err := gogrpc.NewClient(lis.Addr().String(), gogrpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	resp, err := analyzerpb.NewAnalyzerServiceClient(conn).GetStatus(context.Background(), &analyzerpb.GetStatusRequest{})
+	require.NoError(t, err)
+	assert.Contains(t, resp.GetStatus(), "Service is running")
+}
+
+func TestConcurrencyLimitSharedByAPIs(t *testing.T) {
+	cfg := defaultServerConfig()
+	cfg.storeDriver = "none"
+	cfg.maxAnalyses = 1
+	cfg.analysisQueue = 0
+	svcs, err := setupServices(cfg)
+	require.NoError(t, err)
+	defer svcs.Close()
+	require.True(t, svcs.limiter.Acquire(context.Background()), "The only slot should be free")
+	defer svcs.limiter.Release()
+
+	w := httptest.NewRecorder()
+	setupMux(cfg, svcs).ServeHTTP(w, httptest.NewRequest("POST", "/api/graphql", strings.NewReader(`{"query": "{ status }"}`)))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, "GraphQL should wait for the same slots as REST")
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer := setupGRPCServer(svcs)
+	go func() { _ = grpcServer.Serve(lis) }()
+	defer grpcServer.Stop()
+	conn, err := gogrpc.NewClient(lis.Addr().String(), gogrpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = analyzerpb.NewAnalyzerServiceClient(conn).Analyze(context.Background(), &analyzerpb.AnalyzeRequest{Url: "https://example.com"})
+	assert.Equal(t, codes.Unavailable, status.Code(err), "gRPC should wait for the same slots as REST")
+}
+
+func TestServerAssets(t *testing.T) {
+	cfg := defaultServerConfig()
+	cfg.storeDriver = "none"
+	svcs, err := setupServices(cfg)
+	require.NoError(t, err)
+	defer svcs.Close()
+	get := func(server *http.Server, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	// The working directory of tests has no frontend, so these come from the binary.
+	embedded := setupServer(cfg, svcs)
+	for _, path := range []string{"/", "/styles.css", "/docs"} {
+		assert.Equal(t, http.StatusOK, get(embedded, path).Code, "%s should be served from the embedded frontend", path)
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "frontend", "public"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "frontend", "public", "index.html"), []byte("<h1>dev</h1>"), 0o644))
+	cfg.assetsDir = dir
+	dev := setupServer(cfg, svcs)
+
+	assert.Contains(t, get(dev, "/").Body.String(), "<h1>dev</h1>", "--assets-dir should serve the frontend from disk")
+	assert.Equal(t, http.StatusNotFound, get(dev, "/styles.css").Code)
+}
+
+func TestSetupMuxDocumentsAPI(t *testing.T) {
+	cfg := defaultServerConfig()
+	cfg.storeDriver = "none"
+	cfg.apiKeys = true
+	cfg.adminToken = "admin-secret"
+	svcs, err := setupServices(cfg)
+	require.NoError(t, err)
+	defer svcs.Close()
+	mux := setupMux(cfg, svcs)
+
+	for _, pattern := range mux.Undocumented() {
+		assert.False(t, strings.HasPrefix(pattern, "/api/"), "%s should be documented in the OpenAPI spec", pattern)
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/openapi?format=json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var spec struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas         map[string]json.RawMessage `json:"schemas"`
+			SecuritySchemes map[string]json.RawMessage `json:"securitySchemes"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+	for _, path := range []string{"/api/analyze", "/api/stats", "/api/admin/keys/{id}", "/api/usage", "/api/openapi", "/readyz"} {
+		assert.Contains(t, spec.Paths, path)
+	}
+	assert.Contains(t, spec.Paths["/api/admin/loglevel"], "put")
+	assert.Contains(t, spec.Components.Schemas, "analyzer.WebpageAnalysis")
+	assert.Contains(t, spec.Components.SecuritySchemes, httphandler.APIKeyScheme)
+	assert.Contains(t, string(spec.Paths["/api/analyze"]["post"]), `"APIKey"`, "Metered routes should require an API key")
+	var analyze struct {
+		Responses map[string]json.RawMessage `json:"responses"`
+	}
+	require.NoError(t, json.Unmarshal(spec.Paths["/api/analyze"]["post"], &analyze))
+	for _, status := range []string{"401", "422", "429", "502", "503", "504"} {
+		assert.Contains(t, analyze.Responses, status, "/api/analyze should document its %s", status)
+	}
+	assert.Contains(t, string(analyze.Responses["503"]), "host_skipped")
+	assert.Contains(t, string(analyze.Responses["503"]), "server_busy", "The limiter's 503 should be described next to the analysis's")
+	var graphqlOp struct {
+		Responses map[string]json.RawMessage `json:"responses"`
+	}
+	require.NoError(t, json.Unmarshal(spec.Paths["/api/graphql"]["post"], &graphqlOp))
+	assert.Contains(t, graphqlOp.Responses, "401")
+	assert.Contains(t, graphqlOp.Responses, "503", "GraphQL requests wait for the limiter too")
+	assert.NotContains(t, spec.Paths, "/metrics")
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/openapi", nil))
+	assert.Equal(t, "application/yaml", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "openapi: 3.0.3")
+}
+
+func TestSetupServicesDisabledModules(t *testing.T) {
+	cfg := defaultServerConfig()
+	cfg.storeDriver = "none"
+	cfg.disabledMods = []string{"contacts"}
+	svcs, err := setupServices(cfg)
+	require.NoError(t, err)
+	svcs.Close()
+
+	cfg.disabledMods = []string{"nope"}
+	_, err = setupServices(cfg)
+	assert.Error(t, err, "Disabling an unknown module should fail startup")
+}
+
+func TestSetupServerAPIKeys(t *testing.T) {
+	cfg := defaultServerConfig()
+	cfg.storeDriver = "none"
+	cfg.apiKeys = true
+	_, err := setupServices(cfg)
+	assert.Error(t, err, "API keys without an admin token to manage them should fail startup")
+
+	cfg.adminToken = "admin-secret"
+	svcs, err := setupServices(cfg)
+	require.NoError(t, err)
+	defer svcs.Close()
+	server := setupServer(cfg, svcs)
+	serve := func(method, path, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("POST", "/api/analyze", `{"url": "https://example.com"}`, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("POST", "/api/admin/keys", `{"tenant": "acme"}`, "").Code)
+	for _, path := range []string{"/api/jobs", "/api/jobs/1", "/api/analyze/from-sitemap/1", "/api/analyses", "/api/analyses/1", "/api/analyses/1/similar", "/api/status", "/api/stats"} {
+		assert.Equal(t, http.StatusUnauthorized, serve("GET", path, "", "").Code, "%s should need an API key", path)
+	}
+	assert.Equal(t, http.StatusUnauthorized, serve("POST", "/api/jobs/1/retry", "", "").Code)
+
+	w := serve("POST", "/api/admin/keys", `{"tenant": "acme", "daily_quota": 10}`, cfg.adminToken)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created httphandler.CreatedAPIKey
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	w = serve("GET", "/api/usage", "", created.Secret)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusOK, serve("GET", "/api/usage", "", cfg.adminToken).Code)
+	assert.Equal(t, http.StatusOK, serve("GET", "/api/jobs", "", created.Secret).Code)
+}
+
+func TestSetupServicesTrustedProxies(t *testing.T) {
+	cfg := defaultServerConfig()
+	cfg.storeDriver = "none"
+	cfg.proxies = []string{"10.0.0.0/8", "192.0.2.1"}
+	svcs, err := setupServices(cfg)
+	require.NoError(t, err)
+	assert.Len(t, svcs.trustedProxies, 2)
+	svcs.Close()
+
+	cfg.proxies = []string{"proxy.internal"}
+	_, err = setupServices(cfg)
+	assert.Error(t, err, "A trusted proxy that is not an IP or network should fail startup")
+}
+
+func TestSetupServicesReputation(t *testing.T) {
+	cfg := defaultServerConfig()
+	cfg.storeDriver = "none"
+	cfg.reputation = "urlhaus"
+	cfg.reputationKey = "secret"
+	svcs, err := setupServices(cfg)
+	require.NoError(t, err)
+	svcs.Close()
+
+	cfg.reputationKey = ""
+	_, err = setupServices(cfg)
+	assert.Error(t, err, "A reputation feed without a key should fail startup")
+}
+
+func TestSetupServicesGeoIP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geoip.csv")
+	require.NoError(t, os.WriteFile(path, []byte("203.0.113.0/24,64500,Example Hosting,NL\n"), 0o600))
+
+	cfg := defaultServerConfig()
+	cfg.storeDriver = "none"
+	cfg.geoIPDB = path
+	svcs, err := setupServices(cfg)
+	require.NoError(t, err)
+	svcs.Close()
+
+	cfg.geoIPDB = filepath.Join(t.TempDir(), "missing.csv")
+	_, err = setupServices(cfg)
+	assert.Error(t, err, "A missing GeoIP database should fail startup")
+}
+
+func TestSetupServicesProtectedDomains(t *testing.T) {
+	cfg := defaultServerConfig()
+	cfg.storeDriver = "none"
+	cfg.protected = []string{"example.com", "example.org"}
+	svcs, err := setupServices(cfg)
+	require.NoError(t, err)
+	svcs.Close()
+}
+
+func TestSetupServicesCacheKeys(t *testing.T) {
+	cfg := defaultServerConfig()
+	cfg.storeDriver = "none"
+	cfg.stripTracking = true
+	cfg.trailingSlash = "strip"
+	svcs, err := setupServices(cfg)
+	require.NoError(t, err)
+	svcs.Close()
+
+	cfg.trailingSlash = "add"
+	_, err = setupServices(cfg)
+	assert.Error(t, err, "An unknown trailing slash policy should fail startup")
+}
+
+func TestSetupServicesWarmsCache(t *testing.T) {
+	cfg := defaultServerConfig()
+	cfg.storeDriver = "none"
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<title>Warm</title>"))
+	}))
+	defer page.Close()
+	cfg.warmURLs = []string{page.URL}
+	svcs, err := setupServices(cfg)
+	require.NoError(t, err)
+	defer svcs.Close()
+
+	queued, err := svcs.jobQueue.List(context.Background(), "", 0)
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+	assert.Equal(t, jobs.KindWarm, queued[0].Kind)
+
+	cfg.warmURLs = []string{""}
+	_, err = setupServices(cfg)
+	assert.Error(t, err, "An invalid warm list should fail startup")
+}
+
+func TestSetupServicesDiskCache(t *testing.T) {
+	cfg := defaultServerConfig()
+	cfg.storeDriver = "none"
+	cfg.cachePath = filepath.Join(t.TempDir(), "cache.db")
+	svcs, err := setupServices(cfg)
+	require.NoError(t, err)
+	require.NotNil(t, svcs.diskCache)
+	svcs.Close()
+
+	// The file is released on close, so the next start can open it.
+	svcs, err = setupServices(cfg)
+	require.NoError(t, err)
+	svcs.Close()
+}
+
+func TestSetupServicesOutboundNetwork(t *testing.T) {
+	cfg := defaultServerConfig()
+	cfg.storeDriver = "none"
+	cfg.ipFamily = "prefer-ipv4"
+	cfg.localAddr = "127.0.0.1"
+	svcs, err := setupServices(cfg)
+	require.NoError(t, err)
+	svcs.Close()
+
+	cfg.ipFamily = "ipv5"
+	_, err = setupServices(cfg)
+	assert.Error(t, err, "An unknown IP family should fail startup")
+
+	cfg.ipFamily = "ipv6"
+	_, err = setupServices(cfg)
+	assert.Error(t, err, "An IPv4 local address cannot make IPv6 connections")
+}
+
+func TestSetupServicesTransport(t *testing.T) {
+	cfg := defaultServerConfig()
+	cfg.storeDriver = "none"
+	cfg.transport.MaxIdleConnsPerHost = 32
+	cfg.transport.MaxConnsPerHost = 64
+	svcs, err := setupServices(cfg)
+	require.NoError(t, err)
+	svcs.Close()
+
+	cfg.transport.DialTimeout = -time.Second
+	_, err = setupServices(cfg)
+	assert.Error(t, err, "A negative dial timeout should fail startup")
+}
+
+func TestServicesHealthChecks(t *testing.T) {
+	cfg := defaultServerConfig()
+	cfg.storeDriver = "none"
+	svcs, err := setupServices(cfg)
+	require.NoError(t, err)
+	defer svcs.Close()
+
+	names := func(checks []httphandler.DependencyCheck) []string {
+		var names []string
+		for _, check := range checks {
+			names = append(names, check.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"dns", "http", "job_queue", "worker_pool"}, names(svcs.healthChecks(cfg.canaryURL)))
+	assert.Equal(t, []string{"job_queue", "worker_pool"}, names(svcs.healthChecks("")), "An empty canary URL should skip the outbound checks")
 }
@@ -0,0 +1,19 @@
+// Package frontend holds the web frontend the server serves at its root.
+package frontend
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed public
+var files embed.FS
+
+// Public returns the frontend's files, rooted at its public directory.
+func Public() fs.FS {
+	public, err := fs.Sub(files, "public")
+	if err != nil {
+		panic(err) // "public" is a valid path, so this cannot happen.
+	}
+	return public
+}
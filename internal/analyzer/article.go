@@ -0,0 +1,430 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Options accepted by the article module.
+const (
+	articleOptionMaxLength = "max_length" // int: characters of article text to return.
+
+	defaultArticleMaxLength = 20000
+	maxArticleMaxLength     = 200000
+
+	// minParagraphLength is the text length below which a paragraph does not
+	// count towards its container's score.
+	minParagraphLength = 25
+)
+
+var (
+	// unlikelyCandidate matches the class or id of page chrome that is never
+	// part of an article.
+	unlikelyCandidate = regexp.MustCompile(`(?i)banner|breadcrumb|comment|community|cookie|disqus|footer|header|menu|modal|nav|popup|promo|related|replies|share|sidebar|social|sponsor|subscribe`)
+	// likelyCandidate overrides unlikelyCandidate, e.g. for "article-header".
+	likelyCandidate = regexp.MustCompile(`(?i)article|body|content|entry|main|post|story`)
+	// positiveClass and negativeClass adjust the score of a container by its
+	// class and id.
+	positiveClass = regexp.MustCompile(`(?i)article|body|content|entry|hentry|main|page|post|story|text|blog`)
+	negativeClass = regexp.MustCompile(`(?i)hidden|banner|comment|contact|foot|masthead|meta|outbrain|promo|related|share|sidebar|sponsor|shopping|tags|widget|\bads?\b|advert`)
+	// bylineClass matches the class or id of an author line.
+	bylineClass = regexp.MustCompile(`(?i)byline|author`)
+)
+
+// articleSkippedTags are never part of an article's content.
+var articleSkippedTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "nav": true, "header": true,
+	"footer": true, "aside": true, "form": true, "button": true, "input": true, "select": true,
+	"textarea": true, "iframe": true, "object": true, "embed": true, "svg": true, "canvas": true,
+}
+
+// articleBlockTags start a new line in an article's text, and make a <div>
+// that contains them a container rather than a paragraph.
+var articleBlockTags = map[string]bool{
+	"address": true, "article": true, "blockquote": true, "div": true, "dl": true, "figure": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "li": true, "ol": true,
+	"p": true, "pre": true, "section": true, "table": true, "tr": true, "ul": true, "br": true,
+}
+
+// articleKeptAttrs are the attributes kept in an article's HTML.
+var articleKeptAttrs = map[string]bool{"href": true, "src": true, "alt": true, "title": true, "datetime": true}
+
+// articleModule isolates the main text of the page. Its result can be large,
+// so it only runs when requested by name.
+type articleModule struct{}
+
+func (m *articleModule) Name() string { return ModuleArticle }
+
+// OptIn implements OptInModule.
+func (m *articleModule) OptIn() bool { return true }
+
+// ValidateOptions implements OptionsValidator.
+func (m *articleModule) ValidateOptions(opts ModuleOptions) error {
+	_, err := m.parseOptions(opts)
+	return err
+}
+
+// parseOptions reads the max_length option.
+func (m *articleModule) parseOptions(opts ModuleOptions) (int, error) {
+	for key := range opts {
+		if key != articleOptionMaxLength {
+			return 0, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	maxLength, err := opts.Int(articleOptionMaxLength, defaultArticleMaxLength)
+	if err != nil {
+		return 0, err
+	}
+	if maxLength <= 0 || maxLength > maxArticleMaxLength {
+		return 0, fmt.Errorf("option %q must be between 1 and %d", articleOptionMaxLength, maxArticleMaxLength)
+	}
+	return maxLength, nil
+}
+
+// Analyze extracts the article.
+func (m *articleModule) Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error) {
+	maxLength, err := m.parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	article := ExtractArticle(doc, maxLength)
+	return ModuleResultFunc(func(a *WebpageAnalysis) { a.Article = article }), nil
+}
+
+// ExtractArticle finds the main content of doc the way reader views do: each
+// paragraph scores its parent and, half as much, its grandparent by its
+// length and commas; containers gain or lose points for their class and id
+// and lose them in proportion to how much of their text is links. The best
+// container and the siblings that score nearly as well form the article,
+// without navigation, forms, scripts or chrome such as share bars. The text
+// is cut after about maxLength characters, the HTML at the same point. The byline
+// and publish date come from structured data, meta tags or the content.
+// ExtractArticle returns nil when no paragraph qualifies.
+func ExtractArticle(doc *html.Node, maxLength int) *Article {
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if skipArticleNode(n) {
+				return
+			}
+			if n.Data == "p" || n.Data == "pre" || (n.Data == "div" && !hasBlockChild(n)) {
+				if text := collapsedText(n); len(text) >= minParagraphLength {
+					score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+					addScore(n.Parent, score)
+					if n.Parent != nil {
+						addScore(n.Parent.Parent, score/2)
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	var top *html.Node
+	for _, n := range candidates {
+		scores[n] *= 1 - linkDensity(n)
+		if top == nil || scores[n] > scores[top] {
+			top = n
+		}
+	}
+	if top == nil {
+		return nil
+	}
+
+	// Siblings that score nearly as well, such as the other sections of a
+	// long article, belong to it too.
+	content := []*html.Node{top}
+	if top.Parent != nil {
+		threshold := max(10, scores[top]*0.2)
+		content = content[:0]
+		for sibling := top.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+			if score, ok := scores[sibling]; sibling == top || (ok && score >= threshold) {
+				content = append(content, sibling)
+			}
+		}
+	}
+
+	article := &Article{}
+	budget := maxLength
+	var text, markup strings.Builder
+	for _, n := range content {
+		clean, truncated := cleanArticleNode(n, &budget)
+		if clean == nil {
+			break
+		}
+		if err := html.Render(&markup, clean); err != nil {
+			break
+		}
+		writeArticleText(&text, clean)
+		if truncated {
+			article.Truncated = true
+			break
+		}
+	}
+	// The budget does not count line breaks, which can push the text over.
+	article.Text = strings.TrimSpace(truncateRunes(strings.TrimSpace(collapseBlankLines(text.String())), maxLength))
+	article.HTML = markup.String()
+	article.Length = utf8.RuneCountInString(article.Text)
+	article.Byline, article.Published = articleMetadata(doc, top)
+	return article
+}
+
+// skipArticleNode reports whether n and its content are left out of articles.
+func skipArticleNode(n *html.Node) bool {
+	if articleSkippedTags[n.Data] || hasAttr(n, "hidden") {
+		return true
+	}
+	if n.Data == "body" || n.Data == "html" || n.Data == "article" || n.Data == "main" {
+		return false
+	}
+	match := nodeAttr(n, "class") + " " + nodeAttr(n, "id")
+	return unlikelyCandidate.MatchString(match) && !likelyCandidate.MatchString(match)
+}
+
+// initialScore returns the score a container starts with, from its tag and
+// its class and id.
+func initialScore(n *html.Node) float64 {
+	score := 0.0
+	switch n.Data {
+	case "article":
+		score += 10
+	case "div", "main", "section":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "ol", "ul", "dl", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+	for _, value := range []string{nodeAttr(n, "class"), nodeAttr(n, "id")} {
+		if value == "" {
+			continue
+		}
+		if negativeClass.MatchString(value) {
+			score -= 25
+		}
+		if positiveClass.MatchString(value) {
+			score += 25
+		}
+	}
+	return score
+}
+
+// hasBlockChild reports whether n has a child element that is a block.
+func hasBlockChild(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && articleBlockTags[c.Data] && c.Data != "br" {
+			return true
+		}
+	}
+	return false
+}
+
+// linkDensity returns the share of n's text that is inside links.
+func linkDensity(n *html.Node) float64 {
+	total := len(collapsedText(n))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			linked += len(collapsedText(n))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return float64(linked) / float64(total)
+}
+
+// cleanArticleNode copies n without skipped elements and attributes other
+// than articleKeptAttrs, spending budget on its text. Once budget runs out
+// the text is cut and the rest of n dropped, and truncated is set. It
+// returns nil when nothing of n fits.
+func cleanArticleNode(n *html.Node, budget *int) (clean *html.Node, truncated bool) {
+	if *budget <= 0 {
+		return nil, true
+	}
+	switch n.Type {
+	case html.TextNode:
+		length := utf8.RuneCountInString(strings.Join(strings.Fields(n.Data), " "))
+		if length > *budget {
+			text := truncateRunes(strings.TrimLeft(n.Data, " \t\r\n"), *budget)
+			*budget = 0
+			return &html.Node{Type: html.TextNode, Data: text}, true
+		}
+		*budget -= length
+		return &html.Node{Type: html.TextNode, Data: n.Data}, false
+	case html.ElementNode:
+		if skipArticleNode(n) {
+			return nil, false
+		}
+		clean = &html.Node{Type: html.ElementNode, Data: n.Data, DataAtom: n.DataAtom}
+		for _, attr := range n.Attr {
+			if attr.Namespace == "" && articleKeptAttrs[attr.Key] {
+				clean.Attr = append(clean.Attr, attr)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			child, cut := cleanArticleNode(c, budget)
+			if child != nil {
+				clean.AppendChild(child)
+			}
+			if cut {
+				return clean, true
+			}
+		}
+		return clean, false
+	}
+	return nil, false
+}
+
+// writeArticleText writes the text of n to text, with a line break around
+// each block element.
+func writeArticleText(text *strings.Builder, n *html.Node) {
+	if n.Type == html.TextNode {
+		text.WriteString(strings.Join(strings.Fields(n.Data), " "))
+		if strings.HasSuffix(n.Data, " ") || strings.HasSuffix(n.Data, "\n") {
+			text.WriteString(" ")
+		}
+		return
+	}
+	block := n.Type == html.ElementNode && articleBlockTags[n.Data]
+	if block {
+		text.WriteString("\n")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeArticleText(text, c)
+	}
+	if block {
+		text.WriteString("\n")
+	}
+}
+
+// collapseBlankLines trims each line and keeps at most one empty line
+// between paragraphs.
+func collapseBlankLines(s string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// articleMetadata returns the author and publish date of the article in
+// content, preferring structured data, then meta tags, then the markup.
+func articleMetadata(doc, content *html.Node) (byline, published string) {
+	items, _ := extractSchemaItems(doc)
+	for _, it := range items {
+		if containsAny(it.item.types(), []string{"Article", "NewsArticle", "BlogPosting", "Report", "ScholarlyArticle", "TechArticle"}) {
+			byline = firstNonEmpty(byline, schemaString(it.item["author"]))
+			published = firstNonEmpty(published, schemaString(it.item["datePublished"]))
+		}
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+			return
+		}
+		if n.Data == "meta" {
+			key := strings.ToLower(firstNonEmpty(nodeAttr(n, "property"), nodeAttr(n, "name")))
+			value := strings.TrimSpace(nodeAttr(n, "content"))
+			switch key {
+			case "author", "article:author":
+				if !strings.HasPrefix(value, "http") { // article:author is often a profile URL.
+					byline = firstNonEmpty(byline, value)
+				}
+			case "article:published_time", "date", "pubdate", "publish-date", "dc.date.issued":
+				published = firstNonEmpty(published, value)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	// Only look for a byline and date near the article: a page's sidebar
+	// can have both for other articles.
+	scope := content
+	if scope.Parent != nil {
+		scope = scope.Parent
+	}
+	var walkContent func(n *html.Node)
+	walkContent = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if articleSkippedTags[n.Data] && n.Data != "header" {
+				return
+			}
+			if byline == "" && (strings.Contains(nodeAttr(n, "rel"), "author") || bylineClass.MatchString(nodeAttr(n, "class")+" "+nodeAttr(n, "id"))) {
+				if text := collapsedText(n); text != "" && len(text) <= 100 {
+					byline = text
+				}
+			}
+			if published == "" && n.Data == "time" {
+				published = strings.TrimSpace(nodeAttr(n, "datetime"))
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walkContent(c)
+		}
+	}
+	walkContent(scope)
+	return byline, published
+}
+
+// collapsedText returns the text under n with whitespace collapsed.
+func collapsedText(n *html.Node) string {
+	return strings.Join(strings.Fields(nodeText(n)), " ")
+}
+
+// truncateRunes returns the first n runes of s.
+func truncateRunes(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
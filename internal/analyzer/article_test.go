@@ -0,0 +1,95 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+const articlePage = `<!DOCTYPE html>
+<html><head>
+<meta name="author" content="Meta Author">
+<meta property="article:published_time" content="2024-05-01T08:00:00Z">
+</head><body>
+<nav><a href="/">Home</a> <a href="/news">News, sports, weather and everything else you might want</a></nav>
+<div class="sidebar"><p>Popular stories, picked for you, updated every hour of the day.</p></div>
+<div class="post-content">
+	<h1>Headline</h1>
+	<p class="byline">By <a rel="author" href="/jane">Jane Doe</a></p>
+	<p>The first paragraph of the story, which is long enough, with commas, to count as content.</p>
+	<div class="share-bar"><a href="/share">Share this story on your favourite network</a></div>
+	<p>The second paragraph continues the story, with <a href="/more">a link</a>, and more text.</p>
+	<script>track()</script>
+</div>
+<footer><p>Copyright, all rights reserved, example publishing company limited.</p></footer>
+</body></html>`
+
+func extractArticleFrom(t *testing.T, page string, maxLength int) *Article {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(page))
+	require.NoError(t, err)
+	return ExtractArticle(doc, maxLength)
+}
+
+func TestExtractArticle(t *testing.T) {
+	article := extractArticleFrom(t, articlePage, defaultArticleMaxLength)
+	require.NotNil(t, article)
+
+	assert.Equal(t, "Headline\n\nBy Jane Doe\n\n"+
+		"The first paragraph of the story, which is long enough, with commas, to count as content.\n\n"+
+		"The second paragraph continues the story, with a link, and more text.", article.Text)
+	assert.NotContains(t, article.HTML, "Share this story", "Page chrome should be removed")
+	assert.NotContains(t, article.HTML, "track()")
+	assert.NotContains(t, article.HTML, "class=", "Only content attributes should be kept")
+	assert.Contains(t, article.HTML, `<a href="/more">a link</a>`)
+	assert.Equal(t, len([]rune(article.Text)), article.Length)
+	assert.False(t, article.Truncated)
+	assert.Equal(t, "Meta Author", article.Byline)
+	assert.Equal(t, "2024-05-01T08:00:00Z", article.Published)
+}
+
+func TestExtractArticle_StructuredDataAndMarkupMetadata(t *testing.T) {
+	page := `<script type="application/ld+json">{"@type": "NewsArticle", "author": {"@type": "Person", "name": "JSON Author"}}</script>
+<article><time datetime="2023-01-02">2 January</time>
+<p>A paragraph that is long enough, and has a comma, to make this the article.</p></article>`
+
+	article := extractArticleFrom(t, page, defaultArticleMaxLength)
+	require.NotNil(t, article)
+	assert.Equal(t, "JSON Author", article.Byline)
+	assert.Equal(t, "2023-01-02", article.Published)
+}
+
+func TestExtractArticle_Truncates(t *testing.T) {
+	article := extractArticleFrom(t, articlePage, 30)
+	require.NotNil(t, article)
+	assert.True(t, article.Truncated)
+	assert.LessOrEqual(t, article.Length, 30)
+	assert.True(t, strings.HasPrefix(article.Text, "Headline\n\nBy Jane Doe"))
+	assert.True(t, strings.HasSuffix(article.HTML, "</div>"), "Truncated HTML should still be well formed")
+}
+
+func TestExtractArticle_NoContent(t *testing.T) {
+	assert.Nil(t, extractArticleFrom(t, `<nav><a href="/">Home</a></nav><p>Short.</p>`, defaultArticleMaxLength))
+}
+
+func TestArticleModule_OptInAndOptions(t *testing.T) {
+	service := NewService(WithHTTPClient(&mockHTTPClient{response: articlePage}))
+
+	analysis, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://example.com"})
+	require.NoError(t, err)
+	assert.Nil(t, analysis.Article, "The article module should only run when requested")
+
+	analysis, err = service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://example.com", Modules: []string{ModuleArticle}})
+	require.NoError(t, err)
+	assert.NotNil(t, analysis.Article)
+
+	_, err = service.AnalyzeWebpage(context.Background(), AnalysisRequest{
+		URL: "https://example.com", Modules: []string{ModuleArticle},
+		Options: map[string]ModuleOptions{ModuleArticle: {"max_length": 0}},
+	})
+	assert.Error(t, err)
+}
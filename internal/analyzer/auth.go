@@ -0,0 +1,66 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"webpage-analyzer/internal/client"
+)
+
+// redacted replaces secrets wherever credentials are printed or encoded.
+const redacted = "[redacted]"
+
+// validate checks that exactly one kind of credentials is set.
+func (a *AnalysisAuth) validate() error {
+	basic := a.Username != "" || a.Password != ""
+	switch {
+	case basic && a.Token != "":
+		return fmt.Errorf("set either username and password or token, not both")
+	case a.Token == "" && a.Username == "":
+		return fmt.Errorf("username or token is required")
+	}
+	return nil
+}
+
+// String hides the credentials from fmt verbs.
+func (a AnalysisAuth) String() string {
+	return redacted
+}
+
+// LogValue hides the credentials from structured logs.
+func (a AnalysisAuth) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
+// MarshalJSON encodes the credentials with their secrets redacted, so that
+// requests can be logged or stored without leaking them.
+func (a AnalysisAuth) MarshalJSON() ([]byte, error) {
+	type plain AnalysisAuth
+	masked := plain(a)
+	if masked.Password != "" {
+		masked.Password = redacted
+	}
+	if masked.Token != "" {
+		masked.Token = redacted
+	}
+	return json.Marshal(masked)
+}
+
+// Fingerprint returns a hash of the credentials that tells different
+// credentials apart without revealing them, e.g. for cache keys. It is empty
+// when a is nil.
+func (a *AnalysisAuth) Fingerprint() string {
+	if a == nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(a.Username + "\x00" + a.Password + "\x00" + a.Token))
+	return hex.EncodeToString(sum[:])
+}
+
+// credentials converts a to the client's credentials.
+func (a *AnalysisAuth) credentials() client.Credentials {
+	return client.Credentials{Username: a.Username, Password: a.Password, Token: a.Token}
+}
@@ -0,0 +1,114 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/worker"
+)
+
+func TestAnalysisAuth_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    AnalysisAuth
+		wantErr bool
+	}{
+		{"basic", AnalysisAuth{Username: "alice", Password: "s3cret"}, false},
+		{"basic without password", AnalysisAuth{Username: "alice"}, false},
+		{"bearer", AnalysisAuth{Token: "abc123"}, false},
+		{"both", AnalysisAuth{Username: "alice", Token: "abc123"}, true},
+		{"password only", AnalysisAuth{Password: "s3cret"}, true},
+		{"empty", AnalysisAuth{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.auth.validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAnalysisAuth_Redacted(t *testing.T) {
+	auth := &AnalysisAuth{Username: "alice", Password: "s3cret", Token: "abc123"}
+
+	encoded, err := json.Marshal(AnalysisRequest{URL: "https://example.com", Auth: auth})
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"username":"alice"`)
+	assert.NotContains(t, string(encoded), "s3cret")
+	assert.NotContains(t, string(encoded), "abc123")
+
+	assert.Equal(t, redacted, fmt.Sprint(auth))
+	assert.NotContains(t, fmt.Sprintf("%v", AnalysisRequest{Auth: auth}), "s3cret")
+
+	var logs bytes.Buffer
+	slog.New(slog.NewTextHandler(&logs, nil)).Info("Analyzing", "auth", auth)
+	assert.NotContains(t, logs.String(), "s3cret")
+	assert.Contains(t, logs.String(), redacted)
+}
+
+func TestAnalysisAuth_Fingerprint(t *testing.T) {
+	var none *AnalysisAuth
+	assert.Empty(t, none.Fingerprint())
+
+	a := &AnalysisAuth{Username: "alice", Password: "one"}
+	b := &AnalysisAuth{Username: "alice", Password: "two"}
+	assert.Equal(t, a.Fingerprint(), (&AnalysisAuth{Username: "alice", Password: "one"}).Fingerprint())
+	assert.NotEqual(t, a.Fingerprint(), b.Fingerprint())
+	assert.NotContains(t, a.Fingerprint(), "one")
+}
+
+func TestAnalyzeWebpage_ProtectedPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "alice" || pass != "s3cret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="staging"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("<html><head><title>Staging</title></head><body></body></html>"))
+	}))
+	defer server.Close()
+
+	service := NewServiceWithDependencies(client.NewHTTPClient(), parser.NewHTMLParser(), worker.NewWorkerPool(2))
+	ctx := context.Background()
+
+	_, err := service.AnalyzeWebpage(ctx, AnalysisRequest{URL: server.URL})
+	var analysisErr *AnalysisError
+	require.ErrorAs(t, err, &analysisErr)
+	assert.Equal(t, http.StatusUnauthorized, analysisErr.StatusCode)
+
+	result, err := service.AnalyzeWebpage(ctx, AnalysisRequest{
+		URL:  server.URL,
+		Auth: &AnalysisAuth{Username: "alice", Password: "s3cret"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Staging", result.PageTitle)
+}
+
+func TestAnalyzeWebpage_InvalidAuth(t *testing.T) {
+	service := NewServiceWithDependencies(&mockHTTPClient{}, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	_, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{
+		URL:  "https://example.com",
+		Auth: &AnalysisAuth{Username: "alice", Token: "abc123"},
+	})
+	var analysisErr *AnalysisError
+	require.ErrorAs(t, err, &analysisErr)
+	assert.Equal(t, http.StatusBadRequest, analysisErr.StatusCode)
+	assert.Contains(t, analysisErr.ErrorMessage, "Invalid auth")
+	assert.NotContains(t, analysisErr.ErrorMessage, "abc123")
+}
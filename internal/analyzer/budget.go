@@ -0,0 +1,83 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Server maximums for the timeouts a request may ask for.
+const (
+	// MaxFetchTimeout bounds fetch_timeout; it matches the HTTP client's own limit.
+	MaxFetchTimeout = 30 * time.Second
+	// MaxTotalTimeout bounds total_timeout.
+	MaxTotalTimeout = 2 * time.Minute
+)
+
+// budget holds the time limits of one analysis. Zero fields mean no limit.
+type budget struct {
+	fetch    time.Duration // Limit on fetching the page.
+	deadline time.Time     // End of the whole analysis.
+}
+
+// newBudget parses a request's fetch_timeout and total_timeout, counting the
+// total from start.
+func newBudget(req AnalysisRequest, start time.Time) (budget, error) {
+	fetch, err := parseTimeout("fetch_timeout", req.FetchTimeout, MaxFetchTimeout)
+	if err != nil {
+		return budget{}, err
+	}
+	total, err := parseTimeout("total_timeout", req.TotalTimeout, MaxTotalTimeout)
+	if err != nil {
+		return budget{}, err
+	}
+	b := budget{fetch: fetch}
+	if total > 0 {
+		b.deadline = start.Add(total)
+	}
+	return b, nil
+}
+
+// parseTimeout parses a Go duration between zero and max. Empty means zero.
+func parseTimeout(name, value string, max time.Duration) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", name, err)
+	}
+	if d <= 0 || d > max {
+		return 0, fmt.Errorf("%s must be positive and at most %s", name, max)
+	}
+	return d, nil
+}
+
+// Budget returns how long an analysis of the request may take by its own
+// timeouts: its total_timeout, or its fetch_timeout when it only sets that.
+// It is zero when the request sets neither, or sets them invalidly.
+func (r AnalysisRequest) Budget() time.Duration {
+	b, err := newBudget(r, time.Time{})
+	if err != nil {
+		return 0
+	}
+	if !b.deadline.IsZero() {
+		return b.deadline.Sub(time.Time{})
+	}
+	return b.fetch
+}
+
+// fetchContext returns the context to fetch the page with, which ends at the
+// fetch timeout or the total deadline, whichever comes first.
+func (b budget) fetchContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline := b.deadline
+	if b.fetch > 0 {
+		if fetchDeadline := time.Now().Add(b.fetch); deadline.IsZero() || fetchDeadline.Before(deadline) {
+			deadline = fetchDeadline
+		}
+	}
+	if deadline.IsZero() {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, deadline)
+}
@@ -0,0 +1,146 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/worker"
+)
+
+func TestNewBudget(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	b, err := newBudget(AnalysisRequest{FetchTimeout: "5s", TotalTimeout: "1m"}, start)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, b.fetch)
+	assert.Equal(t, start.Add(time.Minute), b.deadline)
+
+	b, err = newBudget(AnalysisRequest{}, start)
+	require.NoError(t, err)
+	assert.Zero(t, b.fetch, "No fetch_timeout should mean no limit")
+	assert.True(t, b.deadline.IsZero(), "No total_timeout should mean no deadline")
+
+	for _, req := range []AnalysisRequest{
+		{FetchTimeout: "soon"},
+		{FetchTimeout: "0s"},
+		{FetchTimeout: "31s"},
+		{TotalTimeout: "-1s"},
+		{TotalTimeout: "3m"},
+	} {
+		_, err := newBudget(req, start)
+		assert.Error(t, err, "%+v should be rejected", req)
+	}
+}
+
+func TestAnalysisRequestBudget(t *testing.T) {
+	assert.Equal(t, time.Minute, AnalysisRequest{FetchTimeout: "5s", TotalTimeout: "1m"}.Budget())
+	assert.Equal(t, 20*time.Second, AnalysisRequest{FetchTimeout: "20s"}.Budget(), "Without a total, the fetch is the budget")
+	assert.Zero(t, AnalysisRequest{}.Budget())
+	assert.Zero(t, AnalysisRequest{TotalTimeout: "3m"}.Budget(), "Invalid timeouts give no budget")
+}
+
+func TestAnalyzeWebpage_FetchTimeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer slow.Close()
+	service := NewServiceWithDependencies(client.NewHTTPClient(), parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	start := time.Now()
+	result, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: slow.URL, FetchTimeout: "100ms"})
+
+	assert.Nil(t, result)
+	var analysisErr *AnalysisError
+	require.ErrorAs(t, err, &analysisErr)
+	assert.Equal(t, client.CodeTimeout, analysisErr.Code)
+	assert.Less(t, time.Since(start), 2*time.Second, "The fetch should stop at fetch_timeout")
+}
+
+func TestAnalyzeWebpage_TotalTimeoutReturnsPartialResult(t *testing.T) {
+	htmlParser := parser.NewHTMLParser()
+	mockClient := &mockHTTPClient{response: `<html><head><title>Test</title></head></html>`}
+	registry := NewDefaultRegistry(htmlParser, mockClient)
+	require.NoError(t, registry.Register(NewModule("slow", func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return ModuleResultFunc(func(a *WebpageAnalysis) { a.HTMLVersion = "slow" }), nil
+		}
+	})))
+	service := NewServiceWithRegistry(mockClient, htmlParser, worker.NewWorkerPool(2), registry)
+
+	start := time.Now()
+	result, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{
+		URL:          "https://example.com",
+		Modules:      []string{ModulePageTitle, "slow"},
+		TotalTimeout: "200ms",
+	})
+
+	require.NoError(t, err, "An expired budget should not fail the analysis")
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.Equal(t, "Test", result.PageTitle, "Finished modules should be returned")
+	assert.Equal(t, []string{ModulePageTitle}, result.Modules)
+	assert.Equal(t, []Warning{{Module: "slow", Code: WarningModuleTimeout, Message: "Module did not finish within total_timeout"}}, result.Warnings)
+}
+
+func TestAnalyzeWebpage_FailedModulesReturnWarnings(t *testing.T) {
+	htmlParser := parser.NewHTMLParser()
+	mockClient := &mockHTTPClient{response: `<html><head><title>Test</title></head></html>`}
+	registry := NewDefaultRegistry(htmlParser, mockClient)
+	require.NoError(t, registry.Register(NewModule("broken", func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		return nil, errors.New("lookup failed")
+	})))
+	require.NoError(t, registry.Register(NewModule("crashes", func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		panic("boom")
+	})))
+	require.NoError(t, registry.Register(NewModule("times_out", func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		return nil, fmt.Errorf("query upstream: %w", context.DeadlineExceeded)
+	})))
+	service := NewServiceWithRegistry(mockClient, htmlParser, worker.NewWorkerPool(2), registry)
+
+	result, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{
+		URL:     "https://example.com",
+		Modules: []string{ModulePageTitle, "broken", "crashes", "times_out"},
+	})
+
+	require.NoError(t, err, "Failing modules should not fail the analysis")
+	assert.Equal(t, "Test", result.PageTitle)
+	assert.Equal(t, []string{ModulePageTitle}, result.Modules)
+	assert.Equal(t, []Warning{
+		{Module: "broken", Code: WarningModuleFailed, Message: "lookup failed"},
+		{Module: "crashes", Code: WarningModuleFailed, Message: "Module crashed"},
+		{Module: "times_out", Code: WarningModuleTimeout, Message: "query upstream: context deadline exceeded"},
+	}, result.Warnings)
+}
+
+func TestAnalyzeWebpage_CancelledWithinBudget(t *testing.T) {
+	htmlParser := parser.NewHTMLParser()
+	mockClient := &mockHTTPClient{response: `<html></html>`}
+	registry := NewRegistry(NewModule("slow", func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}))
+	service := NewServiceWithRegistry(mockClient, htmlParser, worker.NewWorkerPool(2), registry)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	result, err := service.AnalyzeWebpage(ctx, AnalysisRequest{URL: "https://example.com", TotalTimeout: "10s"})
+
+	assert.Nil(t, result, "A caller giving up is not a partial result")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,222 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/urlnorm"
+)
+
+// Options accepted by the canonical module.
+const (
+	canonicalOptionTimeout = "timeout" // duration: timeout for requesting a canonical URL other than the page's.
+
+	defaultCanonicalTimeout = 5 * time.Second
+)
+
+// Where a canonical URL was declared, reported in Canonical.Source.
+const (
+	CanonicalSourceLink   = "link"   // A <link rel="canonical"> element.
+	CanonicalSourceHeader = "header" // A Link response header with rel="canonical".
+)
+
+// canonicalNormalization is how URLs are normalized before comparing them, so
+// that only differences search engines care about are reported.
+var canonicalNormalization = urlnorm.Options{StripTracking: true, TrailingSlash: urlnorm.StripTrailingSlash}
+
+// canonicalModule compares the page's canonical URL with the URL it was
+// fetched from and, when they differ, checks that the canonical URL resolves.
+type canonicalModule struct {
+	htmlParser parser.HTMLParser
+	httpClient client.HTTPClient
+}
+
+func (m *canonicalModule) Name() string { return ModuleCanonical }
+
+// ValidateOptions implements OptionsValidator.
+func (m *canonicalModule) ValidateOptions(opts ModuleOptions) error {
+	_, err := m.parseOptions(opts)
+	return err
+}
+
+// parseOptions reads the timeout option.
+func (m *canonicalModule) parseOptions(opts ModuleOptions) (time.Duration, error) {
+	for key := range opts {
+		if key != canonicalOptionTimeout {
+			return 0, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	timeout, err := opts.Duration(canonicalOptionTimeout, defaultCanonicalTimeout)
+	if err != nil {
+		return 0, err
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("option %q must be positive", canonicalOptionTimeout)
+	}
+	return timeout, nil
+}
+
+// Analyze finds the canonical URL and checks it.
+func (m *canonicalModule) Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error) {
+	timeout, err := m.parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	fetched := firstNonEmpty(info.FinalURL, info.URL)
+	canonical := FindCanonical(fetched, pageFrom(ctx, doc, m.htmlParser).Elements("link"), info.Header)
+	var findings []Finding
+	if canonical != nil {
+		m.resolve(ctx, canonical, timeout)
+		findings = canonicalFindings(canonical)
+	}
+	return ModuleResultFunc(func(a *WebpageAnalysis) {
+		a.Canonical = canonical
+		a.Findings = append(a.Findings, findings...)
+	}), nil
+}
+
+// resolve requests a canonical URL other than the page's and records whether
+// it answers without an error status. The request counts against the
+// analysis' sub-request budget and, when the client can, only asks for the
+// status rather than downloading the page again.
+func (m *canonicalModule) resolve(ctx context.Context, canonical *Canonical, timeout time.Duration) {
+	if len(canonical.Differences) == 0 {
+		canonical.Resolves = true // The canonical URL is the page itself.
+		return
+	}
+	ctx, cancel, err := subrequestsFrom(ctx).one(ctx, timeout)
+	if err != nil {
+		canonical.Unchecked = true
+		return
+	}
+	defer cancel()
+
+	var statusCode int
+	if checker, ok := m.httpClient.(client.StatusChecker); ok {
+		statusCode, err = checker.CheckStatus(ctx, canonical.URL)
+	} else {
+		_, statusCode, err = m.httpClient.FetchWebpage(ctx, canonical.URL)
+	}
+	canonical.StatusCode = statusCode
+	canonical.Resolves = err == nil && statusCode < http.StatusBadRequest
+}
+
+// FindCanonical returns the canonical URL declared by the page's first
+// <link rel="canonical"> element, or failing that its Link header, resolved
+// against fetchedURL, and how it differs from fetchedURL once both are
+// normalized: differences in letter case, default ports, trailing slashes,
+// fragments and tracking parameters do not count. It returns nil when the
+// page declares no canonical URL.
+func FindCanonical(fetchedURL string, links []parser.Element, header http.Header) *Canonical {
+	fetched, err := url.Parse(fetchedURL)
+	if err != nil {
+		return nil
+	}
+
+	href, source := "", ""
+	for _, el := range links {
+		if hasRel(el.Attr("rel"), "canonical") && strings.TrimSpace(el.Attr("href")) != "" {
+			href, source = strings.TrimSpace(el.Attr("href")), CanonicalSourceLink
+			break
+		}
+	}
+	if href == "" {
+		if href = linkHeaderTarget(header.Values("Link"), "canonical"); href != "" {
+			source = CanonicalSourceHeader
+		}
+	}
+	if href == "" {
+		return nil
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return nil
+	}
+	resolved := fetched.ResolveReference(ref)
+
+	canonical := &Canonical{URL: resolved.String(), Source: source, FetchedURL: fetchedURL}
+	want, err := url.Parse(urlnorm.Normalize(resolved.String(), canonicalNormalization))
+	if err != nil {
+		return canonical
+	}
+	got, err := url.Parse(urlnorm.Normalize(fetchedURL, canonicalNormalization))
+	if err != nil {
+		return canonical
+	}
+	if want.Scheme != got.Scheme {
+		canonical.Differences = append(canonical.Differences, CanonicalDiffScheme)
+	}
+	if want.Host != got.Host {
+		canonical.Differences = append(canonical.Differences, CanonicalDiffHost)
+	}
+	if want.EscapedPath() != got.EscapedPath() {
+		canonical.Differences = append(canonical.Differences, CanonicalDiffPath)
+	}
+	if want.RawQuery != got.RawQuery {
+		canonical.Differences = append(canonical.Differences, CanonicalDiffQuery)
+	}
+	return canonical
+}
+
+// hasRel reports whether a space-separated rel attribute holds value.
+func hasRel(rel, value string) bool {
+	for _, token := range strings.Fields(rel) {
+		if strings.EqualFold(token, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// linkHeaderTarget returns the target of the first link with the given rel
+// in Link header values such as `<https://example.com/>; rel="canonical"`.
+func linkHeaderTarget(values []string, rel string) string {
+	for _, value := range values {
+		for _, link := range strings.Split(value, ",") {
+			target, params, _ := strings.Cut(strings.TrimSpace(link), ";")
+			target = strings.TrimSpace(target)
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range strings.Split(params, ";") {
+				name, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(strings.TrimSpace(name), "rel") && hasRel(strings.Trim(strings.TrimSpace(val), `"`), rel) {
+					return target[1 : len(target)-1]
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// canonicalFindings reports a canonical URL that differs from the fetched URL,
+// rated low, or medium when it does not resolve, since search engines are
+// then pointed at a page they cannot index.
+func canonicalFindings(canonical *Canonical) []Finding {
+	if len(canonical.Differences) == 0 {
+		return nil
+	}
+	finding := Finding{
+		Type:     FindingCanonicalMismatch,
+		Severity: SeverityLow,
+		Message:  fmt.Sprintf("Canonical URL differs from the fetched URL in its %s", strings.Join(canonical.Differences, ", ")),
+		Evidence: canonical.URL,
+	}
+	if !canonical.Resolves && !canonical.Unchecked {
+		finding.Severity = SeverityMedium
+		finding.Message = fmt.Sprintf("Canonical URL differs from the fetched URL in its %s and does not resolve", strings.Join(canonical.Differences, ", "))
+		if canonical.StatusCode != 0 {
+			finding.Message += fmt.Sprintf(" (HTTP %d)", canonical.StatusCode)
+		}
+	}
+	return []Finding{finding}
+}
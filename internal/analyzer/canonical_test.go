@@ -0,0 +1,127 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/parser"
+)
+
+func TestFindCanonical(t *testing.T) {
+	tests := []struct {
+		name        string
+		fetched     string
+		href        string
+		differences []string
+	}{
+		{"same URL", "https://example.com/a", "https://example.com/a", nil},
+		{"relative", "https://example.com/a", "/a", nil},
+		{"trivial differences", "https://Example.com:443/a/?utm_source=x#top", "https://example.com/a", nil},
+		{"http vs https", "https://example.com/a", "http://example.com/a", []string{CanonicalDiffScheme}},
+		{"other host", "https://example.com/a", "https://www.example.com/a", []string{CanonicalDiffHost}},
+		{"other path", "https://example.com/a?id=1", "https://example.com/b?id=1", []string{CanonicalDiffPath}},
+		{"other query", "https://example.com/a?id=1", "https://example.com/a", []string{CanonicalDiffQuery}},
+		{"several", "http://m.example.com/a", "https://example.com/b", []string{CanonicalDiffScheme, CanonicalDiffHost, CanonicalDiffPath}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links := []parser.Element{{Tag: "link", Attrs: map[string]string{"rel": "Canonical", "href": tt.href}}}
+			canonical := FindCanonical(tt.fetched, links, nil)
+			require.NotNil(t, canonical)
+			assert.Equal(t, CanonicalSourceLink, canonical.Source)
+			assert.Equal(t, tt.differences, canonical.Differences)
+		})
+	}
+}
+
+func TestFindCanonical_Sources(t *testing.T) {
+	header := http.Header{"Link": []string{`<https://cdn.example/style.css>; rel=preload; as=style, </docs/>; rel="canonical"`}}
+	canonical := FindCanonical("https://example.com/docs/?page=1", nil, header)
+	require.NotNil(t, canonical)
+	assert.Equal(t, &Canonical{URL: "https://example.com/docs/", Source: CanonicalSourceHeader, FetchedURL: "https://example.com/docs/?page=1", Differences: []string{CanonicalDiffQuery}}, canonical)
+
+	links := []parser.Element{
+		{Tag: "link", Attrs: map[string]string{"rel": "stylesheet", "href": "/style.css"}},
+		{Tag: "link", Attrs: map[string]string{"rel": "canonical", "href": "/docs"}},
+	}
+	assert.Equal(t, CanonicalSourceLink, FindCanonical("https://example.com/docs/", links, header).Source, "The element should win over the header")
+
+	assert.Nil(t, FindCanonical("https://example.com/", links[:1], http.Header{}))
+}
+
+func TestCanonicalModule(t *testing.T) {
+	mockClient := &urlMockHTTPClient{responses: map[string]string{
+		"https://example.com":        `<link rel="canonical" href="https://example.com/">`,
+		"https://example.com/a":      `<link rel="canonical" href="https://www.example.com/a">`,
+		"https://www.example.com/a":  `<p>The canonical page</p>`,
+		"https://example.com/broken": `<link rel="canonical" href="/missing">`,
+	}}
+	service := NewService(WithHTTPClient(mockClient))
+	analyze := func(pageURL string) *WebpageAnalysis {
+		analysis, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: pageURL, Modules: []string{ModuleCanonical}})
+		require.NoError(t, err)
+		return analysis
+	}
+
+	analysis := analyze("https://example.com")
+	require.NotNil(t, analysis.Canonical)
+	assert.True(t, analysis.Canonical.Resolves)
+	assert.Empty(t, analysis.Findings)
+
+	analysis = analyze("https://example.com/a")
+	assert.Equal(t, &Canonical{URL: "https://www.example.com/a", Source: CanonicalSourceLink, FetchedURL: "https://example.com/a", Differences: []string{CanonicalDiffHost}, Resolves: true, StatusCode: http.StatusOK}, analysis.Canonical)
+	require.Len(t, analysis.Findings, 1)
+	assert.Equal(t, FindingCanonicalMismatch, analysis.Findings[0].Type)
+	assert.Equal(t, SeverityLow, analysis.Findings[0].Severity)
+
+	analysis = analyze("https://example.com/broken")
+	assert.False(t, analysis.Canonical.Resolves)
+	require.Len(t, analysis.Findings, 1)
+	assert.Equal(t, SeverityMedium, analysis.Findings[0].Severity)
+	assert.Equal(t, "Canonical URL differs from the fetched URL in its path and does not resolve (HTTP 404)", analysis.Findings[0].Message)
+}
+
+// statusMockHTTPClient answers CheckStatus, recording the URLs asked about.
+type statusMockHTTPClient struct {
+	urlMockHTTPClient
+	checked []string
+}
+
+func (m *statusMockHTTPClient) CheckStatus(ctx context.Context, url string) (int, error) {
+	m.checked = append(m.checked, url)
+	return http.StatusMovedPermanently, nil
+}
+
+func TestCanonicalModule_Resolve(t *testing.T) {
+	mockClient := &statusMockHTTPClient{}
+	module := &canonicalModule{httpClient: mockClient}
+	differs := func() *Canonical {
+		return &Canonical{URL: "https://www.example.com/a", Differences: []string{CanonicalDiffHost}}
+	}
+
+	canonical := &Canonical{URL: "https://example.com/a"}
+	module.resolve(context.Background(), canonical, time.Second)
+	assert.True(t, canonical.Resolves)
+	assert.Empty(t, mockClient.checked, "The page itself should not be requested again")
+
+	canonical = differs()
+	module.resolve(context.Background(), canonical, time.Second)
+	assert.Equal(t, []string{"https://www.example.com/a"}, mockClient.checked, "Only the status should be requested")
+	assert.True(t, canonical.Resolves)
+	assert.Equal(t, http.StatusMovedPermanently, canonical.StatusCode)
+
+	ctx := withSubrequests(context.Background(), nil, time.Now())
+	subrequestsFrom(ctx).take(MaxSubrequests)
+	canonical = differs()
+	module.resolve(ctx, canonical, time.Second)
+	assert.True(t, canonical.Unchecked)
+	assert.Len(t, mockClient.checked, 1, "No request should be sent without budget")
+	findings := canonicalFindings(canonical)
+	require.Len(t, findings, 1)
+	assert.Equal(t, SeverityLow, findings[0].Severity, "An unchecked canonical URL should not be reported as broken")
+}
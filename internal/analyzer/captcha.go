@@ -0,0 +1,94 @@
+package analyzer
+
+import (
+	"net/url"
+	"strings"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// CAPTCHA providers reported in Captcha.Provider.
+const (
+	CaptchaReCAPTCHA = "recaptcha"
+	CaptchaHCaptcha  = "hcaptcha"
+	CaptchaTurnstile = "turnstile"
+)
+
+// DetectCaptchas identifies CAPTCHA providers from a page's script, iframe and
+// widget elements. Each provider is reported once, with the most specific
+// version seen; the version is empty when only a generic widget was found.
+func DetectCaptchas(elements []parser.Element) []Captcha {
+	var captchas []Captcha
+	found := make(map[string]int) // provider -> index in captchas.
+	add := func(provider, version string) {
+		if i, ok := found[provider]; ok {
+			if captchas[i].Version == "" {
+				captchas[i].Version = version
+			}
+			return
+		}
+		found[provider] = len(captchas)
+		captchas = append(captchas, Captcha{Provider: provider, Version: version})
+	}
+
+	for _, el := range elements {
+		switch el.Tag {
+		case "script", "iframe":
+			if provider, version := captchaFromURL(el.Attr("src")); provider != "" {
+				add(provider, version)
+			}
+		default:
+			classes := strings.Fields(el.Attr("class"))
+			for _, class := range classes {
+				switch class {
+				case "g-recaptcha":
+					version := "v2"
+					if el.Attr("data-size") == "invisible" {
+						version = "v2-invisible"
+					}
+					add(CaptchaReCAPTCHA, version)
+				case "h-captcha":
+					add(CaptchaHCaptcha, "")
+				case "cf-turnstile":
+					add(CaptchaTurnstile, "")
+				}
+			}
+		}
+	}
+	return captchas
+}
+
+// captchaFromURL recognizes CAPTCHA provider scripts and frames.
+func captchaFromURL(src string) (provider, version string) {
+	u, err := url.Parse(strings.TrimSpace(src))
+	if err != nil {
+		return "", ""
+	}
+	host := strings.ToLower(u.Hostname())
+	path := strings.ToLower(u.Path)
+
+	switch {
+	case (host == "www.google.com" || host == "google.com" || host == "www.recaptcha.net" || host == "recaptcha.net") &&
+		strings.HasPrefix(path, "/recaptcha/"):
+		switch {
+		case strings.Contains(path, "/enterprise"):
+			return CaptchaReCAPTCHA, "enterprise"
+		case strings.HasPrefix(path, "/recaptcha/api2/"):
+			return CaptchaReCAPTCHA, "v2"
+		}
+		// api.js?render=<site key> loads v3; render=explicit or no render is v2.
+		if render := u.Query().Get("render"); render != "" && render != "explicit" && render != "onload" {
+			return CaptchaReCAPTCHA, "v3"
+		}
+		return CaptchaReCAPTCHA, "v2"
+	case host == "hcaptcha.com" || strings.HasSuffix(host, ".hcaptcha.com"):
+		return CaptchaHCaptcha, ""
+	case host == "challenges.cloudflare.com" && strings.HasPrefix(path, "/turnstile/"):
+		// Paths look like /turnstile/v0/api.js.
+		if parts := strings.Split(strings.TrimPrefix(path, "/turnstile/"), "/"); strings.HasPrefix(parts[0], "v") {
+			return CaptchaTurnstile, parts[0]
+		}
+		return CaptchaTurnstile, ""
+	}
+	return "", ""
+}
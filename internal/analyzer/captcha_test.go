@@ -0,0 +1,52 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+func detectCaptchasIn(t *testing.T, page string) []Captcha {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(page))
+	require.NoError(t, err)
+	return DetectCaptchas(parser.NewHTMLParser().ExtractElements(doc, "script", "iframe", "div"))
+}
+
+func TestDetectCaptchas(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want []Captcha
+	}{
+		{"none", `<script src="/app.js"></script><div class="captcha-free"></div>`, nil},
+		{"recaptcha v2 widget", `<script src="https://www.google.com/recaptcha/api.js" async defer></script>
+			<div class="g-recaptcha" data-sitekey="key"></div>`,
+			[]Captcha{{Provider: CaptchaReCAPTCHA, Version: "v2"}}},
+		{"recaptcha v3", `<script src="https://www.google.com/recaptcha/api.js?render=6LcKey"></script>`,
+			[]Captcha{{Provider: CaptchaReCAPTCHA, Version: "v3"}}},
+		{"recaptcha enterprise", `<script src="https://www.recaptcha.net/recaptcha/enterprise.js"></script>`,
+			[]Captcha{{Provider: CaptchaReCAPTCHA, Version: "enterprise"}}},
+		{"invisible widget only", `<div class="g-recaptcha" data-size="invisible"></div>`,
+			[]Captcha{{Provider: CaptchaReCAPTCHA, Version: "v2-invisible"}}},
+		{"hcaptcha", `<script src="https://js.hcaptcha.com/1/api.js"></script><div class="h-captcha"></div>`,
+			[]Captcha{{Provider: CaptchaHCaptcha}}},
+		{"turnstile widget then script", `<div class="cf-turnstile"></div>
+			<script src="https://challenges.cloudflare.com/turnstile/v0/api.js"></script>`,
+			[]Captcha{{Provider: CaptchaTurnstile, Version: "v0"}}},
+		{"several providers", `<iframe src="https://www.google.com/recaptcha/api2/anchor?k=key"></iframe>
+			<div class="h-captcha"></div>`,
+			[]Captcha{{Provider: CaptchaReCAPTCHA, Version: "v2"}, {Provider: CaptchaHCaptcha}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectCaptchasIn(t, tt.page))
+		})
+	}
+}
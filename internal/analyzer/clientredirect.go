@@ -0,0 +1,153 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// Client redirect types.
+const (
+	RedirectMetaRefresh = "meta_refresh"
+	RedirectJavaScript  = "javascript"
+)
+
+var (
+	// locationAssignPattern matches a string literal assigned to the page's
+	// location, e.g. window.location.href = "/new".
+	locationAssignPattern = regexp.MustCompile(`(?:\b(?:window|document|top|self)\.)?\blocation(?:\.href)?\s*=\s*(["'])([^"'\n]+)["']`)
+	// locationCallPattern matches location.replace and location.assign called
+	// with a string literal.
+	locationCallPattern = regexp.MustCompile(`\blocation\.(?:replace|assign)\(\s*(["'])([^"'\n]+)["']\s*\)`)
+)
+
+// clientRedirectModule reports redirects the page performs once loaded.
+func clientRedirectModule(htmlParser parser.HTMLParser) AnalyzerModule {
+	return NewModule(ModuleClientRedirect, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		redirects := DetectClientRedirects(info.URL, pageFrom(ctx, doc, htmlParser).Elements("meta", "script"))
+		findings := clientRedirectFindings(redirects)
+		return ModuleResultFunc(func(a *WebpageAnalysis) {
+			a.ClientRedirects = redirects
+			a.Findings = append(a.Findings, findings...)
+		}), nil
+	})
+}
+
+// DetectClientRedirects finds <meta http-equiv="refresh"> tags with a URL and
+// inline scripts that assign a literal URL to the page's location, in
+// document order. Targets are resolved against pageURL. Scripts that build
+// the URL at run time are not detected.
+func DetectClientRedirects(pageURL string, elements []parser.Element) []ClientRedirect {
+	base, _ := url.Parse(pageURL)
+	var redirects []ClientRedirect
+	for _, el := range elements {
+		switch el.Tag {
+		case "meta":
+			if !strings.EqualFold(strings.TrimSpace(el.Attr("http-equiv")), "refresh") {
+				continue
+			}
+			delay, target, ok := parseMetaRefresh(el.Attr("content"))
+			if !ok {
+				continue
+			}
+			if resolved, ok := resolveRedirect(base, target); ok {
+				redirects = append(redirects, ClientRedirect{Type: RedirectMetaRefresh, Target: resolved, Delay: delay})
+			}
+		case "script":
+			if el.Attr("src") != "" {
+				continue
+			}
+			for _, target := range scriptRedirectTargets(el.Text()) {
+				if resolved, ok := resolveRedirect(base, target); ok {
+					redirects = append(redirects, ClientRedirect{Type: RedirectJavaScript, Target: resolved})
+				}
+			}
+		}
+	}
+	return redirects
+}
+
+// parseMetaRefresh parses a refresh content value such as "5; url=/next". A
+// value without a URL only reloads the page and is not reported.
+func parseMetaRefresh(content string) (delay int, target string, ok bool) {
+	content = strings.TrimSpace(content)
+	end := strings.IndexFunc(content, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if end < 0 {
+		end = len(content)
+	}
+	if end == 0 {
+		return 0, "", false // Browsers ignore a value that does not start with the delay.
+	}
+	// Fractions are allowed but browsers ignore them.
+	if seconds, _, _ := strings.Cut(content[:end], "."); seconds != "" {
+		n, err := strconv.Atoi(seconds)
+		if err != nil {
+			return 0, "", false
+		}
+		delay = n
+	}
+	rest := strings.TrimLeft(content[end:], " \t\n\r;,")
+	if len(rest) >= 3 && strings.EqualFold(rest[:3], "url") {
+		if after := strings.TrimLeft(rest[3:], " \t\n\r"); strings.HasPrefix(after, "=") {
+			rest = strings.TrimLeft(after[1:], " \t\n\r")
+		}
+	}
+	if len(rest) > 0 && (rest[0] == '"' || rest[0] == '\'') {
+		if i := strings.IndexByte(rest[1:], rest[0]); i >= 0 {
+			rest = rest[1 : i+1]
+		} else {
+			rest = rest[1:]
+		}
+	}
+	rest = strings.TrimSpace(rest)
+	return delay, rest, rest != ""
+}
+
+// scriptRedirectTargets returns the literal URLs script assigns to the page's
+// location.
+func scriptRedirectTargets(script string) []string {
+	var targets []string
+	for _, pattern := range []*regexp.Regexp{locationAssignPattern, locationCallPattern} {
+		for _, match := range pattern.FindAllStringSubmatch(script, -1) {
+			targets = append(targets, match[2])
+		}
+	}
+	return targets
+}
+
+// resolveRedirect resolves target against base. Fragment-only and
+// javascript: targets do not leave the page and are rejected.
+func resolveRedirect(base *url.URL, target string) (string, bool) {
+	if strings.HasPrefix(target, "#") || strings.HasPrefix(strings.ToLower(target), "javascript:") {
+		return "", false
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", false
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	return u.String(), true
+}
+
+// clientRedirectFindings reports each client redirect, since the analysis
+// describes a page visitors do not stay on.
+func clientRedirectFindings(redirects []ClientRedirect) []Finding {
+	var findings []Finding
+	for _, r := range redirects {
+		message := "Page redirects from a script"
+		if r.Type == RedirectMetaRefresh {
+			message = fmt.Sprintf("Page redirects with a meta refresh after %d seconds", r.Delay)
+		}
+		findings = append(findings, Finding{Type: FindingClientRedirect, Severity: SeverityLow, Message: message, Evidence: r.Target})
+	}
+	return findings
+}
@@ -0,0 +1,65 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+func TestDetectClientRedirects(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want []ClientRedirect
+	}{
+		{"none", `<meta charset="utf-8"><script>console.log(location.href)</script>`, nil},
+		{"meta refresh", `<meta http-equiv="refresh" content="0; url=https://other.example/">`,
+			[]ClientRedirect{{Type: RedirectMetaRefresh, Target: "https://other.example/"}}},
+		{"meta refresh relative and quoted", `<meta http-equiv="Refresh" content="5;URL='/new?a=1'">`,
+			[]ClientRedirect{{Type: RedirectMetaRefresh, Target: "https://example.com/new?a=1", Delay: 5}}},
+		{"meta refresh without url key", `<meta http-equiv="refresh" content="3.5, next.html">`,
+			[]ClientRedirect{{Type: RedirectMetaRefresh, Target: "https://example.com/blog/next.html", Delay: 3}}},
+		{"reload only", `<meta http-equiv="refresh" content="30">`, nil},
+		{"invalid delay", `<meta http-equiv="refresh" content="url=/x">`, nil},
+		{"script assignments", `<script>
+			if (!window.ok) { window.location.href = "https://other.example/a"; }
+			location.replace('/b');
+		</script>`,
+			[]ClientRedirect{
+				{Type: RedirectJavaScript, Target: "https://other.example/a"},
+				{Type: RedirectJavaScript, Target: "https://example.com/b"},
+			}},
+		{"comparisons and fragments", `<script>
+			if (location.href == "https://example.com/") {}
+			location.hash = "#top";
+			window.location = "#top";
+		</script>`, nil},
+		{"external script", `<script src="/r.js">location.href = "/x"</script>`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.page))
+			require.NoError(t, err)
+			got := DetectClientRedirects("https://example.com/blog/post", parser.NewHTMLParser().ExtractElements(doc, "meta", "script"))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClientRedirectModule(t *testing.T) {
+	page := `<html><head><meta http-equiv="refresh" content="0; url=https://other.example/"></head><body>Moved</body></html>`
+	service := NewService(WithHTTPClient(&mockHTTPClient{response: page}))
+
+	analysis, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://example.com", Modules: []string{ModuleClientRedirect}})
+	require.NoError(t, err)
+	assert.Equal(t, []ClientRedirect{{Type: RedirectMetaRefresh, Target: "https://other.example/"}}, analysis.ClientRedirects)
+	require.Len(t, analysis.Findings, 1)
+	assert.Equal(t, FindingClientRedirect, analysis.Findings[0].Type)
+	assert.Equal(t, "https://other.example/", analysis.Findings[0].Evidence)
+}
@@ -0,0 +1,332 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"webpage-analyzer/internal/logging"
+)
+
+// CompareWebpages analyzes two webpages concurrently and returns a structured diff.
+func (s *service) CompareWebpages(ctx context.Context, req CompareRequest) (*WebpageComparison, error) {
+	startTime := time.Now()
+	logging.FromContext(ctx).Info("Starting webpage comparison", "url_a", req.URLA, "url_b", req.URLB)
+
+	var (
+		wg         sync.WaitGroup
+		resA, resB *WebpageAnalysis
+		errA, errB error
+	)
+
+	// The two analyses run on their own goroutines rather than the worker pool,
+	// because each analysis already fans its extraction tasks out to the pool.
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		resA, errA = s.AnalyzeWebpage(ctx, AnalysisRequest{URL: req.URLA})
+	}()
+	go func() {
+		defer wg.Done()
+		resB, errB = s.AnalyzeWebpage(ctx, AnalysisRequest{URL: req.URLB})
+	}()
+	wg.Wait()
+
+	if errA != nil {
+		logging.FromContext(ctx).Error("Comparison failed analyzing first URL", "url", req.URLA, "error", errA)
+		return nil, errA
+	}
+	if errB != nil {
+		logging.FromContext(ctx).Error("Comparison failed analyzing second URL", "url", req.URLB, "error", errB)
+		return nil, errB
+	}
+
+	comparison := &WebpageComparison{
+		A:              resA,
+		B:              resB,
+		Differences:    DiffAnalyses(resA, resB),
+		ProcessingTime: time.Since(startTime).String(),
+	}
+	logging.FromContext(ctx).Info("Comparison completed",
+		"url_a", req.URLA,
+		"url_b", req.URLB,
+		"identical", comparison.Differences.Identical,
+		"processing_time", comparison.ProcessingTime,
+	)
+
+	return comparison, nil
+}
+
+// DiffAnalyses computes the differences of b relative to a.
+func DiffAnalyses(a, b *WebpageAnalysis) ComparisonDiff {
+	diff := ComparisonDiff{
+		TitleChanged:           a.PageTitle != b.PageTitle,
+		HTMLVersionChanged:     a.HTMLVersion != b.HTMLVersion,
+		Headings:               diffHeadings(a.Headings, b.Headings),
+		InternalLinksDelta:     b.InternalLinks - a.InternalLinks,
+		ExternalLinksDelta:     b.ExternalLinks - a.ExternalLinks,
+		InaccessibleLinksDelta: b.InaccessibleLinks - a.InaccessibleLinks,
+		LoginFormChanged:       a.HasLoginForm != b.HasLoginForm,
+		ContentChanged:         a.ContentHash != "" && b.ContentHash != "" && a.ContentHash != b.ContentHash,
+		Meta:                   diffMeta(a, b),
+	}
+
+	diff.Identical = !diff.TitleChanged &&
+		!diff.HTMLVersionChanged &&
+		len(diff.Headings) == 0 &&
+		diff.InternalLinksDelta == 0 &&
+		diff.ExternalLinksDelta == 0 &&
+		diff.InaccessibleLinksDelta == 0 &&
+		!diff.LoginFormChanged &&
+		!diff.ContentChanged &&
+		diff.Meta == nil
+	diff.Summary = summarizeDiff(a, b, diff)
+
+	return diff
+}
+
+// summarizeDiff renders the notable differences as short human-readable lines.
+func summarizeDiff(a, b *WebpageAnalysis, diff ComparisonDiff) []string {
+	var summary []string
+
+	if diff.TitleChanged {
+		summary = append(summary, fmt.Sprintf("title changed from %q to %q", a.PageTitle, b.PageTitle))
+	}
+	if diff.HTMLVersionChanged {
+		summary = append(summary, fmt.Sprintf("HTML version changed from %s to %s", a.HTMLVersion, b.HTMLVersion))
+	}
+	if diff.Meta != nil {
+		if c := diff.Meta.Description; c != nil {
+			summary = append(summary, fmt.Sprintf("description changed from %q to %q", c.Before, c.After))
+		}
+		if c := diff.Meta.Canonical; c != nil {
+			summary = append(summary, fmt.Sprintf("canonical URL changed from %q to %q", c.Before, c.After))
+		}
+		if c := diff.Meta.Robots; c != nil {
+			summary = append(summary, fmt.Sprintf("robots directives changed from %q to %q", c.Before, c.After))
+		}
+	}
+
+	levels := make([]string, 0, len(diff.Headings))
+	for level := range diff.Headings {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+	for _, level := range levels {
+		summary = append(summary, fmt.Sprintf("%+d %s headings", diff.Headings[level], level))
+	}
+
+	if diff.InternalLinksDelta != 0 {
+		summary = append(summary, fmt.Sprintf("%+d internal links", diff.InternalLinksDelta))
+	}
+	if diff.ExternalLinksDelta != 0 {
+		summary = append(summary, fmt.Sprintf("%+d external links", diff.ExternalLinksDelta))
+	}
+	if diff.InaccessibleLinksDelta != 0 {
+		summary = append(summary, fmt.Sprintf("%+d inaccessible links", diff.InaccessibleLinksDelta))
+	}
+
+	if diff.LoginFormChanged {
+		if b.HasLoginForm {
+			summary = append(summary, "login form appeared")
+		} else {
+			summary = append(summary, "login form disappeared")
+		}
+	}
+
+	if diff.ContentChanged {
+		summary = append(summary, "page content changed")
+	}
+
+	return summary
+}
+
+// diffMeta returns the meta values that differ between a and b, or nil when
+// none do.
+func diffMeta(a, b *WebpageAnalysis) *MetaDiff {
+	meta := &MetaDiff{
+		Title:       valueChange(a.PageTitle, b.PageTitle),
+		Description: valueChange(a.Description, b.Description),
+		Canonical:   valueChange(canonicalURL(a), canonicalURL(b)),
+		Robots:      valueChange(robotsMeta(a), robotsMeta(b)),
+	}
+	if *meta == (MetaDiff{}) {
+		return nil
+	}
+	return meta
+}
+
+// valueChange returns the change from before to after, or nil when they are equal.
+func valueChange(before, after string) *ValueChange {
+	if before == after {
+		return nil
+	}
+	return &ValueChange{Before: before, After: after}
+}
+
+// canonicalURL returns the canonical URL of a, or "" when it has none.
+func canonicalURL(a *WebpageAnalysis) string {
+	if a.Canonical == nil {
+		return ""
+	}
+	return a.Canonical.URL
+}
+
+// robotsMeta returns the directives of a's robots meta tag, comma-separated.
+func robotsMeta(a *WebpageAnalysis) string {
+	if a.Robots == nil {
+		return ""
+	}
+	return strings.Join(a.Robots.Meta, ",")
+}
+
+// diffHeadings returns the per-level heading count delta, omitting unchanged levels.
+func diffHeadings(a, b map[string]int) map[string]int {
+	delta := make(map[string]int)
+	for level, count := range b {
+		delta[level] += count
+	}
+	for level, count := range a {
+		delta[level] -= count
+	}
+	for level, d := range delta {
+		if d == 0 {
+			delete(delta, level)
+		}
+	}
+	return delta
+}
+
+// MaxComparedLanguages is the most Accept-Language values a language
+// comparison fetches the page with.
+const MaxComparedLanguages = 5
+
+// languageComparisonModules are the modules run for each language variant.
+var languageComparisonModules = []string{ModulePageTitle, ModuleContentHash, ModuleLanguage}
+
+// CompareLanguages fetches a page once per Accept-Language value, concurrently,
+// and reports how the variants differ from the first one.
+func (s *service) CompareLanguages(ctx context.Context, req LanguageComparisonRequest) (*LanguageComparison, error) {
+	startTime := time.Now()
+	if err := validateLanguageComparison(req); err != nil {
+		return nil, &AnalysisError{
+			StatusCode:   http.StatusBadRequest,
+			Code:         ErrorCodeInvalidRequest,
+			ErrorMessage: fmt.Sprintf("Invalid languages: %v", err),
+			URL:          req.URL,
+		}
+	}
+	logging.FromContext(ctx).Info("Starting language comparison", "url", req.URL, "languages", req.Languages)
+
+	analyses := make([]*WebpageAnalysis, len(req.Languages))
+	errs := make([]error, len(req.Languages))
+	var wg sync.WaitGroup
+	for i, lang := range req.Languages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			analyses[i], errs[i] = s.AnalyzeWebpage(ctx, AnalysisRequest{URL: req.URL, Modules: languageComparisonModules, AcceptLanguage: lang})
+		}()
+	}
+	wg.Wait()
+
+	variants := make([]LanguageVariant, len(req.Languages))
+	for i, err := range errs {
+		if err != nil {
+			logging.FromContext(ctx).Error("Language comparison failed", "url", req.URL, "accept_language", req.Languages[i], "error", err)
+			return nil, err
+		}
+		variants[i] = LanguageVariant{
+			AcceptLanguage: req.Languages[i],
+			FinalURL:       analyses[i].FinalURL,
+			PageTitle:      analyses[i].PageTitle,
+			ContentHash:    analyses[i].ContentHash,
+			Language:       analyses[i].Language,
+		}
+	}
+
+	comparison := &LanguageComparison{
+		URL:            req.URL,
+		Variants:       variants,
+		Differences:    DiffLanguageVariants(variants),
+		ProcessingTime: time.Since(startTime).String(),
+	}
+	logging.FromContext(ctx).Info("Language comparison completed",
+		"url", req.URL,
+		"negotiated", comparison.Differences.Negotiated,
+		"processing_time", comparison.ProcessingTime,
+	)
+	return comparison, nil
+}
+
+// validateLanguageComparison checks the number and format of the languages.
+func validateLanguageComparison(req LanguageComparisonRequest) error {
+	if len(req.Languages) < 2 || len(req.Languages) > MaxComparedLanguages {
+		return fmt.Errorf("between 2 and %d are required, got %d", MaxComparedLanguages, len(req.Languages))
+	}
+	for _, lang := range req.Languages {
+		if lang == "" {
+			return fmt.Errorf("languages must not be empty")
+		}
+		if err := validateAcceptLanguage(lang); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DiffLanguageVariants compares each variant with the first.
+func DiffLanguageVariants(variants []LanguageVariant) LanguageDiff {
+	var diff LanguageDiff
+	if len(variants) == 0 {
+		return diff
+	}
+	base := variants[0]
+	hasAlternates := false
+	for _, v := range variants {
+		hasAlternates = hasAlternates || v.Language != nil && len(v.Language.Alternates) > 0
+	}
+	for _, v := range variants[1:] {
+		lang := v.AcceptLanguage
+		if v.FinalURL != base.FinalURL {
+			diff.URLChanged = true
+			diff.Summary = append(diff.Summary, fmt.Sprintf("%s redirects to %s", lang, firstNonEmpty(v.FinalURL, "the requested URL")))
+		}
+		if v.PageTitle != base.PageTitle {
+			diff.TitleChanged = true
+			diff.Summary = append(diff.Summary, fmt.Sprintf("title differs for %s: %q", lang, v.PageTitle))
+		}
+		if v.ContentHash != "" && base.ContentHash != "" && v.ContentHash != base.ContentHash {
+			diff.ContentChanged = true
+			diff.Summary = append(diff.Summary, fmt.Sprintf("content differs for %s", lang))
+		}
+		baseLang, vLang := pageLanguageOrEmpty(base.Language), pageLanguageOrEmpty(v.Language)
+		if vLang.Declared != baseLang.Declared || vLang.Detected != baseLang.Detected {
+			diff.LanguageChanged = true
+			diff.Summary = append(diff.Summary, fmt.Sprintf("language differs for %s: declared %q, detected %q", lang, vLang.Declared, vLang.Detected))
+		}
+		if !slices.Equal(vLang.Alternates, baseLang.Alternates) {
+			diff.HreflangChanged = true
+			diff.Summary = append(diff.Summary, fmt.Sprintf("hreflang alternates differ for %s", lang))
+		}
+	}
+	diff.Negotiated = diff.URLChanged || diff.TitleChanged || diff.ContentChanged
+	if diff.Negotiated && !hasAlternates {
+		diff.MissingHreflang = true
+		diff.Summary = append(diff.Summary, "page changes with Accept-Language but lists no hreflang alternates")
+	}
+	return diff
+}
+
+// pageLanguageOrEmpty returns l, or an empty PageLanguage if l is nil.
+func pageLanguageOrEmpty(l *PageLanguage) PageLanguage {
+	if l == nil {
+		return PageLanguage{}
+	}
+	return *l
+}
@@ -0,0 +1,151 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/worker"
+)
+
+// urlMockHTTPClient serves a different response body per URL.
+type urlMockHTTPClient struct {
+	mockHTTPClient
+	responses map[string]string
+}
+
+func (m *urlMockHTTPClient) FetchWebpage(ctx context.Context, url string) ([]byte, int, error) {
+	body, ok := m.responses[url]
+	if !ok {
+		return nil, 404, assert.AnError
+	}
+	return []byte(body), 200, nil
+}
+
+func TestCompareWebpages_Differences(t *testing.T) {
+	mockClient := &urlMockHTTPClient{
+		responses: map[string]string{
+			"https://a.example.com": `<html><head><title>Staging</title></head><body>
+				<h1>Title</h1><h2>One</h2>
+				<a href="/x">x</a>
+			</body></html>`,
+			"https://b.example.com": `<html><head><title>Production</title></head><body>
+				<h1>Title</h1>
+				<a href="/x">x</a><a href="/y">y</a><a href="https://other.com">o</a>
+			</body></html>`,
+		},
+	}
+
+	service := NewServiceWithDependencies(mockClient, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.CompareWebpages(context.Background(), CompareRequest{
+		URLA: "https://a.example.com",
+		URLB: "https://b.example.com",
+	})
+
+	require.NoError(t, err, "CompareWebpages() should not return error")
+	require.NotNil(t, result.A, "First analysis should be returned")
+	require.NotNil(t, result.B, "Second analysis should be returned")
+
+	diff := result.Differences
+	assert.False(t, diff.Identical, "Pages should not be identical")
+	assert.True(t, diff.TitleChanged, "Title change should be detected")
+	assert.Equal(t, map[string]int{"h2": -1}, diff.Headings, "Only changed heading levels should be reported")
+	assert.Equal(t, 1, diff.InternalLinksDelta, "Internal link delta should be B minus A")
+	assert.Equal(t, 1, diff.ExternalLinksDelta, "External link delta should be B minus A")
+	assert.False(t, diff.LoginFormChanged, "Login form state should be unchanged")
+	assert.True(t, diff.ContentChanged, "Visible text change should be detected")
+}
+
+func TestCompareWebpages_Identical(t *testing.T) {
+	page := `<html><head><title>Same</title></head><body><h1>Same</h1></body></html>`
+	mockClient := &urlMockHTTPClient{
+		responses: map[string]string{
+			"https://a.example.com": page,
+			"https://b.example.com": page,
+		},
+	}
+
+	service := NewServiceWithDependencies(mockClient, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.CompareWebpages(context.Background(), CompareRequest{
+		URLA: "https://a.example.com",
+		URLB: "https://b.example.com",
+	})
+
+	require.NoError(t, err, "CompareWebpages() should not return error")
+	assert.True(t, result.Differences.Identical, "Identical pages should be reported as such")
+	assert.Empty(t, result.Differences.Headings, "No heading deltas expected")
+}
+
+func TestCompareWebpages_Error(t *testing.T) {
+	mockClient := &urlMockHTTPClient{
+		responses: map[string]string{
+			"https://a.example.com": `<html></html>`,
+		},
+	}
+
+	service := NewServiceWithDependencies(mockClient, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.CompareWebpages(context.Background(), CompareRequest{
+		URLA: "https://a.example.com",
+		URLB: "https://missing.example.com",
+	})
+
+	require.Error(t, err, "CompareWebpages() should fail when one page fails")
+	assert.Nil(t, result)
+
+	analysisErr, ok := err.(*AnalysisError)
+	require.True(t, ok, "Error should be of type AnalysisError")
+	assert.Equal(t, "https://missing.example.com", analysisErr.URL, "Error should identify the failing URL")
+}
+
+func TestDiffAnalyses_Summary(t *testing.T) {
+	a := &WebpageAnalysis{PageTitle: "Old", Headings: map[string]int{"h1": 1}, ExternalLinks: 2}
+	b := &WebpageAnalysis{PageTitle: "New", Headings: map[string]int{"h1": 1, "h2": 2}, ExternalLinks: 5, HasLoginForm: true}
+
+	diff := DiffAnalyses(a, b)
+
+	assert.Equal(t, []string{
+		`title changed from "Old" to "New"`,
+		"+2 h2 headings",
+		"+3 external links",
+		"login form appeared",
+	}, diff.Summary)
+}
+
+func TestDiffAnalyses_Meta(t *testing.T) {
+	a := &WebpageAnalysis{
+		PageTitle:   "Old",
+		Description: "Old description",
+		Canonical:   &Canonical{URL: "https://example.com/a"},
+		Robots:      &RobotsDirectives{Meta: []string{"index", "follow"}},
+	}
+	b := &WebpageAnalysis{
+		PageTitle:   "New",
+		Description: "Old description",
+		Canonical:   &Canonical{URL: "https://example.com/b"},
+		Robots:      &RobotsDirectives{Meta: []string{"noindex"}},
+	}
+
+	diff := DiffAnalyses(a, b)
+
+	require.NotNil(t, diff.Meta, "Changed meta values should be reported")
+	assert.Equal(t, &ValueChange{Before: "Old", After: "New"}, diff.Meta.Title)
+	assert.Nil(t, diff.Meta.Description, "Unchanged values should be left out")
+	assert.Equal(t, &ValueChange{Before: "https://example.com/a", After: "https://example.com/b"}, diff.Meta.Canonical)
+	assert.Equal(t, &ValueChange{Before: "index,follow", After: "noindex"}, diff.Meta.Robots)
+	assert.False(t, diff.Identical)
+	assert.Contains(t, diff.Summary, `robots directives changed from "index,follow" to "noindex"`)
+
+	b = &WebpageAnalysis{PageTitle: "Old", Description: "New description", Canonical: a.Canonical, Robots: a.Robots}
+	diff = DiffAnalyses(a, b)
+	require.NotNil(t, diff.Meta)
+	assert.Equal(t, &ValueChange{Before: "Old description", After: "New description"}, diff.Meta.Description)
+	assert.False(t, diff.Identical, "A changed description alone should make the pages differ")
+
+	assert.Nil(t, DiffAnalyses(a, a).Meta, "Identical pages have no meta changes")
+}
@@ -0,0 +1,167 @@
+package analyzer
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// cmpSignature describes how to recognize one consent-management platform.
+type cmpSignature struct {
+	name    string
+	hosts   []string // Script and iframe hosts, matched with their subdomains; also searched for in inline scripts.
+	ids     []string // Element ids of the platform's banner or its script tag.
+	classes []string // Class names of the platform's banner.
+	globals []string // Identifiers the platform's inline configuration uses.
+}
+
+// cmpSignatures are the consent-management platforms DetectConsent knows, in
+// reporting order.
+var cmpSignatures = []cmpSignature{
+	{
+		name:    "OneTrust",
+		hosts:   []string{"cookielaw.org", "onetrust.com", "cookiepro.com"},
+		ids:     []string{"onetrust-consent-sdk", "onetrust-banner-sdk"},
+		globals: []string{"OptanonWrapper", "OneTrust."},
+	},
+	{
+		name:    "Cookiebot",
+		hosts:   []string{"cookiebot.com", "cookiebot.eu"},
+		ids:     []string{"Cookiebot", "CybotCookiebotDialog"},
+		globals: []string{"CookiebotCallback_"},
+	},
+	{
+		name:    "Didomi",
+		hosts:   []string{"privacy-center.org"},
+		ids:     []string{"didomi-host", "didomi-notice"},
+		globals: []string{"didomiConfig", "didomiOnReady"},
+	},
+	{
+		name:    "Quantcast Choice",
+		hosts:   []string{"cmp.quantcast.com", "quantcast.mgr.consensu.org"},
+		ids:     []string{"qc-cmp2-container"},
+		classes: []string{"qc-cmp2-ui"},
+	},
+	{
+		name:  "Usercentrics",
+		hosts: []string{"usercentrics.eu"},
+		ids:   []string{"usercentrics-root", "usercentrics-cmp"},
+	},
+	{
+		name:  "TrustArc",
+		hosts: []string{"trustarc.com", "truste.com"},
+		ids:   []string{"truste-consent-track", "teconsent"},
+	},
+	{
+		name:    "CookieYes",
+		hosts:   []string{"cdn-cookieyes.com"},
+		classes: []string{"cky-consent-container"},
+	},
+	{
+		name:    "Osano",
+		hosts:   []string{"osano.com"},
+		classes: []string{"osano-cm-window"},
+	},
+}
+
+// consentBannerPattern matches ids and class names of hand-made and
+// open-source consent banners, such as "cookie-banner", "cookieConsent",
+// "gdpr-notice" and the cookieconsent library's "cc-window".
+var consentBannerPattern = regexp.MustCompile(`(?i)^(cc-window|cc-banner)$|(cookie|gdpr|consent).*(banner|notice|consent|bar|popup|modal|dialog|law)|(banner|notice|bar|popup|modal|dialog).*(cookie|gdpr)`)
+
+// consentModule detects consent-management platforms and cookie banners.
+func consentModule(htmlParser parser.HTMLParser) AnalyzerModule {
+	return NewModule(ModuleConsent, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		consent := DetectConsent(pageFrom(ctx, doc, htmlParser).Elements("script", "iframe", "div", "section", "aside", "dialog", "form"))
+		return ModuleResultFunc(func(a *WebpageAnalysis) { a.Consent = consent }), nil
+	})
+}
+
+// DetectConsent identifies the consent-management platforms a page loads,
+// from their script and frame hosts, banner ids and classes and inline
+// configuration, and whether it has a cookie banner: one a platform renders
+// in the markup, or a hand-made one recognized by its id or class. The IAB
+// Transparency and Consent Framework is reported when an inline script calls
+// its __tcfapi. It returns nil when none of these are found; many platforms
+// only render their banner from script, so a page can have a platform and no
+// banner in its HTML.
+func DetectConsent(elements []parser.Element) *Consent {
+	found := make(map[string]bool)
+	consent := &Consent{}
+	for _, el := range elements {
+		if el.Tag == "script" || el.Tag == "iframe" {
+			if src := strings.TrimSpace(el.Attr("src")); src != "" {
+				if u, err := url.Parse(src); err == nil {
+					host := strings.ToLower(u.Hostname())
+					for _, sig := range cmpSignatures {
+						for _, h := range sig.hosts {
+							if host == h || strings.HasSuffix(host, "."+h) {
+								found[sig.name] = true
+							}
+						}
+					}
+				}
+			} else if el.Tag == "script" {
+				text := el.Text()
+				consent.TCF = consent.TCF || strings.Contains(text, "__tcfapi")
+				for _, sig := range cmpSignatures {
+					if mentionsAny(text, sig.globals) || mentionsAny(text, sig.hosts) {
+						found[sig.name] = true
+					}
+				}
+			}
+		}
+
+		id := el.Attr("id")
+		classes := strings.Fields(el.Attr("class"))
+		for _, sig := range cmpSignatures {
+			for _, sigID := range sig.ids {
+				if id == sigID {
+					found[sig.name] = true
+					consent.Banner = consent.Banner || el.Tag != "script"
+				}
+			}
+			for _, class := range classes {
+				for _, sigClass := range sig.classes {
+					if class == sigClass {
+						found[sig.name] = true
+						consent.Banner = true
+					}
+				}
+			}
+		}
+		if el.Tag != "script" && el.Tag != "iframe" && !consent.Banner {
+			if consentBannerPattern.MatchString(id) {
+				consent.Banner = true
+			}
+			for _, class := range classes {
+				consent.Banner = consent.Banner || consentBannerPattern.MatchString(class)
+			}
+		}
+	}
+
+	for _, sig := range cmpSignatures {
+		if found[sig.name] {
+			consent.Platforms = append(consent.Platforms, sig.name)
+		}
+	}
+	if len(consent.Platforms) == 0 && !consent.Banner && !consent.TCF {
+		return nil
+	}
+	return consent
+}
+
+// mentionsAny reports whether s contains any of substrings.
+func mentionsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+func detectConsentIn(t *testing.T, page string) *Consent {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(page))
+	require.NoError(t, err)
+	return DetectConsent(parser.NewHTMLParser().ExtractElements(doc, "script", "iframe", "div", "section", "aside", "dialog", "form"))
+}
+
+func TestDetectConsent(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want *Consent
+	}{
+		{"none", `<script src="/app.js"></script><div class="cookie-jar">Recipes</div>`, nil},
+		{"onetrust script", `<script src="https://cdn.cookielaw.org/scripttemplates/otSDKStub.js" data-domain-script="abc"></script>
+			<script>function OptanonWrapper() {}</script>`,
+			&Consent{Platforms: []string{"OneTrust"}}},
+		{"onetrust banner", `<div id="onetrust-consent-sdk"><div id="onetrust-banner-sdk"></div></div>`,
+			&Consent{Platforms: []string{"OneTrust"}, Banner: true}},
+		{"cookiebot script tag id", `<script id="Cookiebot" src="https://consent.cookiebot.com/uc.js" data-cbid="id"></script>`,
+			&Consent{Platforms: []string{"Cookiebot"}}},
+		{"didomi inline loader with tcf", `<script>window.didomiConfig = {}; window.__tcfapi = function() {};
+			(function(){var s=document.createElement('script');s.src='https://sdk.privacy-center.org/loader.js';})();</script>`,
+			&Consent{Platforms: []string{"Didomi"}, TCF: true}},
+		{"quantcast choice", `<script src="https://cmp.quantcast.com/choice/abc/example.com/choice.js"></script>
+			<div id="qc-cmp2-container"><div class="qc-cmp2-ui"></div></div>`,
+			&Consent{Platforms: []string{"Quantcast Choice"}, Banner: true}},
+		{"generic banner", `<div id="cookie-banner"><p>We use cookies.</p><button>Accept</button></div>`,
+			&Consent{Banner: true}},
+		{"cookieconsent library", `<div role="dialog" class="cc-window cc-banner cc-type-info"></div>`,
+			&Consent{Banner: true}},
+		{"several platforms", `<script src="https://app.usercentrics.eu/browser-ui/latest/loader.js"></script>
+			<script src="https://consent.trustarc.com/notice?domain=example.com"></script>`,
+			&Consent{Platforms: []string{"Usercentrics", "TrustArc"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectConsentIn(t, tt.page))
+		})
+	}
+}
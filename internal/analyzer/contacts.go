@@ -0,0 +1,136 @@
+package analyzer
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"webpage-analyzer/internal/parser"
+)
+
+var (
+	// emailPattern matches email addresses in page text.
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	// phonePattern matches phone-number-like runs of digits and separators in page text.
+	phonePattern = regexp.MustCompile(`\+?\(?\d[\d ().-]{7,}\d`)
+	// datePattern matches ISO-style dates, which phonePattern would otherwise catch.
+	datePattern = regexp.MustCompile(`^\d{4}[-/.]\d{1,2}[-/.]\d{1,2}$`)
+)
+
+// Phone numbers must have between minPhoneDigits and maxPhoneDigits digits
+// (E.164 allows at most 15).
+const (
+	minPhoneDigits = 9
+	maxPhoneDigits = 15
+)
+
+// ExtractContacts collects email addresses and phone numbers from mailto: and
+// tel: links and from the page's visible text. Emails are lower-cased and
+// phones reduced to digits with an optional leading "+", so duplicates written
+// differently are reported once. It returns nil when nothing was found.
+func ExtractContacts(links []parser.Element, visibleText string) *Contacts {
+	contacts := &Contacts{}
+
+	for _, link := range links {
+		href := strings.TrimSpace(link.Attr("href"))
+		scheme, target, ok := strings.Cut(href, ":")
+		if !ok {
+			continue
+		}
+		target, _, _ = strings.Cut(target, "?")
+		if unescaped, err := url.PathUnescape(target); err == nil {
+			target = unescaped
+		}
+		switch strings.ToLower(scheme) {
+		case "mailto":
+			// A mailto: link may list several comma-separated recipients.
+			for _, address := range strings.Split(target, ",") {
+				if email := emailPattern.FindString(address); email != "" {
+					contacts.Emails = appendUnique(contacts.Emails, strings.ToLower(email))
+				}
+			}
+		case "tel":
+			if phone := normalizePhone(target, true); phone != "" {
+				contacts.Phones = appendUnique(contacts.Phones, phone)
+			}
+		}
+	}
+
+	for _, email := range findInRuns(emailPattern, visibleText, isEmailByte, '@') {
+		contacts.Emails = appendUnique(contacts.Emails, strings.ToLower(email))
+	}
+	for _, candidate := range findInRuns(phonePattern, visibleText, isPhoneByte, 0) {
+		if phone := normalizePhone(candidate, false); phone != "" {
+			contacts.Phones = appendUnique(contacts.Phones, phone)
+		}
+	}
+
+	if len(contacts.Emails) == 0 && len(contacts.Phones) == 0 {
+		return nil
+	}
+	return contacts
+}
+
+// findInRuns returns the matches of pattern in text, running it only over the
+// maximal runs of bytes for which in is true and, unless required is 0, that
+// contain required. pattern must only match such runs, so it finds what it
+// would in the whole text without scanning the prose between them.
+func findInRuns(pattern *regexp.Regexp, text string, in func(c byte) bool, required byte) []string {
+	var matches []string
+	for start := 0; start < len(text); {
+		if !in(text[start]) {
+			start++
+			continue
+		}
+		end := start + 1
+		for end < len(text) && in(text[end]) {
+			end++
+		}
+		if run := text[start:end]; required == 0 || strings.IndexByte(run, required) >= 0 {
+			matches = append(matches, pattern.FindAllString(run, -1)...)
+		}
+		start = end
+	}
+	return matches
+}
+
+// isEmailByte reports whether c may appear in a match of emailPattern.
+func isEmailByte(c byte) bool {
+	return isASCIIAlnum(c) || strings.IndexByte("._%+-@", c) >= 0
+}
+
+// isPhoneByte reports whether c may appear in a match of phonePattern.
+func isPhoneByte(c byte) bool {
+	return c >= '0' && c <= '9' || strings.IndexByte("+(). -", c) >= 0
+}
+
+// isASCIIAlnum reports whether c is an ASCII letter or digit.
+func isASCIIAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// normalizePhone reduces a phone number to its digits, keeping a leading "+".
+// Numbers from tel: links are trusted; numbers found in text must also have a
+// plausible length and not look like a date.
+func normalizePhone(raw string, fromLink bool) string {
+	raw = strings.TrimSpace(raw)
+	if !fromLink && datePattern.MatchString(raw) {
+		return ""
+	}
+
+	var b strings.Builder
+	if strings.HasPrefix(raw, "+") {
+		b.WriteByte('+')
+	}
+	digits := 0
+	for _, r := range raw {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			digits++
+		}
+	}
+	if digits == 0 || (!fromLink && (digits < minPhoneDigits || digits > maxPhoneDigits)) {
+		return ""
+	}
+	return b.String()
+}
@@ -0,0 +1,44 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+func TestExtractContacts(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+		<a href="mailto:Sales@Example.com?subject=Hi">Email sales</a>
+		<a href="mailto:a@example.com,b%40example.com">Both</a>
+		<a href="tel:+1-415-555-0100">Call us</a>
+		<p>Write to sales@example.com or support@help.example.co.uk.</p>
+		<p>Phone: +1 (415) 555-0100, fax 020 7946 0958.</p>
+		<p>Updated 2024-01-15, order #12345.</p>
+	</body></html>`))
+	require.NoError(t, err)
+	p := parser.NewHTMLParser()
+
+	contacts := ExtractContacts(p.ExtractElements(doc, "a"), p.ExtractVisibleText(doc))
+
+	require.NotNil(t, contacts)
+	assert.Equal(t, []string{"sales@example.com", "a@example.com", "b@example.com", "support@help.example.co.uk"}, contacts.Emails,
+		"Emails should be lower-cased and de-duplicated across links and text")
+	assert.Equal(t, []string{"+14155550100", "02079460958"}, contacts.Phones,
+		"Phones should be normalized and de-duplicated, ignoring dates and short numbers")
+}
+
+func TestExtractContacts_None(t *testing.T) {
+	assert.Nil(t, ExtractContacts(nil, "No contact details here, call 555-0100."))
+}
+
+func TestFindInRuns_MatchesWholeText(t *testing.T) {
+	text := "Mail jürgen.o'neil+news@mail.example.de, @handle or x@y; call +44 (20) 7946-0958 · 2024-01-31 or 555.0100.1234 até 99 9999 99999."
+
+	assert.Equal(t, emailPattern.FindAllString(text, -1), findInRuns(emailPattern, text, isEmailByte, '@'))
+	assert.Equal(t, phonePattern.FindAllString(text, -1), findInRuns(phonePattern, text, isPhoneByte, 0))
+}
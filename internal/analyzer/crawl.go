@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"context"
+	"time"
+
+	"webpage-analyzer/internal/logging"
+	"webpage-analyzer/internal/urlnorm"
+)
+
+// crawlItem is a URL queued for crawling at a given link depth.
+type crawlItem struct {
+	url   string
+	depth int
+}
+
+// CrawlSite analyzes the requested page and, breadth-first, the same-host pages it links to.
+// Pages that fail to load are reported in Errors and do not stop the crawl; only a failure
+// of the starting page is returned as an error.
+func (s *service) CrawlSite(ctx context.Context, req CrawlRequest) (*CrawlResult, error) {
+	startTime := time.Now()
+
+	maxDepth := req.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultCrawlMaxDepth
+	}
+	maxPages := req.MaxPages
+	if maxPages <= 0 {
+		maxPages = DefaultCrawlMaxPages
+	}
+	logging.FromContext(ctx).Info("Starting crawl", "url", req.URL, "max_depth", maxDepth, "max_pages", maxPages)
+	modules, _ := s.modules.Select(nil) // Crawls always run every module.
+
+	result := &CrawlResult{URL: req.URL, Pages: make([]*WebpageAnalysis, 0)}
+	queue := []crawlItem{{url: req.URL}}
+	visited := map[string]bool{crawlKey(req.URL): true}
+
+	for len(queue) > 0 && len(result.Pages)+len(result.Errors) < maxPages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		item := queue[0]
+		queue = queue[1:]
+
+		pageStart := time.Now()
+		doc, info, err := s.fetchDocument(ctx, item.url)
+		if err != nil {
+			if item.depth == 0 {
+				return nil, err
+			}
+			if analysisErr, ok := err.(*AnalysisError); ok {
+				result.Errors = append(result.Errors, analysisErr)
+			}
+			continue
+		}
+		analysis, err := s.analyzeDocument(ctx, doc, info, modules, nil, pageStart, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		result.Pages = append(result.Pages, analysis)
+
+		if item.depth >= maxDepth {
+			continue
+		}
+		for _, link := range s.htmlParser.ExtractInternalURLs(doc, item.url) {
+			if key := crawlKey(link); !visited[key] {
+				visited[key] = true
+				queue = append(queue, crawlItem{url: link, depth: item.depth + 1})
+			}
+		}
+	}
+
+	result.ProcessingTime = time.Since(startTime).String()
+	logging.FromContext(ctx).Info("Crawl completed",
+		"url", req.URL,
+		"pages", len(result.Pages),
+		"errors", len(result.Errors),
+		"processing_time", result.ProcessingTime,
+	)
+
+	return result, nil
+}
+
+// crawlKey normalizes a URL for de-duplication so that "https://host" and
+// "https://Host:443/" match.
+func crawlKey(rawURL string) string {
+	return urlnorm.Normalize(rawURL, urlnorm.Options{})
+}
@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/worker"
+)
+
+func newCrawlTestService() Service {
+	mockClient := &urlMockHTTPClient{
+		responses: map[string]string{
+			"https://example.com":        `<html><head><title>Home</title></head><body><a href="/a">A</a><a href="/b">B</a><a href="https://other.com">O</a></body></html>`,
+			"https://example.com/a":      `<html><head><title>A</title></head><body><a href="/">Home</a><a href="/a/deep">Deep</a></body></html>`,
+			"https://example.com/a/deep": `<html><head><title>Deep</title></head><body></body></html>`,
+		},
+	}
+	return NewServiceWithDependencies(mockClient, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+}
+
+func TestCrawlSite(t *testing.T) {
+	service := newCrawlTestService()
+
+	result, err := service.CrawlSite(context.Background(), CrawlRequest{URL: "https://example.com", MaxDepth: 2})
+
+	require.NoError(t, err, "CrawlSite() should not return error")
+
+	titles := make([]string, 0, len(result.Pages))
+	for _, page := range result.Pages {
+		titles = append(titles, page.PageTitle)
+	}
+	assert.Equal(t, []string{"Home", "A", "Deep"}, titles, "Pages should be crawled breadth-first, same-host only")
+	require.Len(t, result.Errors, 1, "Broken internal links should be reported")
+	assert.Equal(t, "https://example.com/b", result.Errors[0].URL)
+}
+
+func TestCrawlSite_Limits(t *testing.T) {
+	service := newCrawlTestService()
+
+	result, err := service.CrawlSite(context.Background(), CrawlRequest{URL: "https://example.com", MaxDepth: 1, MaxPages: 2})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(result.Pages)+len(result.Errors), "MaxPages should cap the crawl")
+}
+
+func TestCrawlSite_StartPageFails(t *testing.T) {
+	service := newCrawlTestService()
+
+	result, err := service.CrawlSite(context.Background(), CrawlRequest{URL: "https://missing.example.com"})
+
+	require.Error(t, err, "CrawlSite() should fail when the starting page fails")
+	assert.Nil(t, result)
+}
+
+func TestCrawlSite_PageMeter(t *testing.T) {
+	service := newCrawlTestService()
+	var charged []string
+	ctx := WithPageMeter(context.Background(), func(ctx context.Context, pageURL string) error {
+		if len(charged) == 2 {
+			return &AnalysisError{StatusCode: http.StatusTooManyRequests, Code: "quota_exceeded", ErrorMessage: "Quota exceeded", URL: pageURL}
+		}
+		charged = append(charged, pageURL)
+		return nil
+	})
+
+	result, err := service.CrawlSite(ctx, CrawlRequest{URL: "https://example.com", MaxDepth: 2})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://example.com", "https://example.com/a"}, charged, "Every page should be charged before it is fetched")
+	require.Len(t, result.Pages, 2)
+	require.NotEmpty(t, result.Errors)
+	assert.Equal(t, "quota_exceeded", result.Errors[0].Code, "Pages the meter refuses should fail with its error")
+}
@@ -0,0 +1,190 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/logging"
+	"webpage-analyzer/internal/urlnorm"
+)
+
+// Browser user agents a device comparison requests the page with by default.
+// The trailing product token keeps the requests recognizable in server logs.
+const (
+	DefaultDesktopUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 WebpageAnalyzer/1.0"
+	DefaultMobileUserAgent  = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36 WebpageAnalyzer/1.0"
+)
+
+// maxUserAgentLength bounds the user agents a device comparison may send.
+const maxUserAgentLength = 512
+
+// CompareDevices fetches a page as a desktop and as a mobile browser,
+// concurrently, and reports how the two responses differ. A mobile variant
+// that fails is reported rather than returned as an error; the comparison
+// only fails when the desktop variant does.
+func (s *service) CompareDevices(ctx context.Context, req DeviceComparisonRequest) (*DeviceComparison, error) {
+	startTime := time.Now()
+	desktopUA := firstNonEmpty(req.DesktopUserAgent, DefaultDesktopUserAgent)
+	mobileUA := firstNonEmpty(req.MobileUserAgent, DefaultMobileUserAgent)
+	for _, ua := range []string{desktopUA, mobileUA} {
+		if err := validateUserAgent(ua); err != nil {
+			return nil, &AnalysisError{
+				StatusCode:   http.StatusBadRequest,
+				Code:         ErrorCodeInvalidRequest,
+				ErrorMessage: fmt.Sprintf("Invalid user agent: %v", err),
+				URL:          req.URL,
+			}
+		}
+	}
+	logging.FromContext(ctx).Info("Starting device comparison", "url", req.URL)
+
+	var desktop, mobile DeviceVariant
+	var desktopErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		desktop, desktopErr = s.fetchDeviceVariant(ctx, req.URL, desktopUA)
+	}()
+	go func() {
+		defer wg.Done()
+		mobile, _ = s.fetchDeviceVariant(ctx, req.URL, mobileUA)
+	}()
+	wg.Wait()
+	if desktopErr != nil {
+		logging.FromContext(ctx).Error("Device comparison failed", "url", req.URL, "error", desktopErr)
+		return nil, desktopErr
+	}
+
+	comparison := &DeviceComparison{
+		URL:            req.URL,
+		Desktop:        desktop,
+		Mobile:         mobile,
+		Differences:    DiffDeviceVariants(req.URL, desktop, mobile),
+		ProcessingTime: time.Since(startTime).String(),
+	}
+	logging.FromContext(ctx).Info("Device comparison completed",
+		"url", req.URL,
+		"suspected_cloaking", comparison.Differences.SuspectedCloaking,
+		"processing_time", comparison.ProcessingTime,
+	)
+	return comparison, nil
+}
+
+// fetchDeviceVariant fetches the page with the given user agent. When the
+// fetch fails, the returned variant records why.
+func (s *service) fetchDeviceVariant(ctx context.Context, pageURL, userAgent string) (DeviceVariant, error) {
+	variant := DeviceVariant{UserAgent: userAgent}
+	doc, info, err := s.fetchDocument(client.WithUserAgent(ctx, userAgent), pageURL)
+	if err != nil {
+		variant.Error = err.Error()
+		var analysisErr *AnalysisError
+		if errors.As(err, &analysisErr) {
+			variant.StatusCode = analysisErr.StatusCode
+			variant.Error = analysisErr.ErrorMessage
+		}
+		return variant, err
+	}
+
+	fetched := firstNonEmpty(info.FinalURL, info.URL)
+	if fetched != pageURL {
+		variant.FinalURL = fetched
+	}
+	variant.StatusCode = info.StatusCode
+	variant.PageTitle = s.htmlParser.ExtractPageTitle(doc)
+	variant.BodySize = info.BodySize
+	elements := s.htmlParser.ExtractElements(doc, "link", "meta")
+	if canonical := FindCanonical(fetched, elements, info.Header); canonical != nil {
+		variant.Canonical = canonical.URL
+	}
+	for _, el := range elements {
+		if el.Tag == "meta" && strings.EqualFold(el.Attr("name"), "viewport") {
+			variant.Viewport = strings.TrimSpace(el.Attr("content"))
+			break
+		}
+	}
+	return variant, nil
+}
+
+// validateUserAgent checks that a user agent fits in a header.
+func validateUserAgent(ua string) error {
+	if len(ua) > maxUserAgentLength {
+		return fmt.Errorf("longer than %d characters", maxUserAgentLength)
+	}
+	if strings.IndexFunc(ua, unicode.IsControl) >= 0 {
+		return errors.New("contains control characters")
+	}
+	return nil
+}
+
+// DiffDeviceVariants compares the mobile variant of the page at pageURL with
+// the desktop one.
+func DiffDeviceVariants(pageURL string, desktop, mobile DeviceVariant) DeviceDiff {
+	var diff DeviceDiff
+	if mobile.Error != "" {
+		diff.MobileBroken = desktop.Error == ""
+		if diff.MobileBroken {
+			diff.Summary = append(diff.Summary, fmt.Sprintf("mobile variant fails: %s", mobile.Error))
+		}
+		return diff
+	}
+
+	desktopURL, mobileURL := firstNonEmpty(desktop.FinalURL, pageURL), firstNonEmpty(mobile.FinalURL, pageURL)
+	if desktopURL != mobileURL {
+		diff.RedirectChanged = true
+		diff.Summary = append(diff.Summary, fmt.Sprintf("mobile ends on %s, desktop on %s", mobileURL, desktopURL))
+	}
+	if desktop.PageTitle != mobile.PageTitle {
+		diff.TitleChanged = true
+		diff.Summary = append(diff.Summary, fmt.Sprintf("title differs on mobile: %q", mobile.PageTitle))
+	}
+	if normalizedCanonical(desktop.Canonical) != normalizedCanonical(mobile.Canonical) {
+		diff.CanonicalChanged = true
+		diff.Summary = append(diff.Summary, fmt.Sprintf("canonical differs on mobile: %q", mobile.Canonical))
+	}
+	if desktop.Viewport != mobile.Viewport {
+		diff.ViewportChanged = true
+	}
+	if mobile.Viewport == "" {
+		diff.MissingViewport = true
+		diff.Summary = append(diff.Summary, "mobile variant has no viewport meta tag")
+	}
+	if desktop.BodySize > 0 {
+		diff.SizeRatio = float64(mobile.BodySize) / float64(desktop.BodySize)
+		if diff.SizeRatio < 0.5 || diff.SizeRatio > 2 {
+			diff.SizeChanged = true
+			diff.Summary = append(diff.Summary, fmt.Sprintf("mobile body is %d bytes, desktop %d", mobile.BodySize, desktop.BodySize))
+		}
+	}
+	if diff.TitleChanged && diff.CanonicalChanged || siteOf(desktopURL) != siteOf(mobileURL) {
+		diff.SuspectedCloaking = true
+		diff.Summary = append(diff.Summary, "mobile and desktop are served different pages")
+	}
+	return diff
+}
+
+// normalizedCanonical normalizes a canonical URL for comparison.
+func normalizedCanonical(canonical string) string {
+	if canonical == "" {
+		return ""
+	}
+	return urlnorm.Normalize(canonical, canonicalNormalization)
+}
+
+// siteOf returns the registrable domain of rawURL's host, so that a mobile
+// subdomain such as m.example.com is the same site as example.com.
+func siteOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return registrableDomain(strings.ToLower(u.Hostname()))
+}
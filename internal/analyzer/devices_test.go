@@ -0,0 +1,88 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/client"
+)
+
+// deviceMockHTTPClient serves one response body to mobile user agents and
+// another to the rest. A mobile body of "" fails.
+type deviceMockHTTPClient struct {
+	mockHTTPClient
+	desktop, mobile string
+}
+
+func (m *deviceMockHTTPClient) FetchWebpage(ctx context.Context, url string) ([]byte, int, error) {
+	if !strings.Contains(client.UserAgent(ctx), "Mobile") {
+		return []byte(m.desktop), 200, nil
+	}
+	if m.mobile == "" {
+		return nil, 503, assert.AnError
+	}
+	return []byte(m.mobile), 200, nil
+}
+
+func TestCompareDevices(t *testing.T) {
+	mockClient := &deviceMockHTTPClient{
+		desktop: `<html><head><title>Example</title><link rel="canonical" href="https://example.com/">
+			<meta name="viewport" content="width=device-width, initial-scale=1"></head><body><p>Desktop</p></body></html>`,
+		mobile: `<html><head><title>Example</title><link rel="canonical" href="https://example.com">
+			</head><body></body></html>`,
+	}
+	service := NewService(WithHTTPClient(mockClient))
+
+	result, err := service.CompareDevices(context.Background(), DeviceComparisonRequest{URL: "https://example.com/"})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultDesktopUserAgent, result.Desktop.UserAgent)
+	assert.Equal(t, DefaultMobileUserAgent, result.Mobile.UserAgent)
+	assert.Equal(t, "width=device-width, initial-scale=1", result.Desktop.Viewport)
+
+	diff := result.Differences
+	assert.False(t, diff.TitleChanged)
+	assert.False(t, diff.CanonicalChanged, "Canonical URLs should be compared normalized")
+	assert.True(t, diff.ViewportChanged)
+	assert.True(t, diff.MissingViewport)
+	assert.False(t, diff.SuspectedCloaking)
+	assert.False(t, diff.MobileBroken)
+}
+
+func TestCompareDevices_MobileBroken(t *testing.T) {
+	service := NewService(WithHTTPClient(&deviceMockHTTPClient{desktop: "<html><title>Example</title></html>"}))
+
+	result, err := service.CompareDevices(context.Background(), DeviceComparisonRequest{URL: "https://example.com/"})
+	require.NoError(t, err, "A failing mobile variant should be reported, not returned")
+	assert.NotEmpty(t, result.Mobile.Error)
+	assert.True(t, result.Differences.MobileBroken)
+}
+
+func TestCompareDevices_InvalidUserAgent(t *testing.T) {
+	service := NewService(WithHTTPClient(&mockHTTPClient{response: "<html></html>"}))
+
+	_, err := service.CompareDevices(context.Background(), DeviceComparisonRequest{URL: "https://example.com", MobileUserAgent: "Mobile\r\nX-Evil: 1"})
+	var analysisErr *AnalysisError
+	require.ErrorAs(t, err, &analysisErr)
+	assert.Equal(t, ErrorCodeInvalidRequest, analysisErr.Code)
+}
+
+func TestDiffDeviceVariants(t *testing.T) {
+	desktop := DeviceVariant{PageTitle: "Shoes", Canonical: "https://example.com/shoes", Viewport: "width=device-width", BodySize: 40000}
+
+	mobileSite := DeviceVariant{FinalURL: "https://m.example.com/shoes", PageTitle: "Shoes", Canonical: "https://example.com/shoes", Viewport: "width=device-width", BodySize: 12000}
+	diff := DiffDeviceVariants("https://example.com/shoes", desktop, mobileSite)
+	assert.True(t, diff.RedirectChanged)
+	assert.True(t, diff.SizeChanged)
+	assert.InDelta(t, 0.3, diff.SizeRatio, 0.001)
+	assert.False(t, diff.SuspectedCloaking, "A mobile subdomain of the same site is not cloaking")
+
+	elsewhere := DeviceVariant{FinalURL: "https://ads.example.net/", PageTitle: "You won!", Viewport: "width=device-width", BodySize: 40000}
+	diff = DiffDeviceVariants("https://example.com/shoes", desktop, elsewhere)
+	assert.True(t, diff.SuspectedCloaking)
+	assert.True(t, diff.TitleChanged)
+	assert.True(t, diff.CanonicalChanged)
+}
@@ -0,0 +1,343 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/publicsuffix"
+
+	"webpage-analyzer/internal/client"
+)
+
+// Options accepted by the dns module.
+const (
+	dnsOptionTimeout = "timeout" // duration: timeout for all DNS lookups together.
+
+	defaultDNSTimeout = 10 * time.Second
+
+	// DefaultDoHURL is the DNS-over-HTTPS JSON API used for lookups. It is used
+	// instead of the system resolver because Go's resolver cannot query CAA.
+	DefaultDoHURL = "https://dns.google/resolve"
+)
+
+// DNS record types, as numbered in DoH JSON answers.
+const (
+	dnsTypeMX  = 15
+	dnsTypeTXT = 16
+	dnsTypeCAA = 257
+)
+
+// dkimSelectors are the DKIM selectors probed, as selectors cannot be listed.
+// They cover the defaults of common mail providers.
+var dkimSelectors = []string{"default", "google", "selector1", "selector2", "k1", "s1", "s2", "dkim", "mail"}
+
+// dnsModule reports the mail and certificate security records of the page's
+// domain. It queries external resolvers, so it only runs when requested by name.
+type dnsModule struct {
+	httpClient client.HTTPClient
+	dohURL     string
+}
+
+func (m *dnsModule) Name() string { return ModuleDNS }
+
+// OptIn implements OptInModule.
+func (m *dnsModule) OptIn() bool { return true }
+
+// ValidateOptions implements OptionsValidator.
+func (m *dnsModule) ValidateOptions(opts ModuleOptions) error {
+	_, err := m.parseOptions(opts)
+	return err
+}
+
+// parseOptions reads the timeout option.
+func (m *dnsModule) parseOptions(opts ModuleOptions) (time.Duration, error) {
+	for key := range opts {
+		if key != dnsOptionTimeout {
+			return 0, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	timeout, err := opts.Duration(dnsOptionTimeout, defaultDNSTimeout)
+	if err != nil {
+		return 0, err
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("option %q must be positive", dnsOptionTimeout)
+	}
+	return timeout, nil
+}
+
+// Analyze looks up MX, SPF, DMARC, DKIM and CAA records of the registered domain.
+func (m *dnsModule) Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error) {
+	timeout, err := m.parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(info.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page URL: %v", err)
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(u.Hostname()))
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine the registered domain of %q: %v", u.Hostname(), err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	posture, err := m.lookupPosture(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	findings := dnsFindings(posture)
+
+	return ModuleResultFunc(func(a *WebpageAnalysis) {
+		a.DNS = posture
+		a.Findings = append(a.Findings, findings...)
+	}), nil
+}
+
+// lookupPosture runs every lookup concurrently and assembles the results.
+func (m *dnsModule) lookupPosture(ctx context.Context, domain string) (*DNSPosture, error) {
+	type lookup struct {
+		name    string
+		rrType  int
+		answers []string
+		err     error
+	}
+	lookups := []*lookup{
+		{name: domain, rrType: dnsTypeMX},
+		{name: domain, rrType: dnsTypeTXT},
+		{name: "_dmarc." + domain, rrType: dnsTypeTXT},
+		{name: domain, rrType: dnsTypeCAA},
+	}
+	for _, selector := range dkimSelectors {
+		lookups = append(lookups, &lookup{name: selector + "._domainkey." + domain, rrType: dnsTypeTXT})
+	}
+
+	var wg sync.WaitGroup
+	for _, l := range lookups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.answers, l.err = m.resolve(ctx, l.name, l.rrType)
+		}()
+	}
+	wg.Wait()
+	for _, l := range lookups {
+		if l.err != nil {
+			return nil, l.err
+		}
+	}
+
+	posture := &DNSPosture{Domain: domain, MX: parseMX(lookups[0].answers), CAA: lookups[3].answers}
+	for _, txt := range lookups[1].answers {
+		if strings.HasPrefix(strings.ToLower(txt), "v=spf1") {
+			posture.SPF = append(posture.SPF, txt)
+		}
+	}
+	for _, txt := range lookups[2].answers {
+		if strings.HasPrefix(strings.ToLower(txt), "v=dmarc1") {
+			posture.DMARC = txt
+			posture.DMARCPolicy = dmarcTag(txt, "p")
+		}
+	}
+	for i, selector := range dkimSelectors {
+		for _, txt := range lookups[4+i].answers {
+			if strings.Contains(strings.ToLower(txt), "p=") {
+				posture.DKIMSelectors = append(posture.DKIMSelectors, selector)
+				break
+			}
+		}
+	}
+	return posture, nil
+}
+
+// dohResponse is the DNS-over-HTTPS JSON response format.
+type dohResponse struct {
+	Status int `json:"Status"`
+	Answer []struct {
+		Type int    `json:"type"`
+		Data string `json:"data"`
+	} `json:"Answer"`
+}
+
+// resolve returns the data of the name's records of the given type. A name
+// that does not exist has no records. Each lookup is a sub-request of the
+// analysis, bounded by the module's overall timeout in ctx.
+func (m *dnsModule) resolve(ctx context.Context, name string, rrType int) ([]string, error) {
+	ctx, cancel, err := subrequestsFrom(ctx).one(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %v", name, err)
+	}
+	defer cancel()
+	q := url.Values{}
+	q.Set("name", name)
+	q.Set("type", strconv.Itoa(rrType))
+	body, statusCode, err := m.httpClient.FetchWebpage(ctx, m.dohURL+"?"+q.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %v", name, err)
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("DNS lookup of %s returned HTTP %d", name, statusCode)
+	}
+
+	var resp dohResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode DNS response for %s: %v", name, err)
+	}
+	switch resp.Status {
+	case 0: // NOERROR
+	case 3: // NXDOMAIN
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("DNS lookup of %s failed with rcode %d", name, resp.Status)
+	}
+
+	var answers []string
+	for _, a := range resp.Answer {
+		if a.Type != rrType {
+			continue // CNAMEs followed on the way.
+		}
+		data := a.Data
+		if rrType == dnsTypeTXT {
+			data = joinTXT(data)
+		}
+		answers = append(answers, data)
+	}
+	return answers, nil
+}
+
+// joinTXT turns TXT data into one string. Some resolvers return the record's
+// character-strings quoted ("v=spf1 " "-all"); others return them joined.
+func joinTXT(data string) string {
+	if !strings.HasPrefix(data, `"`) {
+		return data
+	}
+	var b strings.Builder
+	for rest := data; rest != ""; {
+		rest = strings.TrimLeft(rest, " ")
+		s, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			b.WriteString(rest)
+			break
+		}
+		unquoted, _ := strconv.Unquote(s)
+		b.WriteString(unquoted)
+		rest = rest[len(s):]
+	}
+	return b.String()
+}
+
+// parseMX returns the mail exchangers of MX record data ("10 mx.example.com."),
+// most preferred first.
+func parseMX(answers []string) []string {
+	type mx struct {
+		pref int
+		host string
+	}
+	var records []mx
+	for _, a := range answers {
+		fields := strings.Fields(a)
+		if len(fields) != 2 {
+			continue
+		}
+		pref, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		host := strings.TrimSuffix(fields[1], ".")
+		if host == "" {
+			continue // A null MX ("0 .") declares that the domain takes no mail.
+		}
+		records = append(records, mx{pref: pref, host: host})
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].pref < records[j].pref })
+
+	var hosts []string
+	for _, r := range records {
+		hosts = append(hosts, r.host)
+	}
+	return hosts
+}
+
+// dmarcTag returns the value of a tag in a DMARC record ("v=DMARC1; p=reject").
+func dmarcTag(record, tag string) string {
+	for _, part := range strings.Split(record, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && strings.EqualFold(strings.TrimSpace(key), tag) {
+			return strings.ToLower(strings.TrimSpace(value))
+		}
+	}
+	return ""
+}
+
+// spfAll returns the all mechanism of an SPF record with its qualifier, e.g.
+// "-all", or "" if there is none.
+func spfAll(record string) string {
+	for _, term := range strings.Fields(strings.ToLower(record)) {
+		switch term {
+		case "all", "+all", "-all", "~all", "?all":
+			return term
+		}
+	}
+	return ""
+}
+
+// dnsFindings reports missing or weak mail authentication and certificate
+// issuance records.
+func dnsFindings(p *DNSPosture) []Finding {
+	var findings []Finding
+	add := func(severity Severity, message, evidence string) {
+		findings = append(findings, Finding{Type: FindingDNSMisconfiguration, Severity: severity, Message: message, Evidence: evidence})
+	}
+
+	// Senders can be spoofed whether or not the domain receives mail, but a
+	// domain without mail is a less likely phishing lure.
+	missing := SeverityLow
+	if len(p.MX) > 0 {
+		missing = SeverityMedium
+	}
+
+	switch len(p.SPF) {
+	case 0:
+		add(missing, "Domain "+p.Domain+" has no SPF record", "")
+	case 1:
+		switch spfAll(p.SPF[0]) {
+		case "+all", "all":
+			add(SeverityHigh, "SPF record allows any server to send mail (+all)", p.SPF[0])
+		case "?all":
+			add(SeverityLow, "SPF record is neutral about unlisted senders (?all)", p.SPF[0])
+		case "":
+			if !strings.Contains(strings.ToLower(p.SPF[0]), "redirect=") {
+				add(SeverityLow, "SPF record has no all mechanism", p.SPF[0])
+			}
+		}
+	default:
+		add(SeverityMedium, "Domain has several SPF records, which makes SPF fail", strings.Join(p.SPF, " | "))
+	}
+
+	switch p.DMARCPolicy {
+	case "":
+		if p.DMARC == "" {
+			add(missing, "Domain "+p.Domain+" has no DMARC record", "")
+		} else {
+			add(SeverityMedium, "DMARC record has no policy (p= tag)", p.DMARC)
+		}
+	case "none":
+		add(SeverityLow, "DMARC policy is none, so spoofed mail is only monitored", p.DMARC)
+	}
+
+	if len(p.CAA) == 0 {
+		add(SeverityInfo, "Domain "+p.Domain+" has no CAA records, so any certificate authority may issue for it", "")
+	}
+	return findings
+}
@@ -0,0 +1,100 @@
+package analyzer
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dohMockHTTPClient answers DoH JSON queries from a table keyed by "name type",
+// and NXDOMAIN for anything else.
+type dohMockHTTPClient struct {
+	mockHTTPClient
+	records map[string]string
+}
+
+func (m *dohMockHTTPClient) FetchWebpage(ctx context.Context, rawURL string) ([]byte, int, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, 400, err
+	}
+	if body, ok := m.records[u.Query().Get("name")+" "+u.Query().Get("type")]; ok {
+		return []byte(body), 200, nil
+	}
+	return []byte(`{"Status": 3}`), 200, nil
+}
+
+func analyzeDNS(t *testing.T, records map[string]string) *WebpageAnalysis {
+	t.Helper()
+	module := &dnsModule{httpClient: &dohMockHTTPClient{records: records}, dohURL: DefaultDoHURL}
+
+	result, err := module.Analyze(context.Background(), nil, FetchInfo{URL: "https://www.example.com/"}, nil)
+	require.NoError(t, err)
+	analysis := &WebpageAnalysis{}
+	result.Apply(analysis)
+	return analysis
+}
+
+func TestDNSModule_WellConfigured(t *testing.T) {
+	analysis := analyzeDNS(t, map[string]string{
+		"example.com " + strconv.Itoa(dnsTypeMX): `{"Status": 0, "Answer": [
+			{"type": 15, "data": "20 mx2.example.com."}, {"type": 15, "data": "10 mx1.example.com."}]}`,
+		"example.com " + strconv.Itoa(dnsTypeTXT): `{"Status": 0, "Answer": [
+			{"type": 16, "data": "\"google-site-verification=abc\""},
+			{"type": 16, "data": "\"v=spf1 include:_spf.example.net \" \"-all\""}]}`,
+		"_dmarc.example.com " + strconv.Itoa(dnsTypeTXT): `{"Status": 0, "Answer": [
+			{"type": 5, "data": "dmarc.example.net."}, {"type": 16, "data": "v=DMARC1; p=Reject; rua=mailto:d@example.com"}]}`,
+		"google._domainkey.example.com " + strconv.Itoa(dnsTypeTXT): `{"Status": 0, "Answer": [{"type": 16, "data": "v=DKIM1; k=rsa; p=MIIB"}]}`,
+		"example.com " + strconv.Itoa(dnsTypeCAA):                   `{"Status": 0, "Answer": [{"type": 257, "data": "0 issue \"letsencrypt.org\""}]}`,
+	})
+
+	require.NotNil(t, analysis.DNS)
+	assert.Equal(t, "example.com", analysis.DNS.Domain, "Records should be looked up on the registered domain")
+	assert.Equal(t, []string{"mx1.example.com", "mx2.example.com"}, analysis.DNS.MX)
+	assert.Equal(t, []string{"v=spf1 include:_spf.example.net -all"}, analysis.DNS.SPF, "Quoted TXT strings should be joined")
+	assert.Equal(t, "reject", analysis.DNS.DMARCPolicy)
+	assert.Equal(t, []string{"google"}, analysis.DNS.DKIMSelectors)
+	assert.Equal(t, []string{`0 issue "letsencrypt.org"`}, analysis.DNS.CAA)
+	assert.Empty(t, analysis.Findings)
+}
+
+func TestDNSModule_Misconfigured(t *testing.T) {
+	analysis := analyzeDNS(t, map[string]string{
+		"example.com " + strconv.Itoa(dnsTypeMX):  `{"Status": 0, "Answer": [{"type": 15, "data": "10 mx.example.com."}]}`,
+		"example.com " + strconv.Itoa(dnsTypeTXT): `{"Status": 0, "Answer": [{"type": 16, "data": "v=spf1 a mx +all"}]}`,
+	})
+
+	severities := make(map[string]Severity)
+	for _, f := range analysis.Findings {
+		assert.Equal(t, FindingDNSMisconfiguration, f.Type)
+		severities[f.Message] = f.Severity
+	}
+	assert.Equal(t, map[string]Severity{
+		"SPF record allows any server to send mail (+all)":                                     SeverityHigh,
+		"Domain example.com has no DMARC record":                                               SeverityMedium,
+		"Domain example.com has no CAA records, so any certificate authority may issue for it": SeverityInfo,
+	}, severities)
+}
+
+func TestDNSFindings_SPFAndDMARC(t *testing.T) {
+	findings := dnsFindings(&DNSPosture{
+		Domain:      "example.com",
+		SPF:         []string{"v=spf1 include:a.example ?all"},
+		DMARC:       "v=DMARC1; p=none",
+		DMARCPolicy: "none",
+		CAA:         []string{`0 issue "pki.goog"`},
+	})
+
+	require.Len(t, findings, 2)
+	assert.Equal(t, SeverityLow, findings[0].Severity, "?all should rate low")
+	assert.Contains(t, findings[1].Message, "DMARC policy is none")
+
+	findings = dnsFindings(&DNSPosture{Domain: "example.com", SPF: []string{"v=spf1 -all", "v=spf1 mx -all"}, CAA: []string{"x"}})
+	require.Len(t, findings, 2)
+	assert.Contains(t, findings[0].Message, "several SPF records")
+	assert.Equal(t, SeverityLow, findings[1].Severity, "Missing DMARC should rate low for a domain without mail")
+}
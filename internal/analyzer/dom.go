@@ -0,0 +1,66 @@
+package analyzer
+
+import (
+	"context"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/client"
+)
+
+// domModule measures the size and shape of the DOM.
+func domModule() AnalyzerModule {
+	return NewModule(ModuleDOM, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		metrics := MeasureDOM(doc)
+		metrics.HTMLBytes = info.BodySize
+		return ModuleResultFunc(func(a *WebpageAnalysis) { a.DOM = metrics }), nil
+	})
+}
+
+// MeasureDOM counts the elements of doc, their deepest nesting and the inline
+// scripts and styles among them. HTMLBytes is left for the caller, which
+// knows the size of the source. The tree is walked without recursion.
+func MeasureDOM(doc *html.Node) *DOMMetrics {
+	type item struct {
+		node  *html.Node
+		depth int // Elements from the root to node's parent.
+	}
+	metrics := &DOMMetrics{}
+	stack := []item{{node: doc}}
+	for len(stack) > 0 {
+		it := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		n, depth := it.node, it.depth
+		if n.Type == html.ElementNode {
+			depth++
+			metrics.Elements++
+			metrics.MaxDepth = max(metrics.MaxDepth, depth)
+			switch n.Data {
+			case "script":
+				if !hasAttr(n, "src") {
+					metrics.InlineScripts++
+				}
+			case "style":
+				metrics.InlineStyles++
+			}
+			if hasAttr(n, "style") {
+				metrics.StyleAttributes++
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			stack = append(stack, item{node: child, depth: depth})
+		}
+	}
+	metrics.DepthLimited = metrics.MaxDepth >= client.MaxDOMDepth
+	return metrics
+}
+
+// hasAttr reports whether n has the attribute key.
+func hasAttr(n *html.Node, key string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/client"
+)
+
+func TestMeasureDOM(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<!DOCTYPE html>
+<html><head>
+<style>p { color: red }</style>
+<script>var a = 1;</script>
+<script src="/app.js"></script>
+</head><body>
+<div style="margin: 0"><p>One <b>two</b></p></div>
+<p style="color: blue">Three</p>
+</body></html>`))
+	require.NoError(t, err)
+
+	metrics := MeasureDOM(doc)
+	assert.Equal(t, 10, metrics.Elements, "html, head, style, 2 scripts, body, div, 2 p and b")
+	assert.Equal(t, 5, metrics.MaxDepth, "html > body > div > p > b")
+	assert.Equal(t, 1, metrics.InlineScripts, "Scripts with src are not inline")
+	assert.Equal(t, 1, metrics.InlineStyles)
+	assert.Equal(t, 2, metrics.StyleAttributes)
+	assert.False(t, metrics.DepthLimited)
+}
+
+func TestDOMModule_DeepPage(t *testing.T) {
+	page := "<html><body>" + strings.Repeat("<div>", 5*client.MaxDOMDepth) + "deep</body></html>"
+	service := NewService(WithHTTPClient(&parsingClient{mockHTTPClient{response: page}}))
+
+	analysis, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://example.com", Modules: []string{ModuleDOM}})
+	require.NoError(t, err)
+	require.NotNil(t, analysis.DOM)
+	assert.Equal(t, client.MaxDOMDepth, analysis.DOM.MaxDepth, "Elements below the depth limit should be dropped")
+	assert.True(t, analysis.DOM.DepthLimited)
+	assert.Equal(t, len(page), analysis.DOM.HTMLBytes)
+}
+
+// parsingClient fetches like mockHTTPClient and parses like the real client.
+type parsingClient struct {
+	mockHTTPClient
+}
+
+func (c *parsingClient) ParseHTML(content []byte) (*html.Node, error) {
+	return client.NewHTTPClient().ParseHTML(content)
+}
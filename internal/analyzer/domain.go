@@ -0,0 +1,196 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/publicsuffix"
+
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/parser"
+)
+
+// Options accepted by the domain module.
+const (
+	domainOptionTimeout = "timeout" // duration: timeout for the RDAP lookup.
+
+	defaultDomainTimeout = 10 * time.Second
+
+	// DefaultRDAPURL is a bootstrap RDAP service that redirects each domain
+	// query to its registry's RDAP server.
+	DefaultRDAPURL = "https://rdap.org/domain/"
+
+	// youngDomainAge is the age below which a domain is reported as newly registered.
+	youngDomainAge = 30 * 24 * time.Hour
+)
+
+// domainModule looks up the registration of the page's domain over RDAP, the
+// JSON successor to WHOIS. It calls an external service, so it only runs when
+// requested by name.
+type domainModule struct {
+	htmlParser parser.HTMLParser
+	httpClient client.HTTPClient
+	rdapURL    string
+	now        func() time.Time
+}
+
+func (m *domainModule) Name() string { return ModuleDomain }
+
+// OptIn implements OptInModule.
+func (m *domainModule) OptIn() bool { return true }
+
+// ValidateOptions implements OptionsValidator.
+func (m *domainModule) ValidateOptions(opts ModuleOptions) error {
+	_, err := m.parseOptions(opts)
+	return err
+}
+
+// parseOptions reads the timeout option.
+func (m *domainModule) parseOptions(opts ModuleOptions) (time.Duration, error) {
+	for key := range opts {
+		if key != domainOptionTimeout {
+			return 0, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	timeout, err := opts.Duration(domainOptionTimeout, defaultDomainTimeout)
+	if err != nil {
+		return 0, err
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("option %q must be positive", domainOptionTimeout)
+	}
+	return timeout, nil
+}
+
+// Analyze looks up the registrable domain of the page's host.
+func (m *domainModule) Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error) {
+	timeout, err := m.parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(info.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page URL: %v", err)
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(u.Hostname()))
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine the registered domain of %q: %v", u.Hostname(), err)
+	}
+
+	ctx, cancel, err := subrequestsFrom(ctx).one(ctx, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up domain %s: %v", domain, err)
+	}
+	defer cancel()
+	body, statusCode, err := m.httpClient.FetchWebpage(ctx, m.rdapURL+domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up domain %s: %v", domain, err)
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("RDAP lookup of %s returned HTTP %d", domain, statusCode)
+	}
+
+	registration, err := parseRDAPDomain(body)
+	if err != nil {
+		return nil, err
+	}
+	registration.Name = domain
+	if registration.CreatedAt != nil {
+		registration.AgeDays = int(m.now().Sub(*registration.CreatedAt).Hours() / 24)
+	}
+	findings := domainFindings(registration, m.now(), m.htmlParser.ExtractLoginForm(doc))
+
+	return ModuleResultFunc(func(a *WebpageAnalysis) {
+		a.Domain = registration
+		a.Findings = append(a.Findings, findings...)
+	}), nil
+}
+
+// rdapDomain is the part of an RDAP domain response the module reads.
+type rdapDomain struct {
+	Events []struct {
+		Action string    `json:"eventAction"`
+		Date   time.Time `json:"eventDate"`
+	} `json:"events"`
+	Entities []struct {
+		Roles      []string        `json:"roles"`
+		VCardArray json.RawMessage `json:"vcardArray"`
+	} `json:"entities"`
+}
+
+// parseRDAPDomain extracts the registration and expiration dates and the
+// registrar name from an RDAP domain response.
+func parseRDAPDomain(body []byte) (*DomainRegistration, error) {
+	var resp rdapDomain
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode RDAP response: %v", err)
+	}
+
+	registration := &DomainRegistration{}
+	for _, event := range resp.Events {
+		date := event.Date.UTC()
+		switch event.Action {
+		case "registration":
+			registration.CreatedAt = &date
+		case "expiration":
+			registration.ExpiresAt = &date
+		}
+	}
+	for _, entity := range resp.Entities {
+		for _, role := range entity.Roles {
+			if role == "registrar" {
+				registration.Registrar = vCardName(entity.VCardArray)
+			}
+		}
+	}
+	return registration, nil
+}
+
+// vCardName returns the formatted name ("fn") of a jCard, the JSON vCard form
+// RDAP uses: ["vcard", [["fn", {}, "text", "Name"], ...]].
+func vCardName(raw json.RawMessage) string {
+	var card []json.RawMessage
+	if err := json.Unmarshal(raw, &card); err != nil || len(card) < 2 {
+		return ""
+	}
+	var properties [][]interface{}
+	if err := json.Unmarshal(card[1], &properties); err != nil {
+		return ""
+	}
+	for _, property := range properties {
+		if len(property) < 4 || property[0] != "fn" {
+			continue
+		}
+		if name, ok := property[3].(string); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// domainFindings reports a newly registered domain, a common sign of phishing
+// sites. It is rated high when the page also asks for credentials.
+func domainFindings(registration *DomainRegistration, now time.Time, hasLoginForm bool) []Finding {
+	if registration.CreatedAt == nil || now.Sub(*registration.CreatedAt) >= youngDomainAge {
+		return nil
+	}
+
+	finding := Finding{
+		Type:     FindingYoungDomain,
+		Severity: SeverityMedium,
+		Message:  fmt.Sprintf("Domain %s was registered %d days ago", registration.Name, registration.AgeDays),
+		Evidence: registration.CreatedAt.Format(time.RFC3339),
+	}
+	if hasLoginForm {
+		finding.Severity = SeverityHigh
+		finding.Message = fmt.Sprintf("Login form on domain %s, registered %d days ago", registration.Name, registration.AgeDays)
+	}
+	return []Finding{finding}
+}
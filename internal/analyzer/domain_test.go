@@ -0,0 +1,90 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+const rdapResponse = `{
+	"objectClassName": "domain",
+	"ldhName": "EXAMPLE.COM",
+	"events": [
+		{"eventAction": "registration", "eventDate": "2024-03-01T10:00:00Z"},
+		{"eventAction": "expiration", "eventDate": "2025-03-01T10:00:00Z"},
+		{"eventAction": "last update of RDAP database", "eventDate": "2024-03-10T00:00:00Z"}
+	],
+	"entities": [
+		{"roles": ["registrar"], "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Example Registrar, Inc."]]]},
+		{"roles": ["abuse"], "vcardArray": ["vcard", [["fn", {}, "text", "Abuse Desk"]]]}
+	]
+}`
+
+func analyzeDomain(t *testing.T, page string, now time.Time) *WebpageAnalysis {
+	t.Helper()
+	module := &domainModule{
+		htmlParser: parser.NewHTMLParser(),
+		httpClient: &urlMockHTTPClient{responses: map[string]string{DefaultRDAPURL + "example.com": rdapResponse}},
+		rdapURL:    DefaultRDAPURL,
+		now:        func() time.Time { return now },
+	}
+	doc, err := html.Parse(strings.NewReader(page))
+	require.NoError(t, err)
+
+	result, err := module.Analyze(context.Background(), doc, FetchInfo{URL: "https://login.shop.example.com/account"}, nil)
+	require.NoError(t, err)
+	analysis := &WebpageAnalysis{}
+	result.Apply(analysis)
+	return analysis
+}
+
+func TestDomainModule(t *testing.T) {
+	analysis := analyzeDomain(t, `<html><body><p>Hello</p></body></html>`, time.Date(2024, 3, 11, 10, 0, 0, 0, time.UTC))
+
+	require.NotNil(t, analysis.Domain)
+	assert.Equal(t, "example.com", analysis.Domain.Name, "Subdomains should be stripped to the registered domain")
+	assert.Equal(t, "Example Registrar, Inc.", analysis.Domain.Registrar)
+	assert.Equal(t, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), *analysis.Domain.CreatedAt)
+	assert.Equal(t, time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC), *analysis.Domain.ExpiresAt)
+	assert.Equal(t, 10, analysis.Domain.AgeDays)
+
+	require.Len(t, analysis.Findings, 1)
+	assert.Equal(t, FindingYoungDomain, analysis.Findings[0].Type)
+	assert.Equal(t, SeverityMedium, analysis.Findings[0].Severity)
+}
+
+func TestDomainModule_YoungDomainWithLogin(t *testing.T) {
+	analysis := analyzeDomain(t, `<html><body><form action="/login"><input type="text" name="username"><input type="password" name="password"><button>Sign in</button></form></body></html>`,
+		time.Date(2024, 3, 11, 10, 0, 0, 0, time.UTC))
+
+	require.Len(t, analysis.Findings, 1)
+	assert.Equal(t, SeverityHigh, analysis.Findings[0].Severity, "A login form on a new domain should rate high")
+}
+
+func TestDomainModule_EstablishedDomain(t *testing.T) {
+	analysis := analyzeDomain(t, `<html></html>`, time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, 183, analysis.Domain.AgeDays)
+	assert.Empty(t, analysis.Findings)
+}
+
+func TestDomainModule_SubrequestsSpent(t *testing.T) {
+	module := &domainModule{
+		htmlParser: parser.NewHTMLParser(),
+		httpClient: &urlMockHTTPClient{responses: map[string]string{DefaultRDAPURL + "example.com": rdapResponse}},
+		rdapURL:    DefaultRDAPURL,
+		now:        time.Now,
+	}
+	ctx := withSubrequests(context.Background(), nil, time.Now())
+	subrequestsFrom(ctx).take(MaxSubrequests)
+
+	_, err := module.Analyze(ctx, nil, FetchInfo{URL: "https://example.com/"}, nil)
+	assert.ErrorContains(t, err, errSubrequestsSpent.Error(), "The lookup should count against the analysis' sub-requests")
+}
@@ -0,0 +1,236 @@
+package analyzer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/parser"
+)
+
+// Options accepted by the favicon module.
+const (
+	faviconOptionTimeout = "timeout" // duration: timeout for the favicon request.
+
+	defaultFaviconTimeout = 10 * time.Second
+
+	// maxFaviconSize bounds the favicons the module hashes; real ones are a
+	// few kilobytes.
+	maxFaviconSize = 1 << 20
+)
+
+// faviconModule downloads the page's favicon and hashes it, as Shodan does,
+// to fingerprint the technology behind a site or match phishing kits that
+// copy a brand's icon. It fetches another resource, so it only runs when
+// requested by name.
+type faviconModule struct {
+	htmlParser parser.HTMLParser
+	httpClient client.HTTPClient
+}
+
+func (m *faviconModule) Name() string { return ModuleFavicon }
+
+// OptIn implements OptInModule.
+func (m *faviconModule) OptIn() bool { return true }
+
+// ValidateOptions implements OptionsValidator.
+func (m *faviconModule) ValidateOptions(opts ModuleOptions) error {
+	_, err := m.parseOptions(opts)
+	return err
+}
+
+// parseOptions reads the timeout option.
+func (m *faviconModule) parseOptions(opts ModuleOptions) (time.Duration, error) {
+	for key := range opts {
+		if key != faviconOptionTimeout {
+			return 0, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	timeout, err := opts.Duration(faviconOptionTimeout, defaultFaviconTimeout)
+	if err != nil {
+		return 0, err
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("option %q must be positive", faviconOptionTimeout)
+	}
+	return timeout, nil
+}
+
+// Analyze fetches and hashes the favicon.
+func (m *faviconModule) Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error) {
+	timeout, err := m.parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	iconURL, err := FaviconURL(info.URL, pageFrom(ctx, doc, m.htmlParser).Elements("link"))
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	var contentType string
+	if strings.HasPrefix(iconURL, "data:") {
+		if data, contentType, err = decodeDataURL(iconURL); err != nil {
+			return nil, err
+		}
+	} else {
+		ctx, cancel, err := subrequestsFrom(ctx).one(ctx, timeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch favicon: %v", err)
+		}
+		defer cancel()
+		var response client.ResponseInfo
+		body, statusCode, err := m.httpClient.FetchWebpage(client.WithResponseInfo(ctx, &response), iconURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch favicon: %v", err)
+		}
+		if statusCode != http.StatusOK {
+			return nil, fmt.Errorf("favicon %s returned HTTP %d", iconURL, statusCode)
+		}
+		data, contentType = body, response.Header.Get("Content-Type")
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("favicon %s is empty", iconURL)
+	}
+	if len(data) > maxFaviconSize {
+		return nil, fmt.Errorf("favicon %s is larger than %d bytes", iconURL, maxFaviconSize)
+	}
+
+	sum := sha256.Sum256(data)
+	favicon := &Favicon{
+		URL:         iconURL,
+		Size:        len(data),
+		ContentType: contentType,
+		MMH3:        FaviconMMH3(data),
+		SHA256:      hex.EncodeToString(sum[:]),
+	}
+	if strings.HasPrefix(iconURL, "data:") {
+		favicon.URL = "" // Repeating the icon itself is not useful.
+		favicon.Inline = true
+	}
+	return ModuleResultFunc(func(a *WebpageAnalysis) { a.Favicon = favicon }), nil
+}
+
+// FaviconURL returns the URL of the page's primary favicon: the first <link>
+// whose rel is "icon" (or "shortcut icon"), then the first apple-touch-icon,
+// then /favicon.ico on the page's host. Relative URLs are resolved against
+// pageURL; data: URLs are returned as they are.
+func FaviconURL(pageURL string, links []parser.Element) (string, error) {
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid page URL: %v", err)
+	}
+	var icon, touchIcon string
+	for _, link := range links {
+		href := strings.TrimSpace(link.Attr("href"))
+		if href == "" {
+			continue
+		}
+		for _, rel := range strings.Fields(strings.ToLower(link.Attr("rel"))) {
+			switch {
+			case rel == "icon" && icon == "":
+				icon = href
+			case (rel == "apple-touch-icon" || rel == "apple-touch-icon-precomposed") && touchIcon == "":
+				touchIcon = href
+			}
+		}
+	}
+	href := firstNonEmpty(icon, touchIcon, "/favicon.ico")
+	if strings.HasPrefix(href, "data:") {
+		return href, nil
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("invalid favicon URL %q: %v", href, err)
+	}
+	return page.ResolveReference(ref).String(), nil
+}
+
+// decodeDataURL decodes a base64 or URL-encoded data: URL.
+func decodeDataURL(dataURL string) ([]byte, string, error) {
+	meta, payload, ok := strings.Cut(strings.TrimPrefix(dataURL, "data:"), ",")
+	if !ok {
+		return nil, "", errors.New("invalid data URL favicon")
+	}
+	contentType, isBase64 := strings.CutSuffix(meta, ";base64")
+	if isBase64 {
+		data, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid base64 in data URL favicon: %v", err)
+		}
+		return data, contentType, nil
+	}
+	data, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid data URL favicon: %v", err)
+	}
+	return []byte(data), contentType, nil
+}
+
+// FaviconMMH3 returns the favicon hash Shodan indexes as http.favicon.hash:
+// the signed 32-bit MurmurHash3 of the icon's base64 encoding, wrapped at 76
+// characters with a trailing newline as Python's base64.encodebytes does.
+func FaviconMMH3(data []byte) int32 {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var wrapped strings.Builder
+	for len(encoded) > 76 {
+		wrapped.WriteString(encoded[:76])
+		wrapped.WriteByte('\n')
+		encoded = encoded[76:]
+	}
+	wrapped.WriteString(encoded)
+	wrapped.WriteByte('\n')
+	return int32(murmur3(wrapped.String(), 0))
+}
+
+// murmur3 is the x86 32-bit MurmurHash3 of s.
+func murmur3(s string, seed uint32) uint32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+	h := seed
+	data := []byte(s)
+	nblocks := len(data) / 4
+	for i := 0; i < nblocks; i++ {
+		k := binary.LittleEndian.Uint32(data[i*4:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+	var k uint32
+	tail := data[nblocks*4:]
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
@@ -0,0 +1,91 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/parser"
+)
+
+func TestMurmur3(t *testing.T) {
+	assert.Equal(t, uint32(0), murmur3("", 0))
+	assert.Equal(t, uint32(0x248bfa47), murmur3("hello", 0))
+	assert.Equal(t, uint32(0x2e4ff723), murmur3("The quick brown fox jumps over the lazy dog", 0))
+}
+
+func TestFaviconMMH3_WrapsBase64(t *testing.T) {
+	data := []byte(strings.Repeat("x", 100)) // 136 base64 characters: one full line and a partial one.
+	encoded := "eHh4"                        // base64 of "xxx".
+	want := strings.Repeat(encoded, 19) + "\n" + strings.Repeat(encoded, 14) + "eA==\n"
+	assert.Equal(t, int32(murmur3(want, 0)), FaviconMMH3(data))
+}
+
+func TestFaviconURL(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{"default", `<link rel="stylesheet" href="/a.css">`, "https://example.com/favicon.ico"},
+		{"shortcut icon", `<link rel="apple-touch-icon" href="/touch.png"><link rel="Shortcut Icon" href="img/fav.png">`,
+			"https://example.com/blog/img/fav.png"},
+		{"touch icon only", `<link rel="apple-touch-icon" href="https://cdn.example.net/touch.png">`, "https://cdn.example.net/touch.png"},
+		{"data url", `<link rel="icon" href="data:image/png;base64,AAEC">`, "data:image/png;base64,AAEC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.page))
+			require.NoError(t, err)
+			got, err := FaviconURL("https://example.com/blog/post", parser.NewHTMLParser().ExtractElements(doc, "link"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFaviconModule(t *testing.T) {
+	icon := []byte("\x00\x00\x01\x00icon")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><head><link rel="icon" href="/static/icon.ico"></head></html>`))
+		case "/static/icon.ico":
+			w.Header().Set("Content-Type", "image/x-icon")
+			w.Write(icon)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	service := NewService(WithHTTPClient(client.NewHTTPClient()))
+	analysis, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: server.URL + "/", Modules: []string{ModuleFavicon}})
+	require.NoError(t, err)
+	require.NotNil(t, analysis.Favicon)
+	assert.Equal(t, server.URL+"/static/icon.ico", analysis.Favicon.URL)
+	assert.Equal(t, "image/x-icon", analysis.Favicon.ContentType)
+	assert.Equal(t, len(icon), analysis.Favicon.Size)
+	assert.Equal(t, FaviconMMH3(icon), analysis.Favicon.MMH3)
+	assert.Len(t, analysis.Favicon.SHA256, 64)
+}
+
+func TestFaviconModule_DataURL(t *testing.T) {
+	page := `<link rel="icon" href="data:image/png;base64,AAEC">`
+	service := NewService(WithHTTPClient(&mockHTTPClient{response: page}))
+
+	analysis, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://example.com", Modules: []string{ModuleFavicon}})
+	require.NoError(t, err)
+	require.NotNil(t, analysis.Favicon)
+	assert.True(t, analysis.Favicon.Inline)
+	assert.Empty(t, analysis.Favicon.URL)
+	assert.Equal(t, "image/png", analysis.Favicon.ContentType)
+	assert.Equal(t, 3, analysis.Favicon.Size)
+}
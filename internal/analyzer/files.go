@@ -0,0 +1,209 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"webpage-analyzer/internal/logging"
+)
+
+// htmlFile is an HTML file found on disk. rel is its path below the
+// directory or pattern it was found by, which maps it to a URL.
+type htmlFile struct {
+	path string
+	rel  string
+}
+
+// AnalyzeFiles analyzes HTML files on disk with svc, as AnalyzeHTML would if
+// their content were uploaded, so the same modules run and nothing is fetched.
+// Files that cannot be read or analyzed are reported in Errors; only invalid
+// requests, paths matching no files and cancellation are returned as errors.
+func AnalyzeFiles(ctx context.Context, svc Service, req FilesRequest) (*FilesResult, error) {
+	startTime := time.Now()
+
+	base, err := filesBaseURL(req.BaseURL)
+	if err != nil {
+		return nil, &AnalysisError{
+			StatusCode:   http.StatusBadRequest,
+			Code:         ErrorCodeInvalidRequest,
+			ErrorMessage: fmt.Sprintf("Invalid base URL: %v", err),
+			URL:          req.BaseURL,
+		}
+	}
+	files, err := findHTMLFiles(req.Paths)
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("Starting file analysis", "files", len(files), "base_url", req.BaseURL)
+
+	result := &FilesResult{Pages: make([]*WebpageAnalysis, 0, len(files))}
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		pageURL, err := fileURL(file, base)
+		if err != nil {
+			result.Errors = append(result.Errors, fileError(file.path, err))
+			continue
+		}
+		content, err := os.ReadFile(file.path)
+		if err != nil {
+			result.Errors = append(result.Errors, fileError(pageURL, err))
+			continue
+		}
+
+		htmlReq := HTMLRequest{HTML: string(content), Modules: req.Modules}
+		if base != nil {
+			htmlReq.BaseURL = pageURL
+		}
+		analysis, err := svc.AnalyzeHTML(ctx, htmlReq)
+		if err != nil {
+			if analysisErr, ok := err.(*AnalysisError); ok {
+				analysisErr.URL = pageURL
+				result.Errors = append(result.Errors, analysisErr)
+				continue
+			}
+			return nil, err
+		}
+		analysis.URL = pageURL
+		result.Pages = append(result.Pages, analysis)
+	}
+
+	result.ProcessingTime = time.Since(startTime).String()
+	logging.FromContext(ctx).Info("File analysis completed", "analyzed", len(result.Pages), "failed", len(result.Errors), "processing_time", result.ProcessingTime)
+	return result, nil
+}
+
+// findHTMLFiles expands paths into the HTML files they name, sorted and
+// without duplicates. A path that names no file is an error.
+func findHTMLFiles(paths []string) ([]htmlFile, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no files given")
+	}
+
+	seen := make(map[string]bool)
+	var files []htmlFile
+	add := func(path, rel string) {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, htmlFile{path: path, rel: rel})
+		}
+	}
+
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		switch {
+		case err == nil && info.IsDir():
+			before := len(files)
+			err := filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() && isHTMLFile(path) {
+					rel, err := filepath.Rel(p, path)
+					if err != nil {
+						return err
+					}
+					add(path, rel)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to search %s: %v", p, err)
+			}
+			if len(files) == before {
+				return nil, fmt.Errorf("no .html or .htm files in %s", p)
+			}
+		case err == nil:
+			add(p, filepath.Base(p))
+		default:
+			matches, globErr := filepath.Glob(p)
+			if globErr != nil {
+				return nil, fmt.Errorf("invalid pattern %s: %v", p, globErr)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %s", p)
+			}
+			root := globRoot(p)
+			for _, match := range matches {
+				if info, err := os.Stat(match); err != nil || info.IsDir() {
+					continue
+				}
+				rel, err := filepath.Rel(root, match)
+				if err != nil {
+					return nil, err
+				}
+				add(match, rel)
+			}
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	return files, nil
+}
+
+// isHTMLFile reports whether path has an HTML file extension.
+func isHTMLFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		return true
+	default:
+		return false
+	}
+}
+
+// globRoot returns the directory part of pattern before its first wildcard.
+func globRoot(pattern string) string {
+	root := filepath.Dir(pattern)
+	for strings.ContainsAny(root, `*?[\`) && root != filepath.Dir(root) {
+		root = filepath.Dir(root)
+	}
+	return root
+}
+
+// filesBaseURL parses baseURL as a directory URL, or returns nil if it is empty.
+func filesBaseURL(baseURL string) (*url.URL, error) {
+	if err := validateBaseURL(baseURL); err != nil || baseURL == "" {
+		return nil, err
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+	return base, nil
+}
+
+// fileURL returns the URL file is served from below base, or its file://
+// URL when base is nil.
+func fileURL(file htmlFile, base *url.URL) (string, error) {
+	if base == nil {
+		abs, err := filepath.Abs(file.path)
+		if err != nil {
+			return "", err
+		}
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String(), nil
+	}
+	return base.ResolveReference(&url.URL{Path: filepath.ToSlash(file.rel)}).String(), nil
+}
+
+// fileError reports a file that could not be read.
+func fileError(fileURL string, err error) *AnalysisError {
+	return &AnalysisError{
+		StatusCode:   http.StatusInternalServerError,
+		Code:         ErrorCodeFetchFailure,
+		ErrorMessage: fmt.Sprintf("Failed to read file: %v", err),
+		URL:          fileURL,
+	}
+}
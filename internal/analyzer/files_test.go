@@ -0,0 +1,125 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/worker"
+)
+
+// writeSite writes files, keyed by slash-separated path, below a temporary
+// directory and returns it.
+func writeSite(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func newFilesTestService() Service {
+	// The client fails every fetch, so any network access would show.
+	return NewServiceWithDependencies(&mockHTTPClient{error: errors.New("no network")}, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+}
+
+func TestAnalyzeFiles_Directory(t *testing.T) {
+	dir := writeSite(t, map[string]string{
+		"index.html":      `<title>Home</title><a href="https://example.com/blog/">Blog</a>`,
+		"blog/post.HTM":   `<title>Post</title><h1>Post</h1>`,
+		"assets/app.css":  `body {}`,
+		"blog/empty.html": ``,
+	})
+
+	result, err := AnalyzeFiles(context.Background(), newFilesTestService(), FilesRequest{
+		Paths:   []string{dir},
+		BaseURL: "https://example.com",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Pages, 2)
+	assert.Equal(t, "https://example.com/blog/post.HTM", result.Pages[0].URL)
+	assert.Equal(t, 1, result.Pages[0].Headings["h1"])
+	assert.Equal(t, "https://example.com/index.html", result.Pages[1].URL)
+	assert.Equal(t, 1, result.Pages[1].InternalLinks, "Links should be classified against the file's URL")
+
+	require.Len(t, result.Errors, 1, "Empty files should be reported without stopping the run")
+	assert.Equal(t, "https://example.com/blog/empty.html", result.Errors[0].URL)
+	assert.NotEmpty(t, result.ProcessingTime)
+}
+
+func TestAnalyzeFiles_GlobAndFileURLs(t *testing.T) {
+	dir := writeSite(t, map[string]string{
+		"a/index.html": `<title>A</title><a href="https://example.com/">Out</a>`,
+		"b/index.html": `<title>B</title>`,
+		"b/other.html": `<title>Other</title>`,
+	})
+
+	result, err := AnalyzeFiles(context.Background(), newFilesTestService(), FilesRequest{
+		Paths:   []string{filepath.Join(dir, "*", "index.html"), filepath.Join(dir, "a", "index.html")},
+		Modules: []string{ModulePageTitle, ModuleLinks},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Pages, 2, "Files matched twice should be analyzed once")
+	assert.Equal(t, "A", result.Pages[0].PageTitle)
+	assert.Equal(t, "B", result.Pages[1].PageTitle)
+	assert.Equal(t, "file://"+filepath.ToSlash(filepath.Join(dir, "a", "index.html")), result.Pages[0].URL)
+	assert.Equal(t, 1, result.Pages[0].ExternalLinks)
+}
+
+func TestAnalyzeFiles_GlobMapsToBaseURL(t *testing.T) {
+	dir := writeSite(t, map[string]string{"docs/guide/index.html": `<title>Guide</title>`})
+
+	result, err := AnalyzeFiles(context.Background(), newFilesTestService(), FilesRequest{
+		Paths:   []string{filepath.Join(dir, "docs", "*", "*.html")},
+		BaseURL: "https://example.com/docs/",
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Pages, 1)
+	assert.Equal(t, "https://example.com/docs/guide/index.html", result.Pages[0].URL)
+}
+
+func TestAnalyzeFiles_InvalidRequests(t *testing.T) {
+	dir := writeSite(t, map[string]string{"notes.txt": "hi"})
+	svc := newFilesTestService()
+
+	tests := []struct {
+		name string
+		req  FilesRequest
+	}{
+		{"no paths", FilesRequest{}},
+		{"missing file", FilesRequest{Paths: []string{filepath.Join(dir, "missing.html")}}},
+		{"directory without HTML", FilesRequest{Paths: []string{dir}}},
+		{"invalid base URL", FilesRequest{Paths: []string{dir}, BaseURL: "example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := AnalyzeFiles(context.Background(), svc, tt.req)
+			assert.Error(t, err)
+			assert.Nil(t, result)
+		})
+	}
+}
+
+func TestAnalyzeFiles_NetworkModule(t *testing.T) {
+	dir := writeSite(t, map[string]string{"index.html": `<title>Home</title>`})
+
+	result, err := AnalyzeFiles(context.Background(), newFilesTestService(), FilesRequest{
+		Paths:   []string{dir},
+		Modules: []string{ModuleWayback},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, result.Pages)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0].ErrorMessage, "queries the network")
+}
@@ -0,0 +1,142 @@
+package analyzer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// Options accepted by the content hash module.
+const (
+	contentOptionSimHash = "simhash" // bool: also compute the simhash fingerprint (default true).
+
+	// simHashShingleSize is the number of consecutive words hashed as one simhash feature.
+	simHashShingleSize = 3
+)
+
+// contentHashModule fingerprints a page's visible text for change detection.
+type contentHashModule struct {
+	htmlParser parser.HTMLParser
+}
+
+func (m *contentHashModule) Name() string { return ModuleContentHash }
+
+// ValidateOptions implements OptionsValidator.
+func (m *contentHashModule) ValidateOptions(opts ModuleOptions) error {
+	_, err := m.parseOptions(opts)
+	return err
+}
+
+// parseOptions reads the simhash option.
+func (m *contentHashModule) parseOptions(opts ModuleOptions) (bool, error) {
+	for key := range opts {
+		if key != contentOptionSimHash {
+			return false, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	return opts.Bool(contentOptionSimHash, true)
+}
+
+// Analyze hashes the normalized visible text of the page.
+func (m *contentHashModule) Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error) {
+	withSimHash, err := m.parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	words := pageFrom(ctx, doc, m.htmlParser).Words()
+	contentHash := ContentHash(words)
+	simHash := ""
+	if withSimHash {
+		simHash = FormatSimHash(SimHash(words))
+	}
+
+	return ModuleResultFunc(func(a *WebpageAnalysis) {
+		a.ContentHash = contentHash
+		a.SimHash = simHash
+	}), nil
+}
+
+// normalizedWords lower-cases text and splits it into words, dropping punctuation,
+// so that formatting-only edits do not change the fingerprints.
+func normalizedWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// ContentHash returns the hex SHA-256 of the normalized words. Pages with the
+// same words in the same order have the same hash.
+func ContentHash(words []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(words, " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// SimHash returns a 64-bit simhash of the words, using overlapping shingles of
+// simHashShingleSize words as features. Similar texts get hashes that differ
+// in few bits; see HammingDistance.
+func SimHash(words []string) uint64 {
+	if len(words) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	h := fnv.New64a()
+	var shingle []byte
+	shingles := len(words) - simHashShingleSize + 1
+	if shingles < 1 {
+		shingles = 1
+	}
+	for i := 0; i < shingles; i++ {
+		shingle = shingle[:0]
+		for j, word := range words[i:min(i+simHashShingleSize, len(words))] {
+			if j > 0 {
+				shingle = append(shingle, ' ')
+			}
+			shingle = append(shingle, word...)
+		}
+		h.Reset()
+		h.Write(shingle)
+		feature := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if feature&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var hash uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			hash |= 1 << bit
+		}
+	}
+	return hash
+}
+
+// FormatSimHash renders a simhash as 16 hex digits, the form used in results.
+func FormatSimHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// ParseSimHash parses a simhash produced by FormatSimHash.
+func ParseSimHash(s string) (uint64, error) {
+	return strconv.ParseUint(s, 16, 64)
+}
+
+// HammingDistance returns the number of bits in which two simhashes differ.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
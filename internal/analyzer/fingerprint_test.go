@@ -0,0 +1,96 @@
+package analyzer
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+func analyzeContent(t *testing.T, page string, opts ModuleOptions) *WebpageAnalysis {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(page))
+	require.NoError(t, err)
+
+	module := &contentHashModule{htmlParser: parser.NewHTMLParser()}
+	result, err := module.Analyze(context.Background(), doc, FetchInfo{}, opts)
+	require.NoError(t, err)
+
+	analysis := &WebpageAnalysis{}
+	result.Apply(analysis)
+	return analysis
+}
+
+func TestContentHashModule_IgnoresFormattingAndScripts(t *testing.T) {
+	a := analyzeContent(t, `<html><body><h1>Hello, World</h1><p>Some text.</p></body></html>`, nil)
+	b := analyzeContent(t, `<html><body>
+		<h1>hello   world!</h1>
+		<p>Some <em>text</em></p>
+		<script>track()</script>
+	</body></html>`, nil)
+
+	assert.Len(t, a.ContentHash, 64, "ContentHash should be a hex SHA-256")
+	assert.Equal(t, a.ContentHash, b.ContentHash, "Whitespace, case, punctuation and scripts should not change the hash")
+	assert.Equal(t, a.SimHash, b.SimHash)
+
+	c := analyzeContent(t, `<html><body><h1>Hello, World</h1><p>Other text.</p></body></html>`, nil)
+	assert.NotEqual(t, a.ContentHash, c.ContentHash, "Changed wording should change the hash")
+}
+
+func TestContentHashModule_SimHashOption(t *testing.T) {
+	module := &contentHashModule{}
+	assert.Error(t, module.ValidateOptions(ModuleOptions{"unknown": true}))
+
+	analysis := analyzeContent(t, `<p>Some text</p>`, ModuleOptions{"simhash": false})
+	assert.NotEmpty(t, analysis.ContentHash)
+	assert.Empty(t, analysis.SimHash, "simhash=false should skip the simhash")
+}
+
+func TestSimHash_NearDuplicates(t *testing.T) {
+	base := strings.Repeat("the quick brown fox jumps over the lazy dog while the cat sleeps ", 10)
+	near := normalizedWords(base + "today")
+	far := normalizedWords(strings.Repeat("lorem ipsum dolor sit amet consectetur adipiscing elit sed do ", 10))
+
+	h := SimHash(normalizedWords(base))
+	assert.Less(t, HammingDistance(h, SimHash(near)), 10, "Near-identical texts should have close simhashes")
+	assert.Greater(t, HammingDistance(h, SimHash(far)), 10, "Unrelated texts should have distant simhashes")
+
+	parsed, err := ParseSimHash(FormatSimHash(h))
+	require.NoError(t, err)
+	assert.Equal(t, h, parsed, "ParseSimHash should invert FormatSimHash")
+}
+
+func TestSimHash_HashesJoinedShingles(t *testing.T) {
+	words := normalizedWords("one two three four five")
+
+	var weights [64]int
+	for i := 0; i+simHashShingleSize <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+simHashShingleSize], " ")))
+		for bit := 0; bit < 64; bit++ {
+			if h.Sum64()&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+	var want uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			want |= 1 << bit
+		}
+	}
+
+	assert.Equal(t, want, SimHash(words), "Shingles should hash as their words joined by spaces, as stored simhashes were computed")
+
+	short := fnv.New64a()
+	short.Write([]byte("one two"))
+	assert.Equal(t, short.Sum64(), SimHash(normalizedWords("One, two")), "Texts shorter than a shingle should hash as one")
+}
@@ -0,0 +1,253 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Options accepted by the https module.
+const (
+	httpsOptionTimeout = "timeout" // duration: timeout for each probe request.
+
+	defaultHTTPSTimeout = 10 * time.Second
+	maxHTTPSRedirects   = 5
+
+	// HSTS max-age thresholds used for grading, in seconds.
+	hstsMinMaxAge     = 180 * 24 * 60 * 60 // The commonly recommended minimum.
+	hstsPreloadMaxAge = 365 * 24 * 60 * 60 // Required by the browser preload list.
+)
+
+// httpsModule probes how a site moves visitors to HTTPS: whether plain HTTP
+// redirects to HTTPS, and whether HTTPS responses carry an HSTS policy. It
+// sends its own requests, so it only runs when requested by name.
+type httpsModule struct {
+	client *http.Client
+	// httpPort and httpsPort override the default ports; tests point them at local servers.
+	httpPort, httpsPort string
+}
+
+// newHTTPSModule creates the https module with a client that does not follow redirects.
+func newHTTPSModule() *httpsModule {
+	return &httpsModule{client: &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+func (m *httpsModule) Name() string { return ModuleHTTPS }
+
+// OptIn implements OptInModule.
+func (m *httpsModule) OptIn() bool { return true }
+
+// ValidateOptions implements OptionsValidator.
+func (m *httpsModule) ValidateOptions(opts ModuleOptions) error {
+	_, err := m.parseOptions(opts)
+	return err
+}
+
+// parseOptions reads the timeout option.
+func (m *httpsModule) parseOptions(opts ModuleOptions) (time.Duration, error) {
+	for key := range opts {
+		if key != httpsOptionTimeout {
+			return 0, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	timeout, err := opts.Duration(httpsOptionTimeout, defaultHTTPSTimeout)
+	if err != nil {
+		return 0, err
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("option %q must be positive", httpsOptionTimeout)
+	}
+	return timeout, nil
+}
+
+// Analyze probes http://host/ and https://host/ and grades the result.
+func (m *httpsModule) Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error) {
+	timeout, err := m.parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(info.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page URL: %v", err)
+	}
+	host := u.Hostname()
+
+	posture := &HTTPSPosture{}
+	if resp, err := m.get(ctx, m.origin("https", host), timeout); err == nil {
+		posture.HTTPSAvailable = true
+		posture.HSTS = parseHSTS(resp.Header.Get("Strict-Transport-Security"))
+	}
+	posture.RedirectChain, posture.RedirectsToHTTPS = m.followRedirects(ctx, m.origin("http", host), timeout)
+	posture.Grade = gradeHTTPS(posture)
+	findings := httpsFindings(host, posture)
+
+	return ModuleResultFunc(func(a *WebpageAnalysis) {
+		a.HTTPS = posture
+		a.Findings = append(a.Findings, findings...)
+	}), nil
+}
+
+// origin returns the root URL of host for the scheme, honouring port overrides.
+func (m *httpsModule) origin(scheme, host string) string {
+	port := m.httpPort
+	if scheme == "https" {
+		port = m.httpsPort
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6 literal.
+	}
+	return scheme + "://" + host + "/"
+}
+
+// get sends one GET request, as a sub-request of the analysis, without
+// following redirects and drains the body.
+func (m *httpsModule) get(ctx context.Context, target string, timeout time.Duration) (*http.Response, error) {
+	ctx, cancel, err := subrequestsFrom(ctx).one(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "WebpageAnalyzer/1.0")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	resp.Body.Close()
+	return resp, nil
+}
+
+// followRedirects follows redirects from start and returns the URLs visited
+// after it, and whether the chain ends on HTTPS.
+func (m *httpsModule) followRedirects(ctx context.Context, start string, timeout time.Duration) ([]string, bool) {
+	var chain []string
+	current := start
+	for i := 0; i < maxHTTPSRedirects; i++ {
+		resp, err := m.get(ctx, current, timeout)
+		if err != nil {
+			return chain, false
+		}
+		location := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || location == "" {
+			break
+		}
+		base, _ := url.Parse(current)
+		next, err := base.Parse(location)
+		if err != nil {
+			return chain, false
+		}
+		current = next.String()
+		chain = append(chain, current)
+		if next.Scheme == "https" {
+			return chain, true
+		}
+	}
+	return chain, false
+}
+
+// parseHSTS parses a Strict-Transport-Security header, or returns nil if it is
+// absent or has no valid max-age.
+func parseHSTS(header string) *HSTSPolicy {
+	if header == "" {
+		return nil
+	}
+	policy := &HSTSPolicy{Header: header, MaxAge: -1}
+	for _, directive := range strings.Split(header, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "max-age":
+			if seconds, err := strconv.ParseInt(strings.Trim(strings.TrimSpace(value), `"`), 10, 64); err == nil && seconds >= 0 {
+				policy.MaxAge = seconds
+			}
+		case "includesubdomains":
+			policy.IncludeSubDomains = true
+		case "preload":
+			policy.Preload = true
+		}
+	}
+	if policy.MaxAge < 0 {
+		return nil // max-age is required; browsers ignore the header without it.
+	}
+	return policy
+}
+
+// gradeHTTPS grades the posture from A+ to F:
+//
+//	A+  HTTP redirects to HTTPS and HSTS is preload-ready (1 year, includeSubDomains, preload)
+//	A   HTTP redirects to HTTPS and HSTS lasts at least 180 days
+//	B   HTTP redirects to HTTPS and HSTS is shorter
+//	C   HTTP redirects to HTTPS without HSTS, or HSTS is set but HTTP does not redirect
+//	D   HTTPS works, but HTTP neither redirects nor is covered by HSTS
+//	F   HTTPS is not available
+func gradeHTTPS(p *HTTPSPosture) string {
+	hsts := p.HSTS != nil && p.HSTS.MaxAge > 0
+	switch {
+	case !p.HTTPSAvailable:
+		return "F"
+	case !p.RedirectsToHTTPS && !hsts:
+		return "D"
+	case !p.RedirectsToHTTPS || !hsts:
+		return "C"
+	case p.HSTS.MaxAge < hstsMinMaxAge:
+		return "B"
+	case p.HSTS.MaxAge >= hstsPreloadMaxAge && p.HSTS.IncludeSubDomains && p.HSTS.Preload:
+		return "A+"
+	default:
+		return "A"
+	}
+}
+
+// httpsFindings reports the weaknesses behind a grade below A.
+func httpsFindings(host string, p *HTTPSPosture) []Finding {
+	if !p.HTTPSAvailable {
+		return []Finding{{
+			Type:     FindingNoHTTPS,
+			Severity: SeverityHigh,
+			Message:  "Site " + host + " is not available over HTTPS",
+		}}
+	}
+
+	var findings []Finding
+	if !p.RedirectsToHTTPS {
+		findings = append(findings, Finding{
+			Type:     FindingNoHTTPSRedirect,
+			Severity: SeverityMedium,
+			Message:  "Plain HTTP requests to " + host + " are not redirected to HTTPS",
+		})
+	}
+	switch {
+	case p.HSTS == nil || p.HSTS.MaxAge == 0:
+		findings = append(findings, Finding{
+			Type:     FindingHSTSMissing,
+			Severity: SeverityLow,
+			Message:  "HTTPS responses do not set an HSTS policy",
+		})
+	case p.HSTS.MaxAge < hstsMinMaxAge:
+		findings = append(findings, Finding{
+			Type:     FindingHSTSWeak,
+			Severity: SeverityLow,
+			Message:  fmt.Sprintf("HSTS max-age is %d seconds, under the recommended 180 days", p.HSTS.MaxAge),
+			Evidence: p.HSTS.Header,
+		})
+	}
+	return findings
+}
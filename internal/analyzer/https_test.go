@@ -0,0 +1,137 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// probeHTTPS runs the https module against a local HTTPS server that sends the
+// given HSTS header, and a local HTTP server built by plain from the HTTPS
+// server's URL.
+func probeHTTPS(t *testing.T, hsts string, plain func(httpsURL string) http.HandlerFunc) *HTTPSPosture {
+	t.Helper()
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hsts != "" {
+			w.Header().Set("Strict-Transport-Security", hsts)
+		}
+	}))
+	defer tlsServer.Close()
+	httpServer := httptest.NewServer(plain(tlsServer.URL + "/"))
+	defer httpServer.Close()
+
+	module := newHTTPSModule()
+	module.client.Transport = tlsServer.Client().Transport // Trust the test certificate.
+	module.httpPort = (&url.URL{Host: httpServer.Listener.Addr().String()}).Port()
+	module.httpsPort = (&url.URL{Host: tlsServer.Listener.Addr().String()}).Port()
+
+	result, err := module.Analyze(context.Background(), nil, FetchInfo{URL: "https://127.0.0.1/page"}, nil)
+	require.NoError(t, err)
+	analysis := &WebpageAnalysis{}
+	result.Apply(analysis)
+	require.NotNil(t, analysis.HTTPS)
+	return analysis.HTTPS
+}
+
+// redirectTo redirects every request to target.
+func redirectTo(target string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	}
+}
+
+// servePlain serves every request over HTTP without redirecting.
+func servePlain(string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("<html></html>")) }
+}
+
+func TestHTTPSModule_PreloadReady(t *testing.T) {
+	posture := probeHTTPS(t, "max-age=63072000; includeSubDomains; preload", redirectTo)
+
+	assert.True(t, posture.HTTPSAvailable)
+	assert.True(t, posture.RedirectsToHTTPS)
+	assert.Len(t, posture.RedirectChain, 1)
+	require.NotNil(t, posture.HSTS)
+	assert.Equal(t, int64(63072000), posture.HSTS.MaxAge)
+	assert.True(t, posture.HSTS.IncludeSubDomains)
+	assert.True(t, posture.HSTS.Preload)
+	assert.Equal(t, "A+", posture.Grade)
+}
+
+func TestHTTPSModule_RedirectChain(t *testing.T) {
+	posture := probeHTTPS(t, "max-age=300", func(httpsURL string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/" {
+				http.Redirect(w, r, "/www", http.StatusFound) // Same-scheme hop first.
+				return
+			}
+			http.Redirect(w, r, httpsURL, http.StatusMovedPermanently)
+		}
+	})
+
+	assert.True(t, posture.RedirectsToHTTPS)
+	assert.Len(t, posture.RedirectChain, 2)
+	assert.Equal(t, "B", posture.Grade, "A short max-age should grade B")
+}
+
+func TestHTTPSModule_NoRedirectNoHSTS(t *testing.T) {
+	posture := probeHTTPS(t, "", servePlain)
+
+	assert.True(t, posture.HTTPSAvailable)
+	assert.False(t, posture.RedirectsToHTTPS)
+	assert.Nil(t, posture.HSTS)
+	assert.Equal(t, "D", posture.Grade)
+}
+
+func TestGradeHTTPS(t *testing.T) {
+	year := &HSTSPolicy{MaxAge: hstsPreloadMaxAge}
+	tests := []struct {
+		name    string
+		posture HTTPSPosture
+		want    string
+	}{
+		{"no https", HTTPSPosture{}, "F"},
+		{"hsts without redirect", HTTPSPosture{HTTPSAvailable: true, HSTS: year}, "C"},
+		{"redirect without hsts", HTTPSPosture{HTTPSAvailable: true, RedirectsToHTTPS: true}, "C"},
+		{"hsts max-age 0", HTTPSPosture{HTTPSAvailable: true, RedirectsToHTTPS: true, HSTS: &HSTSPolicy{}}, "C"},
+		{"long hsts, not preload-ready", HTTPSPosture{HTTPSAvailable: true, RedirectsToHTTPS: true, HSTS: year}, "A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, gradeHTTPS(&tt.posture))
+		})
+	}
+}
+
+func TestHTTPSFindings(t *testing.T) {
+	findings := httpsFindings("example.com", &HTTPSPosture{})
+	require.Len(t, findings, 1)
+	assert.Equal(t, FindingNoHTTPS, findings[0].Type)
+	assert.Equal(t, SeverityHigh, findings[0].Severity)
+
+	findings = httpsFindings("example.com", &HTTPSPosture{HTTPSAvailable: true})
+	require.Len(t, findings, 2)
+	assert.Equal(t, FindingNoHTTPSRedirect, findings[0].Type)
+	assert.Equal(t, FindingHSTSMissing, findings[1].Type)
+
+	findings = httpsFindings("example.com", &HTTPSPosture{HTTPSAvailable: true, RedirectsToHTTPS: true, HSTS: &HSTSPolicy{MaxAge: 300, Header: "max-age=300"}})
+	require.Len(t, findings, 1)
+	assert.Equal(t, FindingHSTSWeak, findings[0].Type)
+	assert.Equal(t, "max-age=300", findings[0].Evidence)
+}
+
+func TestParseHSTS(t *testing.T) {
+	policy := parseHSTS(`max-age="31536000"; IncludeSubDomains`)
+	require.NotNil(t, policy)
+	assert.Equal(t, int64(31536000), policy.MaxAge)
+	assert.True(t, policy.IncludeSubDomains)
+	assert.False(t, policy.Preload)
+
+	assert.Nil(t, parseHSTS("includeSubDomains"), "A policy without max-age should be ignored")
+	assert.Nil(t, parseHSTS(""))
+}
@@ -0,0 +1,171 @@
+package analyzer
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Image audit issues.
+const (
+	ImageIssueNotLazy           = "not_lazy"           // Below the first few images and loaded eagerly.
+	ImageIssueMissingDimensions = "missing_dimensions" // No width or height, so the layout shifts when it loads.
+	ImageIssueNoSrcset          = "no_srcset"          // A raster image in a single resolution.
+	ImageIssueMissingSizes      = "missing_sizes"      // Width descriptors in srcset without sizes.
+	ImageIssueLegacyFormat      = "legacy_format"      // JPEG, PNG or GIF without a WebP or AVIF alternative.
+)
+
+const (
+	// eagerImages is the number of images, in document order, assumed to be
+	// near the top of the page, where lazy loading delays what users see
+	// first. Later images should be lazy.
+	eagerImages = 3
+	// maxImageOffenders bounds the images listed in an audit.
+	maxImageOffenders = 10
+)
+
+// modernImageFormats lists the file extensions and MIME types of WebP and
+// AVIF, legacyImageFormats the extensions of the formats they replace.
+var (
+	modernImageFormats = map[string]bool{".webp": true, ".avif": true, "image/webp": true, "image/avif": true}
+	legacyImageFormats = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true}
+)
+
+// imagesModule audits how the page's images load.
+func imagesModule() AnalyzerModule {
+	return NewModule(ModuleImages, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		audit := AuditImages(doc)
+		return ModuleResultFunc(func(a *WebpageAnalysis) { a.Images = audit }), nil
+	})
+}
+
+// AuditImages checks every <img> of doc for lazy loading, explicit
+// dimensions, responsive sources and modern formats. Sources of an
+// enclosing <picture> count as the image's. The images with the most
+// issues are listed, in document order among equals.
+func AuditImages(doc *html.Node) *ImageAudit {
+	audit := &ImageAudit{}
+	var offenders []ImageOffender
+	stack := []*html.Node{doc}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if n.Type == html.ElementNode && n.Data == "img" {
+			if issues := auditImage(audit, n); len(issues) > 0 {
+				src := nodeAttr(n, "src")
+				if strings.HasPrefix(src, "data:") {
+					src, _, _ = strings.Cut(src, ",") // Keep the media type, not the image.
+				}
+				offenders = append(offenders, ImageOffender{Src: src, Issues: issues})
+			}
+		}
+		for child := n.LastChild; child != nil; child = child.PrevSibling {
+			stack = append(stack, child)
+		}
+	}
+	sort.SliceStable(offenders, func(i, j int) bool { return len(offenders[i].Issues) > len(offenders[j].Issues) })
+	if len(offenders) > maxImageOffenders {
+		offenders = offenders[:maxImageOffenders]
+	}
+	audit.WorstOffenders = offenders
+	return audit
+}
+
+// auditImage counts img in audit and returns its issues.
+func auditImage(audit *ImageAudit, img *html.Node) []string {
+	audit.Total++
+	var issues []string
+
+	if strings.EqualFold(strings.TrimSpace(nodeAttr(img, "loading")), "lazy") {
+		audit.Lazy++
+	} else if audit.Total > eagerImages {
+		issues = append(issues, ImageIssueNotLazy)
+	}
+
+	if strings.TrimSpace(nodeAttr(img, "width")) == "" || strings.TrimSpace(nodeAttr(img, "height")) == "" {
+		audit.MissingDimensions++
+		issues = append(issues, ImageIssueMissingDimensions)
+	}
+
+	// Gather the candidate sources: the img's own, then those of <source>
+	// elements in an enclosing <picture>.
+	srcsets := []string{nodeAttr(img, "srcset")}
+	sizes := []string{nodeAttr(img, "sizes")}
+	var types []string
+	if picture := img.Parent; picture != nil && picture.Type == html.ElementNode && picture.Data == "picture" {
+		for c := picture.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.Data == "source" {
+				srcsets = append(srcsets, nodeAttr(c, "srcset"))
+				sizes = append(sizes, nodeAttr(c, "sizes"))
+				types = append(types, strings.ToLower(strings.TrimSpace(nodeAttr(c, "type"))))
+			}
+		}
+	}
+
+	format := imageFormat(nodeAttr(img, "src"))
+	responsive, missingSizes, modern := false, false, modernImageFormats[format]
+	for i, srcset := range srcsets {
+		if strings.TrimSpace(srcset) == "" {
+			continue
+		}
+		responsive = true
+		if usesWidthDescriptors(srcset) && strings.TrimSpace(sizes[i]) == "" {
+			missingSizes = true
+		}
+		for _, candidate := range strings.Split(srcset, ",") {
+			if fields := strings.Fields(candidate); len(fields) > 0 && modernImageFormats[imageFormat(fields[0])] {
+				modern = true
+			}
+		}
+	}
+	for _, t := range types {
+		modern = modern || modernImageFormats[t]
+	}
+
+	if missingSizes {
+		audit.MissingSizes++
+		issues = append(issues, ImageIssueMissingSizes)
+	}
+	if responsive {
+		audit.WithSrcset++
+	} else if format != ".svg" {
+		issues = append(issues, ImageIssueNoSrcset)
+	}
+	switch {
+	case modern:
+		audit.ModernFormat++
+	case legacyImageFormats[format]:
+		audit.LegacyFormat++
+		issues = append(issues, ImageIssueLegacyFormat)
+	}
+	return issues
+}
+
+// imageFormat returns the lower-cased file extension of an image URL, or ""
+// for data: URLs and URLs without one.
+func imageFormat(src string) string {
+	src = strings.TrimSpace(src)
+	if src == "" || strings.HasPrefix(src, "data:") {
+		return ""
+	}
+	u, err := url.Parse(src)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(path.Ext(u.Path))
+}
+
+// usesWidthDescriptors reports whether a srcset lists widths ("480w"), which
+// need a sizes attribute to pick from.
+func usesWidthDescriptors(srcset string) bool {
+	for _, candidate := range strings.Split(srcset, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 1 && strings.HasSuffix(fields[1], "w") {
+			return true
+		}
+	}
+	return false
+}
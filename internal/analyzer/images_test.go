@@ -0,0 +1,40 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func TestAuditImages(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+<img src="/logo.svg" width="100" height="40">
+<picture>
+	<source type="image/avif" srcset="/hero.avif 800w, /hero-2x.avif 1600w" sizes="100vw">
+	<img src="/hero.jpg" width="800" height="400">
+</picture>
+<img src="/icon.png">
+<img src="/photo.jpg" srcset="/photo-480.jpg 480w, /photo-960.jpg 960w" loading="lazy">
+<img src="/banner.webp?v=2" width="600" height="100">
+<img src="data:image/png;base64,AAAA" loading="lazy" width="1" height="1">
+</body></html>`))
+	require.NoError(t, err)
+
+	audit := AuditImages(doc)
+	assert.Equal(t, 6, audit.Total)
+	assert.Equal(t, 2, audit.Lazy)
+	assert.Equal(t, 2, audit.MissingDimensions)
+	assert.Equal(t, 2, audit.WithSrcset)
+	assert.Equal(t, 1, audit.MissingSizes)
+	assert.Equal(t, 2, audit.ModernFormat, "The AVIF picture and the WebP banner")
+	assert.Equal(t, 2, audit.LegacyFormat)
+	assert.Equal(t, []ImageOffender{
+		{Src: "/icon.png", Issues: []string{ImageIssueMissingDimensions, ImageIssueNoSrcset, ImageIssueLegacyFormat}},
+		{Src: "/photo.jpg", Issues: []string{ImageIssueMissingDimensions, ImageIssueMissingSizes, ImageIssueLegacyFormat}},
+		{Src: "/banner.webp?v=2", Issues: []string{ImageIssueNotLazy, ImageIssueNoSrcset}},
+		{Src: "data:image/png;base64", Issues: []string{ImageIssueNoSrcset}},
+	}, audit.WorstOffenders)
+}
@@ -0,0 +1,121 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Options accepted by the infrastructure module.
+const (
+	infrastructureOptionTimeout = "timeout" // duration: timeout for resolving and locating the host.
+
+	defaultInfrastructureTimeout = 5 * time.Second
+)
+
+// GeoLocator maps IP addresses to their network owner and country, from a
+// local database or a remote service.
+type GeoLocator interface {
+	// Locate returns what is known about ip, or nil if it is not covered.
+	Locate(ctx context.Context, ip netip.Addr) (*IPLocation, error)
+}
+
+// infrastructureModule resolves the page's host and locates its addresses.
+// Resolving queries DNS, so it only runs when requested by name.
+type infrastructureModule struct {
+	locator  GeoLocator // nil reports addresses only.
+	lookupIP func(ctx context.Context, host string) ([]netip.Addr, error)
+}
+
+// NewInfrastructureModule creates the opt-in infrastructure module. locator
+// may be nil, in which case only the IP addresses are reported.
+func NewInfrastructureModule(locator GeoLocator) AnalyzerModule {
+	return &infrastructureModule{
+		locator: locator,
+		lookupIP: func(ctx context.Context, host string) ([]netip.Addr, error) {
+			return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		},
+	}
+}
+
+func (m *infrastructureModule) Name() string { return ModuleInfrastructure }
+
+// OptIn implements OptInModule.
+func (m *infrastructureModule) OptIn() bool { return true }
+
+// ValidateOptions implements OptionsValidator.
+func (m *infrastructureModule) ValidateOptions(opts ModuleOptions) error {
+	_, err := m.parseOptions(opts)
+	return err
+}
+
+// parseOptions reads the timeout option.
+func (m *infrastructureModule) parseOptions(opts ModuleOptions) (time.Duration, error) {
+	for key := range opts {
+		if key != infrastructureOptionTimeout {
+			return 0, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	timeout, err := opts.Duration(infrastructureOptionTimeout, defaultInfrastructureTimeout)
+	if err != nil {
+		return 0, err
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("option %q must be positive", infrastructureOptionTimeout)
+	}
+	return timeout, nil
+}
+
+// Analyze resolves the host of the page URL and locates each address.
+func (m *infrastructureModule) Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error) {
+	timeout, err := m.parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(info.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page URL: %v", err)
+	}
+	host := u.Hostname()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var addrs []netip.Addr
+	if ip, err := netip.ParseAddr(host); err == nil {
+		addrs = []netip.Addr{ip} // The URL names an address directly.
+	} else if addrs, err = m.lookupIP(ctx, host); err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %v", host, err)
+	}
+
+	infra := &Infrastructure{Host: host}
+	seen := make(map[netip.Addr]bool)
+	for _, addr := range addrs {
+		addr = addr.Unmap()
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+
+		address := IPAddress{IP: addr.String(), Version: 4}
+		if addr.Is6() {
+			address.Version = 6
+		}
+		if m.locator != nil {
+			location, err := m.locator.Locate(ctx, addr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to locate %s: %v", addr, err)
+			}
+			address.Location = location
+		}
+		infra.Addresses = append(infra.Addresses, address)
+	}
+
+	return ModuleResultFunc(func(a *WebpageAnalysis) { a.Infrastructure = infra }), nil
+}
@@ -0,0 +1,56 @@
+package analyzer
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapLocator locates addresses from a fixed table.
+type mapLocator map[netip.Addr]*IPLocation
+
+func (l mapLocator) Locate(ctx context.Context, ip netip.Addr) (*IPLocation, error) {
+	return l[ip], nil
+}
+
+func TestInfrastructureModule(t *testing.T) {
+	v4 := netip.MustParseAddr("203.0.113.7")
+	v6 := netip.MustParseAddr("2001:db8::1")
+	module := NewInfrastructureModule(mapLocator{v4: {ASN: 64500, ASOrg: "Example Hosting", Country: "NL"}}).(*infrastructureModule)
+	var resolved string
+	module.lookupIP = func(ctx context.Context, host string) ([]netip.Addr, error) {
+		resolved = host
+		return []netip.Addr{v4, netip.MustParseAddr("::ffff:203.0.113.7"), v6}, nil
+	}
+
+	result, err := module.Analyze(context.Background(), nil, FetchInfo{URL: "https://www.example.com:8443/page"}, nil)
+	require.NoError(t, err)
+	analysis := &WebpageAnalysis{}
+	result.Apply(analysis)
+
+	assert.Equal(t, "www.example.com", resolved)
+	require.NotNil(t, analysis.Infrastructure)
+	assert.Equal(t, "www.example.com", analysis.Infrastructure.Host)
+	assert.Equal(t, []IPAddress{
+		{IP: "203.0.113.7", Version: 4, Location: &IPLocation{ASN: 64500, ASOrg: "Example Hosting", Country: "NL"}},
+		{IP: "2001:db8::1", Version: 6},
+	}, analysis.Infrastructure.Addresses, "IPv4-mapped duplicates should be dropped")
+}
+
+func TestInfrastructureModule_IPHost(t *testing.T) {
+	module := NewInfrastructureModule(nil).(*infrastructureModule)
+	module.lookupIP = func(ctx context.Context, host string) ([]netip.Addr, error) {
+		t.Fatal("An IP literal should not be resolved")
+		return nil, nil
+	}
+
+	result, err := module.Analyze(context.Background(), nil, FetchInfo{URL: "http://[2001:db8::2]/"}, nil)
+	require.NoError(t, err)
+	analysis := &WebpageAnalysis{}
+	result.Apply(analysis)
+
+	assert.Equal(t, []IPAddress{{IP: "2001:db8::2", Version: 6}}, analysis.Infrastructure.Addresses)
+}
@@ -0,0 +1,227 @@
+package analyzer
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// Popup kinds reported in Popup.Kind.
+const (
+	PopupNewsletter = "newsletter"
+	PopupPaywall    = "paywall"
+	PopupAgeGate    = "age_gate"
+	PopupGeneric    = "modal"
+)
+
+// Paywall signals reported in Paywall.Signals.
+const (
+	PaywallSignalSchema   = "schema_not_free" // schema.org isAccessibleForFree is false.
+	PaywallSignalProvider = "provider"        // A paywall provider's script or markup.
+	PaywallSignalMarkup   = "paywall_markup"  // An id or class such as "paywall" or "subscriber-only".
+	PaywallSignalMeter    = "meter"           // A metered-content counter.
+)
+
+// paywallProvider describes how to recognize one paywall provider.
+type paywallProvider struct {
+	name    string
+	hosts   []string // Script and iframe hosts, matched with their subdomains.
+	classes []string // Class name prefixes of the provider's markup.
+}
+
+// paywallProviders are the paywall providers DetectInterstitials knows.
+var paywallProviders = []paywallProvider{
+	{name: "Piano", hosts: []string{"tinypass.com", "piano.io"}, classes: []string{"tp-modal", "tp-backdrop", "tp-iframe-wrapper", "piano-"}},
+	{name: "Zephr", hosts: []string{"zephr.com"}, classes: []string{"zephr-"}},
+	{name: "Poool", hosts: []string{"poool.fr"}, classes: []string{"poool-"}},
+	{name: "Pelcro", hosts: []string{"pelcro.com"}, classes: []string{"pelcro-"}},
+	{name: "LaterPay", hosts: []string{"laterpay.net"}},
+	{name: "Memberful", hosts: []string{"memberful.com"}},
+}
+
+var (
+	// popupPattern matches ids and class names of modal overlays, such as
+	// "modal" and "newsletter-popup", but not their parts, such as
+	// "modal-body".
+	popupPattern = regexp.MustCompile(`(?i)(^|[-_])(modal|popup|pop-up|interstitial|lightbox|overlay)$`)
+	// newsletterPattern and ageGatePattern tell the kind of a popup from
+	// its ids and class names.
+	newsletterPattern = regexp.MustCompile(`(?i)newsletter|subscribe|signup|sign-up|email-capture`)
+	ageGatePattern    = regexp.MustCompile(`(?i)age-?gate|age-?verif|age-?check`)
+	// paywallPattern matches ids and class names of paywall markup.
+	paywallPattern = regexp.MustCompile(`(?i)paywall|regwall|subscriber-only|subscribers-only|premium-content|locked-content`)
+	// meterPattern matches ids and class names of metered-content counters,
+	// meterTextPattern the counters themselves, such as "3 free articles
+	// left this month".
+	meterPattern     = regexp.MustCompile(`(?i)(^|[-_])metere?d?([-_]|$)`)
+	meterTextPattern = regexp.MustCompile(`(?i)\b(\d+|no|one|two|three) (more )?(free )?(articles?|stories|story|reads?) (left|remaining)\b`)
+)
+
+// maxMeterTextLength bounds the elements whose text is searched for a meter
+// counter, so that whole sections mentioning one are not.
+const maxMeterTextLength = 200
+
+// interstitialsModule detects popups and paywalls.
+func interstitialsModule(htmlParser parser.HTMLParser) AnalyzerModule {
+	return NewModule(ModuleInterstitials, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		elements := pageFrom(ctx, doc, htmlParser).Elements("script", "iframe", "div", "section", "aside", "dialog", "form", "p", "span")
+		interstitials := DetectInterstitials(elements, notFree(doc))
+		return ModuleResultFunc(func(a *WebpageAnalysis) { a.Interstitials = interstitials }), nil
+	})
+}
+
+// DetectInterstitials finds the modal popups and paywall of a page from its
+// elements. Popups are elements whose id or class names a modal, popup or
+// overlay, <dialog open> elements and elements with aria-modal="true",
+// classified by their ids, classes and text as newsletter sign-ups, paywalls,
+// age gates or other modals. Cookie banners are left to the consent module.
+// A paywall is reported from a provider's scripts or markup, paywall ids and
+// classes, metered-content counters and, when schemaNotFree is set, the
+// page's structured data. It returns nil when nothing is found.
+func DetectInterstitials(elements []parser.Element, schemaNotFree bool) *Interstitials {
+	result := &Interstitials{}
+	paywall := &Paywall{}
+	signal := func(s string) {
+		if !containsAny(paywall.Signals, []string{s}) {
+			paywall.Signals = append(paywall.Signals, s)
+		}
+	}
+	provider := func(name string) {
+		if paywall.Provider == "" {
+			paywall.Provider = name
+		}
+		signal(PaywallSignalProvider)
+	}
+	if schemaNotFree {
+		signal(PaywallSignalSchema)
+	}
+
+	for _, el := range elements {
+		if el.Tag == "script" || el.Tag == "iframe" {
+			if u, err := url.Parse(strings.TrimSpace(el.Attr("src"))); err == nil && u.Host != "" {
+				host := strings.ToLower(u.Hostname())
+				for _, p := range paywallProviders {
+					for _, h := range p.hosts {
+						if host == h || strings.HasSuffix(host, "."+h) {
+							provider(p.name)
+						}
+					}
+				}
+			}
+			continue
+		}
+
+		id := el.Attr("id")
+		names := append(strings.Fields(el.Attr("class")), id)
+		var providerMarkup, paywallMarkup, popup bool
+		for _, name := range names {
+			if name == "" {
+				continue
+			}
+			for _, p := range paywallProviders {
+				for _, prefix := range p.classes {
+					if strings.HasPrefix(strings.ToLower(name), prefix) {
+						provider(p.name)
+						providerMarkup = true
+					}
+				}
+			}
+			if paywallPattern.MatchString(name) {
+				paywallMarkup = true
+			}
+			if meterPattern.MatchString(name) {
+				signal(PaywallSignalMeter)
+			}
+			popup = popup || popupPattern.MatchString(name)
+		}
+		if paywallMarkup {
+			signal(PaywallSignalMarkup)
+		}
+		text, short := el.ShortText(maxMeterTextLength)
+		if short && mayBeMeterText(text) && meterTextPattern.MatchString(text) {
+			signal(PaywallSignalMeter)
+		}
+
+		popup = popup || (el.Tag == "dialog" && hasAttrKey(el, "open")) || strings.EqualFold(el.Attr("aria-modal"), "true")
+		if !popup || el.Tag == "p" || el.Tag == "span" || isConsentBanner(names) {
+			continue
+		}
+		kind := PopupGeneric
+		joined := strings.Join(names, " ")
+		switch {
+		case providerMarkup || paywallMarkup:
+			kind = PopupPaywall
+		case newsletterPattern.MatchString(joined):
+			kind = PopupNewsletter
+		case ageGatePattern.MatchString(joined):
+			kind = PopupAgeGate
+		case short && newsletterPattern.MatchString(text):
+			kind = PopupNewsletter
+		}
+		result.Popups = append(result.Popups, Popup{Kind: kind, Element: elementSelector(el)})
+	}
+
+	if len(paywall.Signals) > 0 {
+		paywall.Metered = containsAny(paywall.Signals, []string{PaywallSignalMeter})
+		result.Paywall = paywall
+	}
+	if len(result.Popups) == 0 && result.Paywall == nil {
+		return nil
+	}
+	return result
+}
+
+// mayBeMeterText reports whether text has the "left" or "remaining" every
+// meter counter ends with, so that meterTextPattern only runs on the few
+// elements whose text could match it.
+func mayBeMeterText(text string) bool {
+	lower := strings.ToLower(text)
+	return strings.Contains(lower, "left") || strings.Contains(lower, "remaining")
+}
+
+// isConsentBanner reports whether any of an element's ids and class names
+// is a cookie banner's.
+func isConsentBanner(names []string) bool {
+	for _, name := range names {
+		if name != "" && consentBannerPattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// elementSelector describes el as a CSS selector: its tag with its id, or
+// with its first class.
+func elementSelector(el parser.Element) string {
+	if id := strings.TrimSpace(el.Attr("id")); id != "" {
+		return el.Tag + "#" + id
+	}
+	if classes := strings.Fields(el.Attr("class")); len(classes) > 0 {
+		return el.Tag + "." + classes[0]
+	}
+	return el.Tag
+}
+
+// notFree reports whether a schema.org item of doc declares its content is
+// not free to access, as Google asks paywalled pages to.
+func notFree(doc *html.Node) bool {
+	items, _ := extractSchemaItems(doc)
+	for _, formatted := range items {
+		switch free := formatted.item["isAccessibleForFree"].(type) {
+		case bool:
+			if !free {
+				return true
+			}
+		case string:
+			if strings.EqualFold(strings.TrimSpace(free), "false") {
+				return true
+			}
+		}
+	}
+	return false
+}
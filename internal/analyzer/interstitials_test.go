@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+func detectInterstitialsIn(t *testing.T, page string) *Interstitials {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(page))
+	require.NoError(t, err)
+	elements := parser.NewHTMLParser().ExtractElements(doc, "script", "iframe", "div", "section", "aside", "dialog", "form", "p", "span")
+	return DetectInterstitials(elements, notFree(doc))
+}
+
+func TestDetectInterstitials(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want *Interstitials
+	}{
+		{"none", `<div class="content"><div class="modal-body">Not a modal on its own</div></div>
+			<div id="cookie-banner" class="overlay">We use cookies</div>`, nil},
+		{"newsletter popup", `<div id="newsletter-popup" class="popup"><form><input type="email"></form></div>`,
+			&Interstitials{Popups: []Popup{{Kind: PopupNewsletter, Element: "div#newsletter-popup"}}}},
+		{"newsletter by text", `<div class="modal" role="dialog"><p>Subscribe to our weekly digest</p></div>`,
+			&Interstitials{Popups: []Popup{{Kind: PopupNewsletter, Element: "div.modal"}}}},
+		{"open dialog and age gate", `<dialog open><p>Welcome back</p></dialog><div class="age-gate" aria-modal="true"></div>`,
+			&Interstitials{Popups: []Popup{{Kind: PopupGeneric, Element: "dialog"}, {Kind: PopupAgeGate, Element: "div.age-gate"}}}},
+		{"piano soft paywall", `<script src="https://cdn.tinypass.com/api/tinypass.min.js"></script>
+			<div class="tp-modal"><div class="tp-iframe-wrapper"></div></div>`,
+			&Interstitials{
+				Popups:  []Popup{{Kind: PopupPaywall, Element: "div.tp-modal"}},
+				Paywall: &Paywall{Provider: "Piano", Signals: []string{PaywallSignalProvider}},
+			}},
+		{"metered content", `<div class="article-meter"><span>2 free articles left this month</span></div>
+			<section class="paywall-container">Subscribe to keep reading</section>`,
+			&Interstitials{Paywall: &Paywall{Metered: true, Signals: []string{PaywallSignalMeter, PaywallSignalMarkup}}}},
+		{"meter by text", `<p class="note">One More Story Remaining</p>`,
+			&Interstitials{Paywall: &Paywall{Metered: true, Signals: []string{PaywallSignalMeter}}}},
+		{"structured data", `<script type="application/ld+json">{"@type": "NewsArticle", "isAccessibleForFree": false}</script>`,
+			&Interstitials{Paywall: &Paywall{Signals: []string{PaywallSignalSchema}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectInterstitialsIn(t, tt.page))
+		})
+	}
+}
+
+func TestInterstitialsModule(t *testing.T) {
+	page := `<div itemscope itemtype="https://schema.org/Article"><meta itemprop="isAccessibleForFree" content="False"></div>`
+	service := NewService(WithHTTPClient(&mockHTTPClient{response: page}))
+
+	analysis, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://example.com", Modules: []string{ModuleInterstitials}})
+	require.NoError(t, err)
+	require.NotNil(t, analysis.Interstitials)
+	assert.Equal(t, &Paywall{Signals: []string{PaywallSignalSchema}}, analysis.Interstitials.Paywall)
+}
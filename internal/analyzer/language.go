@@ -0,0 +1,188 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// maxAcceptLanguageLength bounds the Accept-Language value a request may send.
+const maxAcceptLanguageLength = 256
+
+// languageRangePattern matches one entry of an Accept-Language header: a
+// language range such as "de-CH" or "*", optionally weighted with ";q=0.8".
+var languageRangePattern = regexp.MustCompile(`^(\*|[A-Za-z]{1,8}(-[A-Za-z0-9]{1,8})*)(\s*;\s*q=(0(\.\d{0,3})?|1(\.0{0,3})?))?$`)
+
+// validateAcceptLanguage checks that value is a well-formed Accept-Language
+// header, such as "de-DE,de;q=0.9,en;q=0.5".
+func validateAcceptLanguage(value string) error {
+	if len(value) > maxAcceptLanguageLength {
+		return fmt.Errorf("longer than %d characters", maxAcceptLanguageLength)
+	}
+	for _, entry := range strings.Split(value, ",") {
+		if !languageRangePattern.MatchString(strings.TrimSpace(entry)) {
+			return fmt.Errorf("%q is not a language range", strings.TrimSpace(entry))
+		}
+	}
+	return nil
+}
+
+// Language detection thresholds: texts with fewer words are not classified,
+// and the best language must have enough stopwords and clearly beat the
+// runner-up.
+const (
+	minDetectionWords     = 20
+	minDetectionStopwords = 5
+	detectionMargin       = 1.5
+)
+
+// languageStopwords are frequent short words of each detectable language,
+// chosen to be rare in the others.
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "that", "with", "for", "this", "are", "was", "you", "have", "not", "from", "which", "be", "it"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "ein", "eine", "auf", "sich", "auch", "dem", "für", "werden", "wir", "zu", "den"},
+	"fr": {"le", "les", "et", "des", "est", "une", "dans", "pour", "qui", "pas", "sur", "au", "avec", "sont", "nous", "vous", "du", "ce"},
+	"es": {"el", "los", "las", "y", "del", "una", "por", "con", "para", "se", "como", "más", "pero", "sus", "está", "al", "lo", "es"},
+	"it": {"il", "di", "che", "è", "della", "per", "non", "sono", "gli", "nel", "anche", "alla", "questo", "delle", "dei", "un", "si", "ci"},
+	"pt": {"os", "não", "uma", "com", "são", "mais", "pelo", "dos", "ao", "também", "você", "ele", "nas", "seu", "em", "foi", "isso", "à"},
+	"nl": {"het", "een", "van", "niet", "zijn", "dat", "met", "voor", "ook", "op", "te", "wordt", "naar", "bij", "deze", "ik", "maar", "wij"},
+}
+
+// stopwordLanguages maps each stopword to the languages listing it.
+var stopwordLanguages = func() map[string][]string {
+	index := make(map[string][]string)
+	for lang, words := range languageStopwords {
+		for _, word := range words {
+			index[word] = append(index[word], lang)
+		}
+	}
+	return index
+}()
+
+// languageModule reports the languages the page declares and is written in,
+// and its hreflang alternates.
+func languageModule(htmlParser parser.HTMLParser) AnalyzerModule {
+	return NewModule(ModuleLanguage, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		language := &PageLanguage{
+			Declared:        documentLang(doc),
+			ContentLanguage: info.Header.Get("Content-Language"),
+			Detected:        DetectLanguage(pageFrom(ctx, doc, htmlParser).VisibleText()),
+			Alternates:      hreflangAlternates(info.URL, pageFrom(ctx, doc, htmlParser).Elements("link")),
+		}
+		findings := languageFindings(language)
+		return ModuleResultFunc(func(a *WebpageAnalysis) {
+			a.Language = language
+			a.Findings = append(a.Findings, findings...)
+		}), nil
+	})
+}
+
+// documentLang returns the lang attribute of the <html> element.
+func documentLang(doc *html.Node) string {
+	if doc == nil {
+		return ""
+	}
+	for c := doc.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "html" {
+			return strings.TrimSpace(nodeAttr(c, "lang"))
+		}
+	}
+	return ""
+}
+
+// DetectLanguage guesses the language of text from its stopwords, returning
+// an ISO 639-1 code (en, de, fr, es, it, pt or nl), or "" when the text is
+// too short or not clearly in one of them.
+func DetectLanguage(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(words) < minDetectionWords {
+		return ""
+	}
+	scores := make(map[string]int)
+	for _, word := range words {
+		for _, lang := range stopwordLanguages[word] {
+			scores[lang]++
+		}
+	}
+	if len(scores) == 0 {
+		return ""
+	}
+	ranked := make([]string, 0, len(scores))
+	for lang := range scores {
+		ranked = append(ranked, lang)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if scores[ranked[i]] != scores[ranked[j]] {
+			return scores[ranked[i]] > scores[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+	best, runnerUp := ranked[0], 0
+	if len(ranked) > 1 {
+		runnerUp = scores[ranked[1]]
+	}
+	if scores[best] < minDetectionStopwords || float64(scores[best]) < detectionMargin*float64(runnerUp) {
+		return ""
+	}
+	return best
+}
+
+// hreflangAlternates returns the page's <link rel="alternate" hreflang>
+// targets, resolved against pageURL and sorted by language.
+func hreflangAlternates(pageURL string, links []parser.Element) []HreflangAlternate {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	var alternates []HreflangAlternate
+	seen := make(map[HreflangAlternate]bool)
+	for _, el := range links {
+		lang, href := strings.TrimSpace(el.Attr("hreflang")), strings.TrimSpace(el.Attr("href"))
+		if lang == "" || href == "" || !hasRel(el.Attr("rel"), "alternate") {
+			continue
+		}
+		ref, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+		alternate := HreflangAlternate{Lang: lang, URL: base.ResolveReference(ref).String()}
+		if !seen[alternate] {
+			seen[alternate] = true
+			alternates = append(alternates, alternate)
+		}
+	}
+	sort.SliceStable(alternates, func(i, j int) bool {
+		return strings.ToLower(alternates[i].Lang) < strings.ToLower(alternates[j].Lang)
+	})
+	return alternates
+}
+
+// primaryLanguage returns the primary subtag of a language tag, such as "de"
+// for "de-CH".
+func primaryLanguage(tag string) string {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	return primary
+}
+
+// languageFindings reports a declared language that the text does not match,
+// which misleads screen readers, translation prompts and search engines.
+func languageFindings(language *PageLanguage) []Finding {
+	if language.Declared == "" || language.Detected == "" || primaryLanguage(language.Declared) == language.Detected {
+		return nil
+	}
+	return []Finding{{
+		Type:     FindingLanguageMismatch,
+		Severity: SeverityLow,
+		Message:  fmt.Sprintf("Page declares language %q but its text reads as %q", language.Declared, language.Detected),
+	}}
+}
@@ -0,0 +1,130 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/parser"
+)
+
+const (
+	englishText = "This is the page that you have been looking for. It is not the one from last year, and it was written with care for the readers of this site who are interested in the topic."
+	germanText  = "Das ist die Seite, die Sie gesucht haben. Sie ist nicht die von letztem Jahr und wurde mit Sorgfalt für die Leser geschrieben, die sich auch für das Thema und den Inhalt interessieren."
+)
+
+func TestDetectLanguage(t *testing.T) {
+	assert.Equal(t, "en", DetectLanguage(englishText))
+	assert.Equal(t, "de", DetectLanguage(germanText))
+	assert.Equal(t, "", DetectLanguage("Welcome to the site"), "Short texts should not be classified")
+	assert.Equal(t, "", DetectLanguage(strings.Repeat("lorem ipsum dolor sit amet ", 10)), "Texts without stopwords should not be classified")
+}
+
+func TestValidateAcceptLanguage(t *testing.T) {
+	for _, value := range []string{"de", "de-DE,de;q=0.9,en;q=0.5", "*", "zh-Hant-TW, en ; q=1.0"} {
+		assert.NoError(t, validateAcceptLanguage(value), value)
+	}
+	for _, value := range []string{"de;q=2", "de\r\nX-Evil: 1", "en,,de", strings.Repeat("en,", 100) + "en"} {
+		assert.Error(t, validateAcceptLanguage(value), value)
+	}
+}
+
+func TestHreflangAlternates(t *testing.T) {
+	links := []parser.Element{
+		{Tag: "link", Attrs: map[string]string{"rel": "alternate", "hreflang": "x-default", "href": "/"}},
+		{Tag: "link", Attrs: map[string]string{"rel": "alternate", "hreflang": "de", "href": "/de/"}},
+		{Tag: "link", Attrs: map[string]string{"rel": "alternate", "hreflang": "de", "href": "/de/"}},
+		{Tag: "link", Attrs: map[string]string{"rel": "canonical", "hreflang": "en", "href": "/"}},
+		{Tag: "link", Attrs: map[string]string{"rel": "alternate", "href": "/feed.xml"}},
+	}
+	assert.Equal(t, []HreflangAlternate{
+		{Lang: "de", URL: "https://example.com/de/"},
+		{Lang: "x-default", URL: "https://example.com/"},
+	}, hreflangAlternates("https://example.com/en/", links))
+}
+
+func TestLanguageModule(t *testing.T) {
+	mockClient := &mockHTTPClient{response: `<html lang="de-CH"><head>
+		<link rel="alternate" hreflang="en" href="/en/">
+	</head><body><p>` + englishText + `</p></body></html>`}
+	service := NewService(WithHTTPClient(mockClient))
+
+	analysis, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://example.com/", Modules: []string{ModuleLanguage}})
+	require.NoError(t, err)
+	require.NotNil(t, analysis.Language)
+	assert.Equal(t, "de-CH", analysis.Language.Declared)
+	assert.Equal(t, "en", analysis.Language.Detected)
+	assert.Equal(t, []HreflangAlternate{{Lang: "en", URL: "https://example.com/en/"}}, analysis.Language.Alternates)
+	require.Len(t, analysis.Findings, 1)
+	assert.Equal(t, FindingLanguageMismatch, analysis.Findings[0].Type)
+}
+
+func TestAnalyzeWebpage_InvalidAcceptLanguage(t *testing.T) {
+	service := NewService(WithHTTPClient(&mockHTTPClient{response: "<html></html>"}))
+
+	_, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://example.com", AcceptLanguage: "de\nX: 1"})
+	var analysisErr *AnalysisError
+	require.ErrorAs(t, err, &analysisErr)
+	assert.Equal(t, ErrorCodeInvalidRequest, analysisErr.Code)
+}
+
+// languageMockHTTPClient serves a different response body per Accept-Language value.
+type languageMockHTTPClient struct {
+	mockHTTPClient
+	responses map[string]string
+}
+
+func (m *languageMockHTTPClient) FetchWebpage(ctx context.Context, url string) ([]byte, int, error) {
+	body, ok := m.responses[client.AcceptLanguage(ctx)]
+	if !ok {
+		return nil, 404, assert.AnError
+	}
+	return []byte(body), 200, nil
+}
+
+func TestCompareLanguages(t *testing.T) {
+	mockClient := &languageMockHTTPClient{responses: map[string]string{
+		"en": `<html lang="en"><head><title>Welcome</title></head><body><p>` + englishText + `</p></body></html>`,
+		"de": `<html lang="de"><head><title>Willkommen</title></head><body><p>` + germanText + `</p></body></html>`,
+	}}
+	service := NewService(WithHTTPClient(mockClient))
+
+	result, err := service.CompareLanguages(context.Background(), LanguageComparisonRequest{URL: "https://example.com", Languages: []string{"en", "de"}})
+	require.NoError(t, err)
+	require.Len(t, result.Variants, 2)
+	assert.Equal(t, "Willkommen", result.Variants[1].PageTitle)
+	assert.Equal(t, "de", result.Variants[1].Language.Detected)
+
+	diff := result.Differences
+	assert.True(t, diff.Negotiated)
+	assert.True(t, diff.TitleChanged)
+	assert.True(t, diff.ContentChanged)
+	assert.True(t, diff.LanguageChanged)
+	assert.False(t, diff.URLChanged)
+	assert.True(t, diff.MissingHreflang, "Negotiated content without hreflang alternates should be reported")
+	assert.Contains(t, diff.Summary, `title differs for de: "Willkommen"`)
+}
+
+func TestCompareLanguages_Invalid(t *testing.T) {
+	service := NewService(WithHTTPClient(&mockHTTPClient{response: "<html></html>"}))
+
+	for _, languages := range [][]string{{"en"}, {"en", "de", "fr", "es", "it", "pt"}, {"en", ""}, {"en", "de;q=5"}} {
+		_, err := service.CompareLanguages(context.Background(), LanguageComparisonRequest{URL: "https://example.com", Languages: languages})
+		var analysisErr *AnalysisError
+		require.ErrorAs(t, err, &analysisErr, "%v", languages)
+		assert.Equal(t, ErrorCodeInvalidRequest, analysisErr.Code)
+	}
+}
+
+func TestDiffLanguageVariants_Identical(t *testing.T) {
+	language := &PageLanguage{Declared: "en", Alternates: []HreflangAlternate{{Lang: "de", URL: "https://example.com/de/"}}}
+	diff := DiffLanguageVariants([]LanguageVariant{
+		{AcceptLanguage: "en", PageTitle: "Home", ContentHash: "abc", Language: language},
+		{AcceptLanguage: "de", PageTitle: "Home", ContentHash: "abc", Language: language},
+	})
+	assert.Equal(t, LanguageDiff{}, diff)
+}
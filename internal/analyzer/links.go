@@ -0,0 +1,213 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/parser"
+)
+
+// Options accepted by the links module.
+const (
+	linkOptionProbe        = "probe"         // bool: request each link and count the broken ones.
+	linkOptionProbeTimeout = "probe_timeout" // duration: per-link request timeout.
+	linkOptionProbeLimit   = "probe_limit"   // int: maximum number of links to probe.
+
+	defaultLinkProbeTimeout = 5 * time.Second
+	defaultLinkProbeLimit   = 20
+	maxLinkProbeLimit       = 100
+	linkProbeConcurrency    = 5
+)
+
+// redirectParams are query parameter names conventionally used for redirect
+// targets. Protocol-relative values ("//host/path") only count in these.
+var redirectParams = map[string]bool{
+	"redirect": true, "redirect_uri": true, "redirect_url": true, "redirecturl": true, "redirect_to": true,
+	"next": true, "url": true, "return": true, "returnto": true, "return_to": true, "return_url": true,
+	"returnurl": true, "continue": true, "dest": true, "destination": true, "goto": true, "target": true,
+	"to": true, "out": true, "rurl": true, "forward": true,
+}
+
+// linksModule counts a page's links and, when asked, probes them for broken targets.
+type linksModule struct {
+	htmlParser parser.HTMLParser
+	httpClient client.HTTPClient
+}
+
+func (m *linksModule) Name() string { return ModuleLinks }
+
+// linkProbeOptions are the parsed links module options.
+type linkProbeOptions struct {
+	enabled bool
+	timeout time.Duration
+	limit   int
+}
+
+// parseOptions reads and validates the links module options.
+func (m *linksModule) parseOptions(opts ModuleOptions) (linkProbeOptions, error) {
+	for key := range opts {
+		switch key {
+		case linkOptionProbe, linkOptionProbeTimeout, linkOptionProbeLimit:
+		default:
+			return linkProbeOptions{}, fmt.Errorf("unknown option %q", key)
+		}
+	}
+
+	enabled, err := opts.Bool(linkOptionProbe, false)
+	if err != nil {
+		return linkProbeOptions{}, err
+	}
+	timeout, err := opts.Duration(linkOptionProbeTimeout, defaultLinkProbeTimeout)
+	if err != nil {
+		return linkProbeOptions{}, err
+	}
+	if timeout <= 0 {
+		return linkProbeOptions{}, fmt.Errorf("option %q must be positive", linkOptionProbeTimeout)
+	}
+	limit, err := opts.Int(linkOptionProbeLimit, defaultLinkProbeLimit)
+	if err != nil {
+		return linkProbeOptions{}, err
+	}
+	if limit <= 0 || limit > maxLinkProbeLimit {
+		return linkProbeOptions{}, fmt.Errorf("option %q must be between 1 and %d", linkOptionProbeLimit, maxLinkProbeLimit)
+	}
+
+	return linkProbeOptions{enabled: enabled, timeout: timeout, limit: limit}, nil
+}
+
+// ValidateOptions implements OptionsValidator.
+func (m *linksModule) ValidateOptions(opts ModuleOptions) error {
+	_, err := m.parseOptions(opts)
+	return err
+}
+
+// Analyze counts links and optionally probes them.
+func (m *linksModule) Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error) {
+	probeOpts, err := m.parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	internal, external, inaccessible := m.htmlParser.ExtractLinks(doc, info.URL)
+	urls := pageFrom(ctx, doc, m.htmlParser).LinkURLs(info.URL)
+	findings := openRedirectFindings(info.URL, urls)
+
+	var probe *LinkProbeResult
+	if probeOpts.enabled {
+		probe = m.probe(ctx, urls, probeOpts)
+	}
+
+	return ModuleResultFunc(func(a *WebpageAnalysis) {
+		a.InternalLinks = internal
+		a.ExternalLinks = external
+		a.InaccessibleLinks = inaccessible
+		a.LinkProbe = probe
+		a.Findings = append(a.Findings, findings...)
+	}), nil
+}
+
+// openRedirectFindings reports links whose query string carries a URL on a
+// third host, the shape of an open redirect (?next=https://other.example/).
+// Such a link on the page's own host is a possible open redirect on the site
+// itself and rates medium; one on another host, such as a tracking redirector,
+// rates low. Share buttons, which embed the page's own URL, are not reported.
+func openRedirectFindings(pageURL string, links []string) []Finding {
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+
+	var findings []Finding
+	for _, link := range links {
+		u, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		param, target := embeddedRedirect(u, page.Hostname())
+		if target == "" {
+			continue
+		}
+
+		finding := Finding{
+			Type:     FindingOpenRedirect,
+			Severity: SeverityLow,
+			Message:  fmt.Sprintf("Link passes a URL on %s in its %q parameter through %s", target, param, u.Hostname()),
+			Evidence: link,
+		}
+		if strings.EqualFold(u.Hostname(), page.Hostname()) {
+			finding.Severity = SeverityMedium
+			finding.Message = fmt.Sprintf("Link passes a URL on %s in its %q parameter, a possible open redirect on this site", target, param)
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// embeddedRedirect returns the query parameter of u, alphabetically first if
+// several qualify, holding a URL whose host is neither u's host nor pageHost,
+// and that host.
+func embeddedRedirect(u *url.URL, pageHost string) (param, host string) {
+	for name, values := range u.Query() {
+		for _, value := range values {
+			value = strings.TrimSpace(value)
+			lower := strings.ToLower(value)
+			isAbsolute := strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+			isProtocolRelative := strings.HasPrefix(value, "//") && redirectParams[strings.ToLower(name)]
+			if !isAbsolute && !isProtocolRelative {
+				continue
+			}
+			target, err := url.Parse(value)
+			if err != nil || target.Hostname() == "" {
+				continue
+			}
+			if !strings.EqualFold(target.Hostname(), u.Hostname()) && !strings.EqualFold(target.Hostname(), pageHost) {
+				if param == "" || name < param {
+					param, host = name, target.Hostname()
+				}
+			}
+		}
+	}
+	return param, host
+}
+
+// probe requests up to opts.limit links concurrently and reports the ones that
+// fail or answer with an error status. The requests count against the
+// analysis' sub-request budget; links it leaves no room or time for are not
+// checked.
+func (m *linksModule) probe(ctx context.Context, urls []string, opts linkProbeOptions) *LinkProbeResult {
+	if len(urls) > opts.limit {
+		urls = urls[:opts.limit]
+	}
+
+	checked := make([]bool, len(urls))
+	broken := make([]bool, len(urls))
+	subrequestsFrom(ctx).fanOut(ctx, len(urls), linkProbeConcurrency, func(ctx context.Context, i int) {
+		probeCtx, cancel := context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+		_, statusCode, err := m.httpClient.FetchWebpage(probeCtx, urls[i])
+		if ctx.Err() != nil {
+			return // Out of time: the link was not really checked.
+		}
+		checked[i] = true
+		broken[i] = err != nil || statusCode >= http.StatusBadRequest
+	})
+
+	result := &LinkProbeResult{}
+	for i, isBroken := range broken {
+		if checked[i] {
+			result.Checked++
+		}
+		if isBroken {
+			result.Broken++
+			result.BrokenURLs = append(result.BrokenURLs, urls[i])
+		}
+	}
+	return result
+}
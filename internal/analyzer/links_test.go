@@ -0,0 +1,135 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/worker"
+)
+
+func TestAnalyzeWebpage_LinkProbe(t *testing.T) {
+	mockClient := &urlMockHTTPClient{
+		responses: map[string]string{
+			"https://example.com": `<html><body>
+				<a href="/ok">ok</a>
+				<a href="/missing">missing</a>
+				<a href="https://other.com/ok">other</a>
+			</body></html>`,
+			"https://example.com/ok": "ok",
+			"https://other.com/ok":   "ok",
+		},
+	}
+	service := NewServiceWithDependencies(mockClient, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{
+		URL:     "https://example.com",
+		Modules: []string{ModuleLinks},
+		Options: map[string]ModuleOptions{ModuleLinks: {"probe": true, "probe_timeout": "1s"}},
+	})
+
+	require.NoError(t, err)
+	require.NotNil(t, result.LinkProbe, "Probe option should produce a link probe result")
+	assert.Equal(t, 3, result.LinkProbe.Checked)
+	assert.Equal(t, 1, result.LinkProbe.Broken)
+	assert.Equal(t, []string{"https://example.com/missing"}, result.LinkProbe.BrokenURLs)
+}
+
+func TestAnalyzeWebpage_LinkProbeLimit(t *testing.T) {
+	mockClient := &urlMockHTTPClient{
+		responses: map[string]string{
+			"https://example.com": `<html><body><a href="/a">a</a><a href="/b">b</a><a href="/c">c</a></body></html>`,
+		},
+	}
+	service := NewServiceWithDependencies(mockClient, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{
+		URL:     "https://example.com",
+		Options: map[string]ModuleOptions{ModuleLinks: {"probe": "true", "probe_limit": float64(2)}},
+	})
+
+	require.NoError(t, err)
+	require.NotNil(t, result.LinkProbe)
+	assert.Equal(t, 2, result.LinkProbe.Checked, "Probe should stop at probe_limit")
+}
+
+func TestAnalyzeWebpage_NoProbeByDefault(t *testing.T) {
+	mockClient := &mockHTTPClient{response: `<html><body><a href="/a">a</a></body></html>`}
+	service := NewServiceWithDependencies(mockClient, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://example.com"})
+
+	require.NoError(t, err)
+	assert.Nil(t, result.LinkProbe, "Links should not be probed unless requested")
+}
+
+func TestAnalyzeWebpage_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		modules []string
+		options map[string]ModuleOptions
+	}{
+		{"Unknown option", nil, map[string]ModuleOptions{ModuleLinks: {"depth": 2}}},
+		{"Bad value", nil, map[string]ModuleOptions{ModuleLinks: {"probe_timeout": "soon"}}},
+		{"Limit out of range", nil, map[string]ModuleOptions{ModuleLinks: {"probe_limit": float64(1000)}}},
+		{"Module not selected", []string{ModuleHeadings}, map[string]ModuleOptions{ModuleLinks: {"probe": true}}},
+		{"Module without options", nil, map[string]ModuleOptions{ModulePageTitle: {"x": 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &mockHTTPClient{response: "<html></html>"}
+			service := NewServiceWithDependencies(mockClient, parser.NewHTMLParser(), worker.NewWorkerPool(1))
+
+			_, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{
+				URL:     "https://example.com",
+				Modules: tt.modules,
+				Options: tt.options,
+			})
+
+			var analysisErr *AnalysisError
+			require.ErrorAs(t, err, &analysisErr)
+			assert.Equal(t, 400, analysisErr.StatusCode, "Invalid options should be rejected as a bad request")
+		})
+	}
+}
+
+func TestOpenRedirectFindings(t *testing.T) {
+	findings := openRedirectFindings("https://example.com/page", []string{
+		"https://example.com/login?next=https://evil.test/phish",
+		"https://example.com/go?to=//evil.test/",
+		"https://tracker.test/click?url=https%3A%2F%2Fshop.test%2Fitem",
+		"https://www.facebook.com/sharer/sharer.php?u=https://example.com/page",
+		"https://example.com/search?q=https://example.com/",
+		"https://example.com/a?ref=//evil.test/",
+		"https://example.com/plain",
+	})
+
+	require.Len(t, findings, 3)
+	assert.Equal(t, FindingOpenRedirect, findings[0].Type)
+	assert.Equal(t, SeverityMedium, findings[0].Severity, "A same-site redirector should rate medium")
+	assert.Contains(t, findings[0].Message, `"next"`)
+	assert.Contains(t, findings[0].Message, "evil.test")
+	assert.Equal(t, "https://example.com/login?next=https://evil.test/phish", findings[0].Evidence)
+	assert.Equal(t, SeverityMedium, findings[1].Severity, "Protocol-relative URLs should count in redirect parameters")
+	assert.Equal(t, SeverityLow, findings[2].Severity, "A third-party redirector should rate low")
+	assert.Contains(t, findings[2].Message, "shop.test")
+}
+
+func TestAnalyzeWebpage_OpenRedirectFinding(t *testing.T) {
+	mockClient := &mockHTTPClient{response: `<html><body><a href="/out?redirect=https://evil.test/">out</a></body></html>`}
+	service := NewServiceWithDependencies(mockClient, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{
+		URL:     "https://example.com",
+		Modules: []string{ModuleLinks},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, result.Findings, 1)
+	assert.Equal(t, FindingOpenRedirect, result.Findings[0].Type)
+	assert.Equal(t, "https://example.com/out?redirect=https://evil.test/", result.Findings[0].Evidence)
+}
@@ -0,0 +1,142 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// loginFormModule detects login forms, flags those that submit credentials
+// insecurely, and audits password inputs for password-manager friendliness.
+type loginFormModule struct {
+	htmlParser parser.HTMLParser
+}
+
+func (m *loginFormModule) Name() string { return ModuleLoginForm }
+
+// Analyze reports whether the page has a login form and adds a finding for
+// each login form that posts over plain HTTP or to another host.
+func (m *loginFormModule) Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error) {
+	hasLogin := m.htmlParser.ExtractLoginForm(doc)
+
+	var findings []Finding
+	if hasLogin {
+		for _, form := range m.htmlParser.ExtractForms(doc) {
+			if form.Login {
+				findings = append(findings, checkCredentialSubmission(info.URL, form.Action)...)
+			}
+		}
+	}
+
+	passwordFields := auditPasswordFields(m.htmlParser.ExtractPasswordFields(doc))
+	findings = append(findings, passwordFieldFindings(passwordFields)...)
+
+	return ModuleResultFunc(func(a *WebpageAnalysis) {
+		a.HasLoginForm = hasLogin
+		a.PasswordFields = passwordFields
+		a.Findings = append(a.Findings, findings...)
+	}), nil
+}
+
+// auditPasswordFields classifies each password input's autocomplete and paste handling.
+func auditPasswordFields(fields []parser.PasswordField) []PasswordFieldAudit {
+	var audits []PasswordFieldAudit
+	for _, field := range fields {
+		tokens := strings.Fields(field.Autocomplete)
+		audit := PasswordFieldAudit{
+			Name:                 field.Name,
+			Autocomplete:         field.Autocomplete,
+			AutocompleteDisabled: field.Autocomplete == "off",
+			PasteBlocked:         field.PasteBlocked,
+			MissingHint:          true,
+		}
+		for _, token := range tokens {
+			if token == "current-password" || token == "new-password" {
+				audit.MissingHint = false
+			}
+		}
+		audits = append(audits, audit)
+	}
+	return audits
+}
+
+// passwordFieldFindings turns password field audit results into findings.
+func passwordFieldFindings(audits []PasswordFieldAudit) []Finding {
+	var findings []Finding
+	for i, audit := range audits {
+		evidence := audit.Name
+		if evidence == "" {
+			evidence = fmt.Sprintf("password field #%d", i+1)
+		}
+		switch {
+		case audit.AutocompleteDisabled:
+			findings = append(findings, Finding{
+				Type:     FindingPasswordAutocompleteOff,
+				Severity: SeverityLow,
+				Message:  "Password field sets autocomplete=off, which discourages password managers",
+				Evidence: evidence,
+			})
+		case audit.MissingHint:
+			findings = append(findings, Finding{
+				Type:     FindingPasswordHintMissing,
+				Severity: SeverityInfo,
+				Message:  "Password field has no current-password or new-password autocomplete hint",
+				Evidence: evidence,
+			})
+		}
+		if audit.PasteBlocked {
+			findings = append(findings, Finding{
+				Type:     FindingPasswordPasteBlocked,
+				Severity: SeverityLow,
+				Message:  "Password field blocks pasting, which breaks password managers",
+				Evidence: evidence,
+			})
+		}
+	}
+	return findings
+}
+
+// checkCredentialSubmission resolves a login form's action against the page
+// URL and reports where the credentials would be sent insecurely.
+func checkCredentialSubmission(pageURL, action string) []Finding {
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	target, err := page.Parse(action)
+	if err != nil {
+		return nil
+	}
+	if !strings.EqualFold(target.Scheme, "http") && !strings.EqualFold(target.Scheme, "https") {
+		return nil // javascript: and similar actions are handled by script.
+	}
+
+	var findings []Finding
+	if strings.EqualFold(target.Scheme, "http") {
+		message := "Login form submits credentials over plain HTTP"
+		if strings.EqualFold(page.Scheme, "https") {
+			message = "Login form on an HTTPS page submits credentials over plain HTTP"
+		}
+		findings = append(findings, Finding{
+			Type:     FindingInsecureCredentialSubmission,
+			Severity: SeverityHigh,
+			Message:  message,
+			Evidence: target.String(),
+		})
+	}
+	// A scheme change alone is covered above, so only a different host counts here.
+	if !strings.EqualFold(page.Hostname(), target.Hostname()) {
+		findings = append(findings, Finding{
+			Type:     FindingInsecureCredentialSubmission,
+			Severity: SeverityMedium,
+			Message:  "Login form submits credentials to a different host (" + target.Host + ")",
+			Evidence: target.String(),
+		})
+	}
+	return findings
+}
@@ -0,0 +1,112 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+func TestLoginFormModule_InsecureSubmission(t *testing.T) {
+	loginPage := func(action string) string {
+		return `<html><body><form action="` + action + `" method="post">
+			<input name="username"><input type="password" name="password">
+			<button type="submit">Log in</button>
+		</form></body></html>`
+	}
+
+	tests := []struct {
+		name       string
+		pageURL    string
+		action     string
+		severities []Severity
+	}{
+		{"https same host", "https://example.com/", "/login", nil},
+		{"https empty action", "https://example.com/login", "", nil},
+		{"http page", "http://example.com/", "/login", []Severity{SeverityHigh}},
+		{"downgrade to http", "https://example.com/", "http://example.com/login", []Severity{SeverityHigh}},
+		{"other host", "https://example.com/", "https://auth.other.com/login", []Severity{SeverityMedium}},
+		{"other host over http", "https://example.com/", "http://other.com/login", []Severity{SeverityHigh, SeverityMedium}},
+		{"javascript action", "https://example.com/", "javascript:void(0)", nil},
+	}
+
+	module := &loginFormModule{htmlParser: parser.NewHTMLParser()}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(loginPage(tt.action)))
+			require.NoError(t, err)
+
+			result, err := module.Analyze(context.Background(), doc, FetchInfo{URL: tt.pageURL}, nil)
+			require.NoError(t, err)
+			analysis := &WebpageAnalysis{}
+			result.Apply(analysis)
+
+			assert.True(t, analysis.HasLoginForm)
+			var severities []Severity
+			for _, f := range analysis.Findings {
+				if f.Type != FindingInsecureCredentialSubmission {
+					continue
+				}
+				assert.NotEmpty(t, f.Evidence, "Findings should name the submission URL")
+				severities = append(severities, f.Severity)
+			}
+			assert.Equal(t, tt.severities, severities)
+		})
+	}
+}
+
+func TestLoginFormModule_IgnoresNonLoginForms(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<form action="http://other.com/search"><input name="q"></form>`))
+	require.NoError(t, err)
+
+	result, err := (&loginFormModule{htmlParser: parser.NewHTMLParser()}).Analyze(context.Background(), doc, FetchInfo{URL: "https://example.com/"}, nil)
+	require.NoError(t, err)
+	analysis := &WebpageAnalysis{}
+	result.Apply(analysis)
+
+	assert.False(t, analysis.HasLoginForm)
+	assert.Empty(t, analysis.Findings, "Only login forms should be checked")
+}
+
+func TestLoginFormModule_PasswordFieldAudit(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+		<form action="/login" method="post">
+			<input name="username"><input type="password" name="password" autocomplete="current-password">
+			<button type="submit">Log in</button>
+		</form>
+		<form action="/signup" method="post" autocomplete="off">
+			<input type="password" name="new" onpaste="return false">
+			<input type="password" name="confirm" autocomplete="new-password" onpaste="return false">
+		</form>
+		<input type="password">
+	</body></html>`))
+	require.NoError(t, err)
+
+	result, err := (&loginFormModule{htmlParser: parser.NewHTMLParser()}).Analyze(context.Background(), doc, FetchInfo{URL: "https://example.com/"}, nil)
+	require.NoError(t, err)
+	analysis := &WebpageAnalysis{}
+	result.Apply(analysis)
+
+	assert.Equal(t, []PasswordFieldAudit{
+		{Name: "password", Autocomplete: "current-password"},
+		{Name: "new", Autocomplete: "off", AutocompleteDisabled: true, PasteBlocked: true, MissingHint: true},
+		{Name: "confirm", Autocomplete: "new-password", PasteBlocked: true},
+		{MissingHint: true},
+	}, analysis.PasswordFields)
+
+	var got []string
+	for _, f := range analysis.Findings {
+		got = append(got, f.Type+" "+f.Evidence)
+	}
+	assert.Equal(t, []string{
+		FindingPasswordAutocompleteOff + " new",
+		FindingPasswordPasteBlocked + " new",
+		FindingPasswordPasteBlocked + " confirm",
+		FindingPasswordHintMissing + " password field #4",
+	}, got, "A well-configured login field should produce no findings")
+}
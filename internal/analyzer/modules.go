@@ -0,0 +1,359 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/parser"
+)
+
+// Names of the built-in analysis modules.
+const (
+	ModuleHTMLVersion     = "html_version"
+	ModulePageTitle       = "page_title"
+	ModuleHeadings        = "headings"
+	ModuleLinks           = "links"
+	ModuleLoginForm       = "login_form"
+	ModuleContentHash     = "content_hash"
+	ModuleCaptcha         = "captcha"
+	ModuleSocialLogin     = "social_login"
+	ModulePayment         = "payment"
+	ModuleContacts        = "contacts"
+	ModuleSocialProfiles  = "social_profiles"
+	ModuleTechnologies    = "technologies"
+	ModuleDOM             = "dom"
+	ModuleClientRedirect  = "client_redirect"
+	ModuleRobots          = "robots"
+	ModulePagination      = "pagination"
+	ModuleStructuredData  = "structured_data"
+	ModuleProduct         = "product"
+	ModuleStructure       = "structure"
+	ModuleImages          = "images"
+	ModuleResourceHints   = "resource_hints"
+	ModuleConsent         = "consent"
+	ModuleTrackers        = "trackers"
+	ModuleInterstitials   = "interstitials"
+	ModuleSuspiciousLinks = "suspicious_links"
+	ModuleTrackingParams  = "tracking_params"
+	ModuleCanonical       = "canonical"
+	ModuleLanguage        = "language"
+	ModuleArticle         = "article"
+	ModuleWayback         = "wayback"
+	ModuleDomain          = "domain"
+	ModuleDNS             = "dns"
+	ModuleInfrastructure  = "infrastructure"
+	ModuleHTTPS           = "https"
+	ModuleSiteVariants    = "site_variants"
+	ModuleTLS             = "tls"
+	ModuleFavicon         = "favicon"
+	ModuleReputation      = "reputation" // Registered only when a threat feed is configured; see NewReputationModule.
+)
+
+// Registry holds the analysis modules a service runs, in registration order.
+type Registry struct {
+	mu      sync.RWMutex
+	modules []AnalyzerModule
+	byName  map[string]AnalyzerModule
+}
+
+// NewRegistry creates a registry containing the given modules.
+// It panics if two modules share a name.
+func NewRegistry(modules ...AnalyzerModule) *Registry {
+	r := &Registry{byName: make(map[string]AnalyzerModule)}
+	for _, m := range modules {
+		if err := r.Register(m); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// NewDefaultRegistry creates a registry with the built-in modules.
+func NewDefaultRegistry(htmlParser parser.HTMLParser, httpClient client.HTTPClient) *Registry {
+	return NewRegistry(CoreModules(htmlParser, httpClient)...)
+}
+
+// Register adds a module. Module names must be unique.
+func (r *Registry) Register(m AnalyzerModule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.byName[m.Name()]; exists {
+		return fmt.Errorf("analysis module %q is already registered", m.Name())
+	}
+	r.modules = append(r.modules, m)
+	r.byName[m.Name()] = m
+	return nil
+}
+
+// Remove unregisters the named module, reporting whether it was registered.
+// Deployments use it to switch off modules they must not run, such as contacts
+// where collecting personal data is not allowed.
+func (r *Registry) Remove(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.byName[name]; !exists {
+		return false
+	}
+	delete(r.byName, name)
+	for i, m := range r.modules {
+		if m.Name() == name {
+			r.modules = append(r.modules[:i], r.modules[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Names returns the registered module names in registration order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, len(r.modules))
+	for i, m := range r.modules {
+		names[i] = m.Name()
+	}
+	return names
+}
+
+// Select returns the named modules in registration order, or every module
+// except opt-in ones when names is empty. Unknown names are reported as an error.
+func (r *Registry) Select(names []string) ([]AnalyzerModule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(names) == 0 {
+		selected := make([]AnalyzerModule, 0, len(r.modules))
+		for _, m := range r.modules {
+			if optIn, ok := m.(OptInModule); !ok || !optIn.OptIn() {
+				selected = append(selected, m)
+			}
+		}
+		return selected, nil
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		if _, ok := r.byName[name]; !ok {
+			return nil, fmt.Errorf("unknown analysis module %q", name)
+		}
+		wanted[name] = true
+	}
+
+	selected := make([]AnalyzerModule, 0, len(wanted))
+	for _, m := range r.modules {
+		if wanted[m.Name()] {
+			selected = append(selected, m)
+		}
+	}
+	return selected, nil
+}
+
+// validateOptions checks that every entry in options targets one of the selected
+// modules and that the module accepts the given options.
+func validateOptions(modules []AnalyzerModule, options map[string]ModuleOptions) error {
+	for name, opts := range options {
+		var module AnalyzerModule
+		for _, m := range modules {
+			if m.Name() == name {
+				module = m
+				break
+			}
+		}
+		if module == nil {
+			return fmt.Errorf("options given for module %q, which is not selected", name)
+		}
+
+		validator, ok := module.(OptionsValidator)
+		if !ok {
+			if len(opts) > 0 {
+				return fmt.Errorf("module %q does not take options", name)
+			}
+			continue
+		}
+		if err := validator.ValidateOptions(opts); err != nil {
+			return fmt.Errorf("module %q: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// Bool returns the boolean option key, or def if it is not set.
+func (o ModuleOptions) Bool(key string, def bool) (bool, error) {
+	switch v := o[key].(type) {
+	case nil:
+		return def, nil
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, fmt.Errorf("option %q must be a boolean", key)
+		}
+		return b, nil
+	default:
+		return false, fmt.Errorf("option %q must be a boolean", key)
+	}
+}
+
+// Int returns the integer option key, or def if it is not set.
+func (o ModuleOptions) Int(key string, def int) (int, error) {
+	switch v := o[key].(type) {
+	case nil:
+		return def, nil
+	case int:
+		return v, nil
+	case float64: // JSON numbers.
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("option %q must be an integer", key)
+		}
+		return int(v), nil
+	case string:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("option %q must be an integer", key)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("option %q must be an integer", key)
+	}
+}
+
+// Duration returns the duration option key, or def if it is not set. Strings
+// use time.ParseDuration syntax ("2s", "500ms"); numbers are seconds.
+func (o ModuleOptions) Duration(key string, def time.Duration) (time.Duration, error) {
+	switch v := o[key].(type) {
+	case nil:
+		return def, nil
+	case time.Duration:
+		return v, nil
+	case float64:
+		return time.Duration(v * float64(time.Second)), nil
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("option %q must be a duration such as \"2s\"", key)
+		}
+		return d, nil
+	default:
+		return 0, fmt.Errorf("option %q must be a duration such as \"2s\"", key)
+	}
+}
+
+// moduleFunc adapts a function to AnalyzerModule.
+type moduleFunc struct {
+	name    string
+	analyze func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error)
+}
+
+func (m moduleFunc) Name() string { return m.name }
+
+func (m moduleFunc) Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error) {
+	return m.analyze(ctx, doc, info)
+}
+
+// NewModule creates an option-less AnalyzerModule from a name and an analyze function.
+func NewModule(name string, analyze func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error)) AnalyzerModule {
+	return moduleFunc{name: name, analyze: analyze}
+}
+
+// CoreModules returns the built-in modules. httpClient is used by the links
+// module's optional link probe and by the opt-in modules that query external
+// services.
+func CoreModules(htmlParser parser.HTMLParser, httpClient client.HTTPClient) []AnalyzerModule {
+	return []AnalyzerModule{
+		NewModule(ModuleHTMLVersion, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+			version := htmlParser.ExtractHTMLVersion(doc)
+			return ModuleResultFunc(func(a *WebpageAnalysis) { a.HTMLVersion = version }), nil
+		}),
+		NewModule(ModulePageTitle, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+			title := htmlParser.ExtractPageTitle(doc)
+			description := ExtractDescription(pageFrom(ctx, doc, htmlParser).Elements("meta"))
+			return ModuleResultFunc(func(a *WebpageAnalysis) {
+				a.PageTitle = title
+				a.Description = description
+			}), nil
+		}),
+		NewModule(ModuleHeadings, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+			headings := htmlParser.ExtractHeadings(doc)
+			return ModuleResultFunc(func(a *WebpageAnalysis) { a.Headings = headings }), nil
+		}),
+		&linksModule{htmlParser: htmlParser, httpClient: httpClient},
+		&loginFormModule{htmlParser: htmlParser},
+		&contentHashModule{htmlParser: htmlParser},
+		NewModule(ModuleCaptcha, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+			captchas := DetectCaptchas(pageFrom(ctx, doc, htmlParser).Elements("script", "iframe", "div"))
+			return ModuleResultFunc(func(a *WebpageAnalysis) { a.Captchas = captchas }), nil
+		}),
+		NewModule(ModuleSocialLogin, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+			providers := DetectSocialLogins(pageFrom(ctx, doc, htmlParser).Elements("a", "button", "form", "script", "div"))
+			return ModuleResultFunc(func(a *WebpageAnalysis) { a.SocialLogins = providers }), nil
+		}),
+		NewModule(ModulePayment, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+			payment := DetectPayment(pageFrom(ctx, doc, htmlParser).Elements("input", "iframe", "script", "form"))
+			findings := paymentFindings(info.URL, payment)
+			return ModuleResultFunc(func(a *WebpageAnalysis) {
+				a.Payment = payment
+				a.Findings = append(a.Findings, findings...)
+			}), nil
+		}),
+		NewModule(ModuleContacts, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+			page := pageFrom(ctx, doc, htmlParser)
+			contacts := ExtractContacts(page.Elements("a"), page.VisibleText())
+			return ModuleResultFunc(func(a *WebpageAnalysis) { a.Contacts = contacts }), nil
+		}),
+		NewModule(ModuleSocialProfiles, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+			profiles := ExtractSocialProfiles(pageFrom(ctx, doc, htmlParser).Elements("a"))
+			return ModuleResultFunc(func(a *WebpageAnalysis) { a.SocialProfiles = profiles }), nil
+		}),
+		NewModule(ModuleTechnologies, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+			technologies := DetectTechnologies(pageFrom(ctx, doc, htmlParser).ElementsFunc(technologyCandidate))
+			return ModuleResultFunc(func(a *WebpageAnalysis) { a.Technologies = technologies }), nil
+		}),
+		domModule(),
+		clientRedirectModule(htmlParser),
+		robotsModule(htmlParser),
+		paginationModule(htmlParser),
+		structuredDataModule(),
+		productModule(htmlParser),
+		structureModule(),
+		imagesModule(),
+		resourceHintsModule(htmlParser),
+		consentModule(htmlParser),
+		trackersModule(htmlParser),
+		interstitialsModule(htmlParser),
+		NewSuspiciousLinksModule(htmlParser, DefaultProtectedDomains),
+		&trackingParamsModule{htmlParser: htmlParser},
+		&canonicalModule{htmlParser: htmlParser, httpClient: httpClient},
+		languageModule(htmlParser),
+		&articleModule{},
+		&waybackModule{httpClient: httpClient, cdxURL: DefaultWaybackCDXURL},
+		&domainModule{htmlParser: htmlParser, httpClient: httpClient, rdapURL: DefaultRDAPURL, now: time.Now},
+		&dnsModule{httpClient: httpClient, dohURL: DefaultDoHURL},
+		NewInfrastructureModule(nil),
+		newHTTPSModule(),
+		newSiteVariantsModule(),
+		&tlsModule{},
+		&faviconModule{htmlParser: htmlParser, httpClient: httpClient},
+	}
+}
+
+// ExtractDescription returns the content of the first <meta name="description">
+// with whitespace collapsed, or "" when the page has none.
+func ExtractDescription(elements []parser.Element) string {
+	for _, el := range elements {
+		if el.Tag == "meta" && strings.EqualFold(strings.TrimSpace(el.Attr("name")), "description") {
+			return strings.Join(strings.Fields(el.Attr("content")), " ")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,162 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/worker"
+)
+
+func TestRegistry_RegisterRejectsDuplicates(t *testing.T) {
+	registry := NewDefaultRegistry(parser.NewHTMLParser(), &mockHTTPClient{})
+
+	err := registry.Register(NewModule(ModuleLinks, nil))
+	assert.Error(t, err, "Register() should reject a duplicate module name")
+	assert.Equal(t, []string{ModuleHTMLVersion, ModulePageTitle, ModuleHeadings, ModuleLinks, ModuleLoginForm, ModuleContentHash, ModuleCaptcha, ModuleSocialLogin, ModulePayment, ModuleContacts, ModuleSocialProfiles, ModuleTechnologies, ModuleDOM, ModuleClientRedirect, ModuleRobots, ModulePagination, ModuleStructuredData, ModuleProduct, ModuleStructure, ModuleImages, ModuleResourceHints, ModuleConsent, ModuleTrackers, ModuleInterstitials, ModuleSuspiciousLinks, ModuleTrackingParams, ModuleCanonical, ModuleLanguage, ModuleArticle, ModuleWayback, ModuleDomain, ModuleDNS, ModuleInfrastructure, ModuleHTTPS, ModuleSiteVariants, ModuleTLS, ModuleFavicon}, registry.Names())
+}
+
+func TestRegistry_Select(t *testing.T) {
+	registry := NewDefaultRegistry(parser.NewHTMLParser(), &mockHTTPClient{})
+
+	all, err := registry.Select(nil)
+	require.NoError(t, err)
+	assert.Len(t, all, len(registry.Names())-9, "Select() with no names should return every module except opt-in ones")
+	for _, m := range all {
+		assert.NotContains(t, []string{ModuleWayback, ModuleDomain, ModuleDNS, ModuleInfrastructure, ModuleHTTPS, ModuleTLS, ModuleArticle, ModuleFavicon}, m.Name(), "Opt-in modules should only run when named")
+	}
+
+	selected, err := registry.Select([]string{ModuleLinks, ModuleHTMLVersion, ModuleLinks})
+	require.NoError(t, err)
+	require.Len(t, selected, 2, "Select() should de-duplicate names")
+	assert.Equal(t, ModuleHTMLVersion, selected[0].Name(), "Select() should keep registration order")
+	assert.Equal(t, ModuleLinks, selected[1].Name())
+
+	_, err = registry.Select([]string{"seo"})
+	assert.Error(t, err, "Select() should reject unknown modules")
+}
+
+func TestRegistry_Remove(t *testing.T) {
+	registry := NewDefaultRegistry(parser.NewHTMLParser(), &mockHTTPClient{})
+
+	assert.True(t, registry.Remove(ModuleContacts), "Remove() should report a registered module")
+	assert.False(t, registry.Remove(ModuleContacts), "Remove() should report an unknown module")
+	assert.NotContains(t, registry.Names(), ModuleContacts)
+
+	_, err := registry.Select([]string{ModuleContacts})
+	assert.Error(t, err, "A removed module should no longer be selectable")
+}
+
+func TestAnalyzeWebpage_ModuleSelection(t *testing.T) {
+	mockClient := &mockHTTPClient{response: `<html><head><title>Test</title></head><body><h1>A</h1><a href="/x">x</a></body></html>`}
+	service := NewServiceWithDependencies(mockClient, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{
+		URL:     "https://example.com",
+		Modules: []string{ModuleHeadings},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{ModuleHeadings}, result.Modules, "Only the selected modules should run")
+	assert.Equal(t, 1, result.Headings["h1"])
+	assert.Empty(t, result.PageTitle, "Unselected modules should not populate their fields")
+	assert.Zero(t, result.InternalLinks)
+}
+
+func TestAnalyzeWebpage_UnknownModule(t *testing.T) {
+	service := NewServiceWithDependencies(&mockHTTPClient{}, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{
+		URL:     "https://example.com",
+		Modules: []string{"nope"},
+	})
+
+	assert.Nil(t, result)
+	var analysisErr *AnalysisError
+	require.ErrorAs(t, err, &analysisErr)
+	assert.Equal(t, 400, analysisErr.StatusCode, "Unknown modules should be rejected as a bad request")
+}
+
+func TestAnalyzeWebpage_CustomModule(t *testing.T) {
+	htmlParser := parser.NewHTMLParser()
+	mockClient := &mockHTTPClient{response: `<html><head><title>Test</title></head></html>`}
+	registry := NewDefaultRegistry(htmlParser, mockClient)
+
+	var seenInfo FetchInfo
+	require.NoError(t, registry.Register(NewModule("word_count", func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		seenInfo = info
+		return ModuleResultFunc(func(a *WebpageAnalysis) { a.PageTitle = "custom" }), nil
+	})))
+	require.NoError(t, registry.Register(NewModule("broken", func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		return nil, errors.New("module failure")
+	})))
+
+	service := NewServiceWithRegistry(mockClient, htmlParser, worker.NewWorkerPool(2), registry)
+
+	result, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{
+		URL:     "https://example.com",
+		Modules: []string{"word_count", "broken"},
+	})
+
+	require.NoError(t, err, "A failing module should not fail the whole analysis")
+	assert.Equal(t, "custom", result.PageTitle, "Custom module result should be applied")
+	assert.Equal(t, []string{"word_count"}, result.Modules, "Failed modules should not be listed as run")
+	assert.Equal(t, "https://example.com", seenInfo.URL)
+	assert.Equal(t, 200, seenInfo.StatusCode)
+	assert.Positive(t, seenInfo.BodySize)
+}
+
+func TestModuleOptions_Getters(t *testing.T) {
+	opts := ModuleOptions{
+		"flag":    true,
+		"flagStr": "false",
+		"count":   float64(3),
+		"wait":    "250ms",
+		"seconds": float64(2),
+		"bad":     []string{"x"},
+	}
+
+	b, err := opts.Bool("flag", false)
+	require.NoError(t, err)
+	assert.True(t, b)
+	b, err = opts.Bool("flagStr", true)
+	require.NoError(t, err)
+	assert.False(t, b)
+	b, err = opts.Bool("missing", true)
+	require.NoError(t, err)
+	assert.True(t, b, "Unset options should return the default")
+
+	n, err := opts.Int("count", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	d, err := opts.Duration("wait", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, d)
+	d, err = opts.Duration("seconds", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, d, "Numeric durations should be seconds")
+
+	_, err = opts.Bool("bad", false)
+	assert.Error(t, err)
+	_, err = opts.Int("bad", 0)
+	assert.Error(t, err)
+	_, err = opts.Duration("bad", 0)
+	assert.Error(t, err)
+}
+
+func TestExtractDescription(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<meta name="robots" content="noindex"><meta name="Description" content=" An  example
+		page "><meta name="description" content="Second">`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "An example page", ExtractDescription(parser.NewHTMLParser().ExtractElements(doc, "meta")))
+	assert.Empty(t, ExtractDescription(nil))
+}
@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/worker"
+)
+
+// Option configures a service built by NewService.
+type Option func(*serviceOptions)
+
+// serviceOptions collects the dependencies of a service; nil fields get
+// their defaults.
+type serviceOptions struct {
+	httpClient client.HTTPClient
+	htmlParser parser.HTMLParser
+	workerPool *worker.WorkerPool
+	registry   *Registry
+	decorators []func(Service) Service
+}
+
+// WithHTTPClient fetches pages with c instead of client.NewHTTPClient.
+func WithHTTPClient(c client.HTTPClient) Option {
+	return func(o *serviceOptions) { o.httpClient = c }
+}
+
+// WithHTMLParser parses pages with p instead of parser.NewHTMLParser.
+func WithHTMLParser(p parser.HTMLParser) Option {
+	return func(o *serviceOptions) { o.htmlParser = p }
+}
+
+// WithWorkerPool runs analysis modules on pool instead of a new dynamic pool.
+// The caller shuts the pool down.
+func WithWorkerPool(pool *worker.WorkerPool) Option {
+	return func(o *serviceOptions) { o.workerPool = pool }
+}
+
+// WithRegistry runs the modules in r instead of the default registry built
+// from the service's parser and client.
+func WithRegistry(r *Registry) Option {
+	return func(o *serviceOptions) { o.registry = r }
+}
+
+// WithDecorator wraps the service in decorate, such as a result cache.
+// Decorators are applied in the order given, so the last one is outermost.
+// A nil decorate is ignored.
+func WithDecorator(decorate func(Service) Service) Option {
+	return func(o *serviceOptions) {
+		if decorate != nil {
+			o.decorators = append(o.decorators, decorate)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/worker"
+)
+
+// titleService overrides the page title of the analyses of the service it wraps.
+type titleService struct {
+	Service
+	title string
+}
+
+func (s titleService) AnalyzeWebpage(ctx context.Context, req AnalysisRequest) (*WebpageAnalysis, error) {
+	analysis, err := s.Service.AnalyzeWebpage(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	analysis.PageTitle = s.title
+	return analysis, nil
+}
+
+func TestNewService_Options(t *testing.T) {
+	pool := worker.NewWorkerPool(2)
+	defer pool.Shutdown()
+	htmlParser := parser.NewHTMLParser()
+	mockClient := &mockHTTPClient{response: "<html><head><title>Mock</title></head></html>"}
+
+	svc := NewService(
+		WithHTTPClient(mockClient),
+		WithHTMLParser(htmlParser),
+		WithWorkerPool(pool),
+		WithRegistry(NewDefaultRegistry(htmlParser, mockClient)),
+		WithDecorator(nil),
+	)
+	analysis, err := svc.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://example.com", Modules: []string{ModulePageTitle}})
+	require.NoError(t, err)
+	assert.Equal(t, "Mock", analysis.PageTitle, "The page should be fetched with the given client")
+}
+
+func TestNewService_Decorators(t *testing.T) {
+	svc := NewService(
+		WithHTTPClient(&mockHTTPClient{response: "<title>Mock</title>"}),
+		WithDecorator(func(s Service) Service { return titleService{s, "inner"} }),
+		WithDecorator(func(s Service) Service { return titleService{s, "outer"} }),
+	)
+	analysis, err := svc.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://example.com", Modules: []string{ModulePageTitle}})
+	require.NoError(t, err)
+	assert.Equal(t, "outer", analysis.PageTitle, "The last decorator should be outermost")
+}
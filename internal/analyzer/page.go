@@ -0,0 +1,105 @@
+package analyzer
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+type pageKey struct{}
+
+// page holds what several modules derive from the same document, such as its
+// text, its flattened elements and its links, so that an analysis extracts it once: the
+// first module to ask computes it, and the modules running alongside wait for
+// that instead of walking the DOM again.
+type page struct {
+	doc        *html.Node
+	htmlParser parser.HTMLParser
+
+	textOnce sync.Once
+	text     string
+
+	wordsOnce sync.Once
+	words     []string
+
+	elementsOnce sync.Once
+	elements     []parser.Element
+
+	linksOnce sync.Once
+	linksBase string
+	links     []string
+}
+
+// withPage returns a context carrying the shared extractions of doc for the
+// modules of one analysis.
+func withPage(ctx context.Context, doc *html.Node, htmlParser parser.HTMLParser) context.Context {
+	return context.WithValue(ctx, pageKey{}, &page{doc: doc, htmlParser: htmlParser})
+}
+
+// pageFrom returns the shared extractions of doc from ctx, or fresh ones when
+// a module runs outside an analysis or on another document.
+func pageFrom(ctx context.Context, doc *html.Node, htmlParser parser.HTMLParser) *page {
+	if p, ok := ctx.Value(pageKey{}).(*page); ok && p.doc == doc {
+		return p
+	}
+	return &page{doc: doc, htmlParser: htmlParser}
+}
+
+// VisibleText returns the page's visible text.
+func (p *page) VisibleText() string {
+	p.textOnce.Do(func() { p.text = p.htmlParser.ExtractVisibleText(p.doc) })
+	return p.text
+}
+
+// Words returns the page's visible text as normalized words. Callers must not
+// modify the slice.
+func (p *page) Words() []string {
+	p.wordsOnce.Do(func() { p.words = normalizedWords(p.VisibleText()) })
+	return p.words
+}
+
+// Elements returns the page's elements with the given tag names in document
+// order, or every element when no tags are given. The elements are flattened
+// in one pass over the DOM and shared; callers must not modify them.
+func (p *page) Elements(tags ...string) []parser.Element {
+	if len(tags) == 0 {
+		return p.allElements()
+	}
+	return p.ElementsFunc(func(el parser.Element) bool { return slices.Contains(tags, el.Tag) })
+}
+
+// ElementsFunc returns the page's elements for which keep returns true, in
+// document order.
+func (p *page) ElementsFunc(keep func(el parser.Element) bool) []parser.Element {
+	var elements []parser.Element
+	for _, el := range p.allElements() {
+		if keep(el) {
+			elements = append(elements, el)
+		}
+	}
+	return elements
+}
+
+// allElements flattens the page's elements on first use.
+func (p *page) allElements() []parser.Element {
+	p.elementsOnce.Do(func() { p.elements = p.htmlParser.ExtractElements(p.doc) })
+	return p.elements
+}
+
+// LinkURLs returns the page's links resolved against baseURL, as
+// parser.HTMLParser.ExtractLinkURLs does. The links resolved against the
+// first base asked for are shared; callers must not modify them.
+func (p *page) LinkURLs(baseURL string) []string {
+	p.linksOnce.Do(func() {
+		p.linksBase = baseURL
+		p.links = p.htmlParser.ExtractLinkURLs(p.doc, baseURL)
+	})
+	if baseURL != p.linksBase {
+		return p.htmlParser.ExtractLinkURLs(p.doc, baseURL)
+	}
+	return p.links
+}
@@ -0,0 +1,80 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// countingParser counts the text and element extractions of its parser.
+type countingParser struct {
+	parser.HTMLParser
+	texts    atomic.Int32
+	elements atomic.Int32
+}
+
+func (p *countingParser) ExtractElements(doc *html.Node, tags ...string) []parser.Element {
+	p.elements.Add(1)
+	return p.HTMLParser.ExtractElements(doc, tags...)
+}
+
+func (p *countingParser) ExtractVisibleText(doc *html.Node) string {
+	p.texts.Add(1)
+	return p.HTMLParser.ExtractVisibleText(doc)
+}
+
+func TestPageFrom_SharedWithinAnalysis(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<p>Hello, World</p>`))
+	require.NoError(t, err)
+	other, err := html.Parse(strings.NewReader(`<p>Other</p>`))
+	require.NoError(t, err)
+	htmlParser := &countingParser{HTMLParser: parser.NewHTMLParser()}
+
+	ctx := withPage(context.Background(), doc, htmlParser)
+	assert.Same(t, pageFrom(ctx, doc, htmlParser), pageFrom(ctx, doc, htmlParser), "Modules of one analysis should share its page")
+	assert.Equal(t, []string{"hello", "world"}, pageFrom(ctx, doc, htmlParser).Words())
+	assert.Equal(t, "Hello, World", pageFrom(ctx, doc, htmlParser).VisibleText())
+	assert.EqualValues(t, 1, htmlParser.texts.Load(), "The visible text should be extracted once per analysis")
+
+	assert.Equal(t, "Other", pageFrom(ctx, other, htmlParser).VisibleText(), "Another document should get a page of its own")
+	assert.Equal(t, "Hello, World", pageFrom(context.Background(), doc, htmlParser).VisibleText(), "A module run on its own should get a page of its own")
+}
+
+func TestPage_ElementsFlattenedOnce(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<head><link rel="icon" href="/a.ico"><meta name="robots" content="noindex"></head><body><a href="/">Home</a><div><a href="/b">B</a></div></body>`))
+	require.NoError(t, err)
+	htmlParser := &countingParser{HTMLParser: parser.NewHTMLParser()}
+	p := pageFrom(withPage(context.Background(), doc, htmlParser), doc, htmlParser)
+
+	tags := func(elements []parser.Element) []string {
+		var result []string
+		for _, el := range elements {
+			result = append(result, el.Tag)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"link", "a", "a"}, tags(p.Elements("a", "link")), "Elements should keep document order")
+	assert.Equal(t, []string{"meta"}, tags(p.Elements("meta")))
+	nested := p.ElementsFunc(func(el parser.Element) bool { return el.Attr("href") == "/b" })
+	require.Len(t, nested, 1)
+	assert.Equal(t, "B", nested[0].Text())
+	assert.Len(t, p.Elements(), 8, "No tags should return every element")
+	assert.EqualValues(t, 1, htmlParser.elements.Load(), "The elements should be flattened once per analysis")
+}
+
+func TestPage_LinkURLs(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<a href="/a#top">A</a><a href="https://other.example/">Other</a><a href="/a">Again</a>`))
+	require.NoError(t, err)
+	p := pageFrom(context.Background(), doc, parser.NewHTMLParser())
+
+	assert.Equal(t, []string{"https://example.com/a", "https://other.example/"}, p.LinkURLs("https://example.com/"))
+	assert.Same(t, &p.LinkURLs("https://example.com/")[0], &p.LinkURLs("https://example.com/")[0], "The links should be resolved once")
+	assert.Equal(t, []string{"https://example.org/a", "https://other.example/"}, p.LinkURLs("https://example.org/"), "Another base should resolve the links again")
+}
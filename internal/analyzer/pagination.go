@@ -0,0 +1,138 @@
+package analyzer
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// Pagination signal sources.
+const (
+	PaginationRel       = "rel"        // <link> or <a> elements with rel="next" or rel="prev".
+	PaginationPageLinks = "page_links" // Links to the same URL with another page number.
+)
+
+// pageParams are the query parameters that commonly hold a page number.
+var pageParams = []string{"page", "p", "pg", "paged", "pagenum"}
+
+// pagePathPattern matches a page number at the end of a path, as in
+// /blog/page/2/ or /news/page-3.
+var pagePathPattern = regexp.MustCompile(`(?i)/page[/-]?(\d+)/?$`)
+
+// paginationModule reports whether the page is part of a paginated series.
+func paginationModule(htmlParser parser.HTMLParser) AnalyzerModule {
+	return NewModule(ModulePagination, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		pagination := DetectPagination(info.URL, pageFrom(ctx, doc, htmlParser).Elements("link", "a"))
+		return ModuleResultFunc(func(a *WebpageAnalysis) { a.Pagination = pagination }), nil
+	})
+}
+
+// DetectPagination looks for rel="next" and rel="prev" links and, failing
+// those, for links to the same URL with the next or previous page number in
+// a query parameter (?page=3) or at the end of the path (/page/3/). Links are
+// resolved against pageURL; page number links must stay on its host.
+func DetectPagination(pageURL string, elements []parser.Element) *Pagination {
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return &Pagination{}
+	}
+	pagination := &Pagination{}
+	current, numbered := pageNumber(page)
+	if numbered {
+		pagination.Page = current
+	} else {
+		current = 1
+	}
+	series := seriesKey(page)
+
+	var relNext, relPrev string
+	numberLinks := make(map[int]string)
+	for _, el := range elements {
+		href := strings.TrimSpace(el.Attr("href"))
+		if href == "" || strings.HasPrefix(href, "#") {
+			continue
+		}
+		ref, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+		link := page.ResolveReference(ref)
+		link.Fragment = ""
+
+		for _, rel := range strings.Fields(strings.ToLower(el.Attr("rel"))) {
+			switch {
+			case rel == "next" && relNext == "":
+				relNext = link.String()
+			case (rel == "prev" || rel == "previous") && relPrev == "":
+				relPrev = link.String()
+			}
+		}
+		if !strings.EqualFold(link.Host, page.Host) || seriesKey(link) != series {
+			continue
+		}
+		if n, ok := pageNumber(link); ok {
+			if _, seen := numberLinks[n]; !seen {
+				numberLinks[n] = link.String()
+			}
+		} else if current > 1 {
+			// The first page of a series is often the URL without a number.
+			if _, seen := numberLinks[1]; !seen {
+				numberLinks[1] = link.String()
+			}
+		}
+	}
+
+	if relNext != "" || relPrev != "" {
+		pagination.Paginated = true
+		pagination.Source = PaginationRel
+		pagination.Next, pagination.Prev = relNext, relPrev
+		return pagination
+	}
+	delete(numberLinks, current)
+	if len(numberLinks) == 0 {
+		return pagination
+	}
+	pagination.Paginated = true
+	pagination.Source = PaginationPageLinks
+	pagination.Page = current
+	pagination.Next = numberLinks[current+1]
+	pagination.Prev = numberLinks[current-1]
+	return pagination
+}
+
+// pageNumber returns the page number in u's query or at the end of its path.
+func pageNumber(u *url.URL) (int, bool) {
+	query := u.Query()
+	for _, param := range pageParams {
+		if value := query.Get(param); value != "" {
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				return n, true
+			}
+		}
+	}
+	if m := pagePathPattern.FindStringSubmatch(u.Path); m != nil {
+		if n, err := strconv.Atoi(m[1]); err == nil && n > 0 {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// seriesKey returns u without its page number or trailing slash, which is the
+// same for every page of a series.
+func seriesKey(u *url.URL) string {
+	query := u.Query()
+	for _, param := range pageParams {
+		if n, err := strconv.Atoi(query.Get(param)); err == nil && n > 0 {
+			query.Del(param)
+		}
+	}
+	path := strings.TrimSuffix(pagePathPattern.ReplaceAllString(u.Path, ""), "/")
+	return strings.ToLower(u.Host) + path + "?" + query.Encode()
+}
@@ -0,0 +1,46 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+func TestDetectPagination(t *testing.T) {
+	tests := []struct {
+		name    string
+		pageURL string
+		page    string
+		want    *Pagination
+	}{
+		{"not paginated", "https://example.com/about", `<a href="/">Home</a><a href="/about">About</a><a href="/p/2">Post</a>`,
+			&Pagination{}},
+		{"rel links", "https://example.com/blog/page/2/", `<link rel="prev" href="/blog/"><link rel="next" href="/blog/page/3/">`,
+			&Pagination{Paginated: true, Source: PaginationRel, Page: 2, Next: "https://example.com/blog/page/3/", Prev: "https://example.com/blog/"}},
+		{"rel on anchors", "https://example.com/list", `<a rel="next nofollow" href="?start=20#results">More</a>`,
+			&Pagination{Paginated: true, Source: PaginationRel, Next: "https://example.com/list?start=20"}},
+		{"first page of query series", "https://example.com/search?q=go", `
+			<a href="/search?q=go&page=2">2</a><a href="/search?q=go&page=3">3</a><a href="/search?q=rust&page=2">Other search</a>`,
+			&Pagination{Paginated: true, Source: PaginationPageLinks, Page: 1, Next: "https://example.com/search?q=go&page=2"}},
+		{"middle page of path series", "https://example.com/news/page-3", `
+			<a href="/news">1</a><a href="/news/page-2">2</a><a href="/news/page-4">4</a><a href="https://other.example/news/page-4">Elsewhere</a>`,
+			&Pagination{Paginated: true, Source: PaginationPageLinks, Page: 3, Next: "https://example.com/news/page-4", Prev: "https://example.com/news/page-2"}},
+		{"second page links back to the unnumbered first", "https://example.com/shop/?paged=2", `<a href="/shop/">1</a>`,
+			&Pagination{Paginated: true, Source: PaginationPageLinks, Page: 2, Prev: "https://example.com/shop/"}},
+		{"link to itself only", "https://example.com/?page=1", `<a href="/?page=1">1</a>`,
+			&Pagination{Page: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.page))
+			require.NoError(t, err)
+			got := DetectPagination(tt.pageURL, parser.NewHTMLParser().ExtractElements(doc, "link", "a"))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
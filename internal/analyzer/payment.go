@@ -0,0 +1,116 @@
+package analyzer
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// paymentProviders maps hosted payment field domains to provider names.
+var paymentProviders = map[string]string{
+	"js.stripe.com":                  "stripe",
+	"checkout.stripe.com":            "stripe",
+	"m.stripe.network":               "stripe",
+	"js.braintreegateway.com":        "braintree",
+	"assets.braintreegateway.com":    "braintree",
+	"checkoutshopper-live.adyen.com": "adyen",
+	"checkoutshopper-test.adyen.com": "adyen",
+	"www.paypal.com":                 "paypal",
+	"js.squareup.com":                "square",
+	"web.squarecdn.com":              "square",
+}
+
+// cardAutocompleteTokens are the autocomplete values browsers use for card details.
+var cardAutocompleteTokens = map[string]bool{
+	"cc-number": true, "cc-csc": true, "cc-exp": true, "cc-exp-month": true, "cc-exp-year": true,
+}
+
+// cardFieldPattern matches input names and ids commonly used for card details.
+var cardFieldPattern = regexp.MustCompile(`(?i)^(cc[-_]?(num|number)|card[-_]?(num|number|no)|credit[-_]?card([-_]?number)?|cvv2?|cvc2?|card[-_]?(cvv|cvc|code|security[-_]?code))$`)
+
+// DetectPayment reports whether a page collects payment card data, from card
+// input fields and hosted payment fields or checkout scripts. It returns nil
+// when there are no signals.
+func DetectPayment(elements []parser.Element) *PaymentDetection {
+	detection := &PaymentDetection{}
+	seenProvider := make(map[string]bool)
+
+	for _, el := range elements {
+		switch el.Tag {
+		case "input":
+			if field := cardField(el); field != "" {
+				detection.CardFields = appendUnique(detection.CardFields, field)
+			}
+		case "iframe", "script", "form":
+			src := el.Attr("src")
+			if el.Tag == "form" {
+				src = el.Attr("action")
+			}
+			u, err := url.Parse(strings.TrimSpace(src))
+			if err != nil {
+				continue
+			}
+			if provider, ok := paymentProviders[strings.ToLower(u.Hostname())]; ok && !seenProvider[provider] {
+				seenProvider[provider] = true
+				detection.Providers = append(detection.Providers, provider)
+			}
+		}
+	}
+
+	if len(detection.CardFields) == 0 && len(detection.Providers) == 0 {
+		return nil
+	}
+	return detection
+}
+
+// cardField returns how an input identifies itself as a card field, or "".
+func cardField(el parser.Element) string {
+	for _, token := range strings.Fields(strings.ToLower(el.Attr("autocomplete"))) {
+		if cardAutocompleteTokens[token] {
+			return token
+		}
+	}
+	for _, attr := range []string{"name", "id"} {
+		if value := el.Attr(attr); cardFieldPattern.MatchString(value) {
+			return value
+		}
+	}
+	return ""
+}
+
+// appendUnique appends s to list unless it is already present.
+func appendUnique(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// paymentFindings reports that a page collects payment data, and whether it
+// does so without HTTPS.
+func paymentFindings(pageURL string, detection *PaymentDetection) []Finding {
+	if detection == nil {
+		return nil
+	}
+
+	evidence := strings.Join(append(append([]string(nil), detection.CardFields...), detection.Providers...), ", ")
+	findings := []Finding{{
+		Type:     FindingPaymentDataCollection,
+		Severity: SeverityInfo,
+		Message:  "Page collects payment card data and may be in scope for PCI DSS",
+		Evidence: evidence,
+	}}
+	if u, err := url.Parse(pageURL); err == nil && strings.EqualFold(u.Scheme, "http") {
+		findings = append(findings, Finding{
+			Type:     FindingInsecurePaymentForm,
+			Severity: SeverityHigh,
+			Message:  "Payment form is served over plain HTTP",
+			Evidence: pageURL,
+		})
+	}
+	return findings
+}
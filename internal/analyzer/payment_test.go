@@ -0,0 +1,60 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+func detectPaymentIn(t *testing.T, page string) *PaymentDetection {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(page))
+	require.NoError(t, err)
+	return DetectPayment(parser.NewHTMLParser().ExtractElements(doc, "input", "iframe", "script", "form"))
+}
+
+func TestDetectPayment(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want *PaymentDetection
+	}{
+		{"no payment", `<form><input name="email"><input name="card_holder"></form>`, nil},
+		{"autocomplete tokens", `<form>
+			<input autocomplete="cc-number"><input autocomplete="billing cc-exp"><input autocomplete="cc-number">
+		</form>`, &PaymentDetection{CardFields: []string{"cc-number", "cc-exp"}}},
+		{"field names", `<input name="cardNumber"><input id="cvv"><input name="card-code">`,
+			&PaymentDetection{CardFields: []string{"cardNumber", "cvv", "card-code"}}},
+		{"hosted fields", `<script src="https://js.stripe.com/v3/"></script>
+			<iframe src="https://js.stripe.com/v3/elements-inner-card.html"></iframe>
+			<script src="https://js.braintreegateway.com/web/3.97.2/js/client.min.js"></script>`,
+			&PaymentDetection{Providers: []string{"stripe", "braintree"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectPaymentIn(t, tt.page))
+		})
+	}
+}
+
+func TestPaymentFindings(t *testing.T) {
+	detection := &PaymentDetection{CardFields: []string{"cc-number"}, Providers: []string{"stripe"}}
+
+	findings := paymentFindings("https://shop.example.com/checkout", detection)
+	require.Len(t, findings, 1)
+	assert.Equal(t, FindingPaymentDataCollection, findings[0].Type)
+	assert.Equal(t, "cc-number, stripe", findings[0].Evidence)
+
+	findings = paymentFindings("http://shop.example.com/checkout", detection)
+	require.Len(t, findings, 2, "Payment forms over HTTP should be flagged")
+	assert.Equal(t, FindingInsecurePaymentForm, findings[1].Type)
+	assert.Equal(t, SeverityHigh, findings[1].Severity)
+
+	assert.Nil(t, paymentFindings("http://example.com", nil))
+}
@@ -0,0 +1,189 @@
+package analyzer
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// Product data sources.
+const (
+	ProductSourceStructuredData = "structured_data" // A schema.org Product item.
+	ProductSourceMetaTags       = "meta_tags"       // Open Graph product meta tags.
+)
+
+// productModule extracts the product a shop page sells.
+func productModule(htmlParser parser.HTMLParser) AnalyzerModule {
+	return NewModule(ModuleProduct, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		product := ExtractProduct(doc, pageFrom(ctx, doc, htmlParser).Elements("meta"))
+		return ModuleResultFunc(func(a *WebpageAnalysis) { a.Product = product }), nil
+	})
+}
+
+// ExtractProduct returns the first schema.org Product item of doc, from
+// JSON-LD or microdata, or failing that the product described by Open Graph
+// meta tags such as product:price:amount. It returns nil when the page has
+// neither.
+func ExtractProduct(doc *html.Node, metas []parser.Element) *ProductInfo {
+	items, _ := extractSchemaItems(doc)
+	for _, it := range items {
+		if product := schemaProduct(it.item); product != nil {
+			return product
+		}
+	}
+	return metaProduct(metas)
+}
+
+// schemaProduct reads a Product item, or a Product nested in another item
+// such as a ProductGroup's hasVariant or a WebPage's mainEntity.
+func schemaProduct(item schemaItem) *ProductInfo {
+	if !containsAny(item.types(), []string{"Product", "IndividualProduct", "ProductModel"}) {
+		for _, prop := range []string{"mainEntity", "hasVariant"} {
+			for _, v := range asList(item[prop]) {
+				if nested, ok := v.(map[string]interface{}); ok {
+					if product := schemaProduct(nested); product != nil {
+						return product
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	product := &ProductInfo{
+		Source: ProductSourceStructuredData,
+		Name:   schemaString(item["name"]),
+		SKU:    schemaString(item["sku"]),
+		Brand:  schemaString(item["brand"]),
+	}
+	for _, v := range asList(item["offers"]) {
+		offer, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		price := schemaString(offer["price"])
+		if price == "" {
+			price = schemaString(offer["lowPrice"]) // AggregateOffer.
+		}
+		if p, ok := parsePrice(price); ok {
+			product.Price = p
+			product.Currency = strings.ToUpper(schemaString(offer["priceCurrency"]))
+			product.Availability = schemaEnum(schemaString(offer["availability"]))
+			break
+		}
+	}
+	if rating, ok := firstMap(item["aggregateRating"]); ok {
+		product.Rating, _ = strconv.ParseFloat(schemaString(rating["ratingValue"]), 64)
+		count := schemaString(rating["reviewCount"])
+		if count == "" {
+			count = schemaString(rating["ratingCount"])
+		}
+		product.RatingCount, _ = strconv.Atoi(count)
+	}
+	return product
+}
+
+// metaProduct reads the Open Graph product meta tags, or returns nil when
+// there is no price among them.
+func metaProduct(metas []parser.Element) *ProductInfo {
+	tags := make(map[string]string)
+	for _, el := range metas {
+		key := strings.ToLower(el.Attr("property"))
+		if key == "" {
+			key = strings.ToLower(el.Attr("name"))
+		}
+		if _, seen := tags[key]; !seen && key != "" {
+			tags[key] = strings.TrimSpace(el.Attr("content"))
+		}
+	}
+	price, ok := parsePrice(firstNonEmpty(tags["product:price:amount"], tags["og:price:amount"]))
+	if !ok {
+		return nil
+	}
+	return &ProductInfo{
+		Source:       ProductSourceMetaTags,
+		Name:         tags["og:title"],
+		SKU:          tags["product:retailer_item_id"],
+		Brand:        tags["product:brand"],
+		Price:        price,
+		Currency:     strings.ToUpper(firstNonEmpty(tags["product:price:currency"], tags["og:price:currency"])),
+		Availability: schemaEnum(firstNonEmpty(tags["product:availability"], tags["og:availability"])),
+	}
+}
+
+// parsePrice parses a price such as "1299", "1,299.00" or "1.299,00". The
+// last separator followed by one or two digits is the decimal point; other
+// separators group thousands.
+func parsePrice(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	digits := strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || r == '.' || r == ',' {
+			return r
+		}
+		return -1
+	}, s)
+	if digits == "" {
+		return 0, false
+	}
+	decimal := ""
+	if i := strings.LastIndexAny(digits, ".,"); i >= 0 && len(digits)-i-1 <= 2 {
+		decimal, digits = digits[i+1:], digits[:i]
+	}
+	digits = strings.NewReplacer(".", "", ",", "").Replace(digits)
+	if decimal != "" {
+		digits += "." + decimal
+	}
+	price, err := strconv.ParseFloat(digits, 64)
+	return price, err == nil && price >= 0
+}
+
+// schemaString returns a scalar property value as a string: the first value
+// of a list, or the name of a nested item such as a Brand.
+func schemaString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		if len(v) > 0 {
+			return schemaString(v[0])
+		}
+	case map[string]interface{}:
+		return schemaString(v["name"])
+	}
+	return ""
+}
+
+// schemaEnum strips the vocabulary from an enumeration value, so that
+// "https://schema.org/InStock" becomes "InStock".
+func schemaEnum(s string) string {
+	if i := strings.LastIndex(s, "/"); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+// firstMap returns the first nested item of a property value.
+func firstMap(v interface{}) (map[string]interface{}, bool) {
+	for _, elem := range asList(v) {
+		if m, ok := elem.(map[string]interface{}); ok {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// firstNonEmpty returns the first of values that is not empty.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+func TestExtractProduct(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want *ProductInfo
+	}{
+		{"none", `<meta property="og:title" content="About us">`, nil},
+		{"json-ld", `<script type="application/ld+json">{
+			"@type": "Product", "name": "Widget", "sku": "W-1", "brand": {"@type": "Brand", "name": "Acme"},
+			"offers": [{"@type": "Offer", "price": 19.99, "priceCurrency": "usd", "availability": "https://schema.org/InStock"}],
+			"aggregateRating": {"@type": "AggregateRating", "ratingValue": "4.6", "reviewCount": "128"}
+		}</script>`,
+			&ProductInfo{Source: ProductSourceStructuredData, Name: "Widget", SKU: "W-1", Brand: "Acme", Price: 19.99, Currency: "USD",
+				Availability: "InStock", Rating: 4.6, RatingCount: 128}},
+		{"aggregate offer in a product group", `<script type="application/ld+json">{
+			"@type": "ProductGroup", "hasVariant": [{"@type": "Product", "name": "Shirt M",
+			"offers": {"@type": "AggregateOffer", "lowPrice": "1.299,00", "priceCurrency": "EUR"}}]
+		}</script>`,
+			&ProductInfo{Source: ProductSourceStructuredData, Name: "Shirt M", Price: 1299, Currency: "EUR"}},
+		{"microdata", `<div itemscope itemtype="https://schema.org/Product">
+			<h1 itemprop="name">Lamp</h1>
+			<div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+				<span itemprop="price" content="45.00">$45</span><meta itemprop="priceCurrency" content="USD">
+				<link itemprop="availability" href="https://schema.org/OutOfStock">
+			</div>
+		</div>`,
+			&ProductInfo{Source: ProductSourceStructuredData, Name: "Lamp", Price: 45, Currency: "USD", Availability: "OutOfStock"}},
+		{"open graph", `<meta property="og:title" content="Mug">
+			<meta property="product:price:amount" content="1,250">
+			<meta property="product:price:currency" content="GBP">
+			<meta property="product:availability" content="in stock">`,
+			&ProductInfo{Source: ProductSourceMetaTags, Name: "Mug", Price: 1250, Currency: "GBP", Availability: "in stock"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.page))
+			require.NoError(t, err)
+			got := ExtractProduct(doc, parser.NewHTMLParser().ExtractElements(doc, "meta"))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
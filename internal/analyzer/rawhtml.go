@@ -0,0 +1,91 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"webpage-analyzer/internal/logging"
+)
+
+// AnalyzeHTML runs the analysis modules on HTML supplied by the caller. Nothing
+// is fetched, so modules that query the network, the opt-in ones, cannot be
+// selected, and module options such as the links probe are not taken.
+func (s *service) AnalyzeHTML(ctx context.Context, req HTMLRequest) (*WebpageAnalysis, error) {
+	startTime := time.Now()
+	logging.FromContext(ctx).Info("Starting raw HTML analysis", "base_url", req.BaseURL, "body_size_bytes", len(req.HTML), "modules", req.Modules)
+
+	if strings.TrimSpace(req.HTML) == "" {
+		return nil, &AnalysisError{
+			StatusCode:   http.StatusBadRequest,
+			Code:         ErrorCodeInvalidRequest,
+			ErrorMessage: "html is required",
+			URL:          req.BaseURL,
+		}
+	}
+	if err := validateBaseURL(req.BaseURL); err != nil {
+		return nil, &AnalysisError{
+			StatusCode:   http.StatusBadRequest,
+			Code:         ErrorCodeInvalidRequest,
+			ErrorMessage: fmt.Sprintf("Invalid base_url: %v", err),
+			URL:          req.BaseURL,
+		}
+	}
+
+	modules, err := s.offlineModules(req.Modules)
+	if err != nil {
+		return nil, &AnalysisError{
+			StatusCode:   http.StatusBadRequest,
+			Code:         ErrorCodeInvalidRequest,
+			ErrorMessage: fmt.Sprintf("Invalid module selection: %v", err),
+			URL:          req.BaseURL,
+		}
+	}
+
+	doc, err := s.httpClient.ParseHTML([]byte(req.HTML))
+	if err != nil {
+		logging.FromContext(ctx).Error("Error parsing HTML", "base_url", req.BaseURL, "error", err)
+		return nil, &AnalysisError{
+			StatusCode:   http.StatusBadRequest,
+			Code:         ErrorCodeParseFailure,
+			ErrorMessage: fmt.Sprintf("Failed to parse HTML content: %v", err),
+			URL:          req.BaseURL,
+		}
+	}
+
+	info := FetchInfo{URL: req.BaseURL, StatusCode: http.StatusOK, BodySize: len(req.HTML)}
+	return s.analyzeDocument(ctx, doc, info, modules, nil, startTime, time.Time{})
+}
+
+// offlineModules selects the named modules, rejecting those that need the
+// network. Empty names select every module that runs by default.
+func (s *service) offlineModules(names []string) ([]AnalyzerModule, error) {
+	modules, err := s.modules.Select(names)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range modules {
+		if optIn, ok := m.(OptInModule); ok && optIn.OptIn() {
+			return nil, fmt.Errorf("analysis module %q queries the network and cannot analyze supplied HTML", m.Name())
+		}
+	}
+	return modules, nil
+}
+
+// validateBaseURL checks that baseURL, if set, is an absolute http(s) URL.
+func validateBaseURL(baseURL string) error {
+	if baseURL == "" {
+		return nil
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an absolute http or https URL")
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/worker"
+)
+
+const templateHTML = `<!DOCTYPE html>
+<html>
+	<head><title>Generated Page</title></head>
+	<body>
+		<h1>Welcome</h1>
+		<a href="/about">About</a>
+		<a href="https://example.com/contact">Contact</a>
+		<a href="https://other.example.org">Partner</a>
+	</body>
+</html>`
+
+func TestAnalyzeHTML_Success(t *testing.T) {
+	// The client fails every fetch, so any network access would show.
+	mockClient := &mockHTTPClient{error: errors.New("no network")}
+	service := NewServiceWithDependencies(mockClient, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.AnalyzeHTML(context.Background(), HTMLRequest{HTML: templateHTML, BaseURL: "https://example.com/"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/", result.URL)
+	assert.Equal(t, "Generated Page", result.PageTitle)
+	assert.Equal(t, 1, result.Headings["h1"])
+	assert.Equal(t, 2, result.InternalLinks, "Links to the base URL's host should be internal")
+	assert.Equal(t, 1, result.ExternalLinks)
+	assert.Contains(t, result.Modules, ModuleContentHash)
+}
+
+func TestAnalyzeHTML_WithoutBaseURL(t *testing.T) {
+	service := NewServiceWithDependencies(&mockHTTPClient{}, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.AnalyzeHTML(context.Background(), HTMLRequest{HTML: templateHTML, Modules: []string{ModuleLinks}})
+	require.NoError(t, err)
+	assert.Empty(t, result.URL)
+	assert.Equal(t, 1, result.InternalLinks)
+	assert.Equal(t, 2, result.ExternalLinks)
+	assert.Equal(t, []string{ModuleLinks}, result.Modules)
+}
+
+func TestAnalyzeHTML_InvalidRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     HTMLRequest
+		message string
+	}{
+		{"empty HTML", HTMLRequest{HTML: "  \n"}, "html is required"},
+		{"relative base URL", HTMLRequest{HTML: templateHTML, BaseURL: "/index.html"}, "Invalid base_url"},
+		{"non-HTTP base URL", HTMLRequest{HTML: templateHTML, BaseURL: "file:///tmp/index.html"}, "Invalid base_url"},
+		{"unknown module", HTMLRequest{HTML: templateHTML, Modules: []string{"nope"}}, "Invalid module selection"},
+		{"network module", HTMLRequest{HTML: templateHTML, Modules: []string{ModuleTLS}}, "queries the network"},
+	}
+	service := NewServiceWithDependencies(&mockHTTPClient{}, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.AnalyzeHTML(context.Background(), tt.req)
+			var analysisErr *AnalysisError
+			require.ErrorAs(t, err, &analysisErr)
+			assert.Equal(t, 400, analysisErr.StatusCode)
+			assert.Contains(t, analysisErr.ErrorMessage, tt.message)
+		})
+	}
+}
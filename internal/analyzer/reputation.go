@@ -0,0 +1,168 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// Options accepted by the reputation module.
+const (
+	reputationOptionLinks     = "links"      // bool: also check the page's external links.
+	reputationOptionLinkLimit = "link_limit" // int: maximum number of external links to check.
+
+	defaultReputationLinkLimit = 50
+	maxReputationLinkLimit     = 500
+)
+
+// ReputationChecker looks URLs up in a threat intelligence feed.
+type ReputationChecker interface {
+	// Source names the feed, e.g. "safebrowsing".
+	Source() string
+	// CheckURLs returns a match for every listed URL; unlisted URLs are omitted.
+	CheckURLs(ctx context.Context, urls []string) ([]ThreatMatch, error)
+}
+
+// reputationModule checks the page, and optionally its external links, against
+// a threat feed. It calls an external service, so it only runs when requested
+// by name, and is only registered when a feed is configured.
+type reputationModule struct {
+	htmlParser parser.HTMLParser
+	checker    ReputationChecker
+}
+
+// NewReputationModule creates the opt-in reputation module backed by checker.
+func NewReputationModule(htmlParser parser.HTMLParser, checker ReputationChecker) AnalyzerModule {
+	return &reputationModule{htmlParser: htmlParser, checker: checker}
+}
+
+func (m *reputationModule) Name() string { return ModuleReputation }
+
+// OptIn implements OptInModule.
+func (m *reputationModule) OptIn() bool { return true }
+
+// reputationOptions are the parsed reputation module options.
+type reputationOptions struct {
+	links     bool
+	linkLimit int
+}
+
+// ValidateOptions implements OptionsValidator.
+func (m *reputationModule) ValidateOptions(opts ModuleOptions) error {
+	_, err := m.parseOptions(opts)
+	return err
+}
+
+// parseOptions reads and validates the reputation module options.
+func (m *reputationModule) parseOptions(opts ModuleOptions) (reputationOptions, error) {
+	for key := range opts {
+		switch key {
+		case reputationOptionLinks, reputationOptionLinkLimit:
+		default:
+			return reputationOptions{}, fmt.Errorf("unknown option %q", key)
+		}
+	}
+
+	links, err := opts.Bool(reputationOptionLinks, false)
+	if err != nil {
+		return reputationOptions{}, err
+	}
+	limit, err := opts.Int(reputationOptionLinkLimit, defaultReputationLinkLimit)
+	if err != nil {
+		return reputationOptions{}, err
+	}
+	if limit <= 0 || limit > maxReputationLinkLimit {
+		return reputationOptions{}, fmt.Errorf("option %q must be between 1 and %d", reputationOptionLinkLimit, maxReputationLinkLimit)
+	}
+	return reputationOptions{links: links, linkLimit: limit}, nil
+}
+
+// Analyze looks up the page URL and, if asked, its external links.
+func (m *reputationModule) Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error) {
+	repOpts, err := m.parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := []string{info.URL}
+	if repOpts.links {
+		urls = append(urls, externalLinks(pageFrom(ctx, doc, m.htmlParser).LinkURLs(info.URL), info.URL, repOpts.linkLimit)...)
+	}
+
+	matches, err := m.checker.CheckURLs(ctx, urls)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReputationResult{Source: m.checker.Source(), Checked: len(urls), Threats: matches}
+	if repOpts.links {
+		result.Links = linkVerdicts(urls[1:], matches)
+	}
+	var findings []Finding
+	for _, match := range matches {
+		if match.URL == info.URL {
+			result.Malicious = true
+			findings = append(findings, Finding{
+				Type:     FindingMaliciousURL,
+				Severity: SeverityHigh,
+				Message:  fmt.Sprintf("Page is listed by %s as %s", result.Source, match.ThreatType),
+				Evidence: match.URL,
+			})
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingMaliciousLink,
+			Severity: SeverityMedium,
+			Message:  fmt.Sprintf("Page links to a URL listed by %s as %s", result.Source, match.ThreatType),
+			Evidence: match.URL,
+		})
+	}
+
+	return ModuleResultFunc(func(a *WebpageAnalysis) {
+		a.Reputation = result
+		a.Findings = append(a.Findings, findings...)
+	}), nil
+}
+
+// linkVerdicts annotates each checked link with the feed's verdict.
+func linkVerdicts(links []string, matches []ThreatMatch) []LinkReputation {
+	threats := make(map[string]string, len(matches))
+	for _, match := range matches {
+		threats[match.URL] = match.ThreatType
+	}
+	verdicts := make([]LinkReputation, 0, len(links))
+	for _, link := range links {
+		verdict := LinkReputation{URL: link, Verdict: VerdictClean}
+		if threat, ok := threats[link]; ok {
+			verdict.Verdict, verdict.ThreatType = VerdictListed, threat
+		}
+		verdicts = append(verdicts, verdict)
+	}
+	return verdicts
+}
+
+// externalLinks returns up to limit of the links that point away from the page's host.
+func externalLinks(links []string, pageURL string, limit int) []string {
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+
+	var external []string
+	for _, link := range links {
+		u, err := url.Parse(link)
+		if err != nil || strings.EqualFold(u.Hostname(), page.Hostname()) {
+			continue
+		}
+		external = append(external, link)
+		if len(external) == limit {
+			break
+		}
+	}
+	return external
+}
@@ -0,0 +1,95 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/worker"
+)
+
+// fakeChecker lists a fixed set of URLs and records what it was asked.
+type fakeChecker struct {
+	listed  map[string]string // URL -> threat type.
+	checked []string
+}
+
+func (c *fakeChecker) Source() string { return "fake" }
+
+func (c *fakeChecker) CheckURLs(ctx context.Context, urls []string) ([]ThreatMatch, error) {
+	c.checked = append(c.checked, urls...)
+	var matches []ThreatMatch
+	for _, u := range urls {
+		if threat, ok := c.listed[u]; ok {
+			matches = append(matches, ThreatMatch{URL: u, ThreatType: threat})
+		}
+	}
+	return matches, nil
+}
+
+func newReputationService(t *testing.T, page string, checker ReputationChecker) Service {
+	t.Helper()
+	htmlParser := parser.NewHTMLParser()
+	mockClient := &mockHTTPClient{response: page}
+	registry := NewDefaultRegistry(htmlParser, mockClient)
+	require.NoError(t, registry.Register(NewReputationModule(htmlParser, checker)))
+	return NewServiceWithRegistry(mockClient, htmlParser, worker.NewWorkerPool(2), registry)
+}
+
+func TestAnalyzeWebpage_ReputationPage(t *testing.T) {
+	checker := &fakeChecker{listed: map[string]string{"https://phish.example": "social_engineering"}}
+	service := newReputationService(t, `<html><body><a href="https://bad.example/x">x</a></body></html>`, checker)
+
+	result, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://phish.example", Modules: []string{ModuleReputation}})
+
+	require.NoError(t, err)
+	require.NotNil(t, result.Reputation)
+	assert.Equal(t, "fake", result.Reputation.Source)
+	assert.True(t, result.Reputation.Malicious)
+	assert.Equal(t, []string{"https://phish.example"}, checker.checked, "Links should only be checked when asked")
+	assert.Nil(t, result.Reputation.Links)
+	require.Len(t, result.Findings, 1)
+	assert.Equal(t, FindingMaliciousURL, result.Findings[0].Type)
+	assert.Equal(t, SeverityHigh, result.Findings[0].Severity)
+	assert.Contains(t, result.Findings[0].Message, "social_engineering")
+}
+
+func TestAnalyzeWebpage_ReputationLinks(t *testing.T) {
+	checker := &fakeChecker{listed: map[string]string{"https://bad.example/x": "malware"}}
+	service := newReputationService(t, `<html><body>
+		<a href="/internal">in</a>
+		<a href="https://bad.example/x">x</a>
+		<a href="https://good.example/">y</a>
+		<a href="https://third.example/">z</a>
+	</body></html>`, checker)
+
+	result, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{
+		URL:     "https://example.com",
+		Modules: []string{ModuleReputation},
+		Options: map[string]ModuleOptions{ModuleReputation: {"links": true, "link_limit": float64(2)}},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://example.com", "https://bad.example/x", "https://good.example/"}, checker.checked,
+		"Only external links, up to link_limit, should be checked")
+	assert.False(t, result.Reputation.Malicious)
+	assert.Equal(t, 3, result.Reputation.Checked)
+	assert.Equal(t, []LinkReputation{
+		{URL: "https://bad.example/x", Verdict: VerdictListed, ThreatType: "malware"},
+		{URL: "https://good.example/", Verdict: VerdictClean},
+	}, result.Reputation.Links)
+	require.Len(t, result.Findings, 1)
+	assert.Equal(t, FindingMaliciousLink, result.Findings[0].Type)
+	assert.Equal(t, "https://bad.example/x", result.Findings[0].Evidence)
+}
+
+func TestReputationModule_InvalidOptions(t *testing.T) {
+	module := NewReputationModule(parser.NewHTMLParser(), &fakeChecker{}).(OptionsValidator)
+
+	assert.Error(t, module.ValidateOptions(ModuleOptions{"link_limit": float64(0)}))
+	assert.Error(t, module.ValidateOptions(ModuleOptions{"probe": true}))
+	assert.NoError(t, module.ValidateOptions(ModuleOptions{"links": true}))
+}
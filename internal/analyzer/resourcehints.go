@@ -0,0 +1,192 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// Resource hint issues.
+const (
+	HintIssueMissingAs        = "missing_as"               // A preload without an as attribute, which browsers ignore.
+	HintIssueInvalidAs        = "invalid_as"               // A preload whose as is not a known destination.
+	HintIssueFontCrossOrigin  = "font_without_crossorigin" // A font preload without crossorigin, which is fetched twice.
+	HintIssueUnusedPreload    = "unused_preload"           // A preload of a resource the page does not reference.
+	HintIssueUnusedConnection = "unused_origin"            // A preconnect or dns-prefetch to an origin the page does not use.
+)
+
+// resourceHintRels are the link relations that are resource hints.
+var resourceHintRels = map[string]bool{
+	"preload": true, "modulepreload": true, "prefetch": true, "preconnect": true, "dns-prefetch": true, "prerender": true,
+}
+
+// preloadDestinations are the valid values of a preload's as attribute.
+var preloadDestinations = map[string]bool{
+	"audio": true, "document": true, "embed": true, "fetch": true, "font": true, "image": true, "object": true,
+	"script": true, "style": true, "track": true, "video": true, "worker": true,
+}
+
+// companionOrigins are origins that resources of another origin load from,
+// so that using the first counts as using the second. Google Fonts
+// stylesheets load the fonts themselves from fonts.gstatic.com.
+var companionOrigins = map[string][]string{
+	"https://fonts.googleapis.com": {"https://fonts.gstatic.com"},
+}
+
+// resourceHintsModule audits the page's resource hints.
+func resourceHintsModule(htmlParser parser.HTMLParser) AnalyzerModule {
+	return NewModule(ModuleResourceHints, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		elements := pageFrom(ctx, doc, htmlParser).Elements("link", "script", "style", "img", "source", "iframe", "video", "audio", "embed", "object", "track")
+		hints := AuditResourceHints(info.URL, elements)
+		findings := resourceHintFindings(hints)
+		return ModuleResultFunc(func(a *WebpageAnalysis) {
+			a.ResourceHints = hints
+			a.Findings = append(a.Findings, findings...)
+		}), nil
+	})
+}
+
+// AuditResourceHints lists the preload, modulepreload, prefetch,
+// preconnect, dns-prefetch and prerender links among elements, resolved
+// against pageURL. Preloads must name a valid destination in as, fonts must
+// be requested with crossorigin, and the resource must be used by an
+// element of the page; fonts and fetches, which are requested from CSS and
+// scripts, are not checked for use. Preconnects and dns-prefetches must
+// point at an origin some resource of the page, or an inline script or
+// style, uses.
+func AuditResourceHints(pageURL string, elements []parser.Element) []ResourceHint {
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	resolve := func(ref string) *url.URL {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			return nil
+		}
+		u, err := url.Parse(ref)
+		if err != nil {
+			return nil
+		}
+		u = page.ResolveReference(u)
+		u.Fragment = ""
+		return u
+	}
+
+	// Collect what the page uses, apart from the hints themselves.
+	used := make(map[string]bool)
+	origins := map[string]bool{urlOrigin(page): true}
+	var inline strings.Builder
+	use := func(u *url.URL) {
+		if u == nil {
+			return
+		}
+		used[u.String()] = true
+		origins[urlOrigin(u)] = true
+		for _, companion := range companionOrigins[urlOrigin(u)] {
+			origins[companion] = true
+		}
+	}
+	var hintElements []parser.Element
+	for _, el := range elements {
+		if el.Tag == "link" && isResourceHint(el) {
+			hintElements = append(hintElements, el)
+			continue
+		}
+		for _, attr := range []string{"src", "href", "data", "poster"} {
+			use(resolve(el.Attr(attr)))
+		}
+		for _, candidate := range strings.Split(el.Attr("srcset"), ",") {
+			if fields := strings.Fields(candidate); len(fields) > 0 {
+				use(resolve(fields[0]))
+			}
+		}
+		if (el.Tag == "script" && el.Attr("src") == "") || el.Tag == "style" {
+			inline.WriteString(el.Text())
+			inline.WriteByte('\n')
+		}
+	}
+	inlineCode := inline.String()
+
+	var hints []ResourceHint
+	for _, el := range hintElements {
+		target := resolve(el.Attr("href"))
+		if target == nil {
+			continue
+		}
+		for _, rel := range strings.Fields(strings.ToLower(el.Attr("rel"))) {
+			if !resourceHintRels[rel] {
+				continue
+			}
+			hint := ResourceHint{Rel: rel, Href: target.String(), As: strings.ToLower(strings.TrimSpace(el.Attr("as")))}
+			switch rel {
+			case "preload":
+				switch {
+				case hint.As == "":
+					hint.Issues = append(hint.Issues, HintIssueMissingAs)
+				case !preloadDestinations[hint.As]:
+					hint.Issues = append(hint.Issues, HintIssueInvalidAs)
+				case hint.As == "font" && !hasAttrKey(el, "crossorigin"):
+					hint.Issues = append(hint.Issues, HintIssueFontCrossOrigin)
+				}
+				if hint.As != "font" && hint.As != "fetch" && !used[hint.Href] {
+					hint.Issues = append(hint.Issues, HintIssueUnusedPreload)
+				}
+			case "modulepreload":
+				if !used[hint.Href] {
+					hint.Issues = append(hint.Issues, HintIssueUnusedPreload)
+				}
+			case "preconnect", "dns-prefetch":
+				if !origins[urlOrigin(target)] && !strings.Contains(inlineCode, target.Host) {
+					hint.Issues = append(hint.Issues, HintIssueUnusedConnection)
+				}
+			}
+			hints = append(hints, hint)
+		}
+	}
+	return hints
+}
+
+// isResourceHint reports whether a <link> has a resource hint relation.
+func isResourceHint(el parser.Element) bool {
+	for _, rel := range strings.Fields(strings.ToLower(el.Attr("rel"))) {
+		if resourceHintRels[rel] {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAttrKey reports whether el has the attribute key, even if empty.
+func hasAttrKey(el parser.Element, key string) bool {
+	_, ok := el.Attrs[key]
+	return ok
+}
+
+// urlOrigin returns u's scheme and host.
+func urlOrigin(u *url.URL) string {
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// resourceHintFindings reports each hint with issues. Broken hints waste
+// bandwidth and connections but do not break the page.
+func resourceHintFindings(hints []ResourceHint) []Finding {
+	var findings []Finding
+	for _, hint := range hints {
+		if len(hint.Issues) == 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingResourceHint,
+			Severity: SeverityInfo,
+			Message:  fmt.Sprintf("%s hint has issues: %s", hint.Rel, strings.Join(hint.Issues, ", ")),
+			Evidence: hint.Href,
+		})
+	}
+	return findings
+}
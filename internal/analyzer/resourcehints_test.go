@@ -0,0 +1,74 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+const resourceHintsPage = `<html><head>
+<link rel="preload" href="/app.js" as="script">
+<link rel="preload" href="/old.css" as="style">
+<link rel="preload" href="/hero.jpg">
+<link rel="preload" href="/font.woff2" as="font" type="font/woff2">
+<link rel="preload" href="/data.json" as="json">
+<link rel="modulepreload" href="/module.js">
+<link rel="preconnect dns-prefetch" href="https://fonts.gstatic.com">
+<link rel="preconnect" href="https://cdn.unused.example">
+<link rel="dns-prefetch" href="//analytics.example.net">
+<link rel="prefetch" href="/next.html">
+<link rel="stylesheet" href="https://fonts.googleapis.com/css2?family=Inter">
+<script src="/app.js"></script>
+<script>fetch("https://analytics.example.net/collect")</script>
+</head><body><img src="/hero.jpg"></body></html>`
+
+func TestAuditResourceHints(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(resourceHintsPage))
+	require.NoError(t, err)
+	elements := parser.NewHTMLParser().ExtractElements(doc, "link", "script", "style", "img")
+
+	hints := AuditResourceHints("https://example.com/page", elements)
+	assert.Equal(t, []ResourceHint{
+		{Rel: "preload", Href: "https://example.com/app.js", As: "script"},
+		{Rel: "preload", Href: "https://example.com/old.css", As: "style", Issues: []string{HintIssueUnusedPreload}},
+		{Rel: "preload", Href: "https://example.com/hero.jpg", Issues: []string{HintIssueMissingAs}},
+		{Rel: "preload", Href: "https://example.com/font.woff2", As: "font", Issues: []string{HintIssueFontCrossOrigin}},
+		{Rel: "preload", Href: "https://example.com/data.json", As: "json", Issues: []string{HintIssueInvalidAs, HintIssueUnusedPreload}},
+		{Rel: "modulepreload", Href: "https://example.com/module.js", Issues: []string{HintIssueUnusedPreload}},
+		{Rel: "preconnect", Href: "https://fonts.gstatic.com"},
+		{Rel: "dns-prefetch", Href: "https://fonts.gstatic.com"},
+		{Rel: "preconnect", Href: "https://cdn.unused.example", Issues: []string{HintIssueUnusedConnection}},
+		{Rel: "dns-prefetch", Href: "https://analytics.example.net"},
+		{Rel: "prefetch", Href: "https://example.com/next.html"},
+	}, hints)
+}
+
+func TestAuditResourceHints_NoHints(t *testing.T) {
+	assert.Empty(t, AuditResourceHints("https://example.com", []parser.Element{
+		{Tag: "link", Attrs: map[string]string{"rel": "stylesheet", "href": "/site.css"}},
+	}))
+}
+
+func TestResourceHintsModule_Findings(t *testing.T) {
+	service := NewService(WithHTTPClient(&mockHTTPClient{response: resourceHintsPage}))
+
+	analysis, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://example.com/page", Modules: []string{ModuleResourceHints}})
+	require.NoError(t, err)
+	assert.Len(t, analysis.ResourceHints, 11)
+	var flagged []string
+	for _, finding := range analysis.Findings {
+		if finding.Type == FindingResourceHint {
+			flagged = append(flagged, finding.Evidence)
+		}
+	}
+	assert.Equal(t, []string{
+		"https://example.com/old.css", "https://example.com/hero.jpg", "https://example.com/font.woff2",
+		"https://example.com/data.json", "https://example.com/module.js", "https://cdn.unused.example",
+	}, flagged)
+}
@@ -0,0 +1,124 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// robotsRules maps each restrictive directive to the directives that allow
+// what it forbids. "none" is shorthand for noindex and nofollow, "all" for
+// index and follow.
+var robotsRules = []struct {
+	restrictive []string
+	permissive  []string
+	effective   func(*RobotsDirectives) *bool // The flag the rule sets.
+}{
+	{[]string{"noindex", "none"}, []string{"index", "all"}, func(r *RobotsDirectives) *bool { return &r.NoIndex }},
+	{[]string{"nofollow", "none"}, []string{"follow", "all"}, func(r *RobotsDirectives) *bool { return &r.NoFollow }},
+	{[]string{"noarchive"}, []string{"archive"}, func(r *RobotsDirectives) *bool { return &r.NoArchive }},
+}
+
+// robotsValueDirectives are the directives that take a value after a colon;
+// any other name before a colon in X-Robots-Tag is a crawler's user agent.
+var robotsValueDirectives = map[string]bool{
+	"unavailable_after": true,
+	"max-snippet":       true,
+	"max-image-preview": true,
+	"max-video-preview": true,
+}
+
+// robotsModule reports the page's robots directives.
+func robotsModule(htmlParser parser.HTMLParser) AnalyzerModule {
+	return NewModule(ModuleRobots, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		robots := ExtractRobotsDirectives(pageFrom(ctx, doc, htmlParser).Elements("meta"), info.Header)
+		findings := robotsFindings(robots)
+		return ModuleResultFunc(func(a *WebpageAnalysis) {
+			a.Robots = robots
+			a.Findings = append(a.Findings, findings...)
+		}), nil
+	})
+}
+
+// ExtractRobotsDirectives collects the directives of <meta name="robots">
+// tags and of X-Robots-Tag headers that address every crawler. Directives
+// for a single crawler, such as <meta name="googlebot"> or "googlebot:
+// noindex", are left out. Directives are lower-cased.
+func ExtractRobotsDirectives(elements []parser.Element, header http.Header) *RobotsDirectives {
+	robots := &RobotsDirectives{}
+	for _, el := range elements {
+		if el.Tag == "meta" && strings.EqualFold(strings.TrimSpace(el.Attr("name")), "robots") {
+			robots.Meta = append(robots.Meta, splitRobotsDirectives(el.Attr("content"))...)
+		}
+	}
+	for _, value := range header.Values("X-Robots-Tag") {
+		directives := splitRobotsDirectives(value)
+		if len(directives) == 0 {
+			continue
+		}
+		if agent, rest, ok := strings.Cut(directives[0], ":"); ok && !robotsValueDirectives[strings.TrimSpace(agent)] {
+			if strings.TrimSpace(agent) != "robots" {
+				continue
+			}
+			directives[0] = strings.TrimSpace(rest)
+			if directives[0] == "" {
+				directives = directives[1:]
+			}
+		}
+		robots.Header = append(robots.Header, directives...)
+	}
+
+	all := append(append([]string(nil), robots.Meta...), robots.Header...)
+	for _, rule := range robotsRules {
+		restricted := containsAny(all, rule.restrictive)
+		*rule.effective(robots) = restricted
+		if restricted && containsAny(all, rule.permissive) {
+			robots.Conflicts = append(robots.Conflicts, rule.restrictive[0])
+		}
+	}
+	return robots
+}
+
+// splitRobotsDirectives splits a comma-separated directive list.
+func splitRobotsDirectives(value string) []string {
+	var directives []string
+	for _, d := range strings.Split(value, ",") {
+		if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+			directives = append(directives, d)
+		}
+	}
+	return directives
+}
+
+// containsAny reports whether list contains any of values.
+func containsAny(list, values []string) bool {
+	for _, v := range values {
+		for _, item := range list {
+			if item == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// robotsFindings reports directives that contradict each other. Search
+// engines obey the most restrictive one, which is rarely what the site
+// owner meant when the meta tag and the header disagree.
+func robotsFindings(robots *RobotsDirectives) []Finding {
+	var findings []Finding
+	for _, directive := range robots.Conflicts {
+		findings = append(findings, Finding{
+			Type:     FindingRobotsConflict,
+			Severity: SeverityLow,
+			Message:  fmt.Sprintf("Robots directives both allow and forbid %s; search engines apply %s", strings.TrimPrefix(directive, "no"), directive),
+			Evidence: fmt.Sprintf("meta: %s; X-Robots-Tag: %s", strings.Join(robots.Meta, ", "), strings.Join(robots.Header, ", ")),
+		})
+	}
+	return findings
+}
@@ -0,0 +1,69 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/parser"
+)
+
+func TestExtractRobotsDirectives(t *testing.T) {
+	tests := []struct {
+		name   string
+		page   string
+		header []string
+		want   *RobotsDirectives
+	}{
+		{"none", `<meta name="description" content="noindex">`, nil, &RobotsDirectives{}},
+		{"meta only", `<meta name="Robots" content="NoIndex, follow">`, nil,
+			&RobotsDirectives{Meta: []string{"noindex", "follow"}, NoIndex: true}},
+		{"header only", ``, []string{"noarchive", "max-snippet: 50"},
+			&RobotsDirectives{Header: []string{"noarchive", "max-snippet: 50"}, NoArchive: true}},
+		{"none shorthand", `<meta name="robots" content="none">`, nil,
+			&RobotsDirectives{Meta: []string{"none"}, NoIndex: true, NoFollow: true}},
+		{"crawler specific", `<meta name="googlebot" content="noindex">`, []string{"bingbot: nofollow", "robots: noarchive"},
+			&RobotsDirectives{Header: []string{"noarchive"}, NoArchive: true}},
+		{"conflict", `<meta name="robots" content="index, follow">`, []string{"noindex"},
+			&RobotsDirectives{Meta: []string{"index", "follow"}, Header: []string{"noindex"}, NoIndex: true, Conflicts: []string{"noindex"}}},
+		{"all against none", `<meta name="robots" content="all">`, []string{"none"},
+			&RobotsDirectives{Meta: []string{"all"}, Header: []string{"none"}, NoIndex: true, NoFollow: true, Conflicts: []string{"noindex", "nofollow"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.page))
+			require.NoError(t, err)
+			header := http.Header{}
+			for _, v := range tt.header {
+				header.Add("X-Robots-Tag", v)
+			}
+			got := ExtractRobotsDirectives(parser.NewHTMLParser().ExtractElements(doc, "meta"), header)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRobotsModule_ReadsHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Robots-Tag", "noindex")
+		w.Write([]byte(`<html><head><meta name="robots" content="index"></head></html>`))
+	}))
+	defer server.Close()
+
+	service := NewService(WithHTTPClient(client.NewHTTPClient()))
+	analysis, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: server.URL, Modules: []string{ModuleRobots}})
+	require.NoError(t, err)
+	require.NotNil(t, analysis.Robots)
+	assert.Equal(t, []string{"noindex"}, analysis.Robots.Header)
+	assert.True(t, analysis.Robots.NoIndex)
+	require.Len(t, analysis.Findings, 1)
+	assert.Equal(t, FindingRobotsConflict, analysis.Findings[0].Type)
+	assert.Equal(t, "meta: index; X-Robots-Tag: noindex", analysis.Findings[0].Evidence)
+}
@@ -2,12 +2,16 @@ package analyzer
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log/slog"
+	"math"
 	"net/http"
 	"time"
 
+	"golang.org/x/net/html"
+
 	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/logging"
 	"webpage-analyzer/internal/parser"
 	"webpage-analyzer/internal/worker"
 )
@@ -17,174 +21,337 @@ type service struct {
 	httpClient client.HTTPClient
 	htmlParser parser.HTMLParser
 	workerPool *worker.WorkerPool
+	modules    *Registry
 }
 
-// NewService creates a new instance of the webpage analyzer service.
-func NewService() Service {
-	return &service{
-		httpClient: client.NewHTTPClient(),
-		htmlParser: parser.NewHTMLParser(),
-		workerPool: worker.NewWorkerPool(5), // 5 workers for analysis tasks.
+// NewService creates a new instance of the webpage analyzer service. Without
+// options it fetches pages with client.NewHTTPClient, runs every module of
+// the default registry and uses its own dynamic worker pool.
+func NewService(opts ...Option) Service {
+	var o serviceOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.httpClient == nil {
+		o.httpClient = client.NewHTTPClient()
+	}
+	if o.htmlParser == nil {
+		o.htmlParser = parser.NewHTMLParser()
 	}
+	if o.workerPool == nil {
+		o.workerPool = worker.NewDynamicWorkerPool(worker.DefaultPoolConfig())
+	}
+	if o.registry == nil {
+		o.registry = NewDefaultRegistry(o.htmlParser, o.httpClient)
+	}
+
+	var svc Service = &service{
+		httpClient: o.httpClient,
+		htmlParser: o.htmlParser,
+		workerPool: o.workerPool,
+		modules:    o.registry,
+	}
+	for _, decorate := range o.decorators {
+		svc = decorate(svc)
+	}
+	return svc
 }
 
 // NewServiceWithDependencies creates a service with custom dependencies (useful for testing).
 func NewServiceWithDependencies(httpClient client.HTTPClient, htmlParser parser.HTMLParser, workerPool *worker.WorkerPool) Service {
-	return &service{
-		httpClient: httpClient,
-		htmlParser: htmlParser,
-		workerPool: workerPool,
-	}
+	return NewService(WithHTTPClient(httpClient), WithHTMLParser(htmlParser), WithWorkerPool(workerPool))
+}
+
+// NewServiceWithRegistry creates a service that runs the modules in registry.
+func NewServiceWithRegistry(httpClient client.HTTPClient, htmlParser parser.HTMLParser, workerPool *worker.WorkerPool, registry *Registry) Service {
+	return NewService(WithHTTPClient(httpClient), WithHTMLParser(htmlParser), WithWorkerPool(workerPool), WithRegistry(registry))
 }
 
 // AnalyzeWebpage analyzes a given webpage using the worker pool.
 func (s *service) AnalyzeWebpage(ctx context.Context, req AnalysisRequest) (*WebpageAnalysis, error) {
 	startTime := time.Now()
-	slog.Info("Starting webpage analysis", "url", req.URL)
+	// fetchDocument and analyzeDocument add the URL to the context's logger.
+	logging.FromContext(ctx).Info("Starting webpage analysis", "url", req.URL, "modules", req.Modules)
+
+	modules, err := s.modules.Select(req.Modules)
+	if err != nil {
+		return nil, &AnalysisError{
+			StatusCode:   http.StatusBadRequest,
+			Code:         ErrorCodeInvalidRequest,
+			ErrorMessage: fmt.Sprintf("Invalid module selection: %v", err),
+			URL:          req.URL,
+		}
+	}
+	if err := validateOptions(modules, req.Options); err != nil {
+		return nil, &AnalysisError{
+			StatusCode:   http.StatusBadRequest,
+			Code:         ErrorCodeInvalidRequest,
+			ErrorMessage: fmt.Sprintf("Invalid module options: %v", err),
+			URL:          req.URL,
+		}
+	}
+	if req.Auth != nil {
+		if err := req.Auth.validate(); err != nil {
+			return nil, &AnalysisError{
+				StatusCode:   http.StatusBadRequest,
+				Code:         ErrorCodeInvalidRequest,
+				ErrorMessage: fmt.Sprintf("Invalid auth: %v", err),
+				URL:          req.URL,
+			}
+		}
+		// Modules fetching more of the same site, such as link probes, use the
+		// credentials too; other sites never see them.
+		ctx = client.WithCredentials(ctx, req.URL, req.Auth.credentials())
+	}
+	if req.AcceptLanguage != "" {
+		if err := validateAcceptLanguage(req.AcceptLanguage); err != nil {
+			return nil, &AnalysisError{
+				StatusCode:   http.StatusBadRequest,
+				Code:         ErrorCodeInvalidRequest,
+				ErrorMessage: fmt.Sprintf("Invalid accept_language: %v", err),
+				URL:          req.URL,
+			}
+		}
+		ctx = client.WithAcceptLanguage(ctx, req.AcceptLanguage)
+	}
+	budget, err := newBudget(req, startTime)
+	if err != nil {
+		return nil, &AnalysisError{
+			StatusCode:   http.StatusBadRequest,
+			Code:         ErrorCodeInvalidRequest,
+			ErrorMessage: fmt.Sprintf("Invalid timeout: %v", err),
+			URL:          req.URL,
+		}
+	}
+
+	fetchCtx, cancel := budget.fetchContext(ctx)
+	doc, info, err := s.fetchDocument(fetchCtx, req.URL)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.analyzeDocument(ctx, doc, info, modules, req.Options, startTime, budget.deadline)
+}
+
+type pageMeterKey struct{}
+
+// WithPageMeter returns a context in which charge is called before every page
+// is fetched, such as each page of a sitemap or crawl, so that background jobs
+// can charge their pages to whoever queued them. A page that charge refuses
+// is not fetched, and fails with charge's error.
+func WithPageMeter(ctx context.Context, charge func(ctx context.Context, pageURL string) error) context.Context {
+	return context.WithValue(ctx, pageMeterKey{}, charge)
+}
+
+// chargePage calls the page meter of ctx, if any, for pageURL.
+func chargePage(ctx context.Context, pageURL string) error {
+	charge, ok := ctx.Value(pageMeterKey{}).(func(context.Context, string) error)
+	if !ok {
+		return nil
+	}
+	err := charge(ctx, pageURL)
+	if err == nil {
+		return nil
+	}
+	var analysisErr *AnalysisError
+	if errors.As(err, &analysisErr) {
+		return analysisErr
+	}
+	return &AnalysisError{StatusCode: http.StatusInternalServerError, Code: ErrorCodeInternal, ErrorMessage: err.Error(), URL: pageURL}
+}
+
+// fetchDocument fetches and parses a webpage, mapping failures to AnalysisError.
+// Everything logged while fetching it carries its URL.
+func (s *service) fetchDocument(ctx context.Context, pageURL string) (*html.Node, FetchInfo, error) {
+	if err := chargePage(ctx, pageURL); err != nil {
+		return nil, FetchInfo{}, err
+	}
+	ctx, logger := logging.With(ctx, "url", pageURL)
 
 	// Fetch the webpage.
-	slog.Info("Fetching webpage content", "url", req.URL)
-	body, statusCode, err := s.httpClient.FetchWebpage(ctx, req.URL)
+	logger.Info("Fetching webpage content")
+	var response client.ResponseInfo
+	ctx = client.WithResponseInfo(ctx, &response)
+	var doc *html.Node
+	var body []byte
+	var statusCode int
+	var err error
+	fetcher, streaming := s.httpClient.(client.DocumentFetcher)
+	if streaming {
+		// The page is parsed as it is read, so the raw page is never held.
+		doc, statusCode, err = fetcher.FetchDocument(ctx, pageURL)
+	} else {
+		body, statusCode, err = s.httpClient.FetchWebpage(ctx, pageURL)
+		response.BodySize = int64(len(body))
+	}
 	if err != nil {
-		slog.Error("Error fetching webpage", "url", req.URL, "error", err, "status_code", statusCode)
+		logger.Error("Error fetching webpage", "error", err, "status_code", statusCode)
 		// Create a more meaningful error response.
-		return nil, &AnalysisError{
+		return nil, FetchInfo{}, &AnalysisError{
 			StatusCode:   statusCode,
+			Code:         fetchErrorCode(err),
 			ErrorMessage: err.Error(),
-			URL:          req.URL,
+			URL:          pageURL,
+			Redirects:    fetchRedirects(err),
 		}
 	}
-	slog.Info("Successfully fetched webpage", "url", req.URL, "status_code", statusCode, "body_size_bytes", len(body))
+	logger.Info("Successfully fetched webpage", "status_code", statusCode, "body_size_bytes", response.BodySize)
 
 	// Check if the response is successful.
 	if statusCode != http.StatusOK {
-		slog.Error("HTTP error", "url", req.URL, "status_code", statusCode)
+		logger.Error("HTTP error", "status_code", statusCode)
 		// Provide specific error messages for different HTTP status codes.
 		errorMessage := s.getHTTPStatusMessage(statusCode)
-		return nil, &AnalysisError{
+		retryAfter := int(math.Ceil(response.RetryAfter.Seconds()))
+		if retryAfter > 0 {
+			errorMessage += fmt.Sprintf(" The site asked to retry after %d seconds.", retryAfter)
+		}
+		return nil, FetchInfo{}, &AnalysisError{
 			StatusCode:   statusCode,
+			Code:         ErrorCodeUpstreamStatus,
 			ErrorMessage: errorMessage,
-			URL:          req.URL,
+			URL:          pageURL,
+			RetryAfter:   retryAfter,
 		}
 	}
 
 	// Parse the HTML.
-	slog.Info("Parsing HTML content", "url", req.URL)
-	doc, err := s.httpClient.ParseHTML(body)
-	if err != nil {
-		slog.Error("Error parsing HTML", "url", req.URL, "error", err)
-		return nil, &AnalysisError{
-			StatusCode:   statusCode,
-			ErrorMessage: fmt.Sprintf("Failed to parse HTML content: %v", err),
-			URL:          req.URL,
+	if !streaming {
+		logger.Info("Parsing HTML content")
+		doc, err = s.httpClient.ParseHTML(body)
+		if err != nil {
+			logger.Error("Error parsing HTML", "error", err)
+			return nil, FetchInfo{}, &AnalysisError{
+				StatusCode:   statusCode,
+				Code:         ErrorCodeParseFailure,
+				ErrorMessage: fmt.Sprintf("Failed to parse HTML content: %v", err),
+				URL:          pageURL,
+			}
 		}
+		logger.Info("Successfully parsed HTML")
+	}
+
+	info := FetchInfo{URL: pageURL, FinalURL: response.URL, StatusCode: statusCode, BodySize: int(response.BodySize), Header: response.Header}
+	if response.Protocol != "" {
+		info.Protocol = &ProtocolInfo{Version: response.Protocol, HTTP3Advertised: response.HTTP3Advertised}
+	}
+	return doc, info, nil
+}
+
+// fetchErrorCode returns the code of a FetchWebpage error, if it has one.
+func fetchErrorCode(err error) string {
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) {
+		return coded.ErrorCode()
 	}
-	slog.Info("Successfully parsed HTML", "url", req.URL)
+	return ErrorCodeFetchFailure
+}
+
+// fetchRedirects returns the redirect chain of a fetch stopped by a redirect
+// loop or too long a chain.
+func fetchRedirects(err error) []string {
+	var fetchErr *client.FetchError
+	if errors.As(err, &fetchErr) {
+		return fetchErr.Redirects
+	}
+	return nil
+}
+
+// analyzeDocument runs the selected modules in parallel on a parsed document.
+// It returns the context error if ctx ends before every module has run. A
+// module that fails is left out of the result and reported as a warning, and
+// so are the modules still running when deadline, if set, passes first.
+// Everything logged while analyzing it, modules included, carries its URL.
+func (s *service) analyzeDocument(ctx context.Context, doc *html.Node, info FetchInfo, modules []AnalyzerModule, options map[string]ModuleOptions, startTime, deadline time.Time) (*WebpageAnalysis, error) {
+	pageURL := info.URL
+	ctx, logger := logging.With(ctx, "url", pageURL)
 
 	// Initialize analysis result.
 	analysis := &WebpageAnalysis{
-		URL:        req.URL,
+		URL:        pageURL,
 		Headings:   make(map[string]int),
 		AnalyzedAt: time.Now(),
+		Modules:    make([]string, 0, len(modules)),
+		Protocol:   info.Protocol,
+	}
+	if info.FinalURL != "" && info.FinalURL != pageURL {
+		analysis.FinalURL = info.FinalURL
 	}
 
-	// Use worker pool for parallel analysis.
-	slog.Info("Starting parallel analysis tasks", "url", req.URL)
+	// Use worker pool for parallel analysis, one task per module.
+	logger.Info("Starting parallel analysis tasks")
 	taskGroup := worker.NewAnalysisTaskGroup(s.workerPool)
 
-	// Add analysis tasks to the group.
-	taskGroup.AddTask("html_version", func() (interface{}, error) {
-		slog.Info("Extracting HTML version", "url", req.URL)
-		version := s.htmlParser.ExtractHTMLVersion(doc)
-		slog.Info("HTML version extracted", "url", req.URL, "version", version)
-		return version, nil
-	})
-
-	taskGroup.AddTask("page_title", func() (interface{}, error) {
-		slog.Info("Extracting page title", "url", req.URL)
-		title := s.htmlParser.ExtractPageTitle(doc)
-		slog.Info("Page title extracted", "url", req.URL, "title", title)
-		return title, nil
-	})
-
-	taskGroup.AddTask("headings", func() (interface{}, error) {
-		slog.Info("Extracting headings", "url", req.URL)
-		headings := s.htmlParser.ExtractHeadings(doc)
-		slog.Info("Headings extracted", "url", req.URL, "heading_types_count", len(headings))
-		return headings, nil
-	})
-
-	taskGroup.AddTask("links", func() (interface{}, error) {
-		slog.Info("Extracting links", "url", req.URL)
-		internal, external, inaccessible := s.htmlParser.ExtractLinks(doc, req.URL)
-		slog.Info("Links extracted", "url", req.URL, "internal_count", internal, "external_count", external, "inaccessible_count", inaccessible)
-		return map[string]int{
-			"internal":     internal,
-			"external":     external,
-			"inaccessible": inaccessible,
-		}, nil
-	})
-
-	taskGroup.AddTask("login_form", func() (interface{}, error) {
-		slog.Info("Checking for login form", "url", req.URL)
-		hasLogin := s.htmlParser.ExtractLoginForm(doc)
-		slog.Info("Login form check completed", "url", req.URL, "has_login_form", hasLogin)
-		return hasLogin, nil
-	})
-
-	// Execute all tasks in parallel.
-	slog.Info("Executing analysis tasks in parallel", "url", req.URL, "task_count", 5)
-	taskGroup.ExecuteAll()
-	slog.Info("All analysis tasks completed", "url", req.URL)
-
-	// Collect results.
-	slog.Info("Collecting analysis results", "url", req.URL)
-
-	if htmlVersion, err := taskGroup.GetResult("html_version"); err == nil {
-		analysis.HTMLVersion = htmlVersion.(string)
-		slog.Info("HTML version result collected", "url", req.URL, "version", analysis.HTMLVersion)
-	} else {
-		slog.Error("Error getting HTML version result", "url", req.URL, "error", err)
-	}
-
-	if pageTitle, err := taskGroup.GetResult("page_title"); err == nil {
-		analysis.PageTitle = pageTitle.(string)
-		slog.Info("Page title result collected", "url", req.URL, "title", analysis.PageTitle)
-	} else {
-		slog.Error("Error getting page title result", "url", req.URL, "error", err)
+	results := make([]*worker.Result[ModuleResult], len(modules))
+	for i, module := range modules {
+		results[i] = worker.AddTask(taskGroup, module.Name(), func(ctx context.Context) (ModuleResult, error) {
+			return module.Analyze(ctx, doc, info, options[module.Name()])
+		})
 	}
 
-	if headings, err := taskGroup.GetResult("headings"); err == nil {
-		analysis.Headings = headings.(map[string]int)
-		slog.Info("Headings result collected", "url", req.URL, "headings", analysis.Headings)
-	} else {
-		slog.Error("Error getting headings result", "url", req.URL, "error", err)
+	// Execute all tasks in parallel. The modules share one budget of
+	// sub-requests, counted from the start of the page's analysis, and the
+	// text extracted from the page.
+	logger.Info("Executing analysis tasks in parallel", "task_count", len(modules))
+	taskCtx := withPage(withSubrequests(ctx, s.workerPool, startTime), doc, s.htmlParser)
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		taskCtx, cancel = context.WithDeadline(taskCtx, deadline)
+		defer cancel()
 	}
-
-	if links, err := taskGroup.GetResult("links"); err == nil {
-		linkMap := links.(map[string]int)
-		analysis.InternalLinks = linkMap["internal"]
-		analysis.ExternalLinks = linkMap["external"]
-		analysis.InaccessibleLinks = linkMap["inaccessible"]
-		slog.Info("Links result collected", "url", req.URL, "internal_count", analysis.InternalLinks, "external_count", analysis.ExternalLinks, "inaccessible_count", analysis.InaccessibleLinks)
+	budgetExpired := false
+	if err := taskGroup.ExecuteAll(taskCtx); err != nil {
+		if ctx.Err() != nil || !errors.Is(err, context.DeadlineExceeded) {
+			logger.Warn("Analysis cancelled before all tasks ran", "error", err)
+			return nil, err
+		}
+		budgetExpired = true
+		logger.Warn("Analysis budget expired before all tasks ran")
 	} else {
-		slog.Error("Error getting links result", "url", req.URL, "error", err)
+		logger.Info("All analysis tasks completed")
 	}
 
-	if hasLogin, err := taskGroup.GetResult("login_form"); err == nil {
-		analysis.HasLoginForm = hasLogin.(bool)
-		slog.Info("Login form result collected", "url", req.URL, "has_login_form", analysis.HasLoginForm)
-	} else {
-		slog.Error("Error getting login form result", "url", req.URL, "error", err)
+	// Collect results in registration order.
+	for i, module := range modules {
+		result, err := results[i].Get()
+		if err != nil {
+			if !budgetExpired || !errors.Is(err, context.DeadlineExceeded) {
+				logger.Error("Analysis module failed", "module", module.Name(), "error", err)
+			}
+			analysis.Warnings = append(analysis.Warnings, moduleWarning(module.Name(), err, budgetExpired))
+			continue
+		}
+		if result != nil {
+			result.Apply(analysis)
+		}
+		analysis.Modules = append(analysis.Modules, module.Name())
 	}
 
 	// Calculate processing time.
 	analysis.ProcessingTime = time.Since(startTime).String()
-	slog.Info("Analysis completed", "url", req.URL, "processing_time", analysis.ProcessingTime)
+	logger.Info("Analysis completed", "processing_time", analysis.ProcessingTime)
 
 	return analysis, nil
 }
 
+// moduleWarning reports a module left out of an analysis because it returned
+// err. budgetExpired tells whether the analysis ran out of total_timeout.
+func moduleWarning(module string, err error, budgetExpired bool) Warning {
+	switch {
+	case budgetExpired && errors.Is(err, context.DeadlineExceeded):
+		return Warning{Module: module, Code: WarningModuleTimeout, Message: "Module did not finish within total_timeout"}
+	case errors.Is(err, context.DeadlineExceeded):
+		return Warning{Module: module, Code: WarningModuleTimeout, Message: err.Error()}
+	}
+	var panicErr *worker.PanicError
+	if errors.As(err, &panicErr) {
+		// The panic value and stack are logged, not returned to clients.
+		return Warning{Module: module, Code: WarningModuleFailed, Message: "Module crashed"}
+	}
+	return Warning{Module: module, Code: WarningModuleFailed, Message: err.Error()}
+}
+
 // getHTTPStatusMessage returns a user-friendly message for HTTP status codes.
 func (s *service) getHTTPStatusMessage(statusCode int) string {
 	switch statusCode {
@@ -217,8 +384,12 @@ func (s *service) getHTTPStatusMessage(statusCode int) string {
 
 // GetAnalysisStatus returns the current status of the analysis service.
 func (s *service) GetAnalysisStatus(ctx context.Context) (string, error) {
-	slog.Info("Service status requested")
-	status := "Service is running and ready for parallel webpage analysis with worker pool"
-	slog.Info("Service status", "status", status)
+	logging.FromContext(ctx).Info("Service status requested")
+	stats := s.workerPool.Stats()
+	status := fmt.Sprintf("Service is running and ready for parallel webpage analysis with worker pool "+
+		"(%d workers, scaling %d-%d, %d busy, %d queued; tasks: %d submitted, %d completed, %d failed)",
+		stats.Workers, stats.MinWorkers, stats.MaxWorkers, stats.BusyWorkers, stats.QueueDepth,
+		stats.SubmittedTasks, stats.CompletedTasks, stats.FailedTasks)
+	logging.FromContext(ctx).Info("Service status", "status", status)
 	return status, nil
 }
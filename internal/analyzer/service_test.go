@@ -1,7 +1,14 @@
 package analyzer
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -9,6 +16,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/html"
 
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/logging"
 	"webpage-analyzer/internal/parser"
 	"webpage-analyzer/internal/worker"
 )
@@ -34,7 +43,7 @@ func (m *mockHTTPClient) FetchWebpage(ctx context.Context, url string) ([]byte,
 	return []byte(m.response), 200, nil
 }
 
-func (m *mockHTTPClient) ParseHTML(content []byte) (interface{}, error) {
+func (m *mockHTTPClient) ParseHTML(content []byte) (*html.Node, error) {
 	doc, err := html.Parse(strings.NewReader(string(content)))
 	if err != nil {
 		return nil, err
@@ -88,6 +97,29 @@ func TestAnalyzeWebpage_Success(t *testing.T) {
 	assert.False(t, result.HasLoginForm, "Login form should not be detected")
 }
 
+// streamingMockHTTPClient parses its page in FetchDocument and fails
+// FetchWebpage, to check that clients that stream are streamed from.
+type streamingMockHTTPClient struct {
+	mockHTTPClient
+}
+
+func (m *streamingMockHTTPClient) FetchWebpage(ctx context.Context, url string) ([]byte, int, error) {
+	return nil, 500, assert.AnError
+}
+
+func (m *streamingMockHTTPClient) FetchDocument(ctx context.Context, url string) (*html.Node, int, error) {
+	doc, err := html.Parse(strings.NewReader(m.response))
+	return doc, 200, err
+}
+
+func TestAnalyzeWebpage_StreamsDocument(t *testing.T) {
+	service := NewService(WithHTTPClient(&streamingMockHTTPClient{mockHTTPClient{response: "<html><head><title>Streamed</title></head></html>"}}))
+
+	analysis, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://example.com", Modules: []string{ModulePageTitle}})
+	require.NoError(t, err)
+	assert.Equal(t, "Streamed", analysis.PageTitle)
+}
+
 func TestAnalyzeWebpage_HTTPError(t *testing.T) {
 	// Create mock client that returns error
 	mockClient := &mockHTTPClient{
@@ -112,6 +144,91 @@ func TestAnalyzeWebpage_HTTPError(t *testing.T) {
 	assert.Equal(t, 500, analysisErr.StatusCode, "Status code should match")
 }
 
+func TestAnalyzeWebpage_ReportsProtocol(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Alt-Svc", `h3=":443"; ma=86400`)
+		_, _ = w.Write([]byte(`<html><head><title>Test</title></head></html>`))
+	}))
+	defer server.Close()
+	service := NewServiceWithDependencies(client.NewHTTPClient(), parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: server.URL})
+	require.NoError(t, err)
+	assert.Equal(t, &ProtocolInfo{Version: "HTTP/1.1", HTTP3Advertised: true}, result.Protocol)
+
+	html, err := service.AnalyzeHTML(context.Background(), HTMLRequest{HTML: "<title>Upload</title>"})
+	require.NoError(t, err)
+	assert.Nil(t, html.Protocol, "Supplied HTML has no protocol")
+}
+
+func TestAnalyzeWebpage_ErrorCodes(t *testing.T) {
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	tests := []struct {
+		name   string
+		client client.HTTPClient
+		url    string
+		code   string
+	}{
+		{"coded fetch error", &mockHTTPClient{error: &client.FetchError{Code: client.CodeDNSFailure, Message: "DNS resolution failed"}}, "https://example.com", client.CodeDNSFailure},
+		{"host skipped", &mockHTTPClient{error: &client.HostSkippedError{Host: "example.com"}}, "https://example.com", client.CodeHostSkipped},
+		{"unclassified fetch error", &mockHTTPClient{error: assert.AnError}, "https://example.com", ErrorCodeFetchFailure},
+		{"upstream status", client.NewHTTPClient(), notFound.URL, ErrorCodeUpstreamStatus},
+		{"invalid request", &mockHTTPClient{}, "https://example.com", ErrorCodeInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewServiceWithDependencies(tt.client, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+			req := AnalysisRequest{URL: tt.url}
+			if tt.code == ErrorCodeInvalidRequest {
+				req.Modules = []string{"nope"}
+			}
+
+			_, err := service.AnalyzeWebpage(context.Background(), req)
+			var analysisErr *AnalysisError
+			require.ErrorAs(t, err, &analysisErr)
+			assert.Equal(t, tt.code, analysisErr.Code)
+		})
+	}
+}
+
+func TestAnalyzeWebpage_ReportsRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	service := NewServiceWithDependencies(client.NewHTTPClient(), parser.NewHTMLParser(), worker.NewWorkerPool(2))
+	_, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: server.URL})
+
+	var analysisErr *AnalysisError
+	require.ErrorAs(t, err, &analysisErr)
+	assert.Equal(t, http.StatusTooManyRequests, analysisErr.StatusCode)
+	assert.Equal(t, 120, analysisErr.RetryAfter, "The advised wait should be reported in seconds")
+	assert.Contains(t, analysisErr.ErrorMessage, "retry after 120 seconds")
+}
+
+func TestAnalyzeWebpage_ReportsRedirectLoop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/a" {
+			http.Redirect(w, r, "/b", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, "/a", http.StatusFound)
+	}))
+	defer server.Close()
+
+	service := NewServiceWithDependencies(client.NewHTTPClient(), parser.NewHTMLParser(), worker.NewWorkerPool(2))
+	_, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: server.URL + "/a"})
+
+	var analysisErr *AnalysisError
+	require.ErrorAs(t, err, &analysisErr)
+	assert.Equal(t, client.CodeRedirectLoop, analysisErr.Code)
+	assert.Equal(t, []string{server.URL + "/a", server.URL + "/b", server.URL + "/a"}, analysisErr.Redirects)
+}
+
 func TestAnalyzeWebpage_InvalidURL(t *testing.T) {
 	// Create mock client that returns error for invalid URL
 	mockClient := &mockHTTPClient{
@@ -159,6 +276,7 @@ func TestGetAnalysisStatus(t *testing.T) {
 	require.NoError(t, err, "GetAnalysisStatus() should not return error")
 	assert.NotEmpty(t, status, "GetAnalysisStatus() should return non-empty status")
 	assert.Contains(t, status, "Service is running", "Status should contain expected message")
+	assert.Contains(t, status, "2 workers, scaling 2-10", "Status should report the current worker pool size")
 }
 
 func TestAnalyzeWebpage_ComplexHTML(t *testing.T) {
@@ -258,3 +376,145 @@ func TestAnalyzeWebpage_NoLoginForm(t *testing.T) {
 	require.NotNil(t, result, "AnalyzeWebpage() should not return nil result")
 	assert.False(t, result.HasLoginForm, "Login form should not be detected")
 }
+
+// cancellingHTTPClient cancels the request context once the page is fetched,
+// simulating a client that disconnects mid-analysis.
+type cancellingHTTPClient struct {
+	mockHTTPClient
+	cancel context.CancelFunc
+}
+
+func (c *cancellingHTTPClient) FetchWebpage(ctx context.Context, url string) ([]byte, int, error) {
+	body, status, err := c.mockHTTPClient.FetchWebpage(ctx, url)
+	c.cancel()
+	return body, status, err
+}
+
+func TestAnalyzeWebpage_ContextCancelledDuringAnalysis(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockClient := &cancellingHTTPClient{
+		mockHTTPClient: mockHTTPClient{response: "<html><head><title>Test</title></head></html>"},
+		cancel:         cancel,
+	}
+	service := NewServiceWithDependencies(mockClient, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.AnalyzeWebpage(ctx, AnalysisRequest{URL: "https://example.com"})
+
+	assert.ErrorIs(t, err, context.Canceled, "AnalyzeWebpage() should stop when the request context is cancelled")
+	assert.Nil(t, result)
+}
+
+func TestAnalyzeWebpage_LogsCarryContextFields(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := logging.NewContext(context.Background(), slog.New(slog.NewJSONHandler(&buf, nil)).With("request_id", "abc123"))
+	registry := NewRegistry(NewModule("logs", func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		logging.FromContext(ctx).Info("Module ran")
+		return nil, nil
+	}))
+	service := NewServiceWithRegistry(&mockHTTPClient{response: "<html></html>"}, parser.NewHTMLParser(), worker.NewWorkerPool(2), registry)
+
+	_, err := service.AnalyzeWebpage(ctx, AnalysisRequest{URL: "https://example.com"})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.NotEmpty(t, lines)
+	moduleLogged := false
+	for _, raw := range lines {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(raw), &line))
+		assert.Equal(t, "abc123", line["request_id"], "Every line should carry the request's fields: %s", raw)
+		assert.Equal(t, "https://example.com", line["url"], "Every line should carry the page URL: %s", raw)
+		assert.Equal(t, 1, strings.Count(raw, `"url":`), "No line should repeat the URL: %s", raw)
+		moduleLogged = moduleLogged || line["msg"] == "Module ran"
+	}
+	assert.True(t, moduleLogged, "Modules should log with the analysis' logger")
+}
+
+// benchmarkHTTPClient serves one page, parsed by the real client.
+type benchmarkHTTPClient struct {
+	client.HTTPClient
+	page []byte
+}
+
+func (c *benchmarkHTTPClient) FetchWebpage(ctx context.Context, url string) ([]byte, int, error) {
+	return c.page, 200, nil
+}
+
+// benchmarkPage is a page of the size and variety of a typical article or
+// shop page: metadata, scripts, navigation, cards of text with links and
+// images, a table, and a sign-in form.
+func benchmarkPage() string {
+	var page strings.Builder
+	page.WriteString(`<!DOCTYPE html><html lang="en"><head><meta charset="utf-8"><title>Benchmark page</title>
+<meta name="description" content="A page to benchmark the analysis with">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="WordPress 6.4">
+<link rel="canonical" href="https://example.com/">
+<link rel="preconnect" href="https://fonts.example.net">
+<link rel="stylesheet" href="/wp-content/themes/site/style.css">
+<script src="https://www.googletagmanager.com/gtag/js?id=G-1"></script>
+<script type="application/ld+json">{"@context": "https://schema.org", "@type": "Article", "headline": "Benchmark page"}</script>
+</head><body><header><nav>`)
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&page, `<a href="/section/%d">Section %d</a>`, i, i)
+	}
+	page.WriteString(`</nav></header><main><h1>Benchmark page</h1>`)
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&page, `<div class="card"><h2>Card %d</h2><img src="/img/%d.jpg" alt="Card %d" width="300" height="200" loading="lazy">
+<p>This is the text of card %d, with a <a href="/page/%d?utm_source=news&amp;utm_medium=email">link</a> and <a href="https://other%d.example/">another</a>. Contact sales@example.com for details.</p></div>`,
+			i, i, i, i, i, i%25)
+	}
+	page.WriteString(`<table><tr><th>Plan</th><th>Price</th></tr><tr><td>Basic</td><td>$5</td></tr></table>
+<form action="/login" method="post"><input type="email" name="email"><input type="password" name="password" autocomplete="current-password"><button type="submit">Sign in</button></form>
+</main><footer><a href="https://twitter.com/example">Twitter</a><a href="https://www.linkedin.com/company/example">LinkedIn</a></footer></body></html>`)
+	return page.String()
+}
+
+// BenchmarkAnalyzeWebpage_DefaultModules runs the whole pipeline, parsing
+// included, with the modules a request without "modules" runs.
+func BenchmarkAnalyzeWebpage_DefaultModules(b *testing.B) {
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	service := NewService(WithHTTPClient(&benchmarkHTTPClient{HTTPClient: client.NewHTTPClient(), page: []byte(benchmarkPage())}))
+	req := AnalysisRequest{URL: "https://example.com/"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := service.AnalyzeWebpage(context.Background(), req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAnalyzeWebpage(b *testing.B) {
+	card := `<div class="card"><h2>Section heading</h2><p>This is the text of a card, with a <a href="/page">link</a> and <a href="https://other.example/">another</a>.</p>`
+	req := AnalysisRequest{URL: "https://example.com/", Modules: []string{ModuleHTMLVersion, ModulePageTitle, ModuleHeadings, ModuleLinks, ModuleContentHash, ModuleLanguage}}
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for _, bm := range []struct {
+		name string
+		body string
+	}{
+		{"flat", strings.Repeat(card+"</div>", 1000)},
+		// The cards nested in one another, just under the 512 levels the
+		// dom module reads.
+		{"nested", strings.Repeat(card, 500) + strings.Repeat("</div>", 500)},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			page := "<!DOCTYPE html><html lang=\"en\"><head><title>Benchmark</title></head><body>" + bm.body + "</body></html>"
+			service := NewService(WithHTTPClient(&benchmarkHTTPClient{HTTPClient: client.NewHTTPClient(), page: []byte(page)}))
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := service.AnalyzeWebpage(context.Background(), req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package analyzer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"webpage-analyzer/internal/logging"
+)
+
+const (
+	// maxSitemapFiles bounds how many sitemap files one request reads,
+	// including index files.
+	maxSitemapFiles = 50
+	// maxSitemapDepth bounds how deeply sitemap indexes may nest.
+	maxSitemapDepth = 3
+	// maxSitemapSize is the largest uncompressed sitemap the protocol allows.
+	maxSitemapSize = 50 << 20
+)
+
+// sitemapDocument is either a <urlset> or a <sitemapindex>; both list their
+// entries' addresses in <loc>.
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []sitemapEntry `xml:"url"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+type sitemapEntry struct {
+	Loc string `xml:"loc"`
+}
+
+// AnalyzeSitemap expands a sitemap, following sitemap indexes, and analyzes
+// every listed page with at most req.Concurrency pages in flight. Pages and
+// nested sitemaps that fail to load are reported in Errors; only a failure of
+// the submitted sitemap itself is returned as an error.
+func (s *service) AnalyzeSitemap(ctx context.Context, req SitemapRequest) (*SitemapResult, error) {
+	startTime := time.Now()
+
+	if req.MaxURLs < 0 || req.MaxURLs > MaxSitemapURLs {
+		return nil, &AnalysisError{
+			StatusCode:   http.StatusBadRequest,
+			Code:         ErrorCodeInvalidRequest,
+			ErrorMessage: fmt.Sprintf("max_urls must be between 1 and %d", MaxSitemapURLs),
+			URL:          req.SitemapURL,
+		}
+	}
+	if req.Concurrency < 0 || req.Concurrency > MaxSitemapConcurrency {
+		return nil, &AnalysisError{
+			StatusCode:   http.StatusBadRequest,
+			Code:         ErrorCodeInvalidRequest,
+			ErrorMessage: fmt.Sprintf("concurrency must be between 1 and %d", MaxSitemapConcurrency),
+			URL:          req.SitemapURL,
+		}
+	}
+	maxURLs := req.MaxURLs
+	if maxURLs == 0 {
+		maxURLs = DefaultSitemapMaxURLs
+	}
+	concurrency := req.Concurrency
+	if concurrency == 0 {
+		concurrency = DefaultSitemapConcurrency
+	}
+
+	modules, err := s.modules.Select(req.Modules)
+	if err != nil {
+		return nil, &AnalysisError{
+			StatusCode:   http.StatusBadRequest,
+			Code:         ErrorCodeInvalidRequest,
+			ErrorMessage: fmt.Sprintf("Invalid module selection: %v", err),
+			URL:          req.SitemapURL,
+		}
+	}
+	if err := validateOptions(modules, req.Options); err != nil {
+		return nil, &AnalysisError{
+			StatusCode:   http.StatusBadRequest,
+			Code:         ErrorCodeInvalidRequest,
+			ErrorMessage: fmt.Sprintf("Invalid module options: %v", err),
+			URL:          req.SitemapURL,
+		}
+	}
+
+	logging.FromContext(ctx).Info("Starting sitemap analysis", "sitemap_url", req.SitemapURL, "max_urls", maxURLs, "concurrency", concurrency)
+	result := &SitemapResult{SitemapURL: req.SitemapURL, Pages: make([]*WebpageAnalysis, 0)}
+	urls, err := s.expandSitemap(ctx, req.SitemapURL, maxURLs, result)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex // Guards progress and the callback.
+	progress := SitemapProgress{Total: len(urls)}
+	report := func() {
+		if req.Progress != nil {
+			req.Progress(progress)
+		}
+	}
+	report()
+
+	pages := make([]*WebpageAnalysis, len(urls))
+	pageErrors := make([]*AnalysisError, len(urls))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, pageURL := range urls {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			pages[i], pageErrors[i] = s.analyzeSitemapPage(ctx, pageURL, modules, req.Options)
+
+			mu.Lock()
+			defer mu.Unlock()
+			progress.Completed++
+			if pages[i] == nil {
+				progress.Failed++
+			}
+			report()
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range urls {
+		if pages[i] != nil {
+			result.Pages = append(result.Pages, pages[i])
+		} else if pageErrors[i] != nil {
+			result.Errors = append(result.Errors, pageErrors[i])
+		}
+	}
+	result.Summary = summarizeSite(result.Pages, progress.Failed)
+	result.ProcessingTime = time.Since(startTime).String()
+	logging.FromContext(ctx).Info("Sitemap analysis completed",
+		"sitemap_url", req.SitemapURL,
+		"sitemaps", result.Sitemaps,
+		"pages", len(result.Pages),
+		"errors", len(result.Errors),
+		"processing_time", result.ProcessingTime,
+	)
+
+	return result, nil
+}
+
+// analyzeSitemapPage fetches and analyzes one listed page. A failed page
+// yields an AnalysisError rather than stopping the batch.
+func (s *service) analyzeSitemapPage(ctx context.Context, pageURL string, modules []AnalyzerModule, options map[string]ModuleOptions) (*WebpageAnalysis, *AnalysisError) {
+	pageStart := time.Now()
+	doc, info, err := s.fetchDocument(ctx, pageURL)
+	if err == nil {
+		var analysis *WebpageAnalysis
+		if analysis, err = s.analyzeDocument(ctx, doc, info, modules, options, pageStart, time.Time{}); err == nil {
+			return analysis, nil
+		}
+	}
+	if analysisErr, ok := err.(*AnalysisError); ok {
+		return nil, analysisErr
+	}
+	return nil, &AnalysisError{StatusCode: http.StatusInternalServerError, Code: ErrorCodeInternal, ErrorMessage: err.Error(), URL: pageURL}
+}
+
+// expandSitemap reads the sitemap at sitemapURL and, breadth-first, the
+// sitemaps its indexes list, and returns up to maxURLs distinct page URLs in
+// the order listed. It counts the files read and records nested sitemaps that
+// fail in result.
+func (s *service) expandSitemap(ctx context.Context, sitemapURL string, maxURLs int, result *SitemapResult) ([]string, error) {
+	type sitemapItem struct {
+		url   string
+		depth int
+	}
+	queue := []sitemapItem{{url: sitemapURL}}
+	seenSitemaps := map[string]bool{crawlKey(sitemapURL): true}
+	seenURLs := make(map[string]bool)
+	var urls []string
+
+	for len(queue) > 0 && result.Sitemaps < maxSitemapFiles {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		item := queue[0]
+		queue = queue[1:]
+
+		doc, err := s.fetchSitemap(ctx, item.url)
+		if err != nil {
+			if item.depth == 0 {
+				return nil, err
+			}
+			result.Errors = append(result.Errors, err)
+			continue
+		}
+		result.Sitemaps++
+
+		for _, entry := range doc.URLs {
+			loc := strings.TrimSpace(entry.Loc)
+			if loc == "" || seenURLs[crawlKey(loc)] {
+				continue
+			}
+			if len(urls) == maxURLs {
+				result.Truncated = true
+				return urls, nil
+			}
+			seenURLs[crawlKey(loc)] = true
+			urls = append(urls, loc)
+		}
+		if item.depth >= maxSitemapDepth {
+			continue
+		}
+		for _, entry := range doc.Sitemaps {
+			loc := strings.TrimSpace(entry.Loc)
+			if loc != "" && !seenSitemaps[crawlKey(loc)] {
+				seenSitemaps[crawlKey(loc)] = true
+				queue = append(queue, sitemapItem{url: loc, depth: item.depth + 1})
+			}
+		}
+	}
+	if len(queue) > 0 {
+		result.Truncated = true // Hit maxSitemapFiles.
+	}
+	return urls, nil
+}
+
+// fetchSitemap fetches and decodes one sitemap file, gzipped or not.
+func (s *service) fetchSitemap(ctx context.Context, sitemapURL string) (*sitemapDocument, *AnalysisError) {
+	logging.FromContext(ctx).Info("Fetching sitemap", "url", sitemapURL)
+	body, statusCode, err := s.httpClient.FetchWebpage(ctx, sitemapURL)
+	if err != nil {
+		return nil, &AnalysisError{StatusCode: statusCode, Code: fetchErrorCode(err), ErrorMessage: err.Error(), URL: sitemapURL}
+	}
+	if statusCode != http.StatusOK {
+		return nil, &AnalysisError{StatusCode: statusCode, Code: ErrorCodeUpstreamStatus, ErrorMessage: s.getHTTPStatusMessage(statusCode), URL: sitemapURL}
+	}
+
+	doc, err := parseSitemap(body)
+	if err != nil {
+		return nil, &AnalysisError{
+			StatusCode:   statusCode,
+			Code:         ErrorCodeParseFailure,
+			ErrorMessage: fmt.Sprintf("Failed to parse sitemap: %v", err),
+			URL:          sitemapURL,
+		}
+	}
+	return doc, nil
+}
+
+// parseSitemap decodes a <urlset> or <sitemapindex> document, decompressing
+// it first if it is gzipped (sitemap.xml.gz).
+func parseSitemap(body []byte) (*sitemapDocument, error) {
+	if bytes.HasPrefix(body, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if body, err = io.ReadAll(io.LimitReader(zr, maxSitemapSize)); err != nil {
+			return nil, err
+		}
+	}
+
+	var doc sitemapDocument
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	switch doc.XMLName.Local {
+	case "urlset", "sitemapindex":
+		return &doc, nil
+	default:
+		return nil, fmt.Errorf("unexpected root element <%s>", doc.XMLName.Local)
+	}
+}
+
+// summarizeSite totals the analyses of a site's pages.
+func summarizeSite(pages []*WebpageAnalysis, failed int) SiteSummary {
+	summary := SiteSummary{
+		Pages:        len(pages),
+		FailedPages:  failed,
+		HTMLVersions: make(map[string]int),
+	}
+	titles := make(map[string]int)
+	for _, page := range pages {
+		if page.HTMLVersion != "" {
+			summary.HTMLVersions[page.HTMLVersion]++
+		}
+		if page.HasLoginForm {
+			summary.PagesWithLoginForm++
+		}
+		if title := strings.TrimSpace(page.PageTitle); title == "" {
+			summary.MissingTitles++
+		} else {
+			titles[title]++
+		}
+		summary.InternalLinks += page.InternalLinks
+		summary.ExternalLinks += page.ExternalLinks
+		summary.InaccessibleLinks += page.InaccessibleLinks
+		for _, tech := range page.Technologies {
+			if summary.Technologies == nil {
+				summary.Technologies = make(map[string]int)
+			}
+			summary.Technologies[tech.Name]++
+		}
+		for _, finding := range page.Findings {
+			if summary.Findings == nil {
+				summary.Findings = make(map[Severity]int)
+			}
+			summary.Findings[finding.Severity]++
+		}
+	}
+	for title, count := range titles {
+		if count > 1 {
+			summary.DuplicateTitles = append(summary.DuplicateTitles, title)
+		}
+	}
+	sort.Strings(summary.DuplicateTitles)
+	return summary
+}
@@ -0,0 +1,139 @@
+package analyzer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/worker"
+)
+
+func gzipString(t *testing.T, s string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.String()
+}
+
+func newSitemapTestService(t *testing.T) Service {
+	mockClient := &urlMockHTTPClient{
+		responses: map[string]string{
+			"https://example.com/sitemap.xml": `<?xml version="1.0" encoding="UTF-8"?>
+				<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+					<sitemap><loc>https://example.com/sitemap-pages.xml</loc></sitemap>
+					<sitemap><loc>https://example.com/sitemap-blog.xml.gz</loc></sitemap>
+					<sitemap><loc>https://example.com/sitemap-missing.xml</loc></sitemap>
+				</sitemapindex>`,
+			"https://example.com/sitemap-pages.xml": `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+					<url><loc>https://example.com/</loc></url>
+					<url><loc> https://example.com/about </loc></url>
+					<url><loc>https://example.com/gone</loc></url>
+				</urlset>`,
+			"https://example.com/sitemap-blog.xml.gz": gzipString(t, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+					<url><loc>https://example.com/blog</loc></url>
+					<url><loc>https://example.com/about</loc></url>
+				</urlset>`),
+			"https://example.com/":      `<!DOCTYPE html><html><head><title>Home</title></head><body><form><input type="password"></form></body></html>`,
+			"https://example.com/about": `<!DOCTYPE html><html><head><title>Home</title></head><body><a href="/">Home</a></body></html>`,
+			"https://example.com/blog":  `<html><body><a href="https://other.com">O</a></body></html>`,
+		},
+	}
+	return NewServiceWithDependencies(mockClient, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+}
+
+func TestAnalyzeSitemap(t *testing.T) {
+	service := newSitemapTestService(t)
+
+	var mu sync.Mutex
+	var updates []SitemapProgress
+	result, err := service.AnalyzeSitemap(context.Background(), SitemapRequest{
+		SitemapURL: "https://example.com/sitemap.xml",
+		Progress: func(p SitemapProgress) {
+			mu.Lock()
+			defer mu.Unlock()
+			updates = append(updates, p)
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.Sitemaps, "Index and two readable sitemaps should be counted")
+	assert.False(t, result.Truncated)
+	require.Len(t, result.Pages, 3, "Duplicate and failing URLs should not yield pages")
+	assert.Equal(t, "https://example.com/", result.Pages[0].URL, "Pages should keep sitemap order")
+	assert.Equal(t, "https://example.com/about", result.Pages[1].URL, "Whitespace around <loc> should be trimmed")
+	assert.Equal(t, "https://example.com/blog", result.Pages[2].URL)
+
+	var failed []string
+	for _, e := range result.Errors {
+		failed = append(failed, e.URL)
+	}
+	assert.ElementsMatch(t, []string{"https://example.com/sitemap-missing.xml", "https://example.com/gone"}, failed)
+
+	summary := result.Summary
+	assert.Equal(t, 3, summary.Pages)
+	assert.Equal(t, 1, summary.FailedPages)
+	assert.Equal(t, map[string]int{result.Pages[0].HTMLVersion: 3}, summary.HTMLVersions)
+	assert.Equal(t, 1, summary.PagesWithLoginForm)
+	assert.Equal(t, 1, summary.MissingTitles)
+	assert.Equal(t, []string{"Home"}, summary.DuplicateTitles)
+	assert.Equal(t, 1, summary.ExternalLinks)
+
+	require.NotEmpty(t, updates)
+	assert.Equal(t, SitemapProgress{Total: 4}, updates[0], "First update should come once the sitemap is expanded")
+	assert.Equal(t, SitemapProgress{Total: 4, Completed: 4, Failed: 1}, updates[len(updates)-1])
+}
+
+func TestAnalyzeSitemap_MaxURLs(t *testing.T) {
+	service := newSitemapTestService(t)
+
+	result, err := service.AnalyzeSitemap(context.Background(), SitemapRequest{
+		SitemapURL: "https://example.com/sitemap-pages.xml",
+		MaxURLs:    2,
+		Modules:    []string{ModulePageTitle},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, result.Truncated)
+	require.Len(t, result.Pages, 2)
+	assert.Equal(t, []string{ModulePageTitle}, result.Pages[0].Modules)
+}
+
+func TestAnalyzeSitemap_Errors(t *testing.T) {
+	service := newSitemapTestService(t)
+
+	tests := []struct {
+		name string
+		req  SitemapRequest
+	}{
+		{"missing sitemap", SitemapRequest{SitemapURL: "https://missing.example.com/sitemap.xml"}},
+		{"not a sitemap", SitemapRequest{SitemapURL: "https://example.com/"}},
+		{"too many URLs", SitemapRequest{SitemapURL: "https://example.com/sitemap.xml", MaxURLs: MaxSitemapURLs + 1}},
+		{"too much concurrency", SitemapRequest{SitemapURL: "https://example.com/sitemap.xml", Concurrency: MaxSitemapConcurrency + 1}},
+		{"unknown module", SitemapRequest{SitemapURL: "https://example.com/sitemap.xml", Modules: []string{"nope"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.AnalyzeSitemap(context.Background(), tt.req)
+			var analysisErr *AnalysisError
+			assert.ErrorAs(t, err, &analysisErr)
+		})
+	}
+}
+
+func TestParseSitemap(t *testing.T) {
+	doc, err := parseSitemap([]byte(`<urlset><url><loc>https://a.example.com/</loc></url></urlset>`))
+	require.NoError(t, err)
+	assert.Len(t, doc.URLs, 1)
+
+	_, err = parseSitemap([]byte(`<rss><channel></channel></rss>`))
+	assert.Error(t, err, "Non-sitemap XML should be rejected")
+}
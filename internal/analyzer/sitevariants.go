@@ -0,0 +1,230 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Options accepted by the site_variants module.
+const (
+	siteVariantsOptionTimeout = "timeout" // duration: timeout for each probe request.
+
+	defaultSiteVariantsTimeout = 10 * time.Second
+	maxSiteVariantRedirects    = 5
+)
+
+// siteVariantsModule probes the http and https, www and non-www variants of
+// the page's host and reports whether they all redirect to one of them. It
+// sends its own requests, so it only runs when requested by name.
+type siteVariantsModule struct {
+	client *http.Client
+}
+
+// newSiteVariantsModule creates the site_variants module with a client that
+// does not follow redirects.
+func newSiteVariantsModule() *siteVariantsModule {
+	return &siteVariantsModule{client: &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+func (m *siteVariantsModule) Name() string { return ModuleSiteVariants }
+
+// OptIn implements OptInModule.
+func (m *siteVariantsModule) OptIn() bool { return true }
+
+// ValidateOptions implements OptionsValidator.
+func (m *siteVariantsModule) ValidateOptions(opts ModuleOptions) error {
+	_, err := m.parseOptions(opts)
+	return err
+}
+
+// parseOptions reads the timeout option.
+func (m *siteVariantsModule) parseOptions(opts ModuleOptions) (time.Duration, error) {
+	for key := range opts {
+		if key != siteVariantsOptionTimeout {
+			return 0, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	timeout, err := opts.Duration(siteVariantsOptionTimeout, defaultSiteVariantsTimeout)
+	if err != nil {
+		return 0, err
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("option %q must be positive", siteVariantsOptionTimeout)
+	}
+	return timeout, nil
+}
+
+// Analyze probes the four variants concurrently. Hosts that are IP addresses
+// have no www variant and are not probed.
+func (m *siteVariantsModule) Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error) {
+	timeout, err := m.parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(info.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page URL: %v", err)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" || net.ParseIP(host) != nil {
+		return ModuleResultFunc(func(a *WebpageAnalysis) {}), nil
+	}
+
+	bare := strings.TrimPrefix(host, "www.")
+	starts := []string{"http://" + bare + "/", "http://www." + bare + "/", "https://" + bare + "/", "https://www." + bare + "/"}
+	variants := make([]SiteVariant, len(starts))
+	var wg sync.WaitGroup
+	for i, start := range starts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			variants[i] = m.probe(ctx, start, timeout)
+		}()
+	}
+	wg.Wait()
+
+	result := consolidateSiteVariants(variants)
+	findings := siteVariantFindings(result)
+	return ModuleResultFunc(func(a *WebpageAnalysis) {
+		a.SiteVariants = result
+		a.Findings = append(a.Findings, findings...)
+	}), nil
+}
+
+// probe follows redirects from start, up to maxSiteVariantRedirects, and
+// records where they end.
+func (m *siteVariantsModule) probe(ctx context.Context, start string, timeout time.Duration) SiteVariant {
+	variant := SiteVariant{URL: start}
+	current := start
+	seen := map[string]bool{start: true}
+	for {
+		statusCode, location, err := m.hop(ctx, current, timeout)
+		if err != nil {
+			variant.Error = err.Error()
+			variant.Unchecked = errors.Is(err, errSubrequestsSpent)
+			return variant
+		}
+		if statusCode < 300 || statusCode >= 400 || location == "" {
+			variant.FinalURL, variant.StatusCode = current, statusCode
+			return variant
+		}
+		base, _ := url.Parse(current)
+		next, err := base.Parse(location)
+		if err != nil {
+			variant.Error = fmt.Sprintf("invalid redirect location %q", location)
+			return variant
+		}
+		current = next.String()
+		variant.Redirects = append(variant.Redirects, current)
+		if seen[current] {
+			variant.Error = "redirect loop"
+			return variant
+		}
+		if len(variant.Redirects) > maxSiteVariantRedirects {
+			variant.Error = fmt.Sprintf("more than %d redirects", maxSiteVariantRedirects)
+			return variant
+		}
+		seen[current] = true
+	}
+}
+
+// hop sends one GET request, as a sub-request of the analysis, without
+// following redirects and returns its status and Location header. GET is used
+// rather than HEAD, which some servers answer differently.
+func (m *siteVariantsModule) hop(ctx context.Context, target string, timeout time.Duration) (int, string, error) {
+	ctx, cancel, err := subrequestsFrom(ctx).one(ctx, timeout)
+	if err != nil {
+		return 0, "", err
+	}
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("User-Agent", "WebpageAnalyzer/1.0")
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	resp.Body.Close()
+	return resp.StatusCode, resp.Header.Get("Location"), nil
+}
+
+// consolidateSiteVariants reports whether the variants consolidate: every
+// variant that answered ends, with a success status, on the same origin.
+// Variants that could not be reached, such as a www host without DNS
+// records, cannot split the site and are ignored, as are those the analysis
+// had no sub-requests left to follow.
+func consolidateSiteVariants(variants []SiteVariant) *SiteVariants {
+	result := &SiteVariants{Variants: variants}
+	origins := make(map[string]bool)
+	broken := false
+	for _, v := range variants {
+		if v.Unchecked || v.Error != "" && v.Redirects == nil {
+			continue // Not reachable, or not followed to the end.
+		}
+		if v.Error != "" || v.StatusCode >= http.StatusBadRequest {
+			broken = true
+			continue
+		}
+		if final, err := url.Parse(v.FinalURL); err == nil {
+			origins[urlOrigin(final)] = true
+		}
+	}
+	for origin := range origins {
+		result.Origins = append(result.Origins, origin)
+	}
+	sort.Strings(result.Origins)
+	if len(result.Origins) == 1 && !broken {
+		result.Consolidated = true
+		result.Canonical = result.Origins[0] + "/"
+	}
+	return result
+}
+
+// siteVariantFindings reports variants that do not consolidate. Search
+// engines then see the site's pages under several URLs, splitting their
+// ranking signals.
+func siteVariantFindings(result *SiteVariants) []Finding {
+	if result.Consolidated {
+		return nil
+	}
+	var failed []string
+	for _, v := range result.Variants {
+		if !v.Unchecked && v.Error != "" && v.Redirects != nil || v.StatusCode >= http.StatusBadRequest {
+			failed = append(failed, v.URL)
+		}
+	}
+	switch {
+	case len(result.Origins) > 1:
+		return []Finding{{
+			Type:     FindingSiteVariantsSplit,
+			Severity: SeverityLow,
+			Message:  fmt.Sprintf("Site variants do not redirect to a single variant; they end on %s", strings.Join(result.Origins, ", ")),
+		}}
+	case len(failed) > 0:
+		return []Finding{{
+			Type:     FindingSiteVariantsSplit,
+			Severity: SeverityLow,
+			Message:  fmt.Sprintf("Site variants %s fail instead of redirecting to the site", strings.Join(failed, ", ")),
+		}}
+	}
+	return nil
+}
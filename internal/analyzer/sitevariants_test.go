@@ -0,0 +1,131 @@
+package analyzer
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// probeSiteVariants runs the site_variants module for pageURL with every
+// host's port 80 served by plain and port 443 by secure; hosts in unreachable
+// do not resolve.
+func probeSiteVariants(t *testing.T, pageURL string, plain, secure http.HandlerFunc, unreachable ...string) *WebpageAnalysis {
+	t.Helper()
+	httpServer := httptest.NewServer(plain)
+	defer httpServer.Close()
+	tlsServer := httptest.NewTLSServer(secure)
+	defer tlsServer.Close()
+
+	module := newSiteVariantsModule()
+	module.client.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, port, _ := net.SplitHostPort(addr)
+			for _, u := range unreachable {
+				if host == u {
+					return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+				}
+			}
+			target := httpServer.Listener.Addr().String()
+			if port == "443" {
+				target = tlsServer.Listener.Addr().String()
+			}
+			return (&net.Dialer{}).DialContext(ctx, network, target)
+		},
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // The test certificate does not name the hosts.
+	}
+
+	result, err := module.Analyze(context.Background(), nil, FetchInfo{URL: pageURL}, nil)
+	require.NoError(t, err)
+	analysis := &WebpageAnalysis{}
+	result.Apply(analysis)
+	return analysis
+}
+
+// redirectToCanonical redirects every request that is not for https://example.com/.
+func redirectToCanonical(w http.ResponseWriter, r *http.Request) {
+	if r.TLS == nil || r.Host != "example.com" {
+		http.Redirect(w, r, "https://example.com/", http.StatusMovedPermanently)
+	}
+}
+
+func TestSiteVariantsModule_Consolidated(t *testing.T) {
+	analysis := probeSiteVariants(t, "https://www.example.com/page", redirectToCanonical, redirectToCanonical)
+
+	variants := analysis.SiteVariants
+	require.NotNil(t, variants)
+	assert.True(t, variants.Consolidated)
+	assert.Equal(t, "https://example.com/", variants.Canonical)
+	require.Len(t, variants.Variants, 4)
+	assert.Equal(t, SiteVariant{URL: "http://www.example.com/", Redirects: []string{"https://example.com/"}, FinalURL: "https://example.com/", StatusCode: http.StatusOK}, variants.Variants[1])
+	assert.Equal(t, SiteVariant{URL: "https://example.com/", FinalURL: "https://example.com/", StatusCode: http.StatusOK}, variants.Variants[2])
+	assert.Empty(t, analysis.Findings)
+}
+
+func TestSiteVariantsModule_UnreachableVariant(t *testing.T) {
+	analysis := probeSiteVariants(t, "https://example.com/", redirectToCanonical, redirectToCanonical, "www.example.com")
+
+	assert.True(t, analysis.SiteVariants.Consolidated, "A www host that does not resolve cannot split the site")
+	assert.NotEmpty(t, analysis.SiteVariants.Variants[1].Error)
+	assert.Empty(t, analysis.Findings)
+}
+
+func TestSiteVariantsModule_Split(t *testing.T) {
+	serve := func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("<html></html>")) }
+	analysis := probeSiteVariants(t, "https://example.com/", serve, serve)
+
+	variants := analysis.SiteVariants
+	assert.False(t, variants.Consolidated)
+	assert.Equal(t, []string{"http://example.com", "http://www.example.com", "https://example.com", "https://www.example.com"}, variants.Origins)
+	require.Len(t, analysis.Findings, 1)
+	assert.Equal(t, FindingSiteVariantsSplit, analysis.Findings[0].Type)
+	assert.Equal(t, SeverityLow, analysis.Findings[0].Severity)
+}
+
+func TestSiteVariantsModule_BrokenVariant(t *testing.T) {
+	secure := func(w http.ResponseWriter, r *http.Request) {
+		if r.Host == "www.example.com" {
+			http.Redirect(w, r, "/", http.StatusFound) // A loop.
+		}
+	}
+	plain := func(w http.ResponseWriter, r *http.Request) {
+		if r.Host == "www.example.com" {
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, "https://example.com/", http.StatusMovedPermanently)
+	}
+	analysis := probeSiteVariants(t, "https://example.com/", plain, secure)
+
+	variants := analysis.SiteVariants
+	assert.False(t, variants.Consolidated)
+	assert.Equal(t, []string{"https://example.com"}, variants.Origins)
+	assert.Equal(t, http.StatusNotFound, variants.Variants[1].StatusCode)
+	assert.Equal(t, "redirect loop", variants.Variants[3].Error)
+	require.Len(t, analysis.Findings, 1)
+	assert.Equal(t, "Site variants http://www.example.com/, https://www.example.com/ fail instead of redirecting to the site", analysis.Findings[0].Message)
+}
+
+func TestSiteVariantsModule_IPAddress(t *testing.T) {
+	result, err := newSiteVariantsModule().Analyze(context.Background(), nil, FetchInfo{URL: "http://192.0.2.1/"}, nil)
+	require.NoError(t, err)
+	analysis := &WebpageAnalysis{}
+	result.Apply(analysis)
+	assert.Nil(t, analysis.SiteVariants)
+}
+
+func TestConsolidateSiteVariants_Unchecked(t *testing.T) {
+	result := consolidateSiteVariants([]SiteVariant{
+		{URL: "http://example.com/", Redirects: []string{"https://example.com/"}, FinalURL: "https://example.com/", StatusCode: http.StatusOK},
+		{URL: "http://www.example.com/", Redirects: []string{"https://www.example.com/"}, Error: errSubrequestsSpent.Error(), Unchecked: true},
+		{URL: "https://example.com/", FinalURL: "https://example.com/", StatusCode: http.StatusOK},
+	})
+
+	assert.True(t, result.Consolidated, "A variant the analysis had no sub-requests left for cannot split the site")
+	assert.Empty(t, siteVariantFindings(result))
+}
@@ -0,0 +1,180 @@
+package analyzer
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// socialLoginProvider describes how to recognize one identity provider.
+type socialLoginProvider struct {
+	name      string
+	keywords  []string // Provider names as they appear in button text.
+	endpoints []string // host+path of OAuth and sign-in SDK URLs; see matchesEndpoint.
+	markers   []string // class or id values used by the provider's official buttons.
+}
+
+// socialLoginProviders are the identity providers DetectSocialLogins knows, in reporting order.
+var socialLoginProviders = []socialLoginProvider{
+	{
+		name:      "google",
+		keywords:  []string{"google"},
+		endpoints: []string{"accounts.google.com/o/oauth2", "accounts.google.com/gsi/", "accounts.google.com/signin/oauth"},
+		markers:   []string{"g_id_signin", "g_id_onload", "g-signin2"},
+	},
+	{
+		name:      "apple",
+		keywords:  []string{"apple"},
+		endpoints: []string{"appleid.apple.com/auth/authorize", "appleid.cdn-apple.com/appleauth/"},
+		markers:   []string{"appleid-signin"},
+	},
+	{
+		name:      "facebook",
+		keywords:  []string{"facebook"},
+		endpoints: []string{"facebook.com/dialog/oauth"},
+		markers:   []string{"fb-login-button"},
+	},
+	{
+		name:      "github",
+		keywords:  []string{"github"},
+		endpoints: []string{"github.com/login/oauth/authorize"},
+	},
+	{
+		name:      "microsoft",
+		keywords:  []string{"microsoft"},
+		endpoints: []string{"login.microsoftonline.com/", "login.live.com/oauth20_authorize"},
+	},
+	{
+		name:      "twitter",
+		keywords:  []string{"twitter", "x"},
+		endpoints: []string{"api.twitter.com/oauth/authenticate", "twitter.com/i/oauth2/authorize", "x.com/i/oauth2/authorize"},
+	},
+	{
+		name:      "linkedin",
+		keywords:  []string{"linkedin"},
+		endpoints: []string{"linkedin.com/oauth/"},
+	},
+}
+
+// socialLoginPhrases introduce a provider name on a sign-in button.
+var socialLoginPhrases = []string{
+	"sign in with", "log in with", "login with", "sign up with", "continue with", "connect with",
+}
+
+// DetectSocialLogins lists the identity providers a page offers for sign-in,
+// from button text such as "Sign in with Google", the providers' official
+// button markup, and links, forms or scripts pointing at their OAuth endpoints.
+func DetectSocialLogins(elements []parser.Element) []string {
+	found := make(map[string]bool)
+	for _, el := range elements {
+		signals := socialLoginSignalsOf(el)
+		for _, provider := range socialLoginProviders {
+			if !found[provider.name] && provider.matches(signals) {
+				found[provider.name] = true
+			}
+		}
+	}
+
+	var providers []string
+	for _, provider := range socialLoginProviders {
+		if found[provider.name] {
+			providers = append(providers, provider.name)
+		}
+	}
+	return providers
+}
+
+// socialLoginSignals is what the providers are matched against in one
+// element, read once for all of them.
+type socialLoginSignals struct {
+	urls    []*url.URL // The absolute URLs the element points at.
+	id      string
+	classes []string
+	labels  []string // The lower-cased labels of links and buttons.
+}
+
+// socialLoginSignalsOf reads the signals of el.
+func socialLoginSignalsOf(el parser.Element) socialLoginSignals {
+	signals := socialLoginSignals{id: el.Attr("id"), classes: strings.Fields(el.Attr("class"))}
+	for _, attr := range []string{"href", "action", "src", "data-login-uri"} {
+		// Only URLs with a host can point at a provider, which spares
+		// parsing the page's many relative links.
+		rawURL := strings.TrimSpace(el.Attr(attr))
+		if !strings.Contains(rawURL, "//") {
+			continue
+		}
+		if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+			signals.urls = append(signals.urls, u)
+		}
+	}
+	if el.Tag == "a" || el.Tag == "button" {
+		for _, label := range []string{el.Text(), el.Attr("aria-label"), el.Attr("title")} {
+			if label != "" {
+				signals.labels = append(signals.labels, strings.ToLower(label))
+			}
+		}
+	}
+	return signals
+}
+
+// matches reports whether the element is a sign-in entry point for the provider.
+func (p socialLoginProvider) matches(signals socialLoginSignals) bool {
+	for _, u := range signals.urls {
+		if p.matchesEndpoint(u) {
+			return true
+		}
+	}
+
+	for _, marker := range p.markers {
+		if signals.id == marker || slices.Contains(signals.classes, marker) {
+			return true
+		}
+	}
+
+	for _, label := range signals.labels {
+		if p.matchesLabel(label) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesEndpoint reports whether u points at one of the provider's
+// endpoints. The host must match, ignoring a "www." prefix; the endpoint path
+// may appear anywhere in the URL path, so versioned APIs such as
+// facebook.com/v18.0/dialog/oauth also match.
+func (p socialLoginProvider) matchesEndpoint(u *url.URL) bool {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	path := strings.ToLower(u.Path)
+	for _, endpoint := range p.endpoints {
+		endpointHost, endpointPath, _ := strings.Cut(endpoint, "/")
+		if host == endpointHost && strings.Contains(path, "/"+endpointPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesLabel reports whether a lower-cased button label reads like "Sign in
+// with <provider>".
+func (p socialLoginProvider) matchesLabel(label string) bool {
+	for _, phrase := range socialLoginPhrases {
+		i := strings.Index(label, phrase)
+		if i < 0 {
+			continue
+		}
+		rest := strings.Fields(label[i+len(phrase):])
+		if len(rest) == 0 {
+			continue
+		}
+		word := strings.Trim(rest[0], ".,!:;()")
+		for _, keyword := range p.keywords {
+			if word == keyword {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+func TestDetectSocialLogins(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want []string
+	}{
+		{"none", `<a href="/login">Log in</a><button>Continue</button>`, nil},
+		{"button text", `<button type="button">  Sign in with
+			<span>Google</span></button><a href="/auth/gh">Continue with GitHub</a>`,
+			[]string{"google", "github"}},
+		{"aria label", `<a href="/auth/apple" aria-label="Sign up with Apple"><img src="apple.svg"></a>`,
+			[]string{"apple"}},
+		{"oauth endpoint", `<a href="https://www.facebook.com/v18.0/dialog/oauth?client_id=1">f</a>
+			<a href="https://github.com/login/oauth/authorize?client_id=abc">Octocat</a>`,
+			[]string{"facebook", "github"}},
+		{"sdk markup", `<script src="https://accounts.google.com/gsi/client" async></script>
+			<div id="appleid-signin" data-color="black"></div>`,
+			[]string{"google", "apple"}},
+		{"x is twitter", `<button>Log in with X</button>`, []string{"twitter"}},
+		{"unrelated mention", `<p>Sign in with Google</p><a href="/blog">Google announces</a>`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.page))
+			require.NoError(t, err)
+			elements := parser.NewHTMLParser().ExtractElements(doc, "a", "button", "form", "script", "div")
+			assert.Equal(t, tt.want, DetectSocialLogins(elements))
+		})
+	}
+}
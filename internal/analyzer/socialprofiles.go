@@ -0,0 +1,171 @@
+package analyzer
+
+import (
+	"net/url"
+	"strings"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// socialPlatform describes how to recognize profile links on one social network.
+type socialPlatform struct {
+	name  string
+	host  string   // Canonical host used in normalized URLs.
+	hosts []string // Every host the platform's links use, without "www.".
+	// profilePath returns the normalized path of the profile a link points at,
+	// given the link's path segments, or "" when the link is not a profile
+	// (a share button, a single post, a help page...).
+	profilePath func(segments []string) string
+}
+
+// socialPlatforms are the platforms ExtractSocialProfiles knows, in reporting order.
+var socialPlatforms = []socialPlatform{
+	{
+		name:  "twitter",
+		host:  "x.com",
+		hosts: []string{"x.com", "twitter.com", "mobile.twitter.com"},
+		profilePath: handleProfile(
+			"home", "i", "intent", "share", "search", "hashtag", "explore", "login", "signup",
+			"settings", "messages", "notifications", "privacy", "tos", "about", "download",
+		),
+	},
+	{
+		name:  "linkedin",
+		host:  "www.linkedin.com",
+		hosts: []string{"linkedin.com"},
+		profilePath: func(segments []string) string {
+			if len(segments) >= 2 {
+				switch segments[0] {
+				case "in", "company", "school", "showcase":
+					return "/" + segments[0] + "/" + strings.ToLower(segments[1])
+				}
+			}
+			return ""
+		},
+	},
+	{
+		name:  "facebook",
+		host:  "www.facebook.com",
+		hosts: []string{"facebook.com", "m.facebook.com", "fb.com"},
+		profilePath: handleProfile(
+			"sharer", "sharer.php", "share.php", "share", "dialog", "plugins", "login", "login.php",
+			"tr", "help", "policies", "privacy", "events", "groups", "watch", "profile.php",
+		),
+	},
+	{
+		name:  "instagram",
+		host:  "www.instagram.com",
+		hosts: []string{"instagram.com"},
+		profilePath: handleProfile(
+			"p", "reel", "reels", "tv", "stories", "explore", "accounts", "direct", "about", "legal",
+		),
+	},
+	{
+		name:  "youtube",
+		host:  "www.youtube.com",
+		hosts: []string{"youtube.com", "m.youtube.com"},
+		profilePath: func(segments []string) string {
+			switch {
+			case strings.HasPrefix(segments[0], "@") && len(segments[0]) > 1:
+				return "/" + strings.ToLower(segments[0])
+			case len(segments) >= 2 && segments[0] == "channel":
+				return "/channel/" + segments[1] // Channel IDs are case-sensitive.
+			case len(segments) >= 2 && (segments[0] == "c" || segments[0] == "user"):
+				return "/" + segments[0] + "/" + strings.ToLower(segments[1])
+			}
+			return ""
+		},
+	},
+	{
+		name:  "tiktok",
+		host:  "www.tiktok.com",
+		hosts: []string{"tiktok.com"},
+		profilePath: func(segments []string) string {
+			if strings.HasPrefix(segments[0], "@") && len(segments[0]) > 1 {
+				return "/" + strings.ToLower(segments[0])
+			}
+			return ""
+		},
+	},
+}
+
+// ExtractSocialProfiles lists the social media profiles a page links to.
+// Links are normalized to https on the platform's canonical host, with the
+// query, fragment and any post or tab path after the profile dropped, so
+// https://twitter.com/Example?lang=en and https://x.com/example/status/1 both
+// report https://x.com/example. Share buttons and other non-profile links are
+// ignored.
+func ExtractSocialProfiles(links []parser.Element) []SocialProfile {
+	seen := make(map[string]bool)
+	var found []SocialProfile
+	for _, link := range links {
+		profile, ok := socialProfileFromURL(link.Attr("href"))
+		if ok && !seen[profile.URL] {
+			seen[profile.URL] = true
+			found = append(found, profile)
+		}
+	}
+
+	var profiles []SocialProfile
+	for _, platform := range socialPlatforms {
+		for _, profile := range found {
+			if profile.Platform == platform.name {
+				profiles = append(profiles, profile)
+			}
+		}
+	}
+	return profiles
+}
+
+// socialProfileFromURL returns the normalized profile a link points at.
+func socialProfileFromURL(rawURL string) (SocialProfile, bool) {
+	// Most links of a page are relative; only absolute ones can be profiles,
+	// so the rest are skipped without parsing them.
+	rawURL = strings.TrimSpace(rawURL)
+	scheme, _, ok := strings.Cut(rawURL, "://")
+	if !ok || (!strings.EqualFold(scheme, "http") && !strings.EqualFold(scheme, "https")) {
+		return SocialProfile{}, false
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return SocialProfile{}, false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+	if len(segments) == 0 {
+		return SocialProfile{}, false
+	}
+
+	for _, platform := range socialPlatforms {
+		for _, h := range platform.hosts {
+			if host != h {
+				continue
+			}
+			path := platform.profilePath(segments)
+			if path == "" {
+				return SocialProfile{}, false
+			}
+			return SocialProfile{Platform: platform.name, URL: "https://" + platform.host + path}, true
+		}
+	}
+	return SocialProfile{}, false
+}
+
+// handleProfile returns a profilePath func for platforms whose profiles live at
+// /<handle>, where handles are case-insensitive and reserved lists paths that
+// are not handles.
+func handleProfile(reserved ...string) func(segments []string) string {
+	return func(segments []string) string {
+		handle := strings.ToLower(strings.TrimPrefix(segments[0], "@"))
+		if handle == "" {
+			return ""
+		}
+		for _, r := range reserved {
+			if handle == r {
+				return ""
+			}
+		}
+		return "/" + handle
+	}
+}
@@ -0,0 +1,48 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+func TestExtractSocialProfiles(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+		<a href="https://www.youtube.com/channel/UCabcDEF">YouTube</a>
+		<a href="https://twitter.com/Example?lang=en">Twitter</a>
+		<a href="https://x.com/example/status/123">A post</a>
+		<a href="https://twitter.com/intent/tweet?text=hi">Share</a>
+		<a href="https://www.facebook.com/sharer/sharer.php?u=https://example.com">Share</a>
+		<a href="http://facebook.com/ExamplePage/">Facebook</a>
+		<a href="https://www.linkedin.com/company/example-inc/about/">LinkedIn</a>
+		<a href="https://www.instagram.com/p/Cx123/">A photo</a>
+		<a href=" HTTPS://instagram.com/example">Instagram</a>
+		<a href="https://www.tiktok.com/@Example">TikTok</a>
+		<a href="/about">About</a>
+	</body></html>`))
+	require.NoError(t, err)
+
+	profiles := ExtractSocialProfiles(parser.NewHTMLParser().ExtractElements(doc, "a"))
+
+	assert.Equal(t, []SocialProfile{
+		{Platform: "twitter", URL: "https://x.com/example"},
+		{Platform: "linkedin", URL: "https://www.linkedin.com/company/example-inc"},
+		{Platform: "facebook", URL: "https://www.facebook.com/examplepage"},
+		{Platform: "instagram", URL: "https://www.instagram.com/example"},
+		{Platform: "youtube", URL: "https://www.youtube.com/channel/UCabcDEF"},
+		{Platform: "tiktok", URL: "https://www.tiktok.com/@example"},
+	}, profiles, "Profiles should be normalized, de-duplicated and listed in platform order")
+}
+
+func TestExtractSocialProfiles_None(t *testing.T) {
+	assert.Empty(t, ExtractSocialProfiles([]parser.Element{
+		{Tag: "a", Attrs: map[string]string{"href": "https://example.com/twitter.com/example"}},
+		{Tag: "a", Attrs: map[string]string{"href": "https://www.youtube.com/watch?v=abc"}},
+		{Tag: "a", Attrs: map[string]string{"href": "https://linkedin.com/shareArticle?url=x"}},
+	}))
+}
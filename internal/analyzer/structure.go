@@ -0,0 +1,132 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// layoutCellTags are elements that tables of data rarely hold in a cell but
+// page layouts built from tables do.
+var layoutCellTags = map[string]bool{
+	"table": true, "div": true, "form": true, "nav": true, "ul": true, "ol": true,
+	"h1": true, "h2": true, "h3": true, "section": true, "article": true, "iframe": true,
+}
+
+// structureModule counts the page's tables and lists.
+func structureModule() AnalyzerModule {
+	return NewModule(ModuleStructure, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		structure := SummarizeStructure(doc)
+		findings := structureFindings(structure)
+		return ModuleResultFunc(func(a *WebpageAnalysis) {
+			a.Structure = structure
+			a.Findings = append(a.Findings, findings...)
+		}), nil
+	})
+}
+
+// SummarizeStructure counts the tables of doc, by whether they have headers
+// (<th> cells or a <thead>) and a caption, and its ordered, unordered and
+// definition lists. Tables marked role="presentation" or role="none" are
+// declared layout tables. Other tables without headers or a caption are
+// layout suspects when they have a single row or column, or cells that hold
+// nested tables or blocks such as <div> and <form>.
+func SummarizeStructure(doc *html.Node) *ContentStructure {
+	structure := &ContentStructure{}
+	stack := []*html.Node{doc}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "table":
+				countTable(&structure.Tables, n)
+			case "ol":
+				structure.OrderedLists++
+			case "ul":
+				structure.UnorderedLists++
+			case "dl":
+				structure.DefinitionLists++
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			stack = append(stack, child)
+		}
+	}
+	return structure
+}
+
+// countTable adds table to summary.
+func countTable(summary *TableSummary, table *html.Node) {
+	summary.Total++
+	var headers, caption, layoutCells bool
+	rows, columns := 0, 0
+	// Walk the table's own rows and cells, not those of nested tables.
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.Data {
+			case "caption":
+				caption = caption || collapsedText(c) != ""
+			case "thead", "tbody", "tfoot":
+				headers = headers || c.Data == "thead"
+				walk(c)
+			case "tr":
+				rows++
+				cells := 0
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type != html.ElementNode || (cell.Data != "td" && cell.Data != "th") {
+						continue
+					}
+					cells++
+					headers = headers || cell.Data == "th"
+					layoutCells = layoutCells || hasLayoutContent(cell)
+				}
+				columns = max(columns, cells)
+			}
+		}
+	}
+	walk(table)
+
+	if headers {
+		summary.WithHeaders++
+	}
+	if caption {
+		summary.WithCaption++
+	}
+	switch role := strings.ToLower(strings.TrimSpace(nodeAttr(table, "role"))); {
+	case role == "presentation" || role == "none":
+		summary.Presentational++
+	case !headers && !caption && (layoutCells || rows == 1 || columns == 1):
+		summary.LayoutSuspects++
+	}
+}
+
+// hasLayoutContent reports whether a table cell holds a nested table or a
+// block that data tables rarely contain.
+func hasLayoutContent(cell *html.Node) bool {
+	for c := cell.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (layoutCellTags[c.Data] || hasLayoutContent(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+// structureFindings reports suspected layout tables, which screen readers
+// announce as data tables and which make pages hard to restyle.
+func structureFindings(structure *ContentStructure) []Finding {
+	if structure.Tables.LayoutSuspects == 0 {
+		return nil
+	}
+	return []Finding{{
+		Type:     FindingLayoutTable,
+		Severity: SeverityLow,
+		Message:  fmt.Sprintf("%d of %d tables look like layout tables; use CSS for layout or mark them role=\"presentation\"", structure.Tables.LayoutSuspects, structure.Tables.Total),
+	}}
+}
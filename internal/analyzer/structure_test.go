@@ -0,0 +1,42 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func TestSummarizeStructure(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+<table>
+	<tr><td><div class="header">Logo</div></td></tr>
+	<tr><td>
+		<table><caption>Prices</caption>
+			<thead><tr><td>Item</td><td>Price</td></tr></thead>
+			<tr><td>Tea</td><td>2</td></tr>
+		</table>
+	</td></tr>
+</table>
+<table><tr><th>Name</th><th>Role</th></tr><tr><td>Ada</td><td>Engineer</td></tr></table>
+<table role="presentation"><tr><td><form></form></td></tr></table>
+<table><tr><td>Only</td><td>one</td><td>row</td></tr></table>
+<ol><li>One</li></ol>
+<ul><li>A<ul><li>B</li></ul></li></ul>
+<dl><dt>Term</dt><dd>Definition</dd></dl>
+</body></html>`))
+	require.NoError(t, err)
+
+	structure := SummarizeStructure(doc)
+	assert.Equal(t, TableSummary{Total: 5, WithHeaders: 2, WithCaption: 1, Presentational: 1, LayoutSuspects: 2}, structure.Tables,
+		"The outer layout table and the single-row table are suspects; the nested data table is not")
+	assert.Equal(t, 1, structure.OrderedLists)
+	assert.Equal(t, 2, structure.UnorderedLists)
+	assert.Equal(t, 1, structure.DefinitionLists)
+
+	findings := structureFindings(structure)
+	require.Len(t, findings, 1)
+	assert.Equal(t, FindingLayoutTable, findings[0].Type)
+}
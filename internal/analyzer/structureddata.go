@@ -0,0 +1,328 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Structured data formats.
+const (
+	FormatJSONLD    = "json-ld"
+	FormatMicrodata = "microdata"
+)
+
+// schemaRequiredProperties lists the properties search engines need to use
+// an item of each schema.org type, after Google's rich result requirements.
+// Subtypes are listed on their own since types are matched exactly.
+var schemaRequiredProperties = map[string][]string{
+	"Product":        {"name", "offers"},
+	"Offer":          {"price", "priceCurrency"},
+	"Article":        {"headline", "datePublished"},
+	"NewsArticle":    {"headline", "datePublished"},
+	"BlogPosting":    {"headline", "datePublished"},
+	"Event":          {"name", "startDate", "location"},
+	"Recipe":         {"name", "image"},
+	"Review":         {"itemReviewed", "reviewRating", "author"},
+	"Organization":   {"name"},
+	"LocalBusiness":  {"name", "address"},
+	"Person":         {"name"},
+	"BreadcrumbList": {"itemListElement"},
+	"FAQPage":        {"mainEntity"},
+	"VideoObject":    {"name", "thumbnailUrl", "uploadDate"},
+	"JobPosting":     {"title", "description", "datePosted", "hiringOrganization"},
+}
+
+// schemaItem is a structured data item: its properties keyed by name, with
+// "@type" holding its types. Values are strings, numbers, nested items or
+// lists of those, as decoded from JSON.
+type schemaItem map[string]interface{}
+
+// structuredDataModule summarizes the page's schema.org items.
+func structuredDataModule() AnalyzerModule {
+	return NewModule(ModuleStructuredData, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		data := SummarizeStructuredData(doc)
+		findings := structuredDataFindings(data)
+		return ModuleResultFunc(func(a *WebpageAnalysis) {
+			a.StructuredData = data
+			a.Findings = append(a.Findings, findings...)
+		}), nil
+	})
+}
+
+// SummarizeStructuredData lists the top-level schema.org items of doc, from
+// JSON-LD scripts and microdata, with the required properties each lacks.
+// Nested items, such as a Product's Offer, are checked too but not counted
+// in Types.
+func SummarizeStructuredData(doc *html.Node) *StructuredData {
+	items, invalid := extractSchemaItems(doc)
+	data := &StructuredData{Types: make(map[string]int), InvalidJSONLD: invalid}
+	for _, it := range items {
+		for _, t := range it.item.types() {
+			data.Types[t]++
+			data.Items = append(data.Items, SchemaItem{Type: t, Format: it.format, Missing: it.item.missing(t)})
+		}
+	}
+	return data
+}
+
+// formattedItem is a top-level item and the format it was found in.
+type formattedItem struct {
+	item   schemaItem
+	format string
+}
+
+// extractSchemaItems returns the top-level items of doc's JSON-LD scripts
+// and microdata, in document order, and the number of JSON-LD scripts that
+// could not be decoded.
+func extractSchemaItems(doc *html.Node) (items []formattedItem, invalid int) {
+	stack := []*html.Node{doc}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if n.Type == html.ElementNode {
+			switch {
+			case n.Data == "script" && strings.EqualFold(strings.TrimSpace(nodeAttr(n, "type")), "application/ld+json"):
+				decoded, ok := decodeJSONLD(nodeText(n))
+				if !ok {
+					invalid++
+				}
+				for _, item := range decoded {
+					items = append(items, formattedItem{item: item, format: FormatJSONLD})
+				}
+				continue
+			case hasAttr(n, "itemscope") && !hasAttr(n, "itemprop"):
+				items = append(items, formattedItem{item: microdataItem(n), format: FormatMicrodata})
+			}
+		}
+		// Push children last to first so that they are visited in order.
+		for child := n.LastChild; child != nil; child = child.PrevSibling {
+			stack = append(stack, child)
+		}
+	}
+	return items, invalid
+}
+
+// decodeJSONLD decodes a JSON-LD script into its top-level items, expanding
+// arrays and @graph containers.
+func decodeJSONLD(text string) ([]schemaItem, bool) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return nil, false
+	}
+	var items []schemaItem
+	var collect func(v interface{})
+	collect = func(v interface{}) {
+		switch v := v.(type) {
+		case []interface{}:
+			for _, elem := range v {
+				collect(elem)
+			}
+		case map[string]interface{}:
+			if graph, ok := v["@graph"]; ok {
+				collect(graph)
+				return
+			}
+			items = append(items, schemaItem(v))
+		}
+	}
+	collect(value)
+	return items, true
+}
+
+// microdataItem reads the item whose itemscope is on n. Properties of nested
+// items belong to those items, which become values of their own itemprop.
+func microdataItem(n *html.Node) schemaItem {
+	item := schemaItem{}
+	if itemType := strings.Fields(nodeAttr(n, "itemtype")); len(itemType) > 0 {
+		types := make([]interface{}, len(itemType))
+		for i, t := range itemType {
+			types[i] = t
+		}
+		item["@type"] = types
+	}
+	var walk func(parent *html.Node)
+	walk = func(parent *html.Node) {
+		for c := parent.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			props := strings.Fields(nodeAttr(c, "itemprop"))
+			if hasAttr(c, "itemscope") {
+				if len(props) > 0 {
+					nested := microdataItem(c)
+					for _, prop := range props {
+						item.add(prop, map[string]interface{}(nested))
+					}
+				}
+				continue
+			}
+			for _, prop := range props {
+				item.add(prop, microdataValue(c))
+			}
+			walk(c)
+		}
+	}
+	walk(n)
+	return item
+}
+
+// add appends value to the property name, turning it into a list when it
+// already has a value.
+func (item schemaItem) add(name string, value interface{}) {
+	switch existing := item[name].(type) {
+	case nil:
+		item[name] = value
+	case []interface{}:
+		item[name] = append(existing, value)
+	default:
+		item[name] = []interface{}{existing, value}
+	}
+}
+
+// microdataValue returns the value of a microdata property element.
+func microdataValue(n *html.Node) string {
+	switch n.Data {
+	case "meta":
+		return nodeAttr(n, "content")
+	case "a", "area", "link":
+		return nodeAttr(n, "href")
+	case "img", "audio", "video", "source", "embed", "iframe":
+		return nodeAttr(n, "src")
+	case "object":
+		return nodeAttr(n, "data")
+	case "time":
+		if hasAttr(n, "datetime") {
+			return nodeAttr(n, "datetime")
+		}
+	case "data", "meter":
+		if hasAttr(n, "value") {
+			return nodeAttr(n, "value")
+		}
+	}
+	if hasAttr(n, "content") {
+		return nodeAttr(n, "content")
+	}
+	return strings.Join(strings.Fields(nodeText(n)), " ")
+}
+
+// types returns the item's schema.org types without their vocabulary prefix.
+func (item schemaItem) types() []string {
+	var types []string
+	for _, v := range asList(item["@type"]) {
+		if t, ok := v.(string); ok {
+			t = strings.TrimPrefix(strings.TrimPrefix(t, "http://schema.org/"), "https://schema.org/")
+			if t = strings.TrimSpace(t); t != "" {
+				types = append(types, t)
+			}
+		}
+	}
+	return types
+}
+
+// missing returns the properties required of an item of type t that it does
+// not have, including those missing from its nested items, named with their
+// path, e.g. "offers.price".
+func (item schemaItem) missing(t string) []string {
+	var missing []string
+	for _, prop := range schemaRequiredProperties[t] {
+		if !hasValue(item[prop]) {
+			missing = append(missing, prop)
+		}
+	}
+	props := make([]string, 0, len(item))
+	for prop := range item {
+		props = append(props, prop)
+	}
+	sort.Strings(props) // For a stable order.
+	for _, prop := range props {
+		for _, v := range asList(item[prop]) {
+			nested, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, nestedType := range schemaItem(nested).types() {
+				for _, m := range schemaItem(nested).missing(nestedType) {
+					missing = appendUnique(missing, prop+"."+m)
+				}
+			}
+		}
+	}
+	return missing
+}
+
+// hasValue reports whether a property value is present and not empty.
+func hasValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case string:
+		return strings.TrimSpace(v) != ""
+	case []interface{}:
+		for _, elem := range v {
+			if hasValue(elem) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// asList returns v as a list, wrapping a single value.
+func asList(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	}
+	return []interface{}{v}
+}
+
+// structuredDataFindings reports items that lack required properties.
+func structuredDataFindings(data *StructuredData) []Finding {
+	var findings []Finding
+	for _, item := range data.Items {
+		if len(item.Missing) == 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			Type:     FindingStructuredDataMissing,
+			Severity: SeverityLow,
+			Message:  fmt.Sprintf("%s structured data is missing required properties: %s", item.Type, strings.Join(item.Missing, ", ")),
+			Evidence: item.Format,
+		})
+	}
+	return findings
+}
+
+// nodeAttr returns the value of n's attribute key, or "" if absent.
+func nodeAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// scriptText returns the text under n, unchanged.
+func nodeText(n *html.Node) string {
+	var text strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+	return text.String()
+}
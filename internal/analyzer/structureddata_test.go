@@ -0,0 +1,74 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func summarizeStructuredDataIn(t *testing.T, page string) *StructuredData {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(page))
+	require.NoError(t, err)
+	return SummarizeStructuredData(doc)
+}
+
+func TestSummarizeStructuredData_JSONLD(t *testing.T) {
+	data := summarizeStructuredDataIn(t, `<html><head>
+<script type="application/ld+json">
+{"@context": "https://schema.org", "@graph": [
+	{"@type": "Organization", "name": "Example"},
+	{"@type": "Product", "name": "Widget", "offers": {"@type": "Offer", "price": "9.99"}}
+]}
+</script>
+<script type="application/ld+json">[{"@type": "https://schema.org/NewsArticle", "headline": "News"}]</script>
+<script type="application/ld+json">{"@type": "Article",</script>
+</head></html>`)
+
+	assert.Equal(t, map[string]int{"Organization": 1, "Product": 1, "NewsArticle": 1}, data.Types)
+	assert.Equal(t, []SchemaItem{
+		{Type: "Organization", Format: FormatJSONLD},
+		{Type: "Product", Format: FormatJSONLD, Missing: []string{"offers.priceCurrency"}},
+		{Type: "NewsArticle", Format: FormatJSONLD, Missing: []string{"datePublished"}},
+	}, data.Items)
+	assert.Equal(t, 1, data.InvalidJSONLD)
+}
+
+func TestSummarizeStructuredData_Microdata(t *testing.T) {
+	data := summarizeStructuredDataIn(t, `<div itemscope itemtype="https://schema.org/Product">
+	<h1 itemprop="name">Widget</h1>
+	<div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+		<meta itemprop="priceCurrency" content="EUR">
+		<span itemprop="price" content="9.99">9,99 €</span>
+	</div>
+	<div itemprop="review" itemscope itemtype="https://schema.org/Review">
+		<span itemprop="name">Nice</span>
+	</div>
+</div>
+<article itemscope itemtype="https://schema.org/BlogPosting">
+	<h2 itemprop="headline"> </h2>
+	<time itemprop="datePublished" datetime="2024-05-01">May 1</time>
+</article>`)
+
+	assert.Equal(t, map[string]int{"Product": 1, "BlogPosting": 1}, data.Types, "Nested items should not be counted")
+	assert.Equal(t, []SchemaItem{
+		{Type: "Product", Format: FormatMicrodata, Missing: []string{"review.itemReviewed", "review.reviewRating", "review.author"}},
+		{Type: "BlogPosting", Format: FormatMicrodata, Missing: []string{"headline"}},
+	}, data.Items)
+}
+
+func TestStructuredDataModule_ReportsFindings(t *testing.T) {
+	page := `<script type="application/ld+json">{"@type": "Product", "offers": {"@type": "Offer", "price": "1", "priceCurrency": "USD"}}</script>`
+	service := NewService(WithHTTPClient(&mockHTTPClient{response: page}))
+
+	analysis, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://example.com", Modules: []string{ModuleStructuredData}})
+	require.NoError(t, err)
+	require.NotNil(t, analysis.StructuredData)
+	require.Len(t, analysis.Findings, 1)
+	assert.Equal(t, FindingStructuredDataMissing, analysis.Findings[0].Type)
+	assert.Equal(t, "Product structured data is missing required properties: name", analysis.Findings[0].Message)
+}
@@ -0,0 +1,128 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"webpage-analyzer/internal/worker"
+)
+
+// Limits on the sub-requests one analysis sends on top of fetching the page,
+// such as link probes.
+const (
+	// MaxSubrequests bounds the sub-requests of one analysis, across modules.
+	MaxSubrequests = 100
+	// SubrequestTimeout is how long after the analysis starts sub-requests
+	// may still be sent.
+	SubrequestTimeout = 30 * time.Second
+)
+
+type subrequestsKey struct{}
+
+// subrequests is the sub-request budget of one analysis. Fan-outs run on the
+// shared worker pool, so that probing a page with many links waits its turn
+// like any other task instead of starting goroutines of its own, and the
+// budget's cap and deadline keep one analysis from monopolizing the pool.
+type subrequests struct {
+	pool      *worker.WorkerPool // Nil runs fan-outs on goroutines of their own.
+	deadline  time.Time
+	remaining atomic.Int64
+}
+
+// withSubrequests returns a context carrying a new sub-request budget for an
+// analysis started at start.
+func withSubrequests(ctx context.Context, pool *worker.WorkerPool, start time.Time) context.Context {
+	budget := &subrequests{pool: pool, deadline: start.Add(SubrequestTimeout)}
+	budget.remaining.Store(MaxSubrequests)
+	return context.WithValue(ctx, subrequestsKey{}, budget)
+}
+
+// subrequestsFrom returns the sub-request budget of ctx, or a fresh one when
+// a module runs outside an analysis.
+func subrequestsFrom(ctx context.Context) *subrequests {
+	if budget, ok := ctx.Value(subrequestsKey{}).(*subrequests); ok {
+		return budget
+	}
+	return withSubrequests(ctx, nil, time.Now()).Value(subrequestsKey{}).(*subrequests)
+}
+
+// take reserves up to n sub-requests and returns how many it got.
+func (s *subrequests) take(n int) int {
+	for {
+		remaining := s.remaining.Load()
+		granted := min(int64(n), remaining)
+		if s.remaining.CompareAndSwap(remaining, remaining-granted) {
+			return int(granted)
+		}
+	}
+}
+
+// errSubrequestsSpent is the error of a sub-request the analysis has no
+// budget or time left for.
+var errSubrequestsSpent = errors.New("the analysis has no sub-requests left")
+
+// one reserves a single sub-request, for modules that send their requests one
+// at a time. The returned context ends at the budget's deadline, or after
+// timeout if that comes first. It fails with errSubrequestsSpent, and no
+// request should be sent, when the budget or its time has run out.
+func (s *subrequests) one(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc, error) {
+	if time.Now().After(s.deadline) || s.take(1) == 0 {
+		return nil, nil, errSubrequestsSpent
+	}
+	ctx, cancel := context.WithDeadline(ctx, s.deadline)
+	if timeout <= 0 {
+		return ctx, cancel, nil
+	}
+	ctx, cancelTimeout := context.WithTimeout(ctx, timeout)
+	return ctx, func() { cancelTimeout(); cancel() }, nil
+}
+
+// fanOut calls fn for items 0 to n-1, at most concurrency at a time, and
+// returns once every call has returned. Items beyond the analysis' remaining
+// budget are not called; nor are items reached after its deadline, when fn's
+// context ends. fn reports through its own variables.
+//
+// The calling goroutine works through the items too, so a fan-out started
+// from a pool task finishes even when every other worker is busy; helpers the
+// pool only starts after the items ran out return at once.
+func (s *subrequests) fanOut(ctx context.Context, n, concurrency int, fn func(ctx context.Context, i int)) {
+	n = s.take(n)
+	if n == 0 {
+		return
+	}
+	ctx, cancel := context.WithDeadline(ctx, s.deadline)
+	defer cancel()
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	wg.Add(n)
+	work := func() {
+		for {
+			i := int(next.Add(1) - 1)
+			if i >= n {
+				return
+			}
+			func() {
+				defer wg.Done()
+				if ctx.Err() == nil {
+					fn(ctx, i)
+				}
+			}()
+		}
+	}
+
+	for range min(concurrency, n) - 1 {
+		if s.pool == nil {
+			go work()
+			continue
+		}
+		if !s.pool.TrySubmit(func() error { work(); return nil }) {
+			break // The pool is busy; the caller still works through every item.
+		}
+	}
+	work()
+	wg.Wait()
+}
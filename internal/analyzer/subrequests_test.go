@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/worker"
+)
+
+func TestSubrequestsFanOutSharesCap(t *testing.T) {
+	pool := worker.NewWorkerPool(4)
+	defer pool.Shutdown()
+	ctx := withSubrequests(context.Background(), pool, time.Now())
+
+	var calls atomic.Int64
+	count := func(ctx context.Context, i int) { calls.Add(1) }
+	subrequestsFrom(ctx).fanOut(ctx, 80, 5, count)
+	subrequestsFrom(ctx).fanOut(ctx, 80, 5, count)
+
+	assert.Equal(t, int64(MaxSubrequests), calls.Load(), "Fan-outs of one analysis should share its cap")
+}
+
+func TestSubrequestsFanOutFromBusyPool(t *testing.T) {
+	pool := worker.NewWorkerPool(1)
+	defer pool.Shutdown()
+	ctx := withSubrequests(context.Background(), pool, time.Now())
+
+	// The only worker runs the fan-out itself, so its helpers never start.
+	var calls atomic.Int64
+	done := make(chan error, 1)
+	go func() {
+		done <- pool.SubmitAndWait(func() error {
+			subrequestsFrom(ctx).fanOut(ctx, 10, 5, func(ctx context.Context, i int) { calls.Add(1) })
+			return nil
+		})
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("fanOut() should not wait for helpers the pool has no worker for")
+	}
+	assert.Equal(t, int64(10), calls.Load())
+}
+
+func TestSubrequestsFanOutStopsAtDeadline(t *testing.T) {
+	ctx := withSubrequests(context.Background(), nil, time.Now().Add(-SubrequestTimeout))
+
+	var calls atomic.Int64
+	subrequestsFrom(ctx).fanOut(ctx, 10, 5, func(ctx context.Context, i int) { calls.Add(1) })
+	assert.Zero(t, calls.Load(), "No sub-requests should be sent after the shared deadline")
+}
+
+func TestSubrequestsOne(t *testing.T) {
+	ctx := withSubrequests(context.Background(), nil, time.Now())
+	budget := subrequestsFrom(ctx)
+	budget.take(MaxSubrequests - 1)
+
+	reqCtx, cancel, err := budget.one(ctx, time.Second)
+	require.NoError(t, err)
+	defer cancel()
+	deadline, ok := reqCtx.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond, "The timeout should bound the request")
+
+	_, _, err = budget.one(ctx, time.Second)
+	assert.ErrorIs(t, err, errSubrequestsSpent, "No sub-request should be granted beyond the cap")
+
+	late := subrequestsFrom(withSubrequests(context.Background(), nil, time.Now().Add(-SubrequestTimeout)))
+	_, _, err = late.one(context.Background(), time.Second)
+	assert.ErrorIs(t, err, errSubrequestsSpent, "No sub-request should be granted after the shared deadline")
+}
@@ -0,0 +1,328 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+	"golang.org/x/text/unicode/norm"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// Reasons a link is reported in SuspiciousLink.Reason.
+const (
+	SuspiciousHomograph   = "homograph"    // An internationalized host that reads as a protected domain.
+	SuspiciousMixedScript = "mixed_script" // A host label mixing scripts, such as Latin and Cyrillic.
+	SuspiciousTyposquat   = "typosquat"    // A domain a typo or a lookalike ASCII letter away from a protected domain.
+)
+
+// DefaultProtectedDomains are well-known brands whose domains phishing pages
+// imitate. Deployments can replace them with their own through
+// NewSuspiciousLinksModule.
+var DefaultProtectedDomains = []string{
+	"google.com", "youtube.com", "gmail.com", "facebook.com", "instagram.com", "whatsapp.com",
+	"apple.com", "icloud.com", "microsoft.com", "live.com", "outlook.com", "office.com",
+	"amazon.com", "paypal.com", "netflix.com", "linkedin.com", "twitter.com", "github.com",
+	"dropbox.com", "adobe.com", "ebay.com", "yahoo.com", "coinbase.com", "binance.com",
+	"chase.com", "wellsfargo.com", "bankofamerica.com", "dhl.com", "fedex.com",
+}
+
+// confusables maps letters of other scripts, and unusual Latin letters, to
+// the ASCII letters they are mistaken for. It covers the characters
+// homograph attacks use in practice, not all of Unicode's confusables list.
+var confusables = map[rune]rune{
+	// Cyrillic.
+	'а': 'a', 'в': 'b', 'с': 'c', 'ԁ': 'd', 'е': 'e', 'ё': 'e', 'һ': 'h', 'і': 'i', 'ї': 'i', 'ј': 'j',
+	'к': 'k', 'ӏ': 'l', 'м': 'm', 'п': 'n', 'о': 'o', 'р': 'p', 'ԛ': 'q', 'г': 'r', 'ѕ': 's', 'т': 't',
+	'ц': 'u', 'ѵ': 'v', 'ԝ': 'w', 'х': 'x', 'у': 'y', 'з': '3',
+	// Greek.
+	'α': 'a', 'β': 'b', 'ϲ': 'c', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p',
+	'τ': 't', 'υ': 'u', 'χ': 'x', 'γ': 'y', 'ω': 'w',
+	// Armenian.
+	'օ': 'o', 'ս': 'u', 'հ': 'h', 'ո': 'n', 'ց': 'g', 'զ': 'q',
+	// Latin lookalikes.
+	'ı': 'i', 'ɡ': 'g', 'ɑ': 'a', 'ǀ': 'l', 'ſ': 's',
+}
+
+// asciiConfusables are sequences of ASCII characters that read as others,
+// such as "rn" for "m" in "rnicrosoft".
+var asciiConfusables = strings.NewReplacer("rn", "m", "vv", "w", "cl", "d", "0", "o", "1", "l", "5", "s")
+
+const (
+	// minTypoLength is the shortest domain name checked for single typos;
+	// shorter names are a typo away from too many genuine ones.
+	minTypoLength = 6
+	// minDoubleTypoLength is the shortest domain name checked for two typos.
+	minDoubleTypoLength = 10
+)
+
+// scripts are the Unicode scripts told apart when checking host labels for
+// mixed scripts; letters of other scripts count as "other".
+var scripts = []struct {
+	name  string
+	table *unicode.RangeTable
+}{
+	{"Latin", unicode.Latin}, {"Cyrillic", unicode.Cyrillic}, {"Greek", unicode.Greek},
+	{"Armenian", unicode.Armenian}, {"Han", unicode.Han}, {"Hiragana", unicode.Hiragana},
+	{"Katakana", unicode.Katakana}, {"Hangul", unicode.Hangul}, {"Bopomofo", unicode.Bopomofo},
+	{"Arabic", unicode.Arabic}, {"Hebrew", unicode.Hebrew}, {"Thai", unicode.Thai},
+	{"Devanagari", unicode.Devanagari}, {"Cherokee", unicode.Cherokee},
+}
+
+// scriptCombinations are the sets of scripts a label may mix, those of
+// Chinese, Japanese and Korean writing with Latin, as in Unicode's highly
+// restrictive profile.
+var scriptCombinations = []map[string]bool{
+	{"Latin": true, "Han": true, "Hiragana": true, "Katakana": true},
+	{"Latin": true, "Han": true, "Bopomofo": true},
+	{"Latin": true, "Han": true, "Hangul": true},
+}
+
+// suspiciousLinksModule checks the page's links for hosts that imitate
+// protected domains.
+type suspiciousLinksModule struct {
+	htmlParser parser.HTMLParser
+	protected  []string
+}
+
+// NewSuspiciousLinksModule creates the suspicious_links module checking links
+// against the protected domains, such as DefaultProtectedDomains.
+func NewSuspiciousLinksModule(htmlParser parser.HTMLParser, protected []string) AnalyzerModule {
+	return &suspiciousLinksModule{htmlParser: htmlParser, protected: protected}
+}
+
+func (m *suspiciousLinksModule) Name() string { return ModuleSuspiciousLinks }
+
+// Analyze checks the page's links.
+func (m *suspiciousLinksModule) Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error) {
+	links := FindSuspiciousLinks(info.URL, pageFrom(ctx, doc, m.htmlParser).LinkURLs(info.URL), m.protected)
+	findings := suspiciousLinkFindings(links)
+	return ModuleResultFunc(func(a *WebpageAnalysis) {
+		a.SuspiciousLinks = links
+		a.Findings = append(a.Findings, findings...)
+	}), nil
+}
+
+// FindSuspiciousLinks returns the links whose host imitates one of the
+// protected domains. Internationalized hosts, written in Unicode or as
+// punycode, are homographs when they read as a protected domain once
+// confusable characters are replaced by the ASCII letters they resemble, and
+// are reported anyway when a label mixes scripts. Other hosts are typosquats
+// when their domain name is a typo of a protected one, or reads as one once
+// lookalike ASCII such as "rn" for "m" is replaced. Links to the page's own
+// host are skipped; each host is reported once.
+func FindSuspiciousLinks(pageURL string, links []string, protected []string) []SuspiciousLink {
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	brands := make(map[string]bool, len(protected))
+	for _, domain := range protected {
+		brands[strings.ToLower(domain)] = true
+	}
+
+	seen := make(map[string]bool)
+	var suspicious []SuspiciousLink
+	for _, link := range links {
+		u, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+		if host == "" || strings.EqualFold(host, page.Hostname()) {
+			continue
+		}
+
+		unicodeHost, err := idna.Punycode.ToUnicode(host)
+		if err != nil {
+			unicodeHost = host
+		}
+		asciiHost, err := idna.Punycode.ToASCII(unicodeHost)
+		if err != nil || seen[asciiHost] {
+			continue
+		}
+		seen[asciiHost] = true
+
+		result := SuspiciousLink{URL: link, Host: asciiHost}
+		domain := registrableDomain(asciiHost)
+		if asciiHost != unicodeHost {
+			result.Unicode = unicodeHost
+			if lookalike := registrableDomain(skeleton(unicodeHost)); brands[lookalike] && lookalike != domain {
+				result.Reason, result.Resembles = SuspiciousHomograph, lookalike
+			} else if mixedScript(unicodeHost) {
+				result.Reason = SuspiciousMixedScript
+			}
+		}
+		if result.Reason == "" && !brands[domain] {
+			if brand := typosquatOf(domain, protected); brand != "" {
+				result.Reason, result.Resembles = SuspiciousTyposquat, brand
+			}
+		}
+		if result.Reason != "" {
+			suspicious = append(suspicious, result)
+		}
+	}
+	return suspicious
+}
+
+// typosquatOf returns the protected domain whose name, the part before its
+// public suffix, domain's name is a typo of, or reads as once lookalike ASCII
+// is replaced. Names of minTypoLength or more may be one insertion,
+// deletion, substitution or transposition away; names of
+// minDoubleTypoLength or more two. Names that equal a protected one, such
+// as another country's domain of the brand, are not typos.
+func typosquatOf(domain string, protected []string) string {
+	name := domainName(domain)
+	if name == "" {
+		return ""
+	}
+	for _, brand := range protected {
+		brandName := domainName(strings.ToLower(brand))
+		if brandName == "" || name == brandName {
+			continue
+		}
+		distance := editDistance(name, brandName)
+		switch {
+		case asciiConfusables.Replace(name) == brandName:
+			return brand
+		case len(brandName) >= minDoubleTypoLength && distance <= 2:
+			return brand
+		case len(brandName) >= minTypoLength && distance == 1:
+			return brand
+		}
+	}
+	return ""
+}
+
+// domainName returns the part of a registrable domain before its public
+// suffix, such as "example" for "example.co.uk".
+func domainName(domain string) string {
+	suffix, _ := publicsuffix.PublicSuffix(domain)
+	name, _ := strings.CutSuffix(domain, "."+suffix)
+	if strings.Contains(name, ".") {
+		return ""
+	}
+	return name
+}
+
+// editDistance returns the optimal string alignment distance between a and
+// b: the fewest insertions, deletions, substitutions and transpositions of
+// adjacent bytes that turn one into the other.
+func editDistance(a, b string) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+// skeleton replaces the confusable characters of host with the ASCII
+// letters they resemble and strips diacritics from Latin letters.
+func skeleton(host string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(host) {
+		if ascii, ok := confusables[r]; ok {
+			r = ascii
+		} else if r > unicode.MaxASCII && unicode.Is(unicode.Latin, r) {
+			r = stripDiacritic(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// stripDiacritic returns the base letter of an accented Latin letter, such
+// as 'a' for 'á', or r itself.
+func stripDiacritic(r rune) rune {
+	if base := []rune(norm.NFD.String(string(r)))[0]; base <= unicode.MaxASCII {
+		return base
+	}
+	return r
+}
+
+// mixedScript reports whether a label of host mixes letters of scripts that
+// are not written together.
+func mixedScript(host string) bool {
+	for _, label := range strings.Split(host, ".") {
+		used := make(map[string]bool)
+		for _, r := range label {
+			if !unicode.IsLetter(r) {
+				continue
+			}
+			name := "other"
+			for _, s := range scripts {
+				if unicode.Is(s.table, r) {
+					name = s.name
+					break
+				}
+			}
+			used[name] = true
+		}
+		if len(used) > 1 && !allowedCombination(used) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedCombination reports whether used is a subset of one of the
+// scriptCombinations.
+func allowedCombination(used map[string]bool) bool {
+	for _, allowed := range scriptCombinations {
+		subset := true
+		for name := range used {
+			subset = subset && allowed[name]
+		}
+		if subset {
+			return true
+		}
+	}
+	return false
+}
+
+// suspiciousLinkFindings reports suspicious links. Homographs and typosquats
+// of a protected domain are a strong sign of phishing and rate medium; mixed
+// scripts alone rate low.
+func suspiciousLinkFindings(links []SuspiciousLink) []Finding {
+	var findings []Finding
+	for _, link := range links {
+		finding := Finding{
+			Type:     FindingSuspiciousLink,
+			Severity: SeverityLow,
+			Message:  fmt.Sprintf("Link host %s mixes scripts, a common way to imitate other domains", link.Unicode),
+			Evidence: link.URL,
+		}
+		switch link.Reason {
+		case SuspiciousHomograph:
+			finding.Severity = SeverityMedium
+			finding.Message = fmt.Sprintf("Link host %s (%s) imitates %s with lookalike characters", link.Unicode, link.Host, link.Resembles)
+		case SuspiciousTyposquat:
+			finding.Severity = SeverityMedium
+			finding.Message = fmt.Sprintf("Link host %s looks like a typo of %s", link.Host, link.Resembles)
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
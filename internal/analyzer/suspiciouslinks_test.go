@@ -0,0 +1,95 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/parser"
+)
+
+func TestFindSuspiciousLinks(t *testing.T) {
+	links := []string{
+		"https://example.com/about",        // The page's own host.
+		"https://www.paypal.com/signin",    // The real domain.
+		"https://xn--pypal-4ve.com/login",  // A Cyrillic "а", as punycode.
+		"https://xn--pypal-4ve.com/other",  // The same host again.
+		"https://аррӏе.com/",               // All Cyrillic, in Unicode.
+		"https://xn--80ak6aa92e.com/",      // The same host as punycode.
+		"https://paypál.com/",              // A Latin diacritic.
+		"https://www.xn--mnchen-3ya.de/",   // münchen.de, a genuine IDN.
+		"https://shop.xn--exmple-cua.org/", // exämple.org, not a protected domain.
+		"https://xn--blg-bzc.com/",         // "blοg" with a Greek omicron.
+		"https://xn--wgv71a119e.jp/",       // Han only.
+	}
+
+	suspicious := FindSuspiciousLinks("https://example.com/", links, DefaultProtectedDomains)
+	assert.Equal(t, []SuspiciousLink{
+		{URL: "https://xn--pypal-4ve.com/login", Host: "xn--pypal-4ve.com", Unicode: "pаypal.com", Reason: SuspiciousHomograph, Resembles: "paypal.com"},
+		{URL: "https://аррӏе.com/", Host: "xn--80ak6aa92e.com", Unicode: "аррӏе.com", Reason: SuspiciousHomograph, Resembles: "apple.com"},
+		{URL: "https://paypál.com/", Host: "xn--paypl-0qa.com", Unicode: "paypál.com", Reason: SuspiciousHomograph, Resembles: "paypal.com"},
+		{URL: "https://xn--blg-bzc.com/", Host: "xn--blg-bzc.com", Unicode: "blοg.com", Reason: SuspiciousMixedScript},
+	}, suspicious)
+}
+
+func TestFindSuspiciousLinks_Typosquats(t *testing.T) {
+	links := []string{
+		"https://accounts.google.com/", // A protected domain's subdomain.
+		"https://www.google.co.uk/",    // The brand in another country.
+		"https://gooogle.com/",         // An insertion.
+		"https://login.paypla.com/",    // A transposition.
+		"https://rnicrosoft.com/",      // "rn" for "m".
+		"https://app1e.com/",           // "1" for "l" in a short name.
+		"https://apply.com/",           // Too short a name for a typo.
+		"https://bankofamerlca.net/",   // Two typos in a long name.
+		"https://example-shop.com/",    // Unrelated.
+		"https://foo.github.io/",       // Under a public suffix.
+	}
+
+	suspicious := FindSuspiciousLinks("https://example.com/", links, DefaultProtectedDomains)
+	assert.Equal(t, []SuspiciousLink{
+		{URL: "https://gooogle.com/", Host: "gooogle.com", Reason: SuspiciousTyposquat, Resembles: "google.com"},
+		{URL: "https://login.paypla.com/", Host: "login.paypla.com", Reason: SuspiciousTyposquat, Resembles: "paypal.com"},
+		{URL: "https://rnicrosoft.com/", Host: "rnicrosoft.com", Reason: SuspiciousTyposquat, Resembles: "microsoft.com"},
+		{URL: "https://app1e.com/", Host: "app1e.com", Reason: SuspiciousTyposquat, Resembles: "apple.com"},
+		{URL: "https://bankofamerlca.net/", Host: "bankofamerlca.net", Reason: SuspiciousTyposquat, Resembles: "bankofamerica.com"},
+	}, suspicious)
+
+	assert.Empty(t, FindSuspiciousLinks("https://example.com/", links, []string{"example.org"}), "Only protected domains should be matched")
+	assert.Equal(t, []SuspiciousLink{
+		{URL: "https://examp1e.org/", Host: "examp1e.org", Reason: SuspiciousTyposquat, Resembles: "example.org"},
+	}, FindSuspiciousLinks("https://example.com/", []string{"https://examp1e.org/"}, []string{"example.org"}))
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("paypal", "paypal"))
+	assert.Equal(t, 1, editDistance("paypla", "paypal"))
+	assert.Equal(t, 1, editDistance("gooogle", "google"))
+	assert.Equal(t, 2, editDistance("amazn", "amazon1"))
+	assert.Equal(t, 6, editDistance("", "google"))
+}
+
+func TestSuspiciousLinksModule_Findings(t *testing.T) {
+	page := `<a href="https://xn--pypal-4ve.com/login">Log in</a> <a href="https://xn--blg-bzc.com/">Blog</a>
+<a href="https://exampel.org/">Partner</a>`
+	service := NewService(WithHTTPClient(&mockHTTPClient{response: page}))
+
+	analysis, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://example.com", Modules: []string{ModuleSuspiciousLinks}})
+	require.NoError(t, err)
+	require.Len(t, analysis.Findings, 2)
+	assert.Equal(t, FindingSuspiciousLink, analysis.Findings[0].Type)
+	assert.Equal(t, SeverityMedium, analysis.Findings[0].Severity)
+	assert.Contains(t, analysis.Findings[0].Message, "paypal.com")
+	assert.Equal(t, SeverityLow, analysis.Findings[1].Severity)
+
+	registry := NewRegistry(NewSuspiciousLinksModule(parser.NewHTMLParser(), []string{"example.org"}))
+	service = NewService(WithHTTPClient(&mockHTTPClient{response: page}), WithRegistry(registry))
+	analysis, err = service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://example.com"})
+	require.NoError(t, err)
+	require.Len(t, analysis.SuspiciousLinks, 3)
+	assert.Equal(t, SuspiciousMixedScript, analysis.SuspiciousLinks[0].Reason, "PayPal is no longer protected")
+	assert.Equal(t, SuspiciousTyposquat, analysis.SuspiciousLinks[2].Reason)
+	assert.Equal(t, "example.org", analysis.SuspiciousLinks[2].Resembles)
+}
@@ -0,0 +1,325 @@
+package analyzer
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// Technology categories reported in Technology.Category.
+const (
+	CategoryCMS       = "cms"
+	CategoryEcommerce = "ecommerce"
+	CategoryFramework = "framework"
+	CategoryLibrary   = "library"
+)
+
+// Confidence, in percent, that a single signal of each kind gives. Several
+// distinct signals for one technology are combined in detectedTechnology.add.
+const (
+	confidenceGenerator = 100 // <meta name="generator">, set by the product itself.
+	confidenceVersion   = 100 // A version attribute such as Angular's ng-version.
+	confidenceID        = 90  // Root element ids, e.g. Next.js's __next.
+	confidencePath      = 80  // Well-known asset paths, e.g. /wp-content/.
+	confidenceAttr      = 80  // Framework-generated attributes, e.g. data-reactroot.
+	confidenceScript    = 70  // Script file names, which sites can rename or bundle.
+)
+
+// technologySignature describes how to recognize one technology. Name patterns
+// in ids and attrs match exactly, or by prefix when they end in "*".
+type technologySignature struct {
+	name        string
+	category    string
+	generator   *regexp.Regexp // Matches the generator meta tag; group 1, if any, is the version.
+	paths       []string       // Lower-cased substrings of script, stylesheet and image URLs.
+	scripts     *regexp.Regexp // Matches script file names; group 1, if any, is the version.
+	ids         []string       // Element ids.
+	attrs       []string       // Attribute names.
+	versionAttr string         // Attribute whose value is the version.
+	implies     []string       // Technologies this one is built on.
+}
+
+// technologySignatures are the technologies DetectTechnologies knows, in reporting order.
+var technologySignatures = []technologySignature{
+	{
+		name:      "WordPress",
+		category:  CategoryCMS,
+		generator: regexp.MustCompile(`(?i)^wordpress\s*([\d.]+)?`),
+		paths:     []string{"/wp-content/", "/wp-includes/", "api.w.org"},
+	},
+	{
+		name:      "Drupal",
+		category:  CategoryCMS,
+		generator: regexp.MustCompile(`(?i)^drupal\s*(\d+)?`),
+		paths:     []string{"/sites/default/files/", "/core/misc/drupal.js", "/misc/drupal.js"},
+		attrs:     []string{"data-drupal-*"},
+	},
+	{
+		name:      "Joomla",
+		category:  CategoryCMS,
+		generator: regexp.MustCompile(`(?i)^joomla!?\s*([\d.]+)?`),
+		paths:     []string{"/media/jui/", "/media/system/js/"},
+	},
+	{
+		name:      "Ghost",
+		category:  CategoryCMS,
+		generator: regexp.MustCompile(`(?i)^ghost\s*([\d.]+)?`),
+	},
+	{
+		name:      "Wix",
+		category:  CategoryCMS,
+		generator: regexp.MustCompile(`(?i)^wix\.com`),
+		paths:     []string{"static.wixstatic.com/", "static.parastorage.com/"},
+	},
+	{
+		name:     "Squarespace",
+		category: CategoryCMS,
+		paths:    []string{"static1.squarespace.com/", "assets.squarespace.com/"},
+	},
+	{
+		name:     "Shopify",
+		category: CategoryEcommerce,
+		paths:    []string{"cdn.shopify.com/"},
+		ids:      []string{"shopify-section-*"},
+	},
+	{
+		name:     "Next.js",
+		category: CategoryFramework,
+		paths:    []string{"/_next/static/"},
+		ids:      []string{"__next", "__NEXT_DATA__"},
+		implies:  []string{"React"},
+	},
+	{
+		name:     "Nuxt",
+		category: CategoryFramework,
+		paths:    []string{"/_nuxt/"},
+		ids:      []string{"__nuxt"},
+		implies:  []string{"Vue.js"},
+	},
+	{
+		name:      "Gatsby",
+		category:  CategoryFramework,
+		generator: regexp.MustCompile(`(?i)^gatsby\s*([\d.]+)?`),
+		ids:       []string{"___gatsby"},
+		implies:   []string{"React"},
+	},
+	{
+		name:     "React",
+		category: CategoryFramework,
+		scripts:  regexp.MustCompile(`(?i)^react(?:-dom)?(?:\.production|\.development)?(?:\.min)?\.js$`),
+		attrs:    []string{"data-reactroot", "data-reactid"},
+	},
+	{
+		name:     "Vue.js",
+		category: CategoryFramework,
+		scripts:  regexp.MustCompile(`(?i)^vue(?:\.global|\.runtime)?(?:\.prod)?(?:\.min)?\.js$`),
+		attrs:    []string{"data-v-*", "data-server-rendered"},
+	},
+	{
+		name:        "Angular",
+		category:    CategoryFramework,
+		attrs:       []string{"_nghost-*", "_ngcontent-*"},
+		versionAttr: "ng-version",
+	},
+	{
+		name:     "AngularJS",
+		category: CategoryFramework,
+		scripts:  regexp.MustCompile(`(?i)^angular(?:\.min)?\.js$`),
+		attrs:    []string{"ng-app", "data-ng-app", "ng-controller"},
+	},
+	{
+		name:     "jQuery",
+		category: CategoryLibrary,
+		scripts:  regexp.MustCompile(`(?i)^jquery(?:-([\d.]+?))?(?:\.slim)?(?:\.min)?\.js$`),
+	},
+}
+
+// detectedTechnology accumulates the signals found for one technology.
+type detectedTechnology struct {
+	Technology
+	missing int // Percent confidence still missing, 100 - Confidence.
+}
+
+// add records a signal, ignoring evidence already counted. Independent signals
+// combine so that each one closes part of the remaining doubt: two 80% signals
+// give 96%.
+func (d *detectedTechnology) add(confidence int, evidence, version string) {
+	for _, e := range d.Evidence {
+		if e == evidence {
+			return
+		}
+	}
+	d.Evidence = append(d.Evidence, evidence)
+	d.missing = d.missing * (100 - confidence) / 100
+	d.Confidence = 100 - d.missing
+	if d.Version == "" {
+		d.Version = version
+	}
+}
+
+// DetectTechnologies identifies the CMS, frameworks and libraries a page is
+// built with, from the generator meta tag, well-known asset paths, script file
+// names and framework-specific ids and attributes. Each technology is reported
+// with its confidence in percent and the evidence found; technologies implied
+// by another, such as React under Next.js, inherit its confidence.
+func DetectTechnologies(elements []parser.Element) []Technology {
+	detected := make(map[string]*detectedTechnology)
+	add := func(sig technologySignature, confidence int, evidence, version string) {
+		d, ok := detected[sig.name]
+		if !ok {
+			d = &detectedTechnology{Technology: Technology{Name: sig.name, Category: sig.category}, missing: 100}
+			detected[sig.name] = d
+		}
+		d.add(confidence, evidence, version)
+	}
+
+	for _, el := range elements {
+		te := newTechnologyElement(el)
+		for _, sig := range technologySignatures {
+			sig.match(te, add)
+		}
+	}
+
+	for _, sig := range technologySignatures {
+		d, ok := detected[sig.name]
+		if !ok {
+			continue
+		}
+		for _, name := range sig.implies {
+			for _, implied := range technologySignatures {
+				if implied.name == name {
+					add(implied, d.Confidence, "implied by "+sig.name, "")
+				}
+			}
+		}
+	}
+
+	var technologies []Technology
+	for _, sig := range technologySignatures {
+		if d, ok := detected[sig.name]; ok {
+			technologies = append(technologies, d.Technology)
+		}
+	}
+	return technologies
+}
+
+// technologyCandidate reports whether an element can carry any signal of
+// technologySignatures, so that the module only matches those rather than
+// every element of the page.
+func technologyCandidate(el parser.Element) bool {
+	if el.Tag == "meta" {
+		return true
+	}
+	for key, value := range el.Attrs {
+		if key == "src" || key == "href" {
+			return true
+		}
+		for _, sig := range technologySignatures {
+			if key == sig.versionAttr {
+				return true
+			}
+			for _, pattern := range sig.attrs {
+				if matchesName(pattern, key) {
+					return true
+				}
+			}
+			if key != "id" {
+				continue
+			}
+			for _, pattern := range sig.ids {
+				if matchesName(pattern, value) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// technologyElement is an element with what every signature matches against
+// read once: its lower-cased src and href and, for scripts, the file name.
+type technologyElement struct {
+	parser.Element
+	urls       [2]string // The lower-cased src and href.
+	scriptFile string
+}
+
+// newTechnologyElement reads the URLs of el.
+func newTechnologyElement(el parser.Element) technologyElement {
+	te := technologyElement{Element: el}
+	for i, attr := range []string{"src", "href"} {
+		te.urls[i] = strings.ToLower(el.Attr(attr))
+	}
+	if src := el.Attr("src"); el.Tag == "script" && src != "" {
+		if u, err := url.Parse(strings.TrimSpace(src)); err == nil {
+			te.scriptFile = path.Base(u.Path)
+		}
+	}
+	return te
+}
+
+// match reports every signal of the signature found on the element.
+func (sig technologySignature) match(el technologyElement, add func(technologySignature, int, string, string)) {
+	if sig.generator != nil && el.Tag == "meta" && strings.EqualFold(el.Attr("name"), "generator") {
+		content := strings.TrimSpace(el.Attr("content"))
+		if m := sig.generator.FindStringSubmatch(content); m != nil {
+			add(sig, confidenceGenerator, "generator: "+content, submatch(m, 1))
+		}
+	}
+
+	for i, value := range el.urls {
+		for _, p := range sig.paths {
+			if value != "" && strings.Contains(value, p) {
+				add(sig, confidencePath, "path: "+p, "")
+			}
+		}
+		// The script file name is read from src, and matched right after its paths.
+		if i == 0 && sig.scripts != nil && el.scriptFile != "" {
+			if m := sig.scripts.FindStringSubmatch(el.scriptFile); m != nil {
+				add(sig, confidenceScript, "script: "+el.scriptFile, submatch(m, 1))
+			}
+		}
+	}
+
+	if id := el.Attr("id"); id != "" {
+		for _, pattern := range sig.ids {
+			if matchesName(pattern, id) {
+				add(sig, confidenceID, "id: "+pattern, "")
+			}
+		}
+	}
+
+	for _, pattern := range sig.attrs {
+		for key := range el.Attrs {
+			if matchesName(pattern, key) {
+				add(sig, confidenceAttr, "attribute: "+pattern, "")
+				break
+			}
+		}
+	}
+	if sig.versionAttr != "" {
+		if version := el.Attr(sig.versionAttr); version != "" {
+			add(sig, confidenceVersion, "attribute: "+sig.versionAttr, version)
+		}
+	}
+}
+
+// matchesName matches an id or attribute name against a pattern, which is
+// exact unless it ends in "*".
+func matchesName(pattern, name string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(name, prefix)
+	}
+	return name == pattern
+}
+
+// submatch returns group i of a regexp match, or "" if it did not participate.
+func submatch(m []string, i int) string {
+	if i < len(m) {
+		return m[i]
+	}
+	return ""
+}
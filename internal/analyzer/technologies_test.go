@@ -0,0 +1,91 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+func detectTechnologiesIn(t *testing.T, page string) map[string]Technology {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(page))
+	require.NoError(t, err)
+
+	byName := make(map[string]Technology)
+	for _, tech := range DetectTechnologies(pageFrom(context.Background(), doc, parser.NewHTMLParser()).ElementsFunc(technologyCandidate)) {
+		byName[tech.Name] = tech
+	}
+	return byName
+}
+
+func TestDetectTechnologies_WordPress(t *testing.T) {
+	techs := detectTechnologiesIn(t, `<html><head>
+		<meta name="generator" content="WordPress 6.4.2">
+		<link rel="stylesheet" href="/wp-content/themes/x/style.css">
+		<script src="/wp-includes/js/jquery/jquery.min.js"></script>
+		<script src="/wp-content/plugins/y/app.js"></script>
+	</head><body></body></html>`)
+
+	require.Contains(t, techs, "WordPress")
+	wp := techs["WordPress"]
+	assert.Equal(t, CategoryCMS, wp.Category)
+	assert.Equal(t, "6.4.2", wp.Version)
+	assert.Equal(t, 100, wp.Confidence)
+	assert.Equal(t, []string{"generator: WordPress 6.4.2", "path: /wp-content/", "path: /wp-includes/"}, wp.Evidence,
+		"Repeated signals should be counted once")
+
+	require.Contains(t, techs, "jQuery")
+	assert.Equal(t, confidenceScript, techs["jQuery"].Confidence)
+}
+
+func TestDetectTechnologies_Frameworks(t *testing.T) {
+	techs := detectTechnologiesIn(t, `<html><head>
+		<script src="https://example.com/_next/static/chunks/main.js"></script>
+		<script src="https://code.jquery.com/jquery-3.7.1.min.js"></script>
+	</head><body>
+		<div id="__next"></div>
+		<app-root ng-version="17.0.3"><span _ngcontent-abc-c1>x</span></app-root>
+	</body></html>`)
+
+	assert.Equal(t, 98, techs["Next.js"].Confidence, "A path and an id should combine to 98%")
+	require.Contains(t, techs, "React", "Next.js should imply React")
+	assert.Equal(t, 98, techs["React"].Confidence)
+	assert.Equal(t, []string{"implied by Next.js"}, techs["React"].Evidence)
+
+	assert.Equal(t, "17.0.3", techs["Angular"].Version)
+	assert.Equal(t, 100, techs["Angular"].Confidence)
+	assert.Equal(t, "3.7.1", techs["jQuery"].Version)
+	assert.NotContains(t, techs, "WordPress")
+}
+
+func TestDetectTechnologies_None(t *testing.T) {
+	assert.Empty(t, detectTechnologiesIn(t, `<html><body><p>Plain page</p><script src="/app.js"></script></body></html>`))
+}
+
+func TestTechnologyCandidate(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+		<div id="main"><p class="x">Text</p><div data-v-1a2b>Vue</div></div>
+		<a href="/about">About</a>
+	</body></html>`))
+	require.NoError(t, err)
+
+	var tags []string
+	for _, el := range pageFrom(context.Background(), doc, parser.NewHTMLParser()).ElementsFunc(technologyCandidate) {
+		tags = append(tags, el.Tag)
+	}
+	assert.Equal(t, []string{"div", "a"}, tags, "Only elements that can carry a signal should be matched")
+}
+
+func TestTechnologySignatures_LowerCasePaths(t *testing.T) {
+	for _, sig := range technologySignatures {
+		for _, p := range sig.paths {
+			assert.Equal(t, strings.ToLower(p), p, "%s: paths are matched against lower-cased URLs", sig.name)
+		}
+	}
+}
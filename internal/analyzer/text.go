@@ -0,0 +1,33 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"webpage-analyzer/internal/logging"
+)
+
+// ExtractText fetches a webpage and returns its visible text, without running
+// the analysis modules.
+func (s *service) ExtractText(ctx context.Context, req TextRequest) (*TextExtraction, error) {
+	startTime := time.Now()
+	logging.FromContext(ctx).Info("Starting text extraction", "url", req.URL)
+
+	doc, _, err := s.fetchDocument(ctx, req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	text := s.htmlParser.ExtractVisibleText(doc)
+	extraction := &TextExtraction{
+		URL:            req.URL,
+		PageTitle:      s.htmlParser.ExtractPageTitle(doc),
+		Text:           text,
+		WordCount:      len(strings.Fields(text)),
+		ProcessingTime: time.Since(startTime).String(),
+	}
+	logging.FromContext(ctx).Info("Text extraction completed", "url", req.URL, "word_count", extraction.WordCount, "processing_time", extraction.ProcessingTime)
+
+	return extraction, nil
+}
@@ -0,0 +1,42 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/worker"
+)
+
+func TestExtractText_Success(t *testing.T) {
+	mockClient := &mockHTTPClient{
+		response: `<html><head><title>Docs</title><script>init()</script></head><body>
+			<nav><a href="/">Home</a></nav>
+			<h1>Getting started</h1>
+			<p>Install the tool.</p>
+		</body></html>`,
+	}
+	service := NewServiceWithDependencies(mockClient, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.ExtractText(context.Background(), TextRequest{URL: "https://example.com"})
+
+	require.NoError(t, err, "ExtractText() should not return error")
+	assert.Equal(t, "Docs", result.PageTitle)
+	assert.Equal(t, "Getting started\nInstall the tool.", result.Text, "Only visible body text should be returned")
+	assert.Equal(t, 5, result.WordCount)
+	assert.NotEmpty(t, result.ProcessingTime)
+}
+
+func TestExtractText_FetchError(t *testing.T) {
+	service := NewServiceWithDependencies(&mockHTTPClient{error: assert.AnError}, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.ExtractText(context.Background(), TextRequest{URL: "https://example.com"})
+
+	assert.Nil(t, result)
+	var analysisErr *AnalysisError
+	require.ErrorAs(t, err, &analysisErr, "Fetch failures should be reported as AnalysisError")
+	assert.Equal(t, 500, analysisErr.StatusCode)
+}
@@ -0,0 +1,220 @@
+package analyzer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Options accepted by the tls module.
+const (
+	tlsOptionTimeout = "timeout" // duration: timeout for each handshake.
+
+	defaultTLSTimeout = 10 * time.Second
+)
+
+// tlsModule connects to the page's host and grades its TLS configuration: the
+// negotiated protocol and cipher suite, the certificate, and whether legacy
+// protocols or weak cipher suites are still accepted. It makes several
+// handshakes of its own, so it only runs when requested by name.
+type tlsModule struct {
+	rootCAs *x509.CertPool // nil uses the system roots.
+	port    string         // Overrides the URL's port; tests point it at a local server.
+}
+
+func (m *tlsModule) Name() string { return ModuleTLS }
+
+// OptIn implements OptInModule.
+func (m *tlsModule) OptIn() bool { return true }
+
+// ValidateOptions implements OptionsValidator.
+func (m *tlsModule) ValidateOptions(opts ModuleOptions) error {
+	_, err := m.parseOptions(opts)
+	return err
+}
+
+// parseOptions reads the timeout option.
+func (m *tlsModule) parseOptions(opts ModuleOptions) (time.Duration, error) {
+	for key := range opts {
+		if key != tlsOptionTimeout {
+			return 0, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	timeout, err := opts.Duration(tlsOptionTimeout, defaultTLSTimeout)
+	if err != nil {
+		return 0, err
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("option %q must be positive", tlsOptionTimeout)
+	}
+	return timeout, nil
+}
+
+// Analyze runs the default handshake, then probes for TLS 1.0, TLS 1.1 and
+// weak cipher suites.
+func (m *tlsModule) Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error) {
+	timeout, err := m.parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(info.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page URL: %v", err)
+	}
+	host := u.Hostname()
+	port := "443"
+	if m.port != "" {
+		port = m.port
+	} else if u.Scheme == "https" && u.Port() != "" {
+		port = u.Port()
+	}
+	addr := net.JoinHostPort(host, port)
+
+	report := &TLSReport{}
+	state, err := m.handshake(ctx, addr, host, timeout, &tls.Config{})
+	var verifyErr *tls.CertificateVerificationError
+	if errors.As(err, &verifyErr) {
+		// Still grade the protocol setup of sites with a bad certificate.
+		report.CertificateError = verifyErr.Err.Error()
+		state, err = m.handshake(ctx, addr, host, timeout, &tls.Config{InsecureSkipVerify: true})
+	}
+	if err != nil {
+		report.Error = err.Error()
+	} else {
+		report.Available = true
+		report.Protocol = tls.VersionName(state.Version)
+		report.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+		if len(state.PeerCertificates) > 0 {
+			report.Certificate = describeCertificate(state.PeerCertificates[0])
+		}
+
+		legacy := &tls.Config{InsecureSkipVerify: true}
+		for _, version := range []uint16{tls.VersionTLS10, tls.VersionTLS11} {
+			legacy.MinVersion, legacy.MaxVersion = version, version
+			if _, err := m.handshake(ctx, addr, host, timeout, legacy); err == nil {
+				report.LegacyProtocols = append(report.LegacyProtocols, tls.VersionName(version))
+			}
+		}
+
+		// Cipher suites cannot be chosen in TLS 1.3, which has no weak ones.
+		weak := &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS10, MaxVersion: tls.VersionTLS12}
+		for _, suite := range tls.InsecureCipherSuites() {
+			weak.CipherSuites = []uint16{suite.ID}
+			if _, err := m.handshake(ctx, addr, host, timeout, weak); err == nil {
+				report.WeakCipherSuites = append(report.WeakCipherSuites, suite.Name)
+			}
+		}
+	}
+	report.Grade = gradeTLS(report)
+	findings := tlsFindings(host, report)
+
+	return ModuleResultFunc(func(a *WebpageAnalysis) {
+		a.TLS = report
+		a.Findings = append(a.Findings, findings...)
+	}), nil
+}
+
+// handshake connects to addr, completes a TLS handshake with cfg and closes
+// the connection.
+func (m *tlsModule) handshake(ctx context.Context, addr, serverName string, timeout time.Duration, cfg *tls.Config) (tls.ConnectionState, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cfg = cfg.Clone()
+	cfg.ServerName = serverName
+	cfg.RootCAs = m.rootCAs
+	dialer := &tls.Dialer{Config: cfg}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+	return conn.(*tls.Conn).ConnectionState(), nil
+}
+
+// describeCertificate summarizes the leaf certificate.
+func describeCertificate(cert *x509.Certificate) *CertificateInfo {
+	info := &CertificateInfo{
+		Subject:   cert.Subject.CommonName,
+		Issuer:    cert.Issuer.CommonName,
+		NotAfter:  cert.NotAfter.UTC(),
+		DNSNames:  cert.DNSNames,
+		KeyType:   cert.PublicKeyAlgorithm.String(),
+		Signature: cert.SignatureAlgorithm.String(),
+	}
+	if info.Issuer == "" && len(cert.Issuer.Organization) > 0 {
+		info.Issuer = cert.Issuer.Organization[0]
+	}
+	return info
+}
+
+// gradeTLS grades the report in the spirit of SSL Labs' letter grades:
+//
+//	A+  TLS 1.3 negotiated, no legacy protocols or weak cipher suites
+//	A   TLS 1.2 negotiated, no legacy protocols or weak cipher suites
+//	B   TLS 1.0 or 1.1 still accepted
+//	C   weak cipher suites (RC4, 3DES, CBC with SHA-256) accepted
+//	T   certificate not trusted, otherwise graded as above
+//	F   no TLS handshake possible
+func gradeTLS(r *TLSReport) string {
+	switch {
+	case !r.Available:
+		return "F"
+	case r.CertificateError != "":
+		return "T"
+	case len(r.WeakCipherSuites) > 0:
+		return "C"
+	case len(r.LegacyProtocols) > 0:
+		return "B"
+	case r.Protocol == tls.VersionName(tls.VersionTLS13):
+		return "A+"
+	default:
+		return "A"
+	}
+}
+
+// tlsFindings reports the weaknesses behind the grade.
+func tlsFindings(host string, r *TLSReport) []Finding {
+	if !r.Available {
+		return []Finding{{
+			Type:     FindingTLSUnavailable,
+			Severity: SeverityHigh,
+			Message:  "No TLS connection could be made to " + host,
+			Evidence: r.Error,
+		}}
+	}
+
+	var findings []Finding
+	if r.CertificateError != "" {
+		findings = append(findings, Finding{
+			Type:     FindingUntrustedCertificate,
+			Severity: SeverityHigh,
+			Message:  "TLS certificate of " + host + " is not trusted",
+			Evidence: r.CertificateError,
+		})
+	}
+	if len(r.LegacyProtocols) > 0 {
+		findings = append(findings, Finding{
+			Type:     FindingLegacyTLS,
+			Severity: SeverityMedium,
+			Message:  "Server accepts deprecated protocols: " + strings.Join(r.LegacyProtocols, ", "),
+		})
+	}
+	if len(r.WeakCipherSuites) > 0 {
+		findings = append(findings, Finding{
+			Type:     FindingWeakCipher,
+			Severity: SeverityMedium,
+			Message:  fmt.Sprintf("Server accepts %d weak cipher suites", len(r.WeakCipherSuites)),
+			Evidence: strings.Join(r.WeakCipherSuites, ", "),
+		})
+	}
+	return findings
+}
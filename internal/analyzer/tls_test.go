@@ -0,0 +1,121 @@
+package analyzer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// probeTLS runs the tls module against a local server using cfg. When trusted
+// is false the server's test certificate is not added to the root pool.
+func probeTLS(t *testing.T, cfg *tls.Config, trusted bool) (*TLSReport, []Finding) {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = cfg
+	server.StartTLS()
+	defer server.Close()
+
+	module := &tlsModule{
+		rootCAs: x509.NewCertPool(),
+		port:    (&url.URL{Host: server.Listener.Addr().String()}).Port(),
+	}
+	if trusted {
+		module.rootCAs.AddCert(server.Certificate())
+	}
+
+	result, err := module.Analyze(context.Background(), nil, FetchInfo{URL: "https://127.0.0.1/page"}, nil)
+	require.NoError(t, err)
+	analysis := &WebpageAnalysis{}
+	result.Apply(analysis)
+	require.NotNil(t, analysis.TLS)
+	return analysis.TLS, analysis.Findings
+}
+
+func TestTLSModule_Modern(t *testing.T) {
+	report, findings := probeTLS(t, &tls.Config{MinVersion: tls.VersionTLS12}, true)
+
+	assert.True(t, report.Available)
+	assert.Equal(t, "TLS 1.3", report.Protocol)
+	assert.NotEmpty(t, report.CipherSuite)
+	assert.Empty(t, report.LegacyProtocols)
+	assert.Empty(t, report.WeakCipherSuites)
+	require.NotNil(t, report.Certificate)
+	assert.Contains(t, report.Certificate.DNSNames, "example.com")
+	assert.Equal(t, "A+", report.Grade)
+	assert.Empty(t, findings)
+}
+
+func TestTLSModule_TLS12Only(t *testing.T) {
+	report, findings := probeTLS(t, &tls.Config{MinVersion: tls.VersionTLS12, MaxVersion: tls.VersionTLS12}, true)
+
+	assert.Equal(t, "TLS 1.2", report.Protocol)
+	assert.Equal(t, "A", report.Grade)
+	assert.Empty(t, findings)
+}
+
+func TestTLSModule_LegacyProtocols(t *testing.T) {
+	report, findings := probeTLS(t, &tls.Config{MinVersion: tls.VersionTLS10}, true)
+
+	assert.Equal(t, []string{"TLS 1.0", "TLS 1.1"}, report.LegacyProtocols)
+	assert.Equal(t, "B", report.Grade)
+	require.Len(t, findings, 1)
+	assert.Equal(t, FindingLegacyTLS, findings[0].Type)
+	assert.Equal(t, SeverityMedium, findings[0].Severity)
+}
+
+func TestTLSModule_WeakCipher(t *testing.T) {
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		MaxVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256},
+	}
+	report, findings := probeTLS(t, cfg, true)
+
+	assert.Equal(t, []string{"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256"}, report.WeakCipherSuites)
+	assert.Equal(t, "C", report.Grade)
+	require.Len(t, findings, 1)
+	assert.Equal(t, FindingWeakCipher, findings[0].Type)
+}
+
+func TestTLSModule_UntrustedCertificate(t *testing.T) {
+	report, findings := probeTLS(t, &tls.Config{MinVersion: tls.VersionTLS12}, false)
+
+	assert.True(t, report.Available)
+	assert.Equal(t, "TLS 1.3", report.Protocol)
+	assert.NotEmpty(t, report.CertificateError)
+	assert.Equal(t, "T", report.Grade)
+	require.Len(t, findings, 1)
+	assert.Equal(t, FindingUntrustedCertificate, findings[0].Type)
+	assert.Equal(t, SeverityHigh, findings[0].Severity)
+}
+
+func TestTLSModule_Unavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	module := &tlsModule{port: (&url.URL{Host: server.Listener.Addr().String()}).Port()}
+
+	result, err := module.Analyze(context.Background(), nil, FetchInfo{URL: "https://127.0.0.1/"}, nil)
+	require.NoError(t, err)
+	analysis := &WebpageAnalysis{}
+	result.Apply(analysis)
+
+	assert.False(t, analysis.TLS.Available)
+	assert.NotEmpty(t, analysis.TLS.Error)
+	assert.Equal(t, "F", analysis.TLS.Grade)
+	require.Len(t, analysis.Findings, 1)
+	assert.Equal(t, FindingTLSUnavailable, analysis.Findings[0].Type)
+}
+
+func TestTLSModule_ValidateOptions(t *testing.T) {
+	module := &tlsModule{}
+	assert.NoError(t, module.ValidateOptions(ModuleOptions{"timeout": "3s"}))
+	assert.Error(t, module.ValidateOptions(ModuleOptions{"timeout": "0s"}))
+	assert.Error(t, module.ValidateOptions(ModuleOptions{"ciphers": "all"}))
+}
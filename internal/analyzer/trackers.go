@@ -0,0 +1,180 @@
+package analyzer
+
+import (
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/publicsuffix"
+
+	"webpage-analyzer/internal/parser"
+)
+
+// Third-party categories reported in ThirdParty.Category.
+const (
+	TrackerAdvertising = "advertising"
+	TrackerAnalytics   = "analytics"
+	TrackerSocial      = "social"
+	TrackerTagManager  = "tag_manager"
+	TrackerCDN         = "cdn"
+	TrackerUnknown     = "unknown" // Not in the classification list.
+)
+
+// trackerPenalties is how many points each third party of a category takes
+// off the privacy score. Tag managers load further trackers the page's HTML
+// does not show; unclassified third parties may track too.
+var trackerPenalties = map[string]int{
+	TrackerAdvertising: 15,
+	TrackerAnalytics:   8,
+	TrackerSocial:      8,
+	TrackerTagManager:  5,
+	TrackerCDN:         0,
+	TrackerUnknown:     3,
+}
+
+// trackersCSV is the bundled domain classification list.
+//
+//go:embed trackers.csv
+var trackersCSV string
+
+// trackerDomain is an entry of the classification list.
+type trackerDomain struct {
+	category string
+	company  string
+}
+
+// trackerDomains maps the domains of the classification list to their
+// entries.
+var trackerDomains = mustLoadTrackerDomains(trackersCSV)
+
+// mustLoadTrackerDomains parses a classification list of domain,category,company
+// rows, panicking on malformed rows, which can only come from a broken build.
+func mustLoadTrackerDomains(list string) map[string]trackerDomain {
+	reader := csv.NewReader(strings.NewReader(list))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 3
+	records, err := reader.ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("invalid tracker list: %v", err))
+	}
+	domains := make(map[string]trackerDomain, len(records))
+	for _, record := range records {
+		if _, ok := trackerPenalties[record[1]]; !ok {
+			panic(fmt.Sprintf("invalid tracker list: unknown category %q for %s", record[1], record[0]))
+		}
+		domains[strings.ToLower(record[0])] = trackerDomain{category: record[1], company: record[2]}
+	}
+	return domains
+}
+
+// trackersModule categorizes the page's third parties.
+func trackersModule(htmlParser parser.HTMLParser) AnalyzerModule {
+	return NewModule(ModuleTrackers, func(ctx context.Context, doc *html.Node, info FetchInfo) (ModuleResult, error) {
+		elements := pageFrom(ctx, doc, htmlParser).Elements("script", "iframe", "img", "link", "source", "video", "audio", "embed", "object")
+		trackers := ClassifyThirdParties(info.URL, elements)
+		return ModuleResultFunc(func(a *WebpageAnalysis) { a.Trackers = trackers }), nil
+	})
+}
+
+// ClassifyThirdParties lists the third parties the page loads resources from,
+// scripts, frames, images, stylesheets and media, grouped by the most
+// specific domain of the classification list or, for unlisted hosts, by
+// registrable domain. Hosts under the page's own registrable domain are
+// first party. The privacy score starts at 100 and loses the category's
+// penalty for each third party, down to 0.
+func ClassifyThirdParties(pageURL string, elements []parser.Element) *Trackers {
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	site := registrableDomain(page.Hostname())
+
+	byDomain := make(map[string]*ThirdParty)
+	for _, el := range elements {
+		for _, attr := range []string{"src", "href", "data"} {
+			if el.Tag == "link" && attr == "href" && !loadsResource(el.Attr("rel")) {
+				continue
+			}
+			ref := strings.TrimSpace(el.Attr(attr))
+			if ref == "" {
+				continue
+			}
+			u, err := page.Parse(ref)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				continue
+			}
+			host := strings.ToLower(u.Hostname())
+			if host == "" || registrableDomain(host) == site {
+				continue
+			}
+			domain, entry, listed := classifyHost(host)
+			party, ok := byDomain[domain]
+			if !ok {
+				party = &ThirdParty{Domain: domain, Category: TrackerUnknown}
+				if listed {
+					party.Category, party.Company = entry.category, entry.company
+				}
+				byDomain[domain] = party
+			}
+			party.Requests++
+		}
+	}
+
+	trackers := &Trackers{PrivacyScore: 100}
+	for _, party := range byDomain {
+		trackers.ThirdParties = append(trackers.ThirdParties, *party)
+		if trackers.Categories == nil {
+			trackers.Categories = make(map[string]int)
+		}
+		trackers.Categories[party.Category]++
+		trackers.PrivacyScore -= trackerPenalties[party.Category]
+	}
+	trackers.PrivacyScore = max(trackers.PrivacyScore, 0)
+	sort.Slice(trackers.ThirdParties, func(i, j int) bool {
+		return trackers.ThirdParties[i].Domain < trackers.ThirdParties[j].Domain
+	})
+	return trackers
+}
+
+// loadsResource reports whether a <link> with rel fetches what it points at.
+func loadsResource(rel string) bool {
+	for _, r := range strings.Fields(strings.ToLower(rel)) {
+		switch r {
+		case "stylesheet", "icon", "preload", "modulepreload", "prefetch", "manifest":
+			return true
+		}
+	}
+	return false
+}
+
+// classifyHost returns the most specific domain of the classification list
+// that host is or is under, with its entry, or host's registrable domain
+// when it is not listed.
+func classifyHost(host string) (string, trackerDomain, bool) {
+	for domain := host; domain != ""; {
+		if entry, ok := trackerDomains[domain]; ok {
+			return domain, entry, true
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+	return registrableDomain(host), trackerDomain{}, false
+}
+
+// registrableDomain returns host's domain under its public suffix, or host
+// itself for IP addresses and bare suffixes.
+func registrableDomain(host string) string {
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
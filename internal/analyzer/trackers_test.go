@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+)
+
+func TestClassifyThirdParties(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><head>
+<script async src="https://www.googletagmanager.com/gtag/js?id=G-1"></script>
+<script src="https://www.google-analytics.com/analytics.js"></script>
+<script src="https://securepubads.g.doubleclick.net/tag/js/gpt.js"></script>
+<script src="https://cdn.jsdelivr.net/npm/lib@1/lib.min.js"></script>
+<link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/lib@1/lib.css">
+<link rel="preconnect" href="https://unused.example.org">
+<link rel="canonical" href="https://other.example.org/page">
+<script src="/app.js"></script>
+<script src="https://static.example.com/vendor.js"></script>
+</head><body>
+<img src="https://px.widgets.example.net/p.gif">
+<iframe src="https://www.youtube.com/embed/abc"></iframe>
+<img src="data:image/gif;base64,R0lGOD">
+</body></html>`))
+	require.NoError(t, err)
+	elements := parser.NewHTMLParser().ExtractElements(doc, "script", "iframe", "img", "link")
+
+	trackers := ClassifyThirdParties("https://www.example.com/", elements)
+	require.NotNil(t, trackers)
+	assert.Equal(t, []ThirdParty{
+		{Domain: "cdn.jsdelivr.net", Company: "jsDelivr", Category: TrackerCDN, Requests: 2},
+		{Domain: "doubleclick.net", Company: "Google", Category: TrackerAdvertising, Requests: 1},
+		{Domain: "example.net", Category: TrackerUnknown, Requests: 1},
+		{Domain: "google-analytics.com", Company: "Google", Category: TrackerAnalytics, Requests: 1},
+		{Domain: "googletagmanager.com", Company: "Google", Category: TrackerTagManager, Requests: 1},
+		{Domain: "youtube.com", Company: "Google", Category: TrackerSocial, Requests: 1},
+	}, trackers.ThirdParties, "First-party subdomains, hints, canonical links and data: URLs should not count")
+	assert.Equal(t, map[string]int{TrackerCDN: 1, TrackerAdvertising: 1, TrackerUnknown: 1, TrackerAnalytics: 1, TrackerTagManager: 1, TrackerSocial: 1}, trackers.Categories)
+	assert.Equal(t, 100-15-8-8-5-3, trackers.PrivacyScore)
+}
+
+func TestClassifyThirdParties_ScoreBounds(t *testing.T) {
+	trackers := ClassifyThirdParties("https://example.com", nil)
+	require.NotNil(t, trackers)
+	assert.Empty(t, trackers.ThirdParties)
+	assert.Equal(t, 100, trackers.PrivacyScore)
+
+	var elements []parser.Element
+	for _, domain := range []string{"doubleclick.net", "criteo.com", "taboola.com", "outbrain.com", "adnxs.com", "pubmatic.com", "openx.net", "quantserve.com"} {
+		elements = append(elements, parser.Element{Tag: "script", Attrs: map[string]string{"src": "https://" + domain + "/t.js"}})
+	}
+	assert.Equal(t, 0, ClassifyThirdParties("https://example.com", elements).PrivacyScore)
+}
+
+func TestMustLoadTrackerDomains(t *testing.T) {
+	domains := mustLoadTrackerDomains("# comment\nexample.com,analytics,Example\n")
+	assert.Equal(t, map[string]trackerDomain{"example.com": {category: TrackerAnalytics, company: "Example"}}, domains)
+	assert.NotEmpty(t, trackerDomains, "The bundled list should load")
+
+	assert.Panics(t, func() { mustLoadTrackerDomains("example.com,spying,Example\n") })
+	assert.Panics(t, func() { mustLoadTrackerDomains("example.com,analytics\n") })
+}
@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/urlnorm"
+)
+
+// trackingParamsOptionClean is the tracking_params module's bool option
+// adding each link's URL without its tracking parameters.
+const trackingParamsOptionClean = "clean"
+
+// trackingParamsModule reports the page's links that carry tracking parameters.
+type trackingParamsModule struct {
+	htmlParser parser.HTMLParser
+}
+
+func (m *trackingParamsModule) Name() string { return ModuleTrackingParams }
+
+// parseOptions reads the clean option.
+func (m *trackingParamsModule) parseOptions(opts ModuleOptions) (bool, error) {
+	for key := range opts {
+		if key != trackingParamsOptionClean {
+			return false, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	return opts.Bool(trackingParamsOptionClean, false)
+}
+
+// ValidateOptions implements OptionsValidator.
+func (m *trackingParamsModule) ValidateOptions(opts ModuleOptions) error {
+	_, err := m.parseOptions(opts)
+	return err
+}
+
+// Analyze finds the tracked links.
+func (m *trackingParamsModule) Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error) {
+	clean, err := m.parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	tracking := AnalyzeTrackingParams(pageFrom(ctx, doc, m.htmlParser).LinkURLs(info.URL), clean)
+	return ModuleResultFunc(func(a *WebpageAnalysis) { a.TrackingParams = tracking }), nil
+}
+
+// AnalyzeTrackingParams returns the links carrying tracking parameters, how
+// often each parameter is used, and the campaigns the links' utm_source,
+// utm_medium and utm_campaign values name, most linked first. With clean set,
+// each link also gets its URL without the tracking parameters. It returns nil
+// when no link is tracked.
+func AnalyzeTrackingParams(links []string, clean bool) *TrackingParams {
+	result := &TrackingParams{Params: make(map[string]int)}
+	campaigns := make(map[Campaign]int)
+	for _, link := range links {
+		names := urlnorm.TrackingParams(link)
+		if len(names) == 0 {
+			continue
+		}
+		tracked := TrackedLink{URL: link, Params: names}
+		if clean {
+			tracked.Cleaned = urlnorm.StripTracking(link)
+		}
+		result.Links = append(result.Links, tracked)
+		for _, name := range names {
+			result.Params[name]++
+		}
+		if campaign, ok := campaignOf(link); ok {
+			campaigns[campaign]++
+		}
+	}
+	if len(result.Links) == 0 {
+		return nil
+	}
+
+	for campaign, count := range campaigns {
+		campaign.Links = count
+		result.Campaigns = append(result.Campaigns, campaign)
+	}
+	sort.Slice(result.Campaigns, func(i, j int) bool {
+		a, b := result.Campaigns[i], result.Campaigns[j]
+		if a.Links != b.Links {
+			return a.Links > b.Links
+		}
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Medium != b.Medium {
+			return a.Medium < b.Medium
+		}
+		return a.Campaign < b.Campaign
+	})
+	return result
+}
+
+// campaignOf returns the link's utm values, reporting false when it has none.
+// Click identifiers such as gclid are left out: they are unique per click.
+func campaignOf(link string) (Campaign, bool) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return Campaign{}, false
+	}
+	query := u.Query()
+	campaign := Campaign{
+		Source:   query.Get("utm_source"),
+		Medium:   query.Get("utm_medium"),
+		Campaign: query.Get("utm_campaign"),
+	}
+	return campaign, campaign != Campaign{}
+}
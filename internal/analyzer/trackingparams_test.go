@@ -0,0 +1,60 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeTrackingParams(t *testing.T) {
+	links := []string{
+		"https://shop.example/?utm_source=newsletter&utm_medium=email&utm_campaign=spring&id=7",
+		"https://shop.example/sale?utm_campaign=spring&utm_medium=email&utm_source=newsletter",
+		"https://blog.example/?utm_source=twitter&utm_medium=social",
+		"https://ads.example/click?gclid=abc123",
+		"https://example.com/about?page=2",
+	}
+
+	tracking := AnalyzeTrackingParams(links, false)
+	require.NotNil(t, tracking)
+	assert.Equal(t, []TrackedLink{
+		{URL: links[0], Params: []string{"utm_source", "utm_medium", "utm_campaign"}},
+		{URL: links[1], Params: []string{"utm_campaign", "utm_medium", "utm_source"}},
+		{URL: links[2], Params: []string{"utm_source", "utm_medium"}},
+		{URL: links[3], Params: []string{"gclid"}},
+	}, tracking.Links)
+	assert.Equal(t, map[string]int{"utm_source": 3, "utm_medium": 3, "utm_campaign": 2, "gclid": 1}, tracking.Params)
+	assert.Equal(t, []Campaign{
+		{Source: "newsletter", Medium: "email", Campaign: "spring", Links: 2},
+		{Source: "twitter", Medium: "social", Links: 1},
+	}, tracking.Campaigns)
+
+	cleaned := AnalyzeTrackingParams(links, true)
+	assert.Equal(t, "https://shop.example/?id=7", cleaned.Links[0].Cleaned)
+	assert.Equal(t, "https://ads.example/click", cleaned.Links[3].Cleaned)
+
+	assert.Nil(t, AnalyzeTrackingParams([]string{"https://example.com/?q=utm_source"}, false))
+}
+
+func TestTrackingParamsModule(t *testing.T) {
+	page := `<a href="/offer?fbclid=xyz&ref=home">Offer</a> <a href="/about">About</a>`
+	service := NewService(WithHTTPClient(&mockHTTPClient{response: page}))
+
+	analysis, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{
+		URL:     "https://example.com",
+		Modules: []string{ModuleTrackingParams},
+		Options: map[string]ModuleOptions{ModuleTrackingParams: {"clean": true}},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, analysis.TrackingParams)
+	assert.Equal(t, []TrackedLink{{URL: "https://example.com/offer?fbclid=xyz&ref=home", Params: []string{"fbclid"}, Cleaned: "https://example.com/offer?ref=home"}}, analysis.TrackingParams.Links)
+
+	_, err = service.AnalyzeWebpage(context.Background(), AnalysisRequest{
+		URL:     "https://example.com",
+		Modules: []string{ModuleTrackingParams},
+		Options: map[string]ModuleOptions{ModuleTrackingParams: {"strip": true}},
+	})
+	assert.Error(t, err, "Unknown options should be rejected")
+}
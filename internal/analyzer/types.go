@@ -3,45 +3,1048 @@ package analyzer
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
+
+	"golang.org/x/net/html"
 )
 
 // WebpageAnalysis represents the result of analyzing a webpage.
 // @Description Comprehensive result of webpage analysis
 type WebpageAnalysis struct {
-	URL               string         `json:"url" example:"https://example.com"`
-	HTMLVersion       string         `json:"html_version" example:"HTML5"`
-	PageTitle         string         `json:"page_title" example:"Example Domain"`
-	Headings          map[string]int `json:"headings"` // level -> count.
-	InternalLinks     int            `json:"internal_links" example:"15"`
-	ExternalLinks     int            `json:"external_links" example:"8"`
-	InaccessibleLinks int            `json:"inaccessible_links" example:"0"`
-	HasLoginForm      bool           `json:"has_login_form" example:"false"`
-	AnalyzedAt        time.Time      `json:"analyzed_at" example:"2024-01-15T10:30:00Z"`
-	ProcessingTime    string         `json:"processing_time" example:"150ms"`
+	ID                string               `json:"id,omitempty" example:"3f2a9c1e8b7d4e6f"`
+	URL               string               `json:"url" example:"https://example.com"`
+	FinalURL          string               `json:"final_url,omitempty" example:"https://www.example.com/"` // After redirects, when they led to another URL.
+	HTMLVersion       string               `json:"html_version" example:"HTML5"`
+	PageTitle         string               `json:"page_title" example:"Example Domain"`
+	Description       string               `json:"description,omitempty" example:"An example page"` // From <meta name="description">.
+	Headings          map[string]int       `json:"headings"`                                        // level -> count.
+	InternalLinks     int                  `json:"internal_links" example:"15"`
+	ExternalLinks     int                  `json:"external_links" example:"8"`
+	InaccessibleLinks int                  `json:"inaccessible_links" example:"0"`
+	HasLoginForm      bool                 `json:"has_login_form" example:"false"`
+	AnalyzedAt        time.Time            `json:"analyzed_at" example:"2024-01-15T10:30:00Z"`
+	ProcessingTime    string               `json:"processing_time" example:"150ms"`
+	Changes           *SnapshotChanges     `json:"changes,omitempty"`                              // Set when a previous snapshot of the URL exists.
+	LinkProbe         *LinkProbeResult     `json:"link_probe,omitempty"`                           // Set when the links module's probe option is on.
+	Modules           []string             `json:"modules,omitempty" example:"html_version,links"` // Analysis modules that ran.
+	Cache             *CacheInfo           `json:"cache,omitempty"`                                // Set when the result cache is enabled.
+	Protocol          *ProtocolInfo        `json:"protocol,omitempty"`                             // Set when the page was fetched.
+	PasswordFields    []PasswordFieldAudit `json:"password_fields,omitempty"`
+	Captchas          []Captcha            `json:"captchas,omitempty"`
+	Contacts          *Contacts            `json:"contacts,omitempty"`
+	Payment           *PaymentDetection    `json:"payment,omitempty"`                               // Set when the page collects card data.
+	SocialLogins      []string             `json:"social_logins,omitempty" example:"google,github"` // Identity providers offered for sign-in.
+	SocialProfiles    []SocialProfile      `json:"social_profiles,omitempty"`
+	Technologies      []Technology         `json:"technologies,omitempty"`
+	DOM               *DOMMetrics          `json:"dom,omitempty"`
+	ClientRedirects   []ClientRedirect     `json:"client_redirects,omitempty"`
+	Robots            *RobotsDirectives    `json:"robots,omitempty"`
+	Pagination        *Pagination          `json:"pagination,omitempty"`
+	StructuredData    *StructuredData      `json:"structured_data,omitempty"`
+	Product           *ProductInfo         `json:"product,omitempty"` // Set when the page describes a product.
+	Structure         *ContentStructure    `json:"structure,omitempty"`
+	Images            *ImageAudit          `json:"images,omitempty"`
+	ResourceHints     []ResourceHint       `json:"resource_hints,omitempty"`
+	Consent           *Consent             `json:"consent,omitempty"`
+	Trackers          *Trackers            `json:"trackers,omitempty"`
+	Interstitials     *Interstitials       `json:"interstitials,omitempty"`
+	SuspiciousLinks   []SuspiciousLink     `json:"suspicious_links,omitempty"`
+	TrackingParams    *TrackingParams      `json:"tracking_params,omitempty"`
+	Canonical         *Canonical           `json:"canonical,omitempty"`
+	Language          *PageLanguage        `json:"language,omitempty"`
+	Article           *Article             `json:"article,omitempty"`        // Set when the article module is requested.
+	Wayback           *WaybackHistory      `json:"wayback,omitempty"`        // Set when the wayback module is requested.
+	Reputation        *ReputationResult    `json:"reputation,omitempty"`     // Set when the reputation module is requested.
+	Domain            *DomainRegistration  `json:"domain,omitempty"`         // Set when the domain module is requested.
+	DNS               *DNSPosture          `json:"dns,omitempty"`            // Set when the dns module is requested.
+	Infrastructure    *Infrastructure      `json:"infrastructure,omitempty"` // Set when the infrastructure module is requested.
+	HTTPS             *HTTPSPosture        `json:"https,omitempty"`          // Set when the https module is requested.
+	SiteVariants      *SiteVariants        `json:"site_variants,omitempty"`  // Set when the site_variants module is requested.
+	TLS               *TLSReport           `json:"tls,omitempty"`            // Set when the tls module is requested.
+	Favicon           *Favicon             `json:"favicon,omitempty"`        // Set when the favicon module is requested.
+	Findings          []Finding            `json:"findings,omitempty"`       // Security and quality issues, e.g. insecure login forms.
+	ContentHash       string               `json:"content_hash,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	SimHash           string               `json:"simhash,omitempty" example:"3c5a1f0e9b2d4c68"` // Near-duplicate fingerprint; compare with HammingDistance.
+	Warnings          []Warning            `json:"warnings,omitempty"`                           // Modules left out of the result because they failed or ran out of time.
+}
+
+// Warning codes.
+const (
+	WarningModuleTimeout = "module_timeout"
+	WarningModuleFailed  = "module_failed"
+)
+
+// Warning reports a module whose result is missing from an analysis because
+// it failed or ran out of time.
+// @Description A module left out of the analysis result
+type Warning struct {
+	Module  string `json:"module" example:"wayback"`
+	Code    string `json:"code" example:"module_timeout"`
+	Message string `json:"message" example:"Module did not finish within total_timeout"`
+}
+
+// CacheInfo reports whether an analysis was served from the result cache.
+// @Description Result cache metadata
+type CacheInfo struct {
+	Hit bool   `json:"hit" example:"true"`
+	Age string `json:"age" example:"5m0s"` // Time since the analysis was computed; "0s" on a miss.
+	TTL string `json:"ttl" example:"5m0s"` // How long the analysis is cached in total.
+}
+
+// ProtocolInfo reports the HTTP version a page was served over.
+// @Description HTTP protocol used to fetch the page
+type ProtocolInfo struct {
+	Version         string `json:"version" example:"HTTP/2.0"`
+	HTTP3Advertised bool   `json:"http3_advertised" example:"true"` // The server offers HTTP/3 in an Alt-Svc header.
+}
+
+// Severity ranks how serious a Finding is.
+type Severity string
+
+// Finding severities, from least to most serious.
+const (
+	SeverityInfo   Severity = "info"
+	SeverityLow    Severity = "low"
+	SeverityMedium Severity = "medium"
+	SeverityHigh   Severity = "high"
+)
+
+// Finding types.
+const (
+	FindingInsecureCredentialSubmission = "insecure_credential_submission"
+	FindingPasswordAutocompleteOff      = "password_autocomplete_disabled"
+	FindingPasswordPasteBlocked         = "password_paste_blocked"
+	FindingPasswordHintMissing          = "password_autocomplete_hint_missing"
+	FindingPaymentDataCollection        = "payment_data_collection"
+	FindingInsecurePaymentForm          = "insecure_payment_form"
+	FindingOpenRedirect                 = "open_redirect_pattern"
+	FindingMaliciousURL                 = "malicious_url"
+	FindingMaliciousLink                = "malicious_link"
+	FindingYoungDomain                  = "young_domain"
+	FindingDNSMisconfiguration          = "dns_misconfiguration"
+	FindingNoHTTPS                      = "https_unavailable"
+	FindingNoHTTPSRedirect              = "missing_https_redirect"
+	FindingHSTSMissing                  = "hsts_missing"
+	FindingHSTSWeak                     = "hsts_weak"
+	FindingTLSUnavailable               = "tls_unavailable"
+	FindingUntrustedCertificate         = "untrusted_certificate"
+	FindingLegacyTLS                    = "legacy_tls_protocol"
+	FindingWeakCipher                   = "weak_cipher_suite"
+	FindingClientRedirect               = "client_redirect"
+	FindingRobotsConflict               = "robots_directive_conflict"
+	FindingStructuredDataMissing        = "structured_data_missing_property"
+	FindingLayoutTable                  = "layout_table"
+	FindingSuspiciousLink               = "suspicious_link"
+	FindingResourceHint                 = "resource_hint_issue"
+	FindingCanonicalMismatch            = "canonical_mismatch"
+	FindingSiteVariantsSplit            = "site_variants_split"
+	FindingLanguageMismatch             = "language_mismatch"
+)
+
+// Finding is an issue detected on the page.
+// @Description A security or quality issue detected on the page
+type Finding struct {
+	Type     string   `json:"type" example:"insecure_credential_submission"`
+	Severity Severity `json:"severity" example:"high"`
+	Message  string   `json:"message" example:"Login form submits credentials over plain HTTP"`
+	Evidence string   `json:"evidence,omitempty" example:"http://example.com/login"` // What triggered the finding, e.g. a URL.
+}
+
+// PasswordFieldAudit reports how a password input works with password managers.
+// @Description Autocomplete and paste handling of a password input
+type PasswordFieldAudit struct {
+	Name                 string `json:"name,omitempty" example:"password"`
+	Autocomplete         string `json:"autocomplete,omitempty" example:"current-password"` // Effective value, including one inherited from the form.
+	AutocompleteDisabled bool   `json:"autocomplete_disabled" example:"false"`
+	PasteBlocked         bool   `json:"paste_blocked" example:"false"`
+	MissingHint          bool   `json:"missing_hint" example:"false"` // No current-password or new-password token.
+}
+
+// DOMMetrics describes the size and shape of a page's DOM, a common proxy
+// for how costly it is to render.
+// @Description Element counts and nesting of the page's DOM
+type DOMMetrics struct {
+	Elements        int `json:"elements" example:"842"`
+	MaxDepth        int `json:"max_depth" example:"17"` // Elements on the longest path down from <html>, which counts as 1.
+	HTMLBytes       int `json:"html_bytes" example:"48213"`
+	InlineScripts   int `json:"inline_scripts" example:"6"`    // <script> elements without src.
+	InlineStyles    int `json:"inline_styles" example:"2"`     // <style> elements.
+	StyleAttributes int `json:"style_attributes" example:"31"` // Elements with a style attribute.
+	// DepthLimited is set when elements nest client.MaxDOMDepth deep; deeper
+	// ones were dropped before analysis.
+	DepthLimited bool `json:"depth_limited,omitempty" example:"false"`
+}
+
+// ClientRedirect is a redirect the page performs after it has loaded, with a
+// meta refresh or a script.
+// @Description A meta refresh or script redirect on the page
+type ClientRedirect struct {
+	Type   string `json:"type" example:"meta_refresh"`              // meta_refresh or javascript.
+	Target string `json:"target" example:"https://example.com/new"` // Resolved against the page URL.
+	Delay  int    `json:"delay" example:"0"`                        // Seconds before a meta refresh fires; 0 for scripts.
+}
+
+// RobotsDirectives reports what the page tells search engines through the
+// robots meta tag and the X-Robots-Tag header.
+// @Description Robots directives from the meta tag and the X-Robots-Tag header
+type RobotsDirectives struct {
+	Meta      []string `json:"meta,omitempty" example:"noindex,follow"` // From <meta name="robots">.
+	Header    []string `json:"header,omitempty" example:"noarchive"`    // From X-Robots-Tag.
+	NoIndex   bool     `json:"noindex" example:"true"`                  // Effective directives: the most restrictive of both sources.
+	NoFollow  bool     `json:"nofollow" example:"false"`
+	NoArchive bool     `json:"noarchive" example:"true"`
+	// Conflicts lists the restrictive directives that another directive
+	// contradicts, e.g. noindex when the meta tag says index.
+	Conflicts []string `json:"conflicts,omitempty" example:"noindex"`
+}
+
+// Pagination reports whether the page is one page of a paginated series.
+// @Description Pagination signals and the neighboring pages of the series
+type Pagination struct {
+	Paginated bool   `json:"paginated" example:"true"`
+	Source    string `json:"source,omitempty" example:"rel"`                            // rel or page_links: what the result is based on.
+	Page      int    `json:"page,omitempty" example:"2"`                                // Number of this page, when known.
+	Next      string `json:"next,omitempty" example:"https://example.com/blog/page/3/"` // Resolved against the page URL.
+	Prev      string `json:"prev,omitempty" example:"https://example.com/blog/"`
+}
+
+// StructuredData summarizes the schema.org items on the page.
+// @Description Schema.org items found in JSON-LD and microdata
+type StructuredData struct {
+	Types         map[string]int `json:"types"` // type -> count of top-level items.
+	Items         []SchemaItem   `json:"items,omitempty"`
+	InvalidJSONLD int            `json:"invalid_json_ld,omitempty" example:"0"` // JSON-LD scripts that are not valid JSON.
+}
+
+// SchemaItem is a top-level schema.org item; an item with several types is
+// listed once per type.
+// @Description A schema.org item and the required properties it lacks
+type SchemaItem struct {
+	Type    string   `json:"type" example:"Product"`
+	Format  string   `json:"format" example:"json-ld"` // json-ld or microdata.
+	Missing []string `json:"missing,omitempty" example:"offers.priceCurrency"`
+}
+
+// ProductInfo describes the product a shop page sells.
+// @Description Product name, price, availability and rating
+type ProductInfo struct {
+	Source       string  `json:"source" example:"structured_data"` // structured_data or meta_tags.
+	Name         string  `json:"name,omitempty" example:"Widget"`
+	SKU          string  `json:"sku,omitempty" example:"W-1001"`
+	Brand        string  `json:"brand,omitempty" example:"Acme"`
+	Price        float64 `json:"price" example:"19.99"` // Lowest price of an offer range.
+	Currency     string  `json:"currency,omitempty" example:"USD"`
+	Availability string  `json:"availability,omitempty" example:"InStock"` // A schema.org ItemAvailability value.
+	Rating       float64 `json:"rating,omitempty" example:"4.6"`
+	RatingCount  int     `json:"rating_count,omitempty" example:"128"`
+}
+
+// ContentStructure counts the tables and lists on the page.
+// @Description Table and list counts of the page
+type ContentStructure struct {
+	Tables          TableSummary `json:"tables"`
+	OrderedLists    int          `json:"ordered_lists" example:"2"`
+	UnorderedLists  int          `json:"unordered_lists" example:"14"`
+	DefinitionLists int          `json:"definition_lists" example:"0"`
+}
+
+// TableSummary counts tables by how they are marked up.
+// @Description Table counts by headers, captions and layout use
+type TableSummary struct {
+	Total          int `json:"total" example:"4"`
+	WithHeaders    int `json:"with_headers" example:"2"` // With <th> cells or a <thead>.
+	WithCaption    int `json:"with_caption" example:"1"`
+	Presentational int `json:"presentational" example:"0"`  // Marked role="presentation" or role="none".
+	LayoutSuspects int `json:"layout_suspects" example:"1"` // Unmarked tables that look like page layout.
+}
+
+// ImageAudit reports how well the page's images follow loading best
+// practices.
+// @Description Image loading checks with per-check counts and the worst images
+type ImageAudit struct {
+	Total             int             `json:"total" example:"24"`
+	Lazy              int             `json:"lazy" example:"18"`              // loading="lazy".
+	MissingDimensions int             `json:"missing_dimensions" example:"5"` // Without width or height, a layout shift risk.
+	WithSrcset        int             `json:"with_srcset" example:"12"`       // Offering several resolutions, on the img or a <picture> source.
+	MissingSizes      int             `json:"missing_sizes" example:"2"`      // With width descriptors in srcset but no sizes.
+	ModernFormat      int             `json:"modern_format" example:"10"`     // Offered as WebP or AVIF.
+	LegacyFormat      int             `json:"legacy_format" example:"9"`      // Only offered as JPEG, PNG or GIF.
+	WorstOffenders    []ImageOffender `json:"worst_offenders,omitempty"`      // Images with the most issues, at most 10.
+}
+
+// ImageOffender is an image that fails some of the audit's checks.
+// @Description An image and the loading checks it fails
+type ImageOffender struct {
+	Src    string   `json:"src" example:"/img/hero.jpg"`
+	Issues []string `json:"issues" example:"missing_dimensions,legacy_format"`
+}
+
+// ResourceHint is a <link> asking the browser to fetch or connect ahead of
+// time.
+// @Description A preload, prefetch, preconnect or similar hint and its problems
+type ResourceHint struct {
+	Rel    string   `json:"rel" example:"preload"` // One hint per relation of the link.
+	Href   string   `json:"href" example:"https://example.com/app.js"`
+	As     string   `json:"as,omitempty" example:"script"`
+	Issues []string `json:"issues,omitempty" example:"unused_preload"`
+}
+
+// Article is the main content of the page, without navigation, ads and other
+// page chrome.
+// @Description Main article text and HTML with its byline and publish date
+type Article struct {
+	Byline    string `json:"byline,omitempty" example:"Jane Doe"`
+	Published string `json:"published,omitempty" example:"2024-05-01T08:00:00Z"` // As written on the page.
+	Text      string `json:"text" example:"First paragraph."`                    // Paragraphs separated by blank lines.
+	HTML      string `json:"html" example:"<div><p>First paragraph.</p><p>Second paragraph.</p></div>"`
+	Length    int    `json:"length" example:"3120"`               // Characters of Text.
+	Truncated bool   `json:"truncated,omitempty" example:"false"` // The article was longer than the max_length option.
+}
+
+// Favicon identifies the page's favicon by its hashes.
+// @Description Favicon hashes for fingerprinting, as indexed by Shodan
+type Favicon struct {
+	URL         string `json:"url,omitempty" example:"https://example.com/favicon.ico"`
+	Inline      bool   `json:"inline,omitempty" example:"false"` // The icon is a data: URL in the page.
+	ContentType string `json:"content_type,omitempty" example:"image/x-icon"`
+	Size        int    `json:"size" example:"1150"`        // Bytes.
+	MMH3        int32  `json:"mmh3" example:"-1252041730"` // Shodan's http.favicon.hash.
+	SHA256      string `json:"sha256" example:"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"`
+}
+
+// Consent reports how the page asks for cookie consent.
+// @Description Consent-management platforms and cookie banner detected on the page
+type Consent struct {
+	Platforms []string `json:"platforms,omitempty" example:"OneTrust"`
+	Banner    bool     `json:"banner" example:"true"`        // A consent banner is in the page's markup.
+	TCF       bool     `json:"tcf,omitempty" example:"true"` // The page uses the IAB Transparency and Consent Framework API.
+}
+
+// Trackers summarizes the third parties the page loads resources from.
+// @Description Third parties by category with a privacy score
+type Trackers struct {
+	ThirdParties []ThirdParty   `json:"third_parties,omitempty"`
+	Categories   map[string]int `json:"categories,omitempty"`       // category -> third parties.
+	PrivacyScore int            `json:"privacy_score" example:"61"` // 100 for no tracking, down to 0.
+}
+
+// ThirdParty is a third-party domain the page loads resources from.
+// @Description A third-party domain and its category
+type ThirdParty struct {
+	Domain   string `json:"domain" example:"google-analytics.com"`
+	Company  string `json:"company,omitempty" example:"Google"`
+	Category string `json:"category" example:"analytics"` // advertising, analytics, social, tag_manager, cdn or unknown.
+	Requests int    `json:"requests" example:"2"`         // Elements loading from the domain.
+}
+
+// Interstitials reports what stands between a visitor and the page's
+// content.
+// @Description Modal popups and paywall detected on the page
+type Interstitials struct {
+	Popups  []Popup  `json:"popups,omitempty"`
+	Paywall *Paywall `json:"paywall,omitempty"` // Set when the content looks paywalled.
+}
+
+// Popup is a modal overlay in the page's markup.
+// @Description A modal popup and its kind
+type Popup struct {
+	Kind    string `json:"kind" example:"newsletter"`              // newsletter, paywall, age_gate or modal.
+	Element string `json:"element" example:"div#newsletter-popup"` // The element as a CSS selector.
+}
+
+// Paywall reports the signs that the page's content is paywalled.
+// @Description Paywall provider and the signals that point to one
+type Paywall struct {
+	Provider string   `json:"provider,omitempty" example:"Piano"`
+	Metered  bool     `json:"metered" example:"true"`           // Some articles are free before the wall.
+	Signals  []string `json:"signals" example:"provider,meter"` // schema_not_free, provider, paywall_markup or meter.
+}
+
+// SuspiciousLink is a link whose host imitates another domain.
+// @Description A link to a lookalike host
+type SuspiciousLink struct {
+	URL       string `json:"url" example:"https://xn--pypal-4ve.com/login"`
+	Host      string `json:"host" example:"xn--pypal-4ve.com"`         // In ASCII, as punycode.
+	Unicode   string `json:"unicode,omitempty" example:"pаypal.com"`   // As displayed, for internationalized hosts.
+	Reason    string `json:"reason" example:"homograph"`               // homograph, mixed_script or typosquat.
+	Resembles string `json:"resembles,omitempty" example:"paypal.com"` // The protected domain imitated.
+}
+
+// TrackingParams reports the page's links with campaign or click tracking
+// parameters.
+// @Description Links with tracking parameters, grouped by campaign
+type TrackingParams struct {
+	Links     []TrackedLink  `json:"links"`
+	Params    map[string]int `json:"params"`              // parameter -> links carrying it.
+	Campaigns []Campaign     `json:"campaigns,omitempty"` // Most linked first.
+}
+
+// TrackedLink is a link carrying tracking parameters.
+// @Description A link and its tracking parameters
+type TrackedLink struct {
+	URL     string   `json:"url" example:"https://shop.example/?utm_source=blog&id=7"`
+	Params  []string `json:"params" example:"utm_source"`
+	Cleaned string   `json:"cleaned,omitempty" example:"https://shop.example/?id=7"` // Set with the clean option.
+}
+
+// Campaign is a combination of utm values shared by the page's links.
+// @Description Links sharing utm_source, utm_medium and utm_campaign values
+type Campaign struct {
+	Source   string `json:"source,omitempty" example:"newsletter"`
+	Medium   string `json:"medium,omitempty" example:"email"`
+	Campaign string `json:"campaign,omitempty" example:"spring_sale"`
+	Links    int    `json:"links" example:"3"`
+}
+
+// Ways a canonical URL can differ from the fetched URL, listed in
+// Canonical.Differences.
+const (
+	CanonicalDiffScheme = "scheme"
+	CanonicalDiffHost   = "host"
+	CanonicalDiffPath   = "path"
+	CanonicalDiffQuery  = "query"
+)
+
+// Canonical is the page's canonical URL compared with the URL it was fetched from.
+// @Description The declared canonical URL and how it differs from the fetched URL
+type Canonical struct {
+	URL         string   `json:"url" example:"https://www.example.com/product"`
+	Source      string   `json:"source" example:"link"`                             // link or header.
+	FetchedURL  string   `json:"fetched_url" example:"http://example.com/product/"` // After redirects.
+	Differences []string `json:"differences,omitempty" example:"scheme,host"`       // scheme, host, path or query; empty when they match.
+	Resolves    bool     `json:"resolves" example:"true"`
+	StatusCode  int      `json:"status_code,omitempty" example:"200"` // Set when the canonical URL was requested.
+	Unchecked   bool     `json:"unchecked,omitempty"`                 // The analysis had no sub-requests left to request it.
+}
+
+// SiteVariants reports where the http and https, www and non-www variants of
+// the page's host lead.
+// @Description Whether the scheme and www variants of the site redirect to one of them
+type SiteVariants struct {
+	Variants     []SiteVariant `json:"variants"`
+	Consolidated bool          `json:"consolidated" example:"true"`                        // Every variant that answered ends on one origin.
+	Canonical    string        `json:"canonical,omitempty" example:"https://example.com/"` // The origin they end on, when consolidated.
+	Origins      []string      `json:"origins,omitempty" example:"https://example.com"`    // Origins the variants end on with a success status.
+}
+
+// SiteVariant is one probed variant of the site.
+// @Description Where one scheme and www variant of the site leads
+type SiteVariant struct {
+	URL        string   `json:"url" example:"http://www.example.com/"`
+	Redirects  []string `json:"redirects,omitempty" example:"https://example.com/"` // Locations followed.
+	FinalURL   string   `json:"final_url,omitempty" example:"https://example.com/"`
+	StatusCode int      `json:"status_code,omitempty" example:"200"` // Status of the final response.
+	Error      string   `json:"error,omitempty"`                     // Why the variant could not be followed to the end.
+	Unchecked  bool     `json:"unchecked,omitempty"`                 // The analysis had no sub-requests left to follow it.
+}
+
+// PageLanguage reports the languages a page declares and is written in.
+// @Description Declared and detected page language with hreflang alternates
+type PageLanguage struct {
+	Declared        string              `json:"declared,omitempty" example:"en-US"`      // lang attribute of <html>.
+	ContentLanguage string              `json:"content_language,omitempty" example:"en"` // Content-Language response header.
+	Detected        string              `json:"detected,omitempty" example:"en"`         // From the text; empty when unsure.
+	Alternates      []HreflangAlternate `json:"alternates,omitempty"`
+}
+
+// HreflangAlternate is a translation of the page declared with
+// <link rel="alternate" hreflang>.
+// @Description A language alternate of the page
+type HreflangAlternate struct {
+	Lang string `json:"lang" example:"de"`
+	URL  string `json:"url" example:"https://example.com/de/"`
+}
+
+// Captcha identifies a CAPTCHA widget on the page.
+// @Description A CAPTCHA provider detected on the page
+type Captcha struct {
+	Provider string `json:"provider" example:"recaptcha"` // recaptcha, hcaptcha or turnstile.
+	Version  string `json:"version,omitempty" example:"v3"`
+}
+
+// Contacts holds the contact details published on a page.
+// @Description Email addresses and phone numbers found on the page
+type Contacts struct {
+	Emails []string `json:"emails,omitempty" example:"info@example.com"`
+	Phones []string `json:"phones,omitempty" example:"+14155550100"` // Digits with an optional leading "+".
+}
+
+// SocialProfile is a social media profile the page links to.
+// @Description A social media profile linked from the page
+type SocialProfile struct {
+	Platform string `json:"platform" example:"twitter"` // twitter, linkedin, facebook, instagram, youtube or tiktok.
+	URL      string `json:"url" example:"https://x.com/example"`
+}
+
+// Technology is a CMS, framework or library the page is built with.
+// @Description A technology detected on the page
+type Technology struct {
+	Name       string   `json:"name" example:"WordPress"`
+	Category   string   `json:"category" example:"cms"` // cms, ecommerce, framework or library.
+	Version    string   `json:"version,omitempty" example:"6.4.2"`
+	Confidence int      `json:"confidence" example:"96"` // Percent.
+	Evidence   []string `json:"evidence" example:"generator: WordPress 6.4.2,path: /wp-content/"`
+}
+
+// WaybackHistory summarizes a page's snapshots in the Internet Archive.
+// @Description Wayback Machine snapshot history of the page
+type WaybackHistory struct {
+	Archived   bool       `json:"archived" example:"true"`
+	FirstSeen  *time.Time `json:"first_seen,omitempty" example:"2002-01-20T14:25:10Z"`
+	LastSeen   *time.Time `json:"last_seen,omitempty" example:"2024-01-14T08:02:44Z"`
+	ClosestURL string     `json:"closest_url,omitempty" example:"https://web.archive.org/web/20240114080244/https://example.com/"` // Latest archived copy.
+}
+
+// ReputationResult reports what a threat intelligence feed knows about the page.
+// @Description Threat feed verdicts for the page and its links
+type ReputationResult struct {
+	Source    string           `json:"source" example:"safebrowsing"` // safebrowsing, urlhaus, blocklist or dnsbl.
+	Checked   int              `json:"checked" example:"1"`           // URLs looked up, the page first.
+	Malicious bool             `json:"malicious" example:"false"`     // The page itself is listed.
+	Threats   []ThreatMatch    `json:"threats,omitempty"`
+	Links     []LinkReputation `json:"links,omitempty"` // Every external link checked, when the links option is on.
+}
+
+// Reputation verdicts reported in LinkReputation.Verdict.
+const (
+	VerdictClean  = "clean"
+	VerdictListed = "listed"
+)
+
+// LinkReputation is a threat feed's verdict on one external link.
+// @Description Threat feed verdict for a link
+type LinkReputation struct {
+	URL        string `json:"url" example:"https://partner.example/offer"`
+	Verdict    string `json:"verdict" example:"clean"`                  // clean or listed.
+	ThreatType string `json:"threat_type,omitempty" example:"phishing"` // Set when listed.
+}
+
+// ThreatMatch is a URL listed by a threat feed.
+// @Description A URL listed by a threat feed
+type ThreatMatch struct {
+	URL        string `json:"url" example:"http://malware.example/payload.exe"`
+	ThreatType string `json:"threat_type" example:"social_engineering"` // As named by the feed, lower-cased.
+}
+
+// DomainRegistration describes the registration of the page's domain.
+// @Description Registration details of the analyzed domain, from RDAP
+type DomainRegistration struct {
+	Name      string     `json:"name" example:"example.com"` // Registered domain, without subdomains.
+	Registrar string     `json:"registrar,omitempty" example:"RESERVED-Internet Assigned Numbers Authority"`
+	CreatedAt *time.Time `json:"created_at,omitempty" example:"1995-08-14T04:00:00Z"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2025-08-13T04:00:00Z"`
+	AgeDays   int        `json:"age_days,omitempty" example:"10745"`
+}
+
+// DNSPosture lists the mail and certificate security records of a domain.
+// @Description MX, SPF, DMARC, DKIM and CAA records of the analyzed domain
+type DNSPosture struct {
+	Domain        string   `json:"domain" example:"example.com"`
+	MX            []string `json:"mx,omitempty" example:"mx1.example.com"` // Most preferred first.
+	SPF           []string `json:"spf,omitempty" example:"v=spf1 include:_spf.example.net -all"`
+	DMARC         string   `json:"dmarc,omitempty" example:"v=DMARC1; p=reject"`
+	DMARCPolicy   string   `json:"dmarc_policy,omitempty" example:"reject"`
+	DKIMSelectors []string `json:"dkim_selectors,omitempty" example:"google"` // Common selectors found; others may exist.
+	CAA           []string `json:"caa,omitempty" example:"0 issue \"letsencrypt.org\""`
+}
+
+// HTTPSPosture describes how a site moves visitors to HTTPS.
+// @Description HTTPS redirect and HSTS posture of the analyzed host
+type HTTPSPosture struct {
+	Grade            string      `json:"grade" example:"A"` // A+, A, B, C, D or F.
+	HTTPSAvailable   bool        `json:"https_available" example:"true"`
+	RedirectsToHTTPS bool        `json:"redirects_to_https" example:"true"`                       // http://host/ ends on an https URL.
+	RedirectChain    []string    `json:"redirect_chain,omitempty" example:"https://example.com/"` // Locations followed from http://host/.
+	HSTS             *HSTSPolicy `json:"hsts,omitempty"`
+}
+
+// HSTSPolicy is a parsed Strict-Transport-Security header.
+// @Description HTTP Strict Transport Security policy
+type HSTSPolicy struct {
+	Header            string `json:"header" example:"max-age=31536000; includeSubDomains; preload"`
+	MaxAge            int64  `json:"max_age" example:"31536000"` // Seconds.
+	IncludeSubDomains bool   `json:"include_subdomains" example:"true"`
+	Preload           bool   `json:"preload" example:"true"`
+}
+
+// TLSReport describes a server's TLS configuration.
+// @Description Negotiated TLS parameters, accepted legacy protocols and weak ciphers, and a letter grade
+type TLSReport struct {
+	Grade            string           `json:"grade" example:"A+"` // A+, A, B, C, T (untrusted certificate) or F.
+	Available        bool             `json:"available" example:"true"`
+	Error            string           `json:"error,omitempty"` // Why no handshake succeeded.
+	Protocol         string           `json:"protocol,omitempty" example:"TLS 1.3"`
+	CipherSuite      string           `json:"cipher_suite,omitempty" example:"TLS_AES_128_GCM_SHA256"`
+	LegacyProtocols  []string         `json:"legacy_protocols,omitempty" example:"TLS 1.0"`
+	WeakCipherSuites []string         `json:"weak_cipher_suites,omitempty" example:"TLS_RSA_WITH_3DES_EDE_CBC_SHA"`
+	Certificate      *CertificateInfo `json:"certificate,omitempty"`
+	CertificateError string           `json:"certificate_error,omitempty" example:"x509: certificate has expired or is not yet valid"`
+}
+
+// CertificateInfo summarizes a server's leaf certificate.
+// @Description Leaf certificate presented by the server
+type CertificateInfo struct {
+	Subject   string    `json:"subject" example:"example.com"`
+	Issuer    string    `json:"issuer" example:"R3"`
+	NotAfter  time.Time `json:"not_after" example:"2025-01-01T00:00:00Z"`
+	DNSNames  []string  `json:"dns_names,omitempty" example:"example.com,www.example.com"`
+	KeyType   string    `json:"key_type" example:"ECDSA"`
+	Signature string    `json:"signature" example:"SHA256-RSA"`
+}
+
+// Infrastructure describes where the page is hosted.
+// @Description IP addresses of the analyzed host and their network and country
+type Infrastructure struct {
+	Host      string      `json:"host" example:"example.com"`
+	Addresses []IPAddress `json:"addresses,omitempty"`
+}
+
+// IPAddress is one address the host resolves to.
+// @Description An IP address of the analyzed host
+type IPAddress struct {
+	IP       string      `json:"ip" example:"93.184.216.34"`
+	Version  int         `json:"version" example:"4"` // 4 or 6.
+	Location *IPLocation `json:"location,omitempty"`  // Set when a geolocation database covers the address.
+}
+
+// IPLocation is what a GeoLocator knows about an address.
+// @Description Network owner and country of an IP address
+type IPLocation struct {
+	ASN     int    `json:"asn,omitempty" example:"15133"`
+	ASOrg   string `json:"as_org,omitempty" example:"EDGECAST"`
+	Country string `json:"country,omitempty" example:"US"` // ISO 3166-1 alpha-2 code.
+}
+
+// PaymentDetection lists the signs that a page collects payment card data.
+// @Description Payment card fields and hosted payment providers found on the page
+type PaymentDetection struct {
+	CardFields []string `json:"card_fields,omitempty" example:"cc-number,cvv"` // autocomplete tokens or field names.
+	Providers  []string `json:"providers,omitempty" example:"stripe"`          // Hosted payment fields or checkout scripts.
+}
+
+// LinkProbeResult reports which of a page's links failed to load.
+// @Description Result of probing the page's links
+type LinkProbeResult struct {
+	Checked    int      `json:"checked" example:"20"`
+	Broken     int      `json:"broken" example:"1"`
+	BrokenURLs []string `json:"broken_urls,omitempty"`
 }
 
 // AnalysisRequest represents a request to analyze a webpage.
 // @Description Request to analyze a webpage
 type AnalysisRequest struct {
 	URL string `json:"url" example:"https://example.com" binding:"required"`
+	// Modules limits the analysis to the named modules. Empty runs every registered module.
+	Modules []string `json:"modules,omitempty" example:"headings,links"`
+	// Options holds per-module options keyed by module name, e.g. {"links": {"probe": true}}.
+	Options map[string]ModuleOptions `json:"options,omitempty"`
+	// ForceRefresh bypasses the result cache; the fresh analysis replaces the cached one.
+	ForceRefresh bool `json:"force_refresh,omitempty" example:"false"`
+	// MaxAge is the oldest cached result the client accepts, as a Go duration such as "10m".
+	MaxAge string `json:"max_age,omitempty" example:"10m"`
+	// Auth authenticates the fetch of a page behind HTTP Basic or bearer auth.
+	Auth *AnalysisAuth `json:"auth,omitempty"`
+	// FetchTimeout limits fetching the page, as a Go duration of at most MaxFetchTimeout.
+	FetchTimeout string `json:"fetch_timeout,omitempty" example:"5s"`
+	// TotalTimeout limits the whole analysis, as a Go duration of at most
+	// MaxTotalTimeout. Modules still running when it expires are left out of
+	// the result and reported in its warnings.
+	TotalTimeout string `json:"total_timeout,omitempty" example:"10s"`
+	// AcceptLanguage is sent as the Accept-Language header of the page fetch
+	// and of the modules' own requests, instead of the default "en-US,en;q=0.5".
+	AcceptLanguage string `json:"accept_language,omitempty" example:"de-DE,de;q=0.9"`
+}
+
+// AnalysisAuth holds credentials for a protected page: Username and Password
+// for HTTP Basic auth, or Token for bearer auth. They are sent only to the
+// page's own origin, and never logged, cached or encoded in plaintext.
+// @Description Credentials for a page behind HTTP Basic or bearer auth
+type AnalysisAuth struct {
+	Username string `json:"username,omitempty" example:"staging"`
+	Password string `json:"password,omitempty" example:"s3cret"`
+	Token    string `json:"token,omitempty" example:"eyJhbGciOi..."`
+}
+
+// ModuleOptions are the options passed to one analysis module. Values may be
+// JSON strings, numbers or booleans; use the typed getters to read them.
+type ModuleOptions map[string]interface{}
+
+// CompareRequest represents a request to compare two webpages.
+// @Description Request to analyze and compare two webpages
+type CompareRequest struct {
+	URLA string `json:"url_a" example:"https://staging.example.com" binding:"required"`
+	URLB string `json:"url_b" example:"https://example.com" binding:"required"`
+}
+
+// WebpageComparison represents the result of comparing two webpages.
+// @Description Side-by-side analysis of two webpages with a structured diff
+type WebpageComparison struct {
+	A              *WebpageAnalysis `json:"a"`
+	B              *WebpageAnalysis `json:"b"`
+	Differences    ComparisonDiff   `json:"differences"`
+	ProcessingTime string           `json:"processing_time" example:"300ms"`
+}
+
+// ComparisonDiff describes how page B differs from page A.
+// Numeric deltas are computed as B minus A.
+// @Description Structured differences between two analyzed webpages
+type ComparisonDiff struct {
+	Identical              bool           `json:"identical" example:"false"`
+	TitleChanged           bool           `json:"title_changed" example:"true"`
+	HTMLVersionChanged     bool           `json:"html_version_changed" example:"false"`
+	Headings               map[string]int `json:"headings,omitempty"` // level -> delta, non-zero only.
+	InternalLinksDelta     int            `json:"internal_links_delta" example:"-3"`
+	ExternalLinksDelta     int            `json:"external_links_delta" example:"2"`
+	InaccessibleLinksDelta int            `json:"inaccessible_links_delta" example:"0"`
+	LoginFormChanged       bool           `json:"login_form_changed" example:"false"`
+	ContentChanged         bool           `json:"content_changed" example:"true"` // False when either side has no content hash.
+	Meta                   *MetaDiff      `json:"meta,omitempty"`                 // Set when a meta value changed.
+	Summary                []string       `json:"summary,omitempty"`              // e.g. "+3 external links", "login form appeared".
+}
+
+// MetaDiff lists the meta values that differ between two pages. Unchanged
+// values are nil.
+// @Description The title, description, canonical URL and robots directives that changed
+type MetaDiff struct {
+	Title       *ValueChange `json:"title,omitempty"`
+	Description *ValueChange `json:"description,omitempty"`
+	Canonical   *ValueChange `json:"canonical,omitempty"` // The canonical URL.
+	Robots      *ValueChange `json:"robots,omitempty"`    // The directives of <meta name="robots">, comma-separated.
+}
+
+// ValueChange is a value of page A and the value of page B that replaced it.
+// @Description A value before and after a change
+type ValueChange struct {
+	Before string `json:"before" example:"Old title"`
+	After  string `json:"after" example:"New title"`
+}
+
+// LanguageComparisonRequest asks for a page to be fetched once per
+// Accept-Language value.
+// @Description Request to compare a webpage across reader languages
+type LanguageComparisonRequest struct {
+	URL string `json:"url" example:"https://example.com" binding:"required"`
+	// Languages are the Accept-Language values to fetch the page with, from
+	// 2 to MaxComparedLanguages of them.
+	Languages []string `json:"languages" example:"en,de-DE,fr;q=0.9" binding:"required"`
+}
+
+// LanguageComparison reports how a page changes with the reader's language.
+// @Description A webpage fetched with several Accept-Language values, with a diff
+type LanguageComparison struct {
+	URL            string            `json:"url" example:"https://example.com"`
+	Variants       []LanguageVariant `json:"variants"` // In request order.
+	Differences    LanguageDiff      `json:"differences"`
+	ProcessingTime string            `json:"processing_time" example:"450ms"`
+}
+
+// LanguageVariant is the page as served for one Accept-Language value.
+// @Description The page as served for one Accept-Language value
+type LanguageVariant struct {
+	AcceptLanguage string        `json:"accept_language" example:"de-DE"`
+	FinalURL       string        `json:"final_url,omitempty" example:"https://example.com/de/"` // After redirects, when they led to another URL.
+	PageTitle      string        `json:"page_title" example:"Beispiel"`
+	ContentHash    string        `json:"content_hash,omitempty"`
+	Language       *PageLanguage `json:"language,omitempty"`
+}
+
+// LanguageDiff summarizes how the language variants differ.
+// @Description Differences between the language variants of a page
+type LanguageDiff struct {
+	// Negotiated is set when the page's URL, title or content changes with
+	// the Accept-Language header.
+	Negotiated      bool `json:"negotiated" example:"true"`
+	URLChanged      bool `json:"url_changed" example:"true"`
+	TitleChanged    bool `json:"title_changed" example:"true"`
+	ContentChanged  bool `json:"content_changed" example:"true"`
+	LanguageChanged bool `json:"language_changed" example:"true"` // Declared or detected language.
+	HreflangChanged bool `json:"hreflang_changed" example:"false"`
+	// MissingHreflang is set when the page is negotiated but no variant lists
+	// hreflang alternates, so crawlers, which send no Accept-Language, cannot
+	// find the other languages.
+	MissingHreflang bool     `json:"missing_hreflang" example:"false"`
+	Summary         []string `json:"summary,omitempty"` // e.g. "title differs for de-DE".
+}
+
+// DeviceComparisonRequest asks for a page to be fetched as a desktop and as a
+// mobile browser.
+// @Description Request to compare a webpage as served to desktop and mobile browsers
+type DeviceComparisonRequest struct {
+	URL string `json:"url" example:"https://example.com" binding:"required"`
+	// DesktopUserAgent and MobileUserAgent replace the default browser user
+	// agents.
+	DesktopUserAgent string `json:"desktop_user_agent,omitempty"`
+	MobileUserAgent  string `json:"mobile_user_agent,omitempty"`
+}
+
+// DeviceComparison reports how a page changes between desktop and mobile
+// browsers.
+// @Description A webpage fetched as a desktop and as a mobile browser, with a diff
+type DeviceComparison struct {
+	URL            string        `json:"url" example:"https://example.com"`
+	Desktop        DeviceVariant `json:"desktop"`
+	Mobile         DeviceVariant `json:"mobile"`
+	Differences    DeviceDiff    `json:"differences"`
+	ProcessingTime string        `json:"processing_time" example:"450ms"`
+}
+
+// DeviceVariant is the page as served to one user agent.
+// @Description The page as served to one user agent
+type DeviceVariant struct {
+	UserAgent  string `json:"user_agent"`
+	FinalURL   string `json:"final_url,omitempty" example:"https://m.example.com/"` // After redirects, when they led to another URL.
+	StatusCode int    `json:"status_code,omitempty" example:"200"`
+	PageTitle  string `json:"page_title" example:"Example"`
+	Canonical  string `json:"canonical,omitempty" example:"https://example.com/"`
+	Viewport   string `json:"viewport,omitempty" example:"width=device-width, initial-scale=1"` // Content of <meta name="viewport">.
+	BodySize   int    `json:"body_size" example:"48213"`                                        // Bytes.
+	Error      string `json:"error,omitempty"`                                                  // Why the page could not be fetched.
+}
+
+// DeviceDiff summarizes how the desktop and mobile variants differ.
+// @Description Differences between the desktop and mobile variants of a page
+type DeviceDiff struct {
+	RedirectChanged  bool    `json:"redirect_changed" example:"true"` // The variants end on different URLs.
+	TitleChanged     bool    `json:"title_changed" example:"false"`
+	CanonicalChanged bool    `json:"canonical_changed" example:"false"`
+	ViewportChanged  bool    `json:"viewport_changed" example:"false"`
+	SizeRatio        float64 `json:"size_ratio" example:"0.82"`        // Mobile body size over desktop body size.
+	SizeChanged      bool    `json:"size_changed" example:"false"`     // One body is less than half the size of the other.
+	MobileBroken     bool    `json:"mobile_broken" example:"false"`    // The mobile variant fails while the desktop one loads.
+	MissingViewport  bool    `json:"missing_viewport" example:"false"` // The mobile variant has no viewport meta tag.
+	// SuspectedCloaking is set when the variants look like different pages:
+	// they end on different sites, or differ in both title and canonical URL.
+	SuspectedCloaking bool     `json:"suspected_cloaking" example:"false"`
+	Summary           []string `json:"summary,omitempty"` // e.g. "mobile redirects to https://m.example.com/".
+}
+
+// SnapshotChanges describes how an analysis differs from the previous analysis of the same URL.
+// @Description Changes since the previous stored snapshot of the same URL
+type SnapshotChanges struct {
+	PreviousID         string         `json:"previous_id" example:"9b1c2d3e4f5a6b7c"`
+	PreviousAnalyzedAt time.Time      `json:"previous_analyzed_at" example:"2024-01-14T10:30:00Z"`
+	Differences        ComparisonDiff `json:"differences"`
+}
+
+// Crawl limits applied when a CrawlRequest leaves them unset.
+const (
+	DefaultCrawlMaxDepth = 2
+	DefaultCrawlMaxPages = 20
+)
+
+// CrawlRequest represents a request to crawl and analyze a site.
+// @Description Request to analyze a page and the same-host pages it links to
+type CrawlRequest struct {
+	URL      string `json:"url" example:"https://example.com" binding:"required"`
+	MaxDepth int    `json:"max_depth,omitempty" example:"2"`
+	MaxPages int    `json:"max_pages,omitempty" example:"20"`
+}
+
+// CrawlResult represents the result of crawling a site.
+// @Description Analyses of every page reached during a crawl
+type CrawlResult struct {
+	URL            string             `json:"url" example:"https://example.com"`
+	Pages          []*WebpageAnalysis `json:"pages"`
+	Errors         []*AnalysisError   `json:"errors,omitempty"`
+	ProcessingTime string             `json:"processing_time" example:"2.5s"`
+}
+
+// Sitemap batch limits applied when a SitemapRequest leaves them unset, and
+// the most a request may ask for.
+const (
+	DefaultSitemapMaxURLs     = 100
+	MaxSitemapURLs            = 1000
+	DefaultSitemapConcurrency = 4
+	MaxSitemapConcurrency     = 10
+)
+
+// SitemapRequest represents a request to analyze every page listed in a sitemap.
+// @Description Request to analyze the pages listed in a sitemap or sitemap index
+type SitemapRequest struct {
+	SitemapURL  string                   `json:"sitemap_url" example:"https://example.com/sitemap.xml" binding:"required"`
+	MaxURLs     int                      `json:"max_urls,omitempty" example:"100"`  // Default 100, max 1000.
+	Concurrency int                      `json:"concurrency,omitempty" example:"4"` // Pages analyzed at once; default 4, max 10.
+	Modules     []string                 `json:"modules,omitempty" example:"html_version,links"`
+	Options     map[string]ModuleOptions `json:"options,omitempty"`
+
+	// Progress, if set, is called once the sitemap is expanded and after each
+	// page. Calls are serialized.
+	Progress func(SitemapProgress) `json:"-"`
+}
+
+// SitemapProgress reports how far a sitemap analysis has got.
+// @Description Progress of a sitemap analysis
+type SitemapProgress struct {
+	Total     int `json:"total" example:"120"`    // URLs to analyze; zero until the sitemap is expanded.
+	Completed int `json:"completed" example:"42"` // Pages analyzed or failed so far.
+	Failed    int `json:"failed" example:"1"`
+}
+
+// SitemapResult represents the result of analyzing the pages of a sitemap.
+// @Description Analyses of every page listed in a sitemap, with a site-wide summary
+type SitemapResult struct {
+	SitemapURL     string             `json:"sitemap_url" example:"https://example.com/sitemap.xml"`
+	Sitemaps       int                `json:"sitemaps" example:"3"`      // Sitemap files read, including index files.
+	Truncated      bool               `json:"truncated" example:"false"` // More URLs were listed than max_urls.
+	Summary        SiteSummary        `json:"summary"`
+	Pages          []*WebpageAnalysis `json:"pages"`
+	Errors         []*AnalysisError   `json:"errors,omitempty"` // Pages and nested sitemaps that failed to load.
+	ProcessingTime string             `json:"processing_time" example:"12.5s"`
+}
+
+// SiteSummary aggregates the analyses of a set of pages.
+// @Description Site-wide totals over the analyzed pages
+type SiteSummary struct {
+	Pages              int              `json:"pages" example:"118"`
+	FailedPages        int              `json:"failed_pages" example:"2"`
+	HTMLVersions       map[string]int   `json:"html_versions"` // Version -> pages.
+	PagesWithLoginForm int              `json:"pages_with_login_form" example:"1"`
+	MissingTitles      int              `json:"missing_titles" example:"0"`
+	DuplicateTitles    []string         `json:"duplicate_titles,omitempty" example:"Home"` // Titles shared by several pages.
+	InternalLinks      int              `json:"internal_links" example:"1500"`
+	ExternalLinks      int              `json:"external_links" example:"230"`
+	InaccessibleLinks  int              `json:"inaccessible_links" example:"4"`
+	Technologies       map[string]int   `json:"technologies,omitempty"` // Technology -> pages.
+	Findings           map[Severity]int `json:"findings,omitempty"`     // Severity -> findings.
+}
+
+// TextRequest represents a request to extract a webpage's visible text.
+// @Description Request to extract the visible text of a webpage
+type TextRequest struct {
+	URL string `json:"url" example:"https://example.com" binding:"required"`
+}
+
+// HTMLRequest represents a request to analyze HTML supplied by the caller
+// instead of fetched from the web.
+// @Description Request to analyze raw HTML without fetching anything
+type HTMLRequest struct {
+	HTML string `json:"html" example:"<!DOCTYPE html><html><head><title>Example</title></head></html>" binding:"required"`
+	// BaseURL is the address the HTML would be served from. Links are resolved
+	// against it and classified as internal or external; without it every
+	// relative link is internal and absolute ones external.
+	BaseURL string   `json:"base_url,omitempty" example:"https://example.com/"`
+	Modules []string `json:"modules,omitempty" example:"html_version,headings,links"`
+}
+
+// FilesRequest represents a request to analyze HTML files on disk, such as
+// the exported pages of a static site.
+type FilesRequest struct {
+	// Paths are files, directories, searched recursively for .html and .htm
+	// files, or glob patterns.
+	Paths []string
+	// BaseURL is the address the files are served from. Each file gets the URL
+	// of its path below the directory or pattern it was found by, and links are
+	// classified against that URL. Without it files get file:// URLs.
+	BaseURL string
+	Modules []string
+}
+
+// FilesResult holds the analyses of HTML files read from disk, in path order.
+type FilesResult struct {
+	Pages          []*WebpageAnalysis `json:"pages"`
+	Errors         []*AnalysisError   `json:"errors,omitempty"`
+	ProcessingTime string             `json:"processing_time"`
+}
+
+// TextExtraction holds the visible text of a webpage.
+// @Description Cleaned visible text of a webpage, one block element per line
+type TextExtraction struct {
+	URL            string `json:"url" example:"https://example.com"`
+	PageTitle      string `json:"page_title" example:"Example Domain"`
+	Text           string `json:"text" example:"Example Domain\nThis domain is for use in illustrative examples in documents."`
+	WordCount      int    `json:"word_count" example:"11"`
+	ProcessingTime string `json:"processing_time" example:"80ms"`
 }
 
 // AnalysisError represents an error during webpage analysis.
 // @Description Detailed error response when webpage analysis fails
 type AnalysisError struct {
-	StatusCode   int    `json:"status_code" example:"404"`
+	StatusCode int `json:"status_code" example:"404"`
+	// Code is a stable, machine-readable reason: one of the ErrorCode
+	// constants or, for failed fetches, one of the client.Code constants.
+	Code         string `json:"code" example:"upstream_status"`
 	ErrorMessage string `json:"error_message" example:"Not Found: The requested webpage could not be found on the server."`
 	URL          string `json:"url" example:"https://nonexistent.example.com"`
+	// RetryAfter is how many seconds the page asked to wait before trying
+	// again, from the Retry-After header of a 429 or 503 response.
+	RetryAfter int `json:"retry_after,omitempty" example:"120"`
+	// Redirects lists the URLs followed, starting with the requested one,
+	// when a redirect loop or too long a redirect chain stopped the fetch.
+	Redirects []string `json:"redirects,omitempty" example:"https://example.com/a,https://example.com/b,https://example.com/a"`
 }
 
+// Codes of AnalysisErrors not caused by a failed fetch. Unlike the messages,
+// codes do not change, so clients can switch on them.
+const (
+	ErrorCodeInvalidRequest = "invalid_request" // The request itself is invalid.
+	ErrorCodeUpstreamStatus = "upstream_status" // The page answered with a status other than 200.
+	ErrorCodeParseFailure   = "parse_failure"   // The page could not be parsed.
+	ErrorCodeFetchFailure   = "fetch_failure"   // The fetch failed for an unclassified reason.
+	ErrorCodeInternal       = "internal_error"  // The analysis itself failed.
+	ErrorCodeCanceled       = "canceled"        // The client gave up before the page was analyzed.
+)
+
 // Error implements the error interface.
 func (e *AnalysisError) Error() string {
 	return fmt.Sprintf("HTTP %d: %s (URL: %s)", e.StatusCode, e.ErrorMessage, e.URL)
 }
 
+// FetchInfo describes how an analyzed document was retrieved.
+type FetchInfo struct {
+	URL        string
+	FinalURL   string // URL of the final response, after redirects; empty when the page was not fetched.
+	StatusCode int
+	BodySize   int           // Bytes.
+	Protocol   *ProtocolInfo // Nil when the page was not fetched.
+	Header     http.Header   // Response headers; nil when the page was not fetched.
+}
+
+// ModuleResult is the output of an AnalyzerModule. Apply copies it onto the
+// analysis; it runs after every module has finished, so it needs no locking.
+type ModuleResult interface {
+	Apply(analysis *WebpageAnalysis)
+}
+
+// ModuleResultFunc adapts an ordinary function to ModuleResult.
+type ModuleResultFunc func(analysis *WebpageAnalysis)
+
+// Apply calls f(analysis).
+func (f ModuleResultFunc) Apply(analysis *WebpageAnalysis) {
+	f(analysis)
+}
+
+// AnalyzerModule extracts one aspect of a page. Modules run concurrently on
+// the worker pool and must treat doc as read-only. opts is nil when the
+// request gave the module no options.
+type AnalyzerModule interface {
+	Name() string
+	Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error)
+}
+
+// OptionsValidator is implemented by modules that accept options. The service
+// calls ValidateOptions before fetching the page, so bad options fail fast.
+// Modules that do not implement it reject any options.
+type OptionsValidator interface {
+	ValidateOptions(opts ModuleOptions) error
+}
+
+// OptInModule is implemented by modules that only run when requested by name,
+// typically because they call external services. Modules that do not
+// implement it run by default.
+type OptInModule interface {
+	OptIn() bool
+}
+
 // Service defines the interface for webpage analysis operations.
 type Service interface {
 	AnalyzeWebpage(ctx context.Context, req AnalysisRequest) (*WebpageAnalysis, error)
+	CompareWebpages(ctx context.Context, req CompareRequest) (*WebpageComparison, error)
+	CompareLanguages(ctx context.Context, req LanguageComparisonRequest) (*LanguageComparison, error)
+	CompareDevices(ctx context.Context, req DeviceComparisonRequest) (*DeviceComparison, error)
+	CrawlSite(ctx context.Context, req CrawlRequest) (*CrawlResult, error)
+	AnalyzeSitemap(ctx context.Context, req SitemapRequest) (*SitemapResult, error)
+	ExtractText(ctx context.Context, req TextRequest) (*TextExtraction, error)
+	AnalyzeHTML(ctx context.Context, req HTMLRequest) (*WebpageAnalysis, error)
 	GetAnalysisStatus(ctx context.Context) (string, error)
 }
@@ -0,0 +1,154 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/client"
+)
+
+// Options accepted by the wayback module.
+const (
+	waybackOptionTimeout = "timeout" // duration: timeout for each Internet Archive request.
+
+	defaultWaybackTimeout = 10 * time.Second
+
+	// DefaultWaybackCDXURL is the Internet Archive's CDX search endpoint.
+	DefaultWaybackCDXURL = "https://web.archive.org/cdx/search/cdx"
+	// waybackSnapshotURL is the prefix of archived copies, followed by timestamp/original.
+	waybackSnapshotURL = "https://web.archive.org/web/"
+	// waybackTimestampLayout is the layout of CDX timestamps.
+	waybackTimestampLayout = "20060102150405"
+)
+
+// waybackModule looks up a page's history in the Internet Archive. It calls an
+// external service, so it only runs when requested by name.
+type waybackModule struct {
+	httpClient client.HTTPClient
+	cdxURL     string
+}
+
+func (m *waybackModule) Name() string { return ModuleWayback }
+
+// OptIn implements OptInModule.
+func (m *waybackModule) OptIn() bool { return true }
+
+// ValidateOptions implements OptionsValidator.
+func (m *waybackModule) ValidateOptions(opts ModuleOptions) error {
+	_, err := m.parseOptions(opts)
+	return err
+}
+
+// parseOptions reads the timeout option.
+func (m *waybackModule) parseOptions(opts ModuleOptions) (time.Duration, error) {
+	for key := range opts {
+		if key != waybackOptionTimeout {
+			return 0, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	timeout, err := opts.Duration(waybackOptionTimeout, defaultWaybackTimeout)
+	if err != nil {
+		return 0, err
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("option %q must be positive", waybackOptionTimeout)
+	}
+	return timeout, nil
+}
+
+// Analyze queries the CDX API for the page's oldest and newest snapshots.
+func (m *waybackModule) Analyze(ctx context.Context, doc *html.Node, info FetchInfo, opts ModuleOptions) (ModuleResult, error) {
+	timeout, err := m.parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	first, err := m.snapshot(ctx, info.URL, 1, timeout)
+	if err != nil {
+		return nil, err
+	}
+	history := &WaybackHistory{}
+	if first != nil {
+		// A negative limit asks the CDX API for the last results.
+		last, err := m.snapshot(ctx, info.URL, -1, timeout)
+		if err != nil {
+			return nil, err
+		}
+		if last == nil {
+			last = first
+		}
+		history.Archived = true
+		history.FirstSeen = &first.time
+		history.LastSeen = &last.time
+		history.ClosestURL = waybackSnapshotURL + last.timestamp + "/" + last.original
+	}
+
+	return ModuleResultFunc(func(a *WebpageAnalysis) { a.Wayback = history }), nil
+}
+
+// waybackSnapshot is one row of a CDX response.
+type waybackSnapshot struct {
+	timestamp string
+	original  string
+	time      time.Time
+}
+
+// snapshot fetches the first (limit 1) or last (limit -1) snapshot of pageURL,
+// or nil when the page has never been archived. The query is a sub-request of
+// the analysis.
+func (m *waybackModule) snapshot(ctx context.Context, pageURL string, limit int, timeout time.Duration) (*waybackSnapshot, error) {
+	ctx, cancel, err := subrequestsFrom(ctx).one(ctx, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the Wayback Machine: %v", err)
+	}
+	defer cancel()
+
+	body, statusCode, err := m.httpClient.FetchWebpage(ctx, waybackQueryURL(m.cdxURL, pageURL, limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the Wayback Machine: %v", err)
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("Wayback Machine returned HTTP %d", statusCode)
+	}
+	return parseWaybackCDX(body)
+}
+
+// waybackQueryURL builds a CDX query returning timestamp and original URL as JSON.
+func waybackQueryURL(cdxURL, pageURL string, limit int) string {
+	q := url.Values{}
+	q.Set("url", pageURL)
+	q.Set("output", "json")
+	q.Set("fl", "timestamp,original")
+	q.Set("limit", fmt.Sprint(limit))
+	return cdxURL + "?" + q.Encode()
+}
+
+// parseWaybackCDX decodes a JSON CDX response, a header row followed by one row
+// per snapshot, and returns the first snapshot.
+func parseWaybackCDX(body []byte) (*waybackSnapshot, error) {
+	if len(body) == 0 {
+		return nil, nil // The CDX API answers an empty body when nothing matches.
+	}
+	var rows [][]string
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode Wayback Machine response: %v", err)
+	}
+	if len(rows) < 2 {
+		return nil, nil
+	}
+	row := rows[1]
+	if len(row) < 2 {
+		return nil, fmt.Errorf("unexpected Wayback Machine row %q", row)
+	}
+	t, err := time.Parse(waybackTimestampLayout, row[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid Wayback Machine timestamp %q: %v", row[0], err)
+	}
+	return &waybackSnapshot{timestamp: row[0], original: row[1], time: t}, nil
+}
@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/worker"
+)
+
+func TestAnalyzeWebpage_Wayback(t *testing.T) {
+	page := "https://example.com"
+	mockClient := &urlMockHTTPClient{
+		responses: map[string]string{
+			page: `<html><head><title>Test</title></head></html>`,
+			waybackQueryURL(DefaultWaybackCDXURL, page, 1):  `[["timestamp","original"],["20020120142510","http://example.com:80/"]]`,
+			waybackQueryURL(DefaultWaybackCDXURL, page, -1): `[["timestamp","original"],["20240114080244","https://example.com/"]]`,
+		},
+	}
+	service := NewServiceWithDependencies(mockClient, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: page, Modules: []string{ModuleWayback}})
+
+	require.NoError(t, err)
+	require.NotNil(t, result.Wayback)
+	assert.True(t, result.Wayback.Archived)
+	assert.Equal(t, time.Date(2002, 1, 20, 14, 25, 10, 0, time.UTC), *result.Wayback.FirstSeen)
+	assert.Equal(t, time.Date(2024, 1, 14, 8, 2, 44, 0, time.UTC), *result.Wayback.LastSeen)
+	assert.Equal(t, "https://web.archive.org/web/20240114080244/https://example.com/", result.Wayback.ClosestURL)
+}
+
+func TestAnalyzeWebpage_WaybackNotArchived(t *testing.T) {
+	page := "https://new.example.com"
+	mockClient := &urlMockHTTPClient{
+		responses: map[string]string{
+			page: `<html></html>`,
+			waybackQueryURL(DefaultWaybackCDXURL, page, 1): ``,
+		},
+	}
+	service := NewServiceWithDependencies(mockClient, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: page, Modules: []string{ModuleWayback}})
+
+	require.NoError(t, err)
+	require.NotNil(t, result.Wayback)
+	assert.False(t, result.Wayback.Archived)
+	assert.Nil(t, result.Wayback.FirstSeen)
+}
+
+func TestAnalyzeWebpage_WaybackIsOptIn(t *testing.T) {
+	mockClient := &urlMockHTTPClient{responses: map[string]string{"https://example.com": `<html></html>`}}
+	service := NewServiceWithDependencies(mockClient, parser.NewHTMLParser(), worker.NewWorkerPool(2))
+
+	result, err := service.AnalyzeWebpage(context.Background(), AnalysisRequest{URL: "https://example.com"})
+
+	require.NoError(t, err)
+	assert.Nil(t, result.Wayback)
+	assert.NotContains(t, result.Modules, ModuleWayback, "The wayback module should not run unless requested")
+}
@@ -0,0 +1,188 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/logging"
+	"webpage-analyzer/internal/problem"
+)
+
+// ErrConcurrencyExceeded is matched by the LimitError of Meter.Begin when the
+// key already runs as many analyses as it may.
+var ErrConcurrencyExceeded = errors.New("API key concurrency limit exceeded")
+
+// ErrQuotaExceeded is matched by the LimitError of a key that has used its
+// daily quota.
+var ErrQuotaExceeded = errors.New("API key daily quota exceeded")
+
+// LimitError is the error of an analysis that its key's limits turned away.
+// It matches ErrConcurrencyExceeded or ErrQuotaExceeded with errors.Is, and
+// says what to tell the client.
+type LimitError struct {
+	Err        error         // ErrConcurrencyExceeded or ErrQuotaExceeded.
+	Code       string        // The matching problem code.
+	Message    string        // Human-readable, naming the limit.
+	RetryAfter time.Duration // When to try again.
+}
+
+func (e *LimitError) Error() string { return e.Message }
+func (e *LimitError) Unwrap() error { return e.Err }
+
+// RetryAfterSeconds returns RetryAfter in whole seconds, rounded up, as sent
+// in Retry-After headers.
+func (e *LimitError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// keyContextKey is the context key for the API key a request or job runs as.
+type keyContextKey struct{}
+
+// WithKey returns a context carrying key.
+func WithKey(ctx context.Context, key *Key) context.Context {
+	return context.WithValue(ctx, keyContextKey{}, key)
+}
+
+// KeyFromContext returns the API key ctx carries, if any.
+func KeyFromContext(ctx context.Context) *Key {
+	key, _ := ctx.Value(keyContextKey{}).(*Key)
+	return key
+}
+
+// TenantFromContext returns the tenant of the API key ctx carries, or "" if
+// it carries none. Stores keep each tenant's records apart by it.
+func TenantFromContext(ctx context.Context) string {
+	if key := KeyFromContext(ctx); key != nil {
+		return key.Tenant
+	}
+	return ""
+}
+
+// Meter enforces the limits of keys and accounts their usage, whichever API
+// their analyses come through, so that REST, GraphQL, gRPC and background
+// jobs share each key's concurrency cap and daily quota.
+type Meter struct {
+	store Store
+	now   func() time.Time
+
+	mu      sync.Mutex
+	running map[string]int // Analyses in flight per key ID, guarded by mu.
+}
+
+// NewMeter returns a meter that accounts usage in store by the UTC days of
+// now, or of the current time if now is nil.
+func NewMeter(store Store, now func() time.Time) *Meter {
+	if now == nil {
+		now = time.Now
+	}
+	return &Meter{store: store, now: now, running: make(map[string]int)}
+}
+
+// Begin starts one analysis by key: it takes one of the key's concurrent
+// analyses and counts it against the daily quota, failing with a LimitError.
+// The returned context counts the bytes the analysis fetches; end, which must
+// be called once it is over, records them and frees the slot.
+func (m *Meter) Begin(ctx context.Context, key *Key) (context.Context, func(), error) {
+	ctx, end, err := m.Hold(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := m.Charge(ctx, key); err != nil {
+		end()
+		return nil, nil, err
+	}
+	return ctx, end, nil
+}
+
+// Hold is Begin without counting an analysis, for calls that charge each of
+// their pages as they go, such as gRPC batches: the whole call takes one of
+// the key's concurrent analyses.
+func (m *Meter) Hold(ctx context.Context, key *Key) (context.Context, func(), error) {
+	if !m.acquire(key) {
+		return nil, nil, &LimitError{
+			Err:        ErrConcurrencyExceeded,
+			Code:       problem.CodeConcurrencyExceeded,
+			Message:    "This API key already runs " + strconv.Itoa(key.MaxConcurrent) + " analyses at once",
+			RetryAfter: time.Second,
+		}
+	}
+	fetched := new(atomic.Int64)
+	end := func() {
+		defer m.release(key)
+		m.AddBytes(ctx, key, fetched.Load())
+	}
+	return client.WithBytesFetched(ctx, fetched), end, nil
+}
+
+// Charge counts one analysis by key against its daily quota, failing with a
+// LimitError, without taking a concurrent analysis. It is for the pages of
+// background jobs, whose concurrency the job runner bounds, and of calls that
+// Hold a slot.
+func (m *Meter) Charge(ctx context.Context, key *Key) error {
+	return m.reserve(ctx, key, Today(m.now()))
+}
+
+// AddBytes adds n bytes fetched by key today.
+func (m *Meter) AddBytes(ctx context.Context, key *Key, n int64) {
+	m.addBytes(ctx, key, Today(m.now()), n)
+}
+
+// QuotaResetsIn returns how long until the daily quotas reset, at midnight UTC.
+func (m *Meter) QuotaResetsIn() time.Duration {
+	now := m.now()
+	return now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now)
+}
+
+// reserve counts one analysis by key on day.
+func (m *Meter) reserve(ctx context.Context, key *Key, day string) error {
+	ok, err := m.store.Reserve(ctx, key, day)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &LimitError{
+			Err:        ErrQuotaExceeded,
+			Code:       problem.CodeQuotaExceeded,
+			Message:    "This API key has used its daily quota of " + strconv.Itoa(key.DailyQuota) + " analyses",
+			RetryAfter: m.QuotaResetsIn(),
+		}
+	}
+	return nil
+}
+
+// addBytes adds n bytes fetched by key on day, even when ctx was cancelled
+// meanwhile. Failures are logged; usage is best effort.
+func (m *Meter) addBytes(ctx context.Context, key *Key, day string, n int64) {
+	if n <= 0 {
+		return
+	}
+	if err := m.store.AddBytes(context.WithoutCancel(ctx), key, day, n); err != nil {
+		logging.FromContext(ctx).Error("Failed to count bytes fetched", "error", err, "bytes", n)
+	}
+}
+
+// acquire takes one of key's concurrent analyses, reporting false if all are in use.
+func (m *Meter) acquire(key *Key) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if key.MaxConcurrent > 0 && m.running[key.ID] >= key.MaxConcurrent {
+		return false
+	}
+	m.running[key.ID]++
+	return true
+}
+
+// release returns one of key's concurrent analyses.
+func (m *Meter) release(key *Key) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running[key.ID]--; m.running[key.ID] <= 0 {
+		delete(m.running, key.ID)
+	}
+}
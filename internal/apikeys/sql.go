@@ -0,0 +1,292 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"webpage-analyzer/internal/sqldb"
+)
+
+// schema creates the key and usage tables. It is valid for both SQLite and Postgres.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS api_keys (
+		id             TEXT PRIMARY KEY,
+		tenant         TEXT NOT NULL,
+		name           TEXT NOT NULL DEFAULT '',
+		prefix         TEXT NOT NULL,
+		secret_hash    TEXT NOT NULL UNIQUE,
+		daily_quota    INTEGER NOT NULL DEFAULT 0,
+		max_concurrent INTEGER NOT NULL DEFAULT 0,
+		created_at     BIGINT NOT NULL,
+		revoked_at     BIGINT NOT NULL DEFAULT 0
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_api_keys_tenant ON api_keys (tenant)`,
+	`CREATE TABLE IF NOT EXISTS api_usage (
+		key_id        TEXT NOT NULL,
+		tenant        TEXT NOT NULL,
+		day           TEXT NOT NULL,
+		analyses      BIGINT NOT NULL DEFAULT 0,
+		bytes_fetched BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (key_id, day)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_api_usage_tenant_day ON api_usage (tenant, day)`,
+}
+
+// keyColumns lists the columns read by scanKey, in order.
+const keyColumns = `id, tenant, name, prefix, daily_quota, max_concurrent, created_at, revoked_at`
+
+// sqlStore implements Store on top of database/sql.
+type sqlStore struct {
+	db     *sql.DB
+	driver string
+	now    func() time.Time
+}
+
+// Open connects to the configured database and ensures the key tables exist.
+func Open(ctx context.Context, cfg Config) (Store, error) {
+	db, driver, err := sqldb.Open(ctx, cfg.Driver, cfg.DSN, "API key store", schema)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlStore{db: db, driver: driver, now: time.Now}, nil
+}
+
+// Create adds a key and returns it with its secret.
+func (s *sqlStore) Create(ctx context.Context, spec Spec) (*Key, string, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, "", err
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate key ID: %v", err)
+	}
+	random, err := randomHex(24)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate key secret: %v", err)
+	}
+	secret := secretPrefix + random
+
+	_, err = s.db.ExecContext(ctx,
+		s.rebind(`INSERT INTO api_keys (id, tenant, name, prefix, secret_hash, daily_quota, max_concurrent, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+		id, spec.Tenant, spec.Name, secret[:len(secretPrefix)+4], hashSecret(secret), spec.DailyQuota, spec.MaxConcurrent, s.now().UTC().UnixNano(),
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create API key: %v", err)
+	}
+	key, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	return key, secret, nil
+}
+
+// Get loads a key by ID.
+func (s *sqlStore) Get(ctx context.Context, id string) (*Key, error) {
+	row := s.db.QueryRowContext(ctx, s.rebind(`SELECT `+keyColumns+` FROM api_keys WHERE id = ?`), id)
+	key, err := scanKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return key, err
+}
+
+// List returns the keys of tenant, or of every tenant, oldest first.
+func (s *sqlStore) List(ctx context.Context, tenant string) ([]*Key, error) {
+	query := `SELECT ` + keyColumns + ` FROM api_keys`
+	var args []interface{}
+	if tenant != "" {
+		query += ` WHERE tenant = ?`
+		args = append(args, tenant)
+	}
+	query += ` ORDER BY created_at, id`
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %v", err)
+	}
+	defer rows.Close()
+
+	keys := make([]*Key, 0)
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %v", err)
+	}
+	return keys, nil
+}
+
+// Revoke revokes a key, keeping the time of an earlier revocation.
+func (s *sqlStore) Revoke(ctx context.Context, id string) (*Key, error) {
+	_, err := s.db.ExecContext(ctx,
+		s.rebind(`UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at = 0`),
+		s.now().UTC().UnixNano(), id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke API key %s: %v", id, err)
+	}
+	return s.Get(ctx, id)
+}
+
+// Authenticate returns the active key with the given secret.
+func (s *sqlStore) Authenticate(ctx context.Context, secret string) (*Key, error) {
+	if !strings.HasPrefix(secret, secretPrefix) {
+		return nil, ErrInvalidKey
+	}
+	row := s.db.QueryRowContext(ctx, s.rebind(`SELECT `+keyColumns+` FROM api_keys WHERE secret_hash = ?`), hashSecret(secret))
+	key, err := scanKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate API key: %v", err)
+	}
+	if key.Revoked() {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
+
+// Reserve counts one analysis by key on day unless its quota is used up.
+func (s *sqlStore) Reserve(ctx context.Context, key *Key, day string) (bool, error) {
+	if err := s.ensureUsage(ctx, key, day); err != nil {
+		return false, err
+	}
+	res, err := s.db.ExecContext(ctx,
+		s.rebind(`UPDATE api_usage SET analyses = analyses + 1 WHERE key_id = ? AND day = ? AND (? = 0 OR analyses < ?)`),
+		key.ID, day, key.DailyQuota, key.DailyQuota,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to count analysis for API key %s: %v", key.ID, err)
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// AddBytes adds n bytes fetched by key on day.
+func (s *sqlStore) AddBytes(ctx context.Context, key *Key, day string, n int64) error {
+	if err := s.ensureUsage(ctx, key, day); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx,
+		s.rebind(`UPDATE api_usage SET bytes_fetched = bytes_fetched + ? WHERE key_id = ? AND day = ?`),
+		n, key.ID, day,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to count bytes for API key %s: %v", key.ID, err)
+	}
+	return nil
+}
+
+// ensureUsage creates the usage row of key on day if it does not exist yet.
+func (s *sqlStore) ensureUsage(ctx context.Context, key *Key, day string) error {
+	_, err := s.db.ExecContext(ctx,
+		s.rebind(`INSERT INTO api_usage (key_id, tenant, day) VALUES (?, ?, ?) ON CONFLICT (key_id, day) DO NOTHING`),
+		key.ID, key.Tenant, day,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record usage for API key %s: %v", key.ID, err)
+	}
+	return nil
+}
+
+// Usage returns the usage matching q, summed over each tenant's keys.
+func (s *sqlStore) Usage(ctx context.Context, q UsageQuery) ([]Usage, error) {
+	var (
+		where []string
+		args  []interface{}
+	)
+	if q.Tenant != "" {
+		where, args = append(where, `tenant = ?`), append(args, q.Tenant)
+	}
+	if q.From != "" {
+		where, args = append(where, `day >= ?`), append(args, q.From)
+	}
+	if q.To != "" {
+		where, args = append(where, `day <= ?`), append(args, q.To)
+	}
+	query := `SELECT tenant, day, SUM(analyses), SUM(bytes_fetched) FROM api_usage`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	query += ` GROUP BY tenant, day ORDER BY tenant, day`
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load usage: %v", err)
+	}
+	defer rows.Close()
+
+	usage := make([]Usage, 0)
+	for rows.Next() {
+		var u Usage
+		if err := rows.Scan(&u.Tenant, &u.Day, &u.Analyses, &u.BytesFetched); err != nil {
+			return nil, fmt.Errorf("failed to load usage: %v", err)
+		}
+		usage = append(usage, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load usage: %v", err)
+	}
+	return usage, nil
+}
+
+// Close releases the database connection.
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanKey decodes a single api_keys row selected with keyColumns.
+func scanKey(row rowScanner) (*Key, error) {
+	var (
+		key                  Key
+		createdAt, revokedAt int64
+	)
+	err := row.Scan(&key.ID, &key.Tenant, &key.Name, &key.Prefix, &key.DailyQuota, &key.MaxConcurrent, &createdAt, &revokedAt)
+	if err != nil {
+		return nil, err
+	}
+	key.CreatedAt = time.Unix(0, createdAt).UTC()
+	if revokedAt != 0 {
+		t := time.Unix(0, revokedAt).UTC()
+		key.RevokedAt = &t
+	}
+	return &key, nil
+}
+
+// rebind rewrites '?' placeholders to the driver's native syntax.
+func (s *sqlStore) rebind(query string) string {
+	return sqldb.Rebind(s.driver, query)
+}
+
+// hashSecret returns the hash a secret is stored and looked up by. Secrets
+// are random, so a fast unsalted hash is enough.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex returns n random bytes, hex encoded.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,140 @@
+package apikeys
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStore opens an in-memory store whose clock the test controls.
+func newTestStore(t *testing.T) (*sqlStore, *time.Time) {
+	t.Helper()
+	s, err := Open(context.Background(), Config{Driver: DriverMemory})
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	ss := s.(*sqlStore)
+	ss.now = func() time.Time { return now }
+	return ss, &now
+}
+
+func TestStore_KeyLifecycle(t *testing.T) {
+	s, now := newTestStore(t)
+	ctx := context.Background()
+
+	key, secret, err := s.Create(ctx, Spec{Tenant: "acme", Name: "CI", DailyQuota: 10, MaxConcurrent: 2})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, secretPrefix))
+	assert.True(t, strings.HasPrefix(secret, key.Prefix))
+	assert.Equal(t, "acme", key.Tenant)
+	assert.Equal(t, 10, key.DailyQuota)
+	assert.Equal(t, 2, key.MaxConcurrent)
+	assert.Equal(t, *now, key.CreatedAt)
+
+	authenticated, err := s.Authenticate(ctx, secret)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, authenticated.ID)
+	_, err = s.Authenticate(ctx, secret+"x")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = s.Authenticate(ctx, "")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	_, _, err = s.Create(ctx, Spec{Tenant: "other"})
+	require.NoError(t, err)
+	keys, err := s.List(ctx, "acme")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	all, err := s.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	revoked, err := s.Revoke(ctx, key.ID)
+	require.NoError(t, err)
+	require.NotNil(t, revoked.RevokedAt)
+	*now = now.Add(time.Hour)
+	again, err := s.Revoke(ctx, key.ID)
+	require.NoError(t, err)
+	assert.Equal(t, revoked.RevokedAt, again.RevokedAt, "Revoking twice should keep the first revocation time")
+	_, err = s.Authenticate(ctx, secret)
+	assert.ErrorIs(t, err, ErrInvalidKey, "A revoked key should no longer authenticate")
+
+	_, err = s.Revoke(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStore_CreateValidatesSpec(t *testing.T) {
+	s, _ := newTestStore(t)
+	for _, spec := range []Spec{
+		{},
+		{Tenant: "Acme Corp"},
+		{Tenant: "acme", DailyQuota: -1},
+		{Tenant: "acme", MaxConcurrent: -1},
+	} {
+		_, _, err := s.Create(context.Background(), spec)
+		assert.Error(t, err, "%+v", spec)
+	}
+}
+
+func TestStore_ReserveHonoursDailyQuota(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+	key, _, err := s.Create(ctx, Spec{Tenant: "acme", DailyQuota: 2})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		ok, err := s.Reserve(ctx, key, "2024-01-15")
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+	ok, err := s.Reserve(ctx, key, "2024-01-15")
+	require.NoError(t, err)
+	assert.False(t, ok, "The third analysis of the day should exceed the quota")
+
+	ok, err = s.Reserve(ctx, key, "2024-01-16")
+	require.NoError(t, err)
+	assert.True(t, ok, "The quota should start over the next day")
+
+	unlimited, _, err := s.Create(ctx, Spec{Tenant: "acme"})
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		ok, err := s.Reserve(ctx, unlimited, "2024-01-15")
+		require.NoError(t, err)
+		assert.True(t, ok, "A key without a quota should not be limited")
+	}
+}
+
+func TestStore_UsagePerTenantAndDay(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+	first, _, err := s.Create(ctx, Spec{Tenant: "acme"})
+	require.NoError(t, err)
+	second, _, err := s.Create(ctx, Spec{Tenant: "acme"})
+	require.NoError(t, err)
+	other, _, err := s.Create(ctx, Spec{Tenant: "globex"})
+	require.NoError(t, err)
+
+	for _, key := range []*Key{first, second, other} {
+		_, err := s.Reserve(ctx, key, "2024-01-15")
+		require.NoError(t, err)
+		require.NoError(t, s.AddBytes(ctx, key, "2024-01-15", 1000))
+	}
+	_, err = s.Reserve(ctx, first, "2024-01-16")
+	require.NoError(t, err)
+
+	usage, err := s.Usage(ctx, UsageQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, []Usage{
+		{Tenant: "acme", Day: "2024-01-15", Analyses: 2, BytesFetched: 2000},
+		{Tenant: "acme", Day: "2024-01-16", Analyses: 1},
+		{Tenant: "globex", Day: "2024-01-15", Analyses: 1, BytesFetched: 1000},
+	}, usage)
+
+	usage, err = s.Usage(ctx, UsageQuery{Tenant: "acme", From: "2024-01-16", To: "2024-01-31"})
+	require.NoError(t, err)
+	assert.Equal(t, []Usage{{Tenant: "acme", Day: "2024-01-16", Analyses: 1}}, usage)
+}
@@ -0,0 +1,131 @@
+// Package apikeys manages the API keys of the service's tenants. Each key
+// belongs to one tenant and carries its own limits: a daily quota of analyses
+// and a cap on analyses running at once. Usage, the analyses run and the bytes
+// they fetched, is accounted per key and UTC day and reported per tenant.
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"webpage-analyzer/internal/sqldb"
+)
+
+// Supported key store drivers. DriverMemory is an in-process SQLite database
+// whose keys and usage are lost on restart.
+const (
+	DriverSQLite   = sqldb.DriverSQLite
+	DriverPostgres = sqldb.DriverPostgres
+	DriverMemory   = sqldb.DriverMemory
+)
+
+// secretPrefix starts every key secret, so that leaked keys are easy to spot.
+const secretPrefix = "wpa_"
+
+// DayFormat is the layout of the UTC days usage is accounted by.
+const DayFormat = "2006-01-02"
+
+// ErrNotFound is returned when a requested key does not exist.
+var ErrNotFound = errors.New("API key not found")
+
+// ErrInvalidKey is returned when authenticating with an unknown or revoked key.
+var ErrInvalidKey = errors.New("invalid or revoked API key")
+
+// tenantPattern restricts tenant names to what reads well in URLs and logs.
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+// Config selects the key store's storage.
+type Config struct {
+	Driver string // DriverSQLite, DriverPostgres or DriverMemory.
+	DSN    string // File path for SQLite, connection string for Postgres; unused for memory.
+}
+
+// Key is a tenant's API key. The secret itself is only returned when the key
+// is created; the store keeps its hash.
+// @Description API key of a tenant
+type Key struct {
+	ID            string     `json:"id" example:"3f2a9c1d5e7b8a04"`
+	Tenant        string     `json:"tenant" example:"acme"`
+	Name          string     `json:"name,omitempty" example:"CI pipeline"`
+	Prefix        string     `json:"prefix" example:"wpa_3b1f"`  // Start of the secret, to tell keys apart.
+	DailyQuota    int        `json:"daily_quota" example:"1000"` // Analyses per UTC day; 0 means no limit.
+	MaxConcurrent int        `json:"max_concurrent" example:"5"` // Analyses running at once; 0 means no limit.
+	CreatedAt     time.Time  `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" example:"2024-02-01T08:00:00Z"`
+}
+
+// Revoked reports whether the key has been revoked.
+func (k *Key) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// Spec describes a key to create.
+// @Description New API key
+type Spec struct {
+	Tenant        string `json:"tenant" example:"acme"`
+	Name          string `json:"name,omitempty" example:"CI pipeline"`
+	DailyQuota    int    `json:"daily_quota" example:"1000"` // 0 means no limit.
+	MaxConcurrent int    `json:"max_concurrent" example:"5"` // 0 means no limit.
+}
+
+// Validate checks that the spec describes a usable key.
+func (s Spec) Validate() error {
+	if !tenantPattern.MatchString(s.Tenant) {
+		return fmt.Errorf("tenant must be 1 to 64 lowercase letters, digits, '.', '_' or '-', starting with a letter or digit")
+	}
+	if len(s.Name) > 100 {
+		return fmt.Errorf("name must be at most 100 characters")
+	}
+	if s.DailyQuota < 0 || s.MaxConcurrent < 0 {
+		return fmt.Errorf("daily_quota and max_concurrent must not be negative")
+	}
+	return nil
+}
+
+// Usage is what one tenant used on one UTC day.
+type Usage struct {
+	Tenant       string
+	Day          string // In DayFormat.
+	Analyses     int64
+	BytesFetched int64
+}
+
+// UsageQuery selects usage rows. Empty fields do not filter.
+type UsageQuery struct {
+	Tenant string
+	From   string // First day, in DayFormat.
+	To     string // Last day, in DayFormat.
+}
+
+// Store persists keys and their usage.
+type Store interface {
+	// Create adds a key and returns it with its secret, which is not kept.
+	Create(ctx context.Context, spec Spec) (*Key, string, error)
+	// Get loads a key by ID.
+	Get(ctx context.Context, id string) (*Key, error)
+	// List returns the keys of tenant, or of every tenant when it is empty,
+	// oldest first.
+	List(ctx context.Context, tenant string) ([]*Key, error)
+	// Revoke revokes a key; revoking a revoked key keeps its revocation time.
+	Revoke(ctx context.Context, id string) (*Key, error)
+	// Authenticate returns the key with the given secret, or ErrInvalidKey.
+	Authenticate(ctx context.Context, secret string) (*Key, error)
+	// Reserve counts one analysis by key on day, unless that would exceed
+	// its daily quota. It reports whether the analysis was counted.
+	Reserve(ctx context.Context, key *Key, day string) (bool, error)
+	// AddBytes adds n bytes fetched by key on day.
+	AddBytes(ctx context.Context, key *Key, day string, n int64) error
+	// Usage returns the usage matching q, per tenant and day, ordered by
+	// tenant, then day.
+	Usage(ctx context.Context, q UsageQuery) ([]Usage, error)
+	// Close releases the store's resources.
+	Close() error
+}
+
+// Today returns the UTC day of t, in DayFormat.
+func Today(t time.Time) string {
+	return t.UTC().Format(DayFormat)
+}
@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// entriesBucket maps cache keys to JSON-encoded entries.
+	entriesBucket = []byte("entries")
+	// storedBucket indexes keys by the time they were stored: an 8-byte
+	// big-endian Unix nanosecond time followed by the key, so a cursor walks
+	// entries oldest first.
+	storedBucket = []byte("stored")
+	// metaBucket holds bookkeeping about the cache, under countKey the number
+	// of entries, so that a full cache is noticed without counting them.
+	metaBucket = []byte("meta")
+	countKey   = []byte("count")
+)
+
+// PersistentCache is a Cache kept outside the process, which must be closed.
+type PersistentCache interface {
+	Cache
+	// Ping checks that the cache can still be read.
+	Ping() error
+	Close() error
+}
+
+// diskCache is a Cache kept in a bbolt database file, so cached analyses
+// survive restarts.
+type diskCache struct {
+	db  *bolt.DB
+	cfg Config
+	now func() time.Time
+}
+
+// OpenDiskCache opens, or creates, a cache in the database file at path and
+// drops the entries that expired while it was closed. A non-positive
+// MaxEntries means no size limit. Only one process can have the file open.
+func OpenDiskCache(path string, cfg Config) (PersistentCache, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache file: %v", err)
+	}
+	c := &diskCache{db: db, cfg: cfg, now: time.Now}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{entriesBucket, storedBucket, metaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		if tx.Bucket(metaBucket).Get(countKey) == nil {
+			// A file written before entries were counted: count them once.
+			if err := c.setCount(tx, tx.Bucket(entriesBucket).Stats().KeyN); err != nil {
+				return err
+			}
+		}
+		return c.dropExpired(tx)
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to prepare cache file: %v", err)
+	}
+	return c, nil
+}
+
+// Get returns the entry for key unless it has outlived the TTL.
+func (c *diskCache) Get(key string) (*Entry, bool) {
+	var entry *Entry
+	err := c.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(entriesBucket).Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(data, &entry)
+	})
+	if err != nil {
+		slog.Error("Failed to read cached analysis", "error", err)
+		return nil, false
+	}
+	if entry == nil {
+		return nil, false
+	}
+	if c.now().Sub(entry.StoredAt) > c.cfg.TTL {
+		if err := c.db.Update(func(tx *bolt.Tx) error { return c.delete(tx, []byte(key)) }); err != nil {
+			slog.Error("Failed to drop expired cached analysis", "error", err)
+		}
+		return nil, false
+	}
+	return entry, true
+}
+
+// Set stores entry under key, evicting the oldest entry if the cache is full.
+// Failures are logged; the cache is best effort.
+func (c *diskCache) Set(key string, entry *Entry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		slog.Error("Failed to encode analysis for the cache", "error", err)
+		return
+	}
+	err = c.db.Update(func(tx *bolt.Tx) error {
+		entries := tx.Bucket(entriesBucket)
+		full := c.cfg.MaxEntries > 0 && entries.Get([]byte(key)) == nil && c.count(tx) >= c.cfg.MaxEntries
+		if err := c.delete(tx, []byte(key)); err != nil {
+			return err
+		}
+		if full {
+			if oldest, _ := tx.Bucket(storedBucket).Cursor().First(); oldest != nil {
+				if err := c.delete(tx, oldest[8:]); err != nil {
+					return err
+				}
+			}
+		}
+		if err := entries.Put([]byte(key), data); err != nil {
+			return err
+		}
+		if err := c.setCount(tx, c.count(tx)+1); err != nil {
+			return err
+		}
+		return tx.Bucket(storedBucket).Put(storedKey(entry.StoredAt, []byte(key)), nil)
+	})
+	if err != nil {
+		slog.Error("Failed to store analysis in the cache", "error", err)
+	}
+}
+
+// Ping reads the cache file in a transaction of its own.
+func (c *diskCache) Ping() error {
+	return c.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(entriesBucket) == nil {
+			return errors.New("cache file has no entries bucket")
+		}
+		return nil
+	})
+}
+
+// TTL returns the configured time to live.
+func (c *diskCache) TTL() time.Duration {
+	return c.cfg.TTL
+}
+
+// Close closes the database file.
+func (c *diskCache) Close() error {
+	return c.db.Close()
+}
+
+// delete removes key and its index entry, if it is cached.
+func (c *diskCache) delete(tx *bolt.Tx, key []byte) error {
+	entries := tx.Bucket(entriesBucket)
+	data := entries.Get(key)
+	if data == nil {
+		return nil
+	}
+	var old Entry
+	if err := json.Unmarshal(data, &old); err == nil {
+		if err := tx.Bucket(storedBucket).Delete(storedKey(old.StoredAt, key)); err != nil {
+			return err
+		}
+	}
+	if err := entries.Delete(key); err != nil {
+		return err
+	}
+	return c.setCount(tx, c.count(tx)-1)
+}
+
+// count returns the number of cached entries.
+func (c *diskCache) count(tx *bolt.Tx) int {
+	data := tx.Bucket(metaBucket).Get(countKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return int(binary.BigEndian.Uint64(data))
+}
+
+// setCount records the number of cached entries.
+func (c *diskCache) setCount(tx *bolt.Tx, n int) error {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, uint64(max(n, 0)))
+	return tx.Bucket(metaBucket).Put(countKey, data)
+}
+
+// dropExpired removes the entries that have outlived the TTL.
+func (c *diskCache) dropExpired(tx *bolt.Tx) error {
+	cutoff := storedKey(c.now().Add(-c.cfg.TTL), nil)
+	cursor := tx.Bucket(storedBucket).Cursor()
+	var expired [][]byte
+	for k, _ := cursor.First(); k != nil && bytes.Compare(k, cutoff) < 0; k, _ = cursor.Next() {
+		expired = append(expired, append([]byte(nil), k[8:]...))
+	}
+	for _, key := range expired {
+		if err := c.delete(tx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storedKey returns the index key of a cache key stored at t.
+func storedKey(t time.Time, key []byte) []byte {
+	index := make([]byte, 8, 8+len(key))
+	binary.BigEndian.PutUint64(index, uint64(t.UnixNano()))
+	return append(index, key...)
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+// openDiskCache opens a disk cache in a temporary directory with a fixed clock.
+func openDiskCache(t *testing.T, path string, cfg Config, now time.Time) *diskCache {
+	t.Helper()
+	c, err := OpenDiskCache(path, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	disk := c.(*diskCache)
+	disk.now = func() time.Time { return now }
+	return disk
+}
+
+func TestDiskCache_SurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	svc := NewCachingService(&countingService{}, mustOpenDiskCache(t, path))
+	_, err := svc.AnalyzeWebpage(context.Background(), analyzer.AnalysisRequest{URL: "https://example.com"})
+	require.NoError(t, err)
+	require.NoError(t, svc.(*cachingService).cache.(PersistentCache).Close())
+
+	inner := &countingService{}
+	svc = NewCachingService(inner, mustOpenDiskCache(t, path))
+	analysis, err := svc.AnalyzeWebpage(context.Background(), analyzer.AnalysisRequest{URL: "https://example.com"})
+	require.NoError(t, err)
+	assert.True(t, analysis.Cache.Hit, "A cached analysis should be served after a restart")
+	assert.Equal(t, "Stub", analysis.PageTitle)
+	assert.Zero(t, inner.calls)
+}
+
+// mustOpenDiskCache opens a disk cache with the default configuration.
+func mustOpenDiskCache(t *testing.T, path string) PersistentCache {
+	t.Helper()
+	c, err := OpenDiskCache(path, DefaultConfig())
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestDiskCache_ExpiryAndEviction(t *testing.T) {
+	now := time.Now()
+	c := openDiskCache(t, filepath.Join(t.TempDir(), "cache.db"), Config{TTL: time.Minute, MaxEntries: 2}, now)
+
+	c.Set("a", &Entry{StoredAt: now.Add(-30 * time.Second)})
+	c.Set("b", &Entry{StoredAt: now})
+	c.Set("b", &Entry{StoredAt: now}) // Replacing an entry does not evict another.
+	c.Set("c", &Entry{StoredAt: now})
+
+	_, ok := c.Get("a")
+	assert.False(t, ok, "The oldest entry should be evicted when the cache is full")
+	_, ok = c.Get("b")
+	assert.True(t, ok)
+	_, ok = c.Get("c")
+	assert.True(t, ok)
+
+	c.now = func() time.Time { return now.Add(2 * time.Minute) }
+	_, ok = c.Get("c")
+	assert.False(t, ok, "Entries older than the TTL should expire")
+}
+
+func TestDiskCache_CountsEntries(t *testing.T) {
+	now := time.Now()
+	path := filepath.Join(t.TempDir(), "cache.db")
+	c := openDiskCache(t, path, Config{TTL: time.Minute, MaxEntries: 2}, now)
+	entries := func() (counted, stored int) {
+		require.NoError(t, c.db.View(func(tx *bolt.Tx) error {
+			counted, stored = c.count(tx), tx.Bucket(entriesBucket).Stats().KeyN
+			return nil
+		}))
+		return counted, stored
+	}
+
+	c.Set("a", &Entry{StoredAt: now})
+	c.Set("a", &Entry{StoredAt: now})
+	c.Set("b", &Entry{StoredAt: now})
+	c.Set("c", &Entry{StoredAt: now})
+	counted, stored := entries()
+	assert.Equal(t, 2, counted, "Replacements and evictions should keep the count")
+	assert.Equal(t, stored, counted)
+
+	// Files written before entries were counted are counted when opened.
+	require.NoError(t, c.db.Update(func(tx *bolt.Tx) error { return tx.Bucket(metaBucket).Delete(countKey) }))
+	require.NoError(t, c.Close())
+	c = openDiskCache(t, path, Config{TTL: time.Minute, MaxEntries: 2}, now)
+	counted, _ = entries()
+	assert.Equal(t, 2, counted)
+}
+
+func TestDiskCache_DropsExpiredOnOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	now := time.Now()
+	c := openDiskCache(t, path, Config{TTL: time.Minute}, now)
+	c.Set("old", &Entry{StoredAt: now.Add(-2 * time.Minute)})
+	c.Set("new", &Entry{StoredAt: now})
+	require.NoError(t, c.Close())
+
+	c = openDiskCache(t, path, Config{TTL: time.Minute}, now)
+	var keys []string
+	require.NoError(t, c.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(entriesBucket).ForEach(func(k, _ []byte) error {
+			keys = append(keys, string(k))
+			return nil
+		})
+	}))
+	assert.Equal(t, []string{"new"}, keys, "Entries that expired while closed should be dropped")
+}
+
+func TestDiskCache_Ping(t *testing.T) {
+	c, err := OpenDiskCache(filepath.Join(t.TempDir(), "cache.db"), DefaultConfig())
+	require.NoError(t, err)
+	assert.NoError(t, c.Ping())
+
+	require.NoError(t, c.Close())
+	assert.Error(t, c.Ping(), "A closed cache should fail Ping()")
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// memoryCache is a Cache held in process memory.
+type memoryCache struct {
+	mu      sync.Mutex
+	cfg     Config
+	entries map[string]*Entry
+	now     func() time.Time
+}
+
+// NewMemoryCache creates an in-memory cache. A non-positive MaxEntries means no size limit.
+func NewMemoryCache(cfg Config) Cache {
+	return &memoryCache{
+		cfg:     cfg,
+		entries: make(map[string]*Entry),
+		now:     time.Now,
+	}
+}
+
+// Get returns the entry for key unless it has outlived the TTL.
+func (c *memoryCache) Get(key string) (*Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if c.now().Sub(entry.StoredAt) > c.cfg.TTL {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry, true
+}
+
+// Set stores entry under key, evicting the oldest entry if the cache is full.
+func (c *memoryCache) Set(key string, entry *Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; !exists && c.cfg.MaxEntries > 0 && len(c.entries) >= c.cfg.MaxEntries {
+		c.evictOldest()
+	}
+	c.entries[key] = entry
+}
+
+// TTL returns the configured time to live.
+func (c *memoryCache) TTL() time.Duration {
+	return c.cfg.TTL
+}
+
+// evictOldest removes the entry with the earliest StoredAt. The caller must hold mu.
+func (c *memoryCache) evictOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if oldestKey == "" || entry.StoredAt.Before(oldest) {
+			oldestKey, oldest = key, entry.StoredAt
+		}
+	}
+	delete(c.entries, oldestKey)
+}
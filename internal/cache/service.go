@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/logging"
+	"webpage-analyzer/internal/urlnorm"
+)
+
+// cachingService decorates an analyzer.Service with a result cache.
+type cachingService struct {
+	analyzer.Service
+	cache Cache
+	urls  urlnorm.Options
+}
+
+// NewCachingService wraps an analyzer service so that analyses are served from
+// the cache when possible. Every analysis it returns carries cache metadata.
+// Requests can skip the cache with ForceRefresh or limit the age of a cached
+// result with MaxAge; the fresh analysis is cached either way. A nil c caches
+// nothing.
+func NewCachingService(inner analyzer.Service, c Cache) analyzer.Service {
+	return NewCachingServiceWithKeys(inner, c, urlnorm.Options{})
+}
+
+// NewCachingServiceWithKeys is like NewCachingService, with page URLs
+// normalized by urls before they become cache keys. Case, default ports, dot
+// segments and fragments are always ignored.
+func NewCachingServiceWithKeys(inner analyzer.Service, c Cache, urls urlnorm.Options) analyzer.Service {
+	if c == nil {
+		c = NewNoopCache()
+	}
+	return &cachingService{
+		Service: inner,
+		cache:   c,
+		urls:    urls,
+	}
+}
+
+// WithCache is an analyzer.NewService option that serves analyses from c as
+// NewCachingService does. A nil c caches nothing.
+func WithCache(c Cache) analyzer.Option {
+	return analyzer.WithDecorator(func(inner analyzer.Service) analyzer.Service {
+		return NewCachingService(inner, c)
+	})
+}
+
+// AnalyzeWebpage returns a cached analysis of the page if one is fresh enough,
+// and analyzes it otherwise.
+func (s *cachingService) AnalyzeWebpage(ctx context.Context, req analyzer.AnalysisRequest) (*analyzer.WebpageAnalysis, error) {
+	maxAge, err := parseMaxAge(req.MaxAge)
+	if err != nil {
+		return nil, &analyzer.AnalysisError{
+			StatusCode:   http.StatusBadRequest,
+			Code:         analyzer.ErrorCodeInvalidRequest,
+			ErrorMessage: fmt.Sprintf("Invalid max_age: %v", err),
+			URL:          req.URL,
+		}
+	}
+
+	key := requestKey(req, s.urls)
+	if !req.ForceRefresh {
+		if entry, ok := s.cache.Get(key); ok {
+			age := time.Since(entry.StoredAt)
+			if maxAge < 0 || age <= maxAge {
+				logging.FromContext(ctx).Info("Serving cached analysis", "url", req.URL, "age", age)
+				return withCacheInfo(entry.Analysis, true, age, s.cache.TTL()), nil
+			}
+		}
+	}
+
+	analysis, err := s.Service.AnalyzeWebpage(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// An analysis missing modules, because they failed or its time budget
+	// ran out, is not worth serving again.
+	if len(analysis.Warnings) == 0 {
+		s.cache.Set(key, &Entry{Analysis: analysis, StoredAt: time.Now()})
+	}
+	return withCacheInfo(analysis, false, 0, s.cache.TTL()), nil
+}
+
+// parseMaxAge parses a request's max_age. It returns -1 when the field is empty.
+func parseMaxAge(value string) (time.Duration, error) {
+	if value == "" {
+		return -1, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+	return d, nil
+}
+
+// requestKey identifies the analyses a request can be answered with: the same
+// normalized URL, module selection, module options, credentials and
+// Accept-Language. Module order does not matter.
+func requestKey(req analyzer.AnalysisRequest, urls urlnorm.Options) string {
+	modules := append([]string(nil), req.Modules...)
+	sort.Strings(modules)
+	// Map keys are marshalled in sorted order, so equal options give equal keys.
+	options, _ := json.Marshal(req.Options)
+	// Credentials may change what the page shows; only their hash is kept.
+	return strings.Join([]string{urlnorm.Normalize(req.URL, urls), strings.Join(modules, ","), string(options), req.Auth.Fingerprint(), req.AcceptLanguage}, "\x00")
+}
+
+// withCacheInfo returns a copy of analysis annotated with cache metadata.
+// The cached value itself is shared between requests and never modified.
+func withCacheInfo(analysis *analyzer.WebpageAnalysis, hit bool, age, ttl time.Duration) *analyzer.WebpageAnalysis {
+	annotated := *analysis
+	annotated.Cache = &analyzer.CacheInfo{Hit: hit, Age: age.Round(time.Second).String(), TTL: ttl.String()}
+	return &annotated
+}
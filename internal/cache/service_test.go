@@ -0,0 +1,229 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/urlnorm"
+	"webpage-analyzer/internal/worker"
+)
+
+// countingService returns a fresh analysis for any URL and counts calls.
+type countingService struct {
+	analyzer.Service
+	calls    int
+	warnings []analyzer.Warning
+}
+
+func (s *countingService) AnalyzeWebpage(ctx context.Context, req analyzer.AnalysisRequest) (*analyzer.WebpageAnalysis, error) {
+	s.calls++
+	return &analyzer.WebpageAnalysis{URL: req.URL, PageTitle: "Stub", Warnings: s.warnings}, nil
+}
+
+func TestCachingService_ServesRepeatedRequestsFromCache(t *testing.T) {
+	inner := &countingService{}
+	svc := NewCachingService(inner, NewMemoryCache(DefaultConfig()))
+	ctx := context.Background()
+	req := analyzer.AnalysisRequest{URL: "https://example.com"}
+
+	first, err := svc.AnalyzeWebpage(ctx, req)
+	require.NoError(t, err)
+	require.NotNil(t, first.Cache, "Every analysis should carry cache metadata")
+	assert.False(t, first.Cache.Hit, "The first request should miss")
+	assert.Equal(t, "0s", first.Cache.Age)
+	assert.Equal(t, "5m0s", first.Cache.TTL)
+
+	second, err := svc.AnalyzeWebpage(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, second.Cache.Hit, "A repeated request should hit")
+	assert.Equal(t, 1, inner.calls, "A cache hit should not analyze the page again")
+	assert.False(t, first.Cache.Hit, "Annotating a hit should not change earlier responses")
+}
+
+func TestCachingService_NormalizesURLs(t *testing.T) {
+	inner := &countingService{}
+	svc := NewCachingService(inner, NewMemoryCache(DefaultConfig()))
+	ctx := context.Background()
+
+	for _, url := range []string{"https://example.com", "https://Example.com/", "HTTPS://example.com:443/#top"} {
+		_, err := svc.AnalyzeWebpage(ctx, analyzer.AnalysisRequest{URL: url})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, inner.calls, "Spellings of the same URL should share a cache entry")
+
+	_, err := svc.AnalyzeWebpage(ctx, analyzer.AnalysisRequest{URL: "https://example.com/?utm_source=mail"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.calls, "Tracking parameters are kept by default")
+}
+
+func TestCachingService_KeyOptions(t *testing.T) {
+	inner := &countingService{}
+	urls := urlnorm.Options{StripTracking: true, TrailingSlash: urlnorm.StripTrailingSlash}
+	svc := NewCachingServiceWithKeys(inner, NewMemoryCache(DefaultConfig()), urls)
+	ctx := context.Background()
+
+	for _, url := range []string{"https://example.com/docs", "https://example.com/docs/", "https://example.com/docs?utm_source=mail&gclid=1"} {
+		_, err := svc.AnalyzeWebpage(ctx, analyzer.AnalysisRequest{URL: url})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, inner.calls)
+}
+
+func TestCachingService_NilCache(t *testing.T) {
+	inner := &countingService{}
+	svc := NewCachingService(inner, nil)
+
+	for i := 0; i < 2; i++ {
+		analysis, err := svc.AnalyzeWebpage(context.Background(), analyzer.AnalysisRequest{URL: "https://example.com"})
+		require.NoError(t, err)
+		assert.False(t, analysis.Cache.Hit)
+	}
+	assert.Equal(t, 2, inner.calls, "Without a cache every request should be analyzed")
+}
+
+func TestWithCache(t *testing.T) {
+	pool := worker.NewWorkerPool(1)
+	defer pool.Shutdown()
+	svc := analyzer.NewService(analyzer.WithWorkerPool(pool), WithCache(NewMemoryCache(DefaultConfig())))
+
+	_, isCaching := svc.(*cachingService)
+	assert.True(t, isCaching, "The service should be wrapped in the cache")
+}
+
+func TestCachingService_ForceRefresh(t *testing.T) {
+	inner := &countingService{}
+	svc := NewCachingService(inner, NewMemoryCache(DefaultConfig()))
+	ctx := context.Background()
+
+	_, err := svc.AnalyzeWebpage(ctx, analyzer.AnalysisRequest{URL: "https://example.com"})
+	require.NoError(t, err)
+
+	analysis, err := svc.AnalyzeWebpage(ctx, analyzer.AnalysisRequest{URL: "https://example.com", ForceRefresh: true})
+	require.NoError(t, err)
+	assert.False(t, analysis.Cache.Hit, "force_refresh should bypass the cache")
+	assert.Equal(t, 2, inner.calls)
+}
+
+func TestCachingService_MaxAge(t *testing.T) {
+	inner := &countingService{}
+	c := NewMemoryCache(DefaultConfig())
+	svc := NewCachingService(inner, c)
+	ctx := context.Background()
+	req := analyzer.AnalysisRequest{URL: "https://example.com"}
+
+	c.Set(requestKey(req, urlnorm.Options{}), &Entry{
+		Analysis: &analyzer.WebpageAnalysis{URL: req.URL},
+		StoredAt: time.Now().Add(-2 * time.Minute),
+	})
+
+	req.MaxAge = "5m"
+	analysis, err := svc.AnalyzeWebpage(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, analysis.Cache.Hit, "An entry younger than max_age should be served")
+	assert.Equal(t, "2m0s", analysis.Cache.Age)
+
+	req.MaxAge = "1m"
+	analysis, err = svc.AnalyzeWebpage(ctx, req)
+	require.NoError(t, err)
+	assert.False(t, analysis.Cache.Hit, "An entry older than max_age should not be served")
+	assert.Equal(t, 1, inner.calls)
+}
+
+func TestCachingService_InvalidMaxAge(t *testing.T) {
+	svc := NewCachingService(&countingService{}, NewMemoryCache(DefaultConfig()))
+
+	for _, maxAge := range []string{"soon", "-1m"} {
+		_, err := svc.AnalyzeWebpage(context.Background(), analyzer.AnalysisRequest{URL: "https://example.com", MaxAge: maxAge})
+		var analysisErr *analyzer.AnalysisError
+		require.ErrorAs(t, err, &analysisErr, "max_age %q should be rejected", maxAge)
+		assert.Equal(t, 400, analysisErr.StatusCode)
+	}
+}
+
+func TestCachingService_KeysOnModulesAndOptions(t *testing.T) {
+	inner := &countingService{}
+	svc := NewCachingService(inner, NewMemoryCache(DefaultConfig()))
+	ctx := context.Background()
+
+	requests := []analyzer.AnalysisRequest{
+		{URL: "https://example.com"},
+		{URL: "https://example.com", Modules: []string{"links", "headings"}},
+		{URL: "https://example.com", Modules: []string{"headings", "links"}},
+		{URL: "https://example.com", Modules: []string{"links"}, Options: map[string]analyzer.ModuleOptions{"links": {"probe": true}}},
+	}
+	for _, req := range requests {
+		_, err := svc.AnalyzeWebpage(ctx, req)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, inner.calls, "Only the module order should be ignored when matching requests")
+}
+
+func TestCachingService_KeysOnCredentials(t *testing.T) {
+	inner := &countingService{}
+	c := NewMemoryCache(DefaultConfig())
+	svc := NewCachingService(inner, c)
+	ctx := context.Background()
+
+	requests := []analyzer.AnalysisRequest{
+		{URL: "https://example.com"},
+		{URL: "https://example.com", Auth: &analyzer.AnalysisAuth{Username: "alice", Password: "one"}},
+		{URL: "https://example.com", Auth: &analyzer.AnalysisAuth{Username: "alice", Password: "two"}},
+		{URL: "https://example.com", Auth: &analyzer.AnalysisAuth{Username: "alice", Password: "two"}},
+	}
+	for _, req := range requests {
+		_, err := svc.AnalyzeWebpage(ctx, req)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, inner.calls, "Different credentials should not share cached analyses")
+	assert.NotContains(t, requestKey(requests[2], urlnorm.Options{}), "two", "Cache keys should not hold plaintext secrets")
+}
+
+func TestCachingService_KeysOnAcceptLanguage(t *testing.T) {
+	inner := &countingService{}
+	svc := NewCachingService(inner, NewMemoryCache(DefaultConfig()))
+	ctx := context.Background()
+
+	for _, lang := range []string{"", "de-DE", "fr", "de-DE"} {
+		_, err := svc.AnalyzeWebpage(ctx, analyzer.AnalysisRequest{URL: "https://example.com", AcceptLanguage: lang})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, inner.calls, "Pages fetched in different languages should not share cached analyses")
+}
+
+func TestCachingService_SkipsPartialResults(t *testing.T) {
+	inner := &countingService{warnings: []analyzer.Warning{{Module: analyzer.ModuleWayback, Code: analyzer.WarningModuleTimeout}}}
+	svc := NewCachingService(inner, NewMemoryCache(DefaultConfig()))
+	ctx := context.Background()
+	req := analyzer.AnalysisRequest{URL: "https://example.com", TotalTimeout: "1s"}
+
+	for i := 0; i < 2; i++ {
+		analysis, err := svc.AnalyzeWebpage(ctx, req)
+		require.NoError(t, err)
+		assert.False(t, analysis.Cache.Hit)
+	}
+	assert.Equal(t, 2, inner.calls, "Analyses cut short should not be cached")
+}
+
+func TestMemoryCache_ExpiryAndEviction(t *testing.T) {
+	c := NewMemoryCache(Config{TTL: time.Minute, MaxEntries: 2}).(*memoryCache)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	c.Set("a", &Entry{StoredAt: now.Add(-30 * time.Second)})
+	c.Set("b", &Entry{StoredAt: now})
+	c.Set("c", &Entry{StoredAt: now})
+
+	_, ok := c.Get("a")
+	assert.False(t, ok, "The oldest entry should be evicted when the cache is full")
+	_, ok = c.Get("b")
+	assert.True(t, ok)
+
+	now = now.Add(2 * time.Minute)
+	_, ok = c.Get("c")
+	assert.False(t, ok, "Entries older than the TTL should expire")
+}
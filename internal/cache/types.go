@@ -0,0 +1,51 @@
+// Package cache keeps recent webpage analyses so repeated requests for the
+// same page are answered without fetching it again.
+package cache
+
+import (
+	"time"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+// Config bounds the in-memory cache.
+type Config struct {
+	TTL        time.Duration // Entries older than this are never served.
+	MaxEntries int           // The oldest entry is evicted when the cache is full.
+}
+
+// DefaultConfig returns the cache configuration used by the server.
+func DefaultConfig() Config {
+	return Config{
+		TTL:        5 * time.Minute,
+		MaxEntries: 1000,
+	}
+}
+
+// Entry is a cached analysis and the time it was stored.
+type Entry struct {
+	Analysis *analyzer.WebpageAnalysis
+	StoredAt time.Time
+}
+
+// Cache stores analyses by request key.
+type Cache interface {
+	// Get returns the entry for key, or false if it is missing or expired.
+	Get(key string) (*Entry, bool)
+	Set(key string, entry *Entry)
+	// TTL returns how long entries are served after they are stored.
+	TTL() time.Duration
+}
+
+// noopCache is a Cache that stores nothing.
+type noopCache struct{}
+
+// NewNoopCache returns a Cache that never holds an entry, for running a
+// caching service with caching switched off.
+func NewNoopCache() Cache {
+	return noopCache{}
+}
+
+func (noopCache) Get(string) (*Entry, bool) { return nil, false }
+func (noopCache) Set(string, *Entry)        {}
+func (noopCache) TTL() time.Duration        { return 0 }
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Credentials authenticate requests to a protected page, either with HTTP
+// Basic auth (Username and Password) or with a bearer Token.
+type Credentials struct {
+	Username string
+	Password string
+	Token    string
+}
+
+// credentialsKey is the context key for scoped credentials.
+type credentialsKey struct{}
+
+// scopedCredentials are credentials valid for one origin.
+type scopedCredentials struct {
+	origin string
+	creds  Credentials
+}
+
+// WithCredentials returns a context whose requests to the origin (scheme and
+// host) of pageURL carry creds. Requests to other origins, such as external
+// links being probed, are sent without them, and the standard redirect policy
+// drops them on redirects to another host.
+func WithCredentials(ctx context.Context, pageURL string, creds Credentials) context.Context {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, credentialsKey{}, scopedCredentials{origin: origin(u), creds: creds})
+}
+
+// authorize sets the Authorization header of req if its context holds
+// credentials for req's origin.
+func authorize(req *http.Request) {
+	scoped, ok := req.Context().Value(credentialsKey{}).(scopedCredentials)
+	if !ok || scoped.origin != origin(req.URL) {
+		return
+	}
+	if scoped.creds.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+scoped.creds.Token)
+		return
+	}
+	req.SetBasicAuth(scoped.creds.Username, scoped.creds.Password)
+}
+
+// origin returns the lower-cased scheme and host of u.
+func origin(u *url.URL) string {
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
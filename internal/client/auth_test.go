@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClient_SendsCredentials(t *testing.T) {
+	tests := []struct {
+		name  string
+		creds Credentials
+		want  string
+	}{
+		{"basic", Credentials{Username: "alice", Password: "s3cret"}, "Basic YWxpY2U6czNjcmV0"},
+		{"bearer", Credentials{Token: "abc123"}, "Bearer abc123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get("Authorization")
+			}))
+			defer server.Close()
+
+			ctx := WithCredentials(context.Background(), server.URL+"/page", tt.creds)
+			_, status, err := NewHTTPClient().FetchWebpage(ctx, server.URL+"/other")
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHTTPClient_CredentialsScopedToOrigin(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	ctx := WithCredentials(context.Background(), "https://intranet.example.com/", Credentials{Token: "abc123"})
+	_, _, err := NewHTTPClient().FetchWebpage(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, []string{""}, got, "Credentials should not be sent to another origin")
+}
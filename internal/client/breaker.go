@@ -0,0 +1,133 @@
+package client
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BreakerConfig configures the per-host circuit breaker.
+type BreakerConfig struct {
+	// Threshold is the number of consecutive failures after which requests
+	// to a host are skipped; zero or less disables the breaker.
+	Threshold int
+	// Cooldown is how long requests are skipped before one trial request
+	// is let through. If it succeeds the host is used again; if it fails
+	// the host is skipped for another cooldown.
+	Cooldown time.Duration
+}
+
+// HostSkippedError is returned, without contacting the host, while the
+// breaker for the host is open.
+type HostSkippedError struct {
+	Host       string
+	Failures   int
+	RetryAfter time.Duration
+}
+
+func (e *HostSkippedError) Error() string {
+	return fmt.Sprintf("Host temporarily skipped: %s failed %d times in a row. Requests resume in %s.",
+		e.Host, e.Failures, e.RetryAfter.Round(time.Second))
+}
+
+// ErrorCode returns CodeHostSkipped.
+func (e *HostSkippedError) ErrorCode() string { return CodeHostSkipped }
+
+// hostBreaker tracks consecutive failures per host and short-circuits
+// requests to hosts that keep failing, sparing both sides during an outage.
+type hostBreaker struct {
+	cfg   BreakerConfig
+	mu    sync.Mutex
+	hosts map[string]*breakerState
+	now   func() time.Time
+}
+
+// breakerState is the failure record of one host. Hosts without failures
+// have no state.
+type breakerState struct {
+	failures  int
+	openUntil time.Time // Zero while the breaker is closed.
+	trial     bool      // Whether a trial request is in flight.
+}
+
+// newHostBreaker returns a breaker, or nil when cfg disables it.
+func newHostBreaker(cfg BreakerConfig) *hostBreaker {
+	if cfg.Threshold <= 0 {
+		return nil
+	}
+	return &hostBreaker{cfg: cfg, hosts: make(map[string]*breakerState), now: time.Now}
+}
+
+// allow returns a HostSkippedError if requests to host are being skipped.
+func (b *hostBreaker) allow(host string) error {
+	host = strings.ToLower(host)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, ok := b.hosts[host]
+	if !ok || state.openUntil.IsZero() {
+		return nil
+	}
+	if wait := state.openUntil.Sub(b.now()); wait > 0 {
+		return &HostSkippedError{Host: host, Failures: state.failures, RetryAfter: wait}
+	}
+	if state.trial {
+		// Another request is already finding out whether the host is back.
+		return &HostSkippedError{Host: host, Failures: state.failures}
+	}
+	state.trial = true
+	return nil
+}
+
+// record notes the outcome of a request to host.
+func (b *hostBreaker) record(host string, failed bool) {
+	host = strings.ToLower(host)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		if state, ok := b.hosts[host]; ok && !state.openUntil.IsZero() {
+			slog.Info("Host recovered, circuit breaker closed", "host", host)
+		}
+		delete(b.hosts, host)
+		return
+	}
+
+	state, ok := b.hosts[host]
+	if !ok {
+		state = &breakerState{}
+		b.hosts[host] = state
+	}
+	state.failures++
+	state.trial = false
+	if state.failures >= b.cfg.Threshold {
+		state.openUntil = b.now().Add(b.cfg.Cooldown)
+		slog.Warn("Host keeps failing, circuit breaker opened",
+			"host", host, "failures", state.failures, "cooldown", b.cfg.Cooldown)
+	}
+}
+
+// abandon forgets a trial request whose outcome is unknown, so that the next
+// request can try the host instead.
+func (b *hostBreaker) abandon(host string) {
+	host = strings.ToLower(host)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if state, ok := b.hosts[host]; ok {
+		state.trial = false
+	}
+}
+
+// isHostFailure reports whether a response status means the host itself is
+// struggling, as opposed to the request being wrong.
+func isHostFailure(statusCode int) bool {
+	switch statusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
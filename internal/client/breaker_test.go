@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostBreaker_OpensAfterThreshold(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	b := newHostBreaker(BreakerConfig{Threshold: 3, Cooldown: time.Minute})
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, b.allow("example.com"))
+		b.record("example.com", true)
+	}
+
+	err := b.allow("Example.com")
+	var skipped *HostSkippedError
+	require.ErrorAs(t, err, &skipped)
+	assert.Equal(t, 3, skipped.Failures)
+	assert.Equal(t, time.Minute, skipped.RetryAfter)
+	assert.Contains(t, err.Error(), "temporarily skipped")
+	assert.NoError(t, b.allow("other.example.org"), "Other hosts should be unaffected")
+}
+
+func TestHostBreaker_SuccessResetsFailures(t *testing.T) {
+	b := newHostBreaker(BreakerConfig{Threshold: 2, Cooldown: time.Minute})
+
+	b.record("example.com", true)
+	b.record("example.com", false)
+	b.record("example.com", true)
+
+	assert.NoError(t, b.allow("example.com"), "Failures should have to be consecutive")
+}
+
+func TestHostBreaker_TrialAfterCooldown(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	b := newHostBreaker(BreakerConfig{Threshold: 1, Cooldown: time.Minute})
+	b.now = func() time.Time { return now }
+
+	b.record("example.com", true)
+	require.Error(t, b.allow("example.com"))
+
+	now = now.Add(time.Minute)
+	require.NoError(t, b.allow("example.com"), "One trial request should pass after the cooldown")
+	assert.Error(t, b.allow("example.com"), "Only one trial request at a time")
+
+	b.record("example.com", true)
+	assert.Error(t, b.allow("example.com"), "A failed trial should reopen the breaker")
+
+	now = now.Add(time.Minute)
+	require.NoError(t, b.allow("example.com"))
+	b.record("example.com", false)
+	assert.NoError(t, b.allow("example.com"), "A successful trial should close the breaker")
+	assert.NoError(t, b.allow("example.com"))
+}
+
+func TestHostBreaker_AbandonedTrial(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	b := newHostBreaker(BreakerConfig{Threshold: 1, Cooldown: time.Minute})
+	b.now = func() time.Time { return now }
+
+	b.record("example.com", true)
+	now = now.Add(time.Minute)
+	require.NoError(t, b.allow("example.com"))
+	b.abandon("example.com")
+
+	assert.NoError(t, b.allow("example.com"), "An abandoned trial should not block the next one")
+}
+
+func TestHostBreaker_Disabled(t *testing.T) {
+	assert.Nil(t, newHostBreaker(BreakerConfig{}))
+}
+
+func TestHTTPClient_SkipsFailingHost(t *testing.T) {
+	var hits atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.Breaker = BreakerConfig{Threshold: 2, Cooldown: time.Hour}
+	c := NewHTTPClientWithConfig(cfg)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, status, err := c.FetchWebpage(ctx, server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadGateway, status)
+	}
+
+	_, status, err := c.FetchWebpage(ctx, server.URL)
+	var skipped *HostSkippedError
+	require.ErrorAs(t, err, &skipped)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, int64(2), hits.Load(), "A skipped request should not reach the host")
+}
+
+func TestHTTPClient_ClientErrorsDoNotTripBreaker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.Breaker = BreakerConfig{Threshold: 1, Cooldown: time.Hour}
+	c := NewHTTPClientWithConfig(cfg)
+
+	for i := 0; i < 3; i++ {
+		_, status, err := c.FetchWebpage(context.Background(), server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, status)
+	}
+}
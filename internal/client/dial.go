@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// IPFamily selects the IP versions used for outbound connections.
+type IPFamily string
+
+// IP families.
+const (
+	IPAny     IPFamily = ""            // Addresses in the order the resolver returns them.
+	IPPrefer4 IPFamily = "prefer-ipv4" // IPv4 addresses first, then IPv6.
+	IPPrefer6 IPFamily = "prefer-ipv6" // IPv6 addresses first, then IPv4.
+	IPOnly4   IPFamily = "ipv4"        // IPv4 addresses only.
+	IPOnly6   IPFamily = "ipv6"        // IPv6 addresses only.
+)
+
+// DialConfig configures how outbound connections are made.
+type DialConfig struct {
+	Family IPFamily
+	// LocalAddr is the local IP address connections are made from, for
+	// hosts with several interfaces; empty lets the OS choose. Only
+	// addresses of its family are dialed.
+	LocalAddr string
+}
+
+// Validate checks the family and local address.
+func (c DialConfig) Validate() error {
+	switch c.Family {
+	case IPAny, IPPrefer4, IPPrefer6, IPOnly4, IPOnly6:
+	default:
+		return fmt.Errorf("unknown IP family %q (want %s, %s, %s or %s)", c.Family, IPPrefer4, IPPrefer6, IPOnly4, IPOnly6)
+	}
+	if c.LocalAddr == "" {
+		return nil
+	}
+	ip := net.ParseIP(c.LocalAddr)
+	if ip == nil {
+		return fmt.Errorf("local address %q is not an IP address", c.LocalAddr)
+	}
+	if (c.Family == IPOnly4 && ip.To4() == nil) || (c.Family == IPOnly6 && ip.To4() != nil) {
+		return fmt.Errorf("local address %s does not match IP family %s", c.LocalAddr, c.Family)
+	}
+	return nil
+}
+
+// custom reports whether the configuration differs from Go's defaults.
+func (c DialConfig) custom() bool {
+	return c.Family != IPAny || c.LocalAddr != ""
+}
+
+// family returns the effective family: a local address restricts
+// connections to its own family.
+func (c DialConfig) family() IPFamily {
+	if ip := net.ParseIP(c.LocalAddr); ip != nil {
+		if ip.To4() != nil {
+			return IPOnly4
+		}
+		return IPOnly6
+	}
+	return c.Family
+}
+
+// dialer returns a dialer bound to the local address, if one is set.
+func (c DialConfig) dialer(base net.Dialer) *net.Dialer {
+	if ip := net.ParseIP(c.LocalAddr); ip != nil {
+		base.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+	return &base
+}
+
+// order returns ips sorted and filtered by family. The resolver's order is
+// kept within each family.
+func (f IPFamily) order(ips []string) []string {
+	var v4, v6 []string
+	for _, s := range ips {
+		ip := net.ParseIP(s)
+		switch {
+		case ip == nil:
+			continue
+		case ip.To4() != nil:
+			v4 = append(v4, s)
+		default:
+			v6 = append(v6, s)
+		}
+	}
+	switch f {
+	case IPPrefer4:
+		return append(v4, v6...)
+	case IPPrefer6:
+		return append(v6, v4...)
+	case IPOnly4:
+		return v4
+	case IPOnly6:
+		return v6
+	default:
+		return ips
+	}
+}
+
+// dialResolved returns a DialContext function that resolves host names with
+// resolve and dials the addresses allowed by family in turn until one
+// connects.
+func dialResolved(dialer *net.Dialer, family IPFamily, resolve func(ctx context.Context, host string) ([]string, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		ips := []string{host}
+		if net.ParseIP(host) == nil {
+			if ips, err = resolve(ctx, host); err != nil {
+				return nil, err
+			}
+		}
+		candidates := family.order(ips)
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("dial %s: no %s address for %s", network, family, host)
+		}
+
+		var errs []error
+		for _, ip := range candidates {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return nil, errors.Join(errs...)
+	}
+}
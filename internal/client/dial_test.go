@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPFamily_Order(t *testing.T) {
+	ips := []string{"2001:db8::1", "192.0.2.1", "2001:db8::2", "192.0.2.2"}
+
+	tests := []struct {
+		family   IPFamily
+		expected []string
+	}{
+		{IPAny, ips},
+		{IPPrefer4, []string{"192.0.2.1", "192.0.2.2", "2001:db8::1", "2001:db8::2"}},
+		{IPPrefer6, []string{"2001:db8::1", "2001:db8::2", "192.0.2.1", "192.0.2.2"}},
+		{IPOnly4, []string{"192.0.2.1", "192.0.2.2"}},
+		{IPOnly6, []string{"2001:db8::1", "2001:db8::2"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.family), func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.family.order(ips))
+		})
+	}
+}
+
+func TestDialConfig_Validate(t *testing.T) {
+	valid := []DialConfig{
+		{},
+		{Family: IPPrefer6},
+		{Family: IPOnly4, LocalAddr: "192.0.2.10"},
+		{LocalAddr: "2001:db8::10"},
+	}
+	for _, cfg := range valid {
+		assert.NoError(t, cfg.Validate(), "%+v should be valid", cfg)
+	}
+
+	invalid := []DialConfig{
+		{Family: "ipv5"},
+		{LocalAddr: "eth0"},
+		{Family: IPOnly6, LocalAddr: "192.0.2.10"},
+		{Family: IPOnly4, LocalAddr: "2001:db8::10"},
+	}
+	for _, cfg := range invalid {
+		assert.Error(t, cfg.Validate(), "%+v should be rejected", cfg)
+	}
+}
+
+func TestDialConfig_LocalAddrRestrictsFamily(t *testing.T) {
+	assert.Equal(t, IPOnly4, DialConfig{Family: IPPrefer6, LocalAddr: "192.0.2.10"}.family())
+	assert.Equal(t, IPOnly6, DialConfig{LocalAddr: "2001:db8::10"}.family())
+	assert.Equal(t, IPPrefer4, DialConfig{Family: IPPrefer4}.family())
+}
+
+func TestHTTPClient_DialSettings(t *testing.T) {
+	var remote string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remote = r.RemoteAddr
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	for _, dnsCacheSize := range []int{0, 10} {
+		cfg := DefaultConfig()
+		cfg.DNSCache.MaxEntries = dnsCacheSize
+		cfg.Dial = DialConfig{Family: IPPrefer6, LocalAddr: "127.0.0.1"}
+		_, status, err := NewHTTPClientWithConfig(cfg).FetchWebpage(context.Background(), server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, remote, "127.0.0.1:", "Connections should come from the local address")
+
+		cfg.Dial = DialConfig{Family: IPOnly6}
+		_, _, err = NewHTTPClientWithConfig(cfg).FetchWebpage(context.Background(), server.URL)
+		assert.Error(t, err, "An IPv4 server cannot be reached over IPv6 only")
+	}
+}
@@ -0,0 +1,253 @@
+package client
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DNSCacheConfig configures the resolver cache used when dialing.
+type DNSCacheConfig struct {
+	// MaxEntries bounds the number of cached host names; zero or less
+	// disables the cache. The entry closest to expiry is evicted when full.
+	MaxEntries int
+	// MinTTL is the shortest time an answer is kept, and the lifetime of
+	// answers without a TTL, such as those from /etc/hosts.
+	MinTTL time.Duration
+	// MaxTTL caps the record TTL, so that moved hosts are noticed.
+	MaxTTL time.Duration
+}
+
+// DNSCacheStats is a point-in-time snapshot of the DNS cache.
+type DNSCacheStats struct {
+	Entries   int   `json:"entries"`
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+}
+
+// DNSStatsReporter is implemented by clients that cache DNS answers.
+type DNSStatsReporter interface {
+	DNSCacheStats() DNSCacheStats
+}
+
+// dnsCache resolves host names for the client's dialer and keeps the answers
+// for their record TTL, so that many requests to the same domains share one
+// lookup. Concurrent lookups of the same name are merged.
+type dnsCache struct {
+	cfg    DNSCacheConfig
+	lookup func(ctx context.Context, host string) ([]string, time.Duration, error)
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*dnsEntry
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+// dnsEntry is the answer for one host name. ready is closed once the
+// lookup has finished; failed lookups are dropped rather than cached.
+type dnsEntry struct {
+	ready   chan struct{}
+	addrs   []string
+	err     error
+	expires time.Time
+}
+
+// newDNSCache returns a cache, or nil when cfg disables it.
+func newDNSCache(cfg DNSCacheConfig) *dnsCache {
+	if cfg.MaxEntries <= 0 {
+		return nil
+	}
+	return &dnsCache{
+		cfg:     cfg,
+		lookup:  lookupWithTTL,
+		now:     time.Now,
+		entries: make(map[string]*dnsEntry),
+	}
+}
+
+// resolve returns the addresses of host, from the cache when possible.
+func (c *dnsCache) resolve(ctx context.Context, host string) ([]string, error) {
+	host = strings.ToLower(host)
+
+	c.mu.Lock()
+	entry, ok := c.entries[host]
+	if ok {
+		select {
+		case <-entry.ready:
+			if c.now().After(entry.expires) {
+				delete(c.entries, host)
+				ok = false
+			}
+		default: // A lookup is in flight; wait for it below.
+		}
+	}
+	if ok {
+		c.mu.Unlock()
+		c.hits.Add(1)
+		select {
+		case <-entry.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return entry.addrs, entry.err
+	}
+
+	c.misses.Add(1)
+	entry = &dnsEntry{ready: make(chan struct{})}
+	if len(c.entries) >= c.cfg.MaxEntries {
+		c.evict()
+	}
+	c.entries[host] = entry
+	c.mu.Unlock()
+
+	// The lookup is shared, so it must not fail because one caller gave up.
+	addrs, ttl, err := c.lookup(context.WithoutCancel(ctx), host)
+
+	c.mu.Lock()
+	entry.addrs, entry.err = addrs, err
+	entry.expires = c.now().Add(min(max(ttl, c.cfg.MinTTL), c.cfg.MaxTTL))
+	if err != nil && c.entries[host] == entry {
+		delete(c.entries, host)
+	}
+	c.mu.Unlock()
+	close(entry.ready)
+	return addrs, err
+}
+
+// evict removes the finished entry closest to expiry. The caller must hold mu.
+func (c *dnsCache) evict() {
+	var victim string
+	var soonest time.Time
+	for host, entry := range c.entries {
+		select {
+		case <-entry.ready:
+		default:
+			continue
+		}
+		if victim == "" || entry.expires.Before(soonest) {
+			victim, soonest = host, entry.expires
+		}
+	}
+	if victim != "" {
+		delete(c.entries, victim)
+		c.evictions.Add(1)
+	}
+}
+
+// stats returns a snapshot of the cache counters.
+func (c *dnsCache) stats() DNSCacheStats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+	return DNSCacheStats{
+		Entries:   entries,
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
+
+// lookupWithTTL resolves host with the pure Go resolver and returns the
+// lowest TTL among the answers, read from the DNS messages as they arrive.
+// The TTL is zero when no DNS message was involved, e.g. for /etc/hosts.
+func lookupWithTTL(ctx context.Context, host string) ([]string, time.Duration, error) {
+	var (
+		mu     sync.Mutex
+		ttl    uint32
+		hasTTL bool
+	)
+	record := func(msg []byte) {
+		if answerTTL, ok := minAnswerTTL(msg); ok {
+			mu.Lock()
+			if !hasTTL || answerTTL < ttl {
+				ttl, hasTTL = answerTTL, true
+			}
+			mu.Unlock()
+		}
+	}
+
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, network, address)
+			if err != nil {
+				return nil, err
+			}
+			return &ttlConn{Conn: conn, stream: strings.HasPrefix(network, "tcp"), record: record}, nil
+		},
+	}
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, 0, err
+	}
+	ips := make([]string, len(addrs))
+	for i, a := range addrs {
+		ips[i] = a.IP.String()
+	}
+	return ips, time.Duration(ttl) * time.Second, nil
+}
+
+// minAnswerTTL returns the lowest TTL among the answers of a DNS message.
+func minAnswerTTL(msg []byte) (uint32, bool) {
+	var p dnsmessage.Parser
+	if _, err := p.Start(msg); err != nil {
+		return 0, false
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return 0, false
+	}
+	var ttl uint32
+	found := false
+	for {
+		h, err := p.AnswerHeader()
+		if err != nil {
+			return ttl, found
+		}
+		if !found || h.TTL < ttl {
+			ttl, found = h.TTL, true
+		}
+		if err := p.SkipAnswer(); err != nil {
+			return ttl, found
+		}
+	}
+}
+
+// ttlConn passes a resolver's DNS traffic through, handing each response
+// message to record.
+type ttlConn struct {
+	net.Conn
+	stream bool // TCP frames messages with a two-byte length.
+	buf    []byte
+	record func(msg []byte)
+}
+
+// Read records complete DNS messages as they are read.
+func (c *ttlConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		if !c.stream {
+			c.record(b[:n])
+		} else {
+			c.buf = append(c.buf, b[:n]...)
+			for len(c.buf) >= 2 {
+				size := int(c.buf[0])<<8 | int(c.buf[1])
+				if len(c.buf) < 2+size {
+					break
+				}
+				c.record(c.buf[2 : 2+size])
+				c.buf = c.buf[2+size:]
+			}
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,234 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeResolver answers lookups with fixed addresses and TTL, counting calls.
+type fakeResolver struct {
+	calls   atomic.Int64
+	ttl     time.Duration
+	err     error
+	release chan struct{} // If set, lookups block until it is closed.
+}
+
+func (r *fakeResolver) lookup(ctx context.Context, host string) ([]string, time.Duration, error) {
+	r.calls.Add(1)
+	if r.release != nil {
+		<-r.release
+	}
+	if r.err != nil {
+		return nil, 0, r.err
+	}
+	return []string{"127.0.0.1"}, r.ttl, nil
+}
+
+func newTestDNSCache(cfg DNSCacheConfig, r *fakeResolver) (*dnsCache, *time.Time) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	c := newDNSCache(cfg)
+	c.lookup = r.lookup
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestDNSCache_RespectsTTL(t *testing.T) {
+	r := &fakeResolver{ttl: 30 * time.Second}
+	c, now := newTestDNSCache(DNSCacheConfig{MaxEntries: 10, MinTTL: time.Second, MaxTTL: time.Minute}, r)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		addrs, err := c.resolve(ctx, "Example.com")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"127.0.0.1"}, addrs)
+	}
+	assert.Equal(t, int64(1), r.calls.Load())
+
+	*now = now.Add(31 * time.Second)
+	_, err := c.resolve(ctx, "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), r.calls.Load(), "An expired answer should be looked up again")
+
+	assert.Equal(t, DNSCacheStats{Entries: 1, Hits: 2, Misses: 2}, c.stats())
+}
+
+func TestDNSCache_ClampsTTL(t *testing.T) {
+	tests := []struct {
+		name   string
+		ttl    time.Duration
+		expiry time.Duration
+	}{
+		{"no TTL uses the minimum", 0, 5 * time.Second},
+		{"long TTL is capped", 24 * time.Hour, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeResolver{ttl: tt.ttl}
+			c, now := newTestDNSCache(DNSCacheConfig{MaxEntries: 10, MinTTL: 5 * time.Second, MaxTTL: time.Minute}, r)
+
+			_, _ = c.resolve(context.Background(), "example.com")
+			*now = now.Add(tt.expiry)
+			_, _ = c.resolve(context.Background(), "example.com")
+			assert.Equal(t, int64(1), r.calls.Load(), "The answer should last exactly %s", tt.expiry)
+
+			*now = now.Add(time.Nanosecond)
+			_, _ = c.resolve(context.Background(), "example.com")
+			assert.Equal(t, int64(2), r.calls.Load())
+		})
+	}
+}
+
+func TestDNSCache_MergesConcurrentLookups(t *testing.T) {
+	r := &fakeResolver{ttl: time.Minute, release: make(chan struct{})}
+	c, _ := newTestDNSCache(DNSCacheConfig{MaxEntries: 10, MaxTTL: time.Hour}, r)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.resolve(context.Background(), "example.com")
+			assert.NoError(t, err)
+		}()
+	}
+	require.Eventually(t, func() bool { return c.stats().Hits+c.stats().Misses == 5 }, time.Second, time.Millisecond)
+	close(r.release)
+	wg.Wait()
+
+	assert.Equal(t, int64(1), r.calls.Load())
+}
+
+func TestDNSCache_ErrorsNotCached(t *testing.T) {
+	r := &fakeResolver{err: errors.New("no such host")}
+	c, _ := newTestDNSCache(DNSCacheConfig{MaxEntries: 10, MaxTTL: time.Hour}, r)
+
+	for i := 0; i < 2; i++ {
+		_, err := c.resolve(context.Background(), "example.com")
+		assert.Error(t, err)
+	}
+	assert.Equal(t, int64(2), r.calls.Load())
+	assert.Zero(t, c.stats().Entries)
+}
+
+func TestDNSCache_EvictsSoonestExpiry(t *testing.T) {
+	r := &fakeResolver{}
+	c, _ := newTestDNSCache(DNSCacheConfig{MaxEntries: 2, MaxTTL: time.Hour}, r)
+	ctx := context.Background()
+
+	r.ttl = time.Hour
+	_, _ = c.resolve(ctx, "long.example.com")
+	r.ttl = time.Minute
+	_, _ = c.resolve(ctx, "short.example.com")
+	_, _ = c.resolve(ctx, "new.example.com")
+
+	stats := c.stats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Contains(t, c.entries, "long.example.com")
+	assert.NotContains(t, c.entries, "short.example.com")
+}
+
+func TestDNSCache_Disabled(t *testing.T) {
+	assert.Nil(t, newDNSCache(DNSCacheConfig{}))
+	assert.Equal(t, DNSCacheStats{}, NewHTTPClientWithConfig(Config{}).(DNSStatsReporter).DNSCacheStats())
+}
+
+func TestHTTPClient_DialsThroughDNSCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	r := &fakeResolver{ttl: time.Minute}
+	c := NewHTTPClientWithConfig(DefaultConfig()).(*httpClient)
+	c.dns.lookup = r.lookup
+
+	for i := 0; i < 3; i++ {
+		body, status, err := c.FetchWebpage(context.Background(), "http://cached.example.test:"+port+"/")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "ok", string(body))
+	}
+	assert.Equal(t, int64(1), r.calls.Load())
+	assert.Equal(t, int64(0), c.DNSCacheStats().Hits, "Keep-alive connections are reused without dialing")
+}
+
+func TestTTLConn_RecordsAnswers(t *testing.T) {
+	msg := buildDNSResponse(t, 300, 60)
+
+	var got [][]byte
+	record := func(m []byte) { got = append(got, m) }
+
+	udp := &ttlConn{Conn: &stubConn{data: msg}, record: record}
+	_, err := udp.Read(make([]byte, 512))
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+
+	framed := append([]byte{byte(len(msg) >> 8), byte(len(msg))}, msg...)
+	got = nil
+	tcp := &ttlConn{Conn: &stubConn{data: framed, chunk: 7}, stream: true, record: record}
+	buf := make([]byte, 512)
+	for len(got) == 0 {
+		_, err := tcp.Read(buf)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, msg, got[0], "TCP messages should be reassembled from partial reads")
+}
+
+func TestMinAnswerTTL(t *testing.T) {
+	ttl, ok := minAnswerTTL(buildDNSResponse(t, 300, 60, 120))
+	assert.True(t, ok)
+	assert.Equal(t, uint32(60), ttl)
+
+	_, ok = minAnswerTTL(buildDNSResponse(t))
+	assert.False(t, ok, "A response without answers has no TTL")
+	_, ok = minAnswerTTL([]byte{1, 2, 3})
+	assert.False(t, ok)
+}
+
+// buildDNSResponse returns a DNS response with one A record per TTL.
+func buildDNSResponse(t *testing.T, ttls ...uint32) []byte {
+	t.Helper()
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{Response: true})
+	name := dnsmessage.MustNewName("example.com.")
+	require.NoError(t, b.StartQuestions())
+	require.NoError(t, b.Question(dnsmessage.Question{Name: name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}))
+	require.NoError(t, b.StartAnswers())
+	for _, ttl := range ttls {
+		h := dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: ttl}
+		require.NoError(t, b.AResource(h, dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}}))
+	}
+	msg, err := b.Finish()
+	require.NoError(t, err)
+	return msg
+}
+
+// stubConn serves data in reads of at most chunk bytes.
+type stubConn struct {
+	net.Conn
+	data  []byte
+	chunk int
+}
+
+func (c *stubConn) Read(b []byte) (int, error) {
+	n := len(c.data)
+	if c.chunk > 0 && n > c.chunk {
+		n = c.chunk
+	}
+	n = copy(b, c.data[:n])
+	c.data = c.data[n:]
+	return n, nil
+}
@@ -0,0 +1,44 @@
+package client
+
+import "golang.org/x/net/html"
+
+// MaxDOMDepth is how deeply ParseHTML lets elements nest. Elements below it
+// are dropped, so code that walks the tree recursively stays within a
+// bounded stack whatever the page. The value is Chromium's parser limit,
+// Blink's kMaximumHTMLParserDOMTreeDepth; other engines differ.
+const MaxDOMDepth = 512
+
+// limitDepth removes the child elements of elements nested MaxDOMDepth deep,
+// keeping their text, and reports whether it removed any. It walks the tree
+// without recursion.
+func limitDepth(doc *html.Node) bool {
+	type item struct {
+		node  *html.Node
+		depth int // Elements from the root to node, including node.
+	}
+	pruned := false
+	stack := []item{{node: doc}}
+	for len(stack) > 0 {
+		it := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		depth := it.depth
+		if it.node.Type == html.ElementNode {
+			depth++
+		}
+		if depth >= MaxDOMDepth && it.node.Type == html.ElementNode {
+			for child := it.node.FirstChild; child != nil; {
+				next := child.NextSibling
+				if child.Type == html.ElementNode {
+					it.node.RemoveChild(child)
+					pruned = true
+				}
+				child = next
+			}
+			continue
+		}
+		for child := it.node.FirstChild; child != nil; child = child.NextSibling {
+			stack = append(stack, item{node: child, depth: depth})
+		}
+	}
+	return pruned
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+// depth returns the deepest element nesting below n.
+func depth(n *html.Node) int {
+	deepest := 0
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		deepest = max(deepest, depth(child))
+	}
+	if n.Type == html.ElementNode {
+		deepest++
+	}
+	return deepest
+}
+
+func TestParseHTML_LimitsDepth(t *testing.T) {
+	c := NewHTTPClient()
+
+	doc, err := c.ParseHTML([]byte("<html><body>" + strings.Repeat("<div>", 3*MaxDOMDepth) + "</body></html>"))
+	require.NoError(t, err)
+	assert.Equal(t, MaxDOMDepth, depth(doc))
+
+	doc, err = c.ParseHTML([]byte("<html><body><div><p>Shallow</p></div></body></html>"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, depth(doc), "Shallow pages should be left alone")
+}
+
+func TestLimitDepth_KeepsTextAtLimit(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader("<html><body>" + strings.Repeat("<div>", MaxDOMDepth-2) + "text</body></html>"))
+	require.NoError(t, err)
+
+	assert.False(t, limitDepth(doc), "A page exactly at the limit has nothing to drop")
+	assert.Equal(t, MaxDOMDepth, depth(doc))
+
+	doc, err = html.Parse(strings.NewReader("<html><body>" + strings.Repeat("<div>", MaxDOMDepth-2) + "text<b>bold</b></body></html>"))
+	require.NoError(t, err)
+	assert.True(t, limitDepth(doc))
+	assert.Equal(t, MaxDOMDepth, depth(doc))
+	var text strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+	collect(doc)
+	assert.Equal(t, "text", text.String(), "Text at the limit should be kept")
+}
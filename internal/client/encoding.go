@@ -0,0 +1,130 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	// acceptEncoding lists the content codings decodeBody understands.
+	acceptEncoding = "gzip, deflate, br"
+	// defaultMaxBodySize caps a page body after decoding, so a small
+	// compressed response cannot expand into gigabytes.
+	defaultMaxBodySize = 32 << 20
+)
+
+// bodyReader reads a response body, undoing its Content-Encoding, and fails
+// with CodeBodyTooLarge once more than limit bytes come out. A limit of zero
+// or less reads any size. Other read failures are reported as
+// CodeBodyReadFailure.
+type bodyReader struct {
+	r     io.Reader
+	limit int64
+	n     int64 // Decoded bytes read so far.
+}
+
+// newBodyReader returns a bodyReader for resp's body.
+func newBodyReader(resp *http.Response, limit int64) (*bodyReader, error) {
+	body, err := decodeBody(resp.Body, resp.Header.Get("Content-Encoding"))
+	if err != nil {
+		return nil, err
+	}
+	return &bodyReader{r: body, limit: limit}, nil
+}
+
+func (b *bodyReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.n += int64(n)
+	if b.limit > 0 && b.n > b.limit {
+		return n, &FetchError{
+			Code:    CodeBodyTooLarge,
+			Message: fmt.Sprintf("Response too large: The page is larger than %d bytes once decoded.", b.limit),
+		}
+	}
+	if err != nil && err != io.EOF {
+		err = &FetchError{Code: CodeBodyReadFailure, Message: fmt.Sprintf("failed to read response body: %v", err)}
+	}
+	return n, err
+}
+
+// maxPooledBufferSize is the largest buffer bodyBuffers keeps. Larger ones,
+// grown by a rare huge page, are left to the garbage collector rather than
+// pinned in the pool.
+const maxPooledBufferSize = 4 << 20
+
+// bodyBuffers holds the buffers response bodies are read into. Reading into a
+// reused buffer and copying the result out once costs one allocation of the
+// body's size, where io.ReadAll grows its slice, and leaves garbage, several
+// times over.
+var bodyBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// readAll reads a bodyReader to the end, into a pooled buffer.
+func readAll(r io.Reader) ([]byte, error) {
+	buf := bodyBuffers.Get().(*bytes.Buffer)
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			buf.Reset()
+			bodyBuffers.Put(buf)
+		}
+	}()
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	data := make([]byte, buf.Len())
+	copy(data, buf.Bytes())
+	return data, nil
+}
+
+// decodeBody wraps body in decoders for a Content-Encoding header. Codings
+// are listed in the order they were applied, so they are undone in reverse.
+func decodeBody(body io.Reader, contentEncoding string) (io.Reader, error) {
+	codings := strings.Split(contentEncoding, ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		coding := strings.ToLower(strings.TrimSpace(codings[i]))
+		var err error
+		switch coding {
+		case "", "identity":
+		case "gzip", "x-gzip":
+			body, err = gzip.NewReader(body)
+		case "deflate":
+			body, err = newDeflateReader(body)
+		case "br":
+			body = brotli.NewReader(body)
+		default:
+			return nil, &FetchError{
+				Code:    CodeUnsupportedEncoding,
+				Message: fmt.Sprintf("Unsupported encoding: The page was sent with the %q content encoding, which cannot be decoded.", coding),
+			}
+		}
+		if err != nil {
+			return nil, &FetchError{Code: CodeBodyReadFailure, Message: fmt.Sprintf("failed to decode %s response body: %v", coding, err)}
+		}
+	}
+	return body, nil
+}
+
+// newDeflateReader decodes "deflate" bodies. The coding is zlib-wrapped, but
+// some servers send raw deflate data, so that is accepted as well.
+func newDeflateReader(body io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(body)
+	header, err := buffered.Peek(2)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	// A zlib header names the deflate method and is a multiple of 31.
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
@@ -0,0 +1,225 @@
+package client
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const page = "<html><head><title>Encoded</title></head><body>Hello</body></html>"
+
+// compress applies coding to data the way a server would.
+func compress(t *testing.T, coding string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch coding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	default:
+		t.Fatalf("unknown coding %q", coding)
+	}
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestDecodeBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+	}{
+		{"none", "", []byte(page)},
+		{"identity", "identity", []byte(page)},
+		{"gzip", "gzip", compress(t, "gzip", []byte(page))},
+		{"deflate", "deflate", compress(t, "deflate", []byte(page))},
+		{"raw deflate", "deflate", compress(t, "raw-deflate", []byte(page))},
+		{"brotli", "BR", compress(t, "br", []byte(page))},
+		{"gzip then brotli", "gzip, br", compress(t, "br", compress(t, "gzip", []byte(page)))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := decodeBody(bytes.NewReader(tt.body), tt.encoding)
+			require.NoError(t, err)
+			data, err := io.ReadAll(body)
+			require.NoError(t, err)
+			assert.Equal(t, page, string(data))
+		})
+	}
+}
+
+func TestDecodeBody_Errors(t *testing.T) {
+	_, err := decodeBody(strings.NewReader(page), "zstd")
+	var fetchErr *FetchError
+	require.ErrorAs(t, err, &fetchErr)
+	assert.Equal(t, CodeUnsupportedEncoding, fetchErr.Code)
+
+	_, err = decodeBody(strings.NewReader(page), "gzip")
+	require.ErrorAs(t, err, &fetchErr)
+	assert.Equal(t, CodeBodyReadFailure, fetchErr.Code, "A body that is not gzip should fail to decode")
+}
+
+// encodedServer serves body with the given Content-Encoding and records the
+// Accept-Encoding it was asked for.
+func encodedServer(t *testing.T, encoding string, body []byte, accepted *string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*accepted = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Encoding", encoding)
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPClient_DecodesCompressedPages(t *testing.T) {
+	for _, coding := range []string{"gzip", "deflate", "br"} {
+		t.Run(coding, func(t *testing.T) {
+			var accepted string
+			server := encodedServer(t, coding, compress(t, coding, []byte(page)), &accepted)
+
+			body, status, err := NewHTTPClient().FetchWebpage(context.Background(), server.URL)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, page, string(body), "The parser should get decoded HTML")
+			assert.Equal(t, acceptEncoding, accepted)
+		})
+	}
+}
+
+func TestHTTPClient_LimitsDecodedSize(t *testing.T) {
+	// A few kilobytes of gzip that expand to a megabyte.
+	var accepted string
+	bomb := compress(t, "gzip", bytes.Repeat([]byte("a"), 1<<20))
+	server := encodedServer(t, "gzip", bomb, &accepted)
+
+	cfg := DefaultConfig()
+	cfg.MaxBodySize = 64 << 10
+	_, status, err := NewHTTPClientWithConfig(cfg).FetchWebpage(context.Background(), server.URL)
+	var fetchErr *FetchError
+	require.ErrorAs(t, err, &fetchErr)
+	assert.Equal(t, CodeBodyTooLarge, fetchErr.Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+	assert.False(t, isHostFailure(status), "A page that is too large says nothing about the host")
+}
+
+func TestHTTPClient_FetchDocument_LimitsDecodedSize(t *testing.T) {
+	var accepted string
+	bomb := compress(t, "gzip", bytes.Repeat([]byte("<p>a</p>"), 1<<17))
+	server := encodedServer(t, "gzip", bomb, &accepted)
+
+	cfg := DefaultConfig()
+	cfg.MaxBodySize = 64 << 10
+	doc, status, err := NewHTTPClientWithConfig(cfg).(DocumentFetcher).FetchDocument(context.Background(), server.URL)
+	var fetchErr *FetchError
+	require.ErrorAs(t, err, &fetchErr, "The limit should stop the parse")
+	assert.Equal(t, CodeBodyTooLarge, fetchErr.Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+	assert.Nil(t, doc)
+}
+
+// benchmarkPage is about 200 KB of HTML.
+var benchmarkPage = "<!DOCTYPE html><html><head><title>Benchmark</title></head><body>" +
+	strings.Repeat(`<div class="card"><h2>Section heading</h2><p>Some <strong>bold</strong> text and a <a href="/page">link</a>.</p><ul><li>One</li><li>Two</li></ul></div>`, 1500) +
+	"</body></html>"
+
+// nestedBenchmarkPage has cards like benchmarkPage's, each nested in the one
+// before, as deep as the parser keeps.
+var nestedBenchmarkPage = "<!DOCTYPE html><html><head><title>Benchmark</title></head><body>" +
+	strings.Repeat(`<div class="card"><h2>Section heading</h2><p>Some <strong>bold</strong> text and a <a href="/page">link</a>.</p><ul><li>One</li><li>Two</li></ul>`, 500) +
+	strings.Repeat("</div>", 500) + "</body></html>"
+
+func BenchmarkReadBody(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		resp := &http.Response{Header: http.Header{}, Body: io.NopCloser(strings.NewReader(benchmarkPage))}
+		body, err := newBodyReader(resp, defaultMaxBodySize)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := readAll(body); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseHTML(b *testing.B) {
+	for _, bm := range []struct {
+		name string
+		page string
+	}{
+		{"flat", benchmarkPage},
+		{"nested", nestedBenchmarkPage},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			c := NewHTTPClient()
+			content := []byte(bm.page)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.ParseHTML(content); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func benchmarkServer(b *testing.B) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, benchmarkPage)
+	}))
+	b.Cleanup(server.Close)
+	return server
+}
+
+func BenchmarkFetchWebpageAndParse(b *testing.B) {
+	server := benchmarkServer(b)
+	c := NewHTTPClient()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		body, _, err := c.FetchWebpage(context.Background(), server.URL)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := c.ParseHTML(body); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFetchDocument(b *testing.B) {
+	server := benchmarkServer(b)
+	c := NewHTTPClient().(DocumentFetcher)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := c.FetchDocument(context.Background(), server.URL); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
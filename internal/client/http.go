@@ -1,126 +1,303 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
 	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/logging"
+)
+
+const (
+	// userAgent is sent with every request whose context sets no other.
+	userAgent = "WebpageAnalyzer/1.0"
+	// userAgentToken is the name robots.txt groups use for us.
+	userAgentToken = "WebpageAnalyzer"
 )
 
 // httpClient implements the HTTPClient interface.
 type httpClient struct {
-	client *http.Client
+	client      *http.Client
+	retryAfter  RetryAfterConfig
+	maxBodySize int64
+	limiter     *hostLimiter // nil when requests are not paced.
+	breaker     *hostBreaker // nil when failing hosts are not skipped.
+	dns         *dnsCache    // nil when DNS answers are not cached.
 }
 
 // NewHTTPClient creates a new HTTP client instance.
 func NewHTTPClient() HTTPClient {
+	return NewHTTPClientWithConfig(DefaultConfig())
+}
+
+// NewHTTPClientWithConfig creates an HTTP client with the given configuration.
+func NewHTTPClientWithConfig(cfg Config) HTTPClient {
+	transport := cfg.Transport.transport()
+	dns := newDNSCache(cfg.DNSCache)
+	dialer := cfg.Dial.dialer(cfg.Transport.dialer())
+	switch {
+	case dns != nil:
+		transport.DialContext = dialResolved(dialer, cfg.Dial.family(), dns.resolve)
+	case cfg.Dial.custom():
+		transport.DialContext = dialResolved(dialer, cfg.Dial.family(), net.DefaultResolver.LookupHost)
+	default:
+		transport.DialContext = dialer.DialContext
+	}
+	client := &http.Client{
+		Timeout:       cfg.Timeout,
+		Transport:     transport,
+		CheckRedirect: cfg.Redirects.checkRedirect(),
+	}
+	if cfg.HTTP3 {
+		client.Transport = newAltSvcTransport(transport)
+	}
 	return &httpClient{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				DisableCompression: false,
-				DisableKeepAlives:  false,
-			},
-		},
+		client:      client,
+		retryAfter:  cfg.RetryAfter,
+		maxBodySize: cfg.MaxBodySize,
+		limiter:     newHostLimiter(cfg.HostLimit, client),
+		breaker:     newHostBreaker(cfg.Breaker),
+		dns:         dns,
+	}
+}
+
+// DNSCacheStats returns the DNS cache counters; they stay zero when the
+// cache is disabled.
+func (c *httpClient) DNSCacheStats() DNSCacheStats {
+	if c.dns == nil {
+		return DNSCacheStats{}
 	}
+	return c.dns.stats()
 }
 
 // FetchWebpage fetches a webpage and returns its content, status code, and any error.
 func (c *httpClient) FetchWebpage(ctx context.Context, urlStr string) ([]byte, int, error) {
+	var body []byte
+	statusCode, err := c.fetch(ctx, http.MethodGet, urlStr, func(r io.Reader, statusCode int) (err error) {
+		body, err = readAll(r)
+		return err
+	})
+	if err != nil {
+		return nil, statusCode, err
+	}
+	return body, statusCode, nil
+}
+
+// FetchDocument fetches a webpage and parses it as it is read, so the raw
+// page is never held in memory. Only a 200 OK page is parsed; for other
+// statuses the document is nil.
+func (c *httpClient) FetchDocument(ctx context.Context, urlStr string) (*html.Node, int, error) {
+	var doc *html.Node
+	statusCode, err := c.fetch(ctx, http.MethodGet, urlStr, func(r io.Reader, statusCode int) (err error) {
+		if statusCode != http.StatusOK {
+			return nil
+		}
+		if doc, err = parseHTML(ctx, r); err != nil && !errors.As(err, new(*FetchError)) {
+			err = &FetchError{Code: CodeBodyReadFailure, Message: fmt.Sprintf("failed to parse HTML: %v", err)}
+		}
+		return err
+	})
+	if err != nil {
+		return nil, statusCode, err
+	}
+	return doc, statusCode, nil
+}
+
+// CheckStatus returns the status url answers with after redirects, without
+// downloading its body: it sends a HEAD request, and a GET whose body is left
+// unread only if the server does not allow HEAD.
+func (c *httpClient) CheckStatus(ctx context.Context, urlStr string) (int, error) {
+	statusCode, err := c.fetch(ctx, http.MethodHead, urlStr, nil)
+	if err == nil && (statusCode == http.StatusMethodNotAllowed || statusCode == http.StatusNotImplemented) {
+		statusCode, err = c.fetch(ctx, http.MethodGet, urlStr, nil)
+	}
+	return statusCode, err
+}
+
+// fetch sends a request for urlStr and passes the decoded body of the final
+// response to consume. A nil consume leaves the body unread.
+func (c *httpClient) fetch(ctx context.Context, method, urlStr string, consume func(body io.Reader, statusCode int) error) (int, error) {
 	// Validate URL format first.
-	if err := c.validateURL(urlStr); err != nil {
-		return nil, 400, fmt.Errorf("invalid URL format: %v", err)
+	u, err := c.validateURL(urlStr)
+	if err != nil {
+		return 400, &FetchError{Code: CodeInvalidURL, Message: fmt.Sprintf("invalid URL format: %v", err)}
+	}
+	if err := checkScheme(u); err != nil {
+		return 400, err
 	}
 
 	// Create request with proper headers.
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, method, urlStr, nil)
 	if err != nil {
-		return nil, 400, fmt.Errorf("failed to create request: %v", err)
+		return 400, &FetchError{Code: CodeInvalidURL, Message: fmt.Sprintf("failed to create request: %v", err)}
 	}
 
 	// Add proper headers.
-	httpReq.Header.Set("User-Agent", "WebpageAnalyzer/1.0")
+	setUserAgent(httpReq)
 	httpReq.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	httpReq.Header.Set("Accept-Language", "en-US,en;q=0.5")
-	// Don't request compressed content to avoid decompression issues
-	httpReq.Header.Set("Accept-Encoding", "identity")
+	setAcceptLanguage(httpReq)
+	// Setting Accept-Encoding turns off the transport's own gzip handling;
+	// do decodes every coding listed here.
+	httpReq.Header.Set("Accept-Encoding", acceptEncoding)
 	httpReq.Header.Set("Connection", "keep-alive")
+	authorize(httpReq)
+
+	// Wait for our turn at this host.
+	if c.limiter != nil {
+		if err := c.limiter.wait(ctx, httpReq.URL); err != nil {
+			statusCode, fetchErr := c.categorizeNetworkError(err, urlStr)
+			return statusCode, fetchErr
+		}
+	}
+
+	// Skip hosts that keep failing.
+	if c.breaker != nil {
+		if err := c.breaker.allow(httpReq.URL.Host); err != nil {
+			return http.StatusServiceUnavailable, err
+		}
+	}
+
+	statusCode, header, err := c.do(httpReq, consume)
+	// Wait out a 429 or 503 whose Retry-After fits the budget, and try again.
+	for retries := 0; err == nil && retries < c.retryAfter.MaxRetries; retries++ {
+		wait, ok := retryAfter(statusCode, header, time.Now())
+		if !ok || !c.retryAfter.canWait(ctx, wait) {
+			break
+		}
+		logging.FromContext(ctx).Info("Target asked to retry later, waiting", "target_url", urlStr, "status_code", statusCode, "retry_after", wait)
+		if !sleep(ctx, wait) {
+			break
+		}
+		statusCode, header, err = c.do(httpReq, consume)
+	}
+	if c.breaker != nil {
+		if ctx.Err() != nil {
+			// We gave up; that says nothing about the host.
+			c.breaker.abandon(httpReq.URL.Host)
+		} else {
+			// A refused redirect is an answer; the host is up.
+			failed := !isPolicyError(err) && (err != nil || isHostFailure(statusCode))
+			c.breaker.record(httpReq.URL.Host, failed)
+		}
+	}
+	return statusCode, err
+}
 
-	// Fetch the webpage.
+// do sends the request and passes the decoded response body to consume.
+func (c *httpClient) do(httpReq *http.Request, consume func(body io.Reader, statusCode int) error) (int, http.Header, error) {
 	resp, err := c.client.Do(httpReq)
 	if err != nil {
+		// Redirects refused by the policy carry their own error.
+		var fetchErr *FetchError
+		if errors.As(err, &fetchErr) {
+			return http.StatusBadGateway, nil, fetchErr
+		}
 		// Categorize network errors and provide appropriate status codes.
-		statusCode, errorMsg := c.categorizeNetworkError(err, urlStr)
-		return nil, statusCode, fmt.Errorf(errorMsg)
+		statusCode, fetchErr := c.categorizeNetworkError(err, httpReq.URL.String())
+		return statusCode, nil, fetchErr
 	}
 	defer resp.Body.Close()
+	recordResponse(httpReq, resp)
+	if consume == nil {
+		return resp.StatusCode, resp.Header, nil
+	}
 
-	// Read the response body.
-	body, err := io.ReadAll(resp.Body)
+	// Decode the response body as it is consumed.
+	body, err := newBodyReader(resp, c.maxBodySize)
+	if err == nil {
+		err = consume(body, resp.StatusCode)
+		recordBodySize(httpReq, body.n)
+		countBytesFetched(httpReq, body.n)
+	}
 	if err != nil {
-		return nil, resp.StatusCode, fmt.Errorf("failed to read response body: %v", err)
+		var fetchErr *FetchError
+		if errors.As(err, &fetchErr) && fetchErr.Code == CodeBodyTooLarge {
+			return http.StatusRequestEntityTooLarge, resp.Header, err
+		}
+		return resp.StatusCode, resp.Header, err
 	}
 
-	return body, resp.StatusCode, nil
+	return resp.StatusCode, resp.Header, nil
 }
 
-// validateURL checks if the URL is properly formatted.
-func (c *httpClient) validateURL(urlStr string) error {
-	_, err := url.Parse(urlStr)
+// validateURL checks if the URL is properly formatted and returns it parsed.
+func (c *httpClient) validateURL(urlStr string) (*url.URL, error) {
+	u, err := url.Parse(urlStr)
 	if err != nil {
-		return fmt.Errorf("malformed URL: %v", err)
+		return nil, fmt.Errorf("malformed URL: %v", err)
 	}
-	return nil
+	return u, nil
 }
 
-// categorizeNetworkError categorizes network errors and returns appropriate status codes and messages.
-func (c *httpClient) categorizeNetworkError(err error, urlStr string) (int, string) {
+// categorizeNetworkError categorizes network errors and returns appropriate status codes and errors.
+func (c *httpClient) categorizeNetworkError(err error, urlStr string) (int, *FetchError) {
 	errStr := err.Error()
 
 	// DNS resolution errors.
 	if strings.Contains(errStr, "no such host") || strings.Contains(errStr, "lookup") {
-		return 404, "DNS resolution failed: The domain could not be found. Please check if the URL is correct."
+		return 404, &FetchError{Code: CodeDNSFailure, Message: "DNS resolution failed: The domain could not be found. Please check if the URL is correct."}
 	}
 
 	// Connection refused/timeout errors.
 	if strings.Contains(errStr, "connection refused") || strings.Contains(errStr, "connect: connection refused") {
-		return 503, "Connection refused: The server is not accepting connections. The service might be down or the port might be closed."
+		return 503, &FetchError{Code: CodeConnectionRefused, Message: "Connection refused: The server is not accepting connections. The service might be down or the port might be closed."}
 	}
 
 	// Network timeout errors.
 	if strings.Contains(errStr, "timeout") || strings.Contains(errStr, "deadline exceeded") {
-		return 408, "Request timeout: The server took too long to respond. Please try again later."
+		return 408, &FetchError{Code: CodeTimeout, Message: "Request timeout: The server took too long to respond. Please try again later."}
 	}
 
 	// SSL/TLS errors.
 	if strings.Contains(errStr, "certificate") || strings.Contains(errStr, "tls") || strings.Contains(errStr, "ssl") {
-		return 495, "SSL/TLS error: There was a problem with the security certificate. The connection is not secure."
+		return 495, &FetchError{Code: CodeTLSFailure, Message: "SSL/TLS error: There was a problem with the security certificate. The connection is not secure."}
 	}
 
 	// Protocol errors.
 	if strings.Contains(errStr, "protocol") || strings.Contains(errStr, "unsupported protocol") {
-		return 400, "Protocol error: The URL uses an unsupported protocol. Please use http:// or https://."
+		return 400, &FetchError{Code: CodeUnsupportedProtocol, Message: "Protocol error: The URL uses an unsupported protocol. Please use http:// or https://."}
 	}
 
 	// Network unreachable.
 	if strings.Contains(errStr, "network is unreachable") || strings.Contains(errStr, "no route to host") {
-		return 503, "Network unreachable: Cannot reach the server. Please check your internet connection."
+		return 503, &FetchError{Code: CodeNetworkUnreachable, Message: "Network unreachable: Cannot reach the server. Please check your internet connection."}
 	}
 
 	// Generic network error.
-	return 503, fmt.Sprintf("Network error: %v. Please check your internet connection and try again.", err)
+	return 503, &FetchError{Code: CodeNetworkError, Message: fmt.Sprintf("Network error: %v. Please check your internet connection and try again.", err)}
 }
 
-// ParseHTML parses HTML content and returns the document node.
-func (c *httpClient) ParseHTML(content []byte) (interface{}, error) {
-	doc, err := html.Parse(strings.NewReader(string(content)))
+// ParseHTML parses HTML content and returns the document node. Elements
+// nested deeper than MaxDOMDepth are dropped. content is read in place, not
+// copied. Having no request context, it logs dropped elements with the
+// default logger.
+func (c *httpClient) ParseHTML(content []byte) (*html.Node, error) {
+	doc, err := parseHTML(context.Background(), bytes.NewReader(content))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %v", err)
 	}
 	return doc, nil
 }
+
+// parseHTML parses the HTML read from r, dropping elements nested deeper than
+// MaxDOMDepth, which it logs with ctx's logger. Errors reading r are returned
+// as they are.
+func parseHTML(ctx context.Context, r io.Reader) (*html.Node, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if limitDepth(doc) {
+		logging.FromContext(ctx).Warn("Dropped elements nested too deeply", "max_depth", MaxDOMDepth)
+	}
+	return doc, nil
+}
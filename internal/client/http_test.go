@@ -116,6 +116,32 @@ func TestHTTPClient_FetchWebpage_Timeout(t *testing.T) {
 	require.Error(t, err, "FetchWebpage() should return error for timeout")
 	assert.Nil(t, content, "FetchWebpage() should return nil content for timeout")
 	assert.Equal(t, 408, statusCode, "Status code should be 408 for timeout")
+	var fetchErr *FetchError
+	require.ErrorAs(t, err, &fetchErr)
+	assert.Equal(t, CodeTimeout, fetchErr.ErrorCode())
+}
+
+func TestHTTPClient_FetchWebpage_ErrorCodes(t *testing.T) {
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+
+	tests := []struct {
+		name string
+		url  string
+		code string
+	}{
+		{"unsupported scheme", "invalid-url", CodeUnsupportedProtocol},
+		{"malformed URL", "http://[::1", CodeInvalidURL},
+		{"connection refused", closed.URL, CodeConnectionRefused},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := NewHTTPClient().FetchWebpage(context.Background(), tt.url)
+			var fetchErr *FetchError
+			require.ErrorAs(t, err, &fetchErr)
+			assert.Equal(t, tt.code, fetchErr.Code)
+		})
+	}
 }
 
 func TestHTTPClient_FetchWebpage_NonHTMLContent(t *testing.T) {
@@ -216,6 +242,61 @@ func TestHTTPClient_FetchWebpage_UserAgent(t *testing.T) {
 	assert.Contains(t, userAgent, "WebpageAnalyzer", "User-Agent should contain 'WebpageAnalyzer'")
 }
 
+func TestHTTPClient_FetchDocument(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("<html><title>Not found</title></html>"))
+			return
+		}
+		w.Write([]byte("<html><head><title>Streamed</title></head><body></body></html>"))
+	}))
+	defer server.Close()
+	c := NewHTTPClient().(DocumentFetcher)
+
+	var info ResponseInfo
+	doc, status, err := c.FetchDocument(WithResponseInfo(context.Background(), &info), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	require.NotNil(t, doc)
+	assert.Equal(t, "Streamed", doc.FirstChild.FirstChild.FirstChild.FirstChild.Data, "The page should be parsed")
+	assert.EqualValues(t, 62, info.BodySize, "The size of the body read should be recorded")
+
+	doc, status, err = c.FetchDocument(context.Background(), server.URL+"/missing")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Nil(t, doc, "Error pages should not be parsed")
+}
+
+func TestHTTPClient_CheckStatus(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method+" "+r.URL.Path)
+		switch {
+		case r.URL.Path == "/moved":
+			http.Redirect(w, r, "/page", http.StatusMovedPermanently)
+		case r.URL.Path == "/no-head" && r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		default:
+			w.Header().Set("Content-Encoding", "gzip") // Never read, so never decoded.
+			_, _ = w.Write([]byte("not really gzip"))
+		}
+	}))
+	defer server.Close()
+	checker := NewHTTPClient().(StatusChecker)
+
+	statusCode, err := checker.CheckStatus(context.Background(), server.URL+"/moved")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, statusCode, "Redirects should be followed")
+	assert.Equal(t, []string{"HEAD /moved", "HEAD /page"}, methods, "A HEAD request should do")
+
+	methods = nil
+	statusCode, err = checker.CheckStatus(context.Background(), server.URL+"/no-head")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, []string{"HEAD /no-head", "GET /no-head"}, methods, "Servers refusing HEAD should get a GET")
+}
+
 func TestHTTPClient_ParseHTML_Success(t *testing.T) {
 	client := NewHTTPClient()
 	htmlContent := []byte(`<!DOCTYPE html><html><head><title>Test</title></head><body>Hello</body></html>`)
@@ -225,10 +306,7 @@ func TestHTTPClient_ParseHTML_Success(t *testing.T) {
 	require.NoError(t, err, "ParseHTML() should not return error")
 	require.NotNil(t, doc, "ParseHTML() should not return nil document")
 
-	// Check if it's a valid HTML node
-	htmlNode, ok := doc.(*html.Node)
-	require.True(t, ok, "ParseHTML() should return *html.Node")
-	assert.Equal(t, html.DocumentNode, htmlNode.Type, "Should return document node type")
+	assert.Equal(t, html.DocumentNode, doc.Type, "Should return document node type")
 }
 
 func TestHTTPClient_ParseHTML_InvalidHTML(t *testing.T) {
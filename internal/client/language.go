@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// defaultAcceptLanguage is sent when the context asks for no language.
+const defaultAcceptLanguage = "en-US,en;q=0.5"
+
+// acceptLanguageKey is the context key for the Accept-Language header value.
+type acceptLanguageKey struct{}
+
+// WithAcceptLanguage returns a context whose requests send value as their
+// Accept-Language header, such as "de-DE,de;q=0.9", to fetch the page as a
+// reader with those language preferences would. An empty value keeps the
+// default.
+func WithAcceptLanguage(ctx context.Context, value string) context.Context {
+	if value == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, acceptLanguageKey{}, value)
+}
+
+// AcceptLanguage returns the Accept-Language header value requests made with
+// ctx send.
+func AcceptLanguage(ctx context.Context) string {
+	if value, ok := ctx.Value(acceptLanguageKey{}).(string); ok {
+		return value
+	}
+	return defaultAcceptLanguage
+}
+
+// setAcceptLanguage sets the Accept-Language header of req from its context.
+func setAcceptLanguage(req *http.Request) {
+	req.Header.Set("Accept-Language", AcceptLanguage(req.Context()))
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClient_SendsAcceptLanguage(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Accept-Language"))
+	}))
+	defer server.Close()
+
+	c := NewHTTPClient()
+	_, _, err := c.FetchWebpage(context.Background(), server.URL)
+	require.NoError(t, err)
+	_, _, err = c.FetchWebpage(WithAcceptLanguage(context.Background(), "de-DE,de;q=0.9"), server.URL)
+	require.NoError(t, err)
+	_, _, err = c.FetchWebpage(WithAcceptLanguage(context.Background(), ""), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, []string{defaultAcceptLanguage, "de-DE,de;q=0.9", defaultAcceptLanguage}, got)
+}
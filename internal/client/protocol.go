@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+
+	"webpage-analyzer/internal/logging"
+)
+
+// ResponseInfo describes how a page was served. Pass one to
+// WithResponseInfo to have FetchWebpage fill it in.
+type ResponseInfo struct {
+	URL             string        // URL of the final response, after redirects.
+	Protocol        string        // Protocol of the final response, e.g. "HTTP/2.0".
+	HTTP3Advertised bool          // The response offered HTTP/3 in an Alt-Svc header.
+	RetryAfter      time.Duration // Wait advised by a final 429 or 503 response; zero if none.
+	Header          http.Header   // Headers of the final response.
+	BodySize        int64         // Bytes of the final response body read, once decoded.
+}
+
+// responseInfoKey is the context key for a ResponseInfo to fill in.
+type responseInfoKey struct{}
+
+// WithResponseInfo returns a context whose page fetch records how the page
+// was served in info.
+func WithResponseInfo(ctx context.Context, info *ResponseInfo) context.Context {
+	return context.WithValue(ctx, responseInfoKey{}, info)
+}
+
+// recordResponse fills in the ResponseInfo of req's context, if it has one.
+func recordResponse(req *http.Request, resp *http.Response) {
+	info, ok := req.Context().Value(responseInfoKey{}).(*ResponseInfo)
+	if !ok || info == nil {
+		return
+	}
+	if resp.Request != nil {
+		info.URL = resp.Request.URL.String()
+	}
+	info.Protocol = resp.Proto
+	info.Header = resp.Header
+	info.HTTP3Advertised = info.HTTP3Advertised || advertisesHTTP3(resp.Header.Values("Alt-Svc"), "")
+	info.RetryAfter, _ = retryAfter(resp.StatusCode, resp.Header, time.Now())
+}
+
+// recordBodySize records the size of the body read for req in the
+// ResponseInfo of its context, if it has one.
+func recordBodySize(req *http.Request, n int64) {
+	if info, ok := req.Context().Value(responseInfoKey{}).(*ResponseInfo); ok && info != nil {
+		info.BodySize = n
+	}
+}
+
+// bytesFetchedKey is the context key for a counter of the bytes fetched.
+type bytesFetchedKey struct{}
+
+// WithBytesFetched returns a context whose fetches, including those of
+// modules and link probes, add the size of every response body they read,
+// once decoded, to n.
+func WithBytesFetched(ctx context.Context, n *atomic.Int64) context.Context {
+	return context.WithValue(ctx, bytesFetchedKey{}, n)
+}
+
+// countBytesFetched adds n to the bytes fetched counter of req's context, if
+// it has one.
+func countBytesFetched(req *http.Request, n int64) {
+	if counter, ok := req.Context().Value(bytesFetchedKey{}).(*atomic.Int64); ok && counter != nil {
+		counter.Add(n)
+	}
+}
+
+// advertisesHTTP3 reports whether an Alt-Svc header offers HTTP/3. With a
+// port, only an offer on that port of the same host counts, which is what
+// can be used without resolving another authority.
+func advertisesHTTP3(altSvc []string, port string) bool {
+	for _, header := range altSvc {
+		for _, entry := range strings.Split(header, ",") {
+			protocol, authority, ok := strings.Cut(strings.SplitN(entry, ";", 2)[0], "=")
+			if !ok || strings.TrimSpace(protocol) != "h3" {
+				continue
+			}
+			if port == "" {
+				return true
+			}
+			host, altPort, err := net.SplitHostPort(strings.Trim(strings.TrimSpace(authority), `"`))
+			if err == nil && host == "" && altPort == port {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// altSvcTransport sends requests over HTTP/3 to hosts that advertised it in
+// an Alt-Svc header, and over TCP otherwise. A host whose HTTP/3 request fails
+// is used over TCP again until a later response advertises HTTP/3 anew.
+type altSvcTransport struct {
+	tcp  http.RoundTripper
+	quic http.RoundTripper
+
+	mu    sync.Mutex
+	hosts map[string]bool // Hosts, with port, that advertised HTTP/3.
+}
+
+// newAltSvcTransport wraps tcp with an experimental HTTP/3 transport.
+func newAltSvcTransport(tcp http.RoundTripper) *altSvcTransport {
+	return &altSvcTransport{
+		tcp:   tcp,
+		quic:  &http3.Transport{},
+		hosts: make(map[string]bool),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *altSvcTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	fellBack := false
+	if req.URL.Scheme == "https" && t.advertised(host) {
+		resp, err := t.quic.RoundTrip(req)
+		if err == nil {
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+		logging.FromContext(req.Context()).Debug("HTTP/3 request failed, falling back to TCP", "host", host, "error", err)
+		t.setAdvertised(host, false)
+		fellBack = true
+	}
+
+	resp, err := t.tcp.RoundTrip(req)
+	if err == nil && req.URL.Scheme == "https" && !fellBack {
+		t.setAdvertised(host, advertisesHTTP3(resp.Header.Values("Alt-Svc"), port(req)))
+	}
+	return resp, err
+}
+
+// advertised reports whether host offered HTTP/3.
+func (t *altSvcTransport) advertised(host string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.hosts[host]
+}
+
+// setAdvertised records whether host offers HTTP/3.
+func (t *altSvcTransport) setAdvertised(host string, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if ok {
+		t.hosts[host] = true
+	} else {
+		delete(t.hosts, host)
+	}
+}
+
+// port returns the port req is sent to.
+func port(req *http.Request) string {
+	if p := req.URL.Port(); p != "" {
+		return p
+	}
+	if req.URL.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
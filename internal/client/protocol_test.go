@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvertisesHTTP3(t *testing.T) {
+	tests := []struct {
+		name     string
+		altSvc   []string
+		port     string
+		expected bool
+	}{
+		{"none", nil, "", false},
+		{"h3", []string{`h3=":443"; ma=86400`}, "", true},
+		{"among others", []string{`h2=":443", h3=":443"; ma=86400`}, "443", true},
+		{"second header", []string{`h2=":443"`, `h3=":443"`}, "443", true},
+		{"draft only", []string{`h3-29=":443"`}, "", false},
+		{"other port", []string{`h3=":8443"`}, "443", false},
+		{"other host", []string{`h3="alt.example.com:443"`}, "443", false},
+		{"cleared", []string{"clear"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, advertisesHTTP3(tt.altSvc, tt.port))
+		})
+	}
+}
+
+func TestHTTPClient_RecordsProtocol(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Alt-Svc", `h3=":443"; ma=86400`)
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	c := NewHTTPClient().(*httpClient)
+	c.client.Transport.(*http.Transport).TLSClientConfig = server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	var info ResponseInfo
+	_, _, err := c.FetchWebpage(WithResponseInfo(context.Background(), &info), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/2.0", info.Protocol, "HTTP/2 should be negotiated over TLS")
+	assert.True(t, info.HTTP3Advertised)
+
+	// Fetches without a ResponseInfo still work.
+	_, _, err = c.FetchWebpage(context.Background(), server.URL)
+	require.NoError(t, err)
+}
+
+func TestHTTPClient_CountsBytesFetched(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	var fetched atomic.Int64
+	ctx := WithBytesFetched(context.Background(), &fetched)
+	for i := 0; i < 2; i++ {
+		_, _, err := NewHTTPClient().FetchWebpage(ctx, server.URL)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(2*len("<html></html>")), fetched.Load(), "Every fetch with the context should add its body size")
+}
+
+// stubTransport answers every request with the same response or error.
+type stubTransport struct {
+	altSvc string
+	err    error
+	calls  int
+}
+
+func (s *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	header := http.Header{}
+	if s.altSvc != "" {
+		header.Set("Alt-Svc", s.altSvc)
+	}
+	return &http.Response{StatusCode: http.StatusOK, Header: header, Body: http.NoBody, Request: req}, nil
+}
+
+func TestAltSvcTransport(t *testing.T) {
+	tcp := &stubTransport{altSvc: `h3=":443"`}
+	quic := &stubTransport{}
+	transport := &altSvcTransport{tcp: tcp, quic: quic, hosts: make(map[string]bool)}
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/", nil)
+
+	_, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, tcp.calls, "The first request should go over TCP")
+
+	_, err = transport.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, quic.calls, "A host advertising HTTP/3 should be fetched over it")
+
+	quic.err = errors.New("no UDP")
+	_, err = transport.RoundTrip(req)
+	require.NoError(t, err, "A failed HTTP/3 request should fall back to TCP")
+	assert.Equal(t, 2, quic.calls)
+	assert.Equal(t, 2, tcp.calls)
+
+	_, err = transport.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, 2, quic.calls, "The fallback response should not switch the host back to HTTP/3")
+	assert.Equal(t, 3, tcp.calls)
+
+	quic.err = nil
+	_, err = transport.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, 3, quic.calls, "A later advertisement should switch the host back to HTTP/3")
+
+	plain := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	tcp.altSvc = `h3=":80"`
+	_, _ = transport.RoundTrip(plain)
+	_, _ = transport.RoundTrip(plain)
+	assert.Equal(t, 3, quic.calls, "Plain HTTP should never use HTTP/3")
+}
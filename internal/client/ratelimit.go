@@ -0,0 +1,208 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"webpage-analyzer/internal/logging"
+)
+
+const (
+	// maxRobotsSize bounds how much of a robots.txt file is read.
+	maxRobotsSize = 512 * 1024
+	// defaultMaxCrawlDelay is used when HostLimitConfig.MaxCrawlDelay is unset.
+	defaultMaxCrawlDelay = 10 * time.Second
+)
+
+// HostLimitConfig configures per-host politeness.
+type HostLimitConfig struct {
+	// RequestsPerSecond is the most requests sent to one host per second;
+	// zero or less means no limit.
+	RequestsPerSecond float64
+	// RespectCrawlDelay reads each host's robots.txt once and spaces requests
+	// by its Crawl-delay when that is slower than RequestsPerSecond.
+	RespectCrawlDelay bool
+	// MaxCrawlDelay caps the Crawl-delay honoured, so that one site cannot
+	// stall a job indefinitely. Zero means 10 seconds.
+	MaxCrawlDelay time.Duration
+}
+
+// hostLimiter spaces out requests to each host. It is shared by every
+// goroutine using the client, so a crawl fanned out over the worker pool
+// still reaches a site at the configured pace.
+type hostLimiter struct {
+	cfg   HostLimitConfig
+	robot *http.Client // Fetches robots.txt.
+
+	mu    sync.Mutex
+	hosts map[string]*hostSchedule
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// hostSchedule is the pacing state of one host.
+type hostSchedule struct {
+	next       time.Time // Earliest time of the next request.
+	robotsOnce sync.Once
+	crawlDelay time.Duration
+}
+
+// newHostLimiter returns a limiter, or nil when cfg imposes no limit.
+func newHostLimiter(cfg HostLimitConfig, robot *http.Client) *hostLimiter {
+	if cfg.RequestsPerSecond <= 0 && !cfg.RespectCrawlDelay {
+		return nil
+	}
+	if cfg.MaxCrawlDelay <= 0 {
+		cfg.MaxCrawlDelay = defaultMaxCrawlDelay
+	}
+	return &hostLimiter{
+		cfg:   cfg,
+		robot: robot,
+		hosts: make(map[string]*hostSchedule),
+		now:   time.Now,
+		sleep: sleepContext,
+	}
+}
+
+// wait blocks until a request to u may be sent, or ctx ends.
+func (l *hostLimiter) wait(ctx context.Context, u *url.URL) error {
+	host := strings.ToLower(u.Host)
+
+	l.mu.Lock()
+	schedule, ok := l.hosts[host]
+	if !ok {
+		schedule = &hostSchedule{}
+		l.hosts[host] = schedule
+	}
+	l.mu.Unlock()
+
+	if l.cfg.RespectCrawlDelay {
+		schedule.robotsOnce.Do(func() {
+			schedule.crawlDelay = min(l.fetchCrawlDelay(ctx, u), l.cfg.MaxCrawlDelay)
+			if schedule.crawlDelay > 0 {
+				logging.FromContext(ctx).Info("Honouring robots.txt crawl delay", "host", host, "delay", schedule.crawlDelay)
+			}
+		})
+	}
+
+	interval := schedule.crawlDelay
+	if l.cfg.RequestsPerSecond > 0 {
+		interval = max(interval, time.Duration(float64(time.Second)/l.cfg.RequestsPerSecond))
+	}
+
+	// Reserve the next slot, then wait for it outside the lock.
+	l.mu.Lock()
+	now := l.now()
+	slot := schedule.next
+	if slot.Before(now) {
+		slot = now
+	}
+	schedule.next = slot.Add(interval)
+	l.mu.Unlock()
+
+	return l.sleep(ctx, slot.Sub(now))
+}
+
+// fetchCrawlDelay reads the Crawl-delay that u's robots.txt sets for us.
+// Missing or unreadable files mean no delay.
+func (l *hostLimiter) fetchCrawlDelay(ctx context.Context, u *url.URL) time.Duration {
+	robotsURL := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}).String()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return 0
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := l.robot.Do(req)
+	if err != nil {
+		return 0
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsSize))
+	if err != nil {
+		return 0
+	}
+	return parseCrawlDelay(body, userAgentToken)
+}
+
+// parseCrawlDelay returns the Crawl-delay of the robots.txt group that
+// applies to agent, preferring a group naming agent over the "*" group.
+func parseCrawlDelay(robots []byte, agent string) time.Duration {
+	agent = strings.ToLower(agent)
+	var (
+		groupAgents []string
+		inRules     bool // Whether the current group's rules have started.
+		specific    = time.Duration(-1)
+		wildcard    = time.Duration(-1)
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(robots))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		field = strings.ToLower(strings.TrimSpace(field))
+		value = strings.TrimSpace(value)
+
+		switch field {
+		case "user-agent":
+			if inRules {
+				groupAgents, inRules = nil, false
+			}
+			groupAgents = append(groupAgents, strings.ToLower(value))
+		case "crawl-delay":
+			inRules = true
+			seconds, err := strconv.ParseFloat(value, 64)
+			if err != nil || seconds < 0 {
+				continue
+			}
+			delay := time.Duration(seconds * float64(time.Second))
+			for _, a := range groupAgents {
+				switch {
+				case a == "*":
+					wildcard = delay
+				case a != "" && strings.Contains(agent, a):
+					specific = delay
+				}
+			}
+		default:
+			inRules = true
+		}
+	}
+
+	switch {
+	case specific >= 0:
+		return specific
+	case wildcard >= 0:
+		return wildcard
+	default:
+		return 0
+	}
+}
+
+// sleepContext waits for d or until ctx ends.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
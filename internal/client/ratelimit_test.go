@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock drives a hostLimiter without sleeping; sleeps advance the clock.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	slept []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.slept = append(c.slept, d)
+	return nil
+}
+
+func newFakeLimiter(cfg HostLimitConfig, robot *http.Client) (*hostLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)}
+	l := newHostLimiter(cfg, robot)
+	l.now = clock.Now
+	l.sleep = clock.Sleep
+	return l, clock
+}
+
+func mustParse(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return u
+}
+
+func TestHostLimiter_SpacesRequestsPerHost(t *testing.T) {
+	l, clock := newFakeLimiter(HostLimitConfig{RequestsPerSecond: 2}, nil)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, l.wait(ctx, mustParse(t, "https://example.com/page")))
+	}
+	require.NoError(t, l.wait(ctx, mustParse(t, "https://other.example.org/")))
+
+	assert.Equal(t, []time.Duration{0, 500 * time.Millisecond, time.Second, 0}, clock.slept,
+		"Requests to one host should be 500ms apart; other hosts are not delayed")
+}
+
+func TestHostLimiter_HonoursCrawlDelay(t *testing.T) {
+	var robotsFetches atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsFetches.Add(1)
+			_, _ = w.Write([]byte("User-agent: *\nCrawl-delay: 3\n"))
+		}
+	}))
+	defer server.Close()
+
+	l, clock := newFakeLimiter(HostLimitConfig{RequestsPerSecond: 10, RespectCrawlDelay: true}, server.Client())
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		require.NoError(t, l.wait(ctx, mustParse(t, server.URL+"/page")))
+	}
+
+	assert.Equal(t, []time.Duration{0, 3 * time.Second}, clock.slept)
+	assert.Equal(t, int64(1), robotsFetches.Load(), "robots.txt should be read once per host")
+}
+
+func TestHostLimiter_CapsCrawlDelay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("User-agent: *\nCrawl-delay: 3600\n"))
+	}))
+	defer server.Close()
+
+	l, clock := newFakeLimiter(HostLimitConfig{RespectCrawlDelay: true, MaxCrawlDelay: 5 * time.Second}, server.Client())
+	for i := 0; i < 2; i++ {
+		require.NoError(t, l.wait(context.Background(), mustParse(t, server.URL)))
+	}
+
+	assert.Equal(t, []time.Duration{0, 5 * time.Second}, clock.slept)
+}
+
+func TestHostLimiter_Disabled(t *testing.T) {
+	assert.Nil(t, newHostLimiter(HostLimitConfig{}, nil))
+}
+
+func TestHostLimiter_ContextCancelled(t *testing.T) {
+	l := newHostLimiter(HostLimitConfig{RequestsPerSecond: 0.001}, nil)
+	u := mustParse(t, "https://example.com")
+	require.NoError(t, l.wait(context.Background(), u))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.wait(ctx, u), context.DeadlineExceeded)
+}
+
+func TestParseCrawlDelay(t *testing.T) {
+	tests := []struct {
+		name   string
+		robots string
+		want   time.Duration
+	}{
+		{"none", "User-agent: *\nDisallow: /private\n", 0},
+		{"wildcard", "User-agent: *\nCrawl-delay: 2\n", 2 * time.Second},
+		{"fractional", "User-agent: *\nCrawl-delay: 0.5\n", 500 * time.Millisecond},
+		{"specific wins", "User-agent: *\nCrawl-delay: 2\n\nUser-agent: WebpageAnalyzer\nCrawl-delay: 7\n", 7 * time.Second},
+		{"grouped agents", "User-agent: Googlebot\nUser-agent: webpageanalyzer\nCrawl-delay: 4\n", 4 * time.Second},
+		{"other agent only", "User-agent: Googlebot\nCrawl-delay: 9\n", 0},
+		{"new group after rules", "User-agent: WebpageAnalyzer\nDisallow: /x\nUser-agent: Googlebot\nCrawl-delay: 9\n", 0},
+		{"comments and junk", "# hello\nUser-agent: * # all\nCrawl-delay: abc\nCrawl-delay: 1 # slow\n", time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseCrawlDelay([]byte(tt.robots), userAgentToken))
+		})
+	}
+}
+
+func TestHTTPClient_HostLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.HostLimit.RequestsPerSecond = 20
+	c := NewHTTPClientWithConfig(cfg)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, status, err := c.FetchWebpage(context.Background(), server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, status)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, "Three requests at 20/s take at least 100ms")
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultMaxRedirects is the number of redirects followed when
+// RedirectPolicy.MaxHops is zero, the same as Go's default.
+const defaultMaxRedirects = 10
+
+// RedirectPolicy controls which redirects the client follows.
+type RedirectPolicy struct {
+	MaxHops         int  // Redirects followed per fetch; zero means 10, negative follows none.
+	ForbidDowngrade bool // Refuse redirects from https to http.
+}
+
+// maxHops returns the number of redirects the policy allows.
+func (p RedirectPolicy) maxHops() int {
+	switch {
+	case p.MaxHops < 0:
+		return 0
+	case p.MaxHops == 0:
+		return defaultMaxRedirects
+	default:
+		return p.MaxHops
+	}
+}
+
+// checkRedirect returns an http.Client CheckRedirect function enforcing p.
+// Only http and https URLs are followed, whatever the policy.
+func (p RedirectPolicy) checkRedirect() func(req *http.Request, via []*http.Request) error {
+	maxHops := p.maxHops()
+	return func(req *http.Request, via []*http.Request) error {
+		if err := checkScheme(req.URL); err != nil {
+			return err
+		}
+		chain := redirectURLs(req, via)
+		for _, prev := range via {
+			if prev.URL.String() == req.URL.String() {
+				return &FetchError{
+					Code:      CodeRedirectLoop,
+					Message:   fmt.Sprintf("Redirect loop: %s.", strings.Join(chain, " -> ")),
+					Redirects: chain,
+				}
+			}
+		}
+		if len(via) > maxHops {
+			return &FetchError{
+				Code:      CodeTooManyRedirects,
+				Message:   fmt.Sprintf("Redirect policy: stopped after %d redirects.", maxHops),
+				Redirects: chain,
+			}
+		}
+		if p.ForbidDowngrade && via[len(via)-1].URL.Scheme == "https" && req.URL.Scheme == "http" {
+			return &FetchError{
+				Code:    CodeRedirectDowngrade,
+				Message: fmt.Sprintf("Redirect policy: refused to follow a redirect from https to %s.", req.URL.Redacted()),
+			}
+		}
+		return nil
+	}
+}
+
+// redirectURLs returns the URLs of via followed by req's, redacting any
+// passwords.
+func redirectURLs(req *http.Request, via []*http.Request) []string {
+	chain := make([]string, 0, len(via)+1)
+	for _, prev := range via {
+		chain = append(chain, prev.URL.Redacted())
+	}
+	return append(chain, req.URL.Redacted())
+}
+
+// checkScheme rejects URLs that are neither http nor https. A URL without a
+// scheme is left for the transport to reject.
+func checkScheme(u *url.URL) error {
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https":
+		return nil
+	default:
+		return &FetchError{
+			Code:    CodeSchemeNotAllowed,
+			Message: fmt.Sprintf("Scheme not allowed: %s URLs cannot be fetched. Please use http:// or https://.", u.Scheme),
+		}
+	}
+}
+
+// isPolicyError reports whether err is a refusal by the redirect or scheme
+// policy, or of a body too large to read, which says nothing about the
+// health of the host.
+func isPolicyError(err error) bool {
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) {
+		return false
+	}
+	switch fetchErr.Code {
+	case CodeTooManyRedirects, CodeRedirectLoop, CodeRedirectDowngrade, CodeSchemeNotAllowed, CodeBodyTooLarge:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// redirectChain serves /hop/N, redirecting to /hop/N-1 until /hop/0 answers.
+func redirectChain(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(r.URL.Path[len("/hop/"):])
+		require.NoError(t, err)
+		if n == 0 {
+			_, _ = w.Write([]byte("<html><body>Arrived</body></html>"))
+			return
+		}
+		http.Redirect(w, r, "/hop/"+strconv.Itoa(n-1), http.StatusFound)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPClient_RedirectHopLimit(t *testing.T) {
+	server := redirectChain(t)
+
+	tests := []struct {
+		name    string
+		maxHops int
+		hops    int
+		allowed bool
+	}{
+		{"within the default", 0, 10, true},
+		{"over the default", 0, 11, false},
+		{"at the limit", 2, 2, true},
+		{"over the limit", 2, 3, false},
+		{"redirects disabled", -1, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Redirects = RedirectPolicy{MaxHops: tt.maxHops}
+			body, status, err := NewHTTPClientWithConfig(cfg).FetchWebpage(context.Background(), server.URL+"/hop/"+strconv.Itoa(tt.hops))
+			if tt.allowed {
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusOK, status)
+				assert.Contains(t, string(body), "Arrived")
+				return
+			}
+			var fetchErr *FetchError
+			require.ErrorAs(t, err, &fetchErr)
+			assert.Equal(t, CodeTooManyRedirects, fetchErr.Code)
+			assert.Len(t, fetchErr.Redirects, tt.hops+1, "The chain followed should be listed")
+			assert.Equal(t, http.StatusBadGateway, status)
+		})
+	}
+}
+
+func TestHTTPClient_RedirectLoop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/start":
+			http.Redirect(w, r, "/a", http.StatusMovedPermanently)
+		case "/a":
+			http.Redirect(w, r, "/b", http.StatusFound)
+		default:
+			http.Redirect(w, r, "/a", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	_, status, err := NewHTTPClient().FetchWebpage(context.Background(), server.URL+"/start")
+	var fetchErr *FetchError
+	require.ErrorAs(t, err, &fetchErr)
+	assert.Equal(t, CodeRedirectLoop, fetchErr.Code)
+	assert.Equal(t, http.StatusBadGateway, status)
+	assert.Equal(t, []string{server.URL + "/start", server.URL + "/a", server.URL + "/b", server.URL + "/a"}, fetchErr.Redirects)
+	assert.Contains(t, fetchErr.Message, server.URL+"/b -> "+server.URL+"/a")
+}
+
+func TestHTTPClient_RecordsFinalURL(t *testing.T) {
+	server := redirectChain(t)
+
+	var info ResponseInfo
+	_, _, err := NewHTTPClient().FetchWebpage(WithResponseInfo(context.Background(), &info), server.URL+"/hop/2")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/hop/0", info.URL)
+}
+
+func TestHTTPClient_ForbidDowngradeRedirect(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html><body>Plain</body></html>"))
+	}))
+	defer plain.Close()
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plain.URL, http.StatusMovedPermanently)
+	}))
+	defer secure.Close()
+
+	cfg := DefaultConfig()
+	cfg.Redirects.ForbidDowngrade = true
+	c := NewHTTPClientWithConfig(cfg).(*httpClient)
+	c.client.Transport = secure.Client().Transport
+
+	_, _, err := c.FetchWebpage(context.Background(), secure.URL)
+	var fetchErr *FetchError
+	require.ErrorAs(t, err, &fetchErr)
+	assert.Equal(t, CodeRedirectDowngrade, fetchErr.Code)
+
+	// Downgrades are followed unless forbidden.
+	c = NewHTTPClientWithConfig(DefaultConfig()).(*httpClient)
+	c.client.Transport = secure.Client().Transport
+	body, _, err := c.FetchWebpage(context.Background(), secure.URL)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "Plain")
+}
+
+func TestHTTPClient_RejectsOtherSchemes(t *testing.T) {
+	redirectToFile := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "file:///etc/passwd", http.StatusFound)
+	}))
+	defer redirectToFile.Close()
+
+	for _, pageURL := range []string{"ftp://example.com/index.html", "file:///etc/passwd", "data:text/html,<p>hi</p>", redirectToFile.URL} {
+		t.Run(pageURL, func(t *testing.T) {
+			_, _, err := NewHTTPClient().FetchWebpage(context.Background(), pageURL)
+			var fetchErr *FetchError
+			require.ErrorAs(t, err, &fetchErr)
+			assert.Equal(t, CodeSchemeNotAllowed, fetchErr.Code)
+		})
+	}
+}
+
+func TestHTTPClient_RefusedRedirectIsNotAHostFailure(t *testing.T) {
+	server := redirectChain(t)
+	cfg := DefaultConfig()
+	cfg.Redirects.MaxHops = -1
+	cfg.Breaker = BreakerConfig{Threshold: 1, Cooldown: time.Minute}
+	c := NewHTTPClientWithConfig(cfg)
+
+	for i := 0; i < 2; i++ {
+		_, _, err := c.FetchWebpage(context.Background(), server.URL+"/hop/1")
+		var fetchErr *FetchError
+		require.ErrorAs(t, err, &fetchErr, "The host should not be skipped after a refused redirect")
+		assert.Equal(t, CodeTooManyRedirects, fetchErr.Code)
+	}
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryAfterConfig controls waiting out a 429 or 503 response that carries a
+// Retry-After header.
+type RetryAfterConfig struct {
+	MaxRetries int           // Retries after such responses; zero never waits.
+	MaxWait    time.Duration // Longest advised wait that is waited out.
+}
+
+// retryAfter returns the wait advised by a 429 or 503 response.
+func retryAfter(statusCode int, header http.Header, now time.Time) (time.Duration, bool) {
+	if statusCode != http.StatusTooManyRequests && statusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	return parseRetryAfter(header.Get("Retry-After"), now)
+}
+
+// parseRetryAfter parses a Retry-After value, either seconds or an HTTP date.
+// Dates in the past advise no wait.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(at.Sub(now), 0), true
+}
+
+// canWait reports whether wait fits within the configured maximum and the
+// deadline of ctx.
+func (c RetryAfterConfig) canWait(ctx context.Context, wait time.Duration) bool {
+	if wait > c.MaxWait {
+		return false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+		return false
+	}
+	return true
+}
+
+// sleep waits for d or until ctx ends, and reports whether d passed.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{" 0 ", 0, true},
+		{"Mon, 15 Jan 2024 10:31:30 GMT", 90 * time.Second, true},
+		{"Mon, 15 Jan 2024 10:00:00 GMT", 0, true},
+		{"", 0, false},
+		{"-5", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			d, ok := parseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, d)
+		})
+	}
+}
+
+// busyServer answers 503 with the given Retry-After to the first requests,
+// then 200.
+func busyServer(t *testing.T, busyFor int32, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= busyFor {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestHTTPClient_WaitsOutRetryAfter(t *testing.T) {
+	server, calls := busyServer(t, 1, "1")
+
+	start := time.Now()
+	_, status, err := NewHTTPClient().FetchWebpage(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status, "The fetch should be retried after the advised wait")
+	assert.Equal(t, int32(2), calls.Load())
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestHTTPClient_ReportsRetryAfterBeyondBudget(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		timeout    time.Duration
+	}{
+		{"over the maximum wait", "120", 0},
+		{"past the deadline", "2", time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := busyServer(t, 1, tt.retryAfter)
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			var info ResponseInfo
+			_, status, err := NewHTTPClient().FetchWebpage(WithResponseInfo(ctx, &info), server.URL)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusServiceUnavailable, status)
+			assert.Equal(t, int32(1), calls.Load(), "A wait beyond the budget should not be waited out")
+			assert.NotZero(t, info.RetryAfter, "The advised wait should be reported")
+		})
+	}
+}
+
+func TestHTTPClient_RetryAfterRetriesAreBounded(t *testing.T) {
+	server, calls := busyServer(t, 10, "0")
+
+	var info ResponseInfo
+	_, status, err := NewHTTPClient().FetchWebpage(WithResponseInfo(context.Background(), &info), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, int32(2), calls.Load(), "Only MaxRetries retries should be made")
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportConfig tunes the connection pool and connection timeouts of
+// outbound requests. An interactive server fetching a page now and then
+// does well with the defaults; a batch or crawl against a few hosts wants
+// more idle connections per host, and against many hosts tighter timeouts.
+type TransportConfig struct {
+	MaxIdleConns        int           // Idle connections kept across all hosts; zero means no limit.
+	MaxIdleConnsPerHost int           // Idle connections kept per host; zero means Go's default of 2.
+	MaxConnsPerHost     int           // Connections per host, busy or idle; zero means no limit.
+	IdleConnTimeout     time.Duration // How long an unused connection is kept; zero keeps it until the host closes it.
+	TLSHandshakeTimeout time.Duration // Zero means no limit.
+	DialTimeout         time.Duration // Limit on opening a TCP connection; zero leaves it to the OS.
+	KeepAlive           time.Duration // Interval of TCP keep-alive probes; zero means Go's default, negative turns them off.
+}
+
+// DefaultTransportConfig returns the transport settings used by
+// DefaultConfig, those of Go's default transport.
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		DialTimeout:         30 * time.Second,
+		KeepAlive:           30 * time.Second,
+	}
+}
+
+// Validate checks that no limit or timeout is negative, except KeepAlive.
+func (c TransportConfig) Validate() error {
+	switch {
+	case c.MaxIdleConns < 0:
+		return fmt.Errorf("max idle connections must not be negative, got %d", c.MaxIdleConns)
+	case c.MaxIdleConnsPerHost < 0:
+		return fmt.Errorf("max idle connections per host must not be negative, got %d", c.MaxIdleConnsPerHost)
+	case c.MaxConnsPerHost < 0:
+		return fmt.Errorf("max connections per host must not be negative, got %d", c.MaxConnsPerHost)
+	case c.IdleConnTimeout < 0:
+		return fmt.Errorf("idle connection timeout must not be negative, got %s", c.IdleConnTimeout)
+	case c.TLSHandshakeTimeout < 0:
+		return fmt.Errorf("TLS handshake timeout must not be negative, got %s", c.TLSHandshakeTimeout)
+	case c.DialTimeout < 0:
+		return fmt.Errorf("dial timeout must not be negative, got %s", c.DialTimeout)
+	}
+	return nil
+}
+
+// transport returns an HTTP transport with these settings.
+func (c TransportConfig) transport() *http.Transport {
+	return &http.Transport{
+		MaxIdleConns:        c.MaxIdleConns,
+		MaxIdleConnsPerHost: c.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     c.MaxConnsPerHost,
+		IdleConnTimeout:     c.IdleConnTimeout,
+		TLSHandshakeTimeout: c.TLSHandshakeTimeout,
+		// A custom dialer turns off HTTP/2 unless it is asked for.
+		ForceAttemptHTTP2: true,
+	}
+}
+
+// dialer returns the base dialer for outbound connections.
+func (c TransportConfig) dialer() net.Dialer {
+	return net.Dialer{Timeout: c.DialTimeout, KeepAlive: c.KeepAlive}
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultTransportConfig().Validate())
+	assert.NoError(t, TransportConfig{KeepAlive: -1}.Validate(), "A negative keep-alive turns probes off")
+
+	for _, cfg := range []TransportConfig{
+		{MaxIdleConns: -1},
+		{MaxIdleConnsPerHost: -1},
+		{MaxConnsPerHost: -1},
+		{IdleConnTimeout: -time.Second},
+		{TLSHandshakeTimeout: -time.Second},
+		{DialTimeout: -time.Second},
+	} {
+		assert.Error(t, cfg.Validate(), "%+v", cfg)
+	}
+}
+
+func TestNewHTTPClientWithConfig_Transport(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Transport = TransportConfig{
+		MaxIdleConns:        500,
+		MaxIdleConnsPerHost: 50,
+		MaxConnsPerHost:     100,
+		IdleConnTimeout:     time.Minute,
+		TLSHandshakeTimeout: 5 * time.Second,
+		DialTimeout:         3 * time.Second,
+	}
+	c := NewHTTPClientWithConfig(cfg).(*httpClient)
+
+	transport, ok := c.client.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, 500, transport.MaxIdleConns)
+	assert.Equal(t, 50, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 100, transport.MaxConnsPerHost)
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+	assert.Equal(t, 5*time.Second, transport.TLSHandshakeTimeout)
+	assert.NotNil(t, transport.DialContext, "The dialer should apply the dial timeout")
+}
@@ -1,9 +1,98 @@
 package client
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"golang.org/x/net/html"
+)
 
 // HTTPClient defines the interface for HTTP operations.
 type HTTPClient interface {
 	FetchWebpage(ctx context.Context, url string) ([]byte, int, error)
-	ParseHTML(content []byte) (interface{}, error)
+	ParseHTML(content []byte) (*html.Node, error)
+}
+
+// DocumentFetcher is implemented by clients that can parse a page as they
+// read it, without holding the raw page in memory. Callers that need the raw
+// bytes use FetchWebpage instead.
+type DocumentFetcher interface {
+	// FetchDocument fetches and parses the page at url. Only a 200 OK page is
+	// parsed; for other statuses the document is nil.
+	FetchDocument(ctx context.Context, url string) (*html.Node, int, error)
+}
+
+// StatusChecker is implemented by clients that can check whether a URL
+// answers without downloading it.
+type StatusChecker interface {
+	// CheckStatus returns the status url answers with, after redirects.
+	CheckStatus(ctx context.Context, url string) (int, error)
+}
+
+// Config configures the HTTP client.
+type Config struct {
+	Timeout   time.Duration   // Limit on a whole request, including reading the body.
+	HostLimit HostLimitConfig // Per-host politeness; the zero value sends requests as fast as they come.
+	Breaker   BreakerConfig   // Skipping of hosts that keep failing.
+	DNSCache  DNSCacheConfig  // Caching of DNS answers across requests.
+	Redirects RedirectPolicy  // Which redirects are followed.
+	Dial      DialConfig      // IP family and local address of outbound connections.
+	Transport TransportConfig // Connection pool and connection timeouts.
+	HTTP3     bool            // Experimental: use HTTP/3 with hosts that advertise it.
+	// MaxBodySize caps a page body after decompression; zero or less reads any size.
+	MaxBodySize int64
+	// RetryAfter controls waiting out 429 and 503 responses with a Retry-After header.
+	RetryAfter RetryAfterConfig
 }
+
+// DefaultConfig returns the client configuration used by NewHTTPClient.
+func DefaultConfig() Config {
+	return Config{
+		Timeout:     30 * time.Second,
+		Breaker:     BreakerConfig{Threshold: 5, Cooldown: 30 * time.Second},
+		Redirects:   RedirectPolicy{MaxHops: defaultMaxRedirects},
+		RetryAfter:  RetryAfterConfig{MaxRetries: 1, MaxWait: 10 * time.Second},
+		MaxBodySize: defaultMaxBodySize,
+		Transport:   DefaultTransportConfig(),
+		DNSCache: DNSCacheConfig{
+			MaxEntries: 1000,
+			MinTTL:     5 * time.Second,
+			MaxTTL:     5 * time.Minute,
+		},
+	}
+}
+
+// Machine-readable reasons a fetch failed, reported by FetchError and
+// HostSkippedError through their ErrorCode methods.
+const (
+	CodeInvalidURL          = "invalid_url"
+	CodeDNSFailure          = "dns_failure"
+	CodeConnectionRefused   = "connection_refused"
+	CodeTimeout             = "timeout"
+	CodeTLSFailure          = "tls_failure"
+	CodeUnsupportedProtocol = "unsupported_protocol"
+	CodeNetworkUnreachable  = "network_unreachable"
+	CodeNetworkError        = "network_error"
+	CodeBodyReadFailure     = "body_read_failure"
+	CodeHostSkipped         = "host_skipped"
+	CodeSchemeNotAllowed    = "scheme_not_allowed"
+	CodeTooManyRedirects    = "too_many_redirects"
+	CodeRedirectLoop        = "redirect_loop"
+	CodeRedirectDowngrade   = "redirect_downgrade"
+	CodeBodyTooLarge        = "body_too_large"
+	CodeUnsupportedEncoding = "unsupported_encoding"
+)
+
+// FetchError is returned by FetchWebpage when no response could be read.
+type FetchError struct {
+	Code    string // One of the Code constants.
+	Message string
+	// Redirects is the chain of URLs followed, starting with the one
+	// requested, when a redirect loop or too long a chain stopped the fetch.
+	Redirects []string
+}
+
+func (e *FetchError) Error() string { return e.Message }
+
+// ErrorCode returns the machine-readable reason for the failure.
+func (e *FetchError) ErrorCode() string { return e.Code }
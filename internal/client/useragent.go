@@ -0,0 +1,34 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// userAgentKey is the context key for the User-Agent header value.
+type userAgentKey struct{}
+
+// WithUserAgent returns a context whose page requests send value as their
+// User-Agent header, to fetch the page as a particular browser would. An
+// empty value keeps the default. robots.txt is still requested, and matched,
+// as WebpageAnalyzer.
+func WithUserAgent(ctx context.Context, value string) context.Context {
+	if value == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, userAgentKey{}, value)
+}
+
+// UserAgent returns the User-Agent header value page requests made with ctx
+// send.
+func UserAgent(ctx context.Context) string {
+	if value, ok := ctx.Value(userAgentKey{}).(string); ok {
+		return value
+	}
+	return userAgent
+}
+
+// setUserAgent sets the User-Agent header of req from its context.
+func setUserAgent(req *http.Request) {
+	req.Header.Set("User-Agent", UserAgent(req.Context()))
+}
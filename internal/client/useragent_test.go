@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClient_SendsUserAgent(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("User-Agent"))
+	}))
+	defer server.Close()
+
+	c := NewHTTPClient()
+	_, _, err := c.FetchWebpage(context.Background(), server.URL)
+	require.NoError(t, err)
+	_, _, err = c.FetchWebpage(WithUserAgent(context.Background(), "Mozilla/5.0 (Mobile)"), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, []string{userAgent, "Mozilla/5.0 (Mobile)"}, got)
+}
@@ -0,0 +1,117 @@
+// Package geoip looks up the network owner and country of IP addresses in a
+// local CSV database.
+package geoip
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+// Database is an in-memory table of networks, loaded from CSV rows of
+//
+//	network,asn,as_org,country
+//
+// such as "93.184.216.0/24,15133,EDGECAST,US". This matches the GeoLite2 ASN
+// CSV columns with a country code appended. An optional header row starting
+// with "network" is skipped, and asn, as_org and country may be empty.
+// Networks must not overlap.
+type Database struct {
+	networks []network // Sorted by first address.
+}
+
+// network is one row of the database.
+type network struct {
+	prefix   netip.Prefix
+	location analyzer.IPLocation
+}
+
+// Open loads a database from a CSV file.
+func Open(path string) (*Database, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %v", err)
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Load reads a database in the CSV format described on Database.
+func Load(r io.Reader) (*Database, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+
+	db := &Database{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GeoIP database: %v", err)
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "network") {
+			continue
+		}
+		n, err := parseNetwork(record)
+		if err != nil {
+			return nil, fmt.Errorf("GeoIP database line %d: %v", line, err)
+		}
+		db.networks = append(db.networks, n)
+	}
+
+	sort.Slice(db.networks, func(i, j int) bool {
+		return db.networks[i].prefix.Addr().Less(db.networks[j].prefix.Addr())
+	})
+	return db, nil
+}
+
+// parseNetwork parses one CSV row.
+func parseNetwork(record []string) (network, error) {
+	for len(record) < 4 {
+		record = append(record, "")
+	}
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(record[0]))
+	if err != nil {
+		return network{}, fmt.Errorf("invalid network %q", record[0])
+	}
+
+	n := network{prefix: prefix.Masked()}
+	if asn := strings.TrimSpace(record[1]); asn != "" {
+		if n.location.ASN, err = strconv.Atoi(strings.TrimPrefix(strings.ToUpper(asn), "AS")); err != nil {
+			return network{}, fmt.Errorf("invalid ASN %q", record[1])
+		}
+	}
+	n.location.ASOrg = strings.TrimSpace(record[2])
+	n.location.Country = strings.ToUpper(strings.TrimSpace(record[3]))
+	return n, nil
+}
+
+// Len returns the number of networks in the database.
+func (db *Database) Len() int {
+	return len(db.networks)
+}
+
+// Locate implements analyzer.GeoLocator. It never fails.
+func (db *Database) Locate(ctx context.Context, ip netip.Addr) (*analyzer.IPLocation, error) {
+	ip = ip.Unmap()
+	// The only candidate is the last network starting at or before ip.
+	i := sort.Search(len(db.networks), func(i int) bool {
+		return ip.Less(db.networks[i].prefix.Addr())
+	})
+	if i == 0 || !db.networks[i-1].prefix.Contains(ip) {
+		return nil, nil
+	}
+	location := db.networks[i-1].location
+	return &location, nil
+}
@@ -0,0 +1,70 @@
+package geoip
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+const testDatabase = `network,asn,as_org,country
+# Documentation ranges.
+203.0.113.0/24,64500,Example Hosting,NL
+192.0.2.0/24,AS64501,"Example Transit, Inc.",us
+2001:db8::/32,64502,Example IPv6,DE
+198.51.100.0/25,,,FR
+`
+
+func TestDatabase_Locate(t *testing.T) {
+	db, err := Load(strings.NewReader(testDatabase))
+	require.NoError(t, err)
+	assert.Equal(t, 4, db.Len())
+
+	tests := []struct {
+		ip   string
+		want *analyzer.IPLocation
+	}{
+		{"203.0.113.7", &analyzer.IPLocation{ASN: 64500, ASOrg: "Example Hosting", Country: "NL"}},
+		{"192.0.2.255", &analyzer.IPLocation{ASN: 64501, ASOrg: "Example Transit, Inc.", Country: "US"}},
+		{"::ffff:192.0.2.1", &analyzer.IPLocation{ASN: 64501, ASOrg: "Example Transit, Inc.", Country: "US"}},
+		{"2001:db8:1::1", &analyzer.IPLocation{ASN: 64502, ASOrg: "Example IPv6", Country: "DE"}},
+		{"198.51.100.1", &analyzer.IPLocation{Country: "FR"}},
+		{"198.51.100.200", nil},
+		{"10.0.0.1", nil},
+		{"2001:db9::1", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			got, err := db.Locate(context.Background(), netip.MustParseAddr(tt.ip))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geoip.csv")
+	require.NoError(t, os.WriteFile(path, []byte(testDatabase), 0o600))
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, 4, db.Len())
+
+	_, err = Open(filepath.Join(t.TempDir(), "missing.csv"))
+	assert.Error(t, err)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	_, err := Load(strings.NewReader("not-a-network,1,x,US\n"))
+	assert.Error(t, err)
+
+	_, err = Load(strings.NewReader("192.0.2.0/24,ASX,x,US\n"))
+	assert.Error(t, err)
+}
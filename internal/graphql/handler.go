@@ -0,0 +1,84 @@
+// Package graphql exposes the analyzer over GraphQL so clients can select only the fields they need.
+package graphql
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	gographql "github.com/graph-gophers/graphql-go"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/apikeys"
+	"webpage-analyzer/internal/logging"
+	"webpage-analyzer/internal/openapi"
+	"webpage-analyzer/internal/problem"
+	"webpage-analyzer/internal/store"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// request is a GraphQL-over-HTTP request body.
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Doc documents the GraphQL endpoint for the OpenAPI spec.
+var Doc = openapi.Operation{
+	Method:      http.MethodPost,
+	Summary:     "Run a GraphQL query",
+	Description: "Run a query against the GraphQL schema, selecting only the fields needed. Errors of the query are reported in the errors field of a 200 response.",
+	Tags:        []string{"Analysis"},
+	Request:     openapi.JSONRequest(request{}),
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Body: &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{
+			"data":   {Type: "object"},
+			"errors": {Type: "array", Items: &openapi.Schema{Type: "object"}},
+		}}},
+		{Status: http.StatusBadRequest, Body: problem.Problem{}, ContentType: problem.ContentType},
+	},
+}
+
+// Handler serves GraphQL queries.
+type Handler struct {
+	schema *gographql.Schema
+}
+
+// NewHandler creates a GraphQL handler. historyStore may be nil, in which case
+// history queries return an error. meter may be nil, in which case analyses
+// are not counted against API keys.
+func NewHandler(analyzerService analyzer.Service, historyStore store.Store, meter *apikeys.Meter) *Handler {
+	schema := gographql.MustParseSchema(schemaSDL, &resolver{
+		analyzerService: analyzerService,
+		historyStore:    historyStore,
+		meter:           meter,
+	}, gographql.UseFieldResolvers())
+
+	return &Handler{schema: schema}
+}
+
+// ServeHTTP executes a GraphQL query sent as a JSON POST body.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		problem.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("Failed to decode GraphQL request body", "error", err)
+		problem.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response := h.schema.Exec(r.Context(), req.Query, req.OperationName, req.Variables)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to encode GraphQL response", "error", err)
+	}
+}
@@ -0,0 +1,301 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/apikeys"
+	"webpage-analyzer/internal/store"
+)
+
+// mockAnalyzerService is a mock implementation of analyzer.Service for testing.
+type mockAnalyzerService struct {
+	analysisResult *analyzer.WebpageAnalysis
+	analysisError  error
+	lastRequest    analyzer.AnalysisRequest
+}
+
+func (m *mockAnalyzerService) AnalyzeWebpage(ctx context.Context, req analyzer.AnalysisRequest) (*analyzer.WebpageAnalysis, error) {
+	m.lastRequest = req
+	return m.analysisResult, m.analysisError
+}
+
+func (m *mockAnalyzerService) CompareWebpages(ctx context.Context, req analyzer.CompareRequest) (*analyzer.WebpageComparison, error) {
+	if m.analysisError != nil {
+		return nil, m.analysisError
+	}
+	return &analyzer.WebpageComparison{
+		A:              m.analysisResult,
+		B:              m.analysisResult,
+		Differences:    analyzer.DiffAnalyses(m.analysisResult, m.analysisResult),
+		ProcessingTime: "2ms",
+	}, nil
+}
+
+func (m *mockAnalyzerService) CompareLanguages(ctx context.Context, req analyzer.LanguageComparisonRequest) (*analyzer.LanguageComparison, error) {
+	return nil, m.analysisError
+}
+
+func (m *mockAnalyzerService) CompareDevices(ctx context.Context, req analyzer.DeviceComparisonRequest) (*analyzer.DeviceComparison, error) {
+	return nil, m.analysisError
+}
+
+func (m *mockAnalyzerService) CrawlSite(ctx context.Context, req analyzer.CrawlRequest) (*analyzer.CrawlResult, error) {
+	return nil, m.analysisError
+}
+
+func (m *mockAnalyzerService) AnalyzeSitemap(ctx context.Context, req analyzer.SitemapRequest) (*analyzer.SitemapResult, error) {
+	return nil, m.analysisError
+}
+
+func (m *mockAnalyzerService) ExtractText(ctx context.Context, req analyzer.TextRequest) (*analyzer.TextExtraction, error) {
+	return nil, m.analysisError
+}
+
+func (m *mockAnalyzerService) AnalyzeHTML(ctx context.Context, req analyzer.HTMLRequest) (*analyzer.WebpageAnalysis, error) {
+	return m.analysisResult, m.analysisError
+}
+
+func (m *mockAnalyzerService) GetAnalysisStatus(ctx context.Context) (string, error) {
+	return "Analysis service is running", nil
+}
+
+func sampleAnalysis() *analyzer.WebpageAnalysis {
+	return &analyzer.WebpageAnalysis{
+		URL:            "https://example.com",
+		HTMLVersion:    "HTML5",
+		PageTitle:      "Example",
+		Headings:       map[string]int{"h2": 3, "h1": 1},
+		InternalLinks:  4,
+		ExternalLinks:  2,
+		AnalyzedAt:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		ProcessingTime: "1ms",
+	}
+}
+
+// graphqlResponse mirrors the GraphQL response envelope.
+type graphqlResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func execute(t *testing.T, h *Handler, query string, variables map[string]interface{}) graphqlResponse {
+	t.Helper()
+	return executeIn(t, context.Background(), h, query, variables)
+}
+
+// executeIn runs a query as a request with the given context, such as one
+// carrying an API key.
+func executeIn(t *testing.T, ctx context.Context, h *Handler, query string, variables map[string]interface{}) graphqlResponse {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/api/graphql", bytes.NewReader(body)).WithContext(ctx)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp graphqlResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestAnalyze_ReturnsOnlySelectedFields(t *testing.T) {
+	h := NewHandler(&mockAnalyzerService{analysisResult: sampleAnalysis()}, nil, nil)
+
+	resp := execute(t, h, `query($url: String!) { analyze(url: $url) { pageTitle headings { level count } } }`,
+		map[string]interface{}{"url": "https://example.com"})
+
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"pageTitle":"Example","headings":[{"level":"h1","count":1},{"level":"h2","count":3}]}`,
+		string(resp.Data["analyze"]), "analyze should return only the selected fields, headings sorted by level")
+}
+
+func TestAnalyze_Warnings(t *testing.T) {
+	analysis := sampleAnalysis()
+	analysis.Warnings = []analyzer.Warning{{Module: "wayback", Code: analyzer.WarningModuleTimeout, Message: "Module did not finish within total_timeout"}}
+	h := NewHandler(&mockAnalyzerService{analysisResult: analysis}, nil, nil)
+
+	resp := execute(t, h, `{ analyze(url: "https://example.com") { warnings { module code message } } }`, nil)
+
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"warnings":[{"module":"wayback","code":"module_timeout","message":"Module did not finish within total_timeout"}]}`,
+		string(resp.Data["analyze"]))
+}
+
+func TestAnalyze_PassesSettings(t *testing.T) {
+	service := &mockAnalyzerService{analysisResult: sampleAnalysis()}
+	h := NewHandler(service, nil, nil)
+
+	resp := execute(t, h, `query($options: ModuleOptions) {
+		analyze(url: "https://example.com", modules: ["links"], options: $options, forceRefresh: true, maxAge: "10m",
+			fetchTimeout: "5s", totalTimeout: "10s", acceptLanguage: "de-DE") { pageTitle }
+	}`, map[string]interface{}{"options": map[string]interface{}{"links": map[string]interface{}{"probe": true}}})
+
+	require.Empty(t, resp.Errors)
+	assert.Equal(t, analyzer.AnalysisRequest{
+		URL:            "https://example.com",
+		Modules:        []string{"links"},
+		Options:        map[string]analyzer.ModuleOptions{"links": {"probe": true}},
+		ForceRefresh:   true,
+		MaxAge:         "10m",
+		FetchTimeout:   "5s",
+		TotalTimeout:   "10s",
+		AcceptLanguage: "de-DE",
+	}, service.lastRequest)
+}
+
+func TestAnalyze_InvalidOptions(t *testing.T) {
+	h := NewHandler(&mockAnalyzerService{analysisResult: sampleAnalysis()}, nil, nil)
+
+	resp := execute(t, h, `query($options: ModuleOptions) { analyze(url: "https://example.com", options: $options) { pageTitle } }`,
+		map[string]interface{}{"options": map[string]interface{}{"links": true}})
+
+	require.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, `the options of module "links" must be an object`)
+}
+
+func TestAnalyze_ModuleSections(t *testing.T) {
+	firstSeen := time.Date(2010, 5, 6, 7, 8, 9, 0, time.UTC)
+	analysis := sampleAnalysis()
+	analysis.Description = "An example"
+	analysis.Technologies = []analyzer.Technology{{Name: "WordPress", Category: "cms", Version: "6.4", Confidence: 100, Evidence: []string{"meta generator"}}}
+	analysis.Structure = &analyzer.ContentStructure{Tables: analyzer.TableSummary{Total: 2, WithHeaders: 1}, OrderedLists: 3}
+	analysis.Trackers = &analyzer.Trackers{Categories: map[string]int{"analytics": 2, "ads": 1}, PrivacyScore: 70}
+	analysis.Interstitials = &analyzer.Interstitials{Popups: []analyzer.Popup{{Kind: "newsletter", Element: "div#signup"}}}
+	analysis.Wayback = &analyzer.WaybackHistory{Archived: true, FirstSeen: &firstSeen}
+	analysis.HTTPS = &analyzer.HTTPSPosture{Grade: "A", HSTS: &analyzer.HSTSPolicy{MaxAge: 1 << 40}}
+	h := NewHandler(&mockAnalyzerService{analysisResult: analysis}, nil, nil)
+
+	resp := execute(t, h, `{ analyze(url: "https://example.com") {
+		description
+		technologies { name version confidence evidence }
+		structure { tables { total withHeaders } orderedLists }
+		trackers { categories { name count } privacyScore thirdParties { domain } }
+		interstitials { popups { kind element } paywall { provider } }
+		wayback { archived firstSeen lastSeen }
+		https { grade hsts { maxAge } }
+		tls { grade }
+		clientRedirects { target }
+	} }`, nil)
+
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{
+		"description": "An example",
+		"technologies": [{"name": "WordPress", "version": "6.4", "confidence": 100, "evidence": ["meta generator"]}],
+		"structure": {"tables": {"total": 2, "withHeaders": 1}, "orderedLists": 3},
+		"trackers": {"categories": [{"name": "ads", "count": 1}, {"name": "analytics", "count": 2}], "privacyScore": 70, "thirdParties": []},
+		"interstitials": {"popups": [{"kind": "newsletter", "element": "div#signup"}], "paywall": null},
+		"wayback": {"archived": true, "firstSeen": "2010-05-06T07:08:09Z", "lastSeen": null},
+		"https": {"grade": "A", "hsts": {"maxAge": 2147483647}},
+		"tls": null,
+		"clientRedirects": []
+	}`, string(resp.Data["analyze"]))
+}
+
+func TestAnalyze_AnalysisErrorExtensions(t *testing.T) {
+	h := NewHandler(&mockAnalyzerService{analysisError: &analyzer.AnalysisError{
+		StatusCode:   404,
+		ErrorMessage: "Not Found",
+		URL:          "https://example.com/missing",
+	}}, nil, nil)
+
+	resp := execute(t, h, `{ analyze(url: "https://example.com/missing") { pageTitle } }`, nil)
+
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "Not Found", resp.Errors[0].Message)
+	assert.Equal(t, float64(404), resp.Errors[0].Extensions["status_code"], "Errors should carry the upstream status code")
+	assert.Equal(t, "https://example.com/missing", resp.Errors[0].Extensions["url"])
+}
+
+func TestAnalyze_MeteredPerField(t *testing.T) {
+	keys, err := apikeys.Open(context.Background(), apikeys.Config{Driver: apikeys.DriverMemory})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = keys.Close() })
+	key, _, err := keys.Create(context.Background(), apikeys.Spec{Tenant: "acme", DailyQuota: 2})
+	require.NoError(t, err)
+
+	h := NewHandler(&mockAnalyzerService{analysisResult: sampleAnalysis()}, nil, apikeys.NewMeter(keys, nil))
+	resp := executeIn(t, apikeys.WithKey(context.Background(), key), h,
+		`{ a: analyze(url: "https://a.com") { pageTitle } b: analyze(url: "https://b.com") { pageTitle } c: analyze(url: "https://c.com") { pageTitle } }`, nil)
+
+	require.Len(t, resp.Errors, 1, "Each aliased analysis should count against the quota")
+	assert.Equal(t, "quota_exceeded", resp.Errors[0].Extensions["code"])
+	assert.Equal(t, float64(http.StatusTooManyRequests), resp.Errors[0].Extensions["status_code"])
+	assert.NotZero(t, resp.Errors[0].Extensions["retry_after"])
+
+	usage, err := keys.Usage(context.Background(), apikeys.UsageQuery{Tenant: "acme"})
+	require.NoError(t, err)
+	require.Len(t, usage, 1)
+	assert.Equal(t, int64(2), usage[0].Analyses)
+}
+
+func TestCompare(t *testing.T) {
+	h := NewHandler(&mockAnalyzerService{analysisResult: sampleAnalysis()}, nil, nil)
+
+	resp := execute(t, h, `{ compare(urlA: "https://a.com", urlB: "https://b.com") { differences { identical internalLinksDelta } } }`, nil)
+
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"differences":{"identical":true,"internalLinksDelta":0}}`, string(resp.Data["compare"]))
+}
+
+func TestAnalysis_FromHistory(t *testing.T) {
+	st, err := store.Open(context.Background(), store.Config{Driver: store.DriverSQLite, DSN: ":memory:"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = st.Close() })
+
+	rec, err := st.Save(context.Background(), sampleAnalysis())
+	require.NoError(t, err)
+
+	h := NewHandler(&mockAnalyzerService{}, st, nil)
+
+	resp := execute(t, h, `query($id: ID!) { analysis(id: $id) { id url } }`, map[string]interface{}{"id": rec.ID})
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"id":"`+rec.ID+`","url":"https://example.com"}`, string(resp.Data["analysis"]))
+
+	resp = execute(t, h, `{ analysis(id: "missing") { id } }`, nil)
+	require.Empty(t, resp.Errors)
+	assert.Equal(t, "null", string(resp.Data["analysis"]), "Unknown IDs should resolve to null")
+}
+
+func TestAnalysis_HistoryDisabled(t *testing.T) {
+	h := NewHandler(&mockAnalyzerService{}, nil, nil)
+
+	resp := execute(t, h, `{ analysis(id: "1") { id } }`, nil)
+
+	require.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, "not enabled")
+}
+
+func TestServeHTTP_MethodNotAllowed(t *testing.T) {
+	h := NewHandler(&mockAnalyzerService{}, nil, nil)
+
+	req := httptest.NewRequest("GET", "/api/graphql", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestServeHTTP_InvalidBody(t *testing.T) {
+	h := NewHandler(&mockAnalyzerService{}, nil, nil)
+
+	req := httptest.NewRequest("POST", "/api/graphql", bytes.NewBufferString("{"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,500 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	gographql "github.com/graph-gophers/graphql-go"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/apikeys"
+	"webpage-analyzer/internal/store"
+)
+
+// errHistoryDisabled is returned by history queries when no store is configured.
+var errHistoryDisabled = errors.New("analysis history is not enabled")
+
+// resolver is the root GraphQL resolver.
+type resolver struct {
+	analyzerService analyzer.Service
+	historyStore    store.Store    // Optional.
+	meter           *apikeys.Meter // Optional; counts the analyses of API keys.
+}
+
+// begin counts one analysis by the API key of ctx, if any, so that a query
+// asking for several analyses, under aliases, is charged for each. end must be
+// called once the analysis is over.
+func (r *resolver) begin(ctx context.Context) (context.Context, func(), error) {
+	key := apikeys.KeyFromContext(ctx)
+	if r.meter == nil || key == nil {
+		return ctx, func() {}, nil
+	}
+	ctx, end, err := r.meter.Begin(ctx, key)
+	var limitErr *apikeys.LimitError
+	if errors.As(err, &limitErr) {
+		return nil, nil, analysisError{&analyzer.AnalysisError{
+			StatusCode:   http.StatusTooManyRequests,
+			Code:         limitErr.Code,
+			ErrorMessage: limitErr.Message,
+			RetryAfter:   limitErr.RetryAfterSeconds(),
+		}}
+	}
+	return ctx, end, err
+}
+
+// Analyze resolves Query.analyze.
+func (r *resolver) Analyze(ctx context.Context, args struct {
+	URL            string
+	Modules        *[]string
+	Options        *moduleOptions
+	ForceRefresh   *bool
+	MaxAge         *string
+	FetchTimeout   *string
+	TotalTimeout   *string
+	AcceptLanguage *string
+}) (*analysisResolver, error) {
+	req := analyzer.AnalysisRequest{URL: args.URL}
+	if args.Modules != nil {
+		req.Modules = *args.Modules
+	}
+	if args.Options != nil {
+		req.Options = *args.Options
+	}
+	if args.ForceRefresh != nil {
+		req.ForceRefresh = *args.ForceRefresh
+	}
+	if args.MaxAge != nil {
+		req.MaxAge = *args.MaxAge
+	}
+	if args.FetchTimeout != nil {
+		req.FetchTimeout = *args.FetchTimeout
+	}
+	if args.TotalTimeout != nil {
+		req.TotalTimeout = *args.TotalTimeout
+	}
+	if args.AcceptLanguage != nil {
+		req.AcceptLanguage = *args.AcceptLanguage
+	}
+
+	ctx, end, err := r.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+
+	analysis, err := r.analyzerService.AnalyzeWebpage(ctx, req)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return &analysisResolver{a: analysis}, nil
+}
+
+// Compare resolves Query.compare.
+func (r *resolver) Compare(ctx context.Context, args struct{ URLA, URLB string }) (*comparisonResolver, error) {
+	ctx, end, err := r.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+
+	comparison, err := r.analyzerService.CompareWebpages(ctx, analyzer.CompareRequest{URLA: args.URLA, URLB: args.URLB})
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return &comparisonResolver{c: comparison}, nil
+}
+
+// Analysis resolves Query.analysis.
+func (r *resolver) Analysis(ctx context.Context, args struct{ ID gographql.ID }) (*analysisResolver, error) {
+	if r.historyStore == nil {
+		return nil, errHistoryDisabled
+	}
+	rec, err := r.historyStore.Get(ctx, string(args.ID))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &analysisResolver{a: rec.Analysis}, nil
+}
+
+// Status resolves Query.status.
+func (r *resolver) Status(ctx context.Context) (string, error) {
+	return r.analyzerService.GetAnalysisStatus(ctx)
+}
+
+// analysisResolver resolves the Analysis type.
+type analysisResolver struct {
+	a *analyzer.WebpageAnalysis
+}
+
+func (r *analysisResolver) ID() *gographql.ID {
+	if r.a.ID == "" {
+		return nil
+	}
+	id := gographql.ID(r.a.ID)
+	return &id
+}
+
+func (r *analysisResolver) URL() string                  { return r.a.URL }
+func (r *analysisResolver) FinalURL() *string            { return optionalString(r.a.FinalURL) }
+func (r *analysisResolver) Description() *string         { return optionalString(r.a.Description) }
+func (r *analysisResolver) HTMLVersion() string          { return r.a.HTMLVersion }
+func (r *analysisResolver) PageTitle() string            { return r.a.PageTitle }
+func (r *analysisResolver) Headings() []*headingResolver { return headingCounts(r.a.Headings) }
+func (r *analysisResolver) InternalLinks() int32         { return int32(r.a.InternalLinks) }
+func (r *analysisResolver) ExternalLinks() int32         { return int32(r.a.ExternalLinks) }
+func (r *analysisResolver) InaccessibleLinks() int32     { return int32(r.a.InaccessibleLinks) }
+func (r *analysisResolver) HasLoginForm() bool           { return r.a.HasLoginForm }
+func (r *analysisResolver) AnalyzedAt() string           { return r.a.AnalyzedAt.Format(time.RFC3339) }
+func (r *analysisResolver) ProcessingTime() string       { return r.a.ProcessingTime }
+
+func (r *analysisResolver) ContentHash() *string { return optionalString(r.a.ContentHash) }
+func (r *analysisResolver) SimHash() *string     { return optionalString(r.a.SimHash) }
+
+func (r *analysisResolver) Modules() []string {
+	if r.a.Modules == nil {
+		return []string{}
+	}
+	return r.a.Modules
+}
+
+func (r *analysisResolver) Changes() *changesResolver {
+	if r.a.Changes == nil {
+		return nil
+	}
+	return &changesResolver{c: r.a.Changes}
+}
+
+func (r *analysisResolver) Cache() *cacheResolver {
+	if r.a.Cache == nil {
+		return nil
+	}
+	return &cacheResolver{c: r.a.Cache}
+}
+
+func (r *analysisResolver) LinkProbe() *linkProbeResolver {
+	if r.a.LinkProbe == nil {
+		return nil
+	}
+	return &linkProbeResolver{*r.a.LinkProbe}
+}
+
+func (r *analysisResolver) Protocol() *analyzer.ProtocolInfo { return r.a.Protocol }
+func (r *analysisResolver) PasswordFields() []*analyzer.PasswordFieldAudit {
+	return pointers(r.a.PasswordFields)
+}
+func (r *analysisResolver) Captchas() []*analyzer.Captcha       { return pointers(r.a.Captchas) }
+func (r *analysisResolver) Contacts() *analyzer.Contacts        { return r.a.Contacts }
+func (r *analysisResolver) Payment() *analyzer.PaymentDetection { return r.a.Payment }
+func (r *analysisResolver) SocialLogins() []string              { return r.a.SocialLogins }
+func (r *analysisResolver) SocialProfiles() []*analyzer.SocialProfile {
+	return pointers(r.a.SocialProfiles)
+}
+
+func (r *analysisResolver) Technologies() []*technologyResolver {
+	return wrap(r.a.Technologies, func(t analyzer.Technology) *technologyResolver {
+		return &technologyResolver{t}
+	})
+}
+
+func (r *analysisResolver) DOM() *domResolver {
+	if r.a.DOM == nil {
+		return nil
+	}
+	return &domResolver{*r.a.DOM}
+}
+
+func (r *analysisResolver) ClientRedirects() []*clientRedirectResolver {
+	return wrap(r.a.ClientRedirects, func(c analyzer.ClientRedirect) *clientRedirectResolver {
+		return &clientRedirectResolver{c}
+	})
+}
+
+func (r *analysisResolver) Robots() *analyzer.RobotsDirectives { return r.a.Robots }
+
+func (r *analysisResolver) Pagination() *paginationResolver {
+	if r.a.Pagination == nil {
+		return nil
+	}
+	return &paginationResolver{*r.a.Pagination}
+}
+
+func (r *analysisResolver) StructuredData() *structuredDataResolver {
+	if r.a.StructuredData == nil {
+		return nil
+	}
+	return &structuredDataResolver{*r.a.StructuredData}
+}
+
+func (r *analysisResolver) Product() *productResolver {
+	if r.a.Product == nil {
+		return nil
+	}
+	return &productResolver{*r.a.Product}
+}
+
+func (r *analysisResolver) Structure() *structureResolver {
+	if r.a.Structure == nil {
+		return nil
+	}
+	return &structureResolver{*r.a.Structure}
+}
+
+func (r *analysisResolver) Images() *imagesResolver {
+	if r.a.Images == nil {
+		return nil
+	}
+	return &imagesResolver{*r.a.Images}
+}
+
+func (r *analysisResolver) ResourceHints() []*analyzer.ResourceHint {
+	return pointers(r.a.ResourceHints)
+}
+func (r *analysisResolver) Consent() *analyzer.Consent { return r.a.Consent }
+
+func (r *analysisResolver) Trackers() *trackersResolver {
+	if r.a.Trackers == nil {
+		return nil
+	}
+	return &trackersResolver{*r.a.Trackers}
+}
+
+func (r *analysisResolver) Interstitials() *analyzer.Interstitials { return r.a.Interstitials }
+func (r *analysisResolver) SuspiciousLinks() []*analyzer.SuspiciousLink {
+	return pointers(r.a.SuspiciousLinks)
+}
+
+func (r *analysisResolver) TrackingParams() *trackingParamsResolver {
+	if r.a.TrackingParams == nil {
+		return nil
+	}
+	return &trackingParamsResolver{*r.a.TrackingParams}
+}
+
+func (r *analysisResolver) Canonical() *canonicalResolver {
+	if r.a.Canonical == nil {
+		return nil
+	}
+	return &canonicalResolver{*r.a.Canonical}
+}
+
+func (r *analysisResolver) Language() *analyzer.PageLanguage { return r.a.Language }
+
+func (r *analysisResolver) Article() *articleResolver {
+	if r.a.Article == nil {
+		return nil
+	}
+	return &articleResolver{*r.a.Article}
+}
+
+func (r *analysisResolver) Wayback() *waybackResolver {
+	if r.a.Wayback == nil {
+		return nil
+	}
+	return &waybackResolver{*r.a.Wayback}
+}
+
+func (r *analysisResolver) Reputation() *reputationResolver {
+	if r.a.Reputation == nil {
+		return nil
+	}
+	return &reputationResolver{*r.a.Reputation}
+}
+
+func (r *analysisResolver) Domain() *domainResolver {
+	if r.a.Domain == nil {
+		return nil
+	}
+	return &domainResolver{*r.a.Domain}
+}
+
+func (r *analysisResolver) DNS() *analyzer.DNSPosture { return r.a.DNS }
+
+func (r *analysisResolver) Infrastructure() *infrastructureResolver {
+	if r.a.Infrastructure == nil {
+		return nil
+	}
+	return &infrastructureResolver{*r.a.Infrastructure}
+}
+
+func (r *analysisResolver) HTTPS() *httpsResolver {
+	if r.a.HTTPS == nil {
+		return nil
+	}
+	return &httpsResolver{*r.a.HTTPS}
+}
+
+func (r *analysisResolver) SiteVariants() *siteVariantsResolver {
+	if r.a.SiteVariants == nil {
+		return nil
+	}
+	return &siteVariantsResolver{*r.a.SiteVariants}
+}
+
+func (r *analysisResolver) TLS() *tlsResolver {
+	if r.a.TLS == nil {
+		return nil
+	}
+	return &tlsResolver{*r.a.TLS}
+}
+
+func (r *analysisResolver) Favicon() *faviconResolver {
+	if r.a.Favicon == nil {
+		return nil
+	}
+	return &faviconResolver{*r.a.Favicon}
+}
+
+func (r *analysisResolver) Findings() []*findingResolver {
+	findings := make([]*findingResolver, len(r.a.Findings))
+	for i := range r.a.Findings {
+		findings[i] = &findingResolver{f: &r.a.Findings[i]}
+	}
+	return findings
+}
+
+func (r *analysisResolver) Warnings() []*warningResolver {
+	warnings := make([]*warningResolver, len(r.a.Warnings))
+	for i := range r.a.Warnings {
+		warnings[i] = &warningResolver{w: &r.a.Warnings[i]}
+	}
+	return warnings
+}
+
+// findingResolver resolves the Finding type.
+type findingResolver struct {
+	f *analyzer.Finding
+}
+
+func (r *findingResolver) Type() string      { return r.f.Type }
+func (r *findingResolver) Severity() string  { return string(r.f.Severity) }
+func (r *findingResolver) Message() string   { return r.f.Message }
+func (r *findingResolver) Evidence() *string { return optionalString(r.f.Evidence) }
+
+// warningResolver resolves the Warning type.
+type warningResolver struct {
+	w *analyzer.Warning
+}
+
+func (r *warningResolver) Module() string  { return r.w.Module }
+func (r *warningResolver) Code() string    { return r.w.Code }
+func (r *warningResolver) Message() string { return r.w.Message }
+
+// cacheResolver resolves the CacheInfo type.
+type cacheResolver struct {
+	c *analyzer.CacheInfo
+}
+
+func (r *cacheResolver) Hit() bool   { return r.c.Hit }
+func (r *cacheResolver) Age() string { return r.c.Age }
+func (r *cacheResolver) TTL() string { return r.c.TTL }
+
+// optionalString maps an empty string to null.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// headingResolver resolves the HeadingCount type.
+type headingResolver struct {
+	level string
+	count int
+}
+
+func (r *headingResolver) Level() string { return r.level }
+func (r *headingResolver) Count() int32  { return int32(r.count) }
+
+// headingCounts converts a heading map into a list sorted by level.
+func headingCounts(headings map[string]int) []*headingResolver {
+	result := make([]*headingResolver, 0, len(headings))
+	for level, count := range headings {
+		result = append(result, &headingResolver{level: level, count: count})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].level < result[j].level })
+	return result
+}
+
+// comparisonResolver resolves the Comparison type.
+type comparisonResolver struct {
+	c *analyzer.WebpageComparison
+}
+
+func (r *comparisonResolver) A() *analysisResolver       { return &analysisResolver{a: r.c.A} }
+func (r *comparisonResolver) B() *analysisResolver       { return &analysisResolver{a: r.c.B} }
+func (r *comparisonResolver) Differences() *diffResolver { return &diffResolver{d: r.c.Differences} }
+func (r *comparisonResolver) ProcessingTime() string     { return r.c.ProcessingTime }
+
+// changesResolver resolves the SnapshotChanges type.
+type changesResolver struct {
+	c *analyzer.SnapshotChanges
+}
+
+func (r *changesResolver) PreviousID() gographql.ID { return gographql.ID(r.c.PreviousID) }
+func (r *changesResolver) PreviousAnalyzedAt() string {
+	return r.c.PreviousAnalyzedAt.Format(time.RFC3339)
+}
+func (r *changesResolver) Differences() *diffResolver { return &diffResolver{d: r.c.Differences} }
+
+// diffResolver resolves the Diff type.
+type diffResolver struct {
+	d analyzer.ComparisonDiff
+}
+
+func (r *diffResolver) Identical() bool               { return r.d.Identical }
+func (r *diffResolver) TitleChanged() bool            { return r.d.TitleChanged }
+func (r *diffResolver) HTMLVersionChanged() bool      { return r.d.HTMLVersionChanged }
+func (r *diffResolver) Headings() []*headingResolver  { return headingCounts(r.d.Headings) }
+func (r *diffResolver) InternalLinksDelta() int32     { return int32(r.d.InternalLinksDelta) }
+func (r *diffResolver) ExternalLinksDelta() int32     { return int32(r.d.ExternalLinksDelta) }
+func (r *diffResolver) InaccessibleLinksDelta() int32 { return int32(r.d.InaccessibleLinksDelta) }
+func (r *diffResolver) LoginFormChanged() bool        { return r.d.LoginFormChanged }
+func (r *diffResolver) ContentChanged() bool          { return r.d.ContentChanged }
+func (r *diffResolver) Meta() *analyzer.MetaDiff      { return r.d.Meta }
+
+func (r *diffResolver) Summary() []string {
+	if r.d.Summary == nil {
+		return []string{}
+	}
+	return r.d.Summary
+}
+
+// analysisError exposes an AnalysisError's details as GraphQL error extensions.
+type analysisError struct {
+	*analyzer.AnalysisError
+}
+
+// Extensions implements the graphql-go extension hook.
+func (e analysisError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{
+		"status_code": e.StatusCode,
+		"url":         e.URL,
+	}
+	if e.Code != "" {
+		extensions["code"] = e.Code
+	}
+	if e.RetryAfter > 0 {
+		extensions["retry_after"] = e.RetryAfter
+	}
+	return extensions
+}
+
+// Error returns only the human-readable message; details are in the extensions.
+func (e analysisError) Error() string {
+	return e.ErrorMessage
+}
+
+// wrapError attaches extensions to analysis errors.
+func wrapError(err error) error {
+	var analysisErr *analyzer.AnalysisError
+	if errors.As(err, &analysisErr) {
+		return analysisError{analysisErr}
+	}
+	return err
+}
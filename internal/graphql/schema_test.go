@@ -0,0 +1,103 @@
+package graphql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+// TestSchema_MatchesResultTypes checks that every field of the analyzer's
+// result types, down to the types of their sections, is in the schema, and
+// that the schema has no fields they lack. Names are matched as graphql-go
+// matches them to Go fields: ignoring case and underscores.
+func TestSchema_MatchesResultTypes(t *testing.T) {
+	schema := NewHandler(&mockAnalyzerService{}, nil, nil).schema.ASTSchema()
+
+	checked := make(map[string]bool)
+	var check func(typeName string, goType reflect.Type)
+	check = func(typeName string, goType reflect.Type) {
+		if checked[typeName] {
+			return
+		}
+		checked[typeName] = true
+		object, ok := schema.Types[typeName].(*types.ObjectTypeDefinition)
+		require.True(t, ok, "%s should be an object type", typeName)
+
+		fields := make(map[string]*types.FieldDefinition, len(object.Fields))
+		for _, field := range object.Fields {
+			fields[fieldKey(field.Name)] = field
+		}
+		for i := 0; i < goType.NumField(); i++ {
+			goField := goType.Field(i)
+			name, _, _ := strings.Cut(goField.Tag.Get("json"), ",")
+			field, ok := fields[fieldKey(name)]
+			if !assert.True(t, ok, "%s should have a field for %s.%s", typeName, goType.Name(), goField.Name) {
+				continue
+			}
+			delete(fields, fieldKey(name))
+
+			elem := goField.Type
+			for elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Slice {
+				elem = elem.Elem()
+			}
+			if elem.Kind() == reflect.Struct && elem != reflect.TypeOf(time.Time{}) {
+				check(namedType(field.Type), elem)
+			}
+		}
+		for _, field := range fields {
+			assert.Fail(t, "field not in the result types", "%s.%s has no field in %s", typeName, field.Name, goType.Name())
+		}
+	}
+	check("Analysis", reflect.TypeOf(analyzer.WebpageAnalysis{}))
+	check("Comparison", reflect.TypeOf(analyzer.WebpageComparison{}))
+}
+
+// TestSchema_AnalyzeArguments checks that analyze takes every setting of an
+// analysis request but the page's credentials, which are not sent over
+// GraphQL.
+func TestSchema_AnalyzeArguments(t *testing.T) {
+	schema := NewHandler(&mockAnalyzerService{}, nil, nil).schema.ASTSchema()
+	query := schema.Types["Query"].(*types.ObjectTypeDefinition)
+	analyze := query.Fields.Get("analyze")
+	require.NotNil(t, analyze)
+
+	args := make(map[string]bool)
+	for _, arg := range analyze.Arguments {
+		args[fieldKey(arg.Name.Name)] = true
+	}
+	requestType := reflect.TypeOf(analyzer.AnalysisRequest{})
+	for i := 0; i < requestType.NumField(); i++ {
+		field := requestType.Field(i)
+		if field.Name == "Auth" {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		assert.True(t, args[fieldKey(name)], "analyze should take %s", field.Name)
+	}
+}
+
+// fieldKey is name in lower case without underscores.
+func fieldKey(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// namedType is t without its list and non-null wrappers.
+func namedType(t types.Type) string {
+	for {
+		switch wrapper := t.(type) {
+		case *types.NonNull:
+			t = wrapper.OfType
+		case *types.List:
+			t = wrapper.OfType
+		default:
+			return t.String()
+		}
+	}
+}
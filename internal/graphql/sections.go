@@ -0,0 +1,268 @@
+package graphql
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+// The module sections of an analysis are resolved from their analyzer types'
+// fields where GraphQL can take them as they are. The resolvers below wrap the
+// sections whose fields it cannot: ints, which GraphQL needs as int32, times,
+// given as RFC 3339 strings, and maps, given as lists of counts.
+
+// moduleOptions is the ModuleOptions scalar: options keyed by module name.
+type moduleOptions map[string]analyzer.ModuleOptions
+
+func (moduleOptions) ImplementsGraphQLType(name string) bool { return name == "ModuleOptions" }
+
+func (o *moduleOptions) UnmarshalGraphQL(input interface{}) error {
+	modules, ok := input.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("module options must be an object, not %T", input)
+	}
+	*o = make(moduleOptions, len(modules))
+	for name, v := range modules {
+		options, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("the options of module %q must be an object, not %T", name, v)
+		}
+		(*o)[name] = options
+	}
+	return nil
+}
+
+// countResolver resolves the Count type.
+type countResolver struct {
+	name  string
+	count int
+}
+
+func (r *countResolver) Name() string { return r.name }
+func (r *countResolver) Count() int32 { return int32(r.count) }
+
+// counts converts a map of counts into a list sorted by name.
+func counts(m map[string]int) []*countResolver {
+	result := make([]*countResolver, 0, len(m))
+	for name, count := range m {
+		result = append(result, &countResolver{name: name, count: count})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+	return result
+}
+
+// optionalTime formats t as RFC 3339, mapping nil to null.
+func optionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format(time.RFC3339)
+	return &s
+}
+
+// wrap returns a resolver for each of items.
+func wrap[T, R any](items []T, resolve func(T) R) []R {
+	result := make([]R, len(items))
+	for i, item := range items {
+		result[i] = resolve(item)
+	}
+	return result
+}
+
+// pointers returns pointers to each of items, for list fields resolved from
+// the items' own fields.
+func pointers[T any](items []T) []*T {
+	return wrap(items, func(item T) *T { return &item })
+}
+
+type linkProbeResolver struct{ analyzer.LinkProbeResult }
+
+func (r *linkProbeResolver) Checked() int32 { return int32(r.LinkProbeResult.Checked) }
+func (r *linkProbeResolver) Broken() int32  { return int32(r.LinkProbeResult.Broken) }
+
+type technologyResolver struct{ analyzer.Technology }
+
+func (r *technologyResolver) Confidence() int32 { return int32(r.Technology.Confidence) }
+
+type domResolver struct{ analyzer.DOMMetrics }
+
+func (r *domResolver) Elements() int32        { return int32(r.DOMMetrics.Elements) }
+func (r *domResolver) MaxDepth() int32        { return int32(r.DOMMetrics.MaxDepth) }
+func (r *domResolver) HTMLBytes() int32       { return int32(r.DOMMetrics.HTMLBytes) }
+func (r *domResolver) InlineScripts() int32   { return int32(r.DOMMetrics.InlineScripts) }
+func (r *domResolver) InlineStyles() int32    { return int32(r.DOMMetrics.InlineStyles) }
+func (r *domResolver) StyleAttributes() int32 { return int32(r.DOMMetrics.StyleAttributes) }
+
+type clientRedirectResolver struct{ analyzer.ClientRedirect }
+
+func (r *clientRedirectResolver) Delay() int32 { return int32(r.ClientRedirect.Delay) }
+
+type paginationResolver struct{ analyzer.Pagination }
+
+func (r *paginationResolver) Page() int32 { return int32(r.Pagination.Page) }
+
+type structuredDataResolver struct{ analyzer.StructuredData }
+
+func (r *structuredDataResolver) Types() []*countResolver { return counts(r.StructuredData.Types) }
+func (r *structuredDataResolver) InvalidJSONLD() int32    { return int32(r.StructuredData.InvalidJSONLD) }
+
+type productResolver struct{ analyzer.ProductInfo }
+
+func (r *productResolver) RatingCount() int32 { return int32(r.ProductInfo.RatingCount) }
+
+type structureResolver struct{ analyzer.ContentStructure }
+
+func (r *structureResolver) Tables() *tablesResolver {
+	return &tablesResolver{r.ContentStructure.Tables}
+}
+func (r *structureResolver) OrderedLists() int32 { return int32(r.ContentStructure.OrderedLists) }
+func (r *structureResolver) UnorderedLists() int32 {
+	return int32(r.ContentStructure.UnorderedLists)
+}
+func (r *structureResolver) DefinitionLists() int32 {
+	return int32(r.ContentStructure.DefinitionLists)
+}
+
+type tablesResolver struct{ analyzer.TableSummary }
+
+func (r *tablesResolver) Total() int32          { return int32(r.TableSummary.Total) }
+func (r *tablesResolver) WithHeaders() int32    { return int32(r.TableSummary.WithHeaders) }
+func (r *tablesResolver) WithCaption() int32    { return int32(r.TableSummary.WithCaption) }
+func (r *tablesResolver) Presentational() int32 { return int32(r.TableSummary.Presentational) }
+func (r *tablesResolver) LayoutSuspects() int32 { return int32(r.TableSummary.LayoutSuspects) }
+
+type imagesResolver struct{ analyzer.ImageAudit }
+
+func (r *imagesResolver) Total() int32             { return int32(r.ImageAudit.Total) }
+func (r *imagesResolver) Lazy() int32              { return int32(r.ImageAudit.Lazy) }
+func (r *imagesResolver) MissingDimensions() int32 { return int32(r.ImageAudit.MissingDimensions) }
+func (r *imagesResolver) WithSrcset() int32        { return int32(r.ImageAudit.WithSrcset) }
+func (r *imagesResolver) MissingSizes() int32      { return int32(r.ImageAudit.MissingSizes) }
+func (r *imagesResolver) ModernFormat() int32      { return int32(r.ImageAudit.ModernFormat) }
+func (r *imagesResolver) LegacyFormat() int32      { return int32(r.ImageAudit.LegacyFormat) }
+
+type trackersResolver struct{ analyzer.Trackers }
+
+func (r *trackersResolver) ThirdParties() []*thirdPartyResolver {
+	return wrap(r.Trackers.ThirdParties, func(t analyzer.ThirdParty) *thirdPartyResolver {
+		return &thirdPartyResolver{t}
+	})
+}
+func (r *trackersResolver) Categories() []*countResolver { return counts(r.Trackers.Categories) }
+func (r *trackersResolver) PrivacyScore() int32          { return int32(r.Trackers.PrivacyScore) }
+
+type thirdPartyResolver struct{ analyzer.ThirdParty }
+
+func (r *thirdPartyResolver) Requests() int32 { return int32(r.ThirdParty.Requests) }
+
+type trackingParamsResolver struct{ analyzer.TrackingParams }
+
+func (r *trackingParamsResolver) Params() []*countResolver { return counts(r.TrackingParams.Params) }
+func (r *trackingParamsResolver) Campaigns() []*campaignResolver {
+	return wrap(r.TrackingParams.Campaigns, func(c analyzer.Campaign) *campaignResolver {
+		return &campaignResolver{c}
+	})
+}
+
+type campaignResolver struct{ analyzer.Campaign }
+
+func (r *campaignResolver) Links() int32 { return int32(r.Campaign.Links) }
+
+type canonicalResolver struct{ analyzer.Canonical }
+
+func (r *canonicalResolver) StatusCode() int32 { return int32(r.Canonical.StatusCode) }
+
+type articleResolver struct{ analyzer.Article }
+
+func (r *articleResolver) Length() int32 { return int32(r.Article.Length) }
+
+type waybackResolver struct{ analyzer.WaybackHistory }
+
+func (r *waybackResolver) FirstSeen() *string { return optionalTime(r.WaybackHistory.FirstSeen) }
+func (r *waybackResolver) LastSeen() *string  { return optionalTime(r.WaybackHistory.LastSeen) }
+
+type reputationResolver struct{ analyzer.ReputationResult }
+
+func (r *reputationResolver) Checked() int32 { return int32(r.ReputationResult.Checked) }
+
+type domainResolver struct{ analyzer.DomainRegistration }
+
+func (r *domainResolver) CreatedAt() *string { return optionalTime(r.DomainRegistration.CreatedAt) }
+func (r *domainResolver) ExpiresAt() *string { return optionalTime(r.DomainRegistration.ExpiresAt) }
+func (r *domainResolver) AgeDays() int32     { return int32(r.DomainRegistration.AgeDays) }
+
+type infrastructureResolver struct{ analyzer.Infrastructure }
+
+func (r *infrastructureResolver) Addresses() []*ipAddressResolver {
+	return wrap(r.Infrastructure.Addresses, func(a analyzer.IPAddress) *ipAddressResolver {
+		return &ipAddressResolver{a}
+	})
+}
+
+type ipAddressResolver struct{ analyzer.IPAddress }
+
+func (r *ipAddressResolver) Version() int32 { return int32(r.IPAddress.Version) }
+func (r *ipAddressResolver) Location() *ipLocationResolver {
+	if r.IPAddress.Location == nil {
+		return nil
+	}
+	return &ipLocationResolver{*r.IPAddress.Location}
+}
+
+type ipLocationResolver struct{ analyzer.IPLocation }
+
+func (r *ipLocationResolver) ASN() int32 { return int32(r.IPLocation.ASN) }
+
+type httpsResolver struct{ analyzer.HTTPSPosture }
+
+func (r *httpsResolver) HSTS() *hstsResolver {
+	if r.HTTPSPosture.HSTS == nil {
+		return nil
+	}
+	return &hstsResolver{*r.HTTPSPosture.HSTS}
+}
+
+type hstsResolver struct{ analyzer.HSTSPolicy }
+
+// MaxAge is clamped to the largest GraphQL Int; any max-age that long means
+// the same to browsers.
+func (r *hstsResolver) MaxAge() int32 {
+	if r.HSTSPolicy.MaxAge > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int32(r.HSTSPolicy.MaxAge)
+}
+
+type siteVariantsResolver struct{ analyzer.SiteVariants }
+
+func (r *siteVariantsResolver) Variants() []*siteVariantResolver {
+	return wrap(r.SiteVariants.Variants, func(v analyzer.SiteVariant) *siteVariantResolver {
+		return &siteVariantResolver{v}
+	})
+}
+
+type siteVariantResolver struct{ analyzer.SiteVariant }
+
+func (r *siteVariantResolver) StatusCode() int32 { return int32(r.SiteVariant.StatusCode) }
+
+type tlsResolver struct{ analyzer.TLSReport }
+
+func (r *tlsResolver) Certificate() *certificateResolver {
+	if r.TLSReport.Certificate == nil {
+		return nil
+	}
+	return &certificateResolver{*r.TLSReport.Certificate}
+}
+
+type certificateResolver struct{ analyzer.CertificateInfo }
+
+func (r *certificateResolver) NotAfter() string {
+	return r.CertificateInfo.NotAfter.Format(time.RFC3339)
+}
+
+type faviconResolver struct{ analyzer.Favicon }
+
+func (r *faviconResolver) Size() int32 { return int32(r.Favicon.Size) }
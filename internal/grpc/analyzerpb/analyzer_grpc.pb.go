@@ -0,0 +1,277 @@
+// gRPC API for the webpage analyzer.
+//
+// Regenerate the Go stubs in internal/grpc/analyzerpb after editing this file,
+// with protoc-gen-go v1.34.2 and protoc-gen-go-grpc v1.4.0; never edit them
+// by hand:
+//
+//   protoc -I api/proto \
+//          --go_out=. --go_opt=module=webpage-analyzer \
+//          --go-grpc_out=. --go-grpc_opt=module=webpage-analyzer \
+//          analyzer.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: analyzer.proto
+
+package analyzerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	AnalyzerService_Analyze_FullMethodName            = "/webpageanalyzer.v1.AnalyzerService/Analyze"
+	AnalyzerService_AnalyzeBatch_FullMethodName       = "/webpageanalyzer.v1.AnalyzerService/AnalyzeBatch"
+	AnalyzerService_AnalyzeBatchStream_FullMethodName = "/webpageanalyzer.v1.AnalyzerService/AnalyzeBatchStream"
+	AnalyzerService_GetStatus_FullMethodName          = "/webpageanalyzer.v1.AnalyzerService/GetStatus"
+)
+
+// AnalyzerServiceClient is the client API for AnalyzerService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AnalyzerService exposes the same analysis engine as the REST API.
+type AnalyzerServiceClient interface {
+	// Analyze fetches and analyzes a single webpage.
+	Analyze(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*Analysis, error)
+	// AnalyzeBatch analyzes several webpages concurrently and returns when all are done.
+	AnalyzeBatch(ctx context.Context, in *AnalyzeBatchRequest, opts ...grpc.CallOption) (*AnalyzeBatchResponse, error)
+	// AnalyzeBatchStream analyzes several webpages concurrently, streaming each result
+	// as soon as it completes.
+	AnalyzeBatchStream(ctx context.Context, in *AnalyzeBatchRequest, opts ...grpc.CallOption) (AnalyzerService_AnalyzeBatchStreamClient, error)
+	// GetStatus reports the service status.
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error)
+}
+
+type analyzerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAnalyzerServiceClient(cc grpc.ClientConnInterface) AnalyzerServiceClient {
+	return &analyzerServiceClient{cc}
+}
+
+func (c *analyzerServiceClient) Analyze(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*Analysis, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Analysis)
+	err := c.cc.Invoke(ctx, AnalyzerService_Analyze_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analyzerServiceClient) AnalyzeBatch(ctx context.Context, in *AnalyzeBatchRequest, opts ...grpc.CallOption) (*AnalyzeBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AnalyzeBatchResponse)
+	err := c.cc.Invoke(ctx, AnalyzerService_AnalyzeBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analyzerServiceClient) AnalyzeBatchStream(ctx context.Context, in *AnalyzeBatchRequest, opts ...grpc.CallOption) (AnalyzerService_AnalyzeBatchStreamClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AnalyzerService_ServiceDesc.Streams[0], AnalyzerService_AnalyzeBatchStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &analyzerServiceAnalyzeBatchStreamClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AnalyzerService_AnalyzeBatchStreamClient interface {
+	Recv() (*BatchProgress, error)
+	grpc.ClientStream
+}
+
+type analyzerServiceAnalyzeBatchStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *analyzerServiceAnalyzeBatchStreamClient) Recv() (*BatchProgress, error) {
+	m := new(BatchProgress)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *analyzerServiceClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStatusResponse)
+	err := c.cc.Invoke(ctx, AnalyzerService_GetStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AnalyzerServiceServer is the server API for AnalyzerService service.
+// All implementations must embed UnimplementedAnalyzerServiceServer
+// for forward compatibility
+//
+// AnalyzerService exposes the same analysis engine as the REST API.
+type AnalyzerServiceServer interface {
+	// Analyze fetches and analyzes a single webpage.
+	Analyze(context.Context, *AnalyzeRequest) (*Analysis, error)
+	// AnalyzeBatch analyzes several webpages concurrently and returns when all are done.
+	AnalyzeBatch(context.Context, *AnalyzeBatchRequest) (*AnalyzeBatchResponse, error)
+	// AnalyzeBatchStream analyzes several webpages concurrently, streaming each result
+	// as soon as it completes.
+	AnalyzeBatchStream(*AnalyzeBatchRequest, AnalyzerService_AnalyzeBatchStreamServer) error
+	// GetStatus reports the service status.
+	GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error)
+	mustEmbedUnimplementedAnalyzerServiceServer()
+}
+
+// UnimplementedAnalyzerServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedAnalyzerServiceServer struct {
+}
+
+func (UnimplementedAnalyzerServiceServer) Analyze(context.Context, *AnalyzeRequest) (*Analysis, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Analyze not implemented")
+}
+func (UnimplementedAnalyzerServiceServer) AnalyzeBatch(context.Context, *AnalyzeBatchRequest) (*AnalyzeBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AnalyzeBatch not implemented")
+}
+func (UnimplementedAnalyzerServiceServer) AnalyzeBatchStream(*AnalyzeBatchRequest, AnalyzerService_AnalyzeBatchStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method AnalyzeBatchStream not implemented")
+}
+func (UnimplementedAnalyzerServiceServer) GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedAnalyzerServiceServer) mustEmbedUnimplementedAnalyzerServiceServer() {}
+
+// UnsafeAnalyzerServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AnalyzerServiceServer will
+// result in compilation errors.
+type UnsafeAnalyzerServiceServer interface {
+	mustEmbedUnimplementedAnalyzerServiceServer()
+}
+
+func RegisterAnalyzerServiceServer(s grpc.ServiceRegistrar, srv AnalyzerServiceServer) {
+	s.RegisterService(&AnalyzerService_ServiceDesc, srv)
+}
+
+func _AnalyzerService_Analyze_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnalyzeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyzerServiceServer).Analyze(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnalyzerService_Analyze_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyzerServiceServer).Analyze(ctx, req.(*AnalyzeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnalyzerService_AnalyzeBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnalyzeBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyzerServiceServer).AnalyzeBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnalyzerService_AnalyzeBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyzerServiceServer).AnalyzeBatch(ctx, req.(*AnalyzeBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnalyzerService_AnalyzeBatchStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AnalyzeBatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AnalyzerServiceServer).AnalyzeBatchStream(m, &analyzerServiceAnalyzeBatchStreamServer{ServerStream: stream})
+}
+
+type AnalyzerService_AnalyzeBatchStreamServer interface {
+	Send(*BatchProgress) error
+	grpc.ServerStream
+}
+
+type analyzerServiceAnalyzeBatchStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *analyzerServiceAnalyzeBatchStreamServer) Send(m *BatchProgress) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _AnalyzerService_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyzerServiceServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnalyzerService_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyzerServiceServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AnalyzerService_ServiceDesc is the grpc.ServiceDesc for AnalyzerService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AnalyzerService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "webpageanalyzer.v1.AnalyzerService",
+	HandlerType: (*AnalyzerServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Analyze",
+			Handler:    _AnalyzerService_Analyze_Handler,
+		},
+		{
+			MethodName: "AnalyzeBatch",
+			Handler:    _AnalyzerService_AnalyzeBatch_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _AnalyzerService_GetStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AnalyzeBatchStream",
+			Handler:       _AnalyzerService_AnalyzeBatchStream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "analyzer.proto",
+}
@@ -0,0 +1,183 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/apikeys"
+	"webpage-analyzer/internal/grpc/analyzerpb"
+	"webpage-analyzer/internal/logging"
+)
+
+// apiKeyMetadata is the metadata key of API keys, as the REST API's X-API-Key
+// header; keys may also be sent as "authorization: Bearer <key>".
+const apiKeyMetadata = "x-api-key"
+
+// AuthenticateUnary returns an interceptor that requires the API key of a
+// tenant on every call, failing calls without a valid one with
+// UNAUTHENTICATED. The key is put in the call's context, for MeterUnary and
+// the logs.
+func AuthenticateUnary(keys apikeys.Store) gogrpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, keys)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// AuthenticateStream is AuthenticateUnary for streaming calls.
+func AuthenticateStream(keys apikeys.Store) gogrpc.StreamServerInterceptor {
+	return func(srv interface{}, ss gogrpc.ServerStream, info *gogrpc.StreamServerInfo, handler gogrpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), keys)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticate returns ctx carrying the API key of the call's metadata.
+func authenticate(ctx context.Context, keys apikeys.Store) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	secret := first(md.Get(apiKeyMetadata))
+	if secret == "" {
+		secret, _ = strings.CutPrefix(first(md.Get("authorization")), "Bearer ")
+	}
+	if secret == "" {
+		return nil, status.Error(codes.Unauthenticated, "An API key is required")
+	}
+	key, err := keys.Authenticate(ctx, secret)
+	if errors.Is(err, apikeys.ErrInvalidKey) {
+		return nil, status.Error(codes.Unauthenticated, "Invalid or revoked API key")
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to authenticate API key", "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	ctx, _ = logging.With(ctx, "tenant", key.Tenant, "api_key", key.ID)
+	return apikeys.WithKey(ctx, key), nil
+}
+
+// first returns the first of values, or "" if there are none.
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// MeterUnary returns an interceptor that counts the analyses of calls made
+// with an API key against the key's limits, failing calls past them with
+// RESOURCE_EXHAUSTED. Analyze counts once; a batch takes one of the key's
+// concurrent analyses for the whole call and counts each of its URLs, which
+// fail alone once the quota is used. Calls without a key pass through.
+func MeterUnary(meter *apikeys.Meter) gogrpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (interface{}, error) {
+		ctx, end, err := begin(ctx, meter, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		defer end()
+		return handler(ctx, req)
+	}
+}
+
+// MeterStream is MeterUnary for streaming calls.
+func MeterStream(meter *apikeys.Meter) gogrpc.StreamServerInterceptor {
+	return func(srv interface{}, ss gogrpc.ServerStream, info *gogrpc.StreamServerInfo, handler gogrpc.StreamHandler) error {
+		ctx, end, err := begin(ss.Context(), meter, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		defer end()
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// begin starts metering a call to method by the API key of ctx, if any.
+func begin(ctx context.Context, meter *apikeys.Meter, method string) (context.Context, func(), error) {
+	key := apikeys.KeyFromContext(ctx)
+	if key == nil || !analyzes(method) {
+		return ctx, func() {}, nil
+	}
+	if method == analyzerpb.AnalyzerService_Analyze_FullMethodName {
+		ctx, end, err := meter.Begin(ctx, key)
+		if err != nil {
+			return nil, nil, limitStatus(ctx, err)
+		}
+		return ctx, end, nil
+	}
+
+	ctx, end, err := meter.Hold(ctx, key)
+	if err != nil {
+		return nil, nil, limitStatus(ctx, err)
+	}
+	return withItemCharge(ctx, func(ctx context.Context) error { return meter.Charge(ctx, key) }), end, nil
+}
+
+// limitStatus returns the status of a call the key's limits turned away,
+// carrying the limit's code and when to retry as an AnalysisError detail.
+func limitStatus(ctx context.Context, err error) error {
+	var limitErr *apikeys.LimitError
+	if !errors.As(err, &limitErr) {
+		logging.FromContext(ctx).Error("Failed to count analysis", "error", err)
+		return status.Error(codes.Internal, "internal server error")
+	}
+	return withDetail(status.New(codes.ResourceExhausted, limitErr.Message), &analyzerpb.AnalysisError{
+		StatusCode:   http.StatusTooManyRequests,
+		Code:         limitErr.Code,
+		ErrorMessage: limitErr.Message,
+		RetryAfter:   int32(limitErr.RetryAfterSeconds()),
+	})
+}
+
+type itemChargeKey struct{}
+
+// withItemCharge returns a context in which charge is called before each URL
+// of a batch is analyzed.
+func withItemCharge(ctx context.Context, charge func(ctx context.Context) error) context.Context {
+	return context.WithValue(ctx, itemChargeKey{}, charge)
+}
+
+// chargeItem counts the analysis of one batch URL, returning the item's error
+// if the key's limits turn it away.
+func chargeItem(ctx context.Context, pageURL string) *analyzer.AnalysisError {
+	charge, ok := ctx.Value(itemChargeKey{}).(func(ctx context.Context) error)
+	if !ok {
+		return nil
+	}
+	err := charge(ctx)
+	if err == nil {
+		return nil
+	}
+	var limitErr *apikeys.LimitError
+	if !errors.As(err, &limitErr) {
+		logging.FromContext(ctx).Error("Failed to count analysis", "error", err)
+		return &analyzer.AnalysisError{StatusCode: http.StatusInternalServerError, Code: analyzer.ErrorCodeInternal, ErrorMessage: "Internal server error", URL: pageURL}
+	}
+	return &analyzer.AnalysisError{
+		StatusCode:   http.StatusTooManyRequests,
+		Code:         limitErr.Code,
+		ErrorMessage: limitErr.Message,
+		URL:          pageURL,
+		RetryAfter:   limitErr.RetryAfterSeconds(),
+	}
+}
+
+// contextStream is a server stream whose context interceptors replaced.
+type contextStream struct {
+	gogrpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
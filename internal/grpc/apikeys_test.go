@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"webpage-analyzer/internal/apikeys"
+	"webpage-analyzer/internal/grpc/analyzerpb"
+	"webpage-analyzer/internal/problem"
+)
+
+// newKeyedTestClient serves the mock pages behind API keys, returning the
+// client and a secret of a key with the given daily quota.
+func newKeyedTestClient(t *testing.T, quota int) (analyzerpb.AnalyzerServiceClient, apikeys.Store, string) {
+	t.Helper()
+	keys, err := apikeys.Open(context.Background(), apikeys.Config{Driver: apikeys.DriverMemory})
+	require.NoError(t, err)
+	t.Cleanup(func() { keys.Close() })
+	_, secret, err := keys.Create(context.Background(), apikeys.Spec{Tenant: "acme", DailyQuota: quota})
+	require.NoError(t, err)
+
+	meter := apikeys.NewMeter(keys, nil)
+	svc := &mockAnalyzerService{pages: map[string]string{"https://a.example.com": "A", "https://b.example.com": "B"}}
+	client := newTestClientFor(t, svc,
+		gogrpc.ChainUnaryInterceptor(AuthenticateUnary(keys), MeterUnary(meter)),
+		gogrpc.ChainStreamInterceptor(AuthenticateStream(keys), MeterStream(meter)))
+	return client, keys, secret
+}
+
+func TestAuthenticate(t *testing.T) {
+	client, _, secret := newKeyedTestClient(t, 0)
+
+	_, err := client.Analyze(context.Background(), &analyzerpb.AnalyzeRequest{Url: "https://a.example.com"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "Calls without a key should be refused")
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "wpa_wrong")
+	_, err = client.Analyze(ctx, &analyzerpb.AnalyzeRequest{Url: "https://a.example.com"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "Unknown keys should be refused")
+
+	stream, err := client.AnalyzeBatchStream(context.Background(), &analyzerpb.AnalyzeBatchRequest{Urls: []string{"https://a.example.com"}})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "Streams should need a key too")
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+secret)
+	resp, err := client.Analyze(ctx, &analyzerpb.AnalyzeRequest{Url: "https://a.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "A", resp.GetPageTitle())
+}
+
+func TestMeter(t *testing.T) {
+	client, keys, secret := newKeyedTestClient(t, 2)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", secret)
+
+	_, err := client.Analyze(ctx, &analyzerpb.AnalyzeRequest{Url: "https://a.example.com"})
+	require.NoError(t, err)
+
+	batch, err := client.AnalyzeBatch(ctx, &analyzerpb.AnalyzeBatchRequest{Urls: []string{"https://a.example.com", "https://b.example.com"}})
+	require.NoError(t, err)
+	failed := 0
+	for _, item := range batch.GetResults() {
+		if item.GetError() != nil {
+			failed++
+			assert.Equal(t, problem.CodeQuotaExceeded, item.GetError().GetCode())
+		}
+	}
+	assert.Equal(t, 1, failed, "Each batch URL should count, failing alone past the quota")
+
+	_, err = client.Analyze(ctx, &analyzerpb.AnalyzeRequest{Url: "https://a.example.com"})
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+	detail := st.Details()[0].(*analyzerpb.AnalysisError)
+	assert.Equal(t, problem.CodeQuotaExceeded, detail.GetCode())
+	assert.Positive(t, detail.GetRetryAfter())
+
+	usage, err := keys.Usage(context.Background(), apikeys.UsageQuery{Tenant: "acme"})
+	require.NoError(t, err)
+	require.Len(t, usage, 1)
+	assert.Equal(t, int64(2), usage[0].Analyses)
+}
@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"context"
+	"math"
+	"time"
+
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"webpage-analyzer/internal/grpc/analyzerpb"
+	"webpage-analyzer/internal/logging"
+	"webpage-analyzer/internal/problem"
+)
+
+// Limiter bounds how many analyses run at once. The REST API's
+// ConcurrencyLimiter is one, so that both APIs share its slots.
+type Limiter interface {
+	// Acquire takes a slot, queueing as the limiter allows, and reports
+	// whether it got one.
+	Acquire(ctx context.Context) bool
+	// Release frees a slot taken by Acquire.
+	Release()
+	// RetryAfter is how long rejected callers should wait before retrying.
+	RetryAfter() time.Duration
+}
+
+// analyzes reports whether the method analyzes pages, as every method but
+// GetStatus does.
+func analyzes(fullMethod string) bool {
+	return fullMethod != analyzerpb.AnalyzerService_GetStatus_FullMethodName
+}
+
+// LimitUnary returns an interceptor that runs the unary calls that analyze
+// pages under limiter, failing them with UNAVAILABLE when it is full.
+func LimitUnary(limiter Limiter) gogrpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *gogrpc.UnaryServerInfo, handler gogrpc.UnaryHandler) (interface{}, error) {
+		if !analyzes(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		if !limiter.Acquire(ctx) {
+			return nil, busy(ctx, limiter, info.FullMethod)
+		}
+		defer limiter.Release()
+		return handler(ctx, req)
+	}
+}
+
+// LimitStream is LimitUnary for streaming calls; a stream holds its slot
+// until it ends.
+func LimitStream(limiter Limiter) gogrpc.StreamServerInterceptor {
+	return func(srv interface{}, ss gogrpc.ServerStream, info *gogrpc.StreamServerInfo, handler gogrpc.StreamHandler) error {
+		if !analyzes(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		if !limiter.Acquire(ss.Context()) {
+			return busy(ss.Context(), limiter, info.FullMethod)
+		}
+		defer limiter.Release()
+		return handler(srv, ss)
+	}
+}
+
+// busy returns the error of a call the limiter turned away, carrying the
+// server_busy code and when to retry as an AnalysisError detail.
+func busy(ctx context.Context, limiter Limiter, method string) error {
+	if err := ctx.Err(); err != nil {
+		return status.FromContextError(err).Err() // The client gave up while queued.
+	}
+	logging.FromContext(ctx).Warn("Call rejected by concurrency limiter", "method", method)
+	return withDetail(status.New(codes.Unavailable, "Server is busy, please retry later"), &analyzerpb.AnalysisError{
+		Code:         problem.CodeServerBusy,
+		ErrorMessage: "Server is busy, please retry later",
+		RetryAfter:   int32(math.Ceil(limiter.RetryAfter().Seconds())),
+	})
+}
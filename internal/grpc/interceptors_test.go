@@ -0,0 +1,46 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"webpage-analyzer/internal/grpc/analyzerpb"
+	"webpage-analyzer/internal/problem"
+)
+
+// fullLimiter is a Limiter with every slot taken.
+type fullLimiter struct{ acquired int }
+
+func (l *fullLimiter) Acquire(ctx context.Context) bool { l.acquired++; return false }
+func (l *fullLimiter) Release()                         {}
+func (l *fullLimiter) RetryAfter() time.Duration        { return 1500 * time.Millisecond }
+
+func TestLimitUnary(t *testing.T) {
+	limiter := &fullLimiter{}
+	svc := &mockAnalyzerService{pages: map[string]string{"https://a.example.com": "A"}}
+	client := newTestClientFor(t, svc, gogrpc.UnaryInterceptor(LimitUnary(limiter)), gogrpc.StreamInterceptor(LimitStream(limiter)))
+
+	_, err := client.Analyze(context.Background(), &analyzerpb.AnalyzeRequest{Url: "https://a.example.com"})
+	st := status.Convert(err)
+	assert.Equal(t, codes.Unavailable, st.Code(), "A full limiter should turn calls away")
+	require.Len(t, st.Details(), 1)
+	detail := st.Details()[0].(*analyzerpb.AnalysisError)
+	assert.Equal(t, problem.CodeServerBusy, detail.GetCode())
+	assert.Equal(t, int32(2), detail.GetRetryAfter(), "Retry-after should round up to whole seconds")
+
+	stream, err := client.AnalyzeBatchStream(context.Background(), &analyzerpb.AnalyzeBatchRequest{Urls: []string{"https://a.example.com"}})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err), "Streams should be limited too")
+
+	_, err = client.GetStatus(context.Background(), &analyzerpb.GetStatusRequest{})
+	require.NoError(t, err, "GetStatus does not analyze and should not be limited")
+	assert.Equal(t, 2, limiter.acquired)
+}
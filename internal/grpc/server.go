@@ -0,0 +1,349 @@
+// Package grpc exposes the analyzer service over gRPC, alongside the REST API.
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/grpc/analyzerpb"
+	"webpage-analyzer/internal/logging"
+)
+
+const (
+	// maxBatchSize caps the number of URLs accepted by the batch RPCs.
+	maxBatchSize = 50
+	// batchConcurrency is the number of batch URLs analyzed at the same time.
+	batchConcurrency = 4
+	// statusClientClosedRequest is the de facto HTTP status of a request its
+	// client gave up on, reported for batch URLs left unanalyzed.
+	statusClientClosedRequest = 499
+)
+
+// Server implements analyzerpb.AnalyzerServiceServer on top of analyzer.Service.
+type Server struct {
+	analyzerpb.UnimplementedAnalyzerServiceServer
+	analyzerService analyzer.Service
+}
+
+// NewServer creates a new gRPC analyzer server.
+func NewServer(analyzerService analyzer.Service) *Server {
+	return &Server{
+		analyzerService: analyzerService,
+	}
+}
+
+// Register creates a grpc.Server with the analyzer service registered.
+func Register(analyzerService analyzer.Service, opts ...gogrpc.ServerOption) *gogrpc.Server {
+	s := gogrpc.NewServer(opts...)
+	analyzerpb.RegisterAnalyzerServiceServer(s, NewServer(analyzerService))
+	return s
+}
+
+// Analyze analyzes a single webpage.
+func (s *Server) Analyze(ctx context.Context, req *analyzerpb.AnalyzeRequest) (*analyzerpb.Analysis, error) {
+	if req.GetUrl() == "" {
+		return nil, status.Error(codes.InvalidArgument, "url is required")
+	}
+
+	analysis, err := s.analyzerService.AnalyzeWebpage(ctx, toAnalysisRequest(req))
+	if err != nil {
+		return nil, toStatusError(ctx, err)
+	}
+	return toProtoAnalysis(analysis), nil
+}
+
+// toAnalysisRequest converts an AnalyzeRequest to the service's request.
+func toAnalysisRequest(req *analyzerpb.AnalyzeRequest) analyzer.AnalysisRequest {
+	r := analyzer.AnalysisRequest{
+		URL:            req.GetUrl(),
+		Modules:        req.GetModules(),
+		ForceRefresh:   req.GetForceRefresh(),
+		MaxAge:         req.GetMaxAge(),
+		FetchTimeout:   req.GetFetchTimeout(),
+		TotalTimeout:   req.GetTotalTimeout(),
+		AcceptLanguage: req.GetAcceptLanguage(),
+	}
+	if len(req.GetOptions()) > 0 {
+		r.Options = make(map[string]analyzer.ModuleOptions, len(req.GetOptions()))
+		for module, options := range req.GetOptions() {
+			r.Options[module] = analyzer.ModuleOptions(options.AsMap())
+		}
+	}
+	return r
+}
+
+// toBatchRequests returns the analysis request of each URL of a batch, all
+// with the batch's settings.
+func toBatchRequests(req *analyzerpb.AnalyzeBatchRequest) []analyzer.AnalysisRequest {
+	reqs := make([]analyzer.AnalysisRequest, len(req.GetUrls()))
+	for i, pageURL := range req.GetUrls() {
+		reqs[i] = toAnalysisRequest(&analyzerpb.AnalyzeRequest{
+			Url:            pageURL,
+			Modules:        req.GetModules(),
+			ForceRefresh:   req.GetForceRefresh(),
+			MaxAge:         req.GetMaxAge(),
+			Options:        req.GetOptions(),
+			FetchTimeout:   req.GetFetchTimeout(),
+			TotalTimeout:   req.GetTotalTimeout(),
+			AcceptLanguage: req.GetAcceptLanguage(),
+		})
+	}
+	return reqs
+}
+
+// AnalyzeBatch analyzes several webpages concurrently and returns all results in request order.
+func (s *Server) AnalyzeBatch(ctx context.Context, req *analyzerpb.AnalyzeBatchRequest) (*analyzerpb.AnalyzeBatchResponse, error) {
+	if err := validateBatch(req); err != nil {
+		return nil, err
+	}
+
+	results := make([]*analyzerpb.BatchItem, len(req.GetUrls()))
+	s.runBatch(ctx, toBatchRequests(req), func(index int, item *analyzerpb.BatchItem) {
+		results[index] = item
+	})
+
+	return &analyzerpb.AnalyzeBatchResponse{Results: results}, nil
+}
+
+// AnalyzeBatchStream analyzes several webpages concurrently, streaming each result as it completes.
+func (s *Server) AnalyzeBatchStream(req *analyzerpb.AnalyzeBatchRequest, stream analyzerpb.AnalyzerService_AnalyzeBatchStreamServer) error {
+	if err := validateBatch(req); err != nil {
+		return err
+	}
+
+	var (
+		mu        sync.Mutex
+		completed int32
+		sendErr   error
+	)
+	total := int32(len(req.GetUrls()))
+
+	s.runBatch(stream.Context(), toBatchRequests(req), func(_ int, item *analyzerpb.BatchItem) {
+		mu.Lock()
+		defer mu.Unlock()
+		completed++
+		if sendErr != nil {
+			return
+		}
+		sendErr = stream.Send(&analyzerpb.BatchProgress{
+			Completed: completed,
+			Total:     total,
+			Item:      item,
+		})
+	})
+
+	return sendErr
+}
+
+// GetStatus reports the service status.
+func (s *Server) GetStatus(ctx context.Context, _ *analyzerpb.GetStatusRequest) (*analyzerpb.GetStatusResponse, error) {
+	st, err := s.analyzerService.GetAnalysisStatus(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to get analysis status", "error", err)
+		return nil, status.Error(codes.Internal, "failed to get status")
+	}
+	return &analyzerpb.GetStatusResponse{Status: st}, nil
+}
+
+// runBatch analyzes reqs with bounded concurrency, calling done once per
+// request. Once ctx ends, the requests not started yet are not analyzed but
+// reported as cancelled.
+func (s *Server) runBatch(ctx context.Context, reqs []analyzer.AnalysisRequest, done func(index int, item *analyzerpb.BatchItem)) {
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for i, req := range reqs {
+		if ctx.Err() == nil {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			for j := i; j < len(reqs); j++ {
+				done(j, canceledItem(reqs[j].URL))
+			}
+			return
+		}
+		wg.Add(1)
+		go func(index int, req analyzer.AnalysisRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			done(index, s.analyzeItem(ctx, req))
+		}(i, req)
+	}
+}
+
+// canceledItem is the item of a batch URL left unanalyzed because the call
+// was cancelled.
+func canceledItem(pageURL string) *analyzerpb.BatchItem {
+	return &analyzerpb.BatchItem{Url: pageURL, Outcome: &analyzerpb.BatchItem_Error{Error: toProtoError(&analyzer.AnalysisError{
+		StatusCode:   statusClientClosedRequest,
+		Code:         analyzer.ErrorCodeCanceled,
+		ErrorMessage: "The batch was cancelled before the page was analyzed",
+		URL:          pageURL,
+	})}}
+}
+
+// analyzeItem analyzes one batch URL, capturing failures in the item.
+func (s *Server) analyzeItem(ctx context.Context, req analyzer.AnalysisRequest) *analyzerpb.BatchItem {
+	pageURL := req.URL
+	item := &analyzerpb.BatchItem{Url: pageURL}
+	if chargeErr := chargeItem(ctx, pageURL); chargeErr != nil {
+		item.Outcome = &analyzerpb.BatchItem_Error{Error: toProtoError(chargeErr)}
+		return item
+	}
+
+	analysis, err := s.analyzerService.AnalyzeWebpage(ctx, req)
+	if err != nil {
+		var analysisErr *analyzer.AnalysisError
+		if !errors.As(err, &analysisErr) {
+			analysisErr = &analyzer.AnalysisError{
+				StatusCode:   http.StatusInternalServerError,
+				Code:         analyzer.ErrorCodeInternal,
+				ErrorMessage: err.Error(),
+				URL:          pageURL,
+			}
+		}
+		item.Outcome = &analyzerpb.BatchItem_Error{Error: toProtoError(analysisErr)}
+		return item
+	}
+
+	item.Outcome = &analyzerpb.BatchItem_Analysis{Analysis: toProtoAnalysis(analysis)}
+	return item
+}
+
+// validateBatch checks the batch size limits.
+func validateBatch(req *analyzerpb.AnalyzeBatchRequest) error {
+	switch n := len(req.GetUrls()); {
+	case n == 0:
+		return status.Error(codes.InvalidArgument, "at least one url is required")
+	case n > maxBatchSize:
+		return status.Errorf(codes.InvalidArgument, "at most %d urls are allowed per batch", maxBatchSize)
+	}
+	return nil
+}
+
+// toStatusError maps analysis failures to gRPC status errors, attaching the AnalysisError as a detail.
+func toStatusError(ctx context.Context, err error) error {
+	var analysisErr *analyzer.AnalysisError
+	if !errors.As(err, &analysisErr) {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return status.Error(codes.DeadlineExceeded, err.Error())
+		}
+		if errors.Is(err, context.Canceled) {
+			return status.Error(codes.Canceled, err.Error())
+		}
+		logging.FromContext(ctx).Error("Analysis failed with internal error", "error", err)
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	return withDetail(status.New(statusCode(analysisErr), analysisErr.ErrorMessage), toProtoError(analysisErr))
+}
+
+// withDetail attaches detail to st, falling back to st alone if it cannot.
+func withDetail(st *status.Status, detail *analyzerpb.AnalysisError) error {
+	if withDetails, err := st.WithDetails(detail); err == nil {
+		return withDetails.Err()
+	}
+	return st.Err()
+}
+
+// statusCode returns the gRPC code of a failed analysis. Like the REST API's
+// status, it follows the error's code rather than the page's own status,
+// since a 404 from the page does not mean the RPC's target was not found.
+func statusCode(e *analyzer.AnalysisError) codes.Code {
+	switch e.Code {
+	case analyzer.ErrorCodeInvalidRequest:
+		return codes.InvalidArgument
+	case client.CodeInvalidURL, client.CodeUnsupportedProtocol, client.CodeSchemeNotAllowed, client.CodeRedirectDowngrade,
+		client.CodeDNSFailure, client.CodeBodyTooLarge, client.CodeUnsupportedEncoding, analyzer.ErrorCodeParseFailure:
+		return codes.FailedPrecondition
+	case client.CodeTimeout:
+		return codes.DeadlineExceeded
+	case analyzer.ErrorCodeCanceled:
+		return codes.Canceled
+	case client.CodeHostSkipped:
+		return codes.Unavailable
+	case analyzer.ErrorCodeUpstreamStatus:
+		switch {
+		case e.StatusCode == http.StatusRequestTimeout:
+			return codes.DeadlineExceeded
+		case e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests:
+			return codes.Unavailable
+		default:
+			return codes.FailedPrecondition
+		}
+	case analyzer.ErrorCodeInternal:
+		return codes.Internal
+	default:
+		// The page's server could not be reached or failed mid-response.
+		return codes.Unavailable
+	}
+}
+
+// toProtoAnalysis converts an analysis result to its protobuf form.
+func toProtoAnalysis(a *analyzer.WebpageAnalysis) *analyzerpb.Analysis {
+	headings := make(map[string]int32, len(a.Headings))
+	for level, count := range a.Headings {
+		headings[level] = int32(count)
+	}
+
+	pa := &analyzerpb.Analysis{
+		Id:                a.ID,
+		Url:               a.URL,
+		HtmlVersion:       a.HTMLVersion,
+		PageTitle:         a.PageTitle,
+		Headings:          headings,
+		InternalLinks:     int32(a.InternalLinks),
+		ExternalLinks:     int32(a.ExternalLinks),
+		InaccessibleLinks: int32(a.InaccessibleLinks),
+		HasLoginForm:      a.HasLoginForm,
+		AnalyzedAt:        timestamppb.New(a.AnalyzedAt),
+		ProcessingTime:    a.ProcessingTime,
+		Modules:           a.Modules,
+		ContentHash:       a.ContentHash,
+		Simhash:           a.SimHash,
+	}
+	for _, f := range a.Findings {
+		pa.Findings = append(pa.Findings, &analyzerpb.Finding{
+			Type:     f.Type,
+			Severity: string(f.Severity),
+			Message:  f.Message,
+			Evidence: f.Evidence,
+		})
+	}
+	for _, w := range a.Warnings {
+		pa.Warnings = append(pa.Warnings, &analyzerpb.Warning{Module: w.Module, Code: w.Code, Message: w.Message})
+	}
+	if a.Cache != nil {
+		pa.Cache = &analyzerpb.CacheInfo{Hit: a.Cache.Hit, Age: a.Cache.Age}
+	}
+	return pa
+}
+
+// toProtoError converts an AnalysisError to its protobuf form.
+func toProtoError(e *analyzer.AnalysisError) *analyzerpb.AnalysisError {
+	code := e.Code
+	if code == "" {
+		code = analyzer.ErrorCodeInternal
+	}
+	return &analyzerpb.AnalysisError{
+		StatusCode:   int32(e.StatusCode),
+		ErrorMessage: e.ErrorMessage,
+		Url:          e.URL,
+		Code:         code,
+		RetryAfter:   int32(e.RetryAfter),
+		Redirects:    e.Redirects,
+	}
+}
@@ -0,0 +1,259 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/grpc/analyzerpb"
+)
+
+// mockAnalyzerService returns an analysis for known URLs and a 404 AnalysisError otherwise.
+type mockAnalyzerService struct {
+	analyzer.Service
+	pages map[string]string // url -> title
+
+	mu   sync.Mutex
+	last analyzer.AnalysisRequest
+}
+
+func (m *mockAnalyzerService) AnalyzeWebpage(ctx context.Context, req analyzer.AnalysisRequest) (*analyzer.WebpageAnalysis, error) {
+	m.mu.Lock()
+	m.last = req
+	m.mu.Unlock()
+	title, ok := m.pages[req.URL]
+	if !ok {
+		return nil, &analyzer.AnalysisError{StatusCode: 404, Code: analyzer.ErrorCodeUpstreamStatus, ErrorMessage: "Not Found", URL: req.URL}
+	}
+	return &analyzer.WebpageAnalysis{
+		URL:           req.URL,
+		PageTitle:     title,
+		Headings:      map[string]int{"h1": 2},
+		InternalLinks: 3,
+	}, nil
+}
+
+func (m *mockAnalyzerService) GetAnalysisStatus(ctx context.Context) (string, error) {
+	return "operational", nil
+}
+
+// newTestClient starts an in-memory gRPC server and returns a client connected to it.
+func newTestClient(t *testing.T) analyzerpb.AnalyzerServiceClient {
+	t.Helper()
+
+	return newTestClientFor(t, &mockAnalyzerService{pages: map[string]string{
+		"https://a.example.com": "A",
+		"https://b.example.com": "B",
+	}})
+}
+
+// newTestClientFor is newTestClient serving svc with the given server options.
+func newTestClientFor(t *testing.T, svc analyzer.Service, opts ...gogrpc.ServerOption) analyzerpb.AnalyzerServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	server := Register(svc, opts...)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := gogrpc.NewClient("passthrough:///bufnet",
+		gogrpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		gogrpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return analyzerpb.NewAnalyzerServiceClient(conn)
+}
+
+func TestAnalyze(t *testing.T) {
+	client := newTestClient(t)
+
+	resp, err := client.Analyze(context.Background(), &analyzerpb.AnalyzeRequest{Url: "https://a.example.com"})
+
+	require.NoError(t, err, "Analyze() should not return error")
+	assert.Equal(t, "A", resp.GetPageTitle())
+	assert.Equal(t, int32(2), resp.GetHeadings()["h1"])
+	assert.Equal(t, int32(3), resp.GetInternalLinks())
+}
+
+func TestAnalyze_Errors(t *testing.T) {
+	client := newTestClient(t)
+
+	_, err := client.Analyze(context.Background(), &analyzerpb.AnalyzeRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "Missing URL should be rejected")
+
+	_, err = client.Analyze(context.Background(), &analyzerpb.AnalyzeRequest{Url: "https://missing.example.com"})
+	st := status.Convert(err)
+	assert.Equal(t, codes.FailedPrecondition, st.Code(), "A page's 404 is not the RPC's target missing")
+	require.Len(t, st.Details(), 1, "The AnalysisError should be attached as a detail")
+	detail, ok := st.Details()[0].(*analyzerpb.AnalysisError)
+	require.True(t, ok)
+	assert.Equal(t, int32(404), detail.GetStatusCode())
+	assert.Equal(t, analyzer.ErrorCodeUpstreamStatus, detail.GetCode(), "The problem code should be machine-readable")
+}
+
+func TestAnalyze_RequestFields(t *testing.T) {
+	svc := &mockAnalyzerService{pages: map[string]string{"https://a.example.com": "A"}}
+	client := newTestClientFor(t, svc)
+
+	options, err := structpb.NewStruct(map[string]interface{}{"max_links": 10})
+	require.NoError(t, err)
+	_, err = client.Analyze(context.Background(), &analyzerpb.AnalyzeRequest{
+		Url:            "https://a.example.com",
+		Options:        map[string]*structpb.Struct{"links": options},
+		FetchTimeout:   "5s",
+		TotalTimeout:   "20s",
+		AcceptLanguage: "de",
+	})
+	require.NoError(t, err)
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	assert.Equal(t, analyzer.ModuleOptions{"max_links": float64(10)}, svc.last.Options["links"])
+	assert.Equal(t, "5s", svc.last.FetchTimeout)
+	assert.Equal(t, "20s", svc.last.TotalTimeout)
+	assert.Equal(t, "de", svc.last.AcceptLanguage)
+}
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		err  analyzer.AnalysisError
+		want codes.Code
+	}{
+		{analyzer.AnalysisError{Code: analyzer.ErrorCodeInvalidRequest}, codes.InvalidArgument},
+		{analyzer.AnalysisError{Code: client.CodeDNSFailure}, codes.FailedPrecondition},
+		{analyzer.AnalysisError{Code: client.CodeTimeout}, codes.DeadlineExceeded},
+		{analyzer.AnalysisError{Code: client.CodeHostSkipped}, codes.Unavailable},
+		{analyzer.AnalysisError{Code: analyzer.ErrorCodeCanceled}, codes.Canceled},
+		{analyzer.AnalysisError{Code: analyzer.ErrorCodeUpstreamStatus, StatusCode: 408}, codes.DeadlineExceeded},
+		{analyzer.AnalysisError{Code: analyzer.ErrorCodeUpstreamStatus, StatusCode: 503}, codes.Unavailable},
+		{analyzer.AnalysisError{Code: analyzer.ErrorCodeUpstreamStatus, StatusCode: 403}, codes.FailedPrecondition},
+		{analyzer.AnalysisError{Code: analyzer.ErrorCodeInternal}, codes.Internal},
+		{analyzer.AnalysisError{}, codes.Unavailable},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, statusCode(&tt.err), "code %q, status %d", tt.err.Code, tt.err.StatusCode)
+	}
+}
+
+func TestAnalyzeBatch(t *testing.T) {
+	client := newTestClient(t)
+
+	resp, err := client.AnalyzeBatch(context.Background(), &analyzerpb.AnalyzeBatchRequest{
+		Urls: []string{"https://a.example.com", "https://missing.example.com", "https://b.example.com"},
+	})
+
+	require.NoError(t, err, "AnalyzeBatch() should not fail because one URL fails")
+	require.Len(t, resp.GetResults(), 3)
+	assert.Equal(t, "A", resp.GetResults()[0].GetAnalysis().GetPageTitle(), "Results should keep request order")
+	assert.Equal(t, int32(404), resp.GetResults()[1].GetError().GetStatusCode())
+	assert.Equal(t, "B", resp.GetResults()[2].GetAnalysis().GetPageTitle())
+}
+
+func TestAnalyzeBatch_RequestFields(t *testing.T) {
+	svc := &mockAnalyzerService{pages: map[string]string{"https://a.example.com": "A"}}
+	client := newTestClientFor(t, svc)
+
+	options, err := structpb.NewStruct(map[string]interface{}{"max_links": 10})
+	require.NoError(t, err)
+	_, err = client.AnalyzeBatch(context.Background(), &analyzerpb.AnalyzeBatchRequest{
+		Urls:           []string{"https://a.example.com"},
+		Modules:        []string{"links"},
+		ForceRefresh:   true,
+		Options:        map[string]*structpb.Struct{"links": options},
+		FetchTimeout:   "5s",
+		TotalTimeout:   "20s",
+		AcceptLanguage: "de",
+	})
+	require.NoError(t, err)
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	assert.Equal(t, []string{"links"}, svc.last.Modules)
+	assert.True(t, svc.last.ForceRefresh)
+	assert.Equal(t, analyzer.ModuleOptions{"max_links": float64(10)}, svc.last.Options["links"])
+	assert.Equal(t, "5s", svc.last.FetchTimeout)
+	assert.Equal(t, "20s", svc.last.TotalTimeout)
+	assert.Equal(t, "de", svc.last.AcceptLanguage)
+}
+
+func TestRunBatch_Cancelled(t *testing.T) {
+	server := NewServer(&mockAnalyzerService{pages: map[string]string{"https://a.example.com": "A"}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	reqs := make([]analyzer.AnalysisRequest, batchConcurrency+2)
+	for i := range reqs {
+		reqs[i].URL = "https://a.example.com"
+	}
+	var mu sync.Mutex
+	results := make([]*analyzerpb.BatchItem, len(reqs))
+	server.runBatch(ctx, reqs, func(index int, item *analyzerpb.BatchItem) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[index] = item
+	})
+
+	for i, item := range results {
+		require.NotNil(t, item, "Item %d should be reported", i)
+		assert.Nil(t, item.GetAnalysis(), "Item %d should not be analyzed once the batch is cancelled", i)
+		assert.Equal(t, analyzer.ErrorCodeCanceled, item.GetError().GetCode())
+	}
+}
+
+func TestAnalyzeBatch_Limits(t *testing.T) {
+	client := newTestClient(t)
+
+	_, err := client.AnalyzeBatch(context.Background(), &analyzerpb.AnalyzeBatchRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "Empty batches should be rejected")
+
+	_, err = client.AnalyzeBatch(context.Background(), &analyzerpb.AnalyzeBatchRequest{Urls: make([]string, maxBatchSize+1)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "Oversized batches should be rejected")
+}
+
+func TestAnalyzeBatchStream(t *testing.T) {
+	client := newTestClient(t)
+
+	stream, err := client.AnalyzeBatchStream(context.Background(), &analyzerpb.AnalyzeBatchRequest{
+		Urls: []string{"https://a.example.com", "https://b.example.com"},
+	})
+	require.NoError(t, err)
+
+	var updates []*analyzerpb.BatchProgress
+	for {
+		update, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		updates = append(updates, update)
+	}
+
+	require.Len(t, updates, 2, "One progress update per URL")
+	assert.Equal(t, int32(1), updates[0].GetCompleted())
+	assert.Equal(t, int32(2), updates[1].GetCompleted())
+	assert.Equal(t, int32(2), updates[1].GetTotal())
+}
+
+func TestGetStatus(t *testing.T) {
+	client := newTestClient(t)
+
+	resp, err := client.GetStatus(context.Background(), &analyzerpb.GetStatusRequest{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "operational", resp.GetStatus())
+}
@@ -0,0 +1,186 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"webpage-analyzer/internal/openapi"
+	"webpage-analyzer/internal/problem"
+)
+
+// maxLogLevelDuration bounds how long a temporary log level may stay set.
+const maxLogLevelDuration = 24 * time.Hour
+
+// RequireAdminToken lets only requests carrying "Authorization: Bearer
+// <token>" through to an admin endpoint.
+func RequireAdminToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hasBearerToken(r, token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			problem.Error(w, "A valid admin token is required", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasBearerToken reports whether r carries "Authorization: Bearer <token>".
+func hasBearerToken(r *http.Request, token string) bool {
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+// LogLevelRequest sets the server's log level, for a while or until changed again.
+// @Description New log level of the server
+type LogLevelRequest struct {
+	Level    string `json:"level" example:"debug"`            // debug, info, warn or error.
+	Duration string `json:"duration,omitempty" example:"15m"` // Go duration after which the previous level returns; empty keeps the level.
+}
+
+// LogLevelResponse reports the server's log level.
+// @Description Current log level of the server
+type LogLevelResponse struct {
+	Level     string     `json:"level" example:"debug"`
+	RevertsTo string     `json:"reverts_to,omitempty" example:"info"` // Set while a temporary level is active.
+	RevertsAt *time.Time `json:"reverts_at,omitempty" example:"2024-01-15T10:45:00Z"`
+}
+
+// LogLevel changes the level of a slog.LevelVar at runtime.
+type LogLevel struct {
+	level *slog.LevelVar
+
+	mu       sync.Mutex
+	revert   *time.Timer // Restores revertTo at revertAt; nil unless a temporary level is set.
+	revertTo slog.Level
+	revertAt time.Time
+}
+
+// NewLogLevel returns a LogLevel that changes level.
+func NewLogLevel(level *slog.LevelVar) *LogLevel {
+	return &LogLevel{level: level}
+}
+
+// LogLevelDocs document the log level handler for the OpenAPI spec.
+var LogLevelDocs = []openapi.Operation{
+	{
+		Method:      http.MethodGet,
+		Summary:     "Get the log level",
+		Description: "Report the server's log level and, while a temporary level is set, when it reverts. Requires the admin token.",
+		Tags:        []string{"Admin"},
+		Security:    []string{AdminTokenScheme},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Body: LogLevelResponse{}},
+			failure(http.StatusUnauthorized),
+		},
+	},
+	{
+		Method:      http.MethodPut,
+		Summary:     "Set the log level",
+		Description: "Change the server's log level without a restart. With a duration, the previous level returns once it has passed. Requires the admin token.",
+		Tags:        []string{"Admin"},
+		Security:    []string{AdminTokenScheme},
+		Request:     openapi.JSONRequest(LogLevelRequest{}),
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Body: LogLevelResponse{}},
+			failure(http.StatusBadRequest),
+			failure(http.StatusUnauthorized),
+		},
+	},
+}
+
+// Handler serves the log level: GET reports it and PUT changes it.
+func (l *LogLevel) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var req LogLevelRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				problem.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			if err := l.set(req); err != nil {
+				problem.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			problem.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, l.current())
+	}
+}
+
+// set applies a log level request.
+func (l *LogLevel) set(req LogLevelRequest) error {
+	level, err := parseLogLevel(req.Level)
+	if err != nil {
+		return err
+	}
+	var duration time.Duration
+	if req.Duration != "" {
+		duration, err = time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 || duration > maxLogLevelDuration {
+			return fmt.Errorf("duration must be a positive Go duration of at most %s", maxLogLevelDuration)
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	previous := l.level.Level()
+	if l.revert != nil {
+		// A level set on top of a temporary one reverts to the lasting level.
+		l.revert.Stop()
+		l.revert, previous = nil, l.revertTo
+	}
+	l.level.Set(level)
+	if duration > 0 {
+		l.revertTo, l.revertAt = previous, time.Now().Add(duration)
+		var timer *time.Timer
+		timer = time.AfterFunc(duration, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.revert == timer {
+				l.level.Set(l.revertTo)
+				l.revert = nil
+				slog.Info("Log level reverted", "level", l.revertTo.String())
+			}
+		})
+		l.revert = timer
+	}
+	slog.Warn("Log level changed", "level", level.String(), "duration", req.Duration)
+	return nil
+}
+
+// current reports the log level and any pending revert.
+func (l *LogLevel) current() LogLevelResponse {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	resp := LogLevelResponse{Level: strings.ToLower(l.level.Level().String())}
+	if l.revert != nil {
+		revertAt := l.revertAt
+		resp.RevertsTo, resp.RevertsAt = strings.ToLower(l.revertTo.String()), &revertAt
+	}
+	return resp
+}
+
+// parseLogLevel parses one of the four named log levels.
+func parseLogLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("level must be debug, info, warn or error, got %q", name)
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireAdminToken(t *testing.T) {
+	handler := RequireAdminToken("secret", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name          string
+		authorization string
+		expected      int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer guess", http.StatusUnauthorized},
+		{"not a bearer token", "secret", http.StatusUnauthorized},
+		{"valid token", "Bearer secret", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/admin/loglevel", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Code)
+			if tt.expected == http.StatusUnauthorized {
+				assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	level := new(slog.LevelVar)
+	handler := NewLogLevel(level).Handler()
+	serve := func(method, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(method, "/api/admin/loglevel", strings.NewReader(body)))
+		return w
+	}
+
+	w := serve("GET", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"level": "info"}`, w.Body.String())
+
+	w = serve("PUT", `{"level": "debug"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"level": "debug"}`, w.Body.String())
+	assert.Equal(t, slog.LevelDebug, level.Level())
+
+	for _, body := range []string{`{"level": "verbose"}`, `{"level": "warn", "duration": "forever"}`, `{"level": "warn", "duration": "48h"}`, `not json`} {
+		assert.Equal(t, http.StatusBadRequest, serve("PUT", body).Code, body)
+	}
+	assert.Equal(t, slog.LevelDebug, level.Level(), "A rejected request should not change the level")
+
+	assert.Equal(t, http.StatusMethodNotAllowed, serve("POST", `{"level": "info"}`).Code)
+}
+
+func TestLogLevelTemporary(t *testing.T) {
+	level := new(slog.LevelVar)
+	logLevel := NewLogLevel(level)
+
+	require.NoError(t, logLevel.set(LogLevelRequest{Level: "error", Duration: "1h"}))
+	// A second temporary level reverts to the lasting level, not the first one.
+	require.NoError(t, logLevel.set(LogLevelRequest{Level: "debug", Duration: "20ms"}))
+
+	current := logLevel.current()
+	assert.Equal(t, "debug", current.Level)
+	assert.Equal(t, "info", current.RevertsTo)
+	require.NotNil(t, current.RevertsAt)
+
+	require.Eventually(t, func() bool { return level.Level() == slog.LevelInfo }, time.Second, 5*time.Millisecond)
+	assert.Nil(t, logLevel.current().RevertsAt, "No revert should be pending once the level returned")
+}
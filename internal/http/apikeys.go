@@ -0,0 +1,387 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"webpage-analyzer/internal/apikeys"
+	"webpage-analyzer/internal/logging"
+	"webpage-analyzer/internal/openapi"
+	"webpage-analyzer/internal/problem"
+)
+
+const (
+	// apiKeyHeader carries an API key for clients that cannot set Authorization.
+	apiKeyHeader = "X-API-Key"
+	// defaultUsageDays is the period the usage report covers by default.
+	defaultUsageDays = 30
+	// maxUsageDays bounds the period of one usage report.
+	maxUsageDays = 366
+)
+
+// APIKeyFromContext returns the API key a request was authenticated with, if any.
+func APIKeyFromContext(ctx context.Context) *apikeys.Key {
+	return apikeys.KeyFromContext(ctx)
+}
+
+// APIKeys authenticates requests by the API keys of tenants and enforces
+// each key's limits.
+type APIKeys struct {
+	store      apikeys.Store
+	meter      *apikeys.Meter
+	adminToken string
+	now        func() time.Time
+}
+
+// NewAPIKeys returns API key middleware backed by store, enforcing limits
+// with meter, which the other APIs share. Requests carrying adminToken, if
+// set, may read the usage of every tenant.
+func NewAPIKeys(store apikeys.Store, meter *apikeys.Meter, adminToken string) *APIKeys {
+	return &APIKeys{
+		store:      store,
+		meter:      meter,
+		adminToken: adminToken,
+		now:        time.Now,
+	}
+}
+
+// AuthenticateFailures and MeterFailures document the responses Authenticate
+// and Meter add to the operations of the routes they wrap.
+var (
+	AuthenticateFailures = []openapi.Response{
+		describedFailure(http.StatusUnauthorized, "The request has no valid API key."),
+	}
+	MeterFailures = []openapi.Response{
+		describedFailure(http.StatusTooManyRequests, "The API key's daily quota is used up (code quota_exceeded) or too many of its analyses are running (code concurrency_exceeded)."),
+	}
+)
+
+// Authenticate lets through only requests carrying a valid API key, as
+// "Authorization: Bearer <key>" or in the X-API-Key header. Their context
+// carries the key, and their log lines its tenant.
+func (k *APIKeys) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret := r.Header.Get(apiKeyHeader)
+		if secret == "" {
+			secret, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		if secret == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			problem.Error(w, "An API key is required", http.StatusUnauthorized)
+			return
+		}
+		key, err := k.store.Authenticate(r.Context(), secret)
+		if errors.Is(err, apikeys.ErrInvalidKey) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			problem.Error(w, "Invalid or revoked API key", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Failed to authenticate API key", "error", err)
+			problem.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		ctx, _ := logging.With(r.Context(), "tenant", key.Tenant, "api_key", key.ID)
+		next.ServeHTTP(w, r.WithContext(apikeys.WithKey(ctx, key)))
+	})
+}
+
+// Meter runs next as one analysis of the request's API key: within the key's
+// concurrency limit and daily quota, answering 429 otherwise, and adding the
+// bytes it fetched to the key's usage. Requests without a key pass through.
+func (k *APIKeys) Meter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := APIKeyFromContext(r.Context())
+		if key == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, end, err := k.meter.Begin(r.Context(), key)
+		if err != nil {
+			k.writeLimitError(w, r, err)
+			return
+		}
+		defer end()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// writeLimitError answers a request that key's limits turned away, or that
+// could not be counted.
+func (k *APIKeys) writeLimitError(w http.ResponseWriter, r *http.Request, err error) {
+	var limitErr *apikeys.LimitError
+	if !errors.As(err, &limitErr) {
+		logging.FromContext(r.Context()).Error("Failed to count analysis", "error", err)
+		problem.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(limitErr.RetryAfterSeconds()))
+	problem.Write(w, problem.New(http.StatusTooManyRequests, limitErr.Code, limitErr.Message))
+}
+
+// CreatedAPIKey is an API key together with its secret, which is shown only once.
+// @Description New API key and its secret
+type CreatedAPIKey struct {
+	Key    *apikeys.Key `json:"key"`
+	Secret string       `json:"secret" example:"wpa_3b1f0c9e2d4a6b8c0e1f3a5b7c9d1e3f5a7b9c1d3e5f7a9b"`
+}
+
+// APIKeysDocs document KeysHandler for the OpenAPI spec.
+var APIKeysDocs = []openapi.Operation{
+	{
+		Method:      http.MethodGet,
+		Summary:     "List API keys",
+		Description: "List the API keys, optionally of one tenant. Requires the admin token.",
+		Tags:        []string{"Admin"},
+		Security:    []string{AdminTokenScheme},
+		Params: []openapi.Param{
+			{Name: "tenant", In: "query", Description: "Only list this tenant's keys"},
+		},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Body: []apikeys.Key{}},
+			failure(http.StatusUnauthorized),
+		},
+	},
+	{
+		Method:      http.MethodPost,
+		Summary:     "Create an API key",
+		Description: "Create a key and return its secret, which cannot be retrieved again. Requires the admin token.",
+		Tags:        []string{"Admin"},
+		Security:    []string{AdminTokenScheme},
+		Request:     openapi.JSONRequest(apikeys.Spec{}),
+		Responses: []openapi.Response{
+			{Status: http.StatusCreated, Body: CreatedAPIKey{}},
+			failure(http.StatusBadRequest),
+			failure(http.StatusUnauthorized),
+		},
+	},
+}
+
+// KeysHandler lists and creates API keys.
+func (k *APIKeys) KeysHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			keys, err := k.store.List(r.Context(), r.URL.Query().Get("tenant"))
+			if err != nil {
+				logging.FromContext(r.Context()).Error("Failed to list API keys", "error", err)
+				problem.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, keys)
+		case http.MethodPost:
+			var spec apikeys.Spec
+			if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+				problem.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			if err := spec.Validate(); err != nil {
+				problem.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			key, secret, err := k.store.Create(r.Context(), spec)
+			if err != nil {
+				logging.FromContext(r.Context()).Error("Failed to create API key", "tenant", spec.Tenant, "error", err)
+				problem.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			logging.FromContext(r.Context()).Info("API key created", "tenant", key.Tenant, "api_key", key.ID)
+			writeJSON(w, http.StatusCreated, CreatedAPIKey{Key: key, Secret: secret})
+		default:
+			problem.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// APIKeyDocs document KeyHandler for the OpenAPI spec.
+var APIKeyDocs = []openapi.Operation{
+	{
+		Method:      http.MethodGet,
+		Summary:     "Get an API key",
+		Description: "Show an API key. Requires the admin token.",
+		Tags:        []string{"Admin"},
+		Security:    []string{AdminTokenScheme},
+		Params:      []openapi.Param{{Name: "id", In: "path", Description: "Key ID"}},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Body: apikeys.Key{}},
+			failure(http.StatusUnauthorized),
+			failure(http.StatusNotFound),
+		},
+	},
+	{
+		Method:      http.MethodDelete,
+		Summary:     "Revoke an API key",
+		Description: "Revoke an API key; requests with it are refused from then on. Requires the admin token.",
+		Tags:        []string{"Admin"},
+		Security:    []string{AdminTokenScheme},
+		Params:      []openapi.Param{{Name: "id", In: "path", Description: "Key ID"}},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Body: apikeys.Key{}},
+			failure(http.StatusUnauthorized),
+			failure(http.StatusNotFound),
+		},
+	},
+}
+
+// KeyHandler shows and revokes one API key.
+func (k *APIKeys) KeyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			key *apikeys.Key
+			err error
+		)
+		switch r.Method {
+		case http.MethodGet:
+			key, err = k.store.Get(r.Context(), r.PathValue("id"))
+		case http.MethodDelete:
+			key, err = k.store.Revoke(r.Context(), r.PathValue("id"))
+			if err == nil {
+				logging.FromContext(r.Context()).Info("API key revoked", "tenant", key.Tenant, "api_key", key.ID)
+			}
+		default:
+			problem.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if errors.Is(err, apikeys.ErrNotFound) {
+			problem.Error(w, "API key not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Failed to load API key", "id", r.PathValue("id"), "error", err)
+			problem.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, key)
+	}
+}
+
+// UsageReport is the usage of one or more tenants over a period of UTC days.
+// @Description Analyses and bytes fetched per tenant
+type UsageReport struct {
+	From    string        `json:"from" example:"2024-01-01"`
+	To      string        `json:"to" example:"2024-01-30"`
+	Tenants []TenantUsage `json:"tenants"`
+}
+
+// TenantUsage is the usage of one tenant, in total and per day with any usage.
+// @Description Usage of one tenant
+type TenantUsage struct {
+	Tenant       string     `json:"tenant" example:"acme"`
+	Analyses     int64      `json:"analyses" example:"412"`
+	BytesFetched int64      `json:"bytes_fetched" example:"91234567"`
+	Days         []DayUsage `json:"days"`
+}
+
+// DayUsage is the usage of one tenant on one UTC day.
+// @Description Usage of one tenant on one day
+type DayUsage struct {
+	Day          string `json:"day" example:"2024-01-15"`
+	Analyses     int64  `json:"analyses" example:"37"`
+	BytesFetched int64  `json:"bytes_fetched" example:"8123456"`
+}
+
+// UsageDoc documents UsageHandler for the OpenAPI spec.
+var UsageDoc = openapi.Operation{
+	Method:      http.MethodGet,
+	Summary:     "Usage per tenant",
+	Description: "Report the analyses run and bytes fetched per tenant and UTC day, between from and to (default: the last 30 days). With an API key, only that key's tenant is reported; with the admin token, every tenant or the one given.",
+	Tags:        []string{"Usage"},
+	Security:    []string{AdminTokenScheme, APIKeyScheme},
+	Params: []openapi.Param{
+		{Name: "from", In: "query", Description: "First day (YYYY-MM-DD)"},
+		{Name: "to", In: "query", Description: "Last day (YYYY-MM-DD)"},
+		{Name: "tenant", In: "query", Description: "Tenant to report (admin token only)"},
+	},
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Body: UsageReport{}},
+		failure(http.StatusBadRequest),
+		failure(http.StatusUnauthorized),
+	},
+}
+
+// UsageHandler reports analyses and bytes fetched per tenant. A request with
+// an API key sees its own tenant; one with the admin token sees every tenant,
+// or the one named by the tenant parameter.
+func (k *APIKeys) UsageHandler() http.Handler {
+	report := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			problem.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		q, err := k.usageQuery(r)
+		if err != nil {
+			problem.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if key := APIKeyFromContext(r.Context()); key != nil {
+			q.Tenant = key.Tenant
+		}
+		usage, err := k.store.Usage(r.Context(), q)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Failed to load usage", "error", err)
+			problem.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, newUsageReport(q, usage))
+	})
+	authenticated := k.Authenticate(report)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if k.adminToken != "" && hasBearerToken(r, k.adminToken) {
+			report.ServeHTTP(w, r)
+			return
+		}
+		authenticated.ServeHTTP(w, r)
+	})
+}
+
+// usageQuery reads the period and tenant of a usage request.
+func (k *APIKeys) usageQuery(r *http.Request) (apikeys.UsageQuery, error) {
+	params := r.URL.Query()
+	to := k.now().UTC().Truncate(24 * time.Hour)
+	if s := params.Get("to"); s != "" {
+		t, err := time.Parse(apikeys.DayFormat, s)
+		if err != nil {
+			return apikeys.UsageQuery{}, errors.New("to must be a date like 2024-01-15")
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -(defaultUsageDays - 1))
+	if s := params.Get("from"); s != "" {
+		t, err := time.Parse(apikeys.DayFormat, s)
+		if err != nil {
+			return apikeys.UsageQuery{}, errors.New("from must be a date like 2024-01-15")
+		}
+		from = t
+	}
+	if from.After(to) || to.Sub(from) >= maxUsageDays*24*time.Hour {
+		return apikeys.UsageQuery{}, errors.New("from must not be after to, nor more than 366 days before it")
+	}
+	return apikeys.UsageQuery{
+		Tenant: params.Get("tenant"),
+		From:   from.Format(apikeys.DayFormat),
+		To:     to.Format(apikeys.DayFormat),
+	}, nil
+}
+
+// newUsageReport groups usage rows, ordered by tenant, by tenant.
+func newUsageReport(q apikeys.UsageQuery, usage []apikeys.Usage) UsageReport {
+	report := UsageReport{From: q.From, To: q.To, Tenants: make([]TenantUsage, 0)}
+	for _, u := range usage {
+		if n := len(report.Tenants); n == 0 || report.Tenants[n-1].Tenant != u.Tenant {
+			report.Tenants = append(report.Tenants, TenantUsage{Tenant: u.Tenant})
+		}
+		tenant := &report.Tenants[len(report.Tenants)-1]
+		tenant.Analyses += u.Analyses
+		tenant.BytesFetched += u.BytesFetched
+		tenant.Days = append(tenant.Days, DayUsage{Day: u.Day, Analyses: u.Analyses, BytesFetched: u.BytesFetched})
+	}
+	return report
+}
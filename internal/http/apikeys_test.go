@@ -0,0 +1,227 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/apikeys"
+	"webpage-analyzer/internal/client"
+)
+
+// newTestAPIKeys returns API key middleware over an in-memory store, at a
+// fixed time.
+func newTestAPIKeys(t *testing.T) (*APIKeys, apikeys.Store) {
+	t.Helper()
+	store, err := apikeys.Open(context.Background(), apikeys.Config{Driver: apikeys.DriverMemory})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	now := func() time.Time { return time.Date(2024, 1, 15, 18, 0, 0, 0, time.UTC) }
+	keys := NewAPIKeys(store, apikeys.NewMeter(store, now), "admin-secret")
+	keys.now = now
+	return keys, store
+}
+
+func TestAPIKeys_Authenticate(t *testing.T) {
+	keys, store := newTestAPIKeys(t)
+	key, secret, err := store.Create(context.Background(), apikeys.Spec{Tenant: "acme"})
+	require.NoError(t, err)
+	revoked, revokedSecret, err := store.Create(context.Background(), apikeys.Spec{Tenant: "acme"})
+	require.NoError(t, err)
+	_, err = store.Revoke(context.Background(), revoked.ID)
+	require.NoError(t, err)
+
+	var seen *apikeys.Key
+	handler := keys.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = APIKeyFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		header   string
+		value    string
+		expected int
+	}{
+		{"no key", "", "", http.StatusUnauthorized},
+		{"unknown key", "Authorization", "Bearer wpa_unknown", http.StatusUnauthorized},
+		{"revoked key", "Authorization", "Bearer " + revokedSecret, http.StatusUnauthorized},
+		{"admin token", "Authorization", "Bearer admin-secret", http.StatusUnauthorized},
+		{"bearer key", "Authorization", "Bearer " + secret, http.StatusOK},
+		{"X-API-Key header", apiKeyHeader, secret, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = nil
+			req := httptest.NewRequest("POST", "/api/analyze", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Code)
+			if tt.expected == http.StatusOK {
+				require.NotNil(t, seen)
+				assert.Equal(t, key.ID, seen.ID)
+			} else {
+				assert.Nil(t, seen, "Unauthenticated requests should not reach the handler")
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestAPIKeys_MeterEnforcesDailyQuota(t *testing.T) {
+	keys, store := newTestAPIKeys(t)
+	_, secret, err := store.Create(context.Background(), apikeys.Spec{Tenant: "acme", DailyQuota: 2})
+	require.NoError(t, err)
+	handler := keys.Authenticate(keys.Meter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	analyze := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/analyze", nil)
+		req.Header.Set(apiKeyHeader, secret)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(t, http.StatusOK, analyze().Code)
+	assert.Equal(t, http.StatusOK, analyze().Code)
+
+	w := analyze()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"quota_exceeded"`)
+	assert.Equal(t, "21600", w.Header().Get("Retry-After"), "Clients should retry at the next UTC midnight")
+}
+
+func TestAPIKeys_MeterEnforcesConcurrency(t *testing.T) {
+	keys, store := newTestAPIKeys(t)
+	_, secret, err := store.Create(context.Background(), apikeys.Spec{Tenant: "acme", MaxConcurrent: 1})
+	require.NoError(t, err)
+
+	started, finish := make(chan struct{}), make(chan struct{})
+	handler := keys.Authenticate(keys.Meter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-finish
+	})))
+	analyze := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/analyze", nil)
+		req.Header.Set(apiKeyHeader, secret)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	first := make(chan int)
+	go func() { first <- analyze().Code }()
+	<-started
+
+	w := analyze()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "A second analysis at once should be refused")
+	assert.Contains(t, w.Body.String(), `"code":"concurrency_exceeded"`)
+
+	close(finish)
+	assert.Equal(t, http.StatusOK, <-first)
+	started = make(chan struct{})
+	assert.Equal(t, http.StatusOK, analyze().Code, "Finished analyses should give their slot back")
+}
+
+func TestAPIKeys_MeterCountsBytesFetched(t *testing.T) {
+	keys, store := newTestAPIKeys(t)
+	_, secret, err := store.Create(context.Background(), apikeys.Spec{Tenant: "acme"})
+	require.NoError(t, err)
+
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 1000)))
+	}))
+	defer page.Close()
+	handler := keys.Authenticate(keys.Meter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, err := client.NewHTTPClient().FetchWebpage(r.Context(), page.URL)
+		assert.NoError(t, err)
+	})))
+
+	req := httptest.NewRequest("POST", "/api/analyze", nil)
+	req.Header.Set(apiKeyHeader, secret)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	usage, err := store.Usage(context.Background(), apikeys.UsageQuery{Tenant: "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, []apikeys.Usage{{Tenant: "acme", Day: "2024-01-15", Analyses: 1, BytesFetched: 1000}}, usage)
+}
+
+func TestAPIKeys_UsageHandler(t *testing.T) {
+	keys, store := newTestAPIKeys(t)
+	ctx := context.Background()
+	acme, secret, err := store.Create(ctx, apikeys.Spec{Tenant: "acme"})
+	require.NoError(t, err)
+	globex, _, err := store.Create(ctx, apikeys.Spec{Tenant: "globex"})
+	require.NoError(t, err)
+	for _, day := range []string{"2024-01-14", "2024-01-15"} {
+		_, err := store.Reserve(ctx, acme, day)
+		require.NoError(t, err)
+		require.NoError(t, store.AddBytes(ctx, acme, day, 500))
+	}
+	_, err = store.Reserve(ctx, globex, "2024-01-15")
+	require.NoError(t, err)
+
+	handler := keys.UsageHandler()
+	report := func(query, token string) (int, UsageReport) {
+		req := httptest.NewRequest("GET", "/api/usage"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		var report UsageReport
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		}
+		return w.Code, report
+	}
+
+	status, own := report("?tenant=globex", secret)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, UsageReport{From: "2023-12-17", To: "2024-01-15", Tenants: []TenantUsage{{
+		Tenant: "acme", Analyses: 2, BytesFetched: 1000,
+		Days: []DayUsage{{Day: "2024-01-14", Analyses: 1, BytesFetched: 500}, {Day: "2024-01-15", Analyses: 1, BytesFetched: 500}},
+	}}}, own, "An API key should only see its own tenant")
+
+	status, all := report("?from=2024-01-15&to=2024-01-15", "admin-secret")
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, all.Tenants, 2, "The admin token should see every tenant")
+	assert.Equal(t, int64(1), all.Tenants[0].Analyses)
+	assert.Equal(t, "globex", all.Tenants[1].Tenant)
+
+	status, _ = report("", "wpa_unknown")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, _ = report("?from=2024-02-01&to=2024-01-01", "admin-secret")
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = report("?from=yesterday", "admin-secret")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestIdempotency_ScopedByTenant(t *testing.T) {
+	keys, store := newTestAPIKeys(t)
+	_, acme, err := store.Create(context.Background(), apikeys.Spec{Tenant: "acme"})
+	require.NoError(t, err)
+	_, globex, err := store.Create(context.Background(), apikeys.Spec{Tenant: "globex"})
+	require.NoError(t, err)
+
+	var calls atomic.Int64
+	handler := keys.Authenticate(NewIdempotency(DefaultIdempotencyConfig()).Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	})))
+	for _, secret := range []string{acme, globex} {
+		req := httptest.NewRequest("POST", "/api/analyze", strings.NewReader(`{"url": "https://example.com"}`))
+		req.Header.Set(apiKeyHeader, secret)
+		req.Header.Set(idempotencyKeyHeader, "same-key")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	assert.Equal(t, int64(2), calls.Load(), "The same Idempotency-Key of another tenant should not replay a response")
+}
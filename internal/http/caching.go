@@ -0,0 +1,81 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+// storedAnalysisCacheControl is sent with stored analyses, which never change.
+const storedAnalysisCacheControl = "private, max-age=31536000, immutable"
+
+// contentETag returns a weak entity tag derived from the JSON encoding of v.
+// It is weak because equal content may be encoded with different whitespace
+// or cache metadata.
+func contentETag(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// analysisETag tags an analysis by its content. The cache metadata is left
+// out so that a cached analysis keeps its tag as it ages.
+func analysisETag(analysis *analyzer.WebpageAnalysis) (string, error) {
+	untagged := *analysis
+	untagged.Cache = nil
+	return contentETag(&untagged)
+}
+
+// analysisCacheHeaders sets Cache-Control, and Age for cached analyses, so
+// that clients keep an analysis no longer than the server cache does.
+// Analyses that are not cached must be revalidated before reuse.
+func analysisCacheHeaders(w http.ResponseWriter, analysis *analyzer.WebpageAnalysis) {
+	if analysis.Cache == nil {
+		w.Header().Set("Cache-Control", "no-cache")
+		return
+	}
+	ttl, err := time.ParseDuration(analysis.Cache.TTL)
+	if err != nil {
+		w.Header().Set("Cache-Control", "no-cache")
+		return
+	}
+	age, _ := time.ParseDuration(analysis.Cache.Age)
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(ttl.Seconds())))
+	w.Header().Set("Age", strconv.Itoa(int(age.Seconds())))
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag,
+// using the weak comparison that RFC 9110 requires for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTagged writes data with the given ETag, or 304 Not Modified when the
+// request's If-None-Match already names it. Cache headers must be set first.
+func (h *Handler) writeTagged(w http.ResponseWriter, r *http.Request, etag string, data interface{}) {
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, data)
+}
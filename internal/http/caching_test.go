@@ -0,0 +1,90 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+// analyze posts an analysis request, optionally with If-None-Match.
+func analyze(handler *Handler, ifNoneMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/api/analyze", bytes.NewBufferString(`{"url": "https://example.com"}`))
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	w := httptest.NewRecorder()
+	handler.AnalyzeWebpage(w, req)
+	return w
+}
+
+func TestAnalyzeWebpage_ETag(t *testing.T) {
+	svc := &mockAnalyzerService{analysisResult: &analyzer.WebpageAnalysis{
+		URL:       "https://example.com",
+		PageTitle: "Example",
+		Cache:     &analyzer.CacheInfo{Hit: true, Age: "2m0s", TTL: "5m0s"},
+	}}
+	handler := NewHandler(svc)
+
+	first := analyze(handler, "")
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	assert.Regexp(t, `^W/"[0-9a-f]{32}"$`, etag)
+	assert.Equal(t, "private, max-age=300", first.Header().Get("Cache-Control"))
+	assert.Equal(t, "120", first.Header().Get("Age"))
+
+	// The tag ignores the cache metadata, which changes as the entry ages.
+	svc.analysisResult.Cache = &analyzer.CacheInfo{Hit: true, Age: "3m0s", TTL: "5m0s"}
+	notModified := analyze(handler, etag)
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.String())
+	assert.Equal(t, etag, notModified.Header().Get("ETag"))
+
+	svc.analysisResult.PageTitle = "Changed"
+	changed := analyze(handler, etag)
+	assert.Equal(t, http.StatusOK, changed.Code)
+	assert.NotEqual(t, etag, changed.Header().Get("ETag"))
+}
+
+func TestAnalyzeWebpage_UncachedMustRevalidate(t *testing.T) {
+	handler := NewHandler(&mockAnalyzerService{analysisResult: &analyzer.WebpageAnalysis{URL: "https://example.com"}})
+
+	w := analyze(handler, "")
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	assert.Empty(t, w.Header().Get("Age"))
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+}
+
+func TestGetAnalysis_ETag(t *testing.T) {
+	mux, st := newHistoryHandler(t)
+	rec, err := st.Save(context.Background(), &analyzer.WebpageAnalysis{URL: "https://example.com"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/api/analyses/"+rec.ID, nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, storedAnalysisCacheControl, w.Header().Get("Cache-Control"))
+
+	req = httptest.NewRequest("GET", "/api/analyses/"+rec.ID, nil)
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+}
+
+func TestETagMatches(t *testing.T) {
+	etag := `W/"abc"`
+	assert.True(t, etagMatches(`W/"abc"`, etag))
+	assert.True(t, etagMatches(`"abc"`, etag), "If-None-Match uses weak comparison")
+	assert.True(t, etagMatches(`"xyz", W/"abc"`, etag))
+	assert.True(t, etagMatches(`*`, etag))
+	assert.False(t, etagMatches(`"xyz"`, etag))
+	assert.False(t, etagMatches(``, etag))
+}
@@ -2,21 +2,38 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"mime"
 	"net/http"
-	"os"
+	"strings"
 	"time"
 
 	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/jobs"
+	"webpage-analyzer/internal/logging"
+	"webpage-analyzer/internal/openapi"
+	"webpage-analyzer/internal/problem"
+	"webpage-analyzer/internal/store"
 )
 
 const (
-	openAPIFilePath = "api/swagger.yaml"
+	// WriteTimeout is the server's write timeout. Analyses whose time budget
+	// is longer extend it for their own response; see allowBudget.
+	WriteTimeout = 15 * time.Second
+	// budgetWriteMargin is the time left to write a response once the
+	// analysis's budget has run out.
+	budgetWriteMargin = 5 * time.Second
 )
 
 // Handler handles HTTP requests for the webpage analyzer.
 type Handler struct {
 	analyzerService analyzer.Service
+	historyStore    store.Store // Optional; history endpoints are disabled when nil.
+	jobQueue        jobs.Queue  // Optional; background job endpoints are disabled when nil.
 }
 
 // NewHandler creates a new HTTP handler.
@@ -26,69 +43,180 @@ func NewHandler(analyzerService analyzer.Service) *Handler {
 	}
 }
 
+// NewHandlerWithStore creates a new HTTP handler that also serves analysis history.
+func NewHandlerWithStore(analyzerService analyzer.Service, historyStore store.Store) *Handler {
+	return &Handler{
+		analyzerService: analyzerService,
+		historyStore:    historyStore,
+	}
+}
+
+// NewHandlerWithJobs creates a new HTTP handler that also serves analysis
+// history and queues sitemap analyses and crawls as background jobs.
+// historyStore may be nil.
+func NewHandlerWithJobs(analyzerService analyzer.Service, historyStore store.Store, jobQueue jobs.Queue) *Handler {
+	return &Handler{
+		analyzerService: analyzerService,
+		historyStore:    historyStore,
+		jobQueue:        jobQueue,
+	}
+}
+
 // writeJSON writes a JSON response with proper headers and error handling.
 func (h *Handler) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	writeJSON(w, statusCode, data)
+}
+
+// writeJSON writes a JSON response for handlers that are not Handler methods.
+func writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
+		// The status has been sent; all that is left is to log.
 		slog.Error("Failed to encode JSON response", "error", err)
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-		return
 	}
 }
 
-// writeError writes an error response with proper status code and message.
+// writeError writes a problem+json response with the generic code for statusCode.
 func (h *Handler) writeError(w http.ResponseWriter, statusCode int, message string) {
-	slog.Warn("HTTP error response", "status_code", statusCode, "message", message)
-	http.Error(w, message, statusCode)
+	problem.Error(w, message, statusCode)
+}
+
+// writeProblem writes a problem+json response with a specific code.
+func (h *Handler) writeProblem(w http.ResponseWriter, statusCode int, code, message string) {
+	problem.Write(w, problem.New(statusCode, code, message))
+}
+
+// writeAnalysisError writes a failed analysis as a problem+json response
+// carrying the analysis error's code, the page URL and, if the page answered,
+// its status.
+func (h *Handler) writeAnalysisError(w http.ResponseWriter, analysisErr *analyzer.AnalysisError) {
+	p := problem.New(analysisErrorStatus(analysisErr), analysisErr.Code, analysisErr.ErrorMessage)
+	if p.Code == "" {
+		p.Code = analyzer.ErrorCodeInternal
+	}
+	p.URL = analysisErr.URL
+	if analysisErr.Code == analyzer.ErrorCodeUpstreamStatus {
+		p.UpstreamStatus = analysisErr.StatusCode
+	}
+	p.RetryAfter = analysisErr.RetryAfter
+	p.Redirects = analysisErr.Redirects
+	problem.Write(w, p)
+}
+
+// analysisErrorStatuses are the API statuses of analyses that failed with the
+// listed codes; see analysisErrorStatus.
+var analysisErrorStatuses = map[string]int{
+	analyzer.ErrorCodeInvalidRequest: http.StatusBadRequest,
+	client.CodeInvalidURL:            http.StatusUnprocessableEntity,
+	client.CodeUnsupportedProtocol:   http.StatusUnprocessableEntity,
+	client.CodeSchemeNotAllowed:      http.StatusUnprocessableEntity,
+	client.CodeRedirectDowngrade:     http.StatusUnprocessableEntity,
+	client.CodeDNSFailure:            http.StatusUnprocessableEntity,
+	client.CodeBodyTooLarge:          http.StatusUnprocessableEntity,
+	client.CodeUnsupportedEncoding:   http.StatusUnprocessableEntity,
+	analyzer.ErrorCodeParseFailure:   http.StatusUnprocessableEntity,
+	client.CodeTimeout:               http.StatusGatewayTimeout,
+	client.CodeHostSkipped:           http.StatusServiceUnavailable,
+	analyzer.ErrorCodeInternal:       http.StatusInternalServerError,
+}
+
+// analysisErrorStatus returns the API status for a failed analysis: 400 if the
+// request was invalid, 422 if the page cannot be analyzed as asked, 502 if the
+// page or its server failed, 504 if it timed out, and 503 while its host is
+// skipped. The page's own status is not passed on, since a 404 from the page
+// does not mean the API endpoint was not found.
+func analysisErrorStatus(e *analyzer.AnalysisError) int {
+	if e.Code == analyzer.ErrorCodeUpstreamStatus {
+		switch {
+		case e.StatusCode == http.StatusRequestTimeout:
+			return http.StatusGatewayTimeout
+		case e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests:
+			return http.StatusBadGateway
+		default:
+			return http.StatusUnprocessableEntity
+		}
+	}
+	if status, ok := analysisErrorStatuses[e.Code]; ok {
+		return status
+	}
+	// The page's server could not be reached or failed mid-response.
+	return http.StatusBadGateway
+}
+
+// analysisFailureDescriptions say when an endpoint that analyzes pages fails
+// with each status analysisErrorStatus gives; see the README's Error Handling.
+var analysisFailureDescriptions = map[int]string{
+	http.StatusBadRequest:          "The request is malformed or has invalid parameters (code invalid_request).",
+	http.StatusUnprocessableEntity: "The page cannot be analyzed as asked: its URL is invalid or not http(s), it redirected from https to http, its domain does not resolve, it is too large or cannot be decoded or parsed, or it answered with a 4xx status, given in upstream_status.",
+	http.StatusInternalServerError: "Something went wrong on our side.",
+	http.StatusBadGateway:          "The page's server could not be reached, failed the TLS handshake, broke off its response or redirected too often or in a loop, or the page answered with a 5xx or 429 status, given in upstream_status.",
+	http.StatusServiceUnavailable:  "The page's host keeps failing and is skipped for a while (code host_skipped).",
+	http.StatusGatewayTimeout:      "The page took too long to respond (code timeout), or answered 408.",
+}
+
+// analysisFailures documents the failures of an endpoint that analyzes pages:
+// every status analysisErrorStatus gives a failed analysis.
+func analysisFailures() []openapi.Response {
+	failed := []*analyzer.AnalysisError{
+		{Code: client.CodeConnectionRefused},
+		{Code: analyzer.ErrorCodeUpstreamStatus, StatusCode: http.StatusNotFound},
+		{Code: analyzer.ErrorCodeUpstreamStatus, StatusCode: http.StatusRequestTimeout},
+		{Code: analyzer.ErrorCodeUpstreamStatus, StatusCode: http.StatusInternalServerError},
+	}
+	for code := range analysisErrorStatuses {
+		failed = append(failed, &analyzer.AnalysisError{Code: code})
+	}
+	statuses := make([]int, len(failed))
+	for i, e := range failed {
+		statuses[i] = analysisErrorStatus(e)
+	}
+	responses := failures(statuses...)
+	for i := range responses {
+		responses[i].Description = analysisFailureDescriptions[responses[i].Status]
+	}
+	return responses
+}
+
+// HealthCheckDoc documents HealthCheck for the OpenAPI spec.
+var HealthCheckDoc = openapi.Operation{
+	Method:      http.MethodGet,
+	Summary:     "Health check",
+	Description: "Check if the service is running and healthy",
+	Tags:        []string{"System"},
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Body: map[string]string{}},
+	},
 }
 
 // HealthCheck handles health check requests.
-// @Summary Health check
-// @Description Check if the service is running and healthy
-// @Tags System
-// @Accept json
-// @Produce json
-// @Success 200 {object} map[string]string
-// @Router /api/health [get]
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	start := time.Now()
-
 	response := map[string]string{
 		"status":  "healthy",
 		"service": "webpage-analyzer",
 	}
 	h.writeJSON(w, http.StatusOK, response)
+}
 
-	slog.Info("Health check completed",
-		"method", r.Method,
-		"path", r.URL.Path,
-		"duration", time.Since(start),
-		"status_code", http.StatusOK,
-	)
+// AnalyzeWebpageDoc documents AnalyzeWebpage for the OpenAPI spec.
+var AnalyzeWebpageDoc = openapi.Operation{
+	Method:      http.MethodPost,
+	Summary:     "Analyze webpage",
+	Description: "Analyze a webpage and return comprehensive information including HTML version, page title, headings structure, link analysis, and login form detection. The response carries an ETag; send it back in If-None-Match to get 304 Not Modified while the analysis is unchanged.",
+	Tags:        []string{"Analysis"},
+	Params: []openapi.Param{
+		{Name: "If-None-Match", In: "header", Description: "ETag of an analysis the client already has"},
+	},
+	Request: openapi.JSONRequest(analyzer.AnalysisRequest{}),
+	Responses: append([]openapi.Response{
+		{Status: http.StatusOK, Body: analyzer.WebpageAnalysis{}},
+		{Status: http.StatusNotModified, Description: "Analysis unchanged"},
+	}, analysisFailures()...),
 }
 
 // AnalyzeWebpage handles webpage analysis requests.
-// @Summary Analyze webpage
-// @Description Analyze a webpage and return comprehensive information including HTML version,
-// page title, headings structure, link analysis, and login form detection
-// @Tags Analysis
-// @Accept json
-// @Produce json
-// @Param request body analyzer.AnalysisRequest true "Analysis request"
-// @Success 200 {object} analyzer.WebpageAnalysis
-// @Failure 400 {object} analyzer.AnalysisError
-// @Failure 500 {object} map[string]string
-// @Router /api/analyze [post]
 func (h *Handler) AnalyzeWebpage(w http.ResponseWriter, r *http.Request) {
-	start := time.Now()
-
 	if r.Method != http.MethodPost {
-		slog.Warn("Invalid method for analyze endpoint",
-			"method", r.Method,
-			"path", r.URL.Path,
-			"expected_method", http.MethodPost,
-		)
 		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
@@ -96,87 +224,380 @@ func (h *Handler) AnalyzeWebpage(w http.ResponseWriter, r *http.Request) {
 	// Parse request body.
 	var req analyzer.AnalysisRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		slog.Warn("Failed to decode request body",
-			"method", r.Method,
-			"path", r.URL.Path,
-			"error", err,
-		)
+		logging.FromContext(r.Context()).Warn("Failed to decode request body", "error", err)
 		h.writeError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	slog.Info("Starting webpage analysis",
-		"method", r.Method,
-		"path", r.URL.Path,
-		"url", req.URL,
-	)
-
 	// Analyze the webpage.
+	allowBudget(w, r, req.Budget())
 	analysis, err := h.analyzerService.AnalyzeWebpage(r.Context(), req)
 	if err != nil {
 		// Check if it's an AnalysisError and return it as JSON.
 		if analysisErr, ok := err.(*analyzer.AnalysisError); ok {
-			slog.Warn("Analysis failed with analysis error",
-				"method", r.Method,
-				"path", r.URL.Path,
+			logging.FromContext(r.Context()).Warn("Analysis failed with analysis error",
 				"url", req.URL,
-				"error_type", "analysis_error",
-				"status_code", analysisErr.StatusCode,
+				"upstream_status_code", analysisErr.StatusCode,
 				"error_message", analysisErr.ErrorMessage,
-				"duration", time.Since(start),
 			)
-			h.writeJSON(w, http.StatusBadRequest, analysisErr)
+			h.writeAnalysisError(w, analysisErr)
 			return
 		}
 		// For other errors, return a generic error message.
-		slog.Error("Analysis failed with internal error",
-			"method", r.Method,
-			"path", r.URL.Path,
-			"url", req.URL,
-			"error", err,
-			"duration", time.Since(start),
-		)
+		logging.FromContext(r.Context()).Error("Analysis failed with internal error", "url", req.URL, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	// Return analysis result, tagged so that clients can revalidate it.
+	etag, err := analysisETag(analysis)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Failed to compute analysis ETag", "url", req.URL, "error", err)
+		h.writeJSON(w, http.StatusOK, analysis)
+		return
+	}
+	analysisCacheHeaders(w, analysis)
+	h.writeTagged(w, r, etag, analysis)
+}
+
+// allowBudget extends the response's write deadline so that an analysis may
+// use the whole of its time budget, which can exceed WriteTimeout, and still
+// have budgetWriteMargin to write its result. The deadline is never set
+// earlier than WriteTimeout from now.
+func allowBudget(w http.ResponseWriter, r *http.Request, budget time.Duration) {
+	if budget <= 0 {
+		return
+	}
+	deadline := time.Now().Add(max(budget+budgetWriteMargin, WriteTimeout))
+	if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logging.FromContext(r.Context()).Warn("Failed to extend write deadline", "budget", budget, "error", err)
+	}
+}
+
+// CompareWebpagesDoc documents CompareWebpages for the OpenAPI spec.
+var CompareWebpagesDoc = openapi.Operation{
+	Method:      http.MethodPost,
+	Summary:     "Compare two webpages",
+	Description: "Analyze two webpages concurrently and return both analyses together with a structured diff of titles, headings, link counts, and detected login forms",
+	Tags:        []string{"Analysis"},
+	Request:     openapi.JSONRequest(analyzer.CompareRequest{}),
+	Responses: append([]openapi.Response{
+		{Status: http.StatusOK, Body: analyzer.WebpageComparison{}},
+	}, analysisFailures()...),
+}
+
+// CompareWebpages handles requests to compare two webpages.
+func (h *Handler) CompareWebpages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req analyzer.CompareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("Failed to decode request body", "error", err)
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.URLA == "" || req.URLB == "" {
+		h.writeError(w, http.StatusBadRequest, "Both url_a and url_b are required")
+		return
+	}
+
+	comparison, err := h.analyzerService.CompareWebpages(r.Context(), req)
+	if err != nil {
+		if analysisErr, ok := err.(*analyzer.AnalysisError); ok {
+			logging.FromContext(r.Context()).Warn("Comparison failed with analysis error",
+				"url", analysisErr.URL,
+				"upstream_status_code", analysisErr.StatusCode,
+				"error_message", analysisErr.ErrorMessage,
+			)
+			h.writeAnalysisError(w, analysisErr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("Comparison failed with internal error", "url_a", req.URLA, "url_b", req.URLB, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, comparison)
+}
+
+// CompareLanguagesDoc documents CompareLanguages for the OpenAPI spec.
+var CompareLanguagesDoc = openapi.Operation{
+	Method:      http.MethodPost,
+	Summary:     "Compare a webpage across languages",
+	Description: "Fetch a webpage once per Accept-Language value and report how the variants differ in final URL, title, content, declared and detected language, and hreflang alternates",
+	Tags:        []string{"Analysis"},
+	Request:     openapi.JSONRequest(analyzer.LanguageComparisonRequest{}),
+	Responses: append([]openapi.Response{
+		{Status: http.StatusOK, Body: analyzer.LanguageComparison{}},
+	}, analysisFailures()...),
+}
+
+// CompareLanguages handles requests to compare a webpage across languages.
+func (h *Handler) CompareLanguages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req analyzer.LanguageComparisonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("Failed to decode request body", "error", err)
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.URL == "" {
+		h.writeError(w, http.StatusBadRequest, "URL is required")
+		return
+	}
+
+	comparison, err := h.analyzerService.CompareLanguages(r.Context(), req)
+	if err != nil {
+		if analysisErr, ok := err.(*analyzer.AnalysisError); ok {
+			logging.FromContext(r.Context()).Warn("Language comparison failed with analysis error",
+				"url", analysisErr.URL,
+				"upstream_status_code", analysisErr.StatusCode,
+				"error_message", analysisErr.ErrorMessage,
+			)
+			h.writeAnalysisError(w, analysisErr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("Language comparison failed with internal error", "url", req.URL, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, comparison)
+}
+
+// CompareDevicesDoc documents CompareDevices for the OpenAPI spec.
+var CompareDevicesDoc = openapi.Operation{
+	Method:      http.MethodPost,
+	Summary:     "Compare a webpage on desktop and mobile",
+	Description: "Fetch a webpage with a desktop and a mobile browser user agent and report differences in redirect target, title, canonical URL, viewport and size, to detect cloaking or broken mobile variants",
+	Tags:        []string{"Analysis"},
+	Request:     openapi.JSONRequest(analyzer.DeviceComparisonRequest{}),
+	Responses: append([]openapi.Response{
+		{Status: http.StatusOK, Body: analyzer.DeviceComparison{}},
+	}, analysisFailures()...),
+}
+
+// CompareDevices handles requests to compare a webpage on desktop and mobile.
+func (h *Handler) CompareDevices(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req analyzer.DeviceComparisonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("Failed to decode request body", "error", err)
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.URL == "" {
+		h.writeError(w, http.StatusBadRequest, "URL is required")
+		return
+	}
+
+	comparison, err := h.analyzerService.CompareDevices(r.Context(), req)
+	if err != nil {
+		if analysisErr, ok := err.(*analyzer.AnalysisError); ok {
+			logging.FromContext(r.Context()).Warn("Device comparison failed with analysis error",
+				"url", analysisErr.URL,
+				"upstream_status_code", analysisErr.StatusCode,
+				"error_message", analysisErr.ErrorMessage,
+			)
+			h.writeAnalysisError(w, analysisErr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("Device comparison failed with internal error", "url", req.URL, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, comparison)
+}
+
+// ExtractTextDoc documents ExtractText for the OpenAPI spec.
+var ExtractTextDoc = openapi.Operation{
+	Method:      http.MethodPost,
+	Summary:     "Extract visible text",
+	Description: "Fetch a webpage and return its visible text, one block element per line, with scripts, styles, navigation and hidden elements removed. Suited to search indexing and LLM pipelines.",
+	Tags:        []string{"Analysis"},
+	Request:     openapi.JSONRequest(analyzer.TextRequest{}),
+	Responses: append([]openapi.Response{
+		{Status: http.StatusOK, Body: analyzer.TextExtraction{}},
+	}, analysisFailures()...),
+}
+
+// ExtractText handles requests for the visible text of a webpage.
+func (h *Handler) ExtractText(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req analyzer.TextRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("Failed to decode request body", "error", err)
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.URL == "" {
+		h.writeError(w, http.StatusBadRequest, "url is required")
+		return
+	}
+
+	extraction, err := h.analyzerService.ExtractText(r.Context(), req)
+	if err != nil {
+		if analysisErr, ok := err.(*analyzer.AnalysisError); ok {
+			logging.FromContext(r.Context()).Warn("Text extraction failed with analysis error",
+				"url", req.URL,
+				"upstream_status_code", analysisErr.StatusCode,
+				"error_message", analysisErr.ErrorMessage,
+			)
+			h.writeAnalysisError(w, analysisErr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("Text extraction failed with internal error", "url", req.URL, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, extraction)
+}
+
+// maxHTMLUploadBytes bounds the HTML accepted by AnalyzeHTML.
+const maxHTMLUploadBytes = 10 << 20
+
+// AnalyzeHTMLDoc documents AnalyzeHTML for the OpenAPI spec.
+var AnalyzeHTMLDoc = openapi.Operation{
+	Method:      http.MethodPost,
+	Summary:     "Analyze raw HTML",
+	Description: "Run the analysis modules on HTML sent in the request instead of fetching a page, e.g. to check generated templates in CI. Send the HTML as the request body, as the \"file\" part of a multipart form, or as an analyzer.HTMLRequest JSON object. base_url and modules may be given as query parameters or form fields; modules is comma separated. Modules that query the network cannot be selected.",
+	Tags:        []string{"Analysis"},
+	Params: []openapi.Param{
+		{Name: "base_url", In: "query", Description: "Address the HTML would be served from, for link classification"},
+		{Name: "modules", In: "query", Description: "Comma-separated modules to run"},
+	},
+	Request: &openapi.Request{
+		Required: true,
+		Content: map[string]interface{}{
+			"text/html": &openapi.Schema{Type: "string"},
+			"multipart/form-data": &openapi.Schema{Type: "object", Required: []string{"file"}, Properties: map[string]*openapi.Schema{
+				"file":     {Type: "string", Format: "binary"},
+				"base_url": {Type: "string"},
+				"modules":  {Type: "string"},
+			}},
+			"application/json": analyzer.HTMLRequest{},
+		},
+	},
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Body: analyzer.WebpageAnalysis{}},
+		failure(http.StatusBadRequest),
+		describedFailure(http.StatusRequestEntityTooLarge, "The HTML is larger than 10 MiB."),
+		describedFailure(http.StatusUnprocessableEntity, "The HTML cannot be parsed (code parse_failure)."),
+		failure(http.StatusInternalServerError),
+	},
+}
+
+// AnalyzeHTML handles requests to analyze HTML supplied in the request.
+func (h *Handler) AnalyzeHTML(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxHTMLUploadBytes)
+	req, err := readHTMLRequest(r)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("HTML must not exceed %d bytes", maxHTMLUploadBytes))
+			return
+		}
+		logging.FromContext(r.Context()).Warn("Failed to read HTML upload", "error", err)
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	analysis, err := h.analyzerService.AnalyzeHTML(r.Context(), req)
+	if err != nil {
+		if analysisErr, ok := err.(*analyzer.AnalysisError); ok {
+			logging.FromContext(r.Context()).Warn("HTML analysis failed with analysis error",
+				"base_url", req.BaseURL,
+				"error_message", analysisErr.ErrorMessage,
+			)
+			h.writeAnalysisError(w, analysisErr)
+			return
+		}
+		logging.FromContext(r.Context()).Error("HTML analysis failed with internal error", "base_url", req.BaseURL, "error", err)
 		h.writeError(w, http.StatusInternalServerError, "Internal server error")
 		return
 	}
 
-	// Return analysis result.
 	h.writeJSON(w, http.StatusOK, analysis)
+}
 
-	slog.Info("Webpage analysis completed successfully",
-		"method", r.Method,
-		"path", r.URL.Path,
-		"url", req.URL,
-		"status_code", http.StatusOK,
-		"duration", time.Since(start),
-		"has_login_form", analysis.HasLoginForm,
-		"internal_links", analysis.InternalLinks,
-		"external_links", analysis.ExternalLinks,
-		"inaccessible_links", analysis.InaccessibleLinks,
-		"headings_count", len(analysis.Headings),
-	)
+// readHTMLRequest reads an AnalyzeHTML request from a JSON body, a multipart
+// form with a "file" part, or a raw HTML body. Outside JSON, base_url and
+// modules come from query parameters or form fields.
+func readHTMLRequest(r *http.Request) (analyzer.HTMLRequest, error) {
+	var req analyzer.HTMLRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		err := json.NewDecoder(r.Body).Decode(&req)
+		return req, err
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(maxHTMLUploadBytes); err != nil {
+			return req, err
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return req, err
+		}
+		defer file.Close()
+		body, err := io.ReadAll(file)
+		if err != nil {
+			return req, err
+		}
+		req.HTML = string(body)
+	default:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return req, err
+		}
+		req.HTML = string(body)
+	}
+
+	req.BaseURL = r.FormValue("base_url")
+	if modules := r.FormValue("modules"); modules != "" {
+		req.Modules = strings.Split(modules, ",")
+	}
+	return req, nil
+}
+
+// GetAnalysisStatusDoc documents GetAnalysisStatus for the OpenAPI spec.
+var GetAnalysisStatusDoc = openapi.Operation{
+	Method:      http.MethodGet,
+	Summary:     "Get service status",
+	Description: "Get the current status and capabilities of the analysis service",
+	Tags:        []string{"System"},
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Body: map[string]string{}},
+		failure(http.StatusInternalServerError),
+	},
 }
 
 // GetAnalysisStatus handles status requests.
-// @Summary Get service status
-// @Description Get the current status and capabilities of the analysis service
-// @Tags System
-// @Accept json
-// @Produce json
-// @Success 200 {object} map[string]string
-// @Failure 500 {object} map[string]string
-// @Router /api/status [get]
 func (h *Handler) GetAnalysisStatus(w http.ResponseWriter, r *http.Request) {
-	start := time.Now()
-
 	status, err := h.analyzerService.GetAnalysisStatus(r.Context())
 	if err != nil {
-		slog.Error("Failed to get analysis status",
-			"method", r.Method,
-			"path", r.URL.Path,
-			"error", err,
-			"duration", time.Since(start),
-		)
+		logging.FromContext(r.Context()).Error("Failed to get analysis status", "error", err)
 		h.writeError(w, http.StatusInternalServerError, "Failed to get status")
 		return
 	}
@@ -185,57 +606,4 @@ func (h *Handler) GetAnalysisStatus(w http.ResponseWriter, r *http.Request) {
 		"status": status,
 	}
 	h.writeJSON(w, http.StatusOK, response)
-
-	slog.Info("Status request completed",
-		"method", r.Method,
-		"path", r.URL.Path,
-		"status_code", http.StatusOK,
-		"duration", time.Since(start),
-	)
-}
-
-// ServeOpenAPI serves the OpenAPI specification.
-// @Summary Get OpenAPI specification
-// @Description Retrieve the OpenAPI specification for this API
-// @Tags System
-// @Accept json
-// @Produce json
-// @Success 200 {string} string "OpenAPI specification"
-// @Failure 500 {object} map[string]string
-// @Router /api/openapi [get]
-func (h *Handler) ServeOpenAPI(w http.ResponseWriter, r *http.Request) {
-	start := time.Now()
-
-	w.Header().Set("Content-Type", "application/yaml")
-	// Serve the dynamically generated OpenAPI spec
-	openapiData, err := os.ReadFile(openAPIFilePath)
-	if err != nil {
-		slog.Error("Failed to read OpenAPI spec file",
-			"method", r.Method,
-			"path", r.URL.Path,
-			"file_path", openAPIFilePath,
-			"error", err,
-			"duration", time.Since(start),
-		)
-		h.writeError(w, http.StatusInternalServerError, "Failed to read OpenAPI spec")
-		return
-	}
-	if _, err := w.Write(openapiData); err != nil {
-		slog.Error("Failed to write OpenAPI spec response",
-			"method", r.Method,
-			"path", r.URL.Path,
-			"error", err,
-			"duration", time.Since(start),
-		)
-		h.writeError(w, http.StatusInternalServerError, "Failed to write response")
-		return
-	}
-
-	slog.Info("OpenAPI spec served successfully",
-		"method", r.Method,
-		"path", r.URL.Path,
-		"status_code", http.StatusOK,
-		"duration", time.Since(start),
-		"file_size", len(openapiData),
-	)
 }
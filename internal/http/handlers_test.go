@@ -4,11 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/openapi"
+	"webpage-analyzer/internal/problem"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -16,10 +23,14 @@ import (
 
 // Mock analyzer service for testing
 type mockAnalyzerService struct {
-	analysisResult *analyzer.WebpageAnalysis
-	analysisError  error
-	statusResult   string
-	statusError    error
+	analysisResult   *analyzer.WebpageAnalysis
+	analysisError    error
+	comparisonResult *analyzer.WebpageComparison
+	languagesResult  *analyzer.LanguageComparison
+	devicesResult    *analyzer.DeviceComparison
+	statusResult     string
+	statusError      error
+	htmlRequest      *analyzer.HTMLRequest // Last request passed to AnalyzeHTML.
 }
 
 func (m *mockAnalyzerService) AnalyzeWebpage(ctx context.Context, req analyzer.AnalysisRequest) (*analyzer.WebpageAnalysis, error) {
@@ -29,6 +40,59 @@ func (m *mockAnalyzerService) AnalyzeWebpage(ctx context.Context, req analyzer.A
 	return m.analysisResult, nil
 }
 
+func (m *mockAnalyzerService) CompareWebpages(ctx context.Context, req analyzer.CompareRequest) (*analyzer.WebpageComparison, error) {
+	if m.analysisError != nil {
+		return nil, m.analysisError
+	}
+	return m.comparisonResult, nil
+}
+
+func (m *mockAnalyzerService) CompareLanguages(ctx context.Context, req analyzer.LanguageComparisonRequest) (*analyzer.LanguageComparison, error) {
+	if m.analysisError != nil {
+		return nil, m.analysisError
+	}
+	return m.languagesResult, nil
+}
+
+func (m *mockAnalyzerService) CompareDevices(ctx context.Context, req analyzer.DeviceComparisonRequest) (*analyzer.DeviceComparison, error) {
+	if m.analysisError != nil {
+		return nil, m.analysisError
+	}
+	return m.devicesResult, nil
+}
+
+func (m *mockAnalyzerService) CrawlSite(ctx context.Context, req analyzer.CrawlRequest) (*analyzer.CrawlResult, error) {
+	if m.analysisError != nil {
+		return nil, m.analysisError
+	}
+	return &analyzer.CrawlResult{URL: req.URL}, nil
+}
+
+func (m *mockAnalyzerService) AnalyzeSitemap(ctx context.Context, req analyzer.SitemapRequest) (*analyzer.SitemapResult, error) {
+	if m.analysisError != nil {
+		return nil, m.analysisError
+	}
+	if req.Progress != nil {
+		req.Progress(analyzer.SitemapProgress{Total: 1, Completed: 1})
+	}
+	return &analyzer.SitemapResult{SitemapURL: req.SitemapURL, Pages: []*analyzer.WebpageAnalysis{m.analysisResult}}, nil
+}
+
+func (m *mockAnalyzerService) ExtractText(ctx context.Context, req analyzer.TextRequest) (*analyzer.TextExtraction, error) {
+	if m.analysisError != nil {
+		return nil, m.analysisError
+	}
+	return &analyzer.TextExtraction{URL: req.URL, Text: "Hello world", WordCount: 2}, nil
+}
+
+func (m *mockAnalyzerService) AnalyzeHTML(ctx context.Context, req analyzer.HTMLRequest) (*analyzer.WebpageAnalysis, error) {
+	m.htmlRequest = &req
+	if m.analysisError != nil {
+		return nil, m.analysisError
+	}
+	return m.analysisResult, nil
+}
+
 func (m *mockAnalyzerService) GetAnalysisStatus(ctx context.Context) (string, error) {
 	if m.statusError != nil {
 		return "", m.statusError
@@ -102,6 +166,39 @@ func TestAnalyzeWebpage_Success(t *testing.T) {
 	assert.Equal(t, mockResult.HasLoginForm, response.HasLoginForm, "HasLoginForm should match")
 }
 
+// slowAnalyzerService takes delay to analyze a page.
+type slowAnalyzerService struct {
+	mockAnalyzerService
+	delay time.Duration
+}
+
+func (s *slowAnalyzerService) AnalyzeWebpage(ctx context.Context, req analyzer.AnalysisRequest) (*analyzer.WebpageAnalysis, error) {
+	time.Sleep(s.delay)
+	return s.mockAnalyzerService.AnalyzeWebpage(ctx, req)
+}
+
+func TestAnalyzeWebpage_BudgetExtendsWriteTimeout(t *testing.T) {
+	service := &slowAnalyzerService{mockAnalyzerService: mockAnalyzerService{analysisResult: &analyzer.WebpageAnalysis{PageTitle: "Slow"}}, delay: 300 * time.Millisecond}
+	server := httptest.NewUnstartedServer(AccessLog(http.HandlerFunc(NewHandler(service).AnalyzeWebpage), DefaultAccessLogConfig()))
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, "application/json", strings.NewReader(`{"url": "https://example.com", "total_timeout": "1s"}`))
+	require.NoError(t, err, "A response within the request's budget should be written")
+	defer resp.Body.Close()
+	var analysis analyzer.WebpageAnalysis
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&analysis))
+	assert.Equal(t, "Slow", analysis.PageTitle)
+
+	resp, err = http.Post(server.URL, "application/json", strings.NewReader(`{"url": "https://example.com"}`))
+	if err == nil {
+		_, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	assert.Error(t, err, "Without a budget, the server's write timeout should still apply")
+}
+
 func TestAnalyzeWebpage_InvalidMethod(t *testing.T) {
 	mockService := &mockAnalyzerService{}
 	handler := NewHandler(mockService)
@@ -130,6 +227,7 @@ func TestAnalyzeWebpage_InvalidJSON(t *testing.T) {
 func TestAnalyzeWebpage_AnalysisError(t *testing.T) {
 	mockError := &analyzer.AnalysisError{
 		StatusCode:   400,
+		Code:         analyzer.ErrorCodeInvalidRequest,
 		ErrorMessage: "Invalid URL",
 		URL:          "invalid-url",
 	}
@@ -152,12 +250,16 @@ func TestAnalyzeWebpage_AnalysisError(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code, "AnalyzeWebpage() should return 400 for analysis error")
 
-	var response analyzer.AnalysisError
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+	var response problem.Problem
 	err := json.NewDecoder(w.Body).Decode(&response)
 	require.NoError(t, err, "Should decode error response JSON successfully")
 
-	assert.Equal(t, mockError.ErrorMessage, response.ErrorMessage, "Error message should match")
-	assert.Equal(t, mockError.StatusCode, response.StatusCode, "Status code should match")
+	assert.Equal(t, mockError.ErrorMessage, response.Detail, "Error message should match")
+	assert.Zero(t, response.UpstreamStatus, "An invalid request has no upstream status")
+	assert.Equal(t, analyzer.ErrorCodeInvalidRequest, response.Code)
+	assert.Equal(t, "invalid-url", response.URL)
 }
 
 func TestAnalyzeWebpage_InternalError(t *testing.T) {
@@ -180,6 +282,363 @@ func TestAnalyzeWebpage_InternalError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code, "AnalyzeWebpage() should return 500 for internal error")
 }
 
+func TestAnalysisErrorStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      *analyzer.AnalysisError
+		expected int
+	}{
+		{"invalid request", &analyzer.AnalysisError{StatusCode: 400, Code: analyzer.ErrorCodeInvalidRequest}, http.StatusBadRequest},
+		{"page not found", &analyzer.AnalysisError{StatusCode: 404, Code: analyzer.ErrorCodeUpstreamStatus}, http.StatusUnprocessableEntity},
+		{"page unavailable", &analyzer.AnalysisError{StatusCode: 503, Code: analyzer.ErrorCodeUpstreamStatus}, http.StatusBadGateway},
+		{"page rate limited", &analyzer.AnalysisError{StatusCode: 429, Code: analyzer.ErrorCodeUpstreamStatus}, http.StatusBadGateway},
+		{"page request timeout", &analyzer.AnalysisError{StatusCode: 408, Code: analyzer.ErrorCodeUpstreamStatus}, http.StatusGatewayTimeout},
+		{"fetch timeout", &analyzer.AnalysisError{StatusCode: 408, Code: client.CodeTimeout}, http.StatusGatewayTimeout},
+		{"DNS failure", &analyzer.AnalysisError{StatusCode: 404, Code: client.CodeDNSFailure}, http.StatusUnprocessableEntity},
+		{"scheme not allowed", &analyzer.AnalysisError{StatusCode: 400, Code: client.CodeSchemeNotAllowed}, http.StatusUnprocessableEntity},
+		{"page too large", &analyzer.AnalysisError{StatusCode: 413, Code: client.CodeBodyTooLarge}, http.StatusUnprocessableEntity},
+		{"too many redirects", &analyzer.AnalysisError{StatusCode: 502, Code: client.CodeTooManyRedirects}, http.StatusBadGateway},
+		{"connection refused", &analyzer.AnalysisError{StatusCode: 503, Code: client.CodeConnectionRefused}, http.StatusBadGateway},
+		{"host skipped", &analyzer.AnalysisError{StatusCode: 503, Code: client.CodeHostSkipped}, http.StatusServiceUnavailable},
+		{"parse failure", &analyzer.AnalysisError{StatusCode: 500, Code: analyzer.ErrorCodeParseFailure}, http.StatusUnprocessableEntity},
+		{"internal error", &analyzer.AnalysisError{StatusCode: 500, Code: analyzer.ErrorCodeInternal}, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, analysisErrorStatus(tt.err))
+		})
+	}
+}
+
+func TestAnalysisDocsDocumentFailures(t *testing.T) {
+	docs := map[string]openapi.Operation{
+		"AnalyzeWebpage":   AnalyzeWebpageDoc,
+		"CompareWebpages":  CompareWebpagesDoc,
+		"CompareLanguages": CompareLanguagesDoc,
+		"CompareDevices":   CompareDevicesDoc,
+		"ExtractText":      ExtractTextDoc,
+	}
+	for name, doc := range docs {
+		var statuses []int
+		for _, response := range doc.Responses {
+			statuses = append(statuses, response.Status)
+			if response.Status >= 400 {
+				assert.NotEmpty(t, response.Description, "%s should say when it answers %d", name, response.Status)
+			}
+		}
+		for _, status := range []int{http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusInternalServerError,
+			http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+			assert.Contains(t, statuses, status, "%s should document %d", name, status)
+		}
+		for code := range analysisErrorStatuses {
+			assert.Contains(t, statuses, analysisErrorStatus(&analyzer.AnalysisError{Code: code}), "%s should document the status of %s", name, code)
+		}
+	}
+}
+
+func TestAnalyzeWebpage_UpstreamStatus(t *testing.T) {
+	mockService := &mockAnalyzerService{
+		analysisError: &analyzer.AnalysisError{StatusCode: 503, Code: analyzer.ErrorCodeUpstreamStatus, ErrorMessage: "Service Unavailable", URL: "https://example.com"},
+	}
+	handler := NewHandler(mockService)
+
+	jsonBody, _ := json.Marshal(analyzer.AnalysisRequest{URL: "https://example.com"})
+	req := httptest.NewRequest(http.MethodPost, "/api/analyze", bytes.NewBuffer(jsonBody))
+	w := httptest.NewRecorder()
+	handler.AnalyzeWebpage(w, req)
+
+	assert.Equal(t, http.StatusBadGateway, w.Code, "A failing page should be reported as a bad gateway")
+	var response problem.Problem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, http.StatusBadGateway, response.Status)
+	assert.Equal(t, 503, response.UpstreamStatus, "The page's own status should be reported separately")
+	assert.Equal(t, analyzer.ErrorCodeUpstreamStatus, response.Code)
+}
+
+func TestAnalyzeWebpage_RetryAfter(t *testing.T) {
+	mockService := &mockAnalyzerService{
+		analysisError: &analyzer.AnalysisError{StatusCode: 429, Code: analyzer.ErrorCodeUpstreamStatus, ErrorMessage: "Too Many Requests", URL: "https://example.com", RetryAfter: 30},
+	}
+	handler := NewHandler(mockService)
+
+	jsonBody, _ := json.Marshal(analyzer.AnalysisRequest{URL: "https://example.com"})
+	req := httptest.NewRequest(http.MethodPost, "/api/analyze", bytes.NewBuffer(jsonBody))
+	w := httptest.NewRecorder()
+	handler.AnalyzeWebpage(w, req)
+
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"), "The site's advised wait should be passed on")
+	var response problem.Problem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, 30, response.RetryAfter)
+}
+
+func TestAnalyzeWebpage_RedirectLoop(t *testing.T) {
+	chain := []string{"https://example.com/a", "https://example.com/b", "https://example.com/a"}
+	mockService := &mockAnalyzerService{
+		analysisError: &analyzer.AnalysisError{StatusCode: http.StatusBadGateway, Code: client.CodeRedirectLoop, ErrorMessage: "Redirect loop", URL: "https://example.com/a", Redirects: chain},
+	}
+	handler := NewHandler(mockService)
+
+	jsonBody, _ := json.Marshal(analyzer.AnalysisRequest{URL: "https://example.com/a"})
+	req := httptest.NewRequest(http.MethodPost, "/api/analyze", bytes.NewBuffer(jsonBody))
+	w := httptest.NewRecorder()
+	handler.AnalyzeWebpage(w, req)
+
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	var response problem.Problem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, client.CodeRedirectLoop, response.Code)
+	assert.Equal(t, chain, response.Redirects)
+}
+
+func TestCompareWebpages_Success(t *testing.T) {
+	mockService := &mockAnalyzerService{
+		comparisonResult: &analyzer.WebpageComparison{
+			A:           &analyzer.WebpageAnalysis{PageTitle: "Staging"},
+			B:           &analyzer.WebpageAnalysis{PageTitle: "Production"},
+			Differences: analyzer.ComparisonDiff{TitleChanged: true},
+		},
+	}
+	handler := NewHandler(mockService)
+
+	jsonBody, _ := json.Marshal(analyzer.CompareRequest{URLA: "https://a.example.com", URLB: "https://b.example.com"})
+	req := httptest.NewRequest("POST", "/api/compare", bytes.NewBuffer(jsonBody))
+	w := httptest.NewRecorder()
+
+	handler.CompareWebpages(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "CompareWebpages() should return 200 status")
+
+	var response analyzer.WebpageComparison
+	err := json.NewDecoder(w.Body).Decode(&response)
+	require.NoError(t, err, "Should decode response JSON successfully")
+	assert.True(t, response.Differences.TitleChanged, "Title change should be reported")
+}
+
+func TestCompareWebpages_MissingURL(t *testing.T) {
+	handler := NewHandler(&mockAnalyzerService{})
+
+	jsonBody, _ := json.Marshal(analyzer.CompareRequest{URLA: "https://a.example.com"})
+	req := httptest.NewRequest("POST", "/api/compare", bytes.NewBuffer(jsonBody))
+	w := httptest.NewRecorder()
+
+	handler.CompareWebpages(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "CompareWebpages() should return 400 when a URL is missing")
+}
+
+func TestCompareWebpages_AnalysisError(t *testing.T) {
+	mockService := &mockAnalyzerService{
+		analysisError: &analyzer.AnalysisError{StatusCode: 404, Code: analyzer.ErrorCodeUpstreamStatus, ErrorMessage: "Not Found", URL: "https://b.example.com"},
+	}
+	handler := NewHandler(mockService)
+
+	jsonBody, _ := json.Marshal(analyzer.CompareRequest{URLA: "https://a.example.com", URLB: "https://b.example.com"})
+	req := httptest.NewRequest("POST", "/api/compare", bytes.NewBuffer(jsonBody))
+	w := httptest.NewRecorder()
+
+	handler.CompareWebpages(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "CompareWebpages() should return 422 when a page is not found")
+
+	var response analyzer.AnalysisError
+	err := json.NewDecoder(w.Body).Decode(&response)
+	require.NoError(t, err, "Should decode error response JSON successfully")
+	assert.Equal(t, "https://b.example.com", response.URL, "Error should identify the failing URL")
+}
+
+func TestCompareLanguages_Success(t *testing.T) {
+	mockService := &mockAnalyzerService{
+		languagesResult: &analyzer.LanguageComparison{
+			URL:         "https://example.com",
+			Differences: analyzer.LanguageDiff{Negotiated: true, TitleChanged: true},
+		},
+	}
+	handler := NewHandler(mockService)
+
+	jsonBody, _ := json.Marshal(analyzer.LanguageComparisonRequest{URL: "https://example.com", Languages: []string{"en", "de"}})
+	req := httptest.NewRequest("POST", "/api/compare/languages", bytes.NewBuffer(jsonBody))
+	w := httptest.NewRecorder()
+
+	handler.CompareLanguages(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "CompareLanguages() should return 200 status")
+
+	var response analyzer.LanguageComparison
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response), "Should decode response JSON successfully")
+	assert.True(t, response.Differences.TitleChanged, "Title change should be reported")
+}
+
+func TestCompareLanguages_MissingURL(t *testing.T) {
+	handler := NewHandler(&mockAnalyzerService{})
+
+	jsonBody, _ := json.Marshal(analyzer.LanguageComparisonRequest{Languages: []string{"en", "de"}})
+	req := httptest.NewRequest("POST", "/api/compare/languages", bytes.NewBuffer(jsonBody))
+	w := httptest.NewRecorder()
+
+	handler.CompareLanguages(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "CompareLanguages() should return 400 when the URL is missing")
+}
+
+func TestCompareDevices_Success(t *testing.T) {
+	mockService := &mockAnalyzerService{
+		devicesResult: &analyzer.DeviceComparison{
+			URL:         "https://example.com",
+			Differences: analyzer.DeviceDiff{RedirectChanged: true},
+		},
+	}
+	handler := NewHandler(mockService)
+
+	jsonBody, _ := json.Marshal(analyzer.DeviceComparisonRequest{URL: "https://example.com"})
+	req := httptest.NewRequest("POST", "/api/compare/devices", bytes.NewBuffer(jsonBody))
+	w := httptest.NewRecorder()
+
+	handler.CompareDevices(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "CompareDevices() should return 200 status")
+
+	var response analyzer.DeviceComparison
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response), "Should decode response JSON successfully")
+	assert.True(t, response.Differences.RedirectChanged, "Redirect change should be reported")
+}
+
+func TestAnalyzeHTML_RawBody(t *testing.T) {
+	mockService := &mockAnalyzerService{analysisResult: &analyzer.WebpageAnalysis{PageTitle: "Template"}}
+	handler := NewHandler(mockService)
+
+	req := httptest.NewRequest("POST", "/api/analyze/html?base_url=https://example.com/&modules=headings,links",
+		bytes.NewBufferString("<title>Template</title>"))
+	req.Header.Set("Content-Type", "text/html; charset=utf-8")
+	w := httptest.NewRecorder()
+
+	handler.AnalyzeHTML(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, mockService.htmlRequest)
+	assert.Equal(t, analyzer.HTMLRequest{
+		HTML:    "<title>Template</title>",
+		BaseURL: "https://example.com/",
+		Modules: []string{"headings", "links"},
+	}, *mockService.htmlRequest)
+}
+
+func TestAnalyzeHTML_MultipartFile(t *testing.T) {
+	mockService := &mockAnalyzerService{analysisResult: &analyzer.WebpageAnalysis{}}
+	handler := NewHandler(mockService)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "index.html")
+	require.NoError(t, err)
+	_, _ = part.Write([]byte("<h1>Hi</h1>"))
+	require.NoError(t, form.WriteField("base_url", "https://example.com/"))
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest("POST", "/api/analyze/html", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+
+	handler.AnalyzeHTML(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, mockService.htmlRequest)
+	assert.Equal(t, "<h1>Hi</h1>", mockService.htmlRequest.HTML)
+	assert.Equal(t, "https://example.com/", mockService.htmlRequest.BaseURL)
+}
+
+func TestAnalyzeHTML_JSON(t *testing.T) {
+	mockService := &mockAnalyzerService{analysisResult: &analyzer.WebpageAnalysis{}}
+	handler := NewHandler(mockService)
+
+	jsonBody, _ := json.Marshal(analyzer.HTMLRequest{HTML: "<p>Hi</p>", Modules: []string{"links"}})
+	req := httptest.NewRequest("POST", "/api/analyze/html", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.AnalyzeHTML(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, mockService.htmlRequest)
+	assert.Equal(t, "<p>Hi</p>", mockService.htmlRequest.HTML)
+	assert.Equal(t, []string{"links"}, mockService.htmlRequest.Modules)
+}
+
+func TestAnalyzeHTML_TooLarge(t *testing.T) {
+	mockService := &mockAnalyzerService{}
+	handler := NewHandler(mockService)
+
+	req := httptest.NewRequest("POST", "/api/analyze/html", bytes.NewReader(make([]byte, maxHTMLUploadBytes+1)))
+	w := httptest.NewRecorder()
+
+	handler.AnalyzeHTML(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Nil(t, mockService.htmlRequest, "An oversized upload should not be analyzed")
+}
+
+func TestAnalyzeHTML_AnalysisError(t *testing.T) {
+	handler := NewHandler(&mockAnalyzerService{
+		analysisError: &analyzer.AnalysisError{StatusCode: 400, Code: analyzer.ErrorCodeInvalidRequest, ErrorMessage: "html is required"},
+	})
+
+	req := httptest.NewRequest("POST", "/api/analyze/html", bytes.NewBufferString(""))
+	w := httptest.NewRecorder()
+
+	handler.AnalyzeHTML(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "html is required")
+}
+
+func TestExtractText_Success(t *testing.T) {
+	handler := NewHandler(&mockAnalyzerService{})
+
+	jsonBody, _ := json.Marshal(analyzer.TextRequest{URL: "https://example.com"})
+	req := httptest.NewRequest("POST", "/api/extract/text", bytes.NewBuffer(jsonBody))
+	w := httptest.NewRecorder()
+
+	handler.ExtractText(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "ExtractText() should return 200 status")
+
+	var response analyzer.TextExtraction
+	err := json.NewDecoder(w.Body).Decode(&response)
+	require.NoError(t, err, "Should decode response JSON successfully")
+	assert.Equal(t, "Hello world", response.Text)
+	assert.Equal(t, 2, response.WordCount)
+}
+
+func TestExtractText_MissingURL(t *testing.T) {
+	handler := NewHandler(&mockAnalyzerService{})
+
+	req := httptest.NewRequest("POST", "/api/extract/text", bytes.NewBufferString(`{}`))
+	w := httptest.NewRecorder()
+
+	handler.ExtractText(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "ExtractText() should return 400 when the URL is missing")
+}
+
+func TestExtractText_AnalysisError(t *testing.T) {
+	mockService := &mockAnalyzerService{
+		analysisError: &analyzer.AnalysisError{StatusCode: 404, Code: analyzer.ErrorCodeUpstreamStatus, ErrorMessage: "Not Found", URL: "https://example.com"},
+	}
+	handler := NewHandler(mockService)
+
+	jsonBody, _ := json.Marshal(analyzer.TextRequest{URL: "https://example.com"})
+	req := httptest.NewRequest("POST", "/api/extract/text", bytes.NewBuffer(jsonBody))
+	w := httptest.NewRecorder()
+
+	handler.ExtractText(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "ExtractText() should return 422 when the page is not found")
+	var response problem.Problem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, 404, response.UpstreamStatus, "The upstream status code should be passed through")
+}
+
 func TestGetAnalysisStatus_Success(t *testing.T) {
 	expectedStatus := "operational"
 	mockService := &mockAnalyzerService{
@@ -244,7 +703,16 @@ func TestWriteError(t *testing.T) {
 	handler.writeError(w, http.StatusBadRequest, errorMessage)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code, "writeError() should set correct status code")
-	assert.Equal(t, errorMessage+"\n", w.Body.String(), "writeError() should write error message")
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+	var response problem.Problem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, problem.Problem{
+		Type:   "about:blank",
+		Title:  "Bad Request",
+		Status: http.StatusBadRequest,
+		Detail: errorMessage,
+		Code:   problem.CodeInvalidRequest,
+	}, response, "writeError() should write the message as problem details")
 }
 
 func TestWriteJSON_EncodingError(t *testing.T) {
@@ -0,0 +1,172 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/jobs"
+	"webpage-analyzer/internal/openapi"
+	"webpage-analyzer/internal/problem"
+	"webpage-analyzer/internal/store"
+	"webpage-analyzer/internal/worker"
+)
+
+// deepHealthTimeout bounds each dependency check of the deep health check.
+const deepHealthTimeout = 5 * time.Second
+
+// Dependency check outcomes.
+const (
+	DependencyOK      = "ok"
+	DependencyFailing = "failing"
+)
+
+// DependencyCheck checks that one dependency of the service is usable.
+type DependencyCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// DependencyStatus is the outcome of one dependency check.
+// @Description Outcome of one dependency check
+type DependencyStatus struct {
+	Name    string `json:"name" example:"store"`
+	Status  string `json:"status" example:"ok"` // "ok" or "failing".
+	Latency string `json:"latency" example:"3ms"`
+	Error   string `json:"error,omitempty" example:"context deadline exceeded"`
+}
+
+// DeepHealth is the response of the deep health check.
+// @Description Health of the service and each of its dependencies
+type DeepHealth struct {
+	Status       string             `json:"status" example:"healthy"` // "healthy" when every dependency is ok, otherwise "unhealthy".
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// DeepHealthDoc documents the deep health check for the OpenAPI spec.
+var DeepHealthDoc = openapi.Operation{
+	Method:      http.MethodGet,
+	Summary:     "Deep health check",
+	Description: "Check outbound DNS and HTTP, the analysis store, result cache, job queue and worker pool",
+	Tags:        []string{"System"},
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Body: DeepHealth{}},
+		{Status: http.StatusServiceUnavailable, Body: DeepHealth{}},
+	},
+}
+
+// DeepHealthHandler runs the dependency checks concurrently, each for at most
+// five seconds, and reports their status and latency: 200 when all pass, 503
+// when any fails.
+func DeepHealthHandler(checks []DependencyCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			problem.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		health := DeepHealth{Status: "healthy", Dependencies: make([]DependencyStatus, len(checks))}
+		var wg sync.WaitGroup
+		for i, check := range checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				health.Dependencies[i] = runDependencyCheck(r.Context(), check)
+			}()
+		}
+		wg.Wait()
+
+		status := http.StatusOK
+		for _, dep := range health.Dependencies {
+			if dep.Status != DependencyOK {
+				health.Status = "unhealthy"
+				status = http.StatusServiceUnavailable
+			}
+		}
+		writeJSON(w, status, health)
+	}
+}
+
+// runDependencyCheck runs one check under deepHealthTimeout.
+func runDependencyCheck(ctx context.Context, check DependencyCheck) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, deepHealthTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Check(ctx)
+	status := DependencyStatus{Name: check.Name, Status: DependencyOK, Latency: time.Since(start).Round(time.Microsecond).String()}
+	if err != nil {
+		status.Status = DependencyFailing
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// WorkerPoolCheck checks that the pool still runs tasks, by running a no-op one.
+func WorkerPoolCheck(pool worker.WorkerPoolManager) DependencyCheck {
+	return DependencyCheck{Name: "worker_pool", Check: func(ctx context.Context) error {
+		ran := make(chan struct{})
+		if err := pool.SubmitContext(ctx, func() error { close(ran); return nil }); err != nil {
+			return err
+		}
+		select {
+		case <-ran:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("no worker picked up the task: %w", ctx.Err())
+		}
+	}}
+}
+
+// StoreCheck checks that the analysis store answers a query.
+func StoreCheck(st store.Store) DependencyCheck {
+	return DependencyCheck{Name: "store", Check: func(ctx context.Context) error {
+		_, err := st.List(ctx, store.Query{Limit: 1})
+		return err
+	}}
+}
+
+// JobQueueCheck checks that the job queue answers a query.
+func JobQueueCheck(queue jobs.Queue) DependencyCheck {
+	return DependencyCheck{Name: "job_queue", Check: func(ctx context.Context) error {
+		_, err := queue.Counts(ctx)
+		return err
+	}}
+}
+
+// CacheCheck checks a result cache kept outside the process.
+func CacheCheck(cache interface{ Ping() error }) DependencyCheck {
+	return DependencyCheck{Name: "cache", Check: func(ctx context.Context) error {
+		return cache.Ping()
+	}}
+}
+
+// OutboundChecks check that the canary URL's host resolves and that the
+// outbound HTTP client can fetch the URL without a server error.
+func OutboundChecks(httpClient client.HTTPClient, canaryURL string) []DependencyCheck {
+	return []DependencyCheck{
+		{Name: "dns", Check: func(ctx context.Context) error {
+			u, err := url.Parse(canaryURL)
+			if err != nil {
+				return err
+			}
+			_, err = net.DefaultResolver.LookupHost(ctx, u.Hostname())
+			return err
+		}},
+		{Name: "http", Check: func(ctx context.Context) error {
+			_, statusCode, err := httpClient.FetchWebpage(ctx, canaryURL)
+			if err != nil {
+				return err
+			}
+			if statusCode >= http.StatusInternalServerError {
+				return fmt.Errorf("canary URL answered with status %d", statusCode)
+			}
+			return nil
+		}},
+	}
+}
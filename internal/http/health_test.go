@@ -0,0 +1,71 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/worker"
+)
+
+// canaryClient answers every fetch with statusCode.
+type canaryClient struct {
+	statusCode int
+}
+
+func (c canaryClient) FetchWebpage(ctx context.Context, url string) ([]byte, int, error) {
+	return nil, c.statusCode, nil
+}
+
+func (c canaryClient) ParseHTML(content []byte) (*html.Node, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestDeepHealthHandler(t *testing.T) {
+	pool := worker.NewWorkerPool(1)
+	defer pool.Shutdown()
+
+	checks := append([]DependencyCheck{WorkerPoolCheck(pool)}, OutboundChecks(canaryClient{statusCode: http.StatusOK}, "http://127.0.0.1/")...)
+	w := httptest.NewRecorder()
+	DeepHealthHandler(checks)(w, httptest.NewRequest("GET", "/api/health/deep", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var health DeepHealth
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &health))
+	assert.Equal(t, "healthy", health.Status)
+	require.Len(t, health.Dependencies, 3)
+	assert.Equal(t, "worker_pool", health.Dependencies[0].Name)
+	assert.Equal(t, "dns", health.Dependencies[1].Name)
+	assert.Equal(t, "http", health.Dependencies[2].Name)
+	for _, dep := range health.Dependencies {
+		assert.Equal(t, DependencyOK, dep.Status, dep.Name)
+		assert.NotEmpty(t, dep.Latency, dep.Name)
+	}
+}
+
+func TestDeepHealthHandler_FailingDependency(t *testing.T) {
+	pool := worker.NewWorkerPool(1)
+	pool.Shutdown()
+
+	checks := []DependencyCheck{
+		{Name: "store", Check: func(ctx context.Context) error { return errors.New("database is locked") }},
+		WorkerPoolCheck(pool),
+		OutboundChecks(canaryClient{statusCode: http.StatusBadGateway}, "https://example.com/")[1],
+	}
+	w := httptest.NewRecorder()
+	DeepHealthHandler(checks)(w, httptest.NewRequest("GET", "/api/health/deep", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var health DeepHealth
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &health))
+	assert.Equal(t, "unhealthy", health.Status)
+	assert.Equal(t, []string{"database is locked", worker.ErrPoolClosed.Error(), "canary URL answered with status 502"},
+		[]string{health.Dependencies[0].Error, health.Dependencies[1].Error, health.Dependencies[2].Error})
+}
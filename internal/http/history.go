@@ -0,0 +1,262 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/logging"
+	"webpage-analyzer/internal/openapi"
+	"webpage-analyzer/internal/problem"
+	"webpage-analyzer/internal/store"
+)
+
+// ListAnalysesDoc documents ListAnalyses for the OpenAPI spec.
+var ListAnalysesDoc = openapi.Operation{
+	Method:      http.MethodGet,
+	Summary:     "List stored analyses",
+	Description: "List previously stored analyses, newest first, with optional URL and time-range filters. Pass the returned next_page value as the page parameter to fetch the following page.",
+	Tags:        []string{"History"},
+	Params: []openapi.Param{
+		{Name: "url", In: "query", Description: "Only return analyses of this URL. Spellings that normalize alike, such as a different host case, default port or fragment, match too"},
+		{Name: "from", In: "query", Description: "Inclusive lower bound on analysis time (RFC 3339)"},
+		{Name: "to", In: "query", Description: "Exclusive upper bound on analysis time (RFC 3339)"},
+		{Name: "page", In: "query", Description: "Pagination cursor from a previous response"},
+		{Name: "limit", In: "query", Type: "integer", Description: "Page size (default 20, max 100)"},
+	},
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Body: store.Page{}},
+		failure(http.StatusBadRequest),
+		failure(http.StatusServiceUnavailable),
+	},
+}
+
+// ListAnalyses handles history listing requests.
+func (h *Handler) ListAnalyses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if h.historyStore == nil {
+		h.writeProblem(w, http.StatusServiceUnavailable, problem.CodeFeatureDisabled, "Analysis history is not enabled")
+		return
+	}
+
+	params := r.URL.Query()
+	q := store.Query{
+		URL:    params.Get("url"),
+		Cursor: params.Get("page"),
+	}
+
+	var err error
+	if q.From, err = parseTimeParam(params.Get("from")); err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid from parameter: expected RFC 3339 timestamp")
+		return
+	}
+	if q.To, err = parseTimeParam(params.Get("to")); err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid to parameter: expected RFC 3339 timestamp")
+		return
+	}
+	if limit := params.Get("limit"); limit != "" {
+		if q.Limit, err = strconv.Atoi(limit); err != nil || q.Limit <= 0 {
+			h.writeError(w, http.StatusBadRequest, "Invalid limit parameter: expected a positive integer")
+			return
+		}
+	}
+
+	page, err := h.historyStore.List(r.Context(), q)
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidCursor) {
+			h.writeError(w, http.StatusBadRequest, "Invalid page parameter")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to list analyses", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "Failed to list analyses")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, page)
+}
+
+// GetAnalysisDoc documents GetAnalysis for the OpenAPI spec.
+var GetAnalysisDoc = openapi.Operation{
+	Method:      http.MethodGet,
+	Summary:     "Get a stored analysis",
+	Description: "Retrieve a single stored analysis by its ID. Stored analyses never change, so they can be cached indefinitely and revalidated with If-None-Match.",
+	Tags:        []string{"History"},
+	Params: []openapi.Param{
+		{Name: "id", In: "path", Description: "Analysis ID"},
+		{Name: "If-None-Match", In: "header", Description: "ETag of the analysis the client already has"},
+	},
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Body: store.Record{}},
+		{Status: http.StatusNotModified, Description: "Analysis unchanged"},
+		failure(http.StatusNotFound),
+		failure(http.StatusServiceUnavailable),
+	},
+}
+
+// GetAnalysis handles requests for a single stored analysis.
+func (h *Handler) GetAnalysis(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if h.historyStore == nil {
+		h.writeProblem(w, http.StatusServiceUnavailable, problem.CodeFeatureDisabled, "Analysis history is not enabled")
+		return
+	}
+
+	rec, err := h.historyStore.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "Analysis not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to load analysis", "id", r.PathValue("id"), "error", err)
+		h.writeError(w, http.StatusInternalServerError, "Failed to load analysis")
+		return
+	}
+
+	etag, err := contentETag(rec)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("Failed to compute analysis ETag", "id", rec.ID, "error", err)
+		h.writeJSON(w, http.StatusOK, rec)
+		return
+	}
+	w.Header().Set("Cache-Control", storedAnalysisCacheControl)
+	h.writeTagged(w, r, etag, rec)
+}
+
+// DiffAnalysesDoc documents DiffAnalyses for the OpenAPI spec.
+var DiffAnalysesDoc = openapi.Operation{
+	Method:      http.MethodGet,
+	Summary:     "Diff two stored analyses",
+	Description: "Compare two stored analyses. Numeric deltas are computed as otherId minus id.",
+	Tags:        []string{"History"},
+	Params: []openapi.Param{
+		{Name: "id", In: "path", Description: "Base analysis ID"},
+		{Name: "otherId", In: "path", Description: "Analysis ID to compare against the base"},
+	},
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Body: store.SnapshotDiff{}},
+		failure(http.StatusNotFound),
+		failure(http.StatusServiceUnavailable),
+	},
+}
+
+// DiffAnalyses handles requests to diff two stored analyses.
+func (h *Handler) DiffAnalyses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if h.historyStore == nil {
+		h.writeProblem(w, http.StatusServiceUnavailable, problem.CodeFeatureDisabled, "Analysis history is not enabled")
+		return
+	}
+
+	records := make([]*store.Record, 0, 2)
+	for _, id := range []string{r.PathValue("id"), r.PathValue("otherId")} {
+		rec, err := h.historyStore.Get(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				h.writeError(w, http.StatusNotFound, "Analysis not found: "+id)
+				return
+			}
+			logging.FromContext(r.Context()).Error("Failed to load analysis", "id", id, "error", err)
+			h.writeError(w, http.StatusInternalServerError, "Failed to load analysis")
+			return
+		}
+		records = append(records, rec)
+	}
+
+	h.writeJSON(w, http.StatusOK, store.SnapshotDiff{
+		From:        records[0],
+		To:          records[1],
+		Differences: analyzer.DiffAnalyses(records[0].Analysis, records[1].Analysis),
+	})
+}
+
+// SimilarAnalysesDoc documents SimilarAnalyses for the OpenAPI spec.
+var SimilarAnalysesDoc = openapi.Operation{
+	Method:      http.MethodGet,
+	Summary:     "Find similar analyses",
+	Description: "Find stored analyses of other pages whose visible text is nearly identical to this one, using simhash fingerprints. Matches are ordered by Hamming distance (0 means identical text).",
+	Tags:        []string{"History"},
+	Params: []openapi.Param{
+		{Name: "id", In: "path", Description: "Analysis ID"},
+		{Name: "max_distance", In: "query", Type: "integer", Description: "Maximum simhash Hamming distance (default 3, max 16)"},
+		{Name: "limit", In: "query", Type: "integer", Description: "Maximum number of matches (default 20, max 100)"},
+		{Name: "include_same_url", In: "query", Type: "boolean", Description: "Also match other snapshots of the same URL"},
+	},
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Body: store.SimilarResult{}},
+		failure(http.StatusBadRequest),
+		failure(http.StatusNotFound),
+		failure(http.StatusUnprocessableEntity),
+		failure(http.StatusServiceUnavailable),
+	},
+}
+
+// SimilarAnalyses handles requests for near-duplicates of a stored analysis.
+func (h *Handler) SimilarAnalyses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if h.historyStore == nil {
+		h.writeProblem(w, http.StatusServiceUnavailable, problem.CodeFeatureDisabled, "Analysis history is not enabled")
+		return
+	}
+
+	params := r.URL.Query()
+	var (
+		q   store.SimilarQuery
+		err error
+	)
+	if d := params.Get("max_distance"); d != "" {
+		if q.MaxDistance, err = strconv.Atoi(d); err != nil || q.MaxDistance <= 0 || q.MaxDistance > store.MaxSimilarDistance {
+			h.writeError(w, http.StatusBadRequest, "Invalid max_distance parameter: expected an integer between 1 and 16")
+			return
+		}
+	}
+	if limit := params.Get("limit"); limit != "" {
+		if q.Limit, err = strconv.Atoi(limit); err != nil || q.Limit <= 0 {
+			h.writeError(w, http.StatusBadRequest, "Invalid limit parameter: expected a positive integer")
+			return
+		}
+	}
+	if same := params.Get("include_same_url"); same != "" {
+		if q.IncludeSameURL, err = strconv.ParseBool(same); err != nil {
+			h.writeError(w, http.StatusBadRequest, "Invalid include_same_url parameter: expected true or false")
+			return
+		}
+	}
+
+	result, err := h.historyStore.Similar(r.Context(), r.PathValue("id"), q)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.writeError(w, http.StatusNotFound, "Analysis not found")
+		case errors.Is(err, store.ErrNoFingerprint):
+			h.writeError(w, http.StatusUnprocessableEntity, "Analysis has no content fingerprint; re-analyze the page with the content_hash module")
+		default:
+			logging.FromContext(r.Context()).Error("Failed to find similar analyses", "id", r.PathValue("id"), "error", err)
+			h.writeError(w, http.StatusInternalServerError, "Failed to find similar analyses")
+		}
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, result)
+}
+
+// parseTimeParam parses an optional RFC 3339 query parameter.
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,167 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/store"
+)
+
+// newHistoryHandler returns a handler backed by an in-memory store and a mux
+// with the history routes registered.
+func newHistoryHandler(t *testing.T) (*http.ServeMux, store.Store) {
+	t.Helper()
+	st, err := store.Open(context.Background(), store.Config{Driver: store.DriverSQLite, DSN: ":memory:"})
+	require.NoError(t, err)
+	t.Cleanup(func() { st.Close() })
+
+	handler := NewHandlerWithStore(&mockAnalyzerService{}, st)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/analyses", handler.ListAnalyses)
+	mux.HandleFunc("/api/analyses/{id}", handler.GetAnalysis)
+	mux.HandleFunc("/api/analyses/{id}/diff/{otherId}", handler.DiffAnalyses)
+	mux.HandleFunc("/api/analyses/{id}/similar", handler.SimilarAnalyses)
+	return mux, st
+}
+
+func TestListAnalyses(t *testing.T) {
+	mux, st := newHistoryHandler(t)
+	for _, u := range []string{"https://a.example.com", "https://b.example.com", "https://a.example.com"} {
+		_, err := st.Save(context.Background(), &analyzer.WebpageAnalysis{URL: u})
+		require.NoError(t, err)
+	}
+
+	req := httptest.NewRequest("GET", "/api/analyses?url=https://a.example.com&limit=1", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "ListAnalyses() should return 200 status")
+
+	var page store.Page
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&page))
+	require.Len(t, page.Records, 1, "Limit should be honoured")
+	assert.Equal(t, "https://a.example.com", page.Records[0].URL, "URL filter should be applied")
+	assert.NotEmpty(t, page.NextCursor, "A second page should be available")
+}
+
+func TestListAnalyses_InvalidParams(t *testing.T) {
+	mux, _ := newHistoryHandler(t)
+
+	for _, query := range []string{"from=yesterday", "to=2024-13-01", "limit=-1", "page=%21%21"} {
+		req := httptest.NewRequest("GET", "/api/analyses?"+query, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "ListAnalyses() should reject %q", query)
+	}
+}
+
+func TestListAnalyses_StoreDisabled(t *testing.T) {
+	handler := NewHandler(&mockAnalyzerService{})
+
+	req := httptest.NewRequest("GET", "/api/analyses", nil)
+	w := httptest.NewRecorder()
+	handler.ListAnalyses(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, "ListAnalyses() should return 503 without a store")
+}
+
+func TestGetAnalysis(t *testing.T) {
+	mux, st := newHistoryHandler(t)
+	rec, err := st.Save(context.Background(), &analyzer.WebpageAnalysis{URL: "https://example.com", PageTitle: "Stored"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/api/analyses/"+rec.ID, nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "GetAnalysis() should return 200 status")
+
+	var got store.Record
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, rec.ID, got.ID)
+	assert.Equal(t, "Stored", got.Analysis.PageTitle)
+}
+
+func TestGetAnalysis_NotFound(t *testing.T) {
+	mux, _ := newHistoryHandler(t)
+
+	req := httptest.NewRequest("GET", "/api/analyses/missing", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code, "GetAnalysis() should return 404 for unknown IDs")
+}
+
+func TestDiffAnalyses(t *testing.T) {
+	mux, st := newHistoryHandler(t)
+	older, err := st.Save(context.Background(), &analyzer.WebpageAnalysis{URL: "https://example.com", ExternalLinks: 1})
+	require.NoError(t, err)
+	newer, err := st.Save(context.Background(), &analyzer.WebpageAnalysis{URL: "https://example.com", ExternalLinks: 4, HasLoginForm: true})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/api/analyses/"+older.ID+"/diff/"+newer.ID, nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "DiffAnalyses() should return 200 status")
+
+	var diff store.SnapshotDiff
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&diff))
+	assert.Equal(t, older.ID, diff.From.ID)
+	assert.Equal(t, newer.ID, diff.To.ID)
+	assert.Equal(t, 3, diff.Differences.ExternalLinksDelta, "Delta should be other minus base")
+	assert.Contains(t, diff.Differences.Summary, "login form appeared")
+}
+
+func TestDiffAnalyses_NotFound(t *testing.T) {
+	mux, st := newHistoryHandler(t)
+	rec, err := st.Save(context.Background(), &analyzer.WebpageAnalysis{URL: "https://example.com"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/api/analyses/"+rec.ID+"/diff/missing", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code, "DiffAnalyses() should return 404 when either ID is unknown")
+}
+
+func TestSimilarAnalyses(t *testing.T) {
+	mux, st := newHistoryHandler(t)
+	ctx := context.Background()
+	source, err := st.Save(ctx, &analyzer.WebpageAnalysis{URL: "https://a.example.com", SimHash: "000000000000ff00"})
+	require.NoError(t, err)
+	match, err := st.Save(ctx, &analyzer.WebpageAnalysis{URL: "https://copy.example.com", SimHash: "000000000000ff01"})
+	require.NoError(t, err)
+	unfingerprinted, err := st.Save(ctx, &analyzer.WebpageAnalysis{URL: "https://old.example.com"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/api/analyses/"+source.ID+"/similar?max_distance=2", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "SimilarAnalyses() should return 200 status")
+	var result store.SimilarResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	require.Len(t, result.Matches, 1)
+	assert.Equal(t, match.ID, result.Matches[0].ID)
+	assert.Equal(t, 1, result.Matches[0].Distance)
+
+	for path, want := range map[string]int{
+		"/api/analyses/missing/similar":                                  http.StatusNotFound,
+		"/api/analyses/" + unfingerprinted.ID + "/similar":               http.StatusUnprocessableEntity,
+		"/api/analyses/" + source.ID + "/similar?max_distance=99":        http.StatusBadRequest,
+		"/api/analyses/" + source.ID + "/similar?include_same_url=maybe": http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, want, w.Code, "Unexpected status for %s", path)
+	}
+}
@@ -0,0 +1,207 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"webpage-analyzer/internal/logging"
+	"webpage-analyzer/internal/problem"
+)
+
+const (
+	// idempotencyKeyHeader names the client-chosen key of a retryable request.
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader marks a response replayed from an earlier request.
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKeyLength bounds the keys clients may send.
+	maxIdempotencyKeyLength = 255
+)
+
+// IdempotencyConfig configures an Idempotency middleware.
+type IdempotencyConfig struct {
+	// Window is how long a response is replayed for retries of its request.
+	Window time.Duration
+	// MaxKeys bounds how many responses are kept; the oldest is evicted
+	// when full. A non-positive value means no limit.
+	MaxKeys int
+}
+
+// DefaultIdempotencyConfig returns the idempotency settings used by the server.
+func DefaultIdempotencyConfig() IdempotencyConfig {
+	return IdempotencyConfig{
+		Window:  24 * time.Hour,
+		MaxKeys: 10000,
+	}
+}
+
+// Idempotency lets clients retry POST requests safely. A request carrying an
+// Idempotency-Key header is served once; retries with the same key, method,
+// path and body within the window get the original response instead of
+// fetching the pages again. Responses are kept in process memory.
+type Idempotency struct {
+	cfg     IdempotencyConfig
+	mu      sync.Mutex
+	entries map[string]*idempotentResponse
+	now     func() time.Time
+}
+
+// idempotentResponse is the recorded response to one idempotency key.
+type idempotentResponse struct {
+	fingerprint [sha256.Size]byte // Hash of the request body.
+	done        bool              // False while the first request is being served.
+	status      int
+	header      http.Header
+	body        []byte
+	storedAt    time.Time
+}
+
+// NewIdempotency creates an idempotency middleware.
+func NewIdempotency(cfg IdempotencyConfig) *Idempotency {
+	return &Idempotency{
+		cfg:     cfg,
+		entries: make(map[string]*idempotentResponse),
+		now:     time.Now,
+	}
+}
+
+// Wrap makes next honour the Idempotency-Key header of POST requests.
+func (m *Idempotency) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			problem.Error(w, "Idempotency-Key must be at most 255 characters", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			problem.Error(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := sha256.Sum256(body)
+		entryKey := r.Method + " " + r.URL.Path + " " + key
+		if apiKey := APIKeyFromContext(r.Context()); apiKey != nil {
+			// Tenants choose their keys independently; never replay across them.
+			entryKey = apiKey.Tenant + " " + entryKey
+		}
+
+		m.mu.Lock()
+		if entry, ok := m.lookup(entryKey); ok {
+			m.mu.Unlock()
+			switch {
+			case entry.fingerprint != fingerprint:
+				problem.Write(w, problem.New(http.StatusUnprocessableEntity, problem.CodeIdempotencyKeyReused, "Idempotency-Key was already used with a different request"))
+			case !entry.done:
+				w.Header().Set("Retry-After", "1")
+				problem.Write(w, problem.New(http.StatusConflict, problem.CodeRequestInProgress, "A request with this Idempotency-Key is still in progress"))
+			default:
+				logging.FromContext(r.Context()).Debug("Replaying idempotent response", "path", r.URL.Path, "idempotency_key", key)
+				entry.replay(w)
+			}
+			return
+		}
+		entry := &idempotentResponse{fingerprint: fingerprint}
+		m.store(entryKey, entry)
+		m.mu.Unlock()
+
+		rec := &capturingWriter{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		// Server errors and rejections are worth retrying for real.
+		if rec.status >= http.StatusInternalServerError || rec.status == http.StatusTooManyRequests {
+			delete(m.entries, entryKey)
+			return
+		}
+		entry.done = true
+		entry.status = rec.status
+		entry.header = rec.header
+		entry.body = rec.body.Bytes()
+		entry.storedAt = m.now()
+	})
+}
+
+// lookup returns the live entry for key. The caller must hold mu.
+func (m *Idempotency) lookup(key string) (*idempotentResponse, bool) {
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if entry.done && m.now().Sub(entry.storedAt) > m.cfg.Window {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return entry, true
+}
+
+// store adds entry, evicting the oldest finished entry if full. The caller
+// must hold mu.
+func (m *Idempotency) store(key string, entry *idempotentResponse) {
+	if m.cfg.MaxKeys > 0 && len(m.entries) >= m.cfg.MaxKeys {
+		var oldestKey string
+		var oldest time.Time
+		for k, e := range m.entries {
+			if e.done && (oldestKey == "" || e.storedAt.Before(oldest)) {
+				oldestKey, oldest = k, e.storedAt
+			}
+		}
+		delete(m.entries, oldestKey)
+	}
+	m.entries[key] = entry
+}
+
+// replay writes the recorded response.
+func (e *idempotentResponse) replay(w http.ResponseWriter) {
+	for name, values := range e.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(idempotentReplayedHeader, "true")
+	w.WriteHeader(e.status)
+	_, _ = w.Write(e.body)
+}
+
+// capturingWriter passes a response through while keeping a copy of it.
+type capturingWriter struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+// WriteHeader records the status and headers before delegating.
+func (c *capturingWriter) WriteHeader(statusCode int) {
+	if c.status == 0 {
+		c.status = statusCode
+		c.header = c.ResponseWriter.Header().Clone()
+		// The request ID belongs to the original request, not its retries.
+		c.header.Del(requestIDHeader)
+	}
+	c.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Unwrap returns the wrapped ResponseWriter, for http.ResponseController.
+func (c *capturingWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// Write records the body before delegating.
+func (c *capturingWriter) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.WriteHeader(http.StatusOK)
+	}
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
@@ -0,0 +1,149 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingHandler answers with a body numbering each call.
+func countingHandler(calls *atomic.Int64, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"call": %d}`, n)
+	})
+}
+
+// postWithKey sends a POST with an Idempotency-Key through h.
+func postWithKey(h http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/api/analyze", bytes.NewBufferString(body))
+	if key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysResponse(t *testing.T) {
+	var calls atomic.Int64
+	h := NewIdempotency(DefaultIdempotencyConfig()).Wrap(countingHandler(&calls, http.StatusOK))
+
+	first := postWithKey(h, "abc", `{"url": "https://example.com"}`)
+	second := postWithKey(h, "abc", `{"url": "https://example.com"}`)
+
+	assert.Equal(t, int64(1), calls.Load())
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+	assert.Empty(t, first.Header().Get(idempotentReplayedHeader))
+	assert.Equal(t, "true", second.Header().Get(idempotentReplayedHeader))
+
+	postWithKey(h, "other", `{"url": "https://example.com"}`)
+	postWithKey(h, "", `{"url": "https://example.com"}`)
+	assert.Equal(t, int64(3), calls.Load(), "Other keys and unkeyed requests should run")
+}
+
+func TestIdempotency_KeyReusedWithDifferentBody(t *testing.T) {
+	var calls atomic.Int64
+	h := NewIdempotency(DefaultIdempotencyConfig()).Wrap(countingHandler(&calls, http.StatusOK))
+
+	postWithKey(h, "abc", `{"url": "https://example.com"}`)
+	w := postWithKey(h, "abc", `{"url": "https://example.org"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, int64(1), calls.Load())
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	m := NewIdempotency(DefaultIdempotencyConfig())
+	h := m.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		postWithKey(h, "abc", `{}`)
+	}()
+	<-started
+
+	w := postWithKey(h, "abc", `{}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	close(release)
+	<-done
+}
+
+func TestIdempotency_ServerErrorsNotStored(t *testing.T) {
+	var calls atomic.Int64
+	h := NewIdempotency(DefaultIdempotencyConfig()).Wrap(countingHandler(&calls, http.StatusServiceUnavailable))
+
+	postWithKey(h, "abc", `{}`)
+	w := postWithKey(h, "abc", `{}`)
+
+	assert.Equal(t, int64(2), calls.Load())
+	assert.Empty(t, w.Header().Get(idempotentReplayedHeader))
+}
+
+func TestIdempotency_WindowExpires(t *testing.T) {
+	var calls atomic.Int64
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	m := NewIdempotency(IdempotencyConfig{Window: time.Hour})
+	m.now = func() time.Time { return now }
+	h := m.Wrap(countingHandler(&calls, http.StatusOK))
+
+	postWithKey(h, "abc", `{}`)
+	now = now.Add(time.Hour + time.Second)
+	postWithKey(h, "abc", `{}`)
+
+	assert.Equal(t, int64(2), calls.Load())
+}
+
+func TestIdempotency_EvictsOldest(t *testing.T) {
+	var calls atomic.Int64
+	m := NewIdempotency(IdempotencyConfig{Window: time.Hour, MaxKeys: 2})
+	h := m.Wrap(countingHandler(&calls, http.StatusOK))
+
+	for _, key := range []string{"a", "b", "c"} {
+		postWithKey(h, key, `{}`)
+	}
+	require.Len(t, m.entries, 2)
+
+	postWithKey(h, "c", `{}`)
+	assert.Equal(t, int64(3), calls.Load(), "The newest key should still be replayed")
+}
+
+func TestIdempotency_IgnoresGet(t *testing.T) {
+	var calls atomic.Int64
+	h := NewIdempotency(DefaultIdempotencyConfig()).Wrap(countingHandler(&calls, http.StatusOK))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/api/status", nil)
+		req.Header.Set(idempotencyKeyHeader, "abc")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	assert.Equal(t, int64(2), calls.Load())
+}
+
+func TestIdempotency_KeyTooLong(t *testing.T) {
+	var calls atomic.Int64
+	h := NewIdempotency(DefaultIdempotencyConfig()).Wrap(countingHandler(&calls, http.StatusOK))
+
+	w := postWithKey(h, string(bytes.Repeat([]byte("k"), maxIdempotencyKeyLength+1)), `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Zero(t, calls.Load())
+}
@@ -0,0 +1,271 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/jobs"
+	"webpage-analyzer/internal/logging"
+	"webpage-analyzer/internal/openapi"
+	"webpage-analyzer/internal/problem"
+)
+
+// maxPendingJobs bounds how many jobs may be queued or running at once;
+// further submissions are rejected until the backlog drains.
+const maxPendingJobs = 100
+
+// CrawlSiteDoc documents CrawlSite for the OpenAPI spec.
+var CrawlSiteDoc = openapi.Operation{
+	Method:      http.MethodPost,
+	Summary:     "Crawl a site",
+	Description: "Queue a breadth-first crawl of the same-host pages reachable from a URL. Poll the returned job URL for the analyzer.CrawlResult.",
+	Tags:        []string{"Analysis"},
+	Request:     openapi.JSONRequest(analyzer.CrawlRequest{}),
+	Responses: []openapi.Response{
+		{Status: http.StatusAccepted, Body: jobs.Job{}},
+		failure(http.StatusBadRequest),
+		failure(http.StatusServiceUnavailable),
+	},
+}
+
+// CrawlSite handles requests to crawl a site in the background.
+func (h *Handler) CrawlSite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req analyzer.CrawlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("Failed to decode request body", "error", err)
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.URL == "" {
+		h.writeError(w, http.StatusBadRequest, "url is required")
+		return
+	}
+
+	h.enqueueJob(w, r, jobs.KindCrawl, req, "/api/jobs/")
+}
+
+// WarmCacheDoc documents WarmCache for the OpenAPI spec.
+var WarmCacheDoc = openapi.Operation{
+	Method:      http.MethodPost,
+	Summary:     "Warm the result cache",
+	Description: "Queue analyses of up to 100 pages so that later requests for them are served from the result cache. Poll the returned job URL for the jobs.WarmResult.",
+	Tags:        []string{"Analysis"},
+	Request:     openapi.JSONRequest(jobs.WarmRequest{}),
+	Responses: []openapi.Response{
+		{Status: http.StatusAccepted, Body: jobs.Job{}},
+		failure(http.StatusBadRequest),
+		failure(http.StatusServiceUnavailable),
+	},
+}
+
+// WarmCache handles requests to analyze pages ahead of time.
+func (h *Handler) WarmCache(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req jobs.WarmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("Failed to decode request body", "error", err)
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.enqueueJob(w, r, jobs.KindWarm, req, "/api/jobs/")
+}
+
+// ListJobsDoc documents ListJobs for the OpenAPI spec.
+var ListJobsDoc = openapi.Operation{
+	Method:      http.MethodGet,
+	Summary:     "List background jobs",
+	Description: "List background jobs, newest first. Use status=failed to inspect the dead-letter queue of jobs that ran out of attempts or failed permanently.",
+	Tags:        []string{"Jobs"},
+	Params: []openapi.Param{
+		{Name: "status", In: "query", Description: "Only return jobs in this state (queued, running, done or failed)"},
+		{Name: "limit", In: "query", Type: "integer", Description: "Maximum number of jobs (default 20, max 100)"},
+	},
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Body: []jobs.Job{}},
+		failure(http.StatusBadRequest),
+		failure(http.StatusServiceUnavailable),
+	},
+}
+
+// ListJobs handles job listing requests.
+func (h *Handler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if h.jobQueue == nil {
+		h.writeProblem(w, http.StatusServiceUnavailable, problem.CodeFeatureDisabled, "Background jobs are not enabled")
+		return
+	}
+
+	params := r.URL.Query()
+	status := jobs.Status(params.Get("status"))
+	switch status {
+	case "", jobs.StatusQueued, jobs.StatusRunning, jobs.StatusDone, jobs.StatusFailed:
+	default:
+		h.writeError(w, http.StatusBadRequest, "Invalid status parameter: expected queued, running, done or failed")
+		return
+	}
+	limit := 0
+	if v := params.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			h.writeError(w, http.StatusBadRequest, "Invalid limit parameter: expected a positive integer")
+			return
+		}
+	}
+
+	list, err := h.jobQueue.List(r.Context(), status, limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to list jobs", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "Failed to list jobs")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, list)
+}
+
+// GetJobDoc documents GetJob for the OpenAPI spec.
+var GetJobDoc = openapi.Operation{
+	Method:      http.MethodGet,
+	Summary:     "Get a background job",
+	Description: "Return the status, progress and, once done, result of a background job",
+	Tags:        []string{"Jobs"},
+	Params: []openapi.Param{
+		{Name: "id", In: "path", Description: "Job ID"},
+	},
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Body: jobs.Job{}},
+		failure(http.StatusNotFound),
+		failure(http.StatusServiceUnavailable),
+	},
+}
+
+// GetJob handles background job status requests.
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if job := h.loadJob(w, r); job != nil {
+		h.writeJSON(w, http.StatusOK, job)
+	}
+}
+
+// RetryJobDoc documents RetryJob for the OpenAPI spec.
+var RetryJobDoc = openapi.Operation{
+	Method:      http.MethodPost,
+	Summary:     "Retry a failed job",
+	Description: "Queue a failed job again with a fresh set of attempts",
+	Tags:        []string{"Jobs"},
+	Params: []openapi.Param{
+		{Name: "id", In: "path", Description: "Job ID"},
+	},
+	Responses: []openapi.Response{
+		{Status: http.StatusAccepted, Body: jobs.Job{}},
+		failure(http.StatusNotFound),
+		failure(http.StatusConflict),
+		failure(http.StatusServiceUnavailable),
+	},
+}
+
+// RetryJob handles requests to retry a dead-lettered job.
+func (h *Handler) RetryJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if h.jobQueue == nil {
+		h.writeProblem(w, http.StatusServiceUnavailable, problem.CodeFeatureDisabled, "Background jobs are not enabled")
+		return
+	}
+
+	job, err := h.jobQueue.Requeue(r.Context(), r.PathValue("id"))
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		h.writeError(w, http.StatusNotFound, "Job not found")
+	case errors.Is(err, jobs.ErrNotFailed):
+		h.writeError(w, http.StatusConflict, "Only failed jobs can be retried")
+	case err != nil:
+		logging.FromContext(r.Context()).Error("Failed to requeue job", "id", r.PathValue("id"), "error", err)
+		h.writeError(w, http.StatusInternalServerError, "Failed to retry job")
+	default:
+		h.writeJSON(w, http.StatusAccepted, job)
+	}
+}
+
+// enqueueJob queues a job and answers 202 with a Location of statusPath
+// followed by the job ID. The job records the API key of the request, if
+// any, which its pages are charged to.
+func (h *Handler) enqueueJob(w http.ResponseWriter, r *http.Request, kind string, payload interface{}, statusPath string) {
+	if h.jobQueue == nil {
+		h.writeProblem(w, http.StatusServiceUnavailable, problem.CodeFeatureDisabled, "Background jobs are not enabled")
+		return
+	}
+
+	counts, err := h.jobQueue.Counts(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to count jobs", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "Failed to queue job")
+		return
+	}
+	if counts[jobs.StatusQueued]+counts[jobs.StatusRunning] >= maxPendingJobs {
+		h.writeProblem(w, http.StatusServiceUnavailable, problem.CodeServerBusy, "Too many jobs in progress")
+		return
+	}
+
+	if key := APIKeyFromContext(r.Context()); key != nil {
+		owned, err := jobs.Owned(payload, jobs.Owner{Tenant: key.Tenant, APIKeyID: key.ID})
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Failed to queue job", "kind", kind, "error", err)
+			h.writeError(w, http.StatusInternalServerError, "Failed to queue job")
+			return
+		}
+		payload = owned
+	}
+
+	job, err := h.jobQueue.Enqueue(r.Context(), kind, payload)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to queue job", "kind", kind, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "Failed to queue job")
+		return
+	}
+	w.Header().Set("Location", statusPath+job.ID)
+	h.writeJSON(w, http.StatusAccepted, job)
+}
+
+// loadJob returns the job named by the id path value, or writes an error
+// response and returns nil.
+func (h *Handler) loadJob(w http.ResponseWriter, r *http.Request) *jobs.Job {
+	if h.jobQueue == nil {
+		h.writeProblem(w, http.StatusServiceUnavailable, problem.CodeFeatureDisabled, "Background jobs are not enabled")
+		return nil
+	}
+	job, err := h.jobQueue.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, jobs.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "Job not found")
+			return nil
+		}
+		logging.FromContext(r.Context()).Error("Failed to load job", "id", r.PathValue("id"), "error", err)
+		h.writeError(w, http.StatusInternalServerError, "Failed to load job")
+		return nil
+	}
+	return job
+}
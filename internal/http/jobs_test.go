@@ -0,0 +1,144 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/jobs"
+)
+
+func TestCrawlSite_QueuesJob(t *testing.T) {
+	handler := newJobsHandler(t, &mockAnalyzerService{})
+
+	req := httptest.NewRequest("POST", "/api/crawl", bytes.NewBufferString(`{"url": "https://example.com", "max_pages": 5}`))
+	w := httptest.NewRecorder()
+	handler.CrawlSite(w, req)
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var job jobs.Job
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&job))
+	assert.Equal(t, jobs.KindCrawl, job.Kind)
+	assert.Equal(t, "/api/jobs/"+job.ID, w.Header().Get("Location"))
+
+	var payload analyzer.CrawlRequest
+	require.NoError(t, json.Unmarshal(job.Payload, &payload))
+	assert.Equal(t, analyzer.CrawlRequest{URL: "https://example.com", MaxPages: 5}, payload)
+}
+
+func TestCrawlSite_BadRequest(t *testing.T) {
+	handler := newJobsHandler(t, &mockAnalyzerService{})
+
+	req := httptest.NewRequest("POST", "/api/crawl", bytes.NewBufferString(`{"max_pages": 5}`))
+	w := httptest.NewRecorder()
+	handler.CrawlSite(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWarmCache_QueuesJob(t *testing.T) {
+	handler := newJobsHandler(t, &mockAnalyzerService{})
+
+	req := httptest.NewRequest("POST", "/api/cache/warm", bytes.NewBufferString(`{"urls": ["https://example.com", "https://example.org"]}`))
+	w := httptest.NewRecorder()
+	handler.WarmCache(w, req)
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var job jobs.Job
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&job))
+	assert.Equal(t, jobs.KindWarm, job.Kind)
+	assert.Equal(t, "/api/jobs/"+job.ID, w.Header().Get("Location"))
+
+	var payload jobs.WarmRequest
+	require.NoError(t, json.Unmarshal(job.Payload, &payload))
+	assert.Equal(t, []string{"https://example.com", "https://example.org"}, payload.URLs)
+}
+
+func TestWarmCache_BadRequest(t *testing.T) {
+	handler := newJobsHandler(t, &mockAnalyzerService{})
+
+	for _, body := range []string{`{}`, `{"urls": [""]}`, `not json`} {
+		req := httptest.NewRequest("POST", "/api/cache/warm", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler.WarmCache(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func TestJobs_DeadLetterAndRetry(t *testing.T) {
+	ctx := context.Background()
+	queue, err := jobs.Open(ctx, jobs.Config{Driver: jobs.DriverMemory, Retry: jobs.RetryPolicy{MaxAttempts: 1}})
+	require.NoError(t, err)
+	defer queue.Close()
+	handler := NewHandlerWithJobs(&mockAnalyzerService{}, nil, queue)
+
+	failed, err := queue.Enqueue(ctx, jobs.KindCrawl, nil)
+	require.NoError(t, err)
+	_, err = queue.Claim(ctx)
+	require.NoError(t, err)
+	_, err = queue.Fail(ctx, failed.ID, errors.New("upstream down"))
+	require.NoError(t, err)
+	_, err = queue.Enqueue(ctx, jobs.KindSitemap, nil)
+	require.NoError(t, err)
+
+	// The dead-letter queue lists only the failed job.
+	req := httptest.NewRequest("GET", "/api/jobs?status=failed", nil)
+	w := httptest.NewRecorder()
+	handler.ListJobs(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var list []jobs.Job
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+	require.Len(t, list, 1)
+	assert.Equal(t, failed.ID, list[0].ID)
+	assert.Equal(t, "upstream down", list[0].Error)
+
+	req = httptest.NewRequest("POST", "/api/jobs/"+failed.ID+"/retry", nil)
+	req.SetPathValue("id", failed.ID)
+	w = httptest.NewRecorder()
+	handler.RetryJob(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var retried jobs.Job
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&retried))
+	assert.Equal(t, jobs.StatusQueued, retried.Status)
+
+	w = httptest.NewRecorder()
+	handler.RetryJob(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code, "A queued job cannot be retried")
+
+	req = httptest.NewRequest("GET", "/api/jobs/"+failed.ID, nil)
+	req.SetPathValue("id", failed.ID)
+	w = httptest.NewRecorder()
+	handler.GetJob(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestListJobs_InvalidParams(t *testing.T) {
+	handler := newJobsHandler(t, &mockAnalyzerService{})
+
+	for _, query := range []string{"status=stuck", "limit=0", "limit=abc"} {
+		req := httptest.NewRequest("GET", "/api/jobs?"+query, nil)
+		w := httptest.NewRecorder()
+		handler.ListJobs(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestGetJob_NotFound(t *testing.T) {
+	handler := newJobsHandler(t, &mockAnalyzerService{})
+
+	req := httptest.NewRequest("GET", "/api/jobs/missing", nil)
+	req.SetPathValue("id", "missing")
+	w := httptest.NewRecorder()
+	handler.GetJob(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
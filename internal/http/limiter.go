@@ -0,0 +1,153 @@
+package http
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"webpage-analyzer/internal/logging"
+	"webpage-analyzer/internal/openapi"
+	"webpage-analyzer/internal/problem"
+)
+
+// ConcurrencyLimiterConfig configures a ConcurrencyLimiter.
+type ConcurrencyLimiterConfig struct {
+	// MaxConcurrent is the number of requests served at once.
+	MaxConcurrent int
+	// MaxQueue is the number of requests that may wait for a free slot;
+	// requests beyond it are rejected straight away.
+	MaxQueue int
+	// QueueTimeout is the longest a request waits for a slot. Keep it well
+	// under the server's write timeout so that rejected clients hear back.
+	QueueTimeout time.Duration
+	// RetryAfter is sent in the Retry-After header of rejected requests.
+	RetryAfter time.Duration
+}
+
+// DefaultConcurrencyLimiterConfig returns the limits used by the server.
+func DefaultConcurrencyLimiterConfig() ConcurrencyLimiterConfig {
+	return ConcurrencyLimiterConfig{
+		MaxConcurrent: 20,
+		MaxQueue:      50,
+		QueueTimeout:  5 * time.Second,
+		RetryAfter:    5 * time.Second,
+	}
+}
+
+// ConcurrencyLimiter bounds how many requests are served at once. Requests
+// over the limit wait in a bounded queue; when the queue is full, or a request
+// has waited QueueTimeout, it is answered with 503 and a Retry-After header
+// instead of piling up until the server's write timeout cuts it off.
+type ConcurrencyLimiter struct {
+	cfg      ConcurrencyLimiterConfig
+	slots    chan struct{}
+	queued   atomic.Int64
+	rejected atomic.Int64
+}
+
+// LimiterStats is a point-in-time snapshot of a ConcurrencyLimiter.
+type LimiterStats struct {
+	Limit    int   `json:"limit"`
+	InFlight int   `json:"in_flight"`
+	Queued   int   `json:"queued"`
+	Rejected int64 `json:"rejected"`
+}
+
+// NewConcurrencyLimiter creates a limiter. MaxConcurrent must be positive;
+// a negative MaxQueue is treated as zero.
+func NewConcurrencyLimiter(cfg ConcurrencyLimiterConfig) *ConcurrencyLimiter {
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 1
+	}
+	if cfg.MaxQueue < 0 {
+		cfg.MaxQueue = 0
+	}
+	return &ConcurrencyLimiter{cfg: cfg, slots: make(chan struct{}, cfg.MaxConcurrent)}
+}
+
+// LimitFailures documents the response Limit adds to the operations of the
+// routes it wraps.
+var LimitFailures = []openapi.Response{
+	describedFailure(http.StatusServiceUnavailable, "Too many analyses are running or queued (code server_busy); retry after Retry-After."),
+}
+
+// Limit wraps next so that it runs under the limiter.
+func (l *ConcurrencyLimiter) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.Acquire(r.Context()) {
+			if r.Context().Err() != nil {
+				return // The client gave up while queued.
+			}
+			logging.FromContext(r.Context()).Warn("Request rejected by concurrency limiter", "path", r.URL.Path, "limit", l.cfg.MaxConcurrent)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(l.cfg.RetryAfter.Seconds()))))
+			problem.Write(w, problem.New(http.StatusServiceUnavailable, problem.CodeServerBusy, "Server is busy, please retry later"))
+			return
+		}
+		defer l.Release()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Acquire takes a slot, queueing for up to QueueTimeout, for callers that are
+// not HTTP handlers, such as gRPC interceptors. It reports false if the queue
+// is full, the wait timed out, or ctx was cancelled; only the first two count
+// as rejections. Every successful Acquire must be followed by a Release.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) bool {
+	if !l.acquire(ctx) {
+		if ctx.Err() == nil {
+			l.rejected.Add(1)
+		}
+		return false
+	}
+	return true
+}
+
+// Release frees the slot taken by Acquire.
+func (l *ConcurrencyLimiter) Release() {
+	<-l.slots
+}
+
+// RetryAfter is how long rejected callers are asked to wait before retrying.
+func (l *ConcurrencyLimiter) RetryAfter() time.Duration {
+	return l.cfg.RetryAfter
+}
+
+// acquire takes a slot, queueing for up to QueueTimeout. It reports false if
+// the queue is full, the wait timed out, or ctx was cancelled.
+func (l *ConcurrencyLimiter) acquire(ctx context.Context) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if l.queued.Add(1) > int64(l.cfg.MaxQueue) {
+		l.queued.Add(-1)
+		return false
+	}
+	defer l.queued.Add(-1)
+
+	timer := time.NewTimer(l.cfg.QueueTimeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Stats returns a snapshot of the limiter.
+func (l *ConcurrencyLimiter) Stats() LimiterStats {
+	return LimiterStats{
+		Limit:    l.cfg.MaxConcurrent,
+		InFlight: len(l.slots),
+		Queued:   int(l.queued.Load()),
+		Rejected: l.rejected.Load(),
+	}
+}
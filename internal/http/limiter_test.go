@@ -0,0 +1,133 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/worker"
+)
+
+// blockingHandler holds every request until release is closed.
+type blockingHandler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingHandler() *blockingHandler {
+	return &blockingHandler{started: make(chan struct{}, 16), release: make(chan struct{})}
+}
+
+func (h *blockingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.started <- struct{}{}
+	<-h.release
+	w.WriteHeader(http.StatusOK)
+}
+
+// serve runs one request through handler in the background.
+func serve(handler http.Handler, wg *sync.WaitGroup) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/analyze", nil))
+	}()
+	return w
+}
+
+func TestConcurrencyLimiter_RejectsWhenQueueFull(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyLimiterConfig{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: time.Minute, RetryAfter: 1500 * time.Millisecond})
+	backend := newBlockingHandler()
+	handler := limiter.Limit(backend)
+
+	var wg sync.WaitGroup
+	first := serve(handler, &wg)
+	<-backend.started
+	second := serve(handler, &wg)
+	require.Eventually(t, func() bool { return limiter.Stats().Queued == 1 }, time.Second, time.Millisecond)
+
+	third := httptest.NewRecorder()
+	handler.ServeHTTP(third, httptest.NewRequest("POST", "/api/analyze", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, third.Code, "A request beyond the queue should be rejected")
+	assert.Equal(t, "2", third.Header().Get("Retry-After"), "Retry-After should round up to whole seconds")
+
+	assert.Equal(t, LimiterStats{Limit: 1, InFlight: 1, Queued: 1, Rejected: 1}, limiter.Stats())
+
+	close(backend.release)
+	wg.Wait()
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusOK, second.Code, "The queued request should run once a slot frees up")
+	assert.Equal(t, 0, limiter.Stats().InFlight)
+}
+
+func TestConcurrencyLimiter_QueueTimeout(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyLimiterConfig{MaxConcurrent: 1, MaxQueue: 5, QueueTimeout: 20 * time.Millisecond, RetryAfter: time.Second})
+	backend := newBlockingHandler()
+	handler := limiter.Limit(backend)
+
+	var wg sync.WaitGroup
+	serve(handler, &wg)
+	<-backend.started
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/analyze", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, "A request that waits too long should be rejected")
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	close(backend.release)
+	wg.Wait()
+}
+
+func TestConcurrencyLimiter_ClientCancelsWhileQueued(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyLimiterConfig{MaxConcurrent: 1, MaxQueue: 5, QueueTimeout: time.Minute})
+	backend := newBlockingHandler()
+	handler := limiter.Limit(backend)
+
+	var wg sync.WaitGroup
+	serve(handler, &wg)
+	<-backend.started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/analyze", nil).WithContext(ctx))
+	assert.Equal(t, int64(0), limiter.Stats().Rejected, "A cancelled request should not count as rejected")
+
+	close(backend.release)
+	wg.Wait()
+}
+
+func TestConcurrencyLimiter_Acquire(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyLimiterConfig{MaxConcurrent: 1, QueueTimeout: time.Minute, RetryAfter: 3 * time.Second})
+	require.True(t, limiter.Acquire(context.Background()))
+	assert.False(t, limiter.Acquire(context.Background()), "A call beyond the limit and queue should be rejected")
+	assert.Equal(t, int64(1), limiter.Stats().Rejected)
+
+	// Requests through Limit share the slots taken by Acquire.
+	w := httptest.NewRecorder()
+	limiter.Limit(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest("POST", "/api/analyze", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	limiter.Release()
+	assert.Equal(t, 0, limiter.Stats().InFlight)
+	assert.Equal(t, 3*time.Second, limiter.RetryAfter())
+}
+
+func TestMetricsHandler_Limiter(t *testing.T) {
+	pool := worker.NewWorkerPool(1)
+	defer pool.Shutdown()
+	limiter := NewConcurrencyLimiter(ConcurrencyLimiterConfig{MaxConcurrent: 3})
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	MetricsHandler(pool, limiter, nil)(w, req)
+
+	assert.Contains(t, w.Body.String(), "analysis_concurrency_limit 3\n")
+	assert.Contains(t, w.Body.String(), "analysis_rejected_total 0\n")
+}
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/problem"
+	"webpage-analyzer/internal/worker"
+)
+
+// metric is one gauge or counter sample.
+type metric struct {
+	name  string
+	kind  string // "gauge" or "counter".
+	help  string
+	value int64
+}
+
+// MetricsHandler serves worker pool and, if limiter and dns are not nil,
+// concurrency limiter and DNS cache metrics in the Prometheus text exposition
+// format.
+func MetricsHandler(pool worker.WorkerPoolManager, limiter *ConcurrencyLimiter, dns client.DNSStatsReporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			problem.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		stats := pool.Stats()
+		metrics := []metric{
+			{"worker_pool_workers", "gauge", "Current number of workers in the analysis pool.", int64(stats.Workers)},
+			{"worker_pool_min_workers", "gauge", "Minimum number of workers the pool scales down to.", int64(stats.MinWorkers)},
+			{"worker_pool_max_workers", "gauge", "Maximum number of workers the pool scales up to.", int64(stats.MaxWorkers)},
+			{"worker_pool_busy_workers", "gauge", "Workers currently executing a task.", int64(stats.BusyWorkers)},
+			{"worker_pool_queue_depth", "gauge", "Tasks waiting for a free worker.", int64(stats.QueueDepth)},
+			{"worker_pool_tasks_submitted_total", "counter", "Tasks submitted to the pool.", stats.SubmittedTasks},
+			{"worker_pool_tasks_completed_total", "counter", "Tasks completed by the pool, including failures.", stats.CompletedTasks},
+			{"worker_pool_tasks_failed_total", "counter", "Tasks that returned an error or panicked.", stats.FailedTasks},
+		}
+		if limiter != nil {
+			ls := limiter.Stats()
+			metrics = append(metrics, []metric{
+				{"analysis_concurrency_limit", "gauge", "Analyses allowed to run at once.", int64(ls.Limit)},
+				{"analysis_in_flight", "gauge", "Analyses currently running.", int64(ls.InFlight)},
+				{"analysis_queued", "gauge", "Analysis requests waiting for a free slot.", int64(ls.Queued)},
+				{"analysis_rejected_total", "counter", "Analysis requests rejected with 503 because the server was busy.", ls.Rejected},
+			}...)
+		}
+		if dns != nil {
+			ds := dns.DNSCacheStats()
+			metrics = append(metrics, []metric{
+				{"dns_cache_entries", "gauge", "Host names with a cached DNS answer.", int64(ds.Entries)},
+				{"dns_cache_hits_total", "counter", "Outbound connections that reused a cached DNS answer.", ds.Hits},
+				{"dns_cache_misses_total", "counter", "Outbound connections that needed a DNS lookup.", ds.Misses},
+				{"dns_cache_evictions_total", "counter", "DNS answers evicted because the cache was full.", ds.Evictions},
+			}...)
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		for _, m := range metrics {
+			fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %d\n", m.name, m.help, m.name, m.kind, m.name, m.value)
+		}
+		writeTaskLatencies(w, stats.TaskLatencies)
+	}
+}
+
+// writeTaskLatencies writes the per-task latency histograms, sorted by task name.
+func writeTaskLatencies(w io.Writer, latencies map[string]worker.HistogramSnapshot) {
+	const name = "worker_task_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s Analysis task latency by task name.\n# TYPE %s histogram\n", name, name)
+
+	tasks := make([]string, 0, len(latencies))
+	for task := range latencies {
+		tasks = append(tasks, task)
+	}
+	sort.Strings(tasks)
+
+	for _, task := range tasks {
+		h := latencies[task]
+		for _, b := range h.Buckets {
+			fmt.Fprintf(w, "%s_bucket{task=%q,le=%q} %d\n", name, task, strconv.FormatFloat(b.UpperBound, 'g', -1, 64), b.Count)
+		}
+		fmt.Fprintf(w, "%s_bucket{task=%q,le=\"+Inf\"} %d\n", name, task, h.Count)
+		fmt.Fprintf(w, "%s_sum{task=%q} %g\n", name, task, h.Sum)
+		fmt.Fprintf(w, "%s_count{task=%q} %d\n", name, task, h.Count)
+	}
+}
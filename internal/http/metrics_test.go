@@ -0,0 +1,78 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/worker"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	pool := worker.NewDynamicWorkerPool(worker.PoolConfig{MinWorkers: 1, MaxWorkers: 4})
+	defer pool.Shutdown()
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	MetricsHandler(pool, nil, nil)(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "MetricsHandler() should return 200 status")
+	assert.Contains(t, w.Body.String(), "worker_pool_workers 1\n", "Metrics should report the current pool size")
+	assert.Contains(t, w.Body.String(), "worker_pool_max_workers 4\n")
+}
+
+func TestMetricsHandler_TaskCountersAndLatencies(t *testing.T) {
+	pool := worker.NewWorkerPool(2)
+	defer pool.Shutdown()
+
+	group := worker.NewAnalysisTaskGroup(pool)
+	worker.AddTask(group, "page_title", func(ctx context.Context) (string, error) { return "title", nil })
+	worker.AddTask(group, "links", func(ctx context.Context) (int, error) { return 0, errors.New("boom") })
+	group.ExecuteAll(context.Background())
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	MetricsHandler(pool, nil, nil)(w, req)
+
+	body := w.Body.String()
+	assert.Contains(t, body, "worker_pool_tasks_submitted_total 2\n")
+	assert.Contains(t, body, "worker_pool_tasks_failed_total 1\n", "Metrics should count failed tasks")
+	assert.Contains(t, body, `worker_task_duration_seconds_bucket{task="page_title",le="+Inf"} 1`, "Metrics should include per-task histograms")
+	assert.Contains(t, body, `worker_task_duration_seconds_count{task="links"} 1`)
+}
+
+func TestMetricsHandler_MethodNotAllowed(t *testing.T) {
+	pool := worker.NewWorkerPool(1)
+	defer pool.Shutdown()
+
+	req := httptest.NewRequest("POST", "/metrics", nil)
+	w := httptest.NewRecorder()
+	MetricsHandler(pool, nil, nil)(w, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+// stubDNSStats reports fixed DNS cache counters.
+type stubDNSStats client.DNSCacheStats
+
+func (s stubDNSStats) DNSCacheStats() client.DNSCacheStats { return client.DNSCacheStats(s) }
+
+func TestMetricsHandler_DNSCache(t *testing.T) {
+	pool := worker.NewWorkerPool(1)
+	defer pool.Shutdown()
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	MetricsHandler(pool, nil, stubDNSStats{Entries: 3, Hits: 40, Misses: 5, Evictions: 1})(w, req)
+
+	body := w.Body.String()
+	assert.Contains(t, body, "dns_cache_entries 3\n")
+	assert.Contains(t, body, "dns_cache_hits_total 40\n")
+	assert.Contains(t, body, "dns_cache_misses_total 5\n")
+	assert.Contains(t, body, "dns_cache_evictions_total 1\n")
+}
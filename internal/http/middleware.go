@@ -0,0 +1,226 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	mathrand "math/rand"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"webpage-analyzer/internal/logging"
+)
+
+const (
+	// requestIDHeader is the header used to propagate request IDs.
+	requestIDHeader = "X-Request-ID"
+
+	// maxRequestIDLength bounds the incoming request IDs that are reused.
+	maxRequestIDLength = 64
+)
+
+// requestIDKey is the context key for the request ID.
+type requestIDKey struct{}
+
+// AccessLogConfig configures the access-log middleware.
+type AccessLogConfig struct {
+	// SampleRate is the fraction (0.0-1.0) of successful requests to log.
+	// Requests answered with a 4xx or 5xx status are always logged.
+	SampleRate float64
+	// TrustedProxies are the networks of the reverse proxies whose
+	// X-Forwarded-For header is believed. Without any, the client IP is
+	// always the peer address.
+	TrustedProxies []netip.Prefix
+}
+
+// DefaultAccessLogConfig returns an access-log configuration that logs every request.
+func DefaultAccessLogConfig() AccessLogConfig {
+	return AccessLogConfig{SampleRate: 1.0}
+}
+
+// statusRecorder wraps a ResponseWriter to capture the status code and bytes written.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// WriteHeader records the status code before delegating.
+func (r *statusRecorder) WriteHeader(statusCode int) {
+	if r.status == 0 {
+		r.status = statusCode
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write records the number of bytes written before delegating.
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap returns the wrapped ResponseWriter, for http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// AccessLog wraps a handler and emits one structured log entry per request.
+// The request's context carries a logger that adds its request ID to every
+// line logged while serving it.
+func AccessLog(next http.Handler, cfg AccessLogConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+		ctx, logger := logging.With(r.Context(), "request_id", requestID)
+		r = r.WithContext(context.WithValue(ctx, requestIDKey{}, requestID))
+
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if !shouldLog(status, cfg.SampleRate) {
+			return
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		logger.Log(r.Context(), level, "HTTP request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status_code", status,
+			"bytes", rec.bytes,
+			"duration", time.Since(start),
+			"client_ip", clientIP(r, cfg.TrustedProxies),
+			"user_agent", r.UserAgent(),
+		)
+	})
+}
+
+// RequestIDFromContext returns the request ID stored by AccessLog, if any.
+func RequestIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// shouldLog decides whether a request with the given status is logged.
+func shouldLog(status int, sampleRate float64) bool {
+	if status >= http.StatusBadRequest || sampleRate >= 1 {
+		return true
+	}
+	if sampleRate <= 0 {
+		return false
+	}
+	return mathrand.Float64() < sampleRate
+}
+
+// newRequestID generates a random hex request ID.
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// validRequestID reports whether an incoming request ID is safe to log and
+// echo: 1 to 64 letters, digits, dots, dashes and underscores.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ParseTrustedProxies parses the IP addresses and CIDR networks of trusted
+// reverse proxies.
+func ParseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if strings.Contains(value, "/") {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %v", value, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %v", value, err)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return prefixes, nil
+}
+
+// clientIP returns the originating client IP. X-Forwarded-For is only
+// believed when the peer is a trusted proxy; it is then read from the right,
+// skipping trusted proxies, so that entries a client prepended are ignored.
+func clientIP(r *http.Request, trusted []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer, err := netip.ParseAddr(host)
+	if err != nil || !isTrusted(peer, trusted) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// A malformed entry is not trusted, nor anything left of it.
+			break
+		}
+		if !isTrusted(addr, trusted) {
+			return addr.String()
+		}
+		peer = addr
+	}
+	return peer.String()
+}
+
+// isTrusted reports whether addr belongs to a trusted proxy network.
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/logging"
+)
+
+func TestAccessLog_RecordsStatusAndRequestID(t *testing.T) {
+	var seenID string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenID = RequestIDFromContext(r.Context())
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte("short and stout"))
+	})
+
+	handler := AccessLog(next, DefaultAccessLogConfig())
+
+	req := httptest.NewRequest("GET", "/api/health", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTeapot, w.Code, "AccessLog() should not change the status code")
+	assert.NotEmpty(t, seenID, "AccessLog() should generate a request ID")
+	assert.Equal(t, seenID, w.Header().Get(requestIDHeader), "Request ID should be echoed in the response header")
+}
+
+func TestAccessLog_PropagatesIncomingRequestID(t *testing.T) {
+	var seenID string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenID = RequestIDFromContext(r.Context())
+	})
+
+	handler := AccessLog(next, AccessLogConfig{SampleRate: 0})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(requestIDHeader, "abc123")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, "abc123", seenID, "AccessLog() should reuse the incoming request ID")
+	assert.Equal(t, "abc123", w.Header().Get(requestIDHeader))
+}
+
+func TestAccessLog_ContextLoggerCarriesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context()).Info("Handling request")
+	})
+
+	handler := AccessLog(next, DefaultAccessLogConfig())
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(requestIDHeader, "abc123")
+	req = req.WithContext(logging.NewContext(req.Context(), slog.New(slog.NewJSONHandler(&buf, nil))))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2, "Expected the handler's line and the access log line")
+	for _, line := range lines {
+		assert.Contains(t, line, `"request_id":"abc123"`, "Every line of the request should carry its ID")
+	}
+}
+
+func TestShouldLog(t *testing.T) {
+	assert.True(t, shouldLog(http.StatusOK, 1), "Full sampling should log every request")
+	assert.False(t, shouldLog(http.StatusOK, 0), "Zero sampling should skip successful requests")
+	assert.True(t, shouldLog(http.StatusNotFound, 0), "Client errors should always be logged")
+	assert.True(t, shouldLog(http.StatusInternalServerError, 0), "Server errors should always be logged")
+}
+
+func TestAccessLog_ReplacesInvalidRequestID(t *testing.T) {
+	handler := AccessLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), AccessLogConfig{SampleRate: 0})
+
+	for _, id := range []string{"abc\n{\"level\":\"ERROR\"}", "<script>", strings.Repeat("a", 65)} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(requestIDHeader, id)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		echoed := w.Header().Get(requestIDHeader)
+		assert.NotEqual(t, id, echoed, "Unsafe request IDs should not be reused")
+		assert.True(t, validRequestID(echoed), "A fresh request ID should replace %q", id)
+	}
+	assert.True(t, validRequestID("req-2024.01_abc"))
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "198.51.100.9:1234"
+	assert.Equal(t, "198.51.100.9", clientIP(req, trusted))
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	assert.Equal(t, "198.51.100.9", clientIP(req, trusted), "X-Forwarded-For from an untrusted peer should be ignored")
+	assert.Equal(t, "198.51.100.9", clientIP(req, nil), "Without trusted proxies X-Forwarded-For should be ignored")
+
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("X-Forwarded-For", "1.2.3.4, 203.0.113.7, 10.0.0.1")
+	assert.Equal(t, "203.0.113.7", clientIP(req, trusted), "The rightmost untrusted hop should be the client; earlier entries are spoofable")
+
+	req.Header.Set("X-Forwarded-For", "evil\nvalue")
+	assert.Equal(t, "192.0.2.1", clientIP(req, trusted), "Malformed entries should not be logged")
+
+	_, err = ParseTrustedProxies([]string{"not-an-ip"})
+	assert.Error(t, err)
+}
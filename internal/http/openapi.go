@@ -0,0 +1,114 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"webpage-analyzer/internal/logging"
+	"webpage-analyzer/internal/openapi"
+	"webpage-analyzer/internal/problem"
+)
+
+// Security schemes operations refer to in the OpenAPI spec.
+const (
+	AdminTokenScheme = "AdminToken"
+	APIKeyScheme     = "APIKey"
+)
+
+// AdminTokenSecurity is the security scheme of the admin token.
+var AdminTokenSecurity = openapi.SecurityScheme{
+	Type:        "http",
+	Scheme:      "bearer",
+	Description: `Admin token, sent as "Authorization: Bearer <token>"`,
+}
+
+// APIKeySecurity is the security scheme of tenants' API keys. They may also
+// be sent as "Authorization: Bearer <key>".
+var APIKeySecurity = openapi.SecurityScheme{
+	Type:        "apiKey",
+	In:          "header",
+	Name:        apiKeyHeader,
+	Description: `Tenant API key, sent in the X-API-Key header or as "Authorization: Bearer <key>"`,
+}
+
+// failure documents a problem response of status.
+func failure(status int) openapi.Response {
+	return openapi.Response{Status: status, Body: problem.Problem{}, ContentType: problem.ContentType}
+}
+
+// describedFailure documents a problem response of status, returned when
+// description says.
+func describedFailure(status int, description string) openapi.Response {
+	r := failure(status)
+	r.Description = description
+	return r
+}
+
+// failures documents a problem response of each of statuses, in order.
+func failures(statuses ...int) []openapi.Response {
+	slices.Sort(statuses)
+	statuses = slices.Compact(statuses)
+	responses := make([]openapi.Response, len(statuses))
+	for i, status := range statuses {
+		responses[i] = failure(status)
+	}
+	return responses
+}
+
+// OpenAPIDoc documents OpenAPIHandler for the OpenAPI spec.
+var OpenAPIDoc = openapi.Operation{
+	Method:      http.MethodGet,
+	Summary:     "Get OpenAPI specification",
+	Description: "Retrieve the OpenAPI specification for this API, generated from the registered routes. It is YAML unless format=json is given or the Accept header asks for application/json.",
+	Tags:        []string{"System"},
+	Params: []openapi.Param{
+		{Name: "format", In: "query", Description: "yaml (default) or json"},
+	},
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Description: "OpenAPI specification", Body: &openapi.Schema{Type: "string"}, ContentType: "application/yaml"},
+		failure(http.StatusBadRequest),
+		failure(http.StatusInternalServerError),
+	},
+}
+
+// OpenAPIHandler serves doc as YAML or JSON. The document is rendered once,
+// so routes must be documented before the handler is created.
+func OpenAPIHandler(doc *openapi.Document) http.HandlerFunc {
+	yamlSpec, yamlErr := doc.YAML()
+	jsonSpec, jsonErr := doc.JSON()
+	if yamlErr != nil || jsonErr != nil {
+		slog.Error("Failed to render OpenAPI spec", "yaml_error", yamlErr, "json_error", jsonErr)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			problem.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		contentType, spec, err := "application/yaml", yamlSpec, yamlErr
+		switch format := r.URL.Query().Get("format"); format {
+		case "json":
+			contentType, spec, err = "application/json", jsonSpec, jsonErr
+		case "yaml":
+		case "":
+			if strings.Contains(r.Header.Get("Accept"), "application/json") {
+				contentType, spec, err = "application/json", jsonSpec, jsonErr
+			}
+		default:
+			problem.Error(w, "Invalid format parameter: expected yaml or json", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			problem.Error(w, "Failed to render OpenAPI spec", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		if _, err := w.Write(spec); err != nil {
+			logging.FromContext(r.Context()).Error("Failed to write OpenAPI spec response", "error", err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"webpage-analyzer/internal/openapi"
+)
+
+func TestOpenAPIHandler(t *testing.T) {
+	doc := openapi.NewDocument(openapi.Info{Title: "Webpage Analyzer API", Version: "1.0.0"})
+	doc.Add("/api/stats", StatsDoc)
+	handler := OpenAPIHandler(doc)
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/openapi", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/yaml", w.Header().Get("Content-Type"), "The spec should be YAML by default")
+	var fromYAML map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(w.Body.Bytes(), &fromYAML))
+	assert.Contains(t, fromYAML["paths"], "/api/stats")
+
+	for _, r := range []*http.Request{
+		httptest.NewRequest("GET", "/api/openapi?format=json", nil),
+		func() *http.Request {
+			r := httptest.NewRequest("GET", "/api/openapi", nil)
+			r.Header.Set("Accept", "application/json")
+			return r
+		}(),
+	} {
+		w = httptest.NewRecorder()
+		handler(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		var fromJSON map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &fromJSON))
+		assert.Equal(t, fromYAML["paths"], fromJSON["paths"], "Both formats should hold the same spec")
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/api/openapi?format=xml", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+}
+
+func TestFailure(t *testing.T) {
+	doc := openapi.NewDocument(openapi.Info{Title: "Webpage Analyzer API", Version: "1.0.0"})
+	doc.Add("/api/stats", StatsDoc)
+	data, err := doc.JSON()
+	require.NoError(t, err)
+
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Responses map[string]struct {
+				Content map[string]struct {
+					Schema openapi.Schema `json:"schema"`
+				} `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(data, &spec))
+	content := spec.Paths["/api/stats"]["get"].Responses["400"].Content
+	require.Contains(t, content, "application/problem+json", "Failures should be problem details")
+	assert.Equal(t, "#/components/schemas/problem.Problem", content["application/problem+json"].Schema.Ref)
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"webpage-analyzer/internal/openapi"
+	"webpage-analyzer/internal/worker"
+)
+
+// readinessTimeout bounds the checks of one readiness probe. Orchestrators
+// probe often, so the checks must stay cheap.
+const readinessTimeout = 2 * time.Second
+
+// LivezDoc documents Livez for the OpenAPI spec.
+var LivezDoc = openapi.Operation{
+	Method:      http.MethodGet,
+	Summary:     "Liveness probe",
+	Description: "Report that the process is up",
+	Tags:        []string{"System"},
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Body: map[string]string{}},
+	},
+}
+
+// Livez handles liveness probes. It answers 200 as long as the process can
+// serve HTTP at all, so that an orchestrator only restarts a hung server, not
+// one whose dependencies are down.
+func Livez(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, http.StatusOK, map[string]string{"status": "alive"})
+}
+
+// Readiness tracks whether the server is ready to accept analyses: it has
+// been marked ready once its configuration was validated and its services
+// started, it has not started shutting down, and its checks pass.
+type Readiness struct {
+	ready  atomic.Bool
+	checks []DependencyCheck
+}
+
+// NewReadiness returns a Readiness, not yet ready, that also runs checks on
+// every probe.
+func NewReadiness(checks ...DependencyCheck) *Readiness {
+	return &Readiness{checks: checks}
+}
+
+// SetReady marks the server ready or, when it starts shutting down, not ready.
+func (rd *Readiness) SetReady(ready bool) {
+	rd.ready.Store(ready)
+}
+
+// ReadyzDoc documents the readiness probe handler for the OpenAPI spec.
+var ReadyzDoc = openapi.Operation{
+	Method:      http.MethodGet,
+	Summary:     "Readiness probe",
+	Description: "Report whether the server is ready to accept analyses: started, not shutting down, store connected and worker pool running",
+	Tags:        []string{"System"},
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Body: map[string]string{}},
+		{Status: http.StatusServiceUnavailable, Body: map[string]string{}},
+	},
+}
+
+// Handler handles readiness probes: 200 when ready, otherwise 503 with the
+// reason, so that an orchestrator stops routing analyses to the server.
+func (rd *Readiness) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !rd.ready.Load() {
+			writeProbe(w, http.StatusServiceUnavailable, map[string]string{"status": "not ready", "reason": "starting or shutting down"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+		for _, check := range rd.checks {
+			if err := check.Check(ctx); err != nil {
+				writeProbe(w, http.StatusServiceUnavailable, map[string]string{"status": "not ready", "reason": check.Name + ": " + err.Error()})
+				return
+			}
+		}
+		writeProbe(w, http.StatusOK, map[string]string{"status": "ready"})
+	}
+}
+
+// writeProbe writes a probe response. Probes are never cached.
+func writeProbe(w http.ResponseWriter, statusCode int, body map[string]string) {
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, statusCode, body)
+}
+
+// WorkerPoolRunningCheck checks that the worker pool has not been shut down.
+// Unlike WorkerPoolCheck it runs no task, so a busy pool still counts as ready.
+func WorkerPoolRunningCheck(pool worker.WorkerPoolManager) DependencyCheck {
+	return DependencyCheck{Name: "worker_pool", Check: func(ctx context.Context) error {
+		if pool.Closed() {
+			return worker.ErrPoolClosed
+		}
+		return nil
+	}}
+}
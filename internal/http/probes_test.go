@@ -0,0 +1,52 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"webpage-analyzer/internal/worker"
+)
+
+func TestLivez(t *testing.T) {
+	w := httptest.NewRecorder()
+	Livez(w, httptest.NewRequest("GET", "/livez", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status": "alive"}`, w.Body.String())
+}
+
+func TestReadiness(t *testing.T) {
+	pool := worker.NewWorkerPool(1)
+	storeErr := error(nil)
+	readiness := NewReadiness(
+		DependencyCheck{Name: "store", Check: func(ctx context.Context) error { return storeErr }},
+		WorkerPoolRunningCheck(pool),
+	)
+	probe := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		readiness.Handler()(w, httptest.NewRequest("GET", "/readyz", nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, probe().Code, "A server that has not started should not be ready")
+
+	readiness.SetReady(true)
+	w := probe()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status": "ready"}`, w.Body.String())
+
+	storeErr = errors.New("connection refused")
+	w = probe()
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"status": "not ready", "reason": "store: connection refused"}`, w.Body.String())
+
+	storeErr = nil
+	pool.Shutdown()
+	w = probe()
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, "A shut down worker pool should not be ready")
+	assert.Contains(t, w.Body.String(), "worker_pool")
+}
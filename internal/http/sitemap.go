@@ -0,0 +1,77 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/jobs"
+	"webpage-analyzer/internal/logging"
+	"webpage-analyzer/internal/openapi"
+)
+
+// AnalyzeSitemapDoc documents AnalyzeSitemap for the OpenAPI spec.
+var AnalyzeSitemapDoc = openapi.Operation{
+	Method:      http.MethodPost,
+	Summary:     "Analyze the pages of a sitemap",
+	Description: "Queue an analysis of every URL listed in a sitemap, following sitemap index files. The analysis runs in the background; poll the returned status URL for progress and, once done, the page analyses and a site-wide summary.",
+	Tags:        []string{"Analysis"},
+	Request:     openapi.JSONRequest(analyzer.SitemapRequest{}),
+	Responses: []openapi.Response{
+		{Status: http.StatusAccepted, Body: jobs.Job{}},
+		failure(http.StatusBadRequest),
+		failure(http.StatusServiceUnavailable),
+	},
+}
+
+// AnalyzeSitemap handles requests to analyze every page of a sitemap.
+func (h *Handler) AnalyzeSitemap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req analyzer.SitemapRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("Failed to decode request body", "error", err)
+		h.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.SitemapURL == "" {
+		h.writeError(w, http.StatusBadRequest, "sitemap_url is required")
+		return
+	}
+
+	h.enqueueJob(w, r, jobs.KindSitemap, req, "/api/analyze/from-sitemap/")
+}
+
+// GetSitemapJobDoc documents GetSitemapJob for the OpenAPI spec.
+var GetSitemapJobDoc = openapi.Operation{
+	Method:      http.MethodGet,
+	Summary:     "Get a sitemap analysis",
+	Description: "Return the status and progress of a sitemap analysis, with its analyzer.SitemapResult once done",
+	Tags:        []string{"Analysis"},
+	Params: []openapi.Param{
+		{Name: "id", In: "path", Description: "Job ID"},
+	},
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Body: jobs.Job{}},
+		failure(http.StatusNotFound),
+		failure(http.StatusServiceUnavailable),
+	},
+}
+
+// GetSitemapJob handles sitemap analysis status requests.
+func (h *Handler) GetSitemapJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if job := h.loadJob(w, r); job != nil {
+		if job.Kind != jobs.KindSitemap {
+			h.writeError(w, http.StatusNotFound, "Job not found")
+			return
+		}
+		h.writeJSON(w, http.StatusOK, job)
+	}
+}
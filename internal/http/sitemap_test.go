@@ -0,0 +1,139 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/jobs"
+)
+
+// newJobsHandler returns a handler backed by an in-memory job queue whose
+// jobs are run against svc.
+func newJobsHandler(t *testing.T, svc analyzer.Service) *Handler {
+	t.Helper()
+	queue, err := jobs.Open(context.Background(), jobs.Config{
+		Driver: jobs.DriverMemory,
+		Retry:  jobs.RetryPolicy{MaxAttempts: 1},
+	})
+	require.NoError(t, err)
+
+	runner := jobs.NewRunner(queue, jobs.RunnerConfig{Workers: 1, PollInterval: 5 * time.Millisecond})
+	jobs.RegisterAnalyzerHandlers(runner, svc)
+	require.NoError(t, runner.Start(context.Background()))
+	t.Cleanup(func() {
+		runner.Stop()
+		queue.Close()
+	})
+	return NewHandlerWithJobs(svc, nil, queue)
+}
+
+// postSitemap submits a sitemap analysis and returns the response recorder.
+func postSitemap(handler *Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/api/analyze/from-sitemap", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handler.AnalyzeSitemap(w, req)
+	return w
+}
+
+// waitForSitemapJob polls the job until it is done or failed.
+func waitForSitemapJob(t *testing.T, handler *Handler, id string) jobs.Job {
+	t.Helper()
+	var job jobs.Job
+	require.Eventually(t, func() bool {
+		req := httptest.NewRequest("GET", "/api/analyze/from-sitemap/"+id, nil)
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		handler.GetSitemapJob(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&job))
+		return job.Status == jobs.StatusDone || job.Status == jobs.StatusFailed
+	}, 2*time.Second, 5*time.Millisecond)
+	return job
+}
+
+func TestAnalyzeSitemap_Completed(t *testing.T) {
+	handler := newJobsHandler(t, &mockAnalyzerService{analysisResult: &analyzer.WebpageAnalysis{URL: "https://example.com/"}})
+
+	w := postSitemap(handler, `{"sitemap_url": "https://example.com/sitemap.xml"}`)
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	var started jobs.Job
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&started))
+	assert.NotEmpty(t, started.ID)
+	assert.Equal(t, jobs.KindSitemap, started.Kind)
+	assert.Equal(t, "/api/analyze/from-sitemap/"+started.ID, w.Header().Get("Location"))
+
+	job := waitForSitemapJob(t, handler, started.ID)
+	assert.Equal(t, jobs.StatusDone, job.Status)
+
+	var progress analyzer.SitemapProgress
+	require.NoError(t, json.Unmarshal(job.Progress, &progress))
+	assert.Equal(t, analyzer.SitemapProgress{Total: 1, Completed: 1}, progress)
+
+	var result analyzer.SitemapResult
+	require.NoError(t, json.Unmarshal(job.Result, &result))
+	assert.Len(t, result.Pages, 1)
+}
+
+func TestAnalyzeSitemap_Failed(t *testing.T) {
+	handler := newJobsHandler(t, &mockAnalyzerService{
+		analysisError: &analyzer.AnalysisError{StatusCode: 404, ErrorMessage: "Not Found", URL: "https://example.com/sitemap.xml"},
+	})
+
+	w := postSitemap(handler, `{"sitemap_url": "https://example.com/sitemap.xml"}`)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var started jobs.Job
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&started))
+
+	job := waitForSitemapJob(t, handler, started.ID)
+	assert.Equal(t, jobs.StatusFailed, job.Status)
+	assert.Contains(t, job.Error, "Not Found")
+}
+
+func TestAnalyzeSitemap_BadRequest(t *testing.T) {
+	handler := newJobsHandler(t, &mockAnalyzerService{})
+
+	assert.Equal(t, http.StatusBadRequest, postSitemap(handler, `{`).Code)
+	assert.Equal(t, http.StatusBadRequest, postSitemap(handler, `{"max_urls": 10}`).Code)
+}
+
+func TestAnalyzeSitemap_TooManyPending(t *testing.T) {
+	queue, err := jobs.Open(context.Background(), jobs.Config{Driver: jobs.DriverMemory})
+	require.NoError(t, err)
+	defer queue.Close()
+	for i := 0; i < maxPendingJobs; i++ {
+		_, err := queue.Enqueue(context.Background(), jobs.KindSitemap, nil)
+		require.NoError(t, err)
+	}
+	handler := NewHandlerWithJobs(&mockAnalyzerService{}, nil, queue)
+
+	w := postSitemap(handler, `{"sitemap_url": "https://example.com/sitemap.xml"}`)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestAnalyzeSitemap_JobsDisabled(t *testing.T) {
+	handler := NewHandler(&mockAnalyzerService{})
+
+	w := postSitemap(handler, `{"sitemap_url": "https://example.com/sitemap.xml"}`)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestGetSitemapJob_NotFound(t *testing.T) {
+	handler := newJobsHandler(t, &mockAnalyzerService{})
+
+	req := httptest.NewRequest("GET", "/api/analyze/from-sitemap/missing", nil)
+	req.SetPathValue("id", "missing")
+	w := httptest.NewRecorder()
+	handler.GetSitemapJob(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
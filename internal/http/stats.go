@@ -0,0 +1,127 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"webpage-analyzer/internal/logging"
+	"webpage-analyzer/internal/openapi"
+	"webpage-analyzer/internal/problem"
+	"webpage-analyzer/internal/store"
+)
+
+// Window limits for the stats endpoint.
+const (
+	defaultStatsWindows = "1h,24h,7d"
+	maxStatsWindows     = 5
+	maxStatsWindow      = 366 * 24 * time.Hour
+)
+
+// StatsReport summarizes the analyses of one or more windows ending now.
+type StatsReport struct {
+	GeneratedAt time.Time     `json:"generated_at" example:"2024-01-15T10:30:00Z"`
+	Windows     []WindowStats `json:"windows"`
+}
+
+// WindowStats are the statistics of one window.
+type WindowStats struct {
+	Window string `json:"window" example:"24h"`
+	*store.Stats
+}
+
+// StatsDoc documents Stats for the OpenAPI spec.
+var StatsDoc = openapi.Operation{
+	Method:      http.MethodGet,
+	Summary:     "Get analysis statistics",
+	Description: "Summarize the analyses of recent time windows: totals, success and error ratios, errors by code, the most analyzed domains, the average processing time of fresh analyses and the cache hit rate. Failed analyses and cache hits are counted too; pages analyzed by sitemap and crawl jobs are not. With an API key, only the analyses of its tenant are summarized.",
+	Tags:        []string{"History"},
+	Params: []openapi.Param{
+		{Name: "window", In: "query", Description: "Comma-separated windows ending now, as durations such as 90m or 24h or as days such as 7d (default 1h,24h,7d; at most 5, each at most 366d)"},
+		{Name: "top", In: "query", Type: "integer", Description: "Number of top domains per window (default 10, max 100)"},
+	},
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Body: StatsReport{}},
+		failure(http.StatusBadRequest),
+		failure(http.StatusServiceUnavailable),
+	},
+}
+
+// Stats handles usage statistics requests.
+func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if h.historyStore == nil {
+		h.writeProblem(w, http.StatusServiceUnavailable, problem.CodeFeatureDisabled, "Analysis history is not enabled")
+		return
+	}
+
+	params := r.URL.Query()
+	windows := params.Get("window")
+	if windows == "" {
+		windows = defaultStatsWindows
+	}
+	names := strings.Split(windows, ",")
+	if len(names) > maxStatsWindows {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid window parameter: at most %d windows", maxStatsWindows))
+		return
+	}
+	var top int
+	if t := params.Get("top"); t != "" {
+		var err error
+		if top, err = strconv.Atoi(t); err != nil || top <= 0 || top > store.MaxTopDomains {
+			h.writeError(w, http.StatusBadRequest, "Invalid top parameter: expected an integer between 1 and 100")
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	report := StatsReport{GeneratedAt: now, Windows: make([]WindowStats, 0, len(names))}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		window, err := parseStatsWindow(name)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid window parameter %q: %v", name, err))
+			return
+		}
+		stats, err := h.historyStore.Stats(r.Context(), store.StatsQuery{From: now.Add(-window), To: now, TopDomains: top})
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Failed to compute statistics", "window", name, "error", err)
+			h.writeError(w, http.StatusInternalServerError, "Failed to compute statistics")
+			return
+		}
+		report.Windows = append(report.Windows, WindowStats{Window: name, Stats: stats})
+	}
+
+	h.writeJSON(w, http.StatusOK, report)
+}
+
+// parseStatsWindow parses a window as a Go duration or a number of days
+// such as "7d".
+func parseStatsWindow(value string) (time.Duration, error) {
+	var (
+		window time.Duration
+		err    error
+	)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		var n int
+		if n, err = strconv.Atoi(days); err == nil {
+			window = time.Duration(n) * 24 * time.Hour
+		}
+	} else {
+		window, err = time.ParseDuration(value)
+	}
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("expected a duration such as 24h or a number of days such as 7d")
+	case window <= 0:
+		return 0, fmt.Errorf("must be positive")
+	case window > maxStatsWindow:
+		return 0, fmt.Errorf("must be at most 366d")
+	}
+	return window, nil
+}
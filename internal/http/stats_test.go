@@ -0,0 +1,81 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/store"
+)
+
+func TestStats(t *testing.T) {
+	st, err := store.Open(context.Background(), store.Config{Driver: store.DriverSQLite, DSN: ":memory:"})
+	require.NoError(t, err)
+	defer st.Close()
+	now := time.Now()
+	for _, e := range []store.Event{
+		{URL: "https://example.com/a", At: now.Add(-10 * time.Minute), Duration: 200 * time.Millisecond},
+		{URL: "https://example.com/b", At: now.Add(-3 * time.Hour), ErrorCode: "timeout"},
+		{URL: "https://old.example.org", At: now.Add(-3 * 24 * time.Hour), CacheHit: true},
+	} {
+		require.NoError(t, st.RecordEvent(context.Background(), e))
+	}
+	handler := NewHandlerWithStore(&mockAnalyzerService{}, st)
+
+	w := httptest.NewRecorder()
+	handler.Stats(w, httptest.NewRequest("GET", "/api/stats?window=1h,24h,7d&top=1", nil))
+	require.Equal(t, http.StatusOK, w.Code, "Stats() should return 200 status")
+
+	var report StatsReport
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	require.Len(t, report.Windows, 3)
+	totals := map[string]int64{}
+	for _, window := range report.Windows {
+		totals[window.Window] = window.TotalAnalyses
+	}
+	assert.Equal(t, map[string]int64{"1h": 1, "24h": 2, "7d": 3}, totals, "Each window should count the analyses it covers")
+
+	day := report.Windows[1]
+	assert.InDelta(t, 0.5, day.ErrorRatio, 1e-9)
+	assert.Equal(t, map[string]int64{"timeout": 1}, day.Errors)
+	assert.Equal(t, []store.DomainCount{{Domain: "example.com", Analyses: 2}}, day.TopDomains)
+	assert.Equal(t, "200ms", day.AvgProcessingTime)
+	assert.InDelta(t, 1.0/3, report.Windows[2].CacheHitRate, 1e-9)
+}
+
+func TestStats_DefaultWindows(t *testing.T) {
+	_, st := newHistoryHandler(t)
+	w := httptest.NewRecorder()
+	NewHandlerWithStore(&mockAnalyzerService{}, st).Stats(w, httptest.NewRequest("GET", "/api/stats", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var report StatsReport
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	require.Len(t, report.Windows, 3)
+	assert.Equal(t, "1h", report.Windows[0].Window)
+	assert.Equal(t, "7d", report.Windows[2].Window)
+}
+
+func TestStats_InvalidParams(t *testing.T) {
+	_, st := newHistoryHandler(t)
+	handler := NewHandlerWithStore(&mockAnalyzerService{}, st)
+
+	for _, query := range []string{"window=forever", "window=-1h", "window=0d", "window=400d", "window=1h,2h,3h,4h,5h,6h", "top=0", "top=101"} {
+		w := httptest.NewRecorder()
+		handler.Stats(w, httptest.NewRequest("GET", "/api/stats?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Stats() should reject %q", query)
+	}
+}
+
+func TestStats_StoreDisabled(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewHandler(&mockAnalyzerService{}).Stats(w, httptest.NewRequest("GET", "/api/stats", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, "Stats() should return 503 without a store")
+}
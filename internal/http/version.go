@@ -0,0 +1,31 @@
+package http
+
+import (
+	"net/http"
+
+	"webpage-analyzer/internal/openapi"
+	"webpage-analyzer/internal/problem"
+	"webpage-analyzer/internal/version"
+)
+
+// VersionDoc documents the version endpoint for the OpenAPI spec.
+var VersionDoc = openapi.Operation{
+	Method:      http.MethodGet,
+	Summary:     "Build and version info",
+	Description: "Report the version, git commit, build date and Go version of the server, and which optional features it has enabled",
+	Tags:        []string{"System"},
+	Responses: []openapi.Response{
+		{Status: http.StatusOK, Body: version.Info{}},
+	},
+}
+
+// VersionHandler serves the build details and enabled features of the server.
+func VersionHandler(info version.Info) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			problem.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, info)
+	}
+}
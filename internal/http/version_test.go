@@ -0,0 +1,24 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"webpage-analyzer/internal/version"
+)
+
+func TestVersionHandler(t *testing.T) {
+	info := version.Info{Version: "v1.4.0", Commit: "9b2c1e4", GoVersion: "go1.22.4", Features: map[string]bool{"grpc": true}}
+
+	w := httptest.NewRecorder()
+	VersionHandler(info)(w, httptest.NewRequest("GET", "/api/version", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"version": "v1.4.0", "commit": "9b2c1e4", "go_version": "go1.22.4", "features": {"grpc": true}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	VersionHandler(info)(w, httptest.NewRequest("POST", "/api/version", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+// Job kinds run by the analyzer service.
+const (
+	KindSitemap = "sitemap" // Payload analyzer.SitemapRequest, result analyzer.SitemapResult.
+	KindCrawl   = "crawl"   // Payload analyzer.CrawlRequest, result analyzer.CrawlResult.
+)
+
+// RegisterAnalyzerHandlers registers the handlers of the analyzer job kinds.
+func RegisterAnalyzerHandlers(r *Runner, svc analyzer.Service) {
+	r.Handle(KindSitemap, SitemapHandler(svc))
+	r.Handle(KindCrawl, CrawlHandler(svc))
+	r.Handle(KindWarm, WarmHandler(svc))
+}
+
+// SitemapHandler runs sitemap jobs, reporting analyzer.SitemapProgress.
+func SitemapHandler(svc analyzer.Service) HandlerFunc {
+	return func(ctx context.Context, job *Job, progress func(v interface{})) (interface{}, error) {
+		var req analyzer.SitemapRequest
+		if err := json.Unmarshal(job.Payload, &req); err != nil {
+			return nil, Permanent(fmt.Errorf("invalid sitemap job payload: %v", err))
+		}
+		req.Progress = func(p analyzer.SitemapProgress) { progress(p) }
+		result, err := svc.AnalyzeSitemap(ctx, req)
+		return result, classify(err)
+	}
+}
+
+// CrawlHandler runs crawl jobs.
+func CrawlHandler(svc analyzer.Service) HandlerFunc {
+	return func(ctx context.Context, job *Job, progress func(v interface{})) (interface{}, error) {
+		var req analyzer.CrawlRequest
+		if err := json.Unmarshal(job.Payload, &req); err != nil {
+			return nil, Permanent(fmt.Errorf("invalid crawl job payload: %v", err))
+		}
+		result, err := svc.CrawlSite(ctx, req)
+		return result, classify(err)
+	}
+}
+
+// classify marks analysis errors that retrying cannot fix, such as invalid
+// requests and missing pages, as permanent. Timeouts, rate limits, server
+// errors and network failures are retried, no sooner than the site asked.
+func classify(err error) error {
+	var analysisErr *analyzer.AnalysisError
+	if !errors.As(err, &analysisErr) {
+		return err
+	}
+	if analysisErr.RetryAfter > 0 {
+		return RetryAfter(err, time.Duration(analysisErr.RetryAfter)*time.Second)
+	}
+	switch code := analysisErr.StatusCode; {
+	case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests:
+		return err
+	case code >= 400 && code < 500:
+		return Permanent(err)
+	default:
+		return err
+	}
+}
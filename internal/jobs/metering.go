@@ -0,0 +1,97 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/apikeys"
+	"webpage-analyzer/internal/client"
+	"webpage-analyzer/internal/logging"
+)
+
+// Owner is who queued a job. It is recorded in the job's payload, next to the
+// request's own fields, so that the job's pages are charged to the API key
+// that queued it.
+type Owner struct {
+	Tenant   string `json:"tenant,omitempty"`
+	APIKeyID string `json:"api_key_id,omitempty"`
+}
+
+// Owned returns payload, which must encode as a JSON object, with owner's
+// fields added, to be passed to Enqueue.
+func Owned(payload interface{}, owner Owner) (json.RawMessage, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	if owner == (Owner{}) {
+		return data, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("a job owned by a tenant needs an object payload: %v", err)
+	}
+	if fields == nil {
+		fields = make(map[string]json.RawMessage)
+	}
+	fields["tenant"], _ = json.Marshal(owner.Tenant)
+	fields["api_key_id"], _ = json.Marshal(owner.APIKeyID)
+	return json.Marshal(fields)
+}
+
+// Owner returns who queued the job, as recorded in its payload.
+func (j *Job) Owner() Owner {
+	var owner Owner
+	_ = json.Unmarshal(j.Payload, &owner) // Payloads that are not objects have no owner.
+	return owner
+}
+
+// Metered wraps handlers so that every page a job analyzes counts as one
+// analysis of the API key recorded in its payload, and the bytes it fetches
+// are added to the key's usage. A page past the key's daily quota fails with
+// quota_exceeded; a job whose key was revoked fails for good. Jobs queued
+// without a key run as they are.
+func Metered(keys apikeys.Store, meter *apikeys.Meter) func(HandlerFunc) HandlerFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, job *Job, progress func(v interface{})) (interface{}, error) {
+			owner := job.Owner()
+			if owner.APIKeyID == "" {
+				return next(ctx, job, progress)
+			}
+			key, err := keys.Get(ctx, owner.APIKeyID)
+			if errors.Is(err, apikeys.ErrNotFound) {
+				return nil, Permanent(fmt.Errorf("the API key that queued the job no longer exists"))
+			}
+			if err != nil {
+				return nil, err
+			}
+			if key.Revoked() {
+				return nil, Permanent(fmt.Errorf("the API key that queued the job has been revoked"))
+			}
+
+			ctx, _ = logging.With(apikeys.WithKey(ctx, key), "tenant", key.Tenant, "api_key", key.ID)
+			ctx = analyzer.WithPageMeter(ctx, func(ctx context.Context, pageURL string) error {
+				err := meter.Charge(ctx, key)
+				var limitErr *apikeys.LimitError
+				if errors.As(err, &limitErr) {
+					return &analyzer.AnalysisError{
+						StatusCode:   http.StatusTooManyRequests,
+						Code:         limitErr.Code,
+						ErrorMessage: limitErr.Message,
+						URL:          pageURL,
+						RetryAfter:   limitErr.RetryAfterSeconds(),
+					}
+				}
+				return err
+			})
+			var fetched atomic.Int64
+			defer func() { meter.AddBytes(ctx, key, fetched.Load()) }()
+			return next(client.WithBytesFetched(ctx, &fetched), job, progress)
+		}
+	}
+}
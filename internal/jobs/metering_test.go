@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/apikeys"
+	"webpage-analyzer/internal/parser"
+	"webpage-analyzer/internal/worker"
+)
+
+// pageClient serves the same small page for every URL.
+type pageClient struct{}
+
+func (pageClient) FetchWebpage(ctx context.Context, url string) ([]byte, int, error) {
+	return []byte(`<html><head><title>Page</title></head><body></body></html>`), 200, nil
+}
+
+func (pageClient) ParseHTML(content []byte) (*html.Node, error) {
+	return html.Parse(bytes.NewReader(content))
+}
+
+// startMeteredRunner runs warm jobs metered against a fresh in-memory key store.
+func startMeteredRunner(t *testing.T) (Queue, apikeys.Store) {
+	t.Helper()
+	keys, err := apikeys.Open(context.Background(), apikeys.Config{Driver: apikeys.DriverMemory})
+	require.NoError(t, err)
+	q, err := Open(context.Background(), Config{Driver: DriverMemory, Retry: RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}})
+	require.NoError(t, err)
+
+	runner := NewRunner(q, RunnerConfig{Workers: 1, PollInterval: 5 * time.Millisecond})
+	runner.Use(Metered(keys, apikeys.NewMeter(keys, nil)))
+	runner.Handle(KindWarm, WarmHandler(analyzer.NewServiceWithDependencies(pageClient{}, parser.NewHTMLParser(), worker.NewWorkerPool(1))))
+	require.NoError(t, runner.Start(context.Background()))
+	t.Cleanup(func() {
+		runner.Stop()
+		q.Close()
+		keys.Close()
+	})
+	return q, keys
+}
+
+func TestOwned(t *testing.T) {
+	payload, err := Owned(WarmRequest{URLs: []string{"https://example.com"}}, Owner{Tenant: "acme", APIKeyID: "key-1"})
+	require.NoError(t, err)
+
+	job := &Job{Payload: payload}
+	assert.Equal(t, Owner{Tenant: "acme", APIKeyID: "key-1"}, job.Owner())
+	var req WarmRequest
+	require.NoError(t, json.Unmarshal(payload, &req))
+	assert.Equal(t, []string{"https://example.com"}, req.URLs, "The request's own fields should be kept")
+
+	_, err = Owned([]string{"https://example.com"}, Owner{Tenant: "acme"})
+	assert.Error(t, err, "Only object payloads can record an owner")
+}
+
+func TestMetered_ChargesEveryPage(t *testing.T) {
+	q, keys := startMeteredRunner(t)
+	ctx := context.Background()
+	key, _, err := keys.Create(ctx, apikeys.Spec{Tenant: "acme", DailyQuota: 2})
+	require.NoError(t, err)
+
+	payload, err := Owned(WarmRequest{URLs: []string{"https://a.example.com", "https://b.example.com", "https://c.example.com"}}, Owner{Tenant: key.Tenant, APIKeyID: key.ID})
+	require.NoError(t, err)
+	job, err := q.Enqueue(ctx, KindWarm, payload)
+	require.NoError(t, err)
+
+	done := waitForStatus(t, q, job.ID, StatusDone)
+	var result WarmResult
+	require.NoError(t, json.Unmarshal(done.Result, &result))
+	assert.Equal(t, 2, result.Warmed)
+	require.Len(t, result.Errors, 1, "The page past the quota should fail")
+	assert.Equal(t, "quota_exceeded", result.Errors[0].Code)
+
+	usage, err := keys.Usage(ctx, apikeys.UsageQuery{Tenant: "acme"})
+	require.NoError(t, err)
+	require.Len(t, usage, 1)
+	assert.Equal(t, int64(2), usage[0].Analyses, "Each page should count as one analysis")
+}
+
+func TestMetered_RevokedKey(t *testing.T) {
+	q, keys := startMeteredRunner(t)
+	ctx := context.Background()
+	key, _, err := keys.Create(ctx, apikeys.Spec{Tenant: "acme"})
+	require.NoError(t, err)
+	_, err = keys.Revoke(ctx, key.ID)
+	require.NoError(t, err)
+
+	payload, err := Owned(WarmRequest{URLs: []string{"https://a.example.com"}}, Owner{Tenant: key.Tenant, APIKeyID: key.ID})
+	require.NoError(t, err)
+	job, err := q.Enqueue(ctx, KindWarm, payload)
+	require.NoError(t, err)
+
+	failed := waitForStatus(t, q, job.ID, StatusFailed)
+	assert.Equal(t, 1, failed.Attempts, "A job whose key was revoked should not be retried")
+	assert.Contains(t, failed.Error, "revoked")
+}
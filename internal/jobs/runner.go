@@ -0,0 +1,199 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"webpage-analyzer/internal/logging"
+)
+
+// HandlerFunc runs one job. It decodes job.Payload, may report progress, and
+// returns a result to store as JSON. Errors wrapped with Permanent are not
+// retried.
+type HandlerFunc func(ctx context.Context, job *Job, progress func(v interface{})) (interface{}, error)
+
+// RunnerConfig configures a Runner.
+type RunnerConfig struct {
+	Workers      int           // Jobs run at once.
+	PollInterval time.Duration // How often idle workers look for due jobs.
+	JobTimeout   time.Duration // Deadline of one attempt; zero means none.
+	Retention    time.Duration // How long done jobs are kept; zero keeps them forever.
+}
+
+// DefaultRunnerConfig returns the runner configuration used by the server.
+func DefaultRunnerConfig() RunnerConfig {
+	return RunnerConfig{
+		Workers:      4,
+		PollInterval: time.Second,
+		JobTimeout:   30 * time.Minute,
+		Retention:    24 * time.Hour,
+	}
+}
+
+// Runner takes jobs from a Queue and runs them with the handler registered
+// for their kind.
+type Runner struct {
+	queue    Queue
+	cfg      RunnerConfig
+	handlers map[string]HandlerFunc
+	wrap     []func(HandlerFunc) HandlerFunc
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRunner creates a runner for queue. Register handlers before Start.
+func NewRunner(queue Queue, cfg RunnerConfig) *Runner {
+	defaults := DefaultRunnerConfig()
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaults.Workers
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaults.PollInterval
+	}
+	return &Runner{queue: queue, cfg: cfg, handlers: make(map[string]HandlerFunc)}
+}
+
+// Handle registers the handler for jobs of the given kind.
+func (r *Runner) Handle(kind string, h HandlerFunc) {
+	r.handlers[kind] = h
+}
+
+// Use wraps the handlers of every kind in middleware, such as Metered. The
+// middleware registered first runs first. Call it before Start.
+func (r *Runner) Use(middleware func(HandlerFunc) HandlerFunc) {
+	r.wrap = append(r.wrap, middleware)
+}
+
+// Start requeues jobs interrupted by a previous shutdown and starts the workers.
+func (r *Runner) Start(ctx context.Context) error {
+	recovered, err := r.queue.Recover(ctx)
+	if err != nil {
+		return err
+	}
+	if recovered > 0 {
+		slog.Info("Requeued interrupted jobs", "count", recovered)
+	}
+
+	ctx, r.cancel = context.WithCancel(context.Background())
+	for i := 0; i < r.cfg.Workers; i++ {
+		r.wg.Add(1)
+		go r.work(ctx)
+	}
+	if r.cfg.Retention > 0 {
+		r.wg.Add(1)
+		go r.purge(ctx)
+	}
+	return nil
+}
+
+// Stop stops the workers and waits for them. Jobs still running are
+// cancelled and left running, so the next Start queues them again.
+func (r *Runner) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+}
+
+// work claims and runs jobs until ctx ends.
+func (r *Runner) work(ctx context.Context) {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := r.queue.Claim(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("Failed to claim job", "error", err)
+		}
+		if job != nil {
+			r.run(ctx, job)
+			continue // Look for more work straight away.
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run executes one attempt of job and records the outcome. Everything logged
+// while the handler runs carries the job's ID.
+func (r *Runner) run(ctx context.Context, job *Job) {
+	runCtx, logger := logging.With(ctx, "job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts)
+	logger.Info("Job started")
+
+	handler, ok := r.handlers[job.Kind]
+	if !ok {
+		r.fail(job, logger, Permanent(fmt.Errorf("no handler for job kind %q", job.Kind)))
+		return
+	}
+	for i := len(r.wrap) - 1; i >= 0; i-- {
+		handler = r.wrap[i](handler)
+	}
+
+	if r.cfg.JobTimeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(runCtx, r.cfg.JobTimeout)
+		defer cancel()
+	}
+	progress := func(v interface{}) {
+		if err := r.queue.SetProgress(context.Background(), job.ID, v); err != nil {
+			logger.Warn("Failed to record job progress", "error", err)
+		}
+	}
+
+	result, err := handler(runCtx, job, progress)
+	if ctx.Err() != nil {
+		logger.Info("Job interrupted by shutdown")
+		return
+	}
+	if err != nil {
+		r.fail(job, logger, err)
+		return
+	}
+	if err := r.queue.Complete(context.Background(), job.ID, result); err != nil {
+		logger.Error("Failed to record job result", "error", err)
+		return
+	}
+	logger.Info("Job done")
+}
+
+// fail records a failed attempt.
+func (r *Runner) fail(job *Job, logger *slog.Logger, jobErr error) {
+	updated, err := r.queue.Fail(context.Background(), job.ID, jobErr)
+	if err != nil {
+		logger.Error("Failed to record job failure", "error", err, "job_error", jobErr)
+		return
+	}
+	if updated.Status == StatusFailed {
+		logger.Warn("Job failed permanently", "error", jobErr)
+	} else {
+		logger.Warn("Job attempt failed, will retry", "error", jobErr, "run_at", updated.RunAt)
+	}
+}
+
+// purge periodically deletes done jobs older than the retention period.
+func (r *Runner) purge(ctx context.Context) {
+	defer r.wg.Done()
+	ticker := time.NewTicker(min(r.cfg.Retention, time.Hour))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := r.queue.Purge(ctx, time.Now().Add(-r.cfg.Retention)); err != nil {
+				slog.Error("Failed to purge old jobs", "error", err)
+			} else if n > 0 {
+				slog.Info("Purged old jobs", "count", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+// startRunner runs handlers for the "test" kind on a fresh in-memory queue.
+func startRunner(t *testing.T, retry RetryPolicy, handler HandlerFunc) Queue {
+	t.Helper()
+	q, err := Open(context.Background(), Config{Driver: DriverMemory, Retry: retry})
+	require.NoError(t, err)
+
+	runner := NewRunner(q, RunnerConfig{Workers: 2, PollInterval: 5 * time.Millisecond})
+	runner.Handle("test", handler)
+	require.NoError(t, runner.Start(context.Background()))
+	t.Cleanup(func() {
+		runner.Stop()
+		q.Close()
+	})
+	return q
+}
+
+// waitForStatus polls until the job reaches status.
+func waitForStatus(t *testing.T, q Queue, id string, status Status) *Job {
+	t.Helper()
+	var job *Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = q.Get(context.Background(), id)
+		require.NoError(t, err)
+		return job.Status == status
+	}, 2*time.Second, 5*time.Millisecond)
+	return job
+}
+
+func TestRunner_RunsJob(t *testing.T) {
+	q := startRunner(t, RetryPolicy{}, func(ctx context.Context, job *Job, progress func(v interface{})) (interface{}, error) {
+		progress(map[string]int{"completed": 1})
+		return map[string]string{"echo": string(job.Payload)}, nil
+	})
+
+	job, err := q.Enqueue(context.Background(), "test", "hello")
+	require.NoError(t, err)
+
+	done := waitForStatus(t, q, job.ID, StatusDone)
+	assert.JSONEq(t, `{"echo": "\"hello\""}`, string(done.Result))
+	assert.JSONEq(t, `{"completed": 1}`, string(done.Progress))
+}
+
+func TestRunner_RetriesThenDeadLetters(t *testing.T) {
+	attempts := make(chan int, 10)
+	q := startRunner(t, RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond, MaxBackoff: time.Millisecond},
+		func(ctx context.Context, job *Job, progress func(v interface{})) (interface{}, error) {
+			attempts <- job.Attempts
+			return nil, errors.New("upstream down")
+		})
+
+	job, err := q.Enqueue(context.Background(), "test", nil)
+	require.NoError(t, err)
+
+	failed := waitForStatus(t, q, job.ID, StatusFailed)
+	assert.Equal(t, 2, failed.Attempts)
+	assert.Equal(t, "upstream down", failed.Error)
+	assert.Len(t, attempts, 2)
+}
+
+func TestRunner_UnknownKind(t *testing.T) {
+	q := startRunner(t, RetryPolicy{MaxAttempts: 5}, nil)
+
+	job, err := q.Enqueue(context.Background(), "mystery", nil)
+	require.NoError(t, err)
+
+	failed := waitForStatus(t, q, job.ID, StatusFailed)
+	assert.Equal(t, 1, failed.Attempts, "A job nobody can run should not be retried")
+	assert.Contains(t, failed.Error, "mystery")
+}
+
+func TestClassify(t *testing.T) {
+	assert.True(t, IsPermanent(classify(&analyzer.AnalysisError{StatusCode: 404})))
+	assert.True(t, IsPermanent(classify(&analyzer.AnalysisError{StatusCode: 400})))
+	assert.False(t, IsPermanent(classify(&analyzer.AnalysisError{StatusCode: 429})))
+	assert.False(t, IsPermanent(classify(&analyzer.AnalysisError{StatusCode: 503})))
+	assert.False(t, IsPermanent(classify(errors.New("connection reset"))))
+
+	rateLimited := classify(&analyzer.AnalysisError{StatusCode: 429, RetryAfter: 120})
+	assert.False(t, IsPermanent(rateLimited))
+	assert.Equal(t, 2*time.Minute, retryAfter(rateLimited), "The site's Retry-After should delay the retry")
+	assert.Nil(t, classify(nil))
+}
@@ -0,0 +1,360 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"webpage-analyzer/internal/apikeys"
+	"webpage-analyzer/internal/sqldb"
+)
+
+// schema creates the jobs table. It is valid for both SQLite and Postgres.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS jobs (
+		id           TEXT PRIMARY KEY,
+		kind         TEXT NOT NULL,
+		status       TEXT NOT NULL,
+		payload      TEXT NOT NULL,
+		progress     TEXT NOT NULL DEFAULT '',
+		result       TEXT NOT NULL DEFAULT '',
+		error        TEXT NOT NULL DEFAULT '',
+		attempts     INTEGER NOT NULL DEFAULT 0,
+		max_attempts INTEGER NOT NULL,
+		run_at       BIGINT NOT NULL,
+		created_at   BIGINT NOT NULL,
+		updated_at   BIGINT NOT NULL,
+		tenant       TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_jobs_status_run_at ON jobs (status, run_at)`,
+}
+
+// tenantColumn is added to job queues created before jobs had owners.
+var tenantColumn = sqldb.Column{Table: "jobs", Name: "tenant", Definition: "TEXT NOT NULL DEFAULT ''"}
+
+// jobColumns lists the columns read by scanJob, in order.
+const jobColumns = `id, kind, status, payload, progress, result, error, attempts, max_attempts, run_at, created_at, updated_at`
+
+// sqlQueue implements Queue on top of database/sql.
+type sqlQueue struct {
+	db     *sql.DB
+	driver string
+	retry  RetryPolicy
+	now    func() time.Time
+}
+
+// Open connects to the configured database and ensures the jobs table exists.
+func Open(ctx context.Context, cfg Config) (Queue, error) {
+	retry := cfg.Retry
+	defaults := DefaultRetryPolicy()
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = defaults.MaxAttempts
+	}
+	if retry.Backoff <= 0 {
+		retry.Backoff = defaults.Backoff
+	}
+	if retry.MaxBackoff < retry.Backoff {
+		retry.MaxBackoff = max(defaults.MaxBackoff, retry.Backoff)
+	}
+
+	db, driver, err := sqldb.Open(ctx, cfg.Driver, cfg.DSN, "job queue", schema, tenantColumn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlQueue{db: db, driver: driver, retry: retry, now: time.Now}, nil
+}
+
+// Enqueue adds a queued job that may run immediately, belonging to the tenant
+// of the payload's Owner, if any.
+func (q *sqlQueue) Enqueue(ctx context.Context, kind string, payload interface{}) (*Job, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate job ID: %v", err)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %v", err)
+	}
+	owner := (&Job{Payload: data}).Owner()
+
+	now := q.now().UTC()
+	_, err = q.db.ExecContext(ctx,
+		q.rebind(`INSERT INTO jobs (id, kind, status, payload, max_attempts, run_at, created_at, updated_at, tenant) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		id, kind, string(StatusQueued), string(data), q.retry.MaxAttempts, now.UnixNano(), now.UnixNano(), now.UnixNano(), owner.Tenant,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %v", err)
+	}
+	return q.Get(ctx, id)
+}
+
+// Get loads a job by ID.
+func (q *sqlQueue) Get(ctx context.Context, id string) (*Job, error) {
+	scope, args := tenantScope(ctx)
+	row := q.db.QueryRowContext(ctx, q.rebind(`SELECT `+jobColumns+` FROM jobs WHERE id = ?`+scope), append([]interface{}{id}, args...)...)
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return job, err
+}
+
+// List returns jobs with the given status, newest first.
+func (q *sqlQueue) List(ctx context.Context, status Status, limit int) ([]*Job, error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+
+	scope, args := tenantScope(ctx)
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE 1 = 1` + scope
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, string(status))
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := q.db.QueryContext(ctx, q.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %v", err)
+	}
+	defer rows.Close()
+
+	jobs := make([]*Job, 0)
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %v", err)
+	}
+	return jobs, nil
+}
+
+// Counts returns the number of jobs in each state, including empty ones.
+func (q *sqlQueue) Counts(ctx context.Context) (map[Status]int, error) {
+	rows, err := q.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM jobs GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %v", err)
+	}
+	defer rows.Close()
+
+	counts := map[Status]int{StatusQueued: 0, StatusRunning: 0, StatusDone: 0, StatusFailed: 0}
+	for rows.Next() {
+		var (
+			status string
+			n      int
+		)
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, fmt.Errorf("failed to count jobs: %v", err)
+		}
+		counts[Status(status)] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %v", err)
+	}
+	return counts, nil
+}
+
+// Claim marks the oldest due queued job as running. Losing a race for a job
+// to another process moves on to the next one.
+func (q *sqlQueue) Claim(ctx context.Context) (*Job, error) {
+	for {
+		now := q.now().UTC().UnixNano()
+		var id string
+		err := q.db.QueryRowContext(ctx,
+			q.rebind(`SELECT id FROM jobs WHERE status = ? AND run_at <= ? ORDER BY run_at, created_at LIMIT 1`),
+			string(StatusQueued), now,
+		).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim job: %v", err)
+		}
+
+		res, err := q.db.ExecContext(ctx,
+			q.rebind(`UPDATE jobs SET status = ?, attempts = attempts + 1, updated_at = ? WHERE id = ? AND status = ?`),
+			string(StatusRunning), now, id, string(StatusQueued),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim job %s: %v", id, err)
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			return q.Get(ctx, id)
+		}
+	}
+}
+
+// SetProgress stores a running job's progress report.
+func (q *sqlQueue) SetProgress(ctx context.Context, id string, progress interface{}) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("failed to encode job progress: %v", err)
+	}
+	return q.update(ctx, id, `progress = ?`, string(data))
+}
+
+// Complete marks a job as done with its result.
+func (q *sqlQueue) Complete(ctx context.Context, id string, result interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode job result: %v", err)
+	}
+	return q.update(ctx, id, `status = ?, result = ?, error = ''`, string(StatusDone), string(data))
+}
+
+// Fail records a failed attempt and either schedules a retry or dead-letters the job.
+func (q *sqlQueue) Fail(ctx context.Context, id string, jobErr error) (*Job, error) {
+	job, err := q.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if IsPermanent(jobErr) || job.Attempts >= job.MaxAttempts {
+		err = q.update(ctx, id, `status = ?, error = ?`, string(StatusFailed), jobErr.Error())
+	} else {
+		delay := max(q.retry.delay(job.Attempts), retryAfter(jobErr))
+		runAt := q.now().Add(delay).UTC().UnixNano()
+		err = q.update(ctx, id, `status = ?, error = ?, run_at = ?`, string(StatusQueued), jobErr.Error(), runAt)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return q.Get(ctx, id)
+}
+
+// Requeue gives a failed job a fresh set of attempts, starting now.
+func (q *sqlQueue) Requeue(ctx context.Context, id string) (*Job, error) {
+	now := q.now().UTC().UnixNano()
+	scope, args := tenantScope(ctx)
+	res, err := q.db.ExecContext(ctx,
+		q.rebind(`UPDATE jobs SET status = ?, attempts = 0, max_attempts = ?, run_at = ?, updated_at = ? WHERE id = ? AND status = ?`+scope),
+		append([]interface{}{string(StatusQueued), q.retry.MaxAttempts, now, now, id, string(StatusFailed)}, args...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to requeue job %s: %v", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := q.Get(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotFailed
+	}
+	return q.Get(ctx, id)
+}
+
+// Recover queues every running job. It must only be called before this
+// process's workers start, and with no other process sharing the queue.
+func (q *sqlQueue) Recover(ctx context.Context) (int, error) {
+	now := q.now().UTC().UnixNano()
+	res, err := q.db.ExecContext(ctx,
+		q.rebind(`UPDATE jobs SET status = ?, run_at = ?, updated_at = ? WHERE status = ?`),
+		string(StatusQueued), now, now, string(StatusRunning),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to recover jobs: %v", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// Purge deletes done jobs last updated before the given time.
+func (q *sqlQueue) Purge(ctx context.Context, before time.Time) (int, error) {
+	res, err := q.db.ExecContext(ctx,
+		q.rebind(`DELETE FROM jobs WHERE status = ? AND updated_at < ?`),
+		string(StatusDone), before.UTC().UnixNano(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge jobs: %v", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// Close releases the database connection.
+func (q *sqlQueue) Close() error {
+	return q.db.Close()
+}
+
+// update sets columns of one job and bumps its updated_at.
+func (q *sqlQueue) update(ctx context.Context, id, set string, args ...interface{}) error {
+	args = append(args, q.now().UTC().UnixNano(), id)
+	res, err := q.db.ExecContext(ctx, q.rebind(`UPDATE jobs SET `+set+`, updated_at = ? WHERE id = ?`), args...)
+	if err != nil {
+		return fmt.Errorf("failed to update job %s: %v", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanJob decodes a single jobs row selected with jobColumns.
+func scanJob(row rowScanner) (*Job, error) {
+	var (
+		job                               Job
+		status, payload, progress, result string
+		runAt, createdAt, updatedAt       int64
+	)
+	err := row.Scan(&job.ID, &job.Kind, &status, &payload, &progress, &result, &job.Error,
+		&job.Attempts, &job.MaxAttempts, &runAt, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Status = Status(status)
+	job.Payload = json.RawMessage(payload)
+	if progress != "" {
+		job.Progress = json.RawMessage(progress)
+	}
+	if result != "" {
+		job.Result = json.RawMessage(result)
+	}
+	job.RunAt = time.Unix(0, runAt).UTC()
+	job.CreatedAt = time.Unix(0, createdAt).UTC()
+	job.UpdatedAt = time.Unix(0, updatedAt).UTC()
+	return &job, nil
+}
+
+// tenantScope returns the condition, to append to a WHERE clause, and its
+// argument that keep a query to the jobs of the tenant of ctx's API key.
+// Without a key, as when API keys are off or for the runner, every job is in
+// scope.
+func tenantScope(ctx context.Context) (string, []interface{}) {
+	tenant := apikeys.TenantFromContext(ctx)
+	if tenant == "" {
+		return "", nil
+	}
+	return ` AND tenant = ?`, []interface{}{tenant}
+}
+
+// rebind rewrites '?' placeholders to the driver's native syntax.
+func (q *sqlQueue) rebind(query string) string {
+	return sqldb.Rebind(q.driver, query)
+}
+
+// newID generates a random hex job ID.
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
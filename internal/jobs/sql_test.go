@@ -0,0 +1,244 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/apikeys"
+)
+
+// newTestQueue opens an in-memory queue whose clock the test controls.
+func newTestQueue(t *testing.T, retry RetryPolicy) (*sqlQueue, *time.Time) {
+	t.Helper()
+	q, err := Open(context.Background(), Config{Driver: DriverMemory, Retry: retry})
+	require.NoError(t, err)
+	t.Cleanup(func() { q.Close() })
+
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	sq := q.(*sqlQueue)
+	sq.now = func() time.Time { return now }
+	return sq, &now
+}
+
+func TestQueue_Lifecycle(t *testing.T) {
+	q, _ := newTestQueue(t, RetryPolicy{})
+	ctx := context.Background()
+
+	job, err := q.Enqueue(ctx, "sitemap", map[string]string{"sitemap_url": "https://example.com/sitemap.xml"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusQueued, job.Status)
+	assert.JSONEq(t, `{"sitemap_url": "https://example.com/sitemap.xml"}`, string(job.Payload))
+	assert.Equal(t, DefaultRetryPolicy().MaxAttempts, job.MaxAttempts)
+
+	claimed, err := q.Claim(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, job.ID, claimed.ID)
+	assert.Equal(t, StatusRunning, claimed.Status)
+	assert.Equal(t, 1, claimed.Attempts)
+
+	none, err := q.Claim(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, none, "A running job should not be claimed twice")
+
+	require.NoError(t, q.SetProgress(ctx, job.ID, map[string]int{"completed": 3}))
+	require.NoError(t, q.Complete(ctx, job.ID, map[string]int{"pages": 3}))
+
+	done, err := q.Get(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDone, done.Status)
+	assert.JSONEq(t, `{"completed": 3}`, string(done.Progress))
+	assert.JSONEq(t, `{"pages": 3}`, string(done.Result))
+
+	_, err = q.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestQueue_RetryAndDeadLetter(t *testing.T) {
+	q, now := newTestQueue(t, RetryPolicy{MaxAttempts: 2, Backoff: time.Minute, MaxBackoff: time.Hour})
+	ctx := context.Background()
+
+	job, err := q.Enqueue(ctx, "crawl", nil)
+	require.NoError(t, err)
+
+	_, err = q.Claim(ctx)
+	require.NoError(t, err)
+	retried, err := q.Fail(ctx, job.ID, errors.New("upstream timeout"))
+	require.NoError(t, err)
+	assert.Equal(t, StatusQueued, retried.Status, "A job with attempts left should be retried")
+	assert.Equal(t, "upstream timeout", retried.Error)
+	assert.Equal(t, now.Add(time.Minute), retried.RunAt)
+
+	claimed, err := q.Claim(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, claimed, "A retry should wait for its backoff")
+
+	*now = now.Add(time.Minute)
+	claimed, err = q.Claim(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, 2, claimed.Attempts)
+
+	dead, err := q.Fail(ctx, job.ID, errors.New("upstream timeout"))
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, dead.Status, "A job out of attempts should be dead-lettered")
+
+	failed, err := q.List(ctx, StatusFailed, 0)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, job.ID, failed[0].ID)
+
+	requeued, err := q.Requeue(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusQueued, requeued.Status)
+	assert.Equal(t, 0, requeued.Attempts)
+
+	_, err = q.Requeue(ctx, job.ID)
+	assert.ErrorIs(t, err, ErrNotFailed, "Only failed jobs can be requeued")
+	_, err = q.Requeue(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestQueue_RetryAfter(t *testing.T) {
+	q, now := newTestQueue(t, RetryPolicy{MaxAttempts: 3, Backoff: time.Minute, MaxBackoff: time.Hour})
+	ctx := context.Background()
+
+	job, err := q.Enqueue(ctx, "crawl", nil)
+	require.NoError(t, err)
+	_, err = q.Claim(ctx)
+	require.NoError(t, err)
+	retried, err := q.Fail(ctx, job.ID, RetryAfter(errors.New("rate limited"), 2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(2*time.Hour), retried.RunAt, "The retry should wait as long as the site asked")
+	assert.Equal(t, "rate limited", retried.Error)
+
+	*now = retried.RunAt
+	_, err = q.Claim(ctx)
+	require.NoError(t, err)
+	retried, err = q.Fail(ctx, job.ID, RetryAfter(errors.New("rate limited"), time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(2*time.Minute), retried.RunAt, "A shorter wait should not cut the backoff")
+}
+
+func TestQueue_PermanentFailure(t *testing.T) {
+	q, _ := newTestQueue(t, RetryPolicy{MaxAttempts: 5})
+	ctx := context.Background()
+
+	job, err := q.Enqueue(ctx, "sitemap", nil)
+	require.NoError(t, err)
+	_, err = q.Claim(ctx)
+	require.NoError(t, err)
+
+	failed, err := q.Fail(ctx, job.ID, Permanent(errors.New("invalid request")))
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, failed.Status)
+	assert.Equal(t, 1, failed.Attempts)
+}
+
+func TestQueue_CountsRecoverAndPurge(t *testing.T) {
+	q, now := newTestQueue(t, RetryPolicy{})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := q.Enqueue(ctx, "sitemap", nil)
+		require.NoError(t, err)
+	}
+	running, err := q.Claim(ctx)
+	require.NoError(t, err)
+	done, err := q.Claim(ctx)
+	require.NoError(t, err)
+	require.NoError(t, q.Complete(ctx, done.ID, nil))
+
+	counts, err := q.Counts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[Status]int{StatusQueued: 1, StatusRunning: 1, StatusDone: 1, StatusFailed: 0}, counts)
+
+	recovered, err := q.Recover(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, recovered)
+	job, err := q.Get(ctx, running.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusQueued, job.Status, "An interrupted job should be queued again")
+
+	purged, err := q.Purge(ctx, *now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, purged, "Jobs finished at the cutoff should be kept")
+	purged, err = q.Purge(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged, "Only done jobs should be purged")
+}
+
+func TestQueue_TenantScope(t *testing.T) {
+	q, _ := newTestQueue(t, RetryPolicy{MaxAttempts: 1})
+	ctx := context.Background()
+	acme := apikeys.WithKey(ctx, &apikeys.Key{ID: "key-1", Tenant: "acme"})
+	globex := apikeys.WithKey(ctx, &apikeys.Key{ID: "key-2", Tenant: "globex"})
+
+	payload, err := Owned(map[string]string{"url": "https://example.com"}, Owner{Tenant: "acme", APIKeyID: "key-1"})
+	require.NoError(t, err)
+	job, err := q.Enqueue(ctx, "crawl", payload)
+	require.NoError(t, err)
+	claimed, err := q.Claim(ctx)
+	require.NoError(t, err)
+	_, err = q.Fail(ctx, claimed.ID, errors.New("down"))
+	require.NoError(t, err)
+
+	_, err = q.Get(acme, job.ID)
+	assert.NoError(t, err, "Tenants should see their own jobs")
+	_, err = q.Get(globex, job.ID)
+	assert.ErrorIs(t, err, ErrNotFound, "Tenants should not see other tenants' jobs")
+	_, err = q.Requeue(globex, job.ID)
+	assert.ErrorIs(t, err, ErrNotFound, "Tenants should not retry other tenants' jobs")
+
+	list, err := q.List(globex, "", 0)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	list, err = q.List(acme, "", 0)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+	list, err = q.List(ctx, "", 0)
+	require.NoError(t, err)
+	assert.Len(t, list, 1, "Without a key every job should be listed")
+
+	_, err = q.Requeue(acme, job.ID)
+	assert.NoError(t, err)
+}
+
+func TestQueue_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	cfg := Config{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "jobs.db")}
+
+	q, err := Open(ctx, cfg)
+	require.NoError(t, err)
+	job, err := q.Enqueue(ctx, "sitemap", nil)
+	require.NoError(t, err)
+	require.NoError(t, q.Close())
+
+	q, err = Open(ctx, cfg)
+	require.NoError(t, err)
+	defer q.Close()
+	claimed, err := q.Claim(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, job.ID, claimed.ID)
+}
+
+func TestOpen_UnsupportedDriver(t *testing.T) {
+	_, err := Open(context.Background(), Config{Driver: "redis"})
+	assert.Error(t, err)
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	assert.Equal(t, time.Second, p.delay(1))
+	assert.Equal(t, 2*time.Second, p.delay(2))
+	assert.Equal(t, 4*time.Second, p.delay(3))
+	assert.Equal(t, 5*time.Second, p.delay(4))
+	assert.Equal(t, 5*time.Second, p.delay(30))
+}
@@ -0,0 +1,173 @@
+// Package jobs runs long analyses in the background from a persistent queue,
+// so that queued work survives restarts. Failed jobs are retried with
+// exponential backoff; jobs that run out of attempts are kept as failed (the
+// dead-letter list) until they are requeued or purged.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"webpage-analyzer/internal/sqldb"
+)
+
+// Status is the lifecycle state of a job.
+type Status string
+
+// Job states. A job moves from queued to running, then to done, back to
+// queued for a retry, or to failed once it has no attempts left.
+const (
+	StatusQueued  Status = "queued"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+// Supported queue drivers. DriverMemory is an in-process SQLite database
+// whose jobs are lost on restart.
+const (
+	DriverSQLite   = sqldb.DriverSQLite
+	DriverPostgres = sqldb.DriverPostgres
+	DriverMemory   = sqldb.DriverMemory
+)
+
+// List limits.
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// ErrNotFound is returned when a requested job does not exist.
+var ErrNotFound = errors.New("job not found")
+
+// ErrNotFailed is returned when requeueing a job that has not failed.
+var ErrNotFailed = errors.New("only failed jobs can be requeued")
+
+// Config selects the queue's storage and retry policy.
+type Config struct {
+	Driver string // DriverSQLite, DriverPostgres or DriverMemory.
+	DSN    string // File path for SQLite, connection string for Postgres; unused for memory.
+	Retry  RetryPolicy
+}
+
+// RetryPolicy decides how often and when failed jobs are retried.
+type RetryPolicy struct {
+	MaxAttempts int           // Attempts before a job is dead-lettered, including the first.
+	Backoff     time.Duration // Delay before the first retry; doubles for each later one.
+	MaxBackoff  time.Duration // Upper bound on the delay.
+}
+
+// DefaultRetryPolicy returns the retry policy used when Config leaves it unset.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     30 * time.Second,
+		MaxBackoff:  10 * time.Minute,
+	}
+}
+
+// delay returns how long to wait before retrying after the given attempt.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// Job is a unit of background work and its outcome.
+// @Description A background job: its state, progress and, once done, its result
+type Job struct {
+	ID          string          `json:"id" example:"6f1c2a9b0d3e4f57"`
+	Kind        string          `json:"kind" example:"sitemap"`
+	Status      Status          `json:"status" example:"running"`
+	Payload     json.RawMessage `json:"payload"`                                           // The request that started the job.
+	Progress    json.RawMessage `json:"progress,omitempty"`                                // Kind-specific progress report.
+	Result      json.RawMessage `json:"result,omitempty"`                                  // Set once the job is done.
+	Error       string          `json:"error,omitempty" example:"HTTP 503: upstream down"` // Error of the last failed attempt.
+	Attempts    int             `json:"attempts" example:"1"`
+	MaxAttempts int             `json:"max_attempts" example:"3"`
+	RunAt       time.Time       `json:"run_at" example:"2024-01-15T10:30:00Z"` // Earliest time a queued job may start.
+	CreatedAt   time.Time       `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt   time.Time       `json:"updated_at" example:"2024-01-15T10:31:12Z"`
+}
+
+// Queue stores jobs durably and hands them to workers.
+type Queue interface {
+	// Enqueue adds a job of the given kind; payload is encoded as JSON.
+	Enqueue(ctx context.Context, kind string, payload interface{}) (*Job, error)
+	// Get, List and Requeue only see the jobs of the tenant of ctx's API key,
+	// if it carries one; the jobs of other tenants are not found.
+	Get(ctx context.Context, id string) (*Job, error)
+	// List returns jobs with the given status, newest first; an empty status lists all.
+	List(ctx context.Context, status Status, limit int) ([]*Job, error)
+	// Counts returns the number of jobs in each state.
+	Counts(ctx context.Context) (map[Status]int, error)
+
+	// Claim marks the oldest due queued job as running and returns it, or
+	// returns nil if no job is due.
+	Claim(ctx context.Context) (*Job, error)
+	SetProgress(ctx context.Context, id string, progress interface{}) error
+	Complete(ctx context.Context, id string, result interface{}) error
+	// Fail records a failed attempt. The job is queued again after a backoff
+	// unless it has no attempts left or err is Permanent, in which case it
+	// becomes failed.
+	Fail(ctx context.Context, id string, err error) (*Job, error)
+
+	// Requeue gives a failed job a fresh set of attempts.
+	Requeue(ctx context.Context, id string) (*Job, error)
+	// Recover queues jobs left running by a process that stopped mid-job.
+	Recover(ctx context.Context) (int, error)
+	// Purge deletes done jobs last updated before the given time.
+	Purge(ctx context.Context, before time.Time) (int, error)
+	Close() error
+}
+
+// permanentError marks an error that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that Fail dead-letters the job without retrying,
+// e.g. for invalid requests.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// retryAfterError asks for the next attempt of a job to wait a while.
+type retryAfterError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryAfterError) Error() string { return e.err.Error() }
+func (e *retryAfterError) Unwrap() error { return e.err }
+
+// RetryAfter wraps err so that Fail waits at least d before the next attempt,
+// e.g. when the target site said when to come back.
+func RetryAfter(err error, d time.Duration) error {
+	return &retryAfterError{err: err, after: d}
+}
+
+// retryAfter returns the wait asked for with RetryAfter, or zero.
+func retryAfter(err error) time.Duration {
+	var r *retryAfterError
+	if errors.As(err, &r) {
+		return r.after
+	}
+	return 0
+}
@@ -0,0 +1,107 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/logging"
+)
+
+// KindWarm jobs analyze pages ahead of time so that later requests for them
+// are answered from the result cache. Payload WarmRequest, result WarmResult.
+const KindWarm = "warm"
+
+// MaxWarmURLs bounds the pages one warm job analyzes.
+const MaxWarmURLs = 100
+
+// WarmRequest lists pages to analyze ahead of time.
+// @Description Pages to analyze in the background so that later requests for them are cache hits
+type WarmRequest struct {
+	URLs []string `json:"urls" example:"https://example.com"`
+	// Modules to run, as in an analysis request; a request for other modules
+	// is not served from the warmed entry.
+	Modules      []string `json:"modules,omitempty" example:"links,page_title"`
+	ForceRefresh bool     `json:"force_refresh,omitempty" example:"false"` // Analyze pages again even if they are cached.
+}
+
+// Validate checks that the request names between one and MaxWarmURLs pages.
+func (r WarmRequest) Validate() error {
+	switch {
+	case len(r.URLs) == 0:
+		return errors.New("urls is required")
+	case len(r.URLs) > MaxWarmURLs:
+		return fmt.Errorf("at most %d urls can be warmed at once", MaxWarmURLs)
+	}
+	for _, u := range r.URLs {
+		if strings.TrimSpace(u) == "" {
+			return errors.New("urls must not be empty")
+		}
+	}
+	return nil
+}
+
+// WarmProgress reports how far a warm job has got.
+type WarmProgress struct {
+	Total     int `json:"total" example:"20"`
+	Completed int `json:"completed" example:"12"` // Pages analyzed or failed so far.
+	Failed    int `json:"failed" example:"1"`
+}
+
+// WarmResult reports a finished warm job.
+// @Description Pages analyzed by a cache warming job
+type WarmResult struct {
+	Warmed         int                       `json:"warmed" example:"19"`
+	Errors         []*analyzer.AnalysisError `json:"errors,omitempty"` // Pages that failed to load.
+	ProcessingTime string                    `json:"processing_time" example:"8.2s"`
+}
+
+// WarmHandler runs warm jobs, reporting WarmProgress. Pages are analyzed one
+// at a time; a page that fails is reported in the result and does not fail
+// the job.
+func WarmHandler(svc analyzer.Service) HandlerFunc {
+	return func(ctx context.Context, job *Job, progress func(v interface{})) (interface{}, error) {
+		var req WarmRequest
+		if err := json.Unmarshal(job.Payload, &req); err != nil {
+			return nil, Permanent(fmt.Errorf("invalid warm job payload: %v", err))
+		}
+		if err := req.Validate(); err != nil {
+			return nil, Permanent(err)
+		}
+
+		startTime := time.Now()
+		result := &WarmResult{}
+		state := WarmProgress{Total: len(req.URLs)}
+		progress(state)
+		for _, pageURL := range req.URLs {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			_, err := svc.AnalyzeWebpage(ctx, analyzer.AnalysisRequest{URL: pageURL, Modules: req.Modules, ForceRefresh: req.ForceRefresh})
+			state.Completed++
+			if err != nil {
+				state.Failed++
+				logging.FromContext(ctx).Warn("Failed to warm cache", "url", pageURL, "error", err)
+				result.Errors = append(result.Errors, asAnalysisError(err, pageURL))
+			} else {
+				result.Warmed++
+			}
+			progress(state)
+		}
+		result.ProcessingTime = time.Since(startTime).String()
+		return result, nil
+	}
+}
+
+// asAnalysisError returns err as an AnalysisError about pageURL.
+func asAnalysisError(err error, pageURL string) *analyzer.AnalysisError {
+	var analysisErr *analyzer.AnalysisError
+	if errors.As(err, &analysisErr) {
+		return analysisErr
+	}
+	return &analyzer.AnalysisError{Code: analyzer.ErrorCodeInternal, ErrorMessage: err.Error(), URL: pageURL}
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+// recordingService records the analysis requests it gets and fails pages
+// whose URL contains "broken".
+type recordingService struct {
+	analyzer.Service
+	mu       sync.Mutex
+	requests []analyzer.AnalysisRequest
+}
+
+func (s *recordingService) AnalyzeWebpage(ctx context.Context, req analyzer.AnalysisRequest) (*analyzer.WebpageAnalysis, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	if strings.Contains(req.URL, "broken") {
+		return nil, &analyzer.AnalysisError{StatusCode: 404, Code: analyzer.ErrorCodeUpstreamStatus, URL: req.URL}
+	}
+	return &analyzer.WebpageAnalysis{URL: req.URL}, nil
+}
+
+func TestWarmHandler(t *testing.T) {
+	svc := &recordingService{}
+	q := startRunner(t, RetryPolicy{}, WarmHandler(svc))
+
+	req := WarmRequest{URLs: []string{"https://a.example.com", "https://broken.example.com", "https://b.example.com"}, Modules: []string{"page_title"}}
+	job, err := q.Enqueue(context.Background(), "test", req)
+	require.NoError(t, err)
+
+	done := waitForStatus(t, q, job.ID, StatusDone)
+	var result WarmResult
+	require.NoError(t, json.Unmarshal(done.Result, &result))
+	assert.Equal(t, 2, result.Warmed)
+	require.Len(t, result.Errors, 1, "A failing page should not fail the job")
+	assert.Equal(t, "https://broken.example.com", result.Errors[0].URL)
+	assert.JSONEq(t, `{"total": 3, "completed": 3, "failed": 1}`, string(done.Progress))
+
+	require.Len(t, svc.requests, 3)
+	assert.Equal(t, []string{"page_title"}, svc.requests[0].Modules, "Pages should be warmed for the requested modules")
+}
+
+func TestWarmRequest_Validate(t *testing.T) {
+	assert.NoError(t, WarmRequest{URLs: []string{"https://example.com"}}.Validate())
+	assert.Error(t, WarmRequest{}.Validate())
+	assert.Error(t, WarmRequest{URLs: []string{" "}}.Validate())
+	assert.Error(t, WarmRequest{URLs: make([]string, MaxWarmURLs+1)}.Validate())
+}
@@ -0,0 +1,30 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromContextDefaultsToDefaultLogger(t *testing.T) {
+	assert.Same(t, slog.Default(), FromContext(context.Background()))
+}
+
+func TestWithAddsFieldsToLaterLines(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := NewContext(context.Background(), slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	ctx, _ = With(ctx, "request_id", "abc123")
+	ctx, _ = With(ctx, "url", "https://example.com/")
+	FromContext(ctx).Info("Fetching webpage content")
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "abc123", line["request_id"])
+	assert.Equal(t, "https://example.com/", line["url"])
+}
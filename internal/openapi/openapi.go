@@ -0,0 +1,277 @@
+// Package openapi builds the OpenAPI 3 specification of the service from the
+// routes it registers and the Go types they exchange, so that the spec cannot
+// drift from the code. Handlers are registered on a Mux together with the
+// Operations they serve; request and response schemas are derived from the
+// types' JSON encoding, with the values of their example tags as examples.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the OpenAPI version of generated documents.
+const Version = "3.0.3"
+
+// Info describes the API as a whole.
+type Info struct {
+	Title       string   `json:"title" yaml:"title"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string   `json:"version" yaml:"version"`
+	License     *License `json:"license,omitempty" yaml:"license,omitempty"`
+}
+
+// License is the license the API is offered under.
+type License struct {
+	Name string `json:"name" yaml:"name"`
+	URL  string `json:"url,omitempty" yaml:"url,omitempty"`
+}
+
+// SecurityScheme is a way requests authenticate, such as an API key header.
+type SecurityScheme struct {
+	Type        string `json:"type" yaml:"type"` // "apiKey" or "http".
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Name        string `json:"name,omitempty" yaml:"name,omitempty"` // Header, query parameter or cookie of an apiKey.
+	In          string `json:"in,omitempty" yaml:"in,omitempty"`     // "header", "query" or "cookie" for an apiKey.
+	Scheme      string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+}
+
+// Operation documents what one method of a route does.
+type Operation struct {
+	Method      string // http.MethodGet, http.MethodPost...
+	Summary     string
+	Description string
+	Tags        []string
+	// Security names the security schemes that authorize the operation, any
+	// one of them being enough. Empty means the operation is open.
+	Security  []string
+	Params    []Param
+	Request   *Request
+	Responses []Response
+}
+
+// Param is a path, query or header parameter.
+type Param struct {
+	Name        string
+	In          string // "path", "query" or "header".
+	Type        string // "string", "integer" or "boolean"; "string" when empty.
+	Description string
+	Required    bool // Path parameters are always required.
+}
+
+// Request is an operation's request body. Content maps each accepted media
+// type to a value of the Go type sent, or to a *Schema.
+type Request struct {
+	Description string
+	Required    bool
+	Content     map[string]interface{}
+}
+
+// JSONRequest is a required JSON request body of v's type.
+func JSONRequest(v interface{}) *Request {
+	return &Request{Required: true, Content: map[string]interface{}{"application/json": v}}
+}
+
+// Response is one possible response of an operation.
+type Response struct {
+	Status      int
+	Description string      // http.StatusText(Status) when empty; joined with that of an earlier Response of Status.
+	Body        interface{} // Value of the Go type returned, or a *Schema; nil for no body.
+	ContentType string      // "application/json" when empty.
+}
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string                        `json:"openapi" yaml:"openapi"`
+	Info       Info                          `json:"info" yaml:"info"`
+	Paths      map[string]map[string]*pathOp `json:"paths" yaml:"paths"`
+	Components components                    `json:"components" yaml:"components"`
+	registry   *registry                     `json:"-" yaml:"-"`
+}
+
+// components holds the schemas and security schemes operations refer to.
+type components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
+}
+
+// pathOp is an Operation as the document encodes it.
+type pathOp struct {
+	Summary     string                `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string                `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Security    []map[string][]string `json:"security,omitempty" yaml:"security,omitempty"`
+	Parameters  []parameter           `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*response  `json:"responses" yaml:"responses"`
+}
+
+type parameter struct {
+	Name        string  `json:"name" yaml:"name"`
+	In          string  `json:"in" yaml:"in"`
+	Description string  `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool    `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema      *Schema `json:"schema" yaml:"schema"`
+}
+
+type requestBody struct {
+	Description string                `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty" yaml:"required,omitempty"`
+	Content     map[string]*mediaType `json:"content" yaml:"content"`
+}
+
+type response struct {
+	Description string                `json:"description" yaml:"description"`
+	Content     map[string]*mediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema" yaml:"schema"`
+}
+
+// NewDocument returns a document without operations.
+func NewDocument(info Info) *Document {
+	r := newRegistry()
+	return &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Paths:      map[string]map[string]*pathOp{},
+		Components: components{Schemas: r.schemas},
+		registry:   r,
+	}
+}
+
+// AddSecurityScheme defines a security scheme operations can name.
+func (d *Document) AddSecurityScheme(name string, s SecurityScheme) {
+	if d.Components.SecuritySchemes == nil {
+		d.Components.SecuritySchemes = map[string]SecurityScheme{}
+	}
+	d.Components.SecuritySchemes[name] = s
+}
+
+// Add documents the operations of the route at path, which uses the
+// {name} wildcards of http.ServeMux patterns.
+func (d *Document) Add(path string, ops ...Operation) {
+	item := d.Paths[path]
+	if item == nil {
+		item = map[string]*pathOp{}
+		d.Paths[path] = item
+	}
+	for _, op := range ops {
+		item[strings.ToLower(op.Method)] = d.encode(op)
+	}
+}
+
+// encode converts an operation to its document form.
+func (d *Document) encode(op Operation) *pathOp {
+	out := &pathOp{
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Responses:   map[string]*response{},
+	}
+	for _, name := range op.Security {
+		out.Security = append(out.Security, map[string][]string{name: {}})
+	}
+	for _, p := range op.Params {
+		typ := p.Type
+		if typ == "" {
+			typ = "string"
+		}
+		out.Parameters = append(out.Parameters, parameter{
+			Name:        p.Name,
+			In:          p.In,
+			Description: p.Description,
+			Required:    p.Required || p.In == "path",
+			Schema:      &Schema{Type: typ},
+		})
+	}
+	if op.Request != nil {
+		out.RequestBody = &requestBody{Description: op.Request.Description, Required: op.Request.Required, Content: d.content(op.Request.Content)}
+	}
+	for _, r := range op.Responses {
+		desc := r.Description
+		if desc == "" {
+			desc = http.StatusText(r.Status)
+		}
+		res := &response{Description: desc}
+		if r.Body != nil {
+			contentType := r.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			res.Content = d.content(map[string]interface{}{contentType: r.Body})
+		}
+		status := strconv.Itoa(r.Status)
+		if prev, ok := out.Responses[status]; ok && prev.Description != http.StatusText(r.Status) && r.Description != "" {
+			// A status listed twice, such as by a handler and by the
+			// middleware in front of it, is described by both.
+			res.Description = prev.Description + " " + desc
+		}
+		out.Responses[status] = res
+	}
+	return out
+}
+
+// content describes the body of each media type.
+func (d *Document) content(bodies map[string]interface{}) map[string]*mediaType {
+	content := make(map[string]*mediaType, len(bodies))
+	for contentType, v := range bodies {
+		content[contentType] = &mediaType{Schema: d.registry.schemaFor(v)}
+	}
+	return content
+}
+
+// JSON encodes the document as indented JSON.
+func (d *Document) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// YAML encodes the document as YAML.
+func (d *Document) YAML() ([]byte, error) {
+	return yaml.Marshal(d)
+}
+
+// Mux is an http.ServeMux that documents the routes registered on it.
+type Mux struct {
+	*http.ServeMux
+	doc          *Document
+	undocumented []string
+}
+
+// NewMux returns a mux whose routes are documented in a document of info.
+func NewMux(info Info) *Mux {
+	return &Mux{ServeMux: http.NewServeMux(), doc: NewDocument(info)}
+}
+
+// Handle registers handler for pattern and documents the operations it serves.
+func (m *Mux) Handle(pattern string, handler http.Handler, ops ...Operation) {
+	m.ServeMux.Handle(pattern, handler)
+	if len(ops) == 0 {
+		m.undocumented = append(m.undocumented, pattern)
+		return
+	}
+	m.doc.Add(pattern, ops...)
+}
+
+// HandleFunc registers handler for pattern and documents the operations it serves.
+func (m *Mux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request), ops ...Operation) {
+	m.Handle(pattern, http.HandlerFunc(handler), ops...)
+}
+
+// Document returns the document of the registered routes.
+func (m *Mux) Document() *Document {
+	return m.doc
+}
+
+// Undocumented returns the patterns registered without operations, sorted.
+func (m *Mux) Undocumented() []string {
+	patterns := append([]string(nil), m.undocumented...)
+	sort.Strings(patterns)
+	return patterns
+}
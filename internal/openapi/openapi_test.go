@@ -0,0 +1,122 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type testBase struct {
+	ID string `json:"id" example:"abc"`
+}
+
+type testNode struct {
+	*testBase
+	URL      string            `json:"url" example:"https://example.com" binding:"required"`
+	Count    int               `json:"count,omitempty" example:"3"`
+	Ratio    float64           `json:"ratio" example:"0.5"`
+	Enabled  bool              `json:"enabled" example:"true"`
+	Tags     []string          `json:"tags" example:"a,b"`
+	Sizes    []int             `json:"sizes" example:"1,x"`
+	Labels   map[string]int    `json:"labels" example:"{\"h1\": 1}"`
+	At       time.Time         `json:"at" example:"2024-01-15T10:30:00Z"`
+	Raw      []byte            `json:"raw"`
+	Any      interface{}       `json:"any"`
+	Children []*testNode       `json:"children"`
+	Meta     struct{ N int }   `json:"meta"`
+	Skipped  string            `json:"-"`
+	Headers  map[string]string `json:"headers,omitempty"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	r := newRegistry()
+	ref := r.schemaFor(&testNode{})
+	assert.Equal(t, "#/components/schemas/openapi.testNode", ref.Ref, "Named structs should become components")
+
+	node := r.schemas["openapi.testNode"]
+	require.NotNil(t, node)
+	assert.Equal(t, "object", node.Type)
+	assert.Equal(t, []string{"url"}, node.Required, "binding:\"required\" fields should be required")
+	assert.NotContains(t, node.Properties, "Skipped")
+
+	props := node.Properties
+	assert.Equal(t, &Schema{Type: "string", Example: "abc"}, props["id"], "Fields of embedded structs should be promoted")
+	assert.Equal(t, &Schema{Type: "integer", Example: int64(3)}, props["count"])
+	assert.Equal(t, &Schema{Type: "number", Example: 0.5}, props["ratio"])
+	assert.Equal(t, &Schema{Type: "boolean", Example: true}, props["enabled"])
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Type: "string"}, Example: []interface{}{"a", "b"}}, props["tags"])
+	assert.Nil(t, props["sizes"].Example, "Examples that do not fit the type should be dropped")
+	assert.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "integer"}, Example: map[string]interface{}{"h1": float64(1)}}, props["labels"])
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time", Example: "2024-01-15T10:30:00Z"}, props["at"])
+	assert.Equal(t, &Schema{Type: "string", Format: "byte"}, props["raw"])
+	assert.Equal(t, &Schema{}, props["any"])
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/openapi.testNode"}}, props["children"],
+		"Recursive types should refer to themselves")
+	assert.Equal(t, &Schema{Type: "object", Properties: map[string]*Schema{"N": {Type: "integer"}}}, props["meta"],
+		"Anonymous structs should be inline")
+}
+
+func TestSchemaFor_Schema(t *testing.T) {
+	s := &Schema{Type: "string", Format: "binary"}
+	assert.Same(t, s, newRegistry().schemaFor(s), "A *Schema should be used as is")
+}
+
+func TestMux(t *testing.T) {
+	mux := NewMux(Info{Title: "Test API", Version: "1.0.0"})
+	mux.Document().AddSecurityScheme("Token", SecurityScheme{Type: "apiKey", In: "header", Name: "Authorization"})
+	mux.HandleFunc("/api/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}, Operation{
+		Method:   http.MethodGet,
+		Summary:  "Get an item",
+		Tags:     []string{"Items"},
+		Security: []string{"Token"},
+		Params:   []Param{{Name: "id", In: "path"}, {Name: "limit", In: "query", Type: "integer"}},
+		Responses: []Response{{Status: http.StatusOK, Body: testBase{}}, {Status: http.StatusNotModified},
+			{Status: http.StatusServiceUnavailable, Description: "The item's store is down."},
+			{Status: http.StatusServiceUnavailable, Description: "The server is busy."}},
+	}, Operation{
+		Method:    http.MethodPut,
+		Request:   JSONRequest(testBase{}),
+		Responses: []Response{{Status: http.StatusNoContent, Description: "Stored"}},
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/items/1", nil))
+	assert.Equal(t, http.StatusTeapot, w.Code, "Routes should be served")
+	assert.Equal(t, []string{"/", "/metrics"}, mux.Undocumented())
+
+	data, err := mux.Document().JSON()
+	require.NoError(t, err)
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, Version, doc["openapi"])
+
+	get := doc["paths"].(map[string]interface{})["/api/items/{id}"].(map[string]interface{})["get"].(map[string]interface{})
+	assert.Equal(t, "Get an item", get["summary"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"Token": []interface{}{}}}, get["security"])
+	params := get["parameters"].([]interface{})
+	assert.Equal(t, true, params[0].(map[string]interface{})["required"], "Path parameters should be required")
+	responses := get["responses"].(map[string]interface{})
+	assert.Equal(t, "#/components/schemas/openapi.testBase",
+		responses["200"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})["$ref"])
+	assert.Equal(t, "Not Modified", responses["304"].(map[string]interface{})["description"], "Descriptions should default to the status text")
+	assert.Equal(t, "The item's store is down. The server is busy.", responses["503"].(map[string]interface{})["description"],
+		"Responses of the same status should be described by both")
+	assert.Contains(t, doc["components"].(map[string]interface{})["schemas"], "openapi.testBase")
+
+	data, err = mux.Document().YAML()
+	require.NoError(t, err)
+	var fromYAML map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(data, &fromYAML))
+	assert.Equal(t, "Test API", fromYAML["info"].(map[string]interface{})["title"])
+	assert.Contains(t, fromYAML["paths"].(map[string]interface{})["/api/items/{id}"], "put")
+}
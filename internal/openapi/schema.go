@@ -0,0 +1,205 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema describes a JSON value.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string             `json:"format,omitempty" yaml:"format,omitempty"`
+	Description          string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Items                *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty" yaml:"required,omitempty"`
+	Example              interface{}        `json:"example,omitempty" yaml:"example,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// registry derives schemas from Go types. Named struct types become
+// components that schemas refer to, named like "analyzer.WebpageAnalysis".
+type registry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newRegistry() *registry {
+	return &registry{schemas: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+// schemaFor returns the schema of v's type, or v itself if it is a *Schema.
+func (r *registry) schemaFor(v interface{}) *Schema {
+	if s, ok := v.(*Schema); ok {
+		return s
+	}
+	return r.schemaOf(reflect.TypeOf(v))
+}
+
+// schemaOf returns the schema of values of t as encoding/json encodes them.
+func (r *registry) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + r.component(t)}
+	default:
+		// Interfaces hold any value.
+		return &Schema{}
+	}
+}
+
+// component registers the schema of the named struct type t, once, and
+// returns its name.
+func (r *registry) component(t reflect.Type) string {
+	if name, ok := r.names[t]; ok {
+		return name
+	}
+	name := t.String()
+	if _, taken := r.schemas[name]; taken {
+		// Two packages of the same name; tell them apart by import path.
+		name = strings.ReplaceAll(t.PkgPath(), "/", ".") + "." + t.Name()
+	}
+	// Register the name first so that recursive types refer to themselves.
+	r.names[t] = name
+	r.schemas[name] = &Schema{}
+	*r.schemas[name] = *r.structSchema(t)
+	return name
+}
+
+// structSchema returns the inline object schema of struct type t.
+func (r *registry) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	r.addFields(s, t)
+	return s
+}
+
+// addFields adds the JSON fields of struct type t to s, including those of
+// embedded structs, which encoding/json promotes.
+func (r *registry) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				r.addFields(s, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := r.schemaOf(field.Type)
+		if example, ok := field.Tag.Lookup("example"); ok && prop.Ref == "" {
+			prop.Example = exampleValue(field.Type, example)
+		}
+		s.Properties[name] = prop
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// exampleValue converts an example tag to a value of t's JSON type. Slice
+// examples list their items separated by commas. It returns nil when the tag
+// does not fit the type.
+func exampleValue(t reflect.Type, tag string) interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return tag
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return tag
+	case reflect.Bool:
+		if v, err := strconv.ParseBool(tag); err == nil {
+			return v
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v, err := strconv.ParseInt(tag, 10, 64); err == nil {
+			return v
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v, err := strconv.ParseUint(tag, 10, 64); err == nil {
+			return v
+		}
+	case reflect.Float32, reflect.Float64:
+		if v, err := strconv.ParseFloat(tag, 64); err == nil {
+			return v
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return tag
+		}
+		if strings.HasPrefix(tag, "[") {
+			return jsonExample(tag)
+		}
+		var items []interface{}
+		for _, part := range strings.Split(tag, ",") {
+			item := exampleValue(t.Elem(), strings.TrimSpace(part))
+			if item == nil {
+				return nil
+			}
+			items = append(items, item)
+		}
+		return items
+	default:
+		return jsonExample(tag)
+	}
+	return nil
+}
+
+// jsonExample decodes a JSON example, or returns nil if it is not JSON.
+func jsonExample(tag string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(tag), &v); err != nil {
+		return nil
+	}
+	return v
+}
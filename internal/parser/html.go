@@ -1,8 +1,11 @@
 package parser
 
 import (
+	"bytes"
 	"net/url"
 	"strings"
+	"sync"
+	"unicode"
 
 	"golang.org/x/net/html"
 )
@@ -26,20 +29,13 @@ func NewHTMLParser() HTMLParser {
 	return &htmlParser{}
 }
 
-// toHTMLNode safely converts interface{} to *html.Node.
-func (p *htmlParser) toHTMLNode(doc interface{}) (*html.Node, bool) {
-	htmlDoc, ok := doc.(*html.Node)
-	return htmlDoc, ok
-}
-
 // ExtractHTMLVersion determines the HTML version.
-func (p *htmlParser) ExtractHTMLVersion(doc interface{}) string {
-	htmlDoc, ok := p.toHTMLNode(doc)
-	if !ok {
+func (p *htmlParser) ExtractHTMLVersion(doc *html.Node) string {
+	if doc == nil {
 		return defaultHTMLVersion
 	}
 
-	result := p.findDoctype(htmlDoc)
+	result := p.findDoctype(doc)
 	if result == "" {
 		return defaultHTMLVersion
 	}
@@ -81,13 +77,12 @@ func (p *htmlParser) parseDoctype(n *html.Node) string {
 }
 
 // ExtractPageTitle extracts the page title.
-func (p *htmlParser) ExtractPageTitle(doc interface{}) string {
-	htmlDoc, ok := p.toHTMLNode(doc)
-	if !ok {
+func (p *htmlParser) ExtractPageTitle(doc *html.Node) string {
+	if doc == nil {
 		return ""
 	}
 
-	return p.findTitle(htmlDoc)
+	return p.findTitle(doc)
 }
 
 // findTitle searches for the title element.
@@ -118,14 +113,13 @@ func (p *htmlParser) extractTitleText(n *html.Node) string {
 }
 
 // ExtractHeadings counts headings by level.
-func (p *htmlParser) ExtractHeadings(doc interface{}) map[string]int {
-	htmlDoc, ok := p.toHTMLNode(doc)
-	if !ok {
+func (p *htmlParser) ExtractHeadings(doc *html.Node) map[string]int {
+	if doc == nil {
 		return make(map[string]int)
 	}
 
 	headings := make(map[string]int)
-	p.countHeadings(htmlDoc, headings)
+	p.countHeadings(doc, headings)
 	return headings
 }
 
@@ -155,13 +149,12 @@ func (p *htmlParser) isHeadingElement(n *html.Node) bool {
 }
 
 // ExtractLinks analyzes internal and external links.
-func (p *htmlParser) ExtractLinks(doc interface{}, baseURL string) (internal, external, inaccessible int) {
-	htmlDoc, ok := p.toHTMLNode(doc)
-	if !ok {
+func (p *htmlParser) ExtractLinks(doc *html.Node, baseURL string) (internal, external, inaccessible int) {
+	if doc == nil {
 		return 0, 0, 0
 	}
 
-	p.analyzeLinks(htmlDoc, baseURL, &internal, &external, &inaccessible)
+	p.analyzeLinks(doc, baseURL, &internal, &external, &inaccessible)
 	return internal, external, inaccessible
 }
 
@@ -276,14 +269,211 @@ func (p *htmlParser) isSameDomain(href, baseURL string) bool {
 	return strings.EqualFold(hrefURL.Hostname(), baseURLParsed.Hostname())
 }
 
+// ExtractInternalURLs returns the unique absolute http(s) URLs of same-host links, without fragments.
+func (p *htmlParser) ExtractInternalURLs(doc *html.Node, baseURL string) []string {
+	return p.extractURLs(doc, baseURL, true)
+}
+
+// ExtractLinkURLs returns the unique absolute http(s) URLs of all links, internal and external, without fragments.
+func (p *htmlParser) ExtractLinkURLs(doc *html.Node, baseURL string) []string {
+	return p.extractURLs(doc, baseURL, false)
+}
+
+// extractURLs resolves the page's links against baseURL, optionally keeping only same-host ones.
+func (p *htmlParser) extractURLs(doc *html.Node, baseURL string, sameHostOnly bool) []string {
+	if doc == nil {
+		return nil
+	}
+
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var urls []string
+	p.collectURLs(doc, base, sameHostOnly, seen, &urls)
+	return urls
+}
+
+// collectURLs recursively resolves link elements against base.
+func (p *htmlParser) collectURLs(n *html.Node, base *url.URL, sameHostOnly bool, seen map[string]bool, urls *[]string) {
+	if p.isLinkElement(n) {
+		if resolved := p.resolveURL(p.getHrefAttribute(n), base, sameHostOnly); resolved != "" && !seen[resolved] {
+			seen[resolved] = true
+			*urls = append(*urls, resolved)
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		p.collectURLs(c, base, sameHostOnly, seen, urls)
+	}
+}
+
+// resolveURL resolves href against base and returns it if it is an http(s) URL
+// (on the same host as base when sameHostOnly is set).
+func (p *htmlParser) resolveURL(href string, base *url.URL, sameHostOnly bool) string {
+	if !p.isValidLink(href) {
+		return ""
+	}
+
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+
+	resolved := base.ResolveReference(ref)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return ""
+	}
+	if sameHostOnly && !strings.EqualFold(resolved.Hostname(), base.Hostname()) {
+		return ""
+	}
+
+	resolved.Fragment = ""
+	return resolved.String()
+}
+
 // ExtractLoginForm checks if the page contains a login form.
-func (p *htmlParser) ExtractLoginForm(doc interface{}) bool {
-	htmlDoc, ok := p.toHTMLNode(doc)
-	if !ok {
+func (p *htmlParser) ExtractLoginForm(doc *html.Node) bool {
+	if doc == nil {
 		return false
 	}
 
-	return p.findLoginForm(htmlDoc)
+	return p.findLoginForm(doc)
+}
+
+// ExtractForms returns every form on the page in document order.
+func (p *htmlParser) ExtractForms(doc *html.Node) []Form {
+	if doc == nil {
+		return nil
+	}
+
+	var forms []Form
+	p.collectForms(doc, &forms)
+	return forms
+}
+
+// collectForms appends a Form for each form element under n.
+func (p *htmlParser) collectForms(n *html.Node, forms *[]Form) {
+	if p.isFormElement(n) {
+		form := Form{Method: "get", Login: p.isLoginForm(n)}
+		for _, attr := range n.Attr {
+			switch strings.ToLower(attr.Key) {
+			case "action":
+				form.Action = strings.TrimSpace(attr.Val)
+			case "method":
+				if method := strings.ToLower(strings.TrimSpace(attr.Val)); method != "" {
+					form.Method = method
+				}
+			}
+		}
+		*forms = append(*forms, form)
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		p.collectForms(c, forms)
+	}
+}
+
+// ExtractPasswordFields returns every password input on the page in document order.
+func (p *htmlParser) ExtractPasswordFields(doc *html.Node) []PasswordField {
+	if doc == nil {
+		return nil
+	}
+
+	var fields []PasswordField
+	p.collectPasswordFields(doc, "", &fields)
+	return fields
+}
+
+// collectPasswordFields appends a PasswordField for each password input under n.
+// formAutocomplete is the autocomplete attribute of the enclosing form, if any.
+func (p *htmlParser) collectPasswordFields(n *html.Node, formAutocomplete string, fields *[]PasswordField) {
+	if p.isFormElement(n) {
+		formAutocomplete = strings.ToLower(strings.TrimSpace(p.getAttribute(n, "autocomplete")))
+	}
+	if p.isInputElement(n) && strings.EqualFold(p.getAttribute(n, "type"), "password") {
+		field := PasswordField{
+			Name:         p.getAttribute(n, "name"),
+			Autocomplete: strings.ToLower(strings.TrimSpace(p.getAttribute(n, "autocomplete"))),
+			PasteBlocked: p.blocksPaste(p.getAttribute(n, "onpaste")),
+		}
+		if field.Name == "" {
+			field.Name = p.getAttribute(n, "id")
+		}
+		if field.Autocomplete == "" {
+			field.Autocomplete = formAutocomplete
+		}
+		*fields = append(*fields, field)
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		p.collectPasswordFields(c, formAutocomplete, fields)
+	}
+}
+
+// blocksPaste checks whether an inline onpaste handler cancels the event.
+func (p *htmlParser) blocksPaste(handler string) bool {
+	handler = strings.ToLower(strings.Join(strings.Fields(handler), ""))
+	return strings.Contains(handler, "returnfalse") || strings.Contains(handler, "preventdefault")
+}
+
+// ExtractElements returns the elements with the given tag names in document
+// order, or every element when no tags are given.
+func (p *htmlParser) ExtractElements(doc *html.Node, tags ...string) []Element {
+	if doc == nil {
+		return nil
+	}
+
+	wanted := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		wanted[strings.ToLower(tag)] = true
+	}
+	return p.ExtractElementsFunc(doc, func(tag string, attrs []html.Attribute) bool {
+		return len(wanted) == 0 || wanted[tag]
+	})
+}
+
+// ExtractElementsFunc returns the elements for which keep returns true, in
+// document order. keep gets the lower-cased tag name and the raw attributes,
+// so that detectors can skip elements before they are flattened.
+func (p *htmlParser) ExtractElementsFunc(doc *html.Node, keep func(tag string, attrs []html.Attribute) bool) []Element {
+	if doc == nil {
+		return nil
+	}
+
+	var elements []Element
+	p.collectElements(doc, keep, &elements)
+	return elements
+}
+
+// collectElements appends the elements under n that keep accepts.
+func (p *htmlParser) collectElements(n *html.Node, keep func(string, []html.Attribute) bool, elements *[]Element) {
+	if n.Type == html.ElementNode {
+		tag := strings.ToLower(n.Data)
+		if keep(tag, n.Attr) {
+			attrs := make(map[string]string, len(n.Attr))
+			for _, attr := range n.Attr {
+				attrs[strings.ToLower(attr.Key)] = attr.Val
+			}
+			*elements = append(*elements, Element{Tag: tag, Attrs: attrs, node: n})
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		p.collectElements(c, keep, elements)
+	}
+}
+
+// getAttribute returns the value of the named attribute, or "" if absent.
+func (p *htmlParser) getAttribute(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if strings.EqualFold(attr.Key, key) {
+			return attr.Val
+		}
+	}
+	return ""
 }
 
 // findLoginForm searches for login form indicators.
@@ -558,6 +748,123 @@ func (p *htmlParser) containsLoginKeyword(s string) bool {
 	return false
 }
 
+// hiddenElements hold no visible text, or only navigation chrome.
+var hiddenElements = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true, "template": true,
+	"nav": true, "svg": true, "canvas": true, "iframe": true, "object": true,
+}
+
+// blockElements start a new line of visible text.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "fieldset": true, "figcaption": true,
+	"figure": true, "footer": true, "form": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "header": true, "hr": true, "li": true,
+	"main": true, "ol": true, "p": true, "pre": true, "section": true, "table": true,
+	"td": true, "th": true, "tr": true, "ul": true,
+}
+
+// ExtractVisibleText returns the text a reader would see, one block element per
+// line with whitespace collapsed. Scripts, styles, navigation and hidden elements
+// are skipped.
+func (p *htmlParser) ExtractVisibleText(doc *html.Node) string {
+	if doc == nil {
+		return ""
+	}
+
+	w := visibleTextWriter{buf: textBuffers.Get().(*bytes.Buffer)}
+	defer func() {
+		if w.buf.Cap() <= maxPooledTextSize {
+			w.buf.Reset()
+			textBuffers.Put(w.buf)
+		}
+	}()
+	p.collectVisibleText(doc, &w)
+	return w.buf.String()
+}
+
+// maxPooledTextSize is the largest buffer textBuffers keeps.
+const maxPooledTextSize = 1 << 20
+
+// textBuffers holds the buffers visible text is written into, so that
+// extracting it allocates little more than the resulting string.
+var textBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// visibleTextWriter writes words separated by single spaces, starting a new
+// line for the first word after a block boundary.
+type visibleTextWriter struct {
+	buf     *bytes.Buffer
+	midLine bool // The current line has words.
+}
+
+// writeWords writes the whitespace-separated words of s, splitting as
+// strings.Fields does without allocating the slice.
+func (w *visibleTextWriter) writeWords(s string) {
+	start := -1
+	for i, r := range s {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				w.writeWord(s[start:i])
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		w.writeWord(s[start:])
+	}
+}
+
+func (w *visibleTextWriter) writeWord(word string) {
+	if w.midLine {
+		w.buf.WriteByte(' ')
+	} else if w.buf.Len() > 0 {
+		w.buf.WriteByte('\n')
+	}
+	w.buf.WriteString(word)
+	w.midLine = true
+}
+
+// endLine ends the current line, if it has words.
+func (w *visibleTextWriter) endLine() {
+	w.midLine = false
+}
+
+// collectVisibleText writes the words of visible text nodes to w, ending the
+// line at block element boundaries.
+func (p *htmlParser) collectVisibleText(n *html.Node, w *visibleTextWriter) {
+	switch n.Type {
+	case html.TextNode:
+		w.writeWords(n.Data)
+		return
+	case html.ElementNode:
+		tag := strings.ToLower(n.Data)
+		if hiddenElements[tag] || p.isHiddenElement(n) {
+			return
+		}
+		if blockElements[tag] {
+			w.endLine()
+			defer w.endLine()
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		p.collectVisibleText(c, w)
+	}
+}
+
+// isHiddenElement checks for the hidden and aria-hidden="true" attributes.
+func (p *htmlParser) isHiddenElement(n *html.Node) bool {
+	for _, attr := range n.Attr {
+		key := strings.ToLower(attr.Key)
+		if key == "hidden" || (key == "aria-hidden" && strings.EqualFold(attr.Val, "true")) {
+			return true
+		}
+	}
+	return false
+}
+
 // getNodeText extracts text content from a node.
 func (p *htmlParser) getNodeText(n *html.Node) string {
 	var text strings.Builder
@@ -575,3 +882,36 @@ func (p *htmlParser) extractText(node *html.Node, text *strings.Builder) {
 		p.extractText(c, text)
 	}
 }
+
+// collapsedText builds text with runs of whitespace collapsed to one space
+// and leading and trailing whitespace removed, like joining strings.Fields.
+type collapsedText struct {
+	strings.Builder
+	space bool // Whether whitespace is pending before the next rune.
+}
+
+// walk appends the text under n, stopping once it is longer than max.
+func (t *collapsedText) walk(n *html.Node, max int) bool {
+	if n.Type == html.TextNode {
+		for _, r := range n.Data {
+			if unicode.IsSpace(r) {
+				t.space = t.Len() > 0
+				continue
+			}
+			if t.space {
+				t.WriteByte(' ')
+				t.space = false
+			}
+			t.WriteRune(r)
+			if max >= 0 && t.Len() > max {
+				return false
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if !t.walk(c, max) {
+			return false
+		}
+	}
+	return true
+}
@@ -246,6 +246,50 @@ func TestExtractLinks(t *testing.T) {
 	}
 }
 
+func TestExtractInternalURLs(t *testing.T) {
+	parser := NewHTMLParser()
+
+	doc, _ := html.Parse(strings.NewReader(`<html><body>
+		<a href="/about">About</a>
+		<a href="/about#team">About team</a>
+		<a href="contact?x=1">Contact</a>
+		<a href="https://EXAMPLE.com/blog">Blog</a>
+		<a href="//example.com/cdn">CDN</a>
+		<a href="https://other.com/page">Other</a>
+		<a href="mailto:hi@example.com">Mail</a>
+		<a href="javascript:void(0)">JS</a>
+		<a href="">Empty</a>
+	</body></html>`))
+
+	urls := parser.ExtractInternalURLs(doc, "https://example.com/docs/index.html")
+
+	assert.Equal(t, []string{
+		"https://example.com/about",
+		"https://example.com/docs/contact?x=1",
+		"https://EXAMPLE.com/blog",
+		"https://example.com/cdn",
+	}, urls, "Only unique same-host http(s) URLs should be returned, resolved and without fragments")
+}
+
+func TestExtractLinkURLs(t *testing.T) {
+	parser := NewHTMLParser()
+
+	doc, _ := html.Parse(strings.NewReader(`<html><body>
+		<a href="/about#team">About</a>
+		<a href="/about">About again</a>
+		<a href="https://other.com/page">Other</a>
+		<a href="mailto:hi@example.com">Mail</a>
+		<a href="javascript:void(0)">JS</a>
+	</body></html>`))
+
+	urls := parser.ExtractLinkURLs(doc, "https://example.com/")
+
+	assert.Equal(t, []string{
+		"https://example.com/about",
+		"https://other.com/page",
+	}, urls, "Unique internal and external http(s) URLs should be returned")
+}
+
 func TestExtractLoginForm(t *testing.T) {
 	parser := NewHTMLParser()
 
@@ -351,3 +395,188 @@ func TestCaseInsensitiveElementDetection(t *testing.T) {
 	hasLoginForm := parser.ExtractLoginForm(doc)
 	assert.True(t, hasLoginForm, "Login form detection should work with uppercase FORM/INPUT")
 }
+
+func TestNilDocument(t *testing.T) {
+	parser := NewHTMLParser()
+
+	assert.Equal(t, "HTML5 (implied)", parser.ExtractHTMLVersion(nil), "Nil document should report the default HTML version")
+	assert.Empty(t, parser.ExtractPageTitle(nil))
+	assert.Empty(t, parser.ExtractHeadings(nil))
+	internal, external, inaccessible := parser.ExtractLinks(nil, "https://example.com")
+	assert.Zero(t, internal+external+inaccessible)
+	assert.Nil(t, parser.ExtractInternalURLs(nil, "https://example.com"))
+	assert.Nil(t, parser.ExtractLinkURLs(nil, "https://example.com"))
+	assert.False(t, parser.ExtractLoginForm(nil))
+	assert.Empty(t, parser.ExtractVisibleText(nil))
+	assert.Nil(t, parser.ExtractForms(nil))
+	assert.Nil(t, parser.ExtractPasswordFields(nil))
+	assert.Nil(t, parser.ExtractElements(nil))
+}
+
+func TestExtractElements(t *testing.T) {
+	parser := NewHTMLParser()
+
+	doc, _ := html.Parse(strings.NewReader(`<html><head>
+		<SCRIPT SRC="/app.js" async></SCRIPT>
+	</head><body>
+		<div class="widget" data-sitekey="abc">  Hello
+			<b>there</b></div>
+		<script>inline()</script>
+	</body></html>`))
+
+	scripts := parser.ExtractElements(doc, "script")
+	require.Len(t, scripts, 2, "Only the requested tags should be returned")
+	assert.Equal(t, "/app.js", scripts[0].Attr("src"), "Attribute names should be lower-cased")
+	assert.Empty(t, scripts[1].Attr("src"))
+	assert.Equal(t, "inline()", scripts[1].Text())
+
+	divs := parser.ExtractElements(doc, "div")
+	require.Len(t, divs, 1)
+	assert.Equal(t, "Hello there", divs[0].Text(), "Text should include descendants with whitespace collapsed")
+
+	all := parser.ExtractElements(doc)
+	assert.Len(t, all, 7, "No tags should return every element")
+
+	text, short := divs[0].ShortText(5)
+	assert.False(t, short, "Text longer than the limit should not fit")
+	assert.Less(t, len(text), len("Hello there"), "The walk should stop past the limit")
+	text, short = divs[0].ShortText(11)
+	assert.True(t, short)
+	assert.Equal(t, "Hello there", text)
+	assert.Empty(t, Element{Tag: "div"}.Text(), "Elements built by hand have no text")
+}
+
+func TestExtractPasswordFields(t *testing.T) {
+	parser := NewHTMLParser()
+
+	doc, _ := html.Parse(strings.NewReader(`<html><body>
+		<form autocomplete="off">
+			<input type="password" name="pass" onpaste="return false;">
+			<input type="PASSWORD" id="confirm" autocomplete="New-Password">
+		</form>
+		<input type="password" name="pin" onpaste="event.preventDefault()">
+		<input type="text" name="user">
+	</body></html>`))
+
+	assert.Equal(t, []PasswordField{
+		{Name: "pass", Autocomplete: "off", PasteBlocked: true},
+		{Name: "confirm", Autocomplete: "new-password"},
+		{Name: "pin", PasteBlocked: true},
+	}, parser.ExtractPasswordFields(doc), "Password fields should inherit form autocomplete and detect paste blocking")
+}
+
+func TestExtractForms(t *testing.T) {
+	parser := NewHTMLParser()
+
+	doc, _ := html.Parse(strings.NewReader(`<html><body>
+		<form action="/search"><input name="q"></form>
+		<FORM ACTION=" http://example.com/login " METHOD="POST">
+			<input name="username"><input type="password" name="password">
+		</FORM>
+	</body></html>`))
+
+	assert.Equal(t, []Form{
+		{Action: "/search", Method: "get"},
+		{Action: "http://example.com/login", Method: "post", Login: true},
+	}, parser.ExtractForms(doc), "Forms should be returned in document order with normalized attributes")
+}
+
+func TestExtractVisibleText(t *testing.T) {
+	parser := NewHTMLParser()
+
+	doc, _ := html.Parse(strings.NewReader(`<html><head><title>Ignored</title><style>p { color: red }</style></head>
+	<body>
+		<nav><a href="/">Home</a></nav>
+		<h1>  Welcome
+			home </h1>
+		<p>Read <b>this</b> first.</p>
+		<script>var x = 1;</script>
+		<div hidden>Secret</div>
+		<ul><li>One</li><li aria-hidden="true">Icon</li><li>Two</li></ul>
+	</body></html>`))
+
+	assert.Equal(t, "Welcome home\nRead this first.\nOne\nTwo", parser.ExtractVisibleText(doc),
+		"Visible text should keep one line per block and drop scripts, styles, navigation and hidden elements")
+}
+
+func TestExtractVisibleText_ReusesBuffers(t *testing.T) {
+	parser := NewHTMLParser()
+	long, err := html.Parse(strings.NewReader("<p>" + strings.Repeat("word ", 1000) + "</p>"))
+	require.NoError(t, err)
+	short, err := html.Parse(strings.NewReader("<p>One\n two</p><div>Three</div>"))
+	require.NoError(t, err)
+
+	text := parser.ExtractVisibleText(long)
+	assert.Equal(t, "One two\nThree", parser.ExtractVisibleText(short), "Text from an earlier call should not leak into a later one")
+	assert.Len(t, strings.Fields(text), 1000, "Returned text should not change when its buffer is reused")
+}
+
+// benchmarkPage returns a page of about 200 KB with nested blocks, inline
+// elements and skipped elements, like a typical article or listing page.
+func benchmarkPage() string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html><html><head><title>Benchmark</title><style>p { color: red }</style></head><body><nav><a href=\"/\">Home</a></nav>")
+	for i := 0; i < 400; i++ {
+		b.WriteString("<div class=\"card\"><h2>Section heading</h2><p>Some <strong>bold</strong> text and a <a href=\"/page\">link</a>,\n\tspread over   several lines of markup.</p><ul><li>One</li><li>Two</li></ul><script>var x = 1;</script></div>")
+	}
+	b.WriteString("</body></html>")
+	return b.String()
+}
+
+// nestedPage returns a page whose body is depth nested divs, each with a
+// line of its own text, which is quadratic for anything that copies every
+// element's descendant text.
+func nestedPage(depth int) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html><html><head><title>Nested</title></head><body>")
+	for i := 0; i < depth; i++ {
+		b.WriteString("<div class=\"level\">Some text at this level of the tree\n")
+	}
+	b.WriteString(strings.Repeat("</div>", depth))
+	b.WriteString("</body></html>")
+	return b.String()
+}
+
+func BenchmarkExtractVisibleText(b *testing.B) {
+	for _, bm := range []struct {
+		name string
+		page string
+	}{
+		{"flat", benchmarkPage()},
+		{"nested", nestedPage(2000)},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			doc, err := html.Parse(strings.NewReader(bm.page))
+			require.NoError(b, err)
+			parser := NewHTMLParser()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				parser.ExtractVisibleText(doc)
+			}
+		})
+	}
+}
+
+func BenchmarkExtractElements(b *testing.B) {
+	for _, bm := range []struct {
+		name string
+		page string
+	}{
+		{"flat", benchmarkPage()},
+		{"nested", nestedPage(2000)},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			doc, err := html.Parse(strings.NewReader(bm.page))
+			require.NoError(b, err)
+			parser := NewHTMLParser()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				parser.ExtractElements(doc, "div")
+			}
+		})
+	}
+}
@@ -1,10 +1,72 @@
+// Package parser holds the single HTML extraction implementation (version, title,
+// headings, links, login forms, visible text) shared by the REST, gRPC, GraphQL, CLI and library
+// code paths, so every caller sees the same heuristics.
 package parser
 
+import "golang.org/x/net/html"
+
 // HTMLParser defines the interface for HTML parsing operations.
+// A nil document is treated as an empty page.
 type HTMLParser interface {
-	ExtractHTMLVersion(doc interface{}) string
-	ExtractPageTitle(doc interface{}) string
-	ExtractHeadings(doc interface{}) map[string]int
-	ExtractLinks(doc interface{}, baseURL string) (internal, external, inaccessible int)
-	ExtractLoginForm(doc interface{}) bool
+	ExtractHTMLVersion(doc *html.Node) string
+	ExtractPageTitle(doc *html.Node) string
+	ExtractHeadings(doc *html.Node) map[string]int
+	ExtractLinks(doc *html.Node, baseURL string) (internal, external, inaccessible int)
+	ExtractInternalURLs(doc *html.Node, baseURL string) []string
+	ExtractLinkURLs(doc *html.Node, baseURL string) []string
+	ExtractLoginForm(doc *html.Node) bool
+	ExtractForms(doc *html.Node) []Form
+	ExtractPasswordFields(doc *html.Node) []PasswordField
+	ExtractElements(doc *html.Node, tags ...string) []Element
+	ExtractElementsFunc(doc *html.Node, keep func(tag string, attrs []html.Attribute) bool) []Element
+	ExtractVisibleText(doc *html.Node) string
+}
+
+// Form describes a <form> element on the page.
+type Form struct {
+	Action string // Raw action attribute; empty means the form submits to the page URL.
+	Method string // Lower-cased method attribute; "get" when absent.
+	Login  bool   // Whether the form looks like a login form, as in ExtractLoginForm.
+}
+
+// PasswordField describes an <input type="password"> on the page.
+type PasswordField struct {
+	Name         string // name attribute, falling back to id.
+	Autocomplete string // Lower-cased autocomplete attribute, inherited from the form when unset.
+	PasteBlocked bool   // Whether an onpaste handler cancels pasting.
+}
+
+// Element is a flattened view of an HTML element, for detectors that only
+// need tag names, attributes and text.
+type Element struct {
+	Tag   string            // Lower-cased tag name.
+	Attrs map[string]string // Keyed by lower-cased attribute name.
+
+	node *html.Node
+}
+
+// Text returns the element's descendant text with whitespace collapsed. It
+// walks the subtree on every call rather than when the element is extracted,
+// since nested elements would otherwise each copy the text of all their
+// descendants.
+func (e Element) Text() string {
+	text, _ := e.ShortText(-1)
+	return text
+}
+
+// ShortText is Text for detectors that only look at short elements: it stops
+// walking once the text is longer than max and reports whether it fit. A
+// negative max means no limit.
+func (e Element) ShortText(max int) (string, bool) {
+	if e.node == nil {
+		return "", true
+	}
+	var text collapsedText
+	fits := text.walk(e.node, max)
+	return text.String(), fits
+}
+
+// Attr returns the value of the named attribute, or "" if absent.
+func (e Element) Attr(key string) string {
+	return e.Attrs[key]
 }
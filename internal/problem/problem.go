@@ -0,0 +1,119 @@
+// Package problem writes error responses as RFC 7807 problem details
+// (application/problem+json), each with a stable code clients can switch on.
+package problem
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// ContentType is the media type of problem responses.
+const ContentType = "application/problem+json"
+
+// Codes of problems with the API request rather than with an analyzed page.
+// Analysis failures use the codes of analyzer.AnalysisError instead.
+const (
+	CodeInvalidRequest   = "invalid_request"
+	CodeUnauthorized     = "unauthorized"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeBodyTooLarge     = "body_too_large"
+	CodeUnprocessable    = "unprocessable"
+	CodeServerBusy       = "server_busy"
+	CodeFeatureDisabled  = "feature_disabled"
+	// API key limits; see the API key middleware.
+	CodeQuotaExceeded       = "quota_exceeded"
+	CodeConcurrencyExceeded = "concurrency_exceeded"
+	// Idempotency-Key misuse; see the idempotency middleware.
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodeRequestInProgress    = "request_in_progress"
+	CodeInternal             = "internal_error"
+	CodeUnavailable          = "unavailable"
+)
+
+// requestIDHeader is set on responses by the access log middleware.
+const requestIDHeader = "X-Request-ID"
+
+// Problem is an RFC 7807 problem details object. Type is always
+// "about:blank", so Title is the status text; Code tells problems with the
+// same status apart.
+type Problem struct {
+	Type   string `json:"type" example:"about:blank"`
+	Title  string `json:"title" example:"Bad Request"`
+	Status int    `json:"status" example:"400"`
+	Detail string `json:"detail,omitempty" example:"url is required"`
+	Code   string `json:"code" example:"invalid_request"`
+	// URL and UpstreamStatus describe the page a failed analysis was about.
+	URL            string `json:"url,omitempty" example:"https://example.com"`
+	UpstreamStatus int    `json:"upstream_status,omitempty" example:"404"`
+	// RetryAfter is the page's advised wait in seconds, also sent as the
+	// Retry-After header.
+	RetryAfter int `json:"retry_after,omitempty" example:"120"`
+	// Redirects is the redirect chain of a fetch stopped by a redirect loop
+	// or too many redirects.
+	Redirects []string `json:"redirects,omitempty" example:"https://example.com/a,https://example.com/b,https://example.com/a"`
+	RequestID string   `json:"request_id,omitempty" example:"4bf92f3577b34da6"`
+}
+
+// New returns a problem with the given status, code and human-readable detail.
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// Write sends p, tagged with the request ID of the response if it has one.
+func Write(w http.ResponseWriter, p *Problem) {
+	if p.RequestID == "" {
+		p.RequestID = w.Header().Get(requestIDHeader)
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if p.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(p.RetryAfter))
+	}
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		slog.Error("Failed to encode problem response", "error", err)
+	}
+}
+
+// Error writes a problem whose code follows from the status, like http.Error
+// but as problem details. Use Write with New where a more specific code helps.
+func Error(w http.ResponseWriter, detail string, status int) {
+	Write(w, New(status, CodeForStatus(status), detail))
+}
+
+// CodeForStatus returns the generic code for an HTTP status.
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodeBodyTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	default:
+		if status >= 500 {
+			return CodeInternal
+		}
+		return CodeInvalidRequest
+	}
+}
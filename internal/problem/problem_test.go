@@ -0,0 +1,67 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("X-Request-ID", "abc123")
+
+	Write(w, New(http.StatusConflict, CodeRequestInProgress, "Still running"))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, ContentType, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Conflict",
+		"status": 409,
+		"detail": "Still running",
+		"code": "request_in_progress",
+		"request_id": "abc123"
+	}`, w.Body.String())
+}
+
+func TestWrite_RetryAfter(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	p := New(http.StatusServiceUnavailable, CodeUnavailable, "Rate limited")
+	p.RetryAfter = 30
+	Write(w, p)
+
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), `"retry_after":30`)
+}
+
+func TestError(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+	var p Problem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&p))
+	assert.Equal(t, CodeMethodNotAllowed, p.Code)
+	assert.Equal(t, "Method Not Allowed", p.Title)
+	assert.Empty(t, p.RequestID)
+}
+
+func TestCodeForStatus(t *testing.T) {
+	tests := map[int]string{
+		http.StatusBadRequest:            CodeInvalidRequest,
+		http.StatusUnauthorized:          CodeUnauthorized,
+		http.StatusNotFound:              CodeNotFound,
+		http.StatusRequestEntityTooLarge: CodeBodyTooLarge,
+		http.StatusTeapot:                CodeInvalidRequest,
+		http.StatusServiceUnavailable:    CodeUnavailable,
+		http.StatusBadGateway:            CodeInternal,
+	}
+	for status, code := range tests {
+		assert.Equal(t, code, CodeForStatus(status), "status %d", status)
+	}
+}
@@ -0,0 +1,96 @@
+package reputation
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+// blocklistThreat is the threat type of URLs on a listed domain.
+const blocklistThreat = "blocklisted"
+
+// Blocklist is an in-memory set of domains, loaded from a file with one
+// domain per line. Listing a domain lists its subdomains too. Blank lines and
+// "#" comments are skipped, a leading "*." or "." is ignored, and hosts-file
+// lines such as "0.0.0.0 ads.example" are read for their domains, so common
+// published blocklists can be used as they are.
+type Blocklist struct {
+	domains map[string]bool
+}
+
+// OpenBlocklist loads a blocklist from a file.
+func OpenBlocklist(path string) (*Blocklist, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blocklist: %v", err)
+	}
+	defer f.Close()
+	return LoadBlocklist(f)
+}
+
+// LoadBlocklist reads a blocklist in the format described on Blocklist.
+func LoadBlocklist(r io.Reader) (*Blocklist, error) {
+	b := &Blocklist{domains: make(map[string]bool)}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) > 0 && net.ParseIP(fields[0]) != nil {
+			fields = fields[1:] // A hosts-file line.
+		}
+		for _, domain := range fields {
+			domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+			domain = strings.TrimPrefix(strings.TrimPrefix(domain, "*"), ".")
+			if domain != "" && domain != "localhost" {
+				b.domains[domain] = true
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read blocklist: %v", err)
+	}
+	return b, nil
+}
+
+// Len returns the number of listed domains.
+func (b *Blocklist) Len() int { return len(b.domains) }
+
+// Source implements analyzer.ReputationChecker.
+func (b *Blocklist) Source() string { return ProviderBlocklist }
+
+// CheckURLs reports the URLs whose host is, or is under, a listed domain.
+func (b *Blocklist) CheckURLs(ctx context.Context, urls []string) ([]analyzer.ThreatMatch, error) {
+	var matches []analyzer.ThreatMatch
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			continue
+		}
+		if b.listed(strings.ToLower(parsed.Hostname())) {
+			matches = append(matches, analyzer.ThreatMatch{URL: u, ThreatType: blocklistThreat})
+		}
+	}
+	return matches, nil
+}
+
+// listed reports whether host or one of its parent domains is listed.
+func (b *Blocklist) listed(host string) bool {
+	for host != "" {
+		if b.domains[host] {
+			return true
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			break
+		}
+		host = parent
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package reputation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+// dnsblThreats names the return codes of Spamhaus DBL, the most used
+// domain blocklist. Other lists' codes are reported as "listed".
+var dnsblThreats = map[string]string{
+	"127.0.1.2":   "spam",
+	"127.0.1.4":   "phishing",
+	"127.0.1.5":   "malware",
+	"127.0.1.6":   "botnet_cc",
+	"127.0.1.102": "abused_spam",
+	"127.0.1.103": "abused_redirector",
+	"127.0.1.104": "abused_phishing",
+	"127.0.1.105": "abused_malware",
+	"127.0.1.106": "abused_botnet_cc",
+}
+
+// dnsblChecker looks domains up in a domain-based DNS blocklist: a domain is
+// listed when <domain>.<zone> resolves, to an address in 127.0.0.0/8.
+type dnsblChecker struct {
+	zone    string
+	timeout time.Duration
+	lookup  func(ctx context.Context, host string) ([]string, error)
+}
+
+func newDNSBLChecker(zone string, timeout time.Duration) *dnsblChecker {
+	return &dnsblChecker{
+		zone:    strings.Trim(strings.ToLower(zone), "."),
+		timeout: timeout,
+		lookup:  net.DefaultResolver.LookupHost,
+	}
+}
+
+func (c *dnsblChecker) Source() string { return ProviderDNSBL }
+
+// CheckURLs looks up the registrable domain of each URL's host, once per
+// domain. IP address hosts are skipped, as domain lists do not hold them.
+func (c *dnsblChecker) CheckURLs(ctx context.Context, urls []string) ([]analyzer.ThreatMatch, error) {
+	threats := make(map[string]string) // domain -> threat, "" when not listed.
+	var matches []analyzer.ThreatMatch
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil || net.ParseIP(parsed.Hostname()) != nil {
+			continue
+		}
+		domain, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(parsed.Hostname()))
+		if err != nil {
+			continue
+		}
+		threat, ok := threats[domain]
+		if !ok {
+			if threat, err = c.query(ctx, domain); err != nil {
+				return nil, err
+			}
+			threats[domain] = threat
+		}
+		if threat != "" {
+			matches = append(matches, analyzer.ThreatMatch{URL: u, ThreatType: threat})
+		}
+	}
+	return matches, nil
+}
+
+// query returns the domain's threat, or "" if the list does not hold it.
+func (c *dnsblChecker) query(ctx context.Context, domain string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	addrs, err := c.lookup(ctx, domain+"."+c.zone)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query %s: %v", c.zone, err)
+	}
+	for _, addr := range addrs {
+		if strings.HasPrefix(addr, "127.255.255.") {
+			// Spamhaus answers these for refused queries, such as those
+			// from public resolvers.
+			return "", fmt.Errorf("%s refused the query: %s", c.zone, addr)
+		}
+	}
+	for _, addr := range addrs {
+		if threat, ok := dnsblThreats[addr]; ok {
+			return threat, nil
+		}
+	}
+	for _, addr := range addrs {
+		if strings.HasPrefix(addr, "127.") {
+			return "listed", nil
+		}
+	}
+	return "", nil // Some resolvers answer every name; only loopback answers count.
+}
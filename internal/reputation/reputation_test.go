@@ -0,0 +1,175 @@
+package reputation
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+func TestSafeBrowsingChecker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "secret", r.URL.Query().Get("key"))
+
+		var req safeBrowsingRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Len(t, req.ThreatInfo.ThreatEntries, 2)
+
+		_, _ = w.Write([]byte(`{"matches": [
+			{"threatType": "SOCIAL_ENGINEERING", "platformType": "ANY_PLATFORM", "threat": {"url": "https://phish.example/"}},
+			{"threatType": "SOCIAL_ENGINEERING", "platformType": "WINDOWS", "threat": {"url": "https://phish.example/"}}
+		]}`))
+	}))
+	defer server.Close()
+
+	checker, err := New(Config{Provider: ProviderSafeBrowsing, APIKey: "secret", Endpoint: server.URL})
+	require.NoError(t, err)
+	assert.Equal(t, ProviderSafeBrowsing, checker.Source())
+
+	matches, err := checker.CheckURLs(context.Background(), []string{"https://phish.example/", "https://example.com/"})
+	require.NoError(t, err)
+	assert.Equal(t, []analyzer.ThreatMatch{{URL: "https://phish.example/", ThreatType: "social_engineering"}}, matches,
+		"Matches on several platforms should be reported once")
+}
+
+func TestURLHausChecker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("Auth-Key"))
+		require.NoError(t, r.ParseForm())
+		switch r.PostForm.Get("url") {
+		case "http://malware.example/payload.exe":
+			_, _ = w.Write([]byte(`{"query_status": "ok", "url_status": "online", "threat": "malware_download"}`))
+		case "https://broken.example/":
+			_, _ = w.Write([]byte(`{"query_status": "invalid_url"}`))
+		default:
+			_, _ = w.Write([]byte(`{"query_status": "no_results"}`))
+		}
+	}))
+	defer server.Close()
+
+	checker, err := New(Config{Provider: ProviderURLHaus, APIKey: "secret", Endpoint: server.URL})
+	require.NoError(t, err)
+
+	matches, err := checker.CheckURLs(context.Background(), []string{"https://example.com/", "http://malware.example/payload.exe"})
+	require.NoError(t, err)
+	assert.Equal(t, []analyzer.ThreatMatch{{URL: "http://malware.example/payload.exe", ThreatType: "malware_download"}}, matches)
+
+	_, err = checker.CheckURLs(context.Background(), []string{"https://broken.example/"})
+	assert.Error(t, err, "Lookup failures should be reported")
+}
+
+func TestBlocklist(t *testing.T) {
+	blocklist, err := LoadBlocklist(strings.NewReader(`# Phishing domains
+bad.example
+*.tracker.example
+0.0.0.0 ads.example ads2.example # hosts-file format
+127.0.0.1 localhost
+
+Evil.Example.
+`))
+	require.NoError(t, err)
+	assert.Equal(t, 5, blocklist.Len())
+	assert.Equal(t, ProviderBlocklist, blocklist.Source())
+
+	matches, err := blocklist.CheckURLs(context.Background(), []string{
+		"https://bad.example/login",
+		"https://www.bad.example/",
+		"https://notbad.example/",
+		"https://pixel.tracker.example/p.gif",
+		"http://ads2.example/",
+		"https://evil.example/",
+		"https://example.com/",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []analyzer.ThreatMatch{
+		{URL: "https://bad.example/login", ThreatType: "blocklisted"},
+		{URL: "https://www.bad.example/", ThreatType: "blocklisted"},
+		{URL: "https://pixel.tracker.example/p.gif", ThreatType: "blocklisted"},
+		{URL: "http://ads2.example/", ThreatType: "blocklisted"},
+		{URL: "https://evil.example/", ThreatType: "blocklisted"},
+	}, matches)
+}
+
+func TestOpenBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("bad.example\n"), 0o600))
+
+	checker, err := New(Config{Provider: ProviderBlocklist, Path: path})
+	require.NoError(t, err)
+	assert.Equal(t, ProviderBlocklist, checker.Source())
+
+	_, err = New(Config{Provider: ProviderBlocklist, Path: filepath.Join(t.TempDir(), "missing.txt")})
+	assert.Error(t, err)
+}
+
+func TestDNSBLChecker(t *testing.T) {
+	var queries []string
+	checker := newDNSBLChecker("dbl.example.", time.Second)
+	checker.lookup = func(ctx context.Context, host string) ([]string, error) {
+		queries = append(queries, host)
+		switch host {
+		case "phish.example.dbl.example":
+			return []string{"127.0.1.4"}, nil
+		case "spam.example.dbl.example":
+			return []string{"127.0.0.2"}, nil
+		case "wildcard.example.dbl.example":
+			return []string{"203.0.113.1"}, nil
+		case "refused.example.dbl.example":
+			return []string{"127.255.255.254"}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	assert.Equal(t, ProviderDNSBL, checker.Source())
+
+	matches, err := checker.CheckURLs(context.Background(), []string{
+		"https://login.phish.example/a",
+		"https://phish.example/b",
+		"http://spam.example/",
+		"https://wildcard.example/",
+		"https://clean.example/",
+		"http://192.0.2.1/",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []analyzer.ThreatMatch{
+		{URL: "https://login.phish.example/a", ThreatType: "phishing"},
+		{URL: "https://phish.example/b", ThreatType: "phishing"},
+		{URL: "http://spam.example/", ThreatType: "listed"},
+	}, matches)
+	assert.Equal(t, []string{"phish.example.dbl.example", "spam.example.dbl.example", "wildcard.example.dbl.example", "clean.example.dbl.example"}, queries,
+		"Each registrable domain should be looked up once, and IP addresses not at all")
+
+	_, err = checker.CheckURLs(context.Background(), []string{"https://refused.example/"})
+	assert.Error(t, err, "Refused queries should be reported")
+
+	checker.lookup = func(ctx context.Context, host string) ([]string, error) {
+		return nil, &net.DNSError{Err: "i/o timeout", Name: host, IsTimeout: true}
+	}
+	_, err = checker.CheckURLs(context.Background(), []string{"https://example.com/"})
+	assert.Error(t, err, "Lookup failures should be reported")
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	_, err := New(Config{Provider: ProviderSafeBrowsing})
+	assert.Error(t, err, "A provider without an API key should be rejected")
+
+	_, err = New(Config{Provider: ProviderBlocklist})
+	assert.Error(t, err, "A blocklist without a file should be rejected")
+
+	_, err = New(Config{Provider: ProviderDNSBL})
+	assert.Error(t, err, "A DNSBL without a zone should be rejected")
+
+	_, err = New(Config{Provider: "virustotal", APIKey: "secret"})
+	assert.Error(t, err, "Unknown providers should be rejected")
+}
@@ -0,0 +1,117 @@
+package reputation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+const (
+	safeBrowsingEndpoint = "https://safebrowsing.googleapis.com/v4/threatMatches:find"
+	// safeBrowsingBatchSize is the most URLs the Lookup API accepts per request.
+	safeBrowsingBatchSize = 500
+)
+
+// safeBrowsingThreatTypes are the Safe Browsing lists URLs are checked against.
+var safeBrowsingThreatTypes = []string{"MALWARE", "SOCIAL_ENGINEERING", "UNWANTED_SOFTWARE", "POTENTIALLY_HARMFUL_APPLICATION"}
+
+// safeBrowsingChecker uses the Google Safe Browsing v4 Lookup API.
+type safeBrowsingChecker struct {
+	client   *http.Client
+	endpoint string
+	apiKey   string
+}
+
+type safeBrowsingEntry struct {
+	URL string `json:"url"`
+}
+
+type safeBrowsingRequest struct {
+	Client struct {
+		ClientID      string `json:"clientId"`
+		ClientVersion string `json:"clientVersion"`
+	} `json:"client"`
+	ThreatInfo struct {
+		ThreatTypes      []string            `json:"threatTypes"`
+		PlatformTypes    []string            `json:"platformTypes"`
+		ThreatEntryTypes []string            `json:"threatEntryTypes"`
+		ThreatEntries    []safeBrowsingEntry `json:"threatEntries"`
+	} `json:"threatInfo"`
+}
+
+type safeBrowsingResponse struct {
+	Matches []struct {
+		ThreatType string            `json:"threatType"`
+		Threat     safeBrowsingEntry `json:"threat"`
+	} `json:"matches"`
+}
+
+func (c *safeBrowsingChecker) Source() string { return ProviderSafeBrowsing }
+
+// CheckURLs looks the URLs up in batches and returns one match per listed URL
+// and threat type. Threat types are lower-cased, e.g. "social_engineering".
+func (c *safeBrowsingChecker) CheckURLs(ctx context.Context, urls []string) ([]analyzer.ThreatMatch, error) {
+	var matches []analyzer.ThreatMatch
+	for start := 0; start < len(urls); start += safeBrowsingBatchSize {
+		batch, err := c.lookup(ctx, urls[start:min(start+safeBrowsingBatchSize, len(urls))])
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, batch...)
+	}
+	return matches, nil
+}
+
+// lookup sends one threatMatches:find request.
+func (c *safeBrowsingChecker) lookup(ctx context.Context, urls []string) ([]analyzer.ThreatMatch, error) {
+	var req safeBrowsingRequest
+	req.Client.ClientID = "webpage-analyzer"
+	req.Client.ClientVersion = "1.0"
+	req.ThreatInfo.ThreatTypes = safeBrowsingThreatTypes
+	req.ThreatInfo.PlatformTypes = []string{"ANY_PLATFORM"}
+	req.ThreatInfo.ThreatEntryTypes = []string{"URL"}
+	for _, u := range urls {
+		req.ThreatInfo.ThreatEntries = append(req.ThreatInfo.ThreatEntries, safeBrowsingEntry{URL: u})
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Safe Browsing request: %v", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"?key="+url.QueryEscape(c.apiKey), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Safe Browsing request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Safe Browsing: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Safe Browsing returned HTTP %d", resp.StatusCode)
+	}
+
+	var result safeBrowsingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode Safe Browsing response: %v", err)
+	}
+
+	seen := make(map[analyzer.ThreatMatch]bool)
+	var matches []analyzer.ThreatMatch
+	for _, m := range result.Matches {
+		match := analyzer.ThreatMatch{URL: m.Threat.URL, ThreatType: strings.ToLower(m.ThreatType)}
+		if !seen[match] {
+			seen[match] = true
+			matches = append(matches, match)
+		}
+	}
+	return matches, nil
+}
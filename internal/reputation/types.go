@@ -0,0 +1,72 @@
+// Package reputation checks URLs against threat intelligence feeds such as
+// Google Safe Browsing and URLhaus, domain blocklists and DNSBLs.
+package reputation
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+// Supported providers.
+const (
+	ProviderSafeBrowsing = "safebrowsing"
+	ProviderURLHaus      = "urlhaus"
+	ProviderBlocklist    = "blocklist" // A local file of domains.
+	ProviderDNSBL        = "dnsbl"     // A domain-based DNS blocklist, such as Spamhaus DBL.
+)
+
+// DefaultTimeout bounds each request to a provider.
+const DefaultTimeout = 10 * time.Second
+
+// Config selects and authenticates a reputation provider.
+type Config struct {
+	Provider string // ProviderSafeBrowsing, ProviderURLHaus, ProviderBlocklist or ProviderDNSBL.
+	APIKey   string // Safe Browsing API key or URLhaus Auth-Key.
+	Endpoint string // Overrides the provider's API URL; empty uses the public one.
+	Path     string // Domain list file of ProviderBlocklist.
+	Zone     string // DNS zone of ProviderDNSBL, e.g. dbl.spamhaus.org.
+	Timeout  time.Duration
+}
+
+// New creates a checker for the configured provider.
+func New(cfg Config) (analyzer.ReputationChecker, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	switch cfg.Provider {
+	case ProviderBlocklist:
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("reputation provider %q needs a domain list file", cfg.Provider)
+		}
+		return OpenBlocklist(cfg.Path)
+	case ProviderDNSBL:
+		if cfg.Zone == "" {
+			return nil, fmt.Errorf("reputation provider %q needs a DNS zone", cfg.Provider)
+		}
+		return newDNSBLChecker(cfg.Zone, cfg.Timeout), nil
+	}
+
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("reputation provider %q needs an API key", cfg.Provider)
+	}
+	client := &http.Client{Timeout: cfg.Timeout}
+	switch cfg.Provider {
+	case ProviderSafeBrowsing:
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = safeBrowsingEndpoint
+		}
+		return &safeBrowsingChecker{client: client, endpoint: endpoint, apiKey: cfg.APIKey}, nil
+	case ProviderURLHaus:
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = urlHausEndpoint
+		}
+		return &urlHausChecker{client: client, endpoint: endpoint, authKey: cfg.APIKey}, nil
+	default:
+		return nil, fmt.Errorf("unsupported reputation provider %q", cfg.Provider)
+	}
+}
@@ -0,0 +1,80 @@
+package reputation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+const urlHausEndpoint = "https://urlhaus-api.abuse.ch/v1/url/"
+
+// urlHausChecker uses the abuse.ch URLhaus URL lookup API.
+type urlHausChecker struct {
+	client   *http.Client
+	endpoint string
+	authKey  string
+}
+
+type urlHausResponse struct {
+	QueryStatus string `json:"query_status"`
+	Threat      string `json:"threat"`
+}
+
+func (c *urlHausChecker) Source() string { return ProviderURLHaus }
+
+// CheckURLs looks the URLs up one at a time, as URLhaus has no batch lookup.
+// Listed URLs are reported with URLhaus's threat, e.g. "malware_download".
+func (c *urlHausChecker) CheckURLs(ctx context.Context, urls []string) ([]analyzer.ThreatMatch, error) {
+	var matches []analyzer.ThreatMatch
+	for _, u := range urls {
+		threat, err := c.lookup(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		if threat != "" {
+			matches = append(matches, analyzer.ThreatMatch{URL: u, ThreatType: threat})
+		}
+	}
+	return matches, nil
+}
+
+// lookup returns the URL's threat, or "" if URLhaus does not list it.
+func (c *urlHausChecker) lookup(ctx context.Context, u string) (string, error) {
+	form := url.Values{"url": {u}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create URLhaus request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Auth-Key", c.authKey)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to query URLhaus: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("URLhaus returned HTTP %d", resp.StatusCode)
+	}
+
+	var result urlHausResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode URLhaus response: %v", err)
+	}
+	switch result.QueryStatus {
+	case "ok":
+		if result.Threat == "" {
+			return "malicious", nil
+		}
+		return result.Threat, nil
+	case "no_results":
+		return "", nil
+	default:
+		return "", fmt.Errorf("URLhaus lookup failed: %s", result.QueryStatus)
+	}
+}
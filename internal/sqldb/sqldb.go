@@ -0,0 +1,103 @@
+// Package sqldb opens the SQLite and Postgres databases that the history
+// store, the job queue and the API key store keep their tables in.
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	_ "github.com/lib/pq"  // Registers the "postgres" driver.
+	_ "modernc.org/sqlite" // Registers the "sqlite" driver.
+)
+
+// Supported drivers. DriverMemory is an in-process SQLite database whose
+// contents are lost on restart.
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMemory   = "memory"
+)
+
+// Column is a column added to a table after the table was first released.
+// Open adds it to databases created before, whose tables lack it.
+type Column struct {
+	Table      string
+	Name       string
+	Definition string // Such as "TEXT NOT NULL DEFAULT ''"; existing rows take its default.
+}
+
+// Open connects to dsn with driver, runs the schema statements, which must be
+// valid for both SQLite and Postgres, adds the columns the tables lack, and
+// returns the database with the driver it was opened with: DriverMemory opens
+// as DriverSQLite. name, such as "job queue", says what the database holds in
+// errors.
+func Open(ctx context.Context, driver, dsn, name string, schema []string, columns ...Column) (*sql.DB, string, error) {
+	requested := driver
+	switch driver {
+	case DriverSQLite, DriverPostgres:
+	case DriverMemory:
+		driver, dsn = DriverSQLite, ":memory:"
+	default:
+		return nil, "", fmt.Errorf("unsupported %s driver %q", name, requested)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open %s %s: %v", driver, name, err)
+	}
+	if driver == DriverSQLite {
+		// SQLite allows a single writer; serializing through one connection
+		// also keeps ":memory:" databases consistent across queries.
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, "", fmt.Errorf("failed to connect to %s %s: %v", driver, name, err)
+	}
+	for _, stmt := range schema {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			db.Close()
+			return nil, "", fmt.Errorf("failed to initialize %s %s schema: %v", driver, name, err)
+		}
+	}
+	for _, column := range columns {
+		if err := addColumn(ctx, db, column); err != nil {
+			db.Close()
+			return nil, "", fmt.Errorf("failed to add column %s.%s to %s %s: %v", column.Table, column.Name, driver, name, err)
+		}
+	}
+	return db, driver, nil
+}
+
+// addColumn adds column to its table unless the table already has it.
+func addColumn(ctx context.Context, db *sql.DB, column Column) error {
+	rows, err := db.QueryContext(ctx, `SELECT `+column.Name+` FROM `+column.Table+` WHERE 1 = 0`)
+	if err == nil {
+		return rows.Close()
+	}
+	_, err = db.ExecContext(ctx, `ALTER TABLE `+column.Table+` ADD COLUMN `+column.Name+` `+column.Definition)
+	return err
+}
+
+// Rebind rewrites '?' placeholders in query to driver's native syntax.
+func Rebind(driver, query string) string {
+	if driver != DriverPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package sqldb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+	db, driver, err := Open(context.Background(), DriverMemory, "", "test store", []string{`CREATE TABLE t (id TEXT PRIMARY KEY)`})
+	require.NoError(t, err)
+	defer db.Close()
+	assert.Equal(t, DriverSQLite, driver, "The memory driver should open as SQLite")
+	_, err = db.Exec(`INSERT INTO t (id) VALUES ('a')`)
+	assert.NoError(t, err, "The schema should have been created")
+
+	_, _, err = Open(context.Background(), "mysql", "", "test store", nil)
+	assert.EqualError(t, err, `unsupported test store driver "mysql"`)
+
+	_, _, err = Open(context.Background(), DriverMemory, "", "test store", []string{`NOT SQL`})
+	assert.ErrorContains(t, err, "failed to initialize sqlite test store schema")
+}
+
+func TestOpen_AddsColumns(t *testing.T) {
+	ctx := context.Background()
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	db, _, err := Open(ctx, DriverSQLite, dsn, "test store", []string{`CREATE TABLE t (id TEXT PRIMARY KEY)`})
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO t (id) VALUES ('a')`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	tenant := Column{Table: "t", Name: "tenant", Definition: "TEXT NOT NULL DEFAULT ''"}
+	for range 2 { // Reopening a database that has the column leaves it be.
+		db, _, err = Open(ctx, DriverSQLite, dsn, "test store", []string{`CREATE TABLE IF NOT EXISTS t (id TEXT PRIMARY KEY)`}, tenant)
+		require.NoError(t, err)
+		var got string
+		require.NoError(t, db.QueryRow(`SELECT tenant FROM t WHERE id = 'a'`).Scan(&got))
+		assert.Equal(t, "", got, "Existing rows should take the column's default")
+		require.NoError(t, db.Close())
+	}
+}
+
+func TestRebind(t *testing.T) {
+	assert.Equal(t, "SELECT * FROM t WHERE a = $1 AND b = $2", Rebind(DriverPostgres, "SELECT * FROM t WHERE a = ? AND b = ?"))
+	assert.Equal(t, "SELECT ?", Rebind(DriverSQLite, "SELECT ?"))
+}
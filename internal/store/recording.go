@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/logging"
+)
+
+// recordingService decorates an analyzer.Service and persists every analysis.
+type recordingService struct {
+	analyzer.Service
+	store Store
+}
+
+// NewRecordingService wraps an analyzer service so that every successful analysis is saved.
+// Re-analyses of a URL are annotated with the changes since its previous snapshot.
+// A persistence failure is logged but never fails the analysis itself.
+func NewRecordingService(inner analyzer.Service, st Store) analyzer.Service {
+	return &recordingService{
+		Service: inner,
+		store:   st,
+	}
+}
+
+// AnalyzeWebpage analyzes a webpage and stores the result unless the request
+// restricts the analysis to a subset of modules.
+func (s *recordingService) AnalyzeWebpage(ctx context.Context, req analyzer.AnalysisRequest) (*analyzer.WebpageAnalysis, error) {
+	analysis, err := s.Service.AnalyzeWebpage(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Modules) > 0 {
+		// Partial analyses are not snapshots: diffing them against a full one
+		// would report every skipped field as changed.
+		return analysis, nil
+	}
+	s.attachChanges(ctx, analysis)
+	return s.save(ctx, analysis), nil
+}
+
+// attachChanges diffs the analysis against the latest stored snapshot of the same URL.
+func (s *recordingService) attachChanges(ctx context.Context, analysis *analyzer.WebpageAnalysis) {
+	page, err := s.store.List(ctx, Query{URL: analysis.URL, Limit: 1})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to load previous snapshot", "url", analysis.URL, "error", err)
+		return
+	}
+	if len(page.Records) == 0 {
+		return
+	}
+
+	previous := page.Records[0]
+	analysis.Changes = &analyzer.SnapshotChanges{
+		PreviousID:         previous.ID,
+		PreviousAnalyzedAt: previous.Analysis.AnalyzedAt,
+		Differences:        analyzer.DiffAnalyses(previous.Analysis, analysis),
+	}
+}
+
+// CompareWebpages compares two webpages and stores both analyses.
+func (s *recordingService) CompareWebpages(ctx context.Context, req analyzer.CompareRequest) (*analyzer.WebpageComparison, error) {
+	comparison, err := s.Service.CompareWebpages(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	comparison.A = s.save(ctx, comparison.A)
+	comparison.B = s.save(ctx, comparison.B)
+	return comparison, nil
+}
+
+// save persists an analysis and returns the stored copy, which carries its
+// ID. Failures are logged rather than propagated, and leave the analysis
+// without an ID.
+func (s *recordingService) save(ctx context.Context, analysis *analyzer.WebpageAnalysis) *analyzer.WebpageAnalysis {
+	rec, err := s.store.Save(ctx, analysis)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to persist analysis", "url", analysis.URL, "error", err)
+		return analysis
+	}
+	logging.FromContext(ctx).Info("Analysis persisted", "url", rec.URL, "id", rec.ID)
+	return rec.Analysis
+}
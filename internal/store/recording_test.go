@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+// stubService returns a fixed analysis for any URL, with a configurable title.
+type stubService struct {
+	analyzer.Service
+	title string
+}
+
+func (s *stubService) AnalyzeWebpage(ctx context.Context, req analyzer.AnalysisRequest) (*analyzer.WebpageAnalysis, error) {
+	title := s.title
+	if title == "" {
+		title = "Stub"
+	}
+	return &analyzer.WebpageAnalysis{URL: req.URL, PageTitle: title}, nil
+}
+
+func TestRecordingService_PersistsAnalysis(t *testing.T) {
+	st := newTestStore(t)
+	svc := NewRecordingService(&stubService{}, st)
+
+	analysis, err := svc.AnalyzeWebpage(context.Background(), analyzer.AnalysisRequest{URL: "https://example.com"})
+	require.NoError(t, err)
+	require.NotEmpty(t, analysis.ID, "Recorded analyses should carry their store ID")
+
+	rec, err := st.Get(context.Background(), analysis.ID)
+	require.NoError(t, err, "Analysis should be retrievable from the store")
+	assert.Equal(t, "Stub", rec.Analysis.PageTitle)
+}
+
+func TestRecordingService_AttachesChangesOnReanalysis(t *testing.T) {
+	st := newTestStore(t)
+	stub := &stubService{title: "Before"}
+	svc := NewRecordingService(stub, st)
+	ctx := context.Background()
+	req := analyzer.AnalysisRequest{URL: "https://example.com"}
+
+	first, err := svc.AnalyzeWebpage(ctx, req)
+	require.NoError(t, err)
+	assert.Nil(t, first.Changes, "The first analysis of a URL has nothing to diff against")
+
+	stub.title = "After"
+	second, err := svc.AnalyzeWebpage(ctx, req)
+	require.NoError(t, err)
+	require.NotNil(t, second.Changes, "Re-analysis should include changes")
+	assert.Equal(t, first.ID, second.Changes.PreviousID, "Changes should reference the previous snapshot")
+	assert.True(t, second.Changes.Differences.TitleChanged, "Title change should be detected")
+}
+
+func TestRecordingService_AttachesChangesAcrossURLSpellings(t *testing.T) {
+	st := newTestStore(t)
+	svc := NewRecordingService(&stubService{}, st)
+	ctx := context.Background()
+
+	first, err := svc.AnalyzeWebpage(ctx, analyzer.AnalysisRequest{URL: "https://example.com"})
+	require.NoError(t, err)
+
+	second, err := svc.AnalyzeWebpage(ctx, analyzer.AnalysisRequest{URL: "HTTPS://example.com:443/#main"})
+	require.NoError(t, err)
+	require.NotNil(t, second.Changes, "Another spelling of the URL should be diffed against its history")
+	assert.Equal(t, first.ID, second.Changes.PreviousID)
+}
+
+func TestRecordingService_SkipsModuleRestrictedAnalyses(t *testing.T) {
+	st := newTestStore(t)
+	svc := NewRecordingService(&stubService{}, st)
+
+	analysis, err := svc.AnalyzeWebpage(context.Background(), analyzer.AnalysisRequest{
+		URL:     "https://example.com",
+		Modules: []string{analyzer.ModuleHeadings},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, analysis.ID, "Partial analyses should not be stored")
+
+	page, err := st.List(context.Background(), Query{URL: "https://example.com"})
+	require.NoError(t, err)
+	assert.Empty(t, page.Records)
+}
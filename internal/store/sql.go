@@ -0,0 +1,620 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/apikeys"
+	"webpage-analyzer/internal/sqldb"
+	"webpage-analyzer/internal/urlnorm"
+)
+
+// schema creates the analyses table. It is valid for both SQLite and Postgres.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS analyses (
+		id         TEXT PRIMARY KEY,
+		url        TEXT NOT NULL,
+		created_at BIGINT NOT NULL,
+		result     TEXT NOT NULL,
+		tenant     TEXT NOT NULL DEFAULT '',
+		url_key    TEXT NOT NULL DEFAULT '',
+		simhash    TEXT,
+		simhash_band0 INTEGER NOT NULL DEFAULT 0,
+		simhash_band1 INTEGER NOT NULL DEFAULT 0,
+		simhash_band2 INTEGER NOT NULL DEFAULT 0,
+		simhash_band3 INTEGER NOT NULL DEFAULT 0
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_analyses_url_created ON analyses (url, created_at)`,
+	`CREATE TABLE IF NOT EXISTS analysis_events (
+		created_at  BIGINT NOT NULL,
+		url         TEXT NOT NULL,
+		domain      TEXT NOT NULL,
+		duration_ns BIGINT NOT NULL,
+		error_code  TEXT NOT NULL DEFAULT '',
+		cache_hit   INTEGER NOT NULL DEFAULT 0,
+		tenant      TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_analysis_events_created ON analysis_events (created_at)`,
+}
+
+// addedColumns are added to stores created before the tables had them: the
+// tenants of analyses and events, the normalized URL analyses are looked up
+// by, and the simhash of analyses with its bands.
+var addedColumns = []sqldb.Column{
+	{Table: "analyses", Name: "tenant", Definition: "TEXT NOT NULL DEFAULT ''"},
+	{Table: "analysis_events", Name: "tenant", Definition: "TEXT NOT NULL DEFAULT ''"},
+	{Table: "analyses", Name: "url_key", Definition: "TEXT NOT NULL DEFAULT ''"},
+	{Table: "analyses", Name: "simhash", Definition: "TEXT"}, // NULL until filled in from the result.
+	{Table: "analyses", Name: "simhash_band0", Definition: "INTEGER NOT NULL DEFAULT 0"},
+	{Table: "analyses", Name: "simhash_band1", Definition: "INTEGER NOT NULL DEFAULT 0"},
+	{Table: "analyses", Name: "simhash_band2", Definition: "INTEGER NOT NULL DEFAULT 0"},
+	{Table: "analyses", Name: "simhash_band3", Definition: "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// indexes cover addedColumns, so they are created once those exist.
+var indexes = []string{
+	`CREATE INDEX IF NOT EXISTS idx_analyses_url_key_created ON analyses (url_key, created_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_analyses_simhash_band0 ON analyses (simhash_band0)`,
+	`CREATE INDEX IF NOT EXISTS idx_analyses_simhash_band1 ON analyses (simhash_band1)`,
+	`CREATE INDEX IF NOT EXISTS idx_analyses_simhash_band2 ON analyses (simhash_band2)`,
+	`CREATE INDEX IF NOT EXISTS idx_analyses_simhash_band3 ON analyses (simhash_band3)`,
+}
+
+// simhashBands is the number of 16-bit bands simhashes are split into. Two
+// simhashes less than simhashBands bits apart have at least one band in
+// common, which lets Similar find them through the band indexes.
+const simhashBands = 4
+
+// sqlStore implements the Store interface on top of database/sql.
+type sqlStore struct {
+	db     *sql.DB
+	driver string
+}
+
+// Open connects to the configured database and ensures the schema exists.
+func Open(ctx context.Context, cfg Config) (Store, error) {
+	if cfg.Driver == sqldb.DriverMemory {
+		return nil, fmt.Errorf("unsupported store driver %q", cfg.Driver)
+	}
+	db, driver, err := sqldb.Open(ctx, cfg.Driver, cfg.DSN, "store", schema, addedColumns...)
+	if err != nil {
+		return nil, err
+	}
+	s := &sqlStore{db: db, driver: driver}
+	if err := s.migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// migrate creates the indexes and fills in the URL keys and simhashes of
+// analyses stored before they had them.
+func (s *sqlStore) migrate(ctx context.Context) error {
+	for _, stmt := range indexes {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create store index: %v", err)
+		}
+	}
+	if err := s.migrateURLKeys(ctx); err != nil {
+		return fmt.Errorf("failed to migrate store: %v", err)
+	}
+	if err := s.migrateSimHashes(ctx); err != nil {
+		return fmt.Errorf("failed to migrate store: %v", err)
+	}
+	return nil
+}
+
+// migrateURLKeys sets the URL key of analyses that have none.
+func (s *sqlStore) migrateURLKeys(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, url FROM analyses WHERE url_key = ''`)
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]string)
+	for rows.Next() {
+		var id, rawURL string
+		if err := rows.Scan(&id, &rawURL); err != nil {
+			rows.Close()
+			return err
+		}
+		keys[id] = urlKey(rawURL)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for id, key := range keys {
+		if _, err := s.db.ExecContext(ctx, s.rebind(`UPDATE analyses SET url_key = ? WHERE id = ?`), key, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateSimHashes copies the simhash of analyses stored before it had its
+// own columns out of their results.
+func (s *sqlStore) migrateSimHashes(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, result FROM analyses WHERE simhash IS NULL`)
+	if err != nil {
+		return err
+	}
+	hashes := make(map[string]string)
+	for rows.Next() {
+		var id, result string
+		if err := rows.Scan(&id, &result); err != nil {
+			rows.Close()
+			return err
+		}
+		var analysis struct {
+			SimHash string `json:"simhash"`
+		}
+		_ = json.Unmarshal([]byte(result), &analysis) // Undecodable results have no simhash.
+		hashes[id] = analysis.SimHash
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for id, simhash := range hashes {
+		hash, bands := simhashColumns(simhash)
+		_, err := s.db.ExecContext(ctx,
+			s.rebind(`UPDATE analyses SET simhash = ?, simhash_band0 = ?, simhash_band1 = ?, simhash_band2 = ?, simhash_band3 = ? WHERE id = ?`),
+			hash, bands[0], bands[1], bands[2], bands[3], id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// simhashColumns returns the simhash column of an analysis with the given
+// simhash, "" when it has none or it does not parse, and its bands.
+func simhashColumns(simhash string) (string, [simhashBands]int64) {
+	var bands [simhashBands]int64
+	hash, err := analyzer.ParseSimHash(simhash)
+	if err != nil {
+		return "", bands
+	}
+	for i := range bands {
+		bands[i] = int64(hash >> (16 * i) & 0xffff)
+	}
+	return analyzer.FormatSimHash(hash), bands
+}
+
+// urlKey returns the key analyses of rawURL are looked up by, so that every
+// spelling of a page shares one history.
+func urlKey(rawURL string) string {
+	return urlnorm.Normalize(rawURL, urlnorm.Options{})
+}
+
+// Save persists an analysis and assigns it an ID, under the tenant of ctx's
+// API key.
+func (s *sqlStore) Save(ctx context.Context, analysis *analyzer.WebpageAnalysis) (*Record, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate record ID: %v", err)
+	}
+	// Store a copy: the caller's analysis may already be shared, e.g. with
+	// the result cache, and must not change under its readers.
+	stored := *analysis
+	stored.ID = id
+
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode analysis: %v", err)
+	}
+
+	createdAt := time.Now().UTC()
+	simhash, bands := simhashColumns(analysis.SimHash)
+	_, err = s.db.ExecContext(ctx,
+		s.rebind(`INSERT INTO analyses (id, url, url_key, created_at, result, tenant, simhash, simhash_band0, simhash_band1, simhash_band2, simhash_band3)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		id, analysis.URL, urlKey(analysis.URL), createdAt.UnixNano(), string(data), apikeys.TenantFromContext(ctx),
+		simhash, bands[0], bands[1], bands[2], bands[3],
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save analysis: %v", err)
+	}
+
+	return &Record{ID: id, URL: analysis.URL, CreatedAt: createdAt, Analysis: &stored}, nil
+}
+
+// Get loads a single analysis by ID.
+func (s *sqlStore) Get(ctx context.Context, id string) (*Record, error) {
+	scope, args := tenantScope(ctx)
+	row := s.db.QueryRowContext(ctx,
+		s.rebind(`SELECT id, url, created_at, result FROM analyses WHERE id = ?`+scope), append([]interface{}{id}, args...)...)
+
+	rec, err := scanRecord(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return rec, err
+}
+
+// List returns stored analyses matching the query, newest first.
+func (s *sqlStore) List(ctx context.Context, q Query) (*Page, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if tenant := apikeys.TenantFromContext(ctx); tenant != "" {
+		conditions = append(conditions, "tenant = ?")
+		args = append(args, tenant)
+	}
+	if q.URL != "" {
+		conditions = append(conditions, "url_key = ?")
+		args = append(args, urlKey(q.URL))
+	}
+	if !q.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, q.From.UnixNano())
+	}
+	if !q.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, q.To.UnixNano())
+	}
+	if q.Cursor != "" {
+		createdAt, id, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, "(created_at < ? OR (created_at = ? AND id < ?))")
+		args = append(args, createdAt, createdAt, id)
+	}
+
+	query := `SELECT id, url, created_at, result FROM analyses`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	// Fetch one extra row to learn whether another page exists.
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	args = append(args, limit+1)
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list analyses: %v", err)
+	}
+	defer rows.Close()
+
+	page := &Page{Records: make([]*Record, 0, limit)}
+	for rows.Next() {
+		rec, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		page.Records = append(page.Records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list analyses: %v", err)
+	}
+
+	if len(page.Records) > limit {
+		page.Records = page.Records[:limit]
+		last := page.Records[limit-1]
+		page.NextCursor = encodeCursor(last.CreatedAt.UnixNano(), last.ID)
+	}
+	return page, nil
+}
+
+// Similar finds stored analyses whose simhash is within q.MaxDistance bits of
+// the given analysis, closest first and newest first among equals. Candidates
+// are picked by their simhash column, through the band indexes for distances
+// below simhashBands, and only the matches returned are decoded.
+func (s *sqlStore) Similar(ctx context.Context, id string, q SimilarQuery) (*SimilarResult, error) {
+	maxDistance := q.MaxDistance
+	if maxDistance <= 0 {
+		maxDistance = DefaultSimilarDistance
+	}
+	if maxDistance > MaxSimilarDistance {
+		maxDistance = MaxSimilarDistance
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	source, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if source.Analysis.SimHash == "" {
+		return nil, ErrNoFingerprint
+	}
+	sourceHash, err := analyzer.ParseSimHash(source.Analysis.SimHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse simhash of analysis %s: %v", id, err)
+	}
+
+	scope, args := tenantScope(ctx)
+	query := `SELECT id, simhash FROM analyses WHERE id <> ? AND simhash <> ''` + scope
+	args = append([]interface{}{id}, args...)
+	if !q.IncludeSameURL {
+		query += " AND url_key <> ?"
+		args = append(args, urlKey(source.URL))
+	}
+	if maxDistance < simhashBands {
+		_, bands := simhashColumns(source.Analysis.SimHash)
+		query += " AND (simhash_band0 = ? OR simhash_band1 = ? OR simhash_band2 = ? OR simhash_band3 = ?)"
+		args = append(args, bands[0], bands[1], bands[2], bands[3])
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search analyses: %v", err)
+	}
+	var candidates []*SimilarRecord
+	for rows.Next() {
+		var candidateID, simhash string
+		if err := rows.Scan(&candidateID, &simhash); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to search analyses: %v", err)
+		}
+		hash, err := analyzer.ParseSimHash(simhash)
+		if err != nil {
+			continue
+		}
+		if d := analyzer.HammingDistance(sourceHash, hash); d <= maxDistance {
+			candidates = append(candidates, &SimilarRecord{Record: &Record{ID: candidateID}, Distance: d})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search analyses: %v", err)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Distance < candidates[j].Distance
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	result := &SimilarResult{ID: id, SimHash: source.Analysis.SimHash, Matches: []*SimilarRecord{}}
+	records, err := s.getAll(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	for _, match := range candidates {
+		if rec := records[match.ID]; rec != nil {
+			result.Matches = append(result.Matches, &SimilarRecord{Record: rec, Distance: match.Distance})
+		}
+	}
+	return result, nil
+}
+
+// getAll loads the records of matches by ID.
+func (s *sqlStore) getAll(ctx context.Context, matches []*SimilarRecord) (map[string]*Record, error) {
+	records := make(map[string]*Record, len(matches))
+	if len(matches) == 0 {
+		return records, nil
+	}
+	placeholders := make([]string, len(matches))
+	args := make([]interface{}, len(matches))
+	for i, match := range matches {
+		placeholders[i] = "?"
+		args[i] = match.ID
+	}
+	rows, err := s.db.QueryContext(ctx,
+		s.rebind(`SELECT id, url, created_at, result FROM analyses WHERE id IN (`+strings.Join(placeholders, ", ")+`)`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load similar analyses: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		rec, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records[rec.ID] = rec
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load similar analyses: %v", err)
+	}
+	return records, nil
+}
+
+// RecordEvent stores the outcome of an analysis, under the tenant of ctx's
+// API key.
+func (s *sqlStore) RecordEvent(ctx context.Context, e Event) error {
+	cacheHit := 0
+	if e.CacheHit {
+		cacheHit = 1
+	}
+	_, err := s.db.ExecContext(ctx,
+		s.rebind(`INSERT INTO analysis_events (created_at, url, domain, duration_ns, error_code, cache_hit, tenant) VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		e.At.UTC().UnixNano(), e.URL, eventDomain(e.URL), int64(e.Duration), e.ErrorCode, cacheHit, apikeys.TenantFromContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record analysis event: %v", err)
+	}
+	return nil
+}
+
+// Stats summarizes the events from q.From up to q.To, of the tenant of ctx's
+// API key if it carries one.
+func (s *sqlStore) Stats(ctx context.Context, q StatsQuery) (*Stats, error) {
+	top := q.TopDomains
+	if top <= 0 {
+		top = DefaultTopDomains
+	}
+	if top > MaxTopDomains {
+		top = MaxTopDomains
+	}
+	scope, scopeArgs := tenantScope(ctx)
+	window := ` FROM analysis_events WHERE created_at >= ? AND created_at < ?` + scope
+	args := append([]interface{}{q.From.UnixNano(), q.To.UnixNano()}, scopeArgs...)
+
+	stats := &Stats{From: q.From.UTC(), To: q.To.UTC(), Errors: map[string]int64{}, TopDomains: []DomainCount{}}
+	var freshTime, fresh int64
+	row := s.db.QueryRowContext(ctx, s.rebind(`SELECT
+		COUNT(*),
+		COALESCE(SUM(CASE WHEN error_code = '' THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(cache_hit), 0),
+		COALESCE(SUM(CASE WHEN error_code = '' AND cache_hit = 0 THEN duration_ns ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN error_code = '' AND cache_hit = 0 THEN 1 ELSE 0 END), 0)`+window), args...)
+	if err := row.Scan(&stats.TotalAnalyses, &stats.Succeeded, &stats.CacheHits, &freshTime, &fresh); err != nil {
+		return nil, fmt.Errorf("failed to summarize analyses: %v", err)
+	}
+	stats.Failed = stats.TotalAnalyses - stats.Succeeded
+	if stats.TotalAnalyses > 0 {
+		total := float64(stats.TotalAnalyses)
+		stats.SuccessRatio = float64(stats.Succeeded) / total
+		stats.ErrorRatio = float64(stats.Failed) / total
+		stats.CacheHitRate = float64(stats.CacheHits) / total
+	}
+	if fresh > 0 {
+		stats.AvgProcessingTime = time.Duration(freshTime / fresh).Round(time.Millisecond).String()
+	} else {
+		stats.AvgProcessingTime = "0s"
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT error_code, COUNT(*)`+window+` AND error_code <> '' GROUP BY error_code`), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count analysis errors: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			code  string
+			count int64
+		)
+		if err := rows.Scan(&code, &count); err != nil {
+			return nil, fmt.Errorf("failed to count analysis errors: %v", err)
+		}
+		stats.Errors[code] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count analysis errors: %v", err)
+	}
+
+	rows, err = s.db.QueryContext(ctx,
+		s.rebind(`SELECT domain, COUNT(*) AS analyses`+window+` AND domain <> '' GROUP BY domain ORDER BY analyses DESC, domain LIMIT ?`),
+		append(args, top)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank analyzed domains: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var d DomainCount
+		if err := rows.Scan(&d.Domain, &d.Analyses); err != nil {
+			return nil, fmt.Errorf("failed to rank analyzed domains: %v", err)
+		}
+		stats.TopDomains = append(stats.TopDomains, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to rank analyzed domains: %v", err)
+	}
+	return stats, nil
+}
+
+// eventDomain returns the host an event is counted under, lower-cased and
+// without a leading "www.", or "" when the URL has none.
+func eventDomain(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// Close releases the database connection.
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanRecord decodes a single analyses row.
+func scanRecord(row rowScanner) (*Record, error) {
+	var (
+		rec       Record
+		createdAt int64
+		result    string
+	)
+	if err := row.Scan(&rec.ID, &rec.URL, &createdAt, &result); err != nil {
+		return nil, err
+	}
+
+	rec.CreatedAt = time.Unix(0, createdAt).UTC()
+	rec.Analysis = &analyzer.WebpageAnalysis{}
+	if err := json.Unmarshal([]byte(result), rec.Analysis); err != nil {
+		return nil, fmt.Errorf("failed to decode stored analysis %s: %v", rec.ID, err)
+	}
+	return &rec, nil
+}
+
+// tenantScope returns the condition, to append to a WHERE clause, and its
+// argument that keep a query to the analyses of the tenant of ctx's API key.
+// Without a key, as when API keys are off, every analysis is in scope.
+func tenantScope(ctx context.Context) (string, []interface{}) {
+	tenant := apikeys.TenantFromContext(ctx)
+	if tenant == "" {
+		return "", nil
+	}
+	return " AND tenant = ?", []interface{}{tenant}
+}
+
+// rebind rewrites '?' placeholders to the driver's native syntax.
+func (s *sqlStore) rebind(query string) string {
+	return sqldb.Rebind(s.driver, query)
+}
+
+// encodeCursor builds an opaque cursor pointing just past the given record.
+func encodeCursor(createdAt int64, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(createdAt, 10) + ":" + id))
+}
+
+// decodeCursor parses a cursor produced by encodeCursor.
+func decodeCursor(cursor string) (int64, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", ErrInvalidCursor
+	}
+	tsStr, id, found := strings.Cut(string(raw), ":")
+	if !found || id == "" {
+		return 0, "", ErrInvalidCursor
+	}
+	createdAt, err := strconv.ParseInt(tsStr, 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidCursor
+	}
+	return createdAt, id, nil
+}
+
+// newID generates a random hex record ID.
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,362 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/apikeys"
+)
+
+// newTestStore opens an in-memory SQLite store.
+func newTestStore(t *testing.T) Store {
+	t.Helper()
+	st, err := Open(context.Background(), Config{Driver: DriverSQLite, DSN: ":memory:"})
+	require.NoError(t, err, "Open() should succeed for in-memory SQLite")
+	t.Cleanup(func() { st.Close() })
+	return st
+}
+
+func TestOpen_UnsupportedDriver(t *testing.T) {
+	_, err := Open(context.Background(), Config{Driver: "mysql"})
+	assert.Error(t, err, "Open() should reject unknown drivers")
+}
+
+func TestSaveAndGet(t *testing.T) {
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	analysis := &analyzer.WebpageAnalysis{
+		URL:           "https://example.com",
+		PageTitle:     "Example",
+		Headings:      map[string]int{"h1": 1},
+		InternalLinks: 3,
+	}
+
+	rec, err := st.Save(ctx, analysis)
+	require.NoError(t, err, "Save() should not return error")
+	assert.NotEmpty(t, rec.ID, "Save() should assign an ID")
+	assert.Equal(t, rec.ID, rec.Analysis.ID, "Save() should set the ID on the stored analysis")
+	assert.Empty(t, analysis.ID, "Save() should not modify the caller's analysis, which may be shared")
+
+	got, err := st.Get(ctx, rec.ID)
+	require.NoError(t, err, "Get() should find the saved record")
+	assert.Equal(t, "https://example.com", got.URL)
+	assert.Equal(t, "Example", got.Analysis.PageTitle)
+	assert.Equal(t, 3, got.Analysis.InternalLinks)
+	assert.Equal(t, rec.CreatedAt.UnixNano(), got.CreatedAt.UnixNano(), "Timestamps should round-trip")
+}
+
+func TestGet_NotFound(t *testing.T) {
+	st := newTestStore(t)
+
+	_, err := st.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestRebind(t *testing.T) {
+	pg := &sqlStore{driver: DriverPostgres}
+	assert.Equal(t, "SELECT * FROM t WHERE a = $1 AND b = $2", pg.rebind("SELECT * FROM t WHERE a = ? AND b = ?"))
+
+	lite := &sqlStore{driver: DriverSQLite}
+	assert.Equal(t, "SELECT ?", lite.rebind("SELECT ?"))
+}
+
+func TestList_FiltersAndPaginates(t *testing.T) {
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		_, err := st.Save(ctx, &analyzer.WebpageAnalysis{URL: "https://a.example.com"})
+		require.NoError(t, err)
+	}
+	_, err := st.Save(ctx, &analyzer.WebpageAnalysis{URL: "https://b.example.com"})
+	require.NoError(t, err)
+
+	first, err := st.List(ctx, Query{URL: "https://a.example.com", Limit: 3})
+	require.NoError(t, err, "List() should not return error")
+	assert.Len(t, first.Records, 3, "First page should be full")
+	assert.NotEmpty(t, first.NextCursor, "A next cursor should be returned when more records exist")
+
+	second, err := st.List(ctx, Query{URL: "https://a.example.com", Limit: 3, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	assert.Len(t, second.Records, 2, "Second page should hold the remainder")
+	assert.Empty(t, second.NextCursor, "No cursor expected on the last page")
+
+	seen := make(map[string]bool)
+	for _, rec := range append(first.Records, second.Records...) {
+		assert.Equal(t, "https://a.example.com", rec.URL, "URL filter should be applied")
+		assert.False(t, seen[rec.ID], "Pages should not overlap")
+		seen[rec.ID] = true
+	}
+	assert.True(t, first.Records[0].CreatedAt.After(first.Records[2].CreatedAt) ||
+		first.Records[0].CreatedAt.Equal(first.Records[2].CreatedAt), "Records should be newest first")
+}
+
+func TestList_NormalizesURL(t *testing.T) {
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	_, err := st.Save(ctx, &analyzer.WebpageAnalysis{URL: "https://Example.com:443/#top"})
+	require.NoError(t, err)
+	_, err = st.Save(ctx, &analyzer.WebpageAnalysis{URL: "https://example.com/docs"})
+	require.NoError(t, err)
+
+	page, err := st.List(ctx, Query{URL: "https://example.com"})
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1, "Spellings of the same URL should match")
+	assert.Equal(t, "https://Example.com:443/#top", page.Records[0].URL, "Records should keep the URL as analyzed")
+}
+
+func TestOpen_MigratesURLKeys(t *testing.T) {
+	ctx := context.Background()
+	dsn := filepath.Join(t.TempDir(), "history.db")
+	db, err := sql.Open(DriverSQLite, dsn)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE analyses (id TEXT PRIMARY KEY, url TEXT NOT NULL, created_at BIGINT NOT NULL, result TEXT NOT NULL)`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO analyses (id, url, created_at, result) VALUES ('old', 'https://EXAMPLE.com', 1, '{}')`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	st, err := Open(ctx, Config{Driver: DriverSQLite, DSN: dsn})
+	require.NoError(t, err, "Open() should migrate a store without URL keys")
+	t.Cleanup(func() { st.Close() })
+
+	page, err := st.List(ctx, Query{URL: "https://example.com/"})
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1, "Analyses stored before URL keys should be found by URL")
+	assert.Equal(t, "old", page.Records[0].ID)
+}
+
+func TestList_TimeRange(t *testing.T) {
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	rec, err := st.Save(ctx, &analyzer.WebpageAnalysis{URL: "https://example.com"})
+	require.NoError(t, err)
+
+	page, err := st.List(ctx, Query{To: rec.CreatedAt})
+	require.NoError(t, err)
+	assert.Empty(t, page.Records, "Upper bound should be exclusive")
+
+	page, err = st.List(ctx, Query{From: rec.CreatedAt})
+	require.NoError(t, err)
+	assert.Len(t, page.Records, 1, "Lower bound should be inclusive")
+}
+
+func TestList_InvalidCursor(t *testing.T) {
+	st := newTestStore(t)
+
+	_, err := st.List(context.Background(), Query{Cursor: "!!not-a-cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestTenantScope(t *testing.T) {
+	st := newTestStore(t)
+	acme := apikeys.WithKey(context.Background(), &apikeys.Key{ID: "key-1", Tenant: "acme"})
+	globex := apikeys.WithKey(context.Background(), &apikeys.Key{ID: "key-2", Tenant: "globex"})
+
+	rec, err := st.Save(acme, &analyzer.WebpageAnalysis{URL: "https://example.com", SimHash: "000000000000ff00"})
+	require.NoError(t, err)
+	_, err = st.Save(globex, &analyzer.WebpageAnalysis{URL: "https://copy.example.com", SimHash: "000000000000ff00"})
+	require.NoError(t, err)
+
+	_, err = st.Get(acme, rec.ID)
+	assert.NoError(t, err, "Tenants should find their own analyses")
+	_, err = st.Get(globex, rec.ID)
+	assert.ErrorIs(t, err, ErrNotFound, "Tenants should not find other tenants' analyses")
+
+	page, err := st.List(acme, Query{})
+	require.NoError(t, err)
+	require.Len(t, page.Records, 1)
+	assert.Equal(t, rec.ID, page.Records[0].ID)
+	page, err = st.List(context.Background(), Query{})
+	require.NoError(t, err)
+	assert.Len(t, page.Records, 2, "Without a key every analysis should be listed")
+
+	similar, err := st.Similar(acme, rec.ID, SimilarQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, similar.Matches, "Other tenants' analyses should not match")
+}
+
+func TestSimilar(t *testing.T) {
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	save := func(url string, simhash uint64) *Record {
+		rec, err := st.Save(ctx, &analyzer.WebpageAnalysis{URL: url, SimHash: analyzer.FormatSimHash(simhash)})
+		require.NoError(t, err)
+		return rec
+	}
+	source := save("https://a.example.com", 0xff00)
+	save("https://a.example.com", 0xff00)               // Same URL, skipped by default.
+	copyRec := save("https://copy.example.com", 0xff01) // 1 bit away.
+	exact := save("https://mirror.example.com", 0xff00)
+	save("https://other.example.com", 0x00ff) // 16 bits away.
+	_, err := st.Save(ctx, &analyzer.WebpageAnalysis{URL: "https://old.example.com"})
+	require.NoError(t, err)
+
+	result, err := st.Similar(ctx, source.ID, SimilarQuery{})
+	require.NoError(t, err, "Similar() should not return error")
+	require.Len(t, result.Matches, 2, "Only near-duplicates on other URLs should match")
+	assert.Equal(t, exact.ID, result.Matches[0].ID, "Matches should be ordered by distance")
+	assert.Equal(t, 0, result.Matches[0].Distance)
+	assert.Equal(t, copyRec.ID, result.Matches[1].ID)
+	assert.Equal(t, 1, result.Matches[1].Distance)
+
+	result, err = st.Similar(ctx, source.ID, SimilarQuery{IncludeSameURL: true, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, result.Matches, 3, "IncludeSameURL should also match other snapshots of the URL")
+
+	result, err = st.Similar(ctx, source.ID, SimilarQuery{MaxDistance: MaxSimilarDistance, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, result.Matches, 2, "Limit should be honoured")
+	assert.Equal(t, exact.ID, result.Matches[0].ID)
+	assert.Equal(t, "https://mirror.example.com", result.Matches[0].Analysis.URL, "Matches should be decoded")
+
+	result, err = st.Similar(ctx, source.ID, SimilarQuery{MaxDistance: MaxSimilarDistance})
+	require.NoError(t, err)
+	assert.Len(t, result.Matches, 3, "Distances past the band prefilter should still be searched")
+}
+
+func TestSimilar_BandPrefilter(t *testing.T) {
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	source, err := st.Save(ctx, &analyzer.WebpageAnalysis{URL: "https://a.example.com", SimHash: analyzer.FormatSimHash(0x0001000100010001)})
+	require.NoError(t, err)
+	// Three bits away, one in each of three bands: only the last band is shared.
+	near, err := st.Save(ctx, &analyzer.WebpageAnalysis{URL: "https://b.example.com", SimHash: analyzer.FormatSimHash(0x0001000300030003)})
+	require.NoError(t, err)
+	// Four bits away, one in every band.
+	_, err = st.Save(ctx, &analyzer.WebpageAnalysis{URL: "https://c.example.com", SimHash: analyzer.FormatSimHash(0x0003000300030003)})
+	require.NoError(t, err)
+
+	result, err := st.Similar(ctx, source.ID, SimilarQuery{})
+	require.NoError(t, err)
+	require.Len(t, result.Matches, 1)
+	assert.Equal(t, near.ID, result.Matches[0].ID)
+	assert.Equal(t, 3, result.Matches[0].Distance)
+
+	result, err = st.Similar(ctx, source.ID, SimilarQuery{MaxDistance: 4})
+	require.NoError(t, err)
+	assert.Len(t, result.Matches, 2, "Hashes sharing no band should match past the band prefilter")
+}
+
+func TestOpen_MigratesSimHashes(t *testing.T) {
+	ctx := context.Background()
+	dsn := filepath.Join(t.TempDir(), "history.db")
+	db, err := sql.Open(DriverSQLite, dsn)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE analyses (id TEXT PRIMARY KEY, url TEXT NOT NULL, created_at BIGINT NOT NULL, result TEXT NOT NULL)`)
+	require.NoError(t, err)
+	for _, row := range [][]interface{}{
+		{"a", "https://a.example.com", 1, `{"simhash":"000000000000ff00"}`},
+		{"b", "https://b.example.com", 2, `{"simhash":"000000000000ff01"}`},
+		{"c", "https://c.example.com", 3, `{}`},
+	} {
+		_, err = db.Exec(`INSERT INTO analyses (id, url, created_at, result) VALUES (?, ?, ?, ?)`, row...)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Close())
+
+	st, err := Open(ctx, Config{Driver: DriverSQLite, DSN: dsn})
+	require.NoError(t, err, "Open() should migrate a store without simhash columns")
+	t.Cleanup(func() { st.Close() })
+
+	result, err := st.Similar(ctx, "a", SimilarQuery{})
+	require.NoError(t, err)
+	require.Len(t, result.Matches, 1, "Analyses stored before simhash columns should be searchable")
+	assert.Equal(t, "b", result.Matches[0].ID)
+}
+
+func TestSimilar_Errors(t *testing.T) {
+	st := newTestStore(t)
+	ctx := context.Background()
+
+	_, err := st.Similar(ctx, "missing", SimilarQuery{})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	rec, err := st.Save(ctx, &analyzer.WebpageAnalysis{URL: "https://example.com"})
+	require.NoError(t, err)
+	_, err = st.Similar(ctx, rec.ID, SimilarQuery{})
+	assert.ErrorIs(t, err, ErrNoFingerprint, "Analyses without a simhash cannot be matched")
+}
+
+func TestStats(t *testing.T) {
+	st := newTestStore(t)
+	ctx := context.Background()
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	events := []Event{
+		{URL: "https://www.example.com/a", At: base, Duration: 300 * time.Millisecond},
+		{URL: "https://example.com/b", At: base.Add(time.Minute), Duration: 500 * time.Millisecond},
+		{URL: "https://EXAMPLE.com/c", At: base.Add(2 * time.Minute), Duration: time.Millisecond, CacheHit: true},
+		{URL: "https://other.example.org", At: base.Add(3 * time.Minute), Duration: 2 * time.Second, ErrorCode: "timeout"},
+		{URL: "https://other.example.org", At: base.Add(4 * time.Minute), ErrorCode: "dns_failure"},
+		{URL: "https://old.example.net", At: base.Add(-time.Hour), Duration: time.Second}, // Outside the window.
+	}
+	for _, e := range events {
+		require.NoError(t, st.RecordEvent(ctx, e))
+	}
+
+	stats, err := st.Stats(ctx, StatsQuery{From: base, To: base.Add(time.Hour)})
+	require.NoError(t, err, "Stats() should not return error")
+	assert.Equal(t, int64(5), stats.TotalAnalyses)
+	assert.Equal(t, int64(3), stats.Succeeded)
+	assert.Equal(t, int64(2), stats.Failed)
+	assert.InDelta(t, 0.6, stats.SuccessRatio, 1e-9)
+	assert.InDelta(t, 0.4, stats.ErrorRatio, 1e-9)
+	assert.Equal(t, map[string]int64{"timeout": 1, "dns_failure": 1}, stats.Errors)
+	assert.Equal(t, "400ms", stats.AvgProcessingTime, "Failures and cache hits should not count towards the processing time")
+	assert.Equal(t, int64(1), stats.CacheHits)
+	assert.InDelta(t, 0.2, stats.CacheHitRate, 1e-9)
+	assert.Equal(t, []DomainCount{{Domain: "example.com", Analyses: 3}, {Domain: "other.example.org", Analyses: 2}}, stats.TopDomains,
+		"Domains should be lower-cased, without www., busiest first")
+
+	stats, err = st.Stats(ctx, StatsQuery{From: base, To: base.Add(time.Hour), TopDomains: 1})
+	require.NoError(t, err)
+	assert.Len(t, stats.TopDomains, 1, "TopDomains should limit the ranking")
+}
+
+func TestStats_EmptyWindow(t *testing.T) {
+	st := newTestStore(t)
+	now := time.Now()
+
+	stats, err := st.Stats(context.Background(), StatsQuery{From: now.Add(-time.Hour), To: now})
+	require.NoError(t, err)
+	assert.Zero(t, stats.TotalAnalyses)
+	assert.Zero(t, stats.SuccessRatio, "Ratios of an empty window should be zero, not NaN")
+	assert.Equal(t, "0s", stats.AvgProcessingTime)
+	assert.Empty(t, stats.TopDomains)
+	assert.NotNil(t, stats.Errors)
+}
+
+func TestStats_TenantScope(t *testing.T) {
+	st := newTestStore(t)
+	acme := apikeys.WithKey(context.Background(), &apikeys.Key{ID: "key-1", Tenant: "acme"})
+	globex := apikeys.WithKey(context.Background(), &apikeys.Key{ID: "key-2", Tenant: "globex"})
+	now := time.Now()
+
+	require.NoError(t, st.RecordEvent(acme, Event{URL: "https://acme.example.com", At: now}))
+	require.NoError(t, st.RecordEvent(globex, Event{URL: "https://globex.example.com", At: now, ErrorCode: "timeout"}))
+
+	q := StatsQuery{From: now.Add(-time.Hour), To: now.Add(time.Hour)}
+	stats, err := st.Stats(acme, q)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.TotalAnalyses, "Tenants should only see their own analyses")
+	assert.Empty(t, stats.Errors)
+	require.Len(t, stats.TopDomains, 1)
+	assert.Equal(t, "acme.example.com", stats.TopDomains[0].Domain)
+
+	stats, err = st.Stats(context.Background(), q)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.TotalAnalyses, "Without a key every analysis should count")
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/logging"
+)
+
+// statsService decorates an analyzer.Service and records the outcome of every
+// analysis as an Event.
+type statsService struct {
+	analyzer.Service
+	store Store
+	now   func() time.Time
+}
+
+// NewStatsService wraps an analyzer service so that the outcome of every
+// analysis, failed or served from the cache, is recorded for Stats. Wrap it
+// around the cache to see cache hits. A recording failure is logged but never
+// fails the analysis itself.
+func NewStatsService(inner analyzer.Service, st Store) analyzer.Service {
+	return &statsService{
+		Service: inner,
+		store:   st,
+		now:     time.Now,
+	}
+}
+
+// AnalyzeWebpage analyzes a webpage and records its outcome.
+func (s *statsService) AnalyzeWebpage(ctx context.Context, req analyzer.AnalysisRequest) (*analyzer.WebpageAnalysis, error) {
+	start := s.now()
+	analysis, err := s.Service.AnalyzeWebpage(ctx, req)
+	e := Event{URL: req.URL, At: start, Duration: s.now().Sub(start), ErrorCode: eventErrorCode(err)}
+	if err == nil {
+		e.CacheHit = analysis.Cache != nil && analysis.Cache.Hit
+	}
+	s.record(ctx, e)
+	return analysis, err
+}
+
+// CompareWebpages compares two webpages and records the outcome of both
+// analyses, or of the one that failed.
+func (s *statsService) CompareWebpages(ctx context.Context, req analyzer.CompareRequest) (*analyzer.WebpageComparison, error) {
+	start := s.now()
+	comparison, err := s.Service.CompareWebpages(ctx, req)
+	duration := s.now().Sub(start)
+	if err != nil {
+		var analysisErr *analyzer.AnalysisError
+		if errors.As(err, &analysisErr) && analysisErr.URL != "" {
+			s.record(ctx, Event{URL: analysisErr.URL, At: start, Duration: duration, ErrorCode: eventErrorCode(err)})
+		}
+		return nil, err
+	}
+	for _, analysis := range []*analyzer.WebpageAnalysis{comparison.A, comparison.B} {
+		s.record(ctx, Event{URL: analysis.URL, At: start, Duration: duration})
+	}
+	return comparison, nil
+}
+
+// record stores an event, logging rather than propagating failures. It is
+// recorded even when the client has gone away, since the analysis ran.
+func (s *statsService) record(ctx context.Context, e Event) {
+	if err := s.store.RecordEvent(context.WithoutCancel(ctx), e); err != nil {
+		logging.FromContext(ctx).Error("Failed to record analysis event", "url", e.URL, "error", err)
+	}
+}
+
+// eventErrorCode returns the stable code of an analysis error, or "" for nil.
+func eventErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	var analysisErr *analyzer.AnalysisError
+	if errors.As(err, &analysisErr) && analysisErr.Code != "" {
+		return analysisErr.Code
+	}
+	return analyzer.ErrorCodeInternal
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/analyzer"
+)
+
+// outcomeService answers analyses with a fixed outcome.
+type outcomeService struct {
+	analyzer.Service
+	cacheHit bool
+	err      error
+}
+
+func (s *outcomeService) AnalyzeWebpage(ctx context.Context, req analyzer.AnalysisRequest) (*analyzer.WebpageAnalysis, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &analyzer.WebpageAnalysis{URL: req.URL, Cache: &analyzer.CacheInfo{Hit: s.cacheHit}}, nil
+}
+
+func (s *outcomeService) CompareWebpages(ctx context.Context, req analyzer.CompareRequest) (*analyzer.WebpageComparison, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &analyzer.WebpageComparison{A: &analyzer.WebpageAnalysis{URL: req.URLA}, B: &analyzer.WebpageAnalysis{URL: req.URLB}}, nil
+}
+
+func TestStatsService_RecordsOutcomes(t *testing.T) {
+	st := newTestStore(t)
+	inner := &outcomeService{}
+	svc := NewStatsService(inner, st)
+	ctx := context.Background()
+	start := time.Now().Add(-time.Minute)
+
+	_, err := svc.AnalyzeWebpage(ctx, analyzer.AnalysisRequest{URL: "https://example.com"})
+	require.NoError(t, err)
+	inner.cacheHit = true
+	_, err = svc.AnalyzeWebpage(ctx, analyzer.AnalysisRequest{URL: "https://example.com"})
+	require.NoError(t, err)
+	_, err = svc.CompareWebpages(ctx, analyzer.CompareRequest{URLA: "https://a.example.org", URLB: "https://b.example.org"})
+	require.NoError(t, err)
+
+	inner.err = &analyzer.AnalysisError{Code: "timeout", URL: "https://slow.example.net"}
+	_, err = svc.AnalyzeWebpage(ctx, analyzer.AnalysisRequest{URL: "https://slow.example.net"})
+	assert.Error(t, err, "Failures should still be returned")
+	_, err = svc.CompareWebpages(ctx, analyzer.CompareRequest{URLA: "https://slow.example.net", URLB: "https://b.example.org"})
+	assert.Error(t, err)
+
+	stats, err := st.Stats(ctx, StatsQuery{From: start, To: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), stats.TotalAnalyses, "A comparison counts its two analyses, or the one that failed")
+	assert.Equal(t, int64(4), stats.Succeeded)
+	assert.Equal(t, int64(1), stats.CacheHits)
+	assert.Equal(t, map[string]int64{"timeout": 2}, stats.Errors)
+}
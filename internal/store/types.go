@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/sqldb"
+)
+
+// Supported database drivers.
+const (
+	DriverSQLite   = sqldb.DriverSQLite
+	DriverPostgres = sqldb.DriverPostgres
+)
+
+// Pagination limits for List.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// ErrNotFound is returned when a requested record does not exist.
+var ErrNotFound = errors.New("analysis record not found")
+
+// ErrNoFingerprint is returned by Similar when the source analysis has no simhash,
+// e.g. because it predates content fingerprints or skipped the content_hash module.
+var ErrNoFingerprint = errors.New("analysis has no content fingerprint")
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// Config selects and configures the storage backend.
+type Config struct {
+	Driver string // DriverSQLite or DriverPostgres.
+	DSN    string // File path for SQLite, connection string for Postgres.
+}
+
+// Record is a persisted webpage analysis.
+type Record struct {
+	ID        string                    `json:"id" example:"3f2a9c1e8b7d4e6f"`
+	URL       string                    `json:"url" example:"https://example.com"`
+	CreatedAt time.Time                 `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	Analysis  *analyzer.WebpageAnalysis `json:"analysis"`
+}
+
+// SnapshotDiff is the difference between two stored analyses.
+// Numeric deltas are computed as To minus From.
+type SnapshotDiff struct {
+	From        *Record                 `json:"from"`
+	To          *Record                 `json:"to"`
+	Differences analyzer.ComparisonDiff `json:"differences"`
+}
+
+// Query filters and paginates a history listing. Zero values mean "no filter".
+type Query struct {
+	URL    string    // Matches every spelling of the URL that normalizes alike.
+	From   time.Time // Inclusive lower bound on CreatedAt.
+	To     time.Time // Exclusive upper bound on CreatedAt.
+	Cursor string    // Opaque cursor returned as NextCursor by a previous call.
+	Limit  int       // Page size; DefaultPageSize when zero, capped at MaxPageSize.
+}
+
+// Page is one page of history results, newest first.
+type Page struct {
+	Records    []*Record `json:"analyses"`
+	NextCursor string    `json:"next_page,omitempty" example:"MTcwNTMxNDYwMDAwMDAwMDAwMDozZjJhOWMxZThiN2Q0ZTZm"`
+}
+
+// Near-duplicate search limits for Similar.
+const (
+	DefaultSimilarDistance = 3  // Maximum simhash Hamming distance when unset.
+	MaxSimilarDistance     = 16 // Larger distances match mostly unrelated pages.
+)
+
+// SimilarQuery configures a near-duplicate search.
+type SimilarQuery struct {
+	MaxDistance    int  // Maximum simhash Hamming distance; DefaultSimilarDistance when zero.
+	Limit          int  // Maximum matches; DefaultPageSize when zero, capped at MaxPageSize.
+	IncludeSameURL bool // Also match other snapshots of the source URL.
+}
+
+// SimilarRecord is a stored analysis whose content is close to a source analysis.
+type SimilarRecord struct {
+	*Record
+	Distance int `json:"distance" example:"2"` // Simhash Hamming distance to the source; 0 is identical text.
+}
+
+// SimilarResult lists the near-duplicates of a stored analysis, closest first.
+type SimilarResult struct {
+	ID      string           `json:"id" example:"3f2a9c1e8b7d4e6f"`
+	SimHash string           `json:"simhash" example:"3c5a1f0e9b2d4c68"`
+	Matches []*SimilarRecord `json:"matches"`
+}
+
+// Top domain limits for Stats.
+const (
+	DefaultTopDomains = 10
+	MaxTopDomains     = 100
+)
+
+// Event is the outcome of one analysis, kept for statistics. Unlike records,
+// events are kept for failed analyses and cache hits too.
+type Event struct {
+	URL       string
+	At        time.Time
+	Duration  time.Duration
+	ErrorCode string // Empty when the analysis succeeded.
+	CacheHit  bool
+}
+
+// StatsQuery selects the events Stats summarizes.
+type StatsQuery struct {
+	From       time.Time // Inclusive lower bound on the event time.
+	To         time.Time // Exclusive upper bound on the event time.
+	TopDomains int       // Domains to rank; DefaultTopDomains when zero, capped at MaxTopDomains.
+}
+
+// Stats summarizes the analyses of a time range.
+// @Description Analysis statistics of a time range
+type Stats struct {
+	From              time.Time        `json:"from" example:"2024-01-14T10:30:00Z"`
+	To                time.Time        `json:"to" example:"2024-01-15T10:30:00Z"`
+	TotalAnalyses     int64            `json:"total_analyses" example:"1250"`
+	Succeeded         int64            `json:"succeeded" example:"1175"`
+	Failed            int64            `json:"failed" example:"75"`
+	SuccessRatio      float64          `json:"success_ratio" example:"0.94"`
+	ErrorRatio        float64          `json:"error_ratio" example:"0.06"`
+	Errors            map[string]int64 `json:"errors"` // Failed analyses per error code.
+	TopDomains        []DomainCount    `json:"top_domains"`
+	AvgProcessingTime string           `json:"avg_processing_time" example:"840ms"` // Of successful analyses not served from the cache.
+	CacheHits         int64            `json:"cache_hits" example:"400"`
+	CacheHitRate      float64          `json:"cache_hit_rate" example:"0.32"`
+}
+
+// DomainCount is the number of analyses of one domain.
+type DomainCount struct {
+	Domain   string `json:"domain" example:"example.com"`
+	Analyses int64  `json:"analyses" example:"320"`
+}
+
+// Store defines the interface for persisting analyses. When ctx carries an
+// API key, analyses and events are saved under its tenant and only that
+// tenant's are found, listed, matched or summarized; the analyses of other
+// tenants are not found.
+type Store interface {
+	// Save stores a copy of analysis under a new ID. analysis itself is not
+	// modified; the returned record's Analysis carries the ID.
+	Save(ctx context.Context, analysis *analyzer.WebpageAnalysis) (*Record, error)
+	Get(ctx context.Context, id string) (*Record, error)
+	List(ctx context.Context, q Query) (*Page, error)
+	Similar(ctx context.Context, id string, q SimilarQuery) (*SimilarResult, error)
+	RecordEvent(ctx context.Context, e Event) error
+	Stats(ctx context.Context, q StatsQuery) (*Stats, error)
+	Close() error
+}
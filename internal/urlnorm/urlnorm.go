@@ -0,0 +1,212 @@
+// Package urlnorm normalizes page URLs so that different spellings of the
+// same page, such as "https://Example.com:443/a/../" and
+// "https://example.com/", compare equal.
+package urlnorm
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// TrailingSlash selects what happens to a slash at the end of a path.
+type TrailingSlash string
+
+// Trailing slash policies. The root path "/" is never changed.
+const (
+	KeepTrailingSlash  TrailingSlash = ""      // "/docs" and "/docs/" stay different pages.
+	StripTrailingSlash TrailingSlash = "strip" // "/docs/" is treated as "/docs".
+)
+
+// Options controls the normalizations that can change which page a URL
+// names on some sites. The zero value only applies the safe ones.
+type Options struct {
+	// StripTracking removes tracking parameters such as utm_source and gclid
+	// from the query.
+	StripTracking bool
+	TrailingSlash TrailingSlash
+}
+
+// Validate checks the trailing slash policy.
+func (o Options) Validate() error {
+	switch o.TrailingSlash {
+	case KeepTrailingSlash, StripTrailingSlash:
+		return nil
+	default:
+		return fmt.Errorf("unknown trailing slash policy %q (want keep or %s)", o.TrailingSlash, StripTrailingSlash)
+	}
+}
+
+// ParseTrailingSlash parses a trailing slash policy, "keep" or "strip".
+func ParseTrailingSlash(value string) (TrailingSlash, error) {
+	if value == "keep" {
+		return KeepTrailingSlash, nil
+	}
+	policy := TrailingSlash(value)
+	return policy, Options{TrailingSlash: policy}.Validate()
+}
+
+// defaultPorts are the ports left out of normalized URLs.
+var defaultPorts = map[string]string{"http": "80", "https": "443"}
+
+// trackingParams are query parameters that only tell analytics where a
+// visitor came from; parameters starting with "utm_" are tracking too.
+var trackingParams = map[string]bool{
+	"gclid":   true,
+	"gbraid":  true,
+	"wbraid":  true,
+	"dclid":   true,
+	"fbclid":  true,
+	"msclkid": true,
+	"yclid":   true,
+	"twclid":  true,
+	"ttclid":  true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_ga":     true,
+	"_gl":     true,
+}
+
+// IsTrackingParam reports whether a query parameter name is used for
+// campaign or click tracking.
+func IsTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasPrefix(name, "utm_") || trackingParams[name]
+}
+
+// TrackingParams returns the names of rawURL's tracking parameters, in query
+// order and without repeats.
+func TrackingParams(rawURL string) []string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	seen := make(map[string]bool)
+	for _, param := range strings.Split(u.RawQuery, "&") {
+		name, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if IsTrackingParam(name) && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// StripTracking returns rawURL without its tracking parameters, leaving the
+// rest of it as written. URLs that cannot be parsed are returned unchanged.
+func StripTracking(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.RawQuery = stripTracking(u.RawQuery)
+	if u.RawQuery == "" {
+		u.ForceQuery = false
+	}
+	return u.String()
+}
+
+// Normalize returns rawURL with a lower-case scheme and host, without a
+// default port, dot segments or fragment, and with "/" as the path of a bare
+// host. URLs that cannot be parsed are returned unchanged.
+func Normalize(rawURL string, opts Options) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Opaque != "" {
+		return rawURL
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = normalizeHost(u.Host, u.Scheme)
+	u.Fragment, u.RawFragment = "", ""
+
+	path := removeDotSegments(u.EscapedPath())
+	if path == "" && u.Host != "" {
+		path = "/"
+	}
+	if opts.TrailingSlash == StripTrailingSlash && path != "/" {
+		path = strings.TrimRight(path, "/")
+		if path == "" {
+			path = "/"
+		}
+	}
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		u.Path, u.RawPath = unescaped, path
+	}
+
+	if opts.StripTracking {
+		u.RawQuery = stripTracking(u.RawQuery)
+	}
+	if u.RawQuery == "" {
+		u.ForceQuery = false
+	}
+	return u.String()
+}
+
+// normalizeHost lower-cases host and drops the port if it is the scheme's default.
+func normalizeHost(host, scheme string) string {
+	host = strings.ToLower(host)
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil || port != defaultPorts[scheme] {
+		return host
+	}
+	if strings.Contains(hostname, ":") {
+		return "[" + hostname + "]"
+	}
+	return hostname
+}
+
+// removeDotSegments resolves "." and ".." in an absolute path as described in
+// RFC 3986, section 5.2.4. A path ending in a dot segment keeps its trailing
+// slash.
+func removeDotSegments(path string) string {
+	if !strings.Contains(path, ".") {
+		return path
+	}
+	segments := strings.Split(path, "/")
+	out := make([]string, 0, len(segments))
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch segment {
+		case ".":
+			if last {
+				out = append(out, "")
+			}
+		case "..":
+			// Never remove the empty segment before a leading slash.
+			if len(out) > 1 {
+				out = out[:len(out)-1]
+			}
+			if last {
+				out = append(out, "")
+			}
+		default:
+			out = append(out, segment)
+		}
+	}
+	return strings.Join(out, "/")
+}
+
+// stripTracking removes tracking parameters from a raw query, keeping the
+// order of the others.
+func stripTracking(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	params := strings.Split(rawQuery, "&")
+	kept := params[:0]
+	for _, param := range params {
+		name, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if !IsTrackingParam(name) {
+			kept = append(kept, param)
+		}
+	}
+	return strings.Join(kept, "&")
+}
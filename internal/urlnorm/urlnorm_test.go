@@ -0,0 +1,81 @@
+package urlnorm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		opts     Options
+		expected string
+	}{
+		{"lower-case scheme and host", "HTTPS://Example.COM/Path", Options{}, "https://example.com/Path"},
+		{"bare host", "https://example.com", Options{}, "https://example.com/"},
+		{"default https port", "https://example.com:443/", Options{}, "https://example.com/"},
+		{"default http port", "http://example.com:80/a", Options{}, "http://example.com/a"},
+		{"other port", "https://example.com:8443/", Options{}, "https://example.com:8443/"},
+		{"IPv6 default port", "http://[::1]:80/", Options{}, "http://[::1]/"},
+		{"dot segments", "https://example.com/a/./b/../c", Options{}, "https://example.com/a/c"},
+		{"dot segment at end", "https://example.com/a/b/..", Options{}, "https://example.com/a/"},
+		{"dot segments above root", "https://example.com/../../a", Options{}, "https://example.com/a"},
+		{"dots in names", "https://example.com/v1.2/file.tar.gz", Options{}, "https://example.com/v1.2/file.tar.gz"},
+		{"fragment", "https://example.com/a#top", Options{}, "https://example.com/a"},
+		{"empty query", "https://example.com/a?", Options{}, "https://example.com/a"},
+		{"encoded path", "https://example.com/a%2Fb/../c", Options{}, "https://example.com/c"},
+		{"trailing slash kept", "https://example.com/docs/", Options{}, "https://example.com/docs/"},
+		{"trailing slash stripped", "https://example.com/docs/", Options{TrailingSlash: StripTrailingSlash}, "https://example.com/docs"},
+		{"root slash kept", "https://example.com/", Options{TrailingSlash: StripTrailingSlash}, "https://example.com/"},
+		{"tracking kept", "https://example.com/?utm_source=x&id=1", Options{}, "https://example.com/?utm_source=x&id=1"},
+		{"tracking stripped", "https://example.com/?utm_source=x&id=1&gclid=abc&b=2", Options{StripTracking: true}, "https://example.com/?id=1&b=2"},
+		{"only tracking", "https://example.com/?fbclid=abc", Options{StripTracking: true}, "https://example.com/"},
+		{"unparsable", "https://exa mple.com/%zz", Options{}, "https://exa mple.com/%zz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Normalize(tt.url, tt.opts))
+		})
+	}
+}
+
+func TestNormalize_Equivalent(t *testing.T) {
+	assert.Equal(t, Normalize("https://Example.com/", Options{}), Normalize("https://example.com", Options{}))
+}
+
+func TestIsTrackingParam(t *testing.T) {
+	for _, name := range []string{"utm_source", "UTM_Campaign", "gclid", "fbclid", "msclkid"} {
+		assert.True(t, IsTrackingParam(name), name)
+	}
+	for _, name := range []string{"id", "page", "utm", "q"} {
+		assert.False(t, IsTrackingParam(name), name)
+	}
+}
+
+func TestTrackingParams(t *testing.T) {
+	assert.Equal(t, []string{"utm_source", "gclid"}, TrackingParams("https://example.com/?utm_source=x&id=1&gclid=a&utm_source=y"))
+	assert.Equal(t, []string{"utm_medium"}, TrackingParams("https://example.com/?utm%5Fmedium=email"))
+	assert.Empty(t, TrackingParams("https://example.com/?id=1"))
+}
+
+func TestStripTracking(t *testing.T) {
+	assert.Equal(t, "https://Example.com/a/../b?id=1#top", StripTracking("https://Example.com/a/../b?utm_source=x&id=1#top"), "Only the tracking parameters should change")
+	assert.Equal(t, "https://example.com/", StripTracking("https://example.com/?fbclid=abc"))
+	assert.Equal(t, "https://example.com/?id=1", StripTracking("https://example.com/?id=1"))
+}
+
+func TestParseTrailingSlash(t *testing.T) {
+	policy, err := ParseTrailingSlash("keep")
+	require.NoError(t, err)
+	assert.Equal(t, KeepTrailingSlash, policy)
+
+	policy, err = ParseTrailingSlash("strip")
+	require.NoError(t, err)
+	assert.Equal(t, StripTrailingSlash, policy)
+
+	_, err = ParseTrailingSlash("add")
+	assert.Error(t, err)
+}
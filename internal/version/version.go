@@ -0,0 +1,53 @@
+// Package version reports which build of the server is running.
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Build details, set at build time with
+//
+//	go build -ldflags "-X webpage-analyzer/internal/version.Version=v1.4.0 \
+//	  -X webpage-analyzer/internal/version.Commit=$(git rev-parse HEAD) \
+//	  -X webpage-analyzer/internal/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// A binary built without them reports version "dev" and, when Go embedded
+// version control information, that commit and time instead.
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// Info describes the running build.
+// @Description Build and configuration of the running server
+type Info struct {
+	Version   string          `json:"version" example:"v1.4.0"`
+	Commit    string          `json:"commit,omitempty" example:"9b2c1e4f0a7d3b6e8c5f2a1d4e7b0c3f6a9d2e5b"`
+	BuildDate string          `json:"build_date,omitempty" example:"2024-06-01T12:00:00Z"`
+	GoVersion string          `json:"go_version" example:"go1.22.4"`
+	Features  map[string]bool `json:"features,omitempty"` // Optional features and whether this server has them enabled.
+}
+
+// Get returns the running build's details with the given feature flags.
+func Get(features map[string]bool) Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Features:  features,
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = setting.Value
+			}
+		}
+	}
+	return info
+}
@@ -0,0 +1,22 @@
+package version
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	defer func(version, commit, date string) { Version, Commit, BuildDate = version, commit, date }(Version, Commit, BuildDate)
+	Version, Commit, BuildDate = "v1.4.0", "9b2c1e4", "2024-06-01T12:00:00Z"
+
+	info := Get(map[string]bool{"cache": true})
+	assert.Equal(t, Info{
+		Version:   "v1.4.0",
+		Commit:    "9b2c1e4",
+		BuildDate: "2024-06-01T12:00:00Z",
+		GoVersion: runtime.Version(),
+		Features:  map[string]bool{"cache": true},
+	}, info, "Values set with -ldflags should win over embedded build information")
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"sort"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds, in seconds, of the task latency histograms.
+var LatencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// histogram is a fixed-bucket latency histogram. It is not safe for concurrent
+// use; the pool guards it with its own mutex.
+type histogram struct {
+	counts []int64 // Non-cumulative count per bucket in LatencyBuckets.
+	count  int64
+	sum    float64
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]int64, len(LatencyBuckets))}
+}
+
+// observe records one task duration.
+func (h *histogram) observe(d time.Duration) {
+	seconds := d.Seconds()
+	h.count++
+	h.sum += seconds
+	if i := sort.SearchFloat64s(LatencyBuckets, seconds); i < len(LatencyBuckets) {
+		h.counts[i]++
+	}
+}
+
+// snapshot returns a copy of the histogram with cumulative bucket counts.
+func (h *histogram) snapshot() HistogramSnapshot {
+	buckets := make([]HistogramBucket, len(LatencyBuckets))
+	var cumulative int64
+	for i, bound := range LatencyBuckets {
+		cumulative += h.counts[i]
+		buckets[i] = HistogramBucket{UpperBound: bound, Count: cumulative}
+	}
+	return HistogramSnapshot{Buckets: buckets, Count: h.count, Sum: h.sum}
+}
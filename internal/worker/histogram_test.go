@@ -0,0 +1,22 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogramObserve(t *testing.T) {
+	h := newHistogram()
+	h.observe(2 * time.Millisecond)
+	h.observe(200 * time.Millisecond)
+	h.observe(10 * time.Second) // Beyond the largest bucket.
+
+	snap := h.snapshot()
+	assert.Equal(t, int64(3), snap.Count)
+	assert.InDelta(t, 10.202, snap.Sum, 1e-9)
+	assert.Equal(t, int64(0), snap.Buckets[0].Count, "le=0.001 should be empty")
+	assert.Equal(t, int64(1), snap.Buckets[1].Count, "le=0.005 should include the 2ms observation")
+	assert.Equal(t, int64(2), snap.Buckets[len(snap.Buckets)-1].Count, "Observations above every bound only count toward +Inf")
+}
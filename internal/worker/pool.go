@@ -3,92 +3,415 @@ package worker
 import (
 	"context"
 	"log/slog"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+
+	"webpage-analyzer/internal/logging"
 )
 
-// WorkerPool manages a pool of workers for concurrent task execution.
+// WorkerPool runs tasks concurrently, at most as many at a time as it has
+// workers. Each accepted task gets a goroutine, tracked by an errgroup, that
+// waits for a slot of a weighted semaphore and then runs the oldest pending
+// task; the slots the
+// pool does not currently use are held in reserve, so a pool created with
+// NewDynamicWorkerPool grows and shrinks between its minimum and maximum size
+// by releasing and reacquiring them, based on queue depth and task latency.
+//
+// Every task the pool accepts runs, even when the pool is shut down while the
+// task waits for a slot; tasks submitted after Wait or Shutdown are refused.
 type WorkerPool struct {
-	workers   int
-	taskQueue chan Task
-	wg        sync.WaitGroup
-	ctx       context.Context
+	mu         sync.Mutex
+	workers    int // Current number of workers, guarded by mu.
+	minWorkers int
+	maxWorkers int
+	config     PoolConfig
+
+	slots *semaphore.Weighted // One unit per running task; maxWorkers-workers units are held in reserve.
+	queue *semaphore.Weighted // One unit per task waiting for a slot.
+	group errgroup.Group      // Tracks every accepted task until it finishes.
+
+	// pending holds the accepted tasks waiting for a slot, oldest first.
+	// Whichever goroutine gets a slot runs the oldest task, so tasks start
+	// in the order they were submitted.
+	pendingMu sync.Mutex
+	pending   []pendingTask
+
+	// closing is held for reading while a task is accepted and for writing
+	// once the pool closes, so that no task is added to group after Wait or
+	// Shutdown starts waiting on it.
+	closing   sync.RWMutex
+	ctx       context.Context // Cancelled when the pool stops accepting tasks.
 	cancel    context.CancelFunc
+	closeOnce sync.Once
+
+	busy         atomic.Int64
+	submitted    atomic.Int64
+	completed    atomic.Int64
+	failed       atomic.Int64
+	latencyNanos atomic.Int64
+
+	latencyMu     sync.Mutex
+	taskLatencies map[string]*histogram // Keyed by task name, guarded by latencyMu.
+
+	stopScaler chan struct{}
+	scalerDone chan struct{}
+}
+
+// pendingTask is an accepted task with the logger of the context it was
+// submitted with, which reports its failure.
+type pendingTask struct {
+	task   Task
+	logger *slog.Logger
+}
+
+// DefaultPoolConfig returns the autoscaling configuration used by the analyzer service.
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		MinWorkers:    2,
+		MaxWorkers:    10,
+		ScaleInterval: 250 * time.Millisecond,
+		LatencyTarget: 500 * time.Millisecond,
+	}
 }
 
-// NewWorkerPool creates a new worker pool with the specified number of workers.
+// NewWorkerPool creates a new worker pool with a fixed number of workers.
 func NewWorkerPool(workers int) *WorkerPool {
+	return NewDynamicWorkerPool(PoolConfig{MinWorkers: workers, MaxWorkers: workers})
+}
+
+// NewDynamicWorkerPool creates a worker pool that starts with MinWorkers
+// workers and autoscales up to MaxWorkers.
+func NewDynamicWorkerPool(cfg PoolConfig) *WorkerPool {
+	defaults := DefaultPoolConfig()
+	if cfg.MinWorkers < 0 {
+		cfg.MinWorkers = 0
+	}
+	if cfg.MaxWorkers < cfg.MinWorkers {
+		cfg.MaxWorkers = cfg.MinWorkers
+	}
+	if cfg.ScaleInterval <= 0 {
+		cfg.ScaleInterval = defaults.ScaleInterval
+	}
+	if cfg.LatencyTarget <= 0 {
+		cfg.LatencyTarget = defaults.LatencyTarget
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	pool := &WorkerPool{
-		workers:   workers,
-		taskQueue: make(chan Task, workers*2), // Buffer for better performance.
-		ctx:       ctx,
-		cancel:    cancel,
+		workers:       cfg.MinWorkers,
+		minWorkers:    cfg.MinWorkers,
+		maxWorkers:    cfg.MaxWorkers,
+		config:        cfg,
+		slots:         semaphore.NewWeighted(int64(cfg.MaxWorkers)),
+		queue:         semaphore.NewWeighted(int64(cfg.MaxWorkers * 2)), // Same depth as the former task channel.
+		ctx:           ctx,
+		cancel:        cancel,
+		stopScaler:    make(chan struct{}),
+		taskLatencies: make(map[string]*histogram),
 	}
 
-	// Start workers.
-	for i := 0; i < workers; i++ {
-		pool.wg.Add(1)
-		go pool.worker()
+	// Reserve the slots of the workers the pool may grow to later.
+	pool.slots.TryAcquire(int64(cfg.MaxWorkers - cfg.MinWorkers))
+
+	// Only pools with room to grow need the scaler.
+	if cfg.MaxWorkers > cfg.MinWorkers {
+		pool.scalerDone = make(chan struct{})
+		go pool.scale()
 	}
 
 	return pool
 }
 
-// worker is the main worker goroutine that processes tasks.
-func (wp *WorkerPool) worker() {
-	defer wp.wg.Done()
+// addWorkers releases n reserved slots. The caller must hold wp.mu.
+func (wp *WorkerPool) addWorkers(n int) {
+	wp.slots.Release(int64(n))
+	wp.workers += n
+}
+
+// removeWorker takes an idle slot back into reserve, reporting whether one
+// was idle. The caller must hold wp.mu.
+func (wp *WorkerPool) removeWorker() bool {
+	if !wp.slots.TryAcquire(1) {
+		return false
+	}
+	wp.workers--
+	return true
+}
+
+// submit accepts task once there is room in the queue, or fails with ctx's
+// error, or ErrPoolClosed once the pool stops accepting tasks.
+func (wp *WorkerPool) submit(ctx context.Context, task Task) error {
+	wp.closing.RLock()
+	defer wp.closing.RUnlock()
+	if wp.ctx.Err() != nil {
+		return ErrPoolClosed
+	}
+
+	// Wait for room in the queue until either ctx is done or the pool closes.
+	queueCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(wp.ctx, cancel)
+	defer stop()
+	if err := wp.queue.Acquire(queueCtx, 1); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return ErrPoolClosed
+	}
+	wp.enqueue(pendingTask{task: task, logger: logging.FromContext(ctx)})
+	return nil
+}
+
+// TrySubmit adds a task to the worker pool only if the queue has room, without
+// waiting. It reports whether the task was accepted.
+func (wp *WorkerPool) TrySubmit(task Task) bool {
+	wp.closing.RLock()
+	defer wp.closing.RUnlock()
+	if wp.ctx.Err() != nil || !wp.queue.TryAcquire(1) {
+		return false
+	}
+	wp.enqueue(pendingTask{task: task, logger: slog.Default()})
+	return true
+}
+
+// enqueue adds an accepted task, whose queue place the caller holds, to the
+// pending tasks. The caller must hold wp.closing for reading.
+func (wp *WorkerPool) enqueue(task pendingTask) {
+	wp.submitted.Add(1)
+	wp.pendingMu.Lock()
+	wp.pending = append(wp.pending, task)
+	wp.pendingMu.Unlock()
+	wp.group.Go(func() error {
+		// Acquiring with a background context cannot fail: a closing pool
+		// releases its reserve, so the task gets a slot eventually.
+		_ = wp.slots.Acquire(context.Background(), 1)
+		defer wp.slots.Release(1)
+		wp.run(wp.next())
+		return nil // Task errors are counted and logged by run, not collected.
+	})
+}
+
+// next removes the oldest pending task and frees its place in the queue.
+func (wp *WorkerPool) next() pendingTask {
+	wp.pendingMu.Lock()
+	task := wp.pending[0]
+	wp.pending[0] = pendingTask{}
+	wp.pending = wp.pending[1:]
+	wp.pendingMu.Unlock()
+
+	wp.queue.Release(1)
+	return task
+}
+
+// queueDepth returns the number of tasks waiting for a slot.
+func (wp *WorkerPool) queueDepth() int {
+	wp.pendingMu.Lock()
+	defer wp.pendingMu.Unlock()
+	return len(wp.pending)
+}
+
+// run executes a single task and records its latency.
+func (wp *WorkerPool) run(task pendingTask) {
+	wp.busy.Add(1)
+	start := time.Now()
+	err := runSafely(task.logger, task.task)
+	wp.latencyNanos.Add(int64(time.Since(start)))
+	wp.completed.Add(1)
+	wp.busy.Add(-1)
+
+	if err != nil {
+		wp.failed.Add(1)
+		// Log error but continue processing other tasks.
+		task.logger.Error("Worker task failed", "error", err)
+	}
+}
 
+// runSafely runs fn, converting a panic into a *PanicError so the calling
+// goroutine survives, and logs the panic with logger.
+func runSafely(logger *slog.Logger, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			logger.Error("Worker task panicked", "panic", r, "stack", string(stack))
+			err = &PanicError{Value: r, Stack: stack}
+		}
+	}()
+	return fn()
+}
+
+// scale periodically resizes the pool until it is stopped.
+func (wp *WorkerPool) scale() {
+	defer close(wp.scalerDone)
+
+	ticker := time.NewTicker(wp.config.ScaleInterval)
+	defer ticker.Stop()
+
+	var lastCompleted, lastLatency int64
+	var avgLatency time.Duration // Carried over from the last interval that completed tasks.
 	for {
 		select {
-		case task, ok := <-wp.taskQueue:
-			if !ok {
-				return // Channel closed, exit worker.
-			}
-			if err := task(); err != nil {
-				// Log error but continue processing other tasks.
-				// In a production system, you might want to handle errors differently.
-				slog.Error("Worker task failed", "error", err)
+		case <-ticker.C:
+			completed, latency := wp.completed.Load(), wp.latencyNanos.Load()
+			if completed > lastCompleted {
+				avgLatency = time.Duration((latency - lastLatency) / (completed - lastCompleted))
 			}
-		case <-wp.ctx.Done():
-			return // Context cancelled, exit worker.
+			lastCompleted, lastLatency = completed, latency
+
+			wp.resize(wp.queueDepth(), int(wp.busy.Load()), avgLatency)
+		case <-wp.stopScaler:
+			return
 		}
 	}
 }
 
-// Submit adds a task to the worker pool.
+// resize applies one scaling decision from the current queue depth, number of
+// busy workers, and recent average task latency.
+func (wp *WorkerPool) resize(queued, busy int, avgLatency time.Duration) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	from := wp.workers
+	switch {
+	case queued > 0 && wp.workers < wp.maxWorkers:
+		// Tasks are waiting: add enough workers to drain the queue.
+		wp.addWorkers(min(queued, wp.maxWorkers-wp.workers))
+	case busy >= wp.workers && avgLatency > wp.config.LatencyTarget && wp.workers < wp.maxWorkers:
+		// Every worker is busy with slow tasks: grow ahead of the queue.
+		wp.addWorkers(1)
+	case queued == 0 && busy*2 < wp.workers && wp.workers > wp.minWorkers:
+		// Less than half the workers are busy: retire an idle one.
+		wp.removeWorker()
+	}
+
+	if wp.workers != from {
+		slog.Debug("Worker pool resized",
+			"from", from,
+			"to", wp.workers,
+			"queued", queued,
+			"busy", busy,
+			"avg_task_latency", avgLatency,
+		)
+	}
+}
+
+// Stats returns a snapshot of the pool's size and load.
+func (wp *WorkerPool) Stats() PoolStats {
+	wp.mu.Lock()
+	workers := wp.workers
+	wp.mu.Unlock()
+
+	wp.latencyMu.Lock()
+	latencies := make(map[string]HistogramSnapshot, len(wp.taskLatencies))
+	for name, h := range wp.taskLatencies {
+		latencies[name] = h.snapshot()
+	}
+	wp.latencyMu.Unlock()
+
+	return PoolStats{
+		Workers:        workers,
+		MinWorkers:     wp.minWorkers,
+		MaxWorkers:     wp.maxWorkers,
+		BusyWorkers:    int(wp.busy.Load()),
+		QueueDepth:     wp.queueDepth(),
+		SubmittedTasks: wp.submitted.Load(),
+		CompletedTasks: wp.completed.Load(),
+		FailedTasks:    wp.failed.Load(),
+		TaskLatencies:  latencies,
+	}
+}
+
+// observeTask records the latency of a named task.
+func (wp *WorkerPool) observeTask(name string, d time.Duration) {
+	wp.latencyMu.Lock()
+	defer wp.latencyMu.Unlock()
+
+	h, ok := wp.taskLatencies[name]
+	if !ok {
+		h = newHistogram()
+		wp.taskLatencies[name] = h
+	}
+	h.observe(d)
+}
+
+// Submit adds a task to the worker pool, blocking while the queue is full.
+// The task is dropped if the pool is shutting down.
 func (wp *WorkerPool) Submit(task Task) {
-	select {
-	case wp.taskQueue <- task:
-		// Task submitted successfully.
-	case <-wp.ctx.Done():
-		// Pool is shutting down.
+	if err := wp.submit(context.Background(), task); err != nil {
+		slog.Warn("Worker task dropped", "error", err)
 	}
 }
 
-// SubmitAndWait submits a task and waits for it to complete.
+// SubmitContext adds a task to the worker pool, giving up if ctx is done
+// before the task is queued. It returns ErrPoolClosed if the pool is shutting down.
+func (wp *WorkerPool) SubmitContext(ctx context.Context, task Task) error {
+	return wp.submit(ctx, task)
+}
+
+// SubmitAndWait submits a task and waits for it to complete. It returns
+// ErrPoolClosed if the pool is shutting down.
 func (wp *WorkerPool) SubmitAndWait(task Task) error {
 	resultChan := make(chan error, 1)
 
-	wp.Submit(func() error {
-		err := task()
+	err := wp.submit(context.Background(), func() error {
+		err := runSafely(slog.Default(), task)
 		resultChan <- err
 		return err
 	})
+	if err != nil {
+		return err
+	}
 
 	return <-resultChan
 }
 
-// Wait waits for all submitted tasks to complete.
+// Closed reports whether the pool has stopped accepting tasks.
+func (wp *WorkerPool) Closed() bool {
+	return wp.ctx.Err() != nil
+}
+
+// Wait stops accepting tasks and waits for all submitted tasks to complete.
 func (wp *WorkerPool) Wait() {
-	close(wp.taskQueue) // Signal workers to stop accepting new tasks.
-	wp.wg.Wait()        // Wait for all workers to finish.
+	wp.close()
+	_ = wp.group.Wait() // Tasks never return errors to the group.
 }
 
-// Shutdown gracefully shuts down the worker pool.
+// Shutdown gracefully shuts down the worker pool: new tasks are refused and
+// the accepted ones, running or queued, finish before it returns.
 func (wp *WorkerPool) Shutdown() {
-	wp.cancel()         // Cancel context to stop workers.
-	close(wp.taskQueue) // Close task queue to signal workers to stop
-	wp.wg.Wait()        // Wait for all workers to finish.
+	wp.Wait()
+}
+
+// close stops the pool from accepting tasks, once. It returns when no more
+// tasks can be added to the group, with every slot available to drain the
+// queue.
+func (wp *WorkerPool) close() {
+	wp.closeOnce.Do(func() {
+		wp.cancel() // Refuse new tasks and wake submitters waiting for queue room.
+
+		// Wait for submitters that were already accepting a task.
+		wp.closing.Lock()
+		wp.closing.Unlock()
+
+		wp.stopScaling()
+
+		wp.mu.Lock()
+		wp.addWorkers(wp.maxWorkers - wp.workers)
+		wp.mu.Unlock()
+	})
+}
+
+// stopScaling stops the scaler and waits for it to exit so that the pool is
+// not resized while it drains.
+func (wp *WorkerPool) stopScaling() {
+	close(wp.stopScaler)
+	if wp.scalerDone != nil {
+		<-wp.scalerDone
+	}
 }
 
 // NewAnalysisTaskGroup creates a new task group for analysis.
@@ -99,47 +422,102 @@ func NewAnalysisTaskGroup(pool *WorkerPool) *AnalysisTaskGroup {
 	}
 }
 
-// AddTask adds a task to the group.
-func (atg *AnalysisTaskGroup) AddTask(name string, task func() (interface{}, error)) {
+// SetTimeout sets a deadline for the whole group, applied when ExecuteAll starts.
+func (atg *AnalysisTaskGroup) SetTimeout(timeout time.Duration) {
+	atg.timeout = timeout
+}
+
+// AddTask adds a task to the group and returns a handle to its typed result.
+func AddTask[T any](atg *AnalysisTaskGroup, name string, task func(ctx context.Context) (T, error)) *Result[T] {
+	return AddTaskWithTimeout(atg, name, 0, task)
+}
+
+// AddTaskWithTimeout adds a task whose context expires after timeout.
+// A zero timeout means the task only inherits the group's deadline.
+func AddTaskWithTimeout[T any](atg *AnalysisTaskGroup, name string, timeout time.Duration, task func(ctx context.Context) (T, error)) *Result[T] {
 	analysisTask := &AnalysisTask{
 		Name: name,
-		Task: task,
+		Task: func(ctx context.Context) (interface{}, error) {
+			return task(ctx)
+		},
+		Timeout: timeout,
 	}
 	atg.tasks = append(atg.tasks, analysisTask)
+	return &Result[T]{task: analysisTask}
 }
 
-// ExecuteAll runs all tasks in parallel and waits for completion.
-func (atg *AnalysisTaskGroup) ExecuteAll() {
+// Get returns the task's result and error. It must be called after ExecuteAll;
+// a task that failed or never ran yields the zero value of T.
+func (r *Result[T]) Get() (T, error) {
+	value, _ := r.task.Result.(T) // Always a T when set, since the task returned it.
+	return value, r.task.Error
+}
+
+// ExecuteAll runs all tasks in parallel and waits for the scheduled ones to complete.
+// Once ctx is done, tasks that have not started are skipped and report the context
+// error; ExecuteAll then returns that error as well. Tasks that are already running
+// are expected to watch their ctx and return early.
+func (atg *AnalysisTaskGroup) ExecuteAll(ctx context.Context) error {
+	if atg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, atg.timeout)
+		defer cancel()
+	}
+
 	var wg sync.WaitGroup
+	var submitErr error
 
 	for _, task := range atg.tasks {
 		wg.Add(1)
-		atg.pool.Submit(func() error {
+		err := atg.pool.SubmitContext(ctx, func() error {
 			defer wg.Done()
-			result, err := task.Task()
-			task.Result = result
-			task.Error = err
-			if err != nil {
-				slog.Error("Analysis task failed",
-					"task_name", task.Name,
-					"error", err,
-				)
+			if err := ctx.Err(); err != nil {
+				// The group ended while this task was queued.
+				task.Error = err
+				return nil
 			}
-			return err
+			return atg.run(ctx, task)
 		})
+		if err != nil {
+			wg.Done()
+			task.Error = err
+			submitErr = err
+		}
 	}
 
 	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return submitErr
 }
 
-// GetResult retrieves the result of a specific task.
-func (atg *AnalysisTaskGroup) GetResult(taskName string) (interface{}, error) {
-	for _, task := range atg.tasks {
-		if task.Name == taskName {
-			return task.Result, task.Error
-		}
+// run executes a single task with its own deadline and records the outcome.
+func (atg *AnalysisTaskGroup) run(ctx context.Context, task *AnalysisTask) error {
+	if task.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, task.Timeout)
+		defer cancel()
+	}
+
+	logger := logging.FromContext(ctx)
+	start := time.Now()
+	var result interface{}
+	err := runSafely(logger, func() error {
+		var err error
+		result, err = task.Task(ctx)
+		return err
+	})
+	atg.pool.observeTask(task.Name, time.Since(start))
+	task.Result = result
+	task.Error = err
+	if err != nil {
+		logger.Error("Analysis task failed",
+			"task_name", task.Name,
+			"error", err,
+		)
 	}
-	return nil, nil
+	return err
 }
 
 // HasErrors checks if any tasks had errors.
@@ -1,12 +1,18 @@
 package worker
 
 import (
+	"bytes"
+	"context"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/internal/logging"
 )
 
 func TestNewWorkerPool(t *testing.T) {
@@ -71,31 +77,31 @@ func TestAnalysisTaskGroup(t *testing.T) {
 	group := NewAnalysisTaskGroup(pool)
 
 	// Add tasks
-	group.AddTask("task1", func() (interface{}, error) {
+	task1 := AddTask(group, "task1", func(ctx context.Context) (string, error) {
 		return "result1", nil
 	})
 
-	group.AddTask("task2", func() (interface{}, error) {
-		return "result2", nil
+	task2 := AddTask(group, "task2", func(ctx context.Context) (int, error) {
+		return 2, nil
 	})
 
-	group.AddTask("task3", func() (interface{}, error) {
-		return nil, &AnalysisError{StatusCode: 400, ErrorMessage: "task3 error", URL: "test"}
+	task3 := AddTask(group, "task3", func(ctx context.Context) (string, error) {
+		return "", &AnalysisError{StatusCode: 400, ErrorMessage: "task3 error", URL: "test"}
 	})
 
 	// Execute all tasks
-	group.ExecuteAll()
+	group.ExecuteAll(context.Background())
 
 	// Check results
-	result1, err := group.GetResult("task1")
+	result1, err := task1.Get()
 	assert.NoError(t, err, "task1 should not have error")
 	assert.Equal(t, "result1", result1, "task1 result should match")
 
-	result2, err := group.GetResult("task2")
+	result2, err := task2.Get()
 	assert.NoError(t, err, "task2 should not have error")
-	assert.Equal(t, "result2", result2, "task2 result should match")
+	assert.Equal(t, 2, result2, "task2 result should match")
 
-	_, err = group.GetResult("task3")
+	_, err = task3.Get()
 	assert.Error(t, err, "task3 should have error")
 
 	// Check if any tasks had errors
@@ -109,31 +115,41 @@ func TestAnalysisTaskGroupNoErrors(t *testing.T) {
 	group := NewAnalysisTaskGroup(pool)
 
 	// Add successful tasks only
-	group.AddTask("task1", func() (interface{}, error) {
+	AddTask(group, "task1", func(ctx context.Context) (string, error) {
 		return "result1", nil
 	})
 
-	group.AddTask("task2", func() (interface{}, error) {
+	AddTask(group, "task2", func(ctx context.Context) (string, error) {
 		return "result2", nil
 	})
 
 	// Execute all tasks
-	group.ExecuteAll()
+	group.ExecuteAll(context.Background())
 
 	// Check if any tasks had errors
 	assert.False(t, group.HasErrors(), "HasErrors() should return false when no tasks have errors")
 }
 
-func TestAnalysisTaskGroupGetResultNonExistent(t *testing.T) {
+func TestResultGetFailedTaskReturnsZeroValue(t *testing.T) {
 	pool := NewWorkerPool(2)
 	defer pool.Shutdown()
 
 	group := NewAnalysisTaskGroup(pool)
+	counts := AddTask(group, "counts", func(ctx context.Context) (map[string]int, error) {
+		return map[string]int{"h1": 1}, &AnalysisError{StatusCode: 500, ErrorMessage: "partial", URL: "test"}
+	})
+	flag := AddTask(group, "flag", func(ctx context.Context) (bool, error) {
+		panic("boom")
+	})
+	group.ExecuteAll(context.Background())
 
-	// Try to get result for non-existent task
-	result, err := group.GetResult("nonexistent")
-	assert.Nil(t, result, "GetResult() for non-existent task should return nil result")
-	assert.Nil(t, err, "GetResult() for non-existent task should return nil error")
+	result, err := counts.Get()
+	assert.Error(t, err)
+	assert.Equal(t, map[string]int{"h1": 1}, result, "Get() should return the value the task produced alongside its error")
+
+	value, err := flag.Get()
+	assert.Error(t, err)
+	assert.False(t, value, "Get() should return the zero value for a task that never returned")
 }
 
 func TestWorkerPoolConcurrentAccess(t *testing.T) {
@@ -161,3 +177,342 @@ func TestWorkerPoolConcurrentAccess(t *testing.T) {
 
 	assert.Equal(t, 100, counter, "Counter should be 100 after all tasks complete")
 }
+
+func TestDynamicWorkerPoolScalesUpAndDown(t *testing.T) {
+	pool := NewDynamicWorkerPool(PoolConfig{
+		MinWorkers:    1,
+		MaxWorkers:    4,
+		ScaleInterval: 5 * time.Millisecond,
+	})
+	defer pool.Shutdown()
+
+	require.Equal(t, 1, pool.Stats().Workers, "Pool should start with MinWorkers")
+
+	// Block workers so tasks pile up in the queue.
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		pool.Submit(func() error {
+			defer wg.Done()
+			<-release
+			return nil
+		})
+	}
+
+	assert.Eventually(t, func() bool { return pool.Stats().Workers == 4 }, time.Second, 5*time.Millisecond,
+		"Pool should grow to MaxWorkers while tasks are queued")
+
+	close(release)
+	wg.Wait()
+
+	assert.Eventually(t, func() bool { return pool.Stats().Workers == 1 }, time.Second, 5*time.Millisecond,
+		"Pool should shrink back to MinWorkers when idle")
+	assert.Equal(t, int64(8), pool.Stats().CompletedTasks, "Stats() should count completed tasks")
+}
+
+func TestDynamicWorkerPoolGrowsOnSlowTasks(t *testing.T) {
+	pool := NewDynamicWorkerPool(PoolConfig{
+		MinWorkers:    1,
+		MaxWorkers:    2,
+		ScaleInterval: 5 * time.Millisecond,
+		LatencyTarget: time.Millisecond,
+	})
+	defer pool.Shutdown()
+
+	// A completed slow task sets the average latency; a second keeps the pool saturated.
+	require.NoError(t, pool.SubmitAndWait(func() error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}))
+	release := make(chan struct{})
+	defer close(release)
+	pool.Submit(func() error {
+		<-release
+		return nil
+	})
+
+	assert.Eventually(t, func() bool { return pool.Stats().Workers == 2 }, time.Second, 2*time.Millisecond,
+		"Saturated pool with slow tasks should grow before tasks queue")
+}
+
+func TestNewDynamicWorkerPoolNormalizesConfig(t *testing.T) {
+	pool := NewDynamicWorkerPool(PoolConfig{MinWorkers: 3, MaxWorkers: 1})
+	defer pool.Shutdown()
+
+	stats := pool.Stats()
+	assert.Equal(t, 3, stats.MinWorkers)
+	assert.Equal(t, 3, stats.MaxWorkers, "MaxWorkers below MinWorkers should be raised to MinWorkers")
+	assert.Equal(t, 3, stats.Workers)
+}
+
+func TestWorkerPoolRecoversFromPanic(t *testing.T) {
+	pool := NewWorkerPool(1)
+	defer pool.Shutdown()
+
+	err := pool.SubmitAndWait(func() error {
+		panic("boom")
+	})
+	var panicErr *PanicError
+	require.ErrorAs(t, err, &panicErr, "SubmitAndWait() should return a PanicError for a panicking task")
+	assert.Equal(t, "boom", panicErr.Value)
+	assert.NotEmpty(t, panicErr.Stack, "PanicError should carry a stack trace")
+
+	// The single worker must still be alive to run this task.
+	err = pool.SubmitAndWait(func() error { return nil })
+	assert.NoError(t, err, "Worker should keep processing tasks after a panic")
+}
+
+func TestWorkerPoolLogsFailuresWithSubmitterLogger(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := logging.NewContext(context.Background(), slog.New(slog.NewJSONHandler(&buf, nil)).With("job_id", "job-1"))
+	pool := NewWorkerPool(1)
+
+	require.NoError(t, pool.SubmitContext(ctx, func() error { return assert.AnError }))
+	pool.Shutdown()
+
+	assert.Contains(t, buf.String(), `"msg":"Worker task failed"`)
+	assert.Contains(t, buf.String(), `"job_id":"job-1"`, "A failed task should be logged with the logger of the context it was submitted with")
+}
+
+func TestAnalysisTaskGroupPanickingTask(t *testing.T) {
+	pool := NewWorkerPool(1)
+	defer pool.Shutdown()
+
+	group := NewAnalysisTaskGroup(pool)
+	panics := AddTask(group, "panics", func(ctx context.Context) (int, error) {
+		var m map[string]int
+		m["x"] = 1 // Nil map write.
+		return len(m), nil
+	})
+	ok := AddTask(group, "ok", func(ctx context.Context) (string, error) {
+		return "fine", nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		group.ExecuteAll(context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ExecuteAll() should not hang when a task panics")
+	}
+
+	_, err := panics.Get()
+	var panicErr *PanicError
+	assert.ErrorAs(t, err, &panicErr, "Panicking task should report a PanicError")
+
+	result, err := ok.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, "fine", result, "Other tasks should still complete")
+}
+
+func TestWorkerPoolStatsCountersAndLatencies(t *testing.T) {
+	pool := NewWorkerPool(2)
+	defer pool.Shutdown()
+
+	group := NewAnalysisTaskGroup(pool)
+	AddTask(group, "fast", func(ctx context.Context) (bool, error) { return true, nil })
+	AddTask(group, "fails", func(ctx context.Context) (bool, error) {
+		return false, &AnalysisError{StatusCode: 500, ErrorMessage: "fail", URL: "test"}
+	})
+	group.ExecuteAll(context.Background())
+
+	stats := pool.Stats()
+	assert.Equal(t, int64(2), stats.SubmittedTasks, "Stats() should count submitted tasks")
+	assert.Equal(t, int64(2), stats.CompletedTasks, "Stats() should count completed tasks")
+	assert.Equal(t, int64(1), stats.FailedTasks, "Stats() should count failed tasks")
+
+	require.Contains(t, stats.TaskLatencies, "fast", "Stats() should track latency per task name")
+	fast := stats.TaskLatencies["fast"]
+	assert.Equal(t, int64(1), fast.Count)
+	require.Len(t, fast.Buckets, len(LatencyBuckets))
+	assert.Equal(t, int64(1), fast.Buckets[len(fast.Buckets)-1].Count, "Bucket counts should be cumulative")
+}
+
+func TestAnalysisTaskGroupCancelledContext(t *testing.T) {
+	pool := NewWorkerPool(1)
+	defer pool.Shutdown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	group := NewAnalysisTaskGroup(pool)
+
+	var ran []string
+	var mu sync.Mutex
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		ran = append(ran, name)
+	}
+
+	AddTask(group, "first", func(ctx context.Context) (string, error) {
+		record("first")
+		cancel() // The client goes away while the first task runs.
+		return "done", nil
+	})
+	second := AddTask(group, "second", func(ctx context.Context) (string, error) {
+		record("second")
+		return "done", nil
+	})
+
+	err := group.ExecuteAll(ctx)
+	assert.ErrorIs(t, err, context.Canceled, "ExecuteAll() should return the context error")
+	assert.Equal(t, []string{"first"}, ran, "Tasks queued after cancellation should not run")
+
+	_, err = second.Get()
+	assert.ErrorIs(t, err, context.Canceled, "Skipped tasks should report the context error")
+}
+
+func TestAnalysisTaskGroupPerTaskTimeout(t *testing.T) {
+	pool := NewWorkerPool(2)
+	defer pool.Shutdown()
+
+	group := NewAnalysisTaskGroup(pool)
+	slow := AddTaskWithTimeout(group, "slow", 10*time.Millisecond, func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	fast := AddTask(group, "fast", func(ctx context.Context) (string, error) {
+		return "ok", nil
+	})
+
+	err := group.ExecuteAll(context.Background())
+	assert.NoError(t, err, "A per-task timeout should not fail the group")
+
+	_, err = slow.Get()
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "Slow task should hit its own deadline")
+	result, err := fast.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", result)
+}
+
+func TestAnalysisTaskGroupTimeout(t *testing.T) {
+	pool := NewWorkerPool(1)
+	defer pool.Shutdown()
+
+	group := NewAnalysisTaskGroup(pool)
+	group.SetTimeout(10 * time.Millisecond)
+	AddTask(group, "blocks", func(ctx context.Context) (struct{}, error) {
+		<-ctx.Done()
+		return struct{}{}, ctx.Err()
+	})
+
+	start := time.Now()
+	err := group.ExecuteAll(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "ExecuteAll() should stop at the group deadline")
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestAnalysisTaskGroupShutdownPool(t *testing.T) {
+	pool := NewWorkerPool(1)
+	pool.Shutdown()
+
+	group := NewAnalysisTaskGroup(pool)
+	AddTask(group, "never", func(ctx context.Context) (string, error) {
+		return "", nil
+	})
+
+	err := group.ExecuteAll(context.Background())
+	assert.ErrorIs(t, err, ErrPoolClosed, "ExecuteAll() should not hang on a shut down pool")
+}
+
+func TestWorkerPoolSubmitAfterClose(t *testing.T) {
+	for name, closePool := range map[string]func(*WorkerPool){
+		"Wait":     (*WorkerPool).Wait,
+		"Shutdown": (*WorkerPool).Shutdown,
+	} {
+		t.Run(name, func(t *testing.T) {
+			pool := NewWorkerPool(1)
+			require.False(t, pool.Closed())
+			closePool(pool)
+			assert.True(t, pool.Closed())
+
+			assert.NotPanics(t, func() { pool.Submit(func() error { return nil }) }, "Submit() after close should not panic")
+			assert.ErrorIs(t, pool.SubmitContext(context.Background(), func() error { return nil }), ErrPoolClosed)
+			assert.ErrorIs(t, pool.SubmitAndWait(func() error { return nil }), ErrPoolClosed)
+			assert.NotPanics(t, pool.Shutdown, "Closing twice should not panic")
+			assert.Equal(t, int64(0), pool.Stats().SubmittedTasks)
+		})
+	}
+}
+
+func TestWorkerPoolShutdownRunsQueuedTasks(t *testing.T) {
+	pool := NewDynamicWorkerPool(PoolConfig{MinWorkers: 1, MaxWorkers: 2, ScaleInterval: time.Hour})
+
+	release := make(chan struct{})
+	var ran sync.WaitGroup
+	var count atomic.Int64
+	for i := 0; i < 4; i++ {
+		ran.Add(1)
+		pool.Submit(func() error {
+			defer ran.Done()
+			<-release
+			count.Add(1)
+			return nil
+		})
+	}
+	require.Eventually(t, func() bool { return pool.Stats().QueueDepth == 3 }, time.Second, time.Millisecond,
+		"One worker should leave three tasks queued")
+
+	done := make(chan struct{})
+	go func() {
+		pool.Shutdown()
+		close(done)
+	}()
+	close(release)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown() should return once the queued tasks ran")
+	}
+	assert.Equal(t, int64(4), count.Load(), "Tasks accepted before Shutdown() should not be lost")
+	ran.Wait()
+}
+
+func TestWorkerPoolConcurrentSubmitAndShutdown(t *testing.T) {
+	pool := NewWorkerPool(2)
+
+	var accepted, ran atomic.Int64
+	var submitters sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		submitters.Add(1)
+		go func() {
+			defer submitters.Done()
+			for j := 0; j < 20; j++ {
+				err := pool.SubmitContext(context.Background(), func() error {
+					ran.Add(1)
+					return nil
+				})
+				if err == nil {
+					accepted.Add(1)
+				}
+			}
+		}()
+	}
+	pool.Shutdown()
+	submitters.Wait()
+
+	assert.Equal(t, accepted.Load(), ran.Load(), "Every accepted task should run exactly once")
+	assert.Equal(t, accepted.Load(), pool.Stats().CompletedTasks)
+}
+
+func TestWorkerPoolTrySubmit(t *testing.T) {
+	pool := NewWorkerPool(1)
+
+	release := make(chan struct{})
+	block := func() error { <-release; return nil }
+	require.True(t, pool.TrySubmit(block))
+	require.Eventually(t, func() bool { return pool.Stats().BusyWorkers == 1 }, time.Second, time.Millisecond)
+	assert.True(t, pool.TrySubmit(block))
+	assert.True(t, pool.TrySubmit(block))
+	assert.False(t, pool.TrySubmit(block), "TrySubmit() should refuse a task when the queue is full")
+
+	close(release)
+	pool.Shutdown()
+	assert.False(t, pool.TrySubmit(block), "TrySubmit() should refuse a task after Shutdown()")
+	assert.Equal(t, int64(3), pool.Stats().CompletedTasks)
+}
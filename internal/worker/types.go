@@ -1,6 +1,14 @@
 package worker
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrPoolClosed is returned when submitting to a pool that is shutting down.
+var ErrPoolClosed = errors.New("worker pool is shut down")
 
 // TaskFunc represents a unit of work to be executed by a worker.
 // It should return an error if the task fails, or nil if successful.
@@ -12,23 +20,86 @@ type Task = TaskFunc
 // WorkerPoolManager defines the interface for worker pool operations.
 type WorkerPoolManager interface {
 	Submit(task Task)
+	SubmitContext(ctx context.Context, task Task) error
+	TrySubmit(task Task) bool
 	SubmitAndWait(task Task) error
 	Wait()
 	Shutdown()
+	Closed() bool
+	Stats() PoolStats
+}
+
+// PoolConfig configures the size and autoscaling behaviour of a WorkerPool.
+type PoolConfig struct {
+	MinWorkers int // Workers kept alive when the pool is idle.
+	MaxWorkers int // Upper bound on workers when the pool is under load.
+
+	// ScaleInterval is how often the pool re-evaluates its size.
+	ScaleInterval time.Duration
+	// LatencyTarget is the average task latency above which a saturated pool
+	// grows even before tasks start queuing.
+	LatencyTarget time.Duration
+}
+
+// PoolStats is a point-in-time snapshot of a WorkerPool.
+type PoolStats struct {
+	Workers        int   `json:"workers"`
+	MinWorkers     int   `json:"min_workers"`
+	MaxWorkers     int   `json:"max_workers"`
+	BusyWorkers    int   `json:"busy_workers"`
+	QueueDepth     int   `json:"queue_depth"`
+	SubmittedTasks int64 `json:"submitted_tasks"`
+	CompletedTasks int64 `json:"completed_tasks"`
+	FailedTasks    int64 `json:"failed_tasks"` // Included in CompletedTasks.
+
+	// TaskLatencies holds a latency histogram per AnalysisTaskGroup task name.
+	TaskLatencies map[string]HistogramSnapshot `json:"task_latencies"`
+}
+
+// HistogramSnapshot is a copy of a latency histogram.
+type HistogramSnapshot struct {
+	Buckets []HistogramBucket `json:"buckets"`
+	Count   int64             `json:"count"`
+	Sum     float64           `json:"sum_seconds"`
 }
 
-// AnalysisTask represents a specific analysis task with result.
+// HistogramBucket counts observations at or below UpperBound seconds (cumulative).
+type HistogramBucket struct {
+	UpperBound float64 `json:"le"`
+	Count      int64   `json:"count"`
+}
+
+// AnalysisTask represents a specific analysis task with result. Result holds
+// the value returned by the typed task; read it through the task's Result[T].
 type AnalysisTask struct {
-	Name   string
-	Task   func() (interface{}, error)
-	Result interface{}
-	Error  error
+	Name    string
+	Task    func(ctx context.Context) (interface{}, error)
+	Timeout time.Duration // Per-task deadline; zero inherits the group's.
+	Result  interface{}
+	Error   error
+}
+
+// Result is a typed handle to the outcome of a task added with AddTask.
+type Result[T any] struct {
+	task *AnalysisTask
 }
 
 // AnalysisTaskGroup manages a group of related analysis tasks.
 type AnalysisTaskGroup struct {
-	tasks []*AnalysisTask
-	pool  *WorkerPool
+	tasks   []*AnalysisTask
+	pool    *WorkerPool
+	timeout time.Duration // Group deadline; zero means none.
+}
+
+// PanicError reports a task that panicked instead of returning.
+type PanicError struct {
+	Value interface{} // The value passed to panic.
+	Stack []byte      // Stack trace captured at the point of recovery.
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("task panicked: %v", e.Value)
 }
 
 // AnalysisError represents an error during analysis (for testing purposes).
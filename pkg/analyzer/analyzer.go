@@ -0,0 +1,187 @@
+// Package analyzer is the public, importable entry point to the webpage analysis engine.
+//
+// It lets other Go programs analyze, compare, and crawl webpages in-process without
+// running the HTTP service:
+//
+//	result, err := analyzer.Analyze(ctx, "https://example.com", nil)
+//	if err != nil {
+//		var analysisErr *analyzer.Error
+//		if errors.As(err, &analysisErr) {
+//			log.Printf("upstream returned %d: %s", analysisErr.StatusCode, analysisErr.ErrorMessage)
+//		}
+//		return err
+//	}
+//	fmt.Println(result.PageTitle, result.InternalLinks, result.HasLoginForm)
+//
+// For repeated use, create an Analyzer once with New and call Close when done; it keeps
+// a worker pool alive between calls. Options select the modules to run, their options,
+// the time budget of each analysis and an in-memory result cache.
+//
+// # Compatibility
+//
+// The result types are those of the engine itself, so that results are encoded exactly
+// like the service's. They are frozen: fields may be added, but never removed, renamed
+// or retyped. TestAPIFrozen records every exported field reachable from them in
+// testdata/api.golden, so a change to the engine that would break programs importing
+// this package fails the build rather than going unnoticed.
+package analyzer
+
+import (
+	"context"
+	"time"
+
+	"webpage-analyzer/internal/analyzer"
+	"webpage-analyzer/internal/cache"
+	"webpage-analyzer/internal/worker"
+)
+
+// Result is the analysis of a single webpage.
+type Result = analyzer.WebpageAnalysis
+
+// Comparison is the side-by-side analysis of two webpages with a structured diff.
+type Comparison = analyzer.WebpageComparison
+
+// Diff describes how one analysis differs from another.
+type Diff = analyzer.ComparisonDiff
+
+// CrawlResult holds the analyses of every page reached during a crawl.
+type CrawlResult = analyzer.CrawlResult
+
+// Error is returned when a page cannot be fetched or parsed. StatusCode carries the
+// upstream HTTP status (or a synthetic one for network failures).
+type Error = analyzer.AnalysisError
+
+// DefaultWorkers is the number of concurrent extraction workers used when Options.Workers is zero.
+const DefaultWorkers = 5
+
+// DefaultCacheEntries is the size of the result cache when Options.CacheEntries is zero.
+const DefaultCacheEntries = 1000
+
+// Options configures an Analyzer. The zero value is ready to use.
+type Options struct {
+	// Workers is the number of concurrent extraction workers. Defaults to DefaultWorkers.
+	Workers int
+	// Timeout bounds each Analyze, Compare, or Crawl call. Zero means no additional limit
+	// beyond the caller's context and the HTTP client's own timeout.
+	Timeout time.Duration
+
+	// The options below apply to Analyze; Compare and Crawl run the default modules.
+
+	// Modules limits analyses to the named modules, such as "headings" or "links".
+	// Empty runs the default modules.
+	Modules []string
+	// ModuleOptions holds per-module options keyed by module name, e.g.
+	// {"links": {"probe": true}}.
+	ModuleOptions map[string]map[string]interface{}
+	// FetchTimeout limits fetching each page, and TotalTimeout each whole analysis,
+	// after which the modules that completed are returned with timeout warnings.
+	// Zero means the engine defaults; values above the engine's maximums are an error.
+	FetchTimeout time.Duration
+	TotalTimeout time.Duration
+	// AcceptLanguage is sent as the Accept-Language header of page fetches.
+	AcceptLanguage string
+
+	// CacheTTL keeps analyses in an in-memory cache for this long, so that analyzing
+	// the same page again is served from it. Zero disables the cache.
+	CacheTTL time.Duration
+	// CacheEntries bounds the cache. Defaults to DefaultCacheEntries.
+	CacheEntries int
+}
+
+// Analyzer analyzes webpages in-process. It is safe for concurrent use.
+type Analyzer struct {
+	service analyzer.Service
+	pool    *worker.WorkerPool
+	timeout time.Duration
+	request analyzer.AnalysisRequest // Settings of every analysis, without a URL.
+}
+
+// New creates an Analyzer. opts may be nil.
+func New(opts *Options) *Analyzer {
+	if opts == nil {
+		opts = &Options{}
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	pool := worker.NewWorkerPool(workers)
+	service := analyzer.NewService(analyzer.WithWorkerPool(pool))
+	if opts.CacheTTL > 0 {
+		entries := opts.CacheEntries
+		if entries <= 0 {
+			entries = DefaultCacheEntries
+		}
+		service = cache.NewCachingService(service, cache.NewMemoryCache(cache.Config{TTL: opts.CacheTTL, MaxEntries: entries}))
+	}
+	return &Analyzer{
+		service: service,
+		pool:    pool,
+		timeout: opts.Timeout,
+		request: analysisRequest(opts),
+	}
+}
+
+// analysisRequest converts the per-analysis options to an engine request.
+func analysisRequest(opts *Options) analyzer.AnalysisRequest {
+	req := analyzer.AnalysisRequest{Modules: opts.Modules, AcceptLanguage: opts.AcceptLanguage}
+	if len(opts.ModuleOptions) > 0 {
+		req.Options = make(map[string]analyzer.ModuleOptions, len(opts.ModuleOptions))
+		for name, options := range opts.ModuleOptions {
+			req.Options[name] = analyzer.ModuleOptions(options)
+		}
+	}
+	if opts.FetchTimeout > 0 {
+		req.FetchTimeout = opts.FetchTimeout.String()
+	}
+	if opts.TotalTimeout > 0 {
+		req.TotalTimeout = opts.TotalTimeout.String()
+	}
+	return req
+}
+
+// Analyze fetches and analyzes a single webpage.
+func (a *Analyzer) Analyze(ctx context.Context, url string) (*Result, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+	req := a.request
+	req.URL = url
+	return a.service.AnalyzeWebpage(ctx, req)
+}
+
+// Compare analyzes two webpages concurrently and reports how the second differs from the first.
+func (a *Analyzer) Compare(ctx context.Context, urlA, urlB string) (*Comparison, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+	return a.service.CompareWebpages(ctx, analyzer.CompareRequest{URLA: urlA, URLB: urlB})
+}
+
+// Crawl analyzes a page and, breadth-first, the same-host pages it links to.
+// Zero maxDepth or maxPages select the engine defaults.
+func (a *Analyzer) Crawl(ctx context.Context, url string, maxDepth, maxPages int) (*CrawlResult, error) {
+	ctx, cancel := a.withTimeout(ctx)
+	defer cancel()
+	return a.service.CrawlSite(ctx, analyzer.CrawlRequest{URL: url, MaxDepth: maxDepth, MaxPages: maxPages})
+}
+
+// Close stops the Analyzer's workers. The Analyzer must not be used afterwards.
+func (a *Analyzer) Close() {
+	a.pool.Shutdown()
+}
+
+// Analyze is a convenience wrapper that analyzes one webpage with a short-lived Analyzer.
+// opts may be nil.
+func Analyze(ctx context.Context, url string, opts *Options) (*Result, error) {
+	a := New(opts)
+	defer a.Close()
+	return a.Analyze(ctx, url)
+}
+
+// withTimeout applies the configured per-call timeout, if any.
+func (a *Analyzer) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if a.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, a.timeout)
+}
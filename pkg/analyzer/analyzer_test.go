@@ -0,0 +1,105 @@
+package analyzer_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/pkg/analyzer"
+)
+
+func newTestSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			_, _ = io.WriteString(w, `<html><head><title>Home</title></head><body><h1>Hi</h1><a href="/about">About</a></body></html>`)
+		case "/about":
+			_, _ = io.WriteString(w, `<html><head><title>About</title></head><body><h1>About</h1><h2>Team</h2></body></html>`)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+			_, _ = io.WriteString(w, `<html></html>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(site.Close)
+	return site
+}
+
+func TestAnalyze(t *testing.T) {
+	site := newTestSite(t)
+
+	result, err := analyzer.Analyze(context.Background(), site.URL+"/", nil)
+
+	require.NoError(t, err, "Analyze() should not return error")
+	assert.Equal(t, "Home", result.PageTitle)
+	assert.Equal(t, 1, result.InternalLinks)
+}
+
+func TestAnalyze_Error(t *testing.T) {
+	site := newTestSite(t)
+
+	_, err := analyzer.Analyze(context.Background(), site.URL+"/missing", nil)
+
+	var analysisErr *analyzer.Error
+	require.True(t, errors.As(err, &analysisErr), "Failures should be reported as *analyzer.Error")
+	assert.Equal(t, http.StatusNotFound, analysisErr.StatusCode)
+}
+
+func TestAnalyzer_Timeout(t *testing.T) {
+	site := newTestSite(t)
+	a := analyzer.New(&analyzer.Options{Timeout: 20 * time.Millisecond})
+	defer a.Close()
+
+	_, err := a.Analyze(context.Background(), site.URL+"/slow")
+
+	assert.Error(t, err, "Analyze() should honour Options.Timeout")
+}
+
+func TestAnalyzer_CompareAndCrawl(t *testing.T) {
+	site := newTestSite(t)
+	a := analyzer.New(&analyzer.Options{Workers: 2})
+	defer a.Close()
+
+	comparison, err := a.Compare(context.Background(), site.URL+"/", site.URL+"/about")
+	require.NoError(t, err, "Compare() should not return error")
+	assert.True(t, comparison.Differences.TitleChanged)
+
+	crawl, err := a.Crawl(context.Background(), site.URL+"/", 1, 0)
+	require.NoError(t, err, "Crawl() should not return error")
+	assert.Len(t, crawl.Pages, 2)
+}
+
+func TestAnalyzer_Options(t *testing.T) {
+	site := newTestSite(t)
+	a := analyzer.New(&analyzer.Options{
+		Modules:      []string{"headings"},
+		FetchTimeout: 5 * time.Second,
+		CacheTTL:     time.Minute,
+	})
+	defer a.Close()
+
+	first, err := a.Analyze(context.Background(), site.URL+"/about")
+	require.NoError(t, err, "Analyze() should not return error")
+	assert.Equal(t, []string{"headings"}, first.Modules, "Options.Modules should select the modules")
+	assert.Empty(t, first.PageTitle, "Modules that were not selected should not run")
+	assert.Equal(t, 1, first.Headings["h2"])
+
+	second, err := a.Analyze(context.Background(), site.URL+"/about")
+	require.NoError(t, err)
+	require.NotNil(t, second.Cache)
+	assert.True(t, second.Cache.Hit, "Options.CacheTTL should serve repeated analyses from the cache")
+
+	tooLong := analyzer.New(&analyzer.Options{FetchTimeout: time.Hour})
+	defer tooLong.Close()
+	_, err = tooLong.Analyze(context.Background(), site.URL+"/")
+	assert.Error(t, err, "A fetch timeout above the engine's maximum should be rejected")
+}
@@ -0,0 +1,74 @@
+package analyzer_test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"webpage-analyzer/pkg/analyzer"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/api.golden with the current API")
+
+// TestAPIFrozen fails when an exported field reachable from the public result
+// types is removed, renamed or retyped. Additions fail too, so that they are
+// reviewed; accept them with go test ./pkg/analyzer -run TestAPIFrozen -update.
+func TestAPIFrozen(t *testing.T) {
+	api := describeAPI(
+		reflect.TypeOf(analyzer.Result{}),
+		reflect.TypeOf(analyzer.Comparison{}),
+		reflect.TypeOf(analyzer.CrawlResult{}),
+		reflect.TypeOf(analyzer.Error{}),
+		reflect.TypeOf(analyzer.Options{}),
+	)
+	golden := filepath.Join("testdata", "api.golden")
+	if *update {
+		require.NoError(t, os.WriteFile(golden, []byte(api), 0o644))
+	}
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err)
+
+	got := strings.Split(api, "\n")
+	for _, line := range strings.Split(string(want), "\n") {
+		assert.Contains(t, got, line, "The public API lost or changed this field")
+	}
+	assert.Equal(t, string(want), api, "The public API changed; review it and run with -update")
+}
+
+// describeAPI lists the exported fields of the named struct types reachable
+// from roots, one "Type.Field Go-type `tag`" line each, sorted.
+func describeAPI(roots ...reflect.Type) string {
+	var lines []string
+	seen := map[reflect.Type]bool{}
+	var visit func(t reflect.Type)
+	visit = func(t reflect.Type) {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || seen[t] || t.PkgPath() == "time" {
+			return
+		}
+		seen[t] = true
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s.%s %s `%s`", t.Name(), field.Name, field.Type, field.Tag.Get("json")))
+			visit(field.Type)
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n"
+}